@@ -0,0 +1,251 @@
+// Package accesslog writes a line for each HTTP request, separate from the
+// application logs, in the format set by ACCESS_LOG_FORMAT:
+//
+//   - gin, the default, the format of the gin request logger
+//   - json, one JSON object per request
+//   - combined, the Combined Log Format of Apache and nginx
+//   - template, the Go template set by ACCESS_LOG_TEMPLATE, such as
+//     "{{.Method}} {{.Path}} {{.Status}} {{.Latency}}"
+//   - off, requests are not logged
+//
+// Lines go to the file set by ACCESS_LOG_PATH, or to the standard output.
+// Requests to the paths in ACCESS_LOG_EXCLUDE, by default the health checks
+// /ping and /readyz and the /metrics scrapes, are not logged, and ACCESS_LOG_SAMPLE logs only that
+// fraction of the successful requests; failed requests are always logged.
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/redact"
+	"github.com/pkg/errors"
+)
+
+// Formats of the access log
+const (
+	FormatGin      = "gin"
+	FormatJSON     = "json"
+	FormatCombined = "combined"
+	FormatTemplate = "template"
+)
+
+// defaultExclude are the paths not logged when ACCESS_LOG_EXCLUDE is not
+// set
+const defaultExclude = "/ping,/readyz,/metrics"
+
+// Entry is a logged request, as given to templates
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	ClientIP  string        `json:"clientIp"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Query     string        `json:"query,omitempty"`
+	Route     string        `json:"route,omitempty"`
+	Proto     string        `json:"proto"`
+	Status    int           `json:"status"`
+	Bytes     int           `json:"bytes"`
+	Latency   time.Duration `json:"-"`
+	LatencyMs float64       `json:"latencyMs"`
+	User      string        `json:"user,omitempty"`
+	Referer   string        `json:"referer,omitempty"`
+	UserAgent string        `json:"userAgent,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// config is the access log configuration, read once
+type config struct {
+	format   string
+	tmpl     *template.Template
+	out      io.Writer
+	exclude  map[string]bool
+	sample   float64
+	mutex    sync.Mutex
+	disabled bool
+}
+
+var (
+	cfg     *config
+	cfgOnce sync.Once
+)
+
+// getConfig reads the configuration from the environment. An invalid
+// configuration falls back to the gin format on the standard output, so
+// requests are still logged.
+func getConfig() *config {
+	cfgOnce.Do(func() {
+		cfg = &config{
+			format:  FormatGin,
+			out:     os.Stdout,
+			exclude: make(map[string]bool),
+			sample:  1,
+		}
+
+		exclude := defaultExclude
+		if value, ok := os.LookupEnv("ACCESS_LOG_EXCLUDE"); ok {
+			exclude = value
+		}
+		for _, path := range strings.Split(exclude, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				cfg.exclude[path] = true
+			}
+		}
+
+		if value := os.Getenv("ACCESS_LOG_SAMPLE"); value != "" {
+			sample, err := strconv.ParseFloat(value, 64)
+			if err != nil || sample < 0 || sample > 1 {
+				log.Printf("invalid ACCESS_LOG_SAMPLE '%s', logging every request\n", value)
+			} else {
+				cfg.sample = sample
+			}
+		}
+
+		switch format := os.Getenv("ACCESS_LOG_FORMAT"); format {
+		case "", FormatGin:
+		case "off":
+			cfg.disabled = true
+		case FormatJSON, FormatCombined:
+			cfg.format = format
+		case FormatTemplate:
+			tmpl, err := template.New("accesslog").Parse(os.Getenv("ACCESS_LOG_TEMPLATE"))
+			if err != nil {
+				log.Println("invalid ACCESS_LOG_TEMPLATE, using the gin format: ", err)
+				break
+			}
+			cfg.format, cfg.tmpl = format, tmpl
+		default:
+			log.Printf("unknown ACCESS_LOG_FORMAT '%s', using the gin format\n", format)
+		}
+
+		if path := os.Getenv("ACCESS_LOG_PATH"); path != "" && path != "-" {
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				log.Println("error opening access log, using the standard output: ", errors.Wrap(err, path))
+			} else {
+				cfg.out = f
+			}
+		}
+		// Query strings and errors may hold sensitive values
+		cfg.out = redact.Writer(cfg.out)
+	})
+
+	return cfg
+}
+
+// Middleware logs the requests once they are handled
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		cfg := getConfig()
+		if cfg.disabled || cfg.exclude[c.Request.URL.Path] {
+			return
+		}
+		status := c.Writer.Status()
+		if status < 400 && cfg.sample < 1 && rand.Float64() >= cfg.sample {
+			return
+		}
+
+		latency := time.Since(start)
+		e := Entry{
+			Time:      start,
+			ClientIP:  c.ClientIP(),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Query:     c.Request.URL.RawQuery,
+			Route:     c.FullPath(),
+			Proto:     c.Request.Proto,
+			Status:    status,
+			Bytes:     c.Writer.Size(),
+			Latency:   latency,
+			LatencyMs: float64(latency.Microseconds()) / 1000,
+			User:      common.GetUser(c),
+			Referer:   c.Request.Referer(),
+			UserAgent: c.Request.UserAgent(),
+			Error:     c.Errors.ByType(gin.ErrorTypePrivate).String(),
+		}
+		if e.Bytes < 0 {
+			e.Bytes = 0
+		}
+
+		line, err := cfg.formatEntry(e)
+		if err != nil {
+			log.Println("error formatting access log: ", err)
+			return
+		}
+
+		cfg.mutex.Lock()
+		defer cfg.mutex.Unlock()
+		io.WriteString(cfg.out, line+"\n")
+	}
+}
+
+// formatEntry returns the line of the request in the configured format
+func (cfg *config) formatEntry(e Entry) (string, error) {
+	switch cfg.format {
+	case FormatJSON:
+		b, err := json.Marshal(e)
+		return string(b), err
+	case FormatCombined:
+		return combined(e), nil
+	case FormatTemplate:
+		var b strings.Builder
+		err := cfg.tmpl.Execute(&b, e)
+		return b.String(), err
+	default:
+		return ginLine(e), nil
+	}
+}
+
+// ginLine formats the request as the gin request logger, without colors
+func ginLine(e Entry) string {
+	line := fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v",
+		e.Time.Format("2006/01/02 - 15:04:05"),
+		e.Status,
+		e.Latency,
+		e.ClientIP,
+		e.Method,
+		requestURI(e),
+	)
+	return strings.TrimSuffix(line+"\n"+e.Error, "\n")
+}
+
+// combined formats the request in the Combined Log Format
+func combined(e Entry) string {
+	user := e.User
+	if user == "" {
+		user = "-"
+	}
+	return fmt.Sprintf(`%s - %s [%s] "%s %s %s" %d %d "%s" "%s"`,
+		e.ClientIP, user, e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, requestURI(e), e.Proto, e.Status, e.Bytes,
+		dash(e.Referer), dash(e.UserAgent))
+}
+
+// requestURI returns the path of the request with its query
+func requestURI(e Entry) string {
+	if e.Query != "" {
+		return e.Path + "?" + e.Query
+	}
+	return e.Path
+}
+
+// dash returns - for empty values of the Combined Log Format
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,270 @@
+// Package anchor periodically publishes a digest of the latest block of the
+// channel to an external endpoint, such as a timestamping service that
+// anchors it on a public chain, and records the receipts. Anchors make a
+// later rewrite of the channel history evident to anyone holding a receipt.
+package anchor
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/scheduler"
+	protos "github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// Formats of the published digest
+const (
+	FormatJSON           = "json"
+	FormatOpenTimestamps = "opentimestamps"
+)
+
+const jobID = "anchor"
+
+// maxReceiptSize limits the response of the endpoint kept in a receipt
+const maxReceiptSize = 64 * 1024
+
+// Receipt records an anchor of the channel
+type Receipt struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Channel     string    `json:"channel"`
+	BlockNumber uint64    `json:"blockNumber"`
+	BlockHash   string    `json:"blockHash"`
+	Digest      string    `json:"digest"`
+	URL         string    `json:"url"`
+	Status      int       `json:"status,omitempty"`
+	Receipt     string    `json:"receipt,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+var (
+	client = &http.Client{Timeout: 30 * time.Second}
+
+	// mutex guards the receipts file and the last anchored block
+	mutex      sync.Mutex
+	lastHeight uint64
+)
+
+// Enabled reports whether anchoring is configured with ANCHOR_URL
+func Enabled() bool {
+	return os.Getenv("ANCHOR_URL") != ""
+}
+
+// getInterval returns the time between anchors from ANCHOR_INTERVAL,
+// default one hour
+func getInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("ANCHOR_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
+	}
+	return interval
+}
+
+// getReceiptsPath returns the receipts file from ANCHOR_RECEIPTS_PATH
+func getReceiptsPath() string {
+	if path := os.Getenv("ANCHOR_RECEIPTS_PATH"); path != "" {
+		return path
+	}
+	return "anchors.jsonl"
+}
+
+// Start schedules the periodic anchoring job, if enabled
+func Start() {
+	if !Enabled() {
+		return
+	}
+	schedule()
+}
+
+func schedule() {
+	scheduler.Schedule(jobID, time.Now().Add(getInterval()), func() error {
+		// Failures are recorded in the receipts and retried at the next run
+		_, err := anchor(true)
+		if err != nil {
+			log.Println("error anchoring ledger: ", err)
+		}
+		schedule()
+		return nil
+	})
+}
+
+// Anchor publishes the digest of the latest block of the channel right away
+func Anchor() (Receipt, error) {
+	return anchor(false)
+}
+
+// anchor publishes the digest of the latest block, skipping it when it was
+// already anchored if onlyNew is set. The receipt is zero when skipped.
+func anchor(onlyNew bool) (Receipt, error) {
+	channelName := os.Getenv("CHANNEL")
+	result, err := chaincode.QueryGateway(channelName, "qscc", "GetChainInfo", os.Getenv("USER"), []string{channelName})
+	if err != nil {
+		return Receipt{}, errors.Wrap(err, "failed to get chain info")
+	}
+
+	var chainInfo protos.BlockchainInfo
+	err = proto.Unmarshal(result, &chainInfo)
+	if err != nil {
+		return Receipt{}, errors.Wrap(err, "failed to unmarshal chain info")
+	}
+	if chainInfo.Height == 0 {
+		return Receipt{}, errors.New("channel has no blocks")
+	}
+
+	mutex.Lock()
+	skip := onlyNew && chainInfo.Height == lastHeight
+	mutex.Unlock()
+	if skip {
+		return Receipt{}, nil
+	}
+
+	blockHash := hex.EncodeToString(chainInfo.CurrentBlockHash)
+	receipt := Receipt{
+		Timestamp:   time.Now().UTC(),
+		Channel:     channelName,
+		BlockNumber: chainInfo.Height - 1,
+		BlockHash:   blockHash,
+		Digest:      Digest(channelName, chainInfo.Height-1, blockHash),
+		URL:         os.Getenv("ANCHOR_URL"),
+	}
+
+	err = publish(&receipt)
+	if err != nil {
+		receipt.Error = err.Error()
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err == nil {
+		lastHeight = chainInfo.Height
+	}
+	record(receipt)
+
+	return receipt, err
+}
+
+// Digest returns the SHA-256 of "{channel}:{blockNumber}:{blockHash}", the
+// hex encoded digest that is anchored
+func Digest(channelName string, blockNumber uint64, blockHash string) string {
+	digest := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%s", channelName, blockNumber, blockHash)))
+	return hex.EncodeToString(digest[:])
+}
+
+// publish posts the digest to the endpoint, in the format set by
+// ANCHOR_FORMAT, and keeps its response in the receipt
+func publish(receipt *Receipt) error {
+	var body []byte
+	contentType := "application/json"
+
+	format := os.Getenv("ANCHOR_FORMAT")
+	switch format {
+	case "", FormatJSON:
+		body, _ = json.Marshal(map[string]interface{}{
+			"channel":     receipt.Channel,
+			"blockNumber": receipt.BlockNumber,
+			"blockHash":   receipt.BlockHash,
+			"digest":      receipt.Digest,
+			"timestamp":   receipt.Timestamp,
+		})
+	case FormatOpenTimestamps:
+		// OpenTimestamps calendars take the raw digest at /digest and
+		// answer with a binary timestamp proof
+		body, _ = hex.DecodeString(receipt.Digest)
+		contentType = "application/octet-stream"
+	default:
+		return errors.Errorf("unknown anchor format '%s'", format)
+	}
+
+	res, err := client.Post(receipt.URL, contentType, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to publish anchor")
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, maxReceiptSize))
+	if err != nil {
+		return errors.Wrap(err, "failed to read anchor receipt")
+	}
+	receipt.Status = res.StatusCode
+	if format == FormatOpenTimestamps {
+		receipt.Receipt = base64.StdEncoding.EncodeToString(resBody)
+	} else {
+		receipt.Receipt = string(resBody)
+	}
+
+	if res.StatusCode >= 300 {
+		return errors.Errorf("anchor endpoint answered with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// record appends the receipt to the receipts file as a JSON line. The
+// caller must hold the mutex.
+func record(receipt Receipt) {
+	line, err := json.Marshal(receipt)
+	if err != nil {
+		log.Println("error marshalling anchor receipt: ", err)
+		return
+	}
+
+	f, err := os.OpenFile(getReceiptsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Println("error opening anchor receipts: ", err)
+		return
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		log.Println("error writing anchor receipt: ", err)
+	}
+}
+
+// Receipts reads the recorded receipts, oldest first
+func Receipts() ([]Receipt, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	receipts := make([]Receipt, 0)
+	f, err := os.Open(getReceiptsPath())
+	if os.IsNotExist(err) {
+		return receipts, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open anchor receipts")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var receipt Receipt
+		err := json.Unmarshal(scanner.Bytes(), &receipt)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal anchor receipt")
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read anchor receipts")
+	}
+	return receipts, nil
+}
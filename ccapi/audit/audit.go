@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/redact"
+	"github.com/hyperledger-labs/ccapi/siem"
+	"github.com/pkg/errors"
+)
+
+// Entry is a record of a transaction submitted through the ccapi
+type Entry struct {
+	Timestamp    time.Time `json:"timestamp"`
+	TxID         string    `json:"txId,omitempty"`
+	Channel      string    `json:"channel"`
+	Chaincode    string    `json:"chaincode"`
+	TxName       string    `json:"txName"`
+	User         string    `json:"user"`
+	Args         []string  `json:"args"`
+	Endorsers    []string  `json:"endorsers,omitempty"`
+	HasTransient bool      `json:"hasTransient,omitempty"`
+	Redacted     bool      `json:"redacted,omitempty"`
+	Status       int       `json:"status"`
+	Error        string    `json:"error,omitempty"`
+
+	// Seq is the position of the entry in the log and PrevHash the SHA-256
+	// of the line before it, chaining the entries so changes are detected
+	Seq      uint64 `json:"seq,omitempty"`
+	PrevHash string `json:"prevHash,omitempty"`
+}
+
+var mutex sync.Mutex
+
+// getLogPath returns the path of the audit log file from environment.
+// Audit is disabled when AUDIT_LOG_PATH is not set.
+func getLogPath() string {
+	return os.Getenv("AUDIT_LOG_PATH")
+}
+
+// Enabled reports whether transactions are being audited
+func Enabled() bool {
+	return getLogPath() != ""
+}
+
+// Record appends an entry to the audit log as a JSON line, chained to the
+// line before it. Transient data is never persisted, only flagged, and
+// sensitive values of the arguments and error are masked.
+func Record(entry Entry) {
+	path := getLogPath()
+	if path == "" {
+		return
+	}
+
+	args := make([]string, len(entry.Args))
+	for i, arg := range entry.Args {
+		args[i] = redact.JSON(arg)
+		if args[i] != arg {
+			entry.Redacted = true
+		}
+	}
+	entry.Args = args
+	entry.Error = redact.String(entry.Error)
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	err := appendLine(path, func(seq uint64, prevHash string) interface{} {
+		entry.Seq, entry.PrevHash = seq, prevHash
+		return entry
+	})
+	if err != nil {
+		log.Println("error writing audit entry: ", err)
+		return
+	}
+	tail.unsealed++
+
+	forward(entry)
+}
+
+// forward sends the entry to the SIEM, if enabled
+func forward(entry Entry) {
+	e := siem.Event{
+		Time:     entry.Timestamp,
+		Type:     siem.TypeTransaction,
+		Name:     "Transaction " + entry.TxName,
+		Severity: 3,
+		User:     entry.User,
+		Status:   entry.Status,
+		Outcome:  "success",
+		Message:  entry.Error,
+		Fields: map[string]string{
+			"txName":    entry.TxName,
+			"txId":      entry.TxID,
+			"channel":   entry.Channel,
+			"chaincode": entry.Chaincode,
+		},
+	}
+	if entry.Error != "" || entry.Status >= 400 {
+		e.Severity, e.Outcome = 5, "failure"
+	}
+	siem.Send(e)
+}
+
+// ReadFile reads all entries from an audit log file, skipping its seals
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open audit log")
+	}
+	defer f.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry struct {
+			Entry
+			Signature string `json:"signature"`
+		}
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal audit entry")
+		}
+		if entry.Signature != "" {
+			continue
+		}
+		entries = append(entries, entry.Entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read audit log")
+	}
+
+	return entries, nil
+}
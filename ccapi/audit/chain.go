@@ -0,0 +1,400 @@
+package audit
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultSealInterval is how often the log is sealed when
+// AUDIT_SEAL_INTERVAL is not set
+const defaultSealInterval = time.Minute
+
+// Seal is a line of the audit log signing the chain up to it with the key
+// of the server, so entries cannot be rewritten along with their hashes
+type Seal struct {
+	Timestamp time.Time `json:"timestamp"`
+	Seq       uint64    `json:"seq"`
+	PrevHash  string    `json:"prevHash"`
+	Signature string    `json:"signature"`
+}
+
+// chain is the position of the end of the log, where the next line goes.
+// It is guarded by the mutex of the log.
+type chain struct {
+	loaded   bool
+	seq      uint64
+	lastHash string
+	unsealed int
+}
+
+var (
+	tail chain
+
+	signer     crypto.Signer
+	signerErr  error
+	signerOnce sync.Once
+)
+
+// hashLine returns the hash the next line of the log chains to
+func hashLine(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// sealMessage returns the message signed by the seal
+func sealMessage(s Seal) []byte {
+	return []byte(fmt.Sprintf("ccapi-audit:%d:%s:%s", s.Seq, s.Timestamp.Format(time.RFC3339Nano), s.PrevHash))
+}
+
+// loadChain finds the end of the chain in the existing log, so a restarted
+// CCAPI continues it. The caller must hold the mutex.
+func loadChain(path string) error {
+	if tail.loaded {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		tail.loaded = true
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to open audit log")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var link struct {
+			Seq       uint64 `json:"seq"`
+			Signature string `json:"signature"`
+		}
+		json.Unmarshal(scanner.Bytes(), &link)
+		if link.Seq > 0 {
+			tail.seq = link.Seq
+		}
+		tail.lastHash = hashLine(scanner.Bytes())
+		if link.Signature != "" {
+			tail.unsealed = 0
+		} else {
+			tail.unsealed++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "failed to read audit log")
+	}
+
+	tail.loaded = true
+	return nil
+}
+
+// appendLine marshals the line with the next position of the chain and
+// appends it to the log. The caller must hold the mutex.
+func appendLine(path string, link func(seq uint64, prevHash string) interface{}) error {
+	err := loadChain(path)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(link(tail.seq+1, tail.lastHash))
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal audit line")
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open audit log")
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		return errors.Wrap(err, "failed to write audit line")
+	}
+
+	tail.seq++
+	tail.lastHash = hashLine(line)
+	return nil
+}
+
+// SealingEnabled reports whether the log is sealed with the key in the file
+// set by AUDIT_SIGNING_KEY
+func SealingEnabled() bool {
+	return Enabled() && os.Getenv("AUDIT_SIGNING_KEY") != ""
+}
+
+// getSigner returns the key of the server, read once
+func getSigner() (crypto.Signer, error) {
+	signerOnce.Do(func() {
+		data, err := os.ReadFile(os.Getenv("AUDIT_SIGNING_KEY"))
+		if err != nil {
+			signerErr = errors.Wrap(err, "failed to read audit signing key")
+			return
+		}
+		signer, signerErr = parsePrivateKey(data)
+	})
+
+	return signer, signerErr
+}
+
+// parsePrivateKey parses a PEM ECDSA, Ed25519 or RSA private key
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("audit signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if s, ok := key.(crypto.Signer); ok {
+			return s, nil
+		}
+		return nil, errors.New("unsupported audit signing key")
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported audit signing key")
+}
+
+// ParsePublicKey parses the PEM public key, certificate or private key
+// whose public key verifies the seals
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("key is not PEM encoded")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse certificate")
+		}
+		return cert.PublicKey, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		return key, errors.Wrap(err, "failed to parse public key")
+	default:
+		key, err := parsePrivateKey(data)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public(), nil
+	}
+}
+
+// sign signs the message with the key, hashing it with SHA-256 unless the
+// key is Ed25519, which hashes it itself
+func sign(key crypto.Signer, message []byte) ([]byte, error) {
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		return key.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(message)
+	return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// verify checks the signature of the message with the public key
+func verify(key crypto.PublicKey, message, signature []byte) bool {
+	digest := sha256.Sum256(message)
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, message, signature)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+// SealNow signs the chain up to the last entry, if entries were recorded
+// since the last seal
+func SealNow() error {
+	if !SealingEnabled() {
+		return nil
+	}
+	key, err := getSigner()
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	err = loadChain(getLogPath())
+	if err != nil {
+		return err
+	}
+	if tail.unsealed == 0 {
+		return nil
+	}
+
+	var signErr error
+	err = appendLine(getLogPath(), func(seq uint64, prevHash string) interface{} {
+		s := Seal{
+			Timestamp: time.Now().UTC(),
+			Seq:       seq,
+			PrevHash:  prevHash,
+		}
+		var signature []byte
+		signature, signErr = sign(key, sealMessage(s))
+		s.Signature = base64.StdEncoding.EncodeToString(signature)
+		return s
+	})
+	if signErr != nil {
+		return errors.Wrap(signErr, "failed to sign audit log")
+	}
+	if err != nil {
+		return err
+	}
+	tail.unsealed = 0
+	return nil
+}
+
+// getSealInterval returns how often the log is sealed, from
+// AUDIT_SEAL_INTERVAL
+func getSealInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("AUDIT_SEAL_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return defaultSealInterval
+}
+
+// Start seals the log periodically, if sealing is enabled
+func Start() {
+	if !SealingEnabled() {
+		return
+	}
+	go func() {
+		for range time.Tick(getSealInterval()) {
+			err := SealNow()
+			if err != nil {
+				log.Println("error sealing audit log: ", err)
+			}
+		}
+	}()
+}
+
+// Problem is a break of the chain found by Verify
+type Problem struct {
+	Line    int    `json:"line"`
+	Seq     uint64 `json:"seq,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report is the outcome of the verification of a log
+type Report struct {
+	// Unchained counts the entries recorded before chaining, which cannot be
+	// verified
+	Unchained int `json:"unchained"`
+	Entries   int `json:"entries"`
+	Seals     int `json:"seals"`
+	// LastSealedSeq is the last position covered by a valid seal, and
+	// Unsealed counts the entries after it, whose removal cannot be detected
+	LastSealedSeq uint64    `json:"lastSealedSeq"`
+	Unsealed      int       `json:"unsealed"`
+	Problems      []Problem `json:"problems"`
+}
+
+// Verify checks the chain of the log in the file: every line must follow
+// the previous one in sequence and carry its hash, and the seals must be
+// signed by the key, if given, so modified, inserted and removed lines are
+// detected
+func Verify(path string, key crypto.PublicKey) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open audit log")
+	}
+	defer f.Close()
+
+	report := &Report{Problems: make([]Problem, 0)}
+	var seq uint64
+	prevHash := ""
+	problem := func(line int, seq uint64, format string, args ...interface{}) {
+		report.Problems = append(report.Problems, Problem{Line: line, Seq: seq, Message: fmt.Sprintf(format, args...)})
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		hash := hashLine(line)
+
+		var link Seal
+		err := json.Unmarshal(line, &link)
+		if err != nil {
+			problem(n, 0, "line is not valid JSON")
+			prevHash = hash
+			continue
+		}
+
+		if link.Seq == 0 {
+			if seq > 0 {
+				problem(n, 0, "entry is not chained")
+			} else {
+				report.Unchained++
+			}
+			prevHash = hash
+			continue
+		}
+
+		if seq > 0 && link.Seq != seq+1 {
+			problem(n, link.Seq, "expected seq %d, lines were removed or reordered", seq+1)
+		}
+		if link.PrevHash != prevHash {
+			problem(n, link.Seq, "hash of the previous line does not match, it was modified, removed or inserted")
+		}
+		seq, prevHash = link.Seq, hash
+
+		if link.Signature == "" {
+			report.Entries++
+			report.Unsealed++
+			continue
+		}
+
+		report.Seals++
+		if key == nil {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(link.Signature)
+		if err != nil || !verify(key, sealMessage(link), signature) {
+			problem(n, link.Seq, "invalid seal signature")
+			continue
+		}
+		report.LastSealedSeq = link.Seq
+		report.Unsealed = 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read audit log")
+	}
+
+	return report, nil
+}
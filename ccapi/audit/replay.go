@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// Filter selects which audit entries are replayed
+type Filter struct {
+	TxNames       []string
+	From          time.Time
+	To            time.Time
+	IncludeFailed bool
+}
+
+// Match reports whether the entry passes the filter
+func (f Filter) Match(e Entry) bool {
+	if !f.IncludeFailed && e.Status != http.StatusOK {
+		return false
+	}
+	if !f.From.IsZero() && e.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && e.Timestamp.After(f.To) {
+		return false
+	}
+	if len(f.TxNames) > 0 {
+		for _, name := range f.TxNames {
+			if name == e.TxName {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// ReplayOptions overrides where and as whom entries are replayed.
+// Empty values keep the ones recorded on the entry.
+type ReplayOptions struct {
+	Channel   string
+	Chaincode string
+	User      string
+	DryRun    bool
+}
+
+// Replay re-submits the entries that match the filter, in order. Old
+// transaction ids found in the arguments are replaced by the ids of
+// the replayed transactions, and the old->new mapping is returned.
+func Replay(entries []Entry, filter Filter, opts ReplayOptions) (map[string]string, error) {
+	txIDMap := make(map[string]string)
+
+	for _, entry := range entries {
+		if !filter.Match(entry) {
+			continue
+		}
+
+		if entry.HasTransient {
+			log.Printf("skipping %s (%s): transient data is not recorded\n", entry.TxName, entry.TxID)
+			continue
+		}
+		if entry.Redacted {
+			log.Printf("skipping %s (%s): its arguments were redacted\n", entry.TxName, entry.TxID)
+			continue
+		}
+
+		channel := entry.Channel
+		if opts.Channel != "" {
+			channel = opts.Channel
+		}
+		ccName := entry.Chaincode
+		if opts.Chaincode != "" {
+			ccName = opts.Chaincode
+		}
+		user := entry.User
+		if opts.User != "" {
+			user = opts.User
+		}
+
+		// Remap transaction ids referenced by the arguments
+		args := make([]string, len(entry.Args))
+		for i, arg := range entry.Args {
+			for oldID, newID := range txIDMap {
+				arg = strings.ReplaceAll(arg, oldID, newID)
+			}
+			args[i] = arg
+		}
+
+		if opts.DryRun {
+			log.Printf("would replay %s (%s) on %s/%s as %s\n", entry.TxName, entry.TxID, channel, ccName, user)
+			continue
+		}
+
+		res, err := chaincode.SubmitGateway(channel, ccName, entry.TxName, user, args, nil, entry.Endorsers)
+		if err != nil {
+			err, _ := common.ParseError(err)
+			return txIDMap, errors.Wrap(err, "failed to replay "+entry.TxName)
+		}
+
+		if entry.TxID != "" {
+			txIDMap[entry.TxID] = res.TxID
+		}
+		log.Printf("replayed %s: %s -> %s (block %d)\n", entry.TxName, entry.TxID, res.TxID, res.BlockNumber)
+	}
+
+	return txIDMap, nil
+}
@@ -0,0 +1,89 @@
+// Package blockdecode parses raw Fabric blocks and transactions, as
+// returned by qscc, into structs that are easy to read and to encode as
+// JSON: the type, creator and validation of each transaction, the
+// chaincode function and arguments it called, its read-write sets and its
+// chaincode events.
+//
+//	block, err := blockdecode.DecodeBlock(blockBytes)
+//	for _, tx := range block.Transactions {
+//		fmt.Println(tx.TxID, tx.CreatorMSP, tx.Function, tx.Valid)
+//	}
+package blockdecode
+
+import (
+	"encoding/hex"
+
+	protos "github.com/hyperledger/fabric-protos-go-apiv2/common"
+	peerprotos "github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// Block is a decoded block of the ledger
+type Block struct {
+	Number       uint64         `json:"number"`
+	PreviousHash string         `json:"previousHash"`
+	DataHash     string         `json:"dataHash"`
+	Transactions []*Transaction `json:"transactions"`
+}
+
+// DecodeBlock parses a block serialized as protobuf
+func DecodeBlock(b []byte) (*Block, error) {
+	var block protos.Block
+	err := proto.Unmarshal(b, &block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal block")
+	}
+	return FromBlock(&block)
+}
+
+// FromBlock decodes the transactions of a block, with their validation
+// codes taken from the transactions filter of the block metadata
+func FromBlock(block *protos.Block) (*Block, error) {
+	res := &Block{
+		Number:       block.GetHeader().GetNumber(),
+		PreviousHash: hex.EncodeToString(block.GetHeader().GetPreviousHash()),
+		DataHash:     hex.EncodeToString(block.GetHeader().GetDataHash()),
+		Transactions: make([]*Transaction, 0, len(block.GetData().GetData())),
+	}
+
+	var filter []byte
+	if metadata := block.GetMetadata().GetMetadata(); len(metadata) > int(protos.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		filter = metadata[protos.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+
+	for i, data := range block.GetData().GetData() {
+		var envelope protos.Envelope
+		err := proto.Unmarshal(data, &envelope)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal envelope %d", i)
+		}
+
+		// Blocks not yet validated, such as those of the orderer, have no
+		// filter, and their transactions are reported as valid
+		code := peerprotos.TxValidationCode_VALID
+		if i < len(filter) {
+			code = peerprotos.TxValidationCode(filter[i])
+		}
+
+		tx, err := FromEnvelope(&envelope, code)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode transaction %d", i)
+		}
+		tx.BlockNumber = res.Number
+		tx.Index = i
+		res.Transactions = append(res.Transactions, tx)
+	}
+	return res, nil
+}
+
+// Find returns the transaction of the block with the id, or nil if it is
+// not in the block
+func (b *Block) Find(txID string) *Transaction {
+	for _, tx := range b.Transactions {
+		if tx.TxID == txID {
+			return tx
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package blockdecode
+
+// Conflict is a key read by a transaction and written by an earlier valid
+// transaction of the same block, which invalidates the read
+type Conflict struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	WrittenBy string `json:"writtenBy"`
+	// Range is set when the key was in a range read by the transaction
+	// instead of being read by itself
+	Range *RangeQuery `json:"range,omitempty"`
+}
+
+// Conflicts returns the reads of the transaction that were overwritten by
+// the valid transactions before it in the block. These are the usual cause
+// of MVCC read conflicts and phantom read conflicts; keys changed in
+// previous blocks after the transaction was endorsed are not found here.
+func (b *Block) Conflicts(tx *Transaction) []Conflict {
+	conflicts := make([]Conflict, 0)
+	for _, prev := range b.Transactions {
+		if prev == tx || prev.Index >= tx.Index {
+			break
+		}
+		if !prev.Valid {
+			continue
+		}
+
+		for _, written := range prev.RWSets {
+			for _, read := range tx.RWSets {
+				if read.Namespace != written.Namespace {
+					continue
+				}
+				for _, write := range written.Writes {
+					conflicts = append(conflicts, readConflicts(read, write.Key, prev.TxID)...)
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// readConflicts returns the conflicts of the reads of the set with a write
+// of the key by another transaction
+func readConflicts(set *RWSet, key, writtenBy string) []Conflict {
+	conflicts := make([]Conflict, 0)
+	for _, read := range set.Reads {
+		if read.Key == key {
+			conflicts = append(conflicts, Conflict{
+				Namespace: set.Namespace,
+				Key:       key,
+				WrittenBy: writtenBy,
+			})
+		}
+	}
+	for _, query := range set.RangeQueries {
+		// An empty end key reads to the end of the namespace
+		if key >= query.StartKey && (query.EndKey == "" || key < query.EndKey) {
+			queried := query
+			queried.Reads = nil
+			conflicts = append(conflicts, Conflict{
+				Namespace: set.Namespace,
+				Key:       key,
+				WrittenBy: writtenBy,
+				Range:     &queried,
+			})
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,335 @@
+package blockdecode
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"time"
+
+	protos "github.com/hyperledger/fabric-protos-go-apiv2/common"
+	rwsetprotos "github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset/kvrwset"
+	mspprotos "github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	peerprotos "github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// Transaction is a decoded transaction of a block. Only endorser
+// transactions have the chaincode fields, other types such as config
+// updates only have their header.
+type Transaction struct {
+	TxID           string    `json:"txId"`
+	Type           string    `json:"type"`
+	ChannelID      string    `json:"channelId"`
+	Timestamp      time.Time `json:"timestamp"`
+	BlockNumber    uint64    `json:"blockNumber"`
+	Index          int       `json:"index"`
+	ValidationCode string    `json:"validationCode"`
+	Valid          bool      `json:"valid"`
+	CreatorMSP     string    `json:"creatorMsp,omitempty"`
+	CreatorID      string    `json:"creatorId,omitempty"`
+
+	Chaincode string     `json:"chaincode,omitempty"`
+	Function  string     `json:"function,omitempty"`
+	Args      []string   `json:"args,omitempty"`
+	Response  *Response  `json:"response,omitempty"`
+	Endorsers []Identity `json:"endorsers,omitempty"`
+	RWSets    []*RWSet   `json:"rwsets,omitempty"`
+	Event     *Event     `json:"event,omitempty"`
+}
+
+// Identity is the MSP and the common name of the certificate of a signer
+type Identity struct {
+	MSP string `json:"msp"`
+	ID  string `json:"id,omitempty"`
+}
+
+// Response is the response of the chaincode to the endorsed proposal
+type Response struct {
+	Status  int32  `json:"status"`
+	Message string `json:"message,omitempty"`
+	Payload Value  `json:"payload,omitempty"`
+}
+
+// Event is the chaincode event set by the transaction
+type Event struct {
+	Chaincode string `json:"chaincode"`
+	Name      string `json:"name"`
+	Payload   Value  `json:"payload,omitempty"`
+}
+
+// RWSet holds the keys a transaction read and wrote in the namespace of a
+// chaincode
+type RWSet struct {
+	Namespace    string       `json:"namespace"`
+	Reads        []Read       `json:"reads"`
+	RangeQueries []RangeQuery `json:"rangeQueries,omitempty"`
+	Writes       []Write      `json:"writes"`
+	Collections  []Collection `json:"collections,omitempty"`
+}
+
+// Read is a key read by the transaction, with the version it had. Keys
+// that did not exist have no version.
+type Read struct {
+	Key      string  `json:"key"`
+	BlockNum *uint64 `json:"blockNum,omitempty"`
+	TxNum    *uint64 `json:"txNum,omitempty"`
+}
+
+// RangeQuery is a range of keys read by the transaction
+type RangeQuery struct {
+	StartKey  string `json:"startKey"`
+	EndKey    string `json:"endKey"`
+	Exhausted bool   `json:"exhausted"`
+	Reads     []Read `json:"reads,omitempty"`
+}
+
+// Write is a key written or deleted by the transaction
+type Write struct {
+	Key      string `json:"key"`
+	IsDelete bool   `json:"isDelete,omitempty"`
+	Value    Value  `json:"value,omitempty"`
+}
+
+// Collection is a private data collection written by the transaction,
+// of which only the hash is in the block
+type Collection struct {
+	Name      string `json:"name"`
+	RWSetHash []byte `json:"rwsetHash"`
+}
+
+// Value is a value of the ledger. It is encoded as JSON when it holds JSON,
+// as assets do, and as a string otherwise.
+type Value []byte
+
+// MarshalJSON encodes the value as is if it is JSON, or as a string
+func (v Value) MarshalJSON() ([]byte, error) {
+	if json.Valid(v) {
+		return v, nil
+	}
+	return json.Marshal(string(v))
+}
+
+// DecodeProcessedTransaction parses a transaction with its validation code,
+// as returned by GetTransactionByID of qscc
+func DecodeProcessedTransaction(b []byte) (*Transaction, error) {
+	var processed peerprotos.ProcessedTransaction
+	err := proto.Unmarshal(b, &processed)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal processed transaction")
+	}
+	return FromEnvelope(processed.GetTransactionEnvelope(), peerprotos.TxValidationCode(processed.GetValidationCode()))
+}
+
+// FromEnvelope decodes the transaction in the envelope, which got the
+// validation code when it was committed
+func FromEnvelope(envelope *protos.Envelope, code peerprotos.TxValidationCode) (*Transaction, error) {
+	var payload protos.Payload
+	err := proto.Unmarshal(envelope.GetPayload(), &payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal payload")
+	}
+
+	var channelHeader protos.ChannelHeader
+	err = proto.Unmarshal(payload.GetHeader().GetChannelHeader(), &channelHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal channel header")
+	}
+
+	var signatureHeader protos.SignatureHeader
+	err = proto.Unmarshal(payload.GetHeader().GetSignatureHeader(), &signatureHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal signature header")
+	}
+
+	tx := &Transaction{
+		TxID:           channelHeader.TxId,
+		Type:           protos.HeaderType(channelHeader.Type).String(),
+		ChannelID:      channelHeader.ChannelId,
+		Timestamp:      channelHeader.GetTimestamp().AsTime().UTC(),
+		ValidationCode: code.String(),
+		Valid:          code == peerprotos.TxValidationCode_VALID,
+	}
+	if creator, err := decodeIdentity(signatureHeader.Creator); err == nil {
+		tx.CreatorMSP = creator.MSP
+		tx.CreatorID = creator.ID
+	}
+
+	if channelHeader.Type != int32(protos.HeaderType_ENDORSER_TRANSACTION) {
+		return tx, nil
+	}
+
+	err = decodeEndorserTransaction(tx, payload.Data)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// decodeEndorserTransaction fills the chaincode fields of the transaction.
+// Fabric commits a single action per transaction, so only the first one is
+// decoded.
+func decodeEndorserTransaction(tx *Transaction, data []byte) error {
+	var transaction peerprotos.Transaction
+	err := proto.Unmarshal(data, &transaction)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal transaction")
+	}
+	if len(transaction.Actions) == 0 {
+		return nil
+	}
+	action := transaction.Actions[0]
+
+	var actionPayload peerprotos.ChaincodeActionPayload
+	err = proto.Unmarshal(action.Payload, &actionPayload)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal chaincode action payload")
+	}
+
+	var proposalPayload peerprotos.ChaincodeProposalPayload
+	err = proto.Unmarshal(actionPayload.ChaincodeProposalPayload, &proposalPayload)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal chaincode proposal payload")
+	}
+
+	var input peerprotos.ChaincodeInvocationSpec
+	err = proto.Unmarshal(proposalPayload.Input, &input)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal chaincode invocation spec")
+	}
+	tx.Chaincode = input.GetChaincodeSpec().GetChaincodeId().GetName()
+	for i, arg := range input.GetChaincodeSpec().GetInput().GetArgs() {
+		if i == 0 {
+			tx.Function = string(arg)
+			continue
+		}
+		tx.Args = append(tx.Args, string(arg))
+	}
+
+	endorsed := actionPayload.GetAction()
+	for _, endorsement := range endorsed.GetEndorsements() {
+		endorser, err := decodeIdentity(endorsement.Endorser)
+		if err != nil {
+			return errors.Wrap(err, "failed to unmarshal endorser")
+		}
+		tx.Endorsers = append(tx.Endorsers, endorser)
+	}
+
+	var responsePayload peerprotos.ProposalResponsePayload
+	err = proto.Unmarshal(endorsed.GetProposalResponsePayload(), &responsePayload)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal proposal response payload")
+	}
+
+	var ccAction peerprotos.ChaincodeAction
+	err = proto.Unmarshal(responsePayload.Extension, &ccAction)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal chaincode action")
+	}
+	if ccAction.Response != nil {
+		tx.Response = &Response{
+			Status:  ccAction.Response.Status,
+			Message: ccAction.Response.Message,
+			Payload: ccAction.Response.Payload,
+		}
+	}
+
+	if len(ccAction.Events) > 0 {
+		var event peerprotos.ChaincodeEvent
+		err = proto.Unmarshal(ccAction.Events, &event)
+		if err != nil {
+			return errors.Wrap(err, "failed to unmarshal chaincode event")
+		}
+		if event.EventName != "" {
+			tx.Event = &Event{
+				Chaincode: event.ChaincodeId,
+				Name:      event.EventName,
+				Payload:   event.Payload,
+			}
+		}
+	}
+
+	tx.RWSets, err = DecodeRWSets(ccAction.Results)
+	return err
+}
+
+// DecodeRWSets parses the read-write sets of a transaction, one for each
+// chaincode namespace it touched
+func DecodeRWSets(results []byte) ([]*RWSet, error) {
+	var txRWSet rwsetprotos.TxReadWriteSet
+	err := proto.Unmarshal(results, &txRWSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal tx read write set")
+	}
+
+	sets := make([]*RWSet, 0, len(txRWSet.NsRwset))
+	for _, nsRWSet := range txRWSet.NsRwset {
+		var kvSet kvrwset.KVRWSet
+		err = proto.Unmarshal(nsRWSet.Rwset, &kvSet)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal read write set of %s", nsRWSet.Namespace)
+		}
+
+		set := &RWSet{
+			Namespace: nsRWSet.Namespace,
+			Reads:     decodeReads(kvSet.Reads),
+			Writes:    make([]Write, 0, len(kvSet.Writes)),
+		}
+		for _, query := range kvSet.RangeQueriesInfo {
+			set.RangeQueries = append(set.RangeQueries, RangeQuery{
+				StartKey:  query.StartKey,
+				EndKey:    query.EndKey,
+				Exhausted: query.ItrExhausted,
+				Reads:     decodeReads(query.GetRawReads().GetKvReads()),
+			})
+		}
+		for _, write := range kvSet.Writes {
+			set.Writes = append(set.Writes, Write{
+				Key:      write.Key,
+				IsDelete: write.IsDelete,
+				Value:    write.Value,
+			})
+		}
+		for _, collection := range nsRWSet.CollectionHashedRwset {
+			set.Collections = append(set.Collections, Collection{
+				Name:      collection.CollectionName,
+				RWSetHash: collection.PvtRwsetHash,
+			})
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// decodeReads returns the keys read with their versions
+func decodeReads(kvReads []*kvrwset.KVRead) []Read {
+	reads := make([]Read, 0, len(kvReads))
+	for _, kvRead := range kvReads {
+		read := Read{Key: kvRead.Key}
+		if version := kvRead.GetVersion(); version != nil {
+			blockNum, txNum := version.BlockNum, version.TxNum
+			read.BlockNum, read.TxNum = &blockNum, &txNum
+		}
+		reads = append(reads, read)
+	}
+	return reads
+}
+
+// decodeIdentity returns the MSP of a serialized identity and the common
+// name of its certificate, if it can be parsed
+func decodeIdentity(b []byte) (Identity, error) {
+	var serialized mspprotos.SerializedIdentity
+	err := proto.Unmarshal(b, &serialized)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	identity := Identity{MSP: serialized.Mspid}
+	if block, _ := pem.Decode(serialized.IdBytes); block != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			identity.ID = cert.Subject.CommonName
+		}
+	}
+	return identity, nil
+}
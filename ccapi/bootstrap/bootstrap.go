@@ -0,0 +1,442 @@
+// Package bootstrap reconciles the CCAPI with the declarative file set by
+// BOOTSTRAP_CONFIG, so environments are provisioned reproducibly by
+// Terraform, Helm or an operator instead of by hand. The file declares the
+// identities, webhooks, API keys, scheduled transactions and feature flags
+// the CCAPI must have:
+//
+//	{
+//	  "identities": [{"as": "User3@org2", "wallet": "/wallets/app", "label": "appUser"}],
+//	  "webhooks": ["https://hooks.example.com/ccapi"],
+//	  "apiKeys": {"ci": "${CI_API_KEY}"},
+//	  "schedules": [{"name": "nightlyReport", "txName": "createReport", "args": {}, "every": "24h"}],
+//	  "features": {"jsonLD": true}
+//	}
+//
+// Sections left out of the file are not managed. The webhooks, API keys
+// and schedules of a section are made to match it exactly, while
+// identities are created or updated but never deleted, and flags not in
+// the file keep their state.
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/pipeline"
+	"github.com/hyperledger-labs/ccapi/scheduler"
+	"github.com/hyperledger-labs/ccapi/wallet"
+	"github.com/hyperledger-labs/ccapi/webhook"
+	"github.com/pkg/errors"
+)
+
+// Actions of the changes
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// jobID is the scheduler job of the periodic reconciliation
+const jobID = "bootstrap"
+
+// Config is the declarative file
+type Config struct {
+	Identities []Identity        `json:"identities"`
+	Webhooks   []string          `json:"webhooks"`
+	APIKeys    map[string]string `json:"apiKeys"`
+	Schedules  []Schedule        `json:"schedules"`
+	Features   map[string]bool   `json:"features"`
+}
+
+// Identity is an identity of the CCAPI, such as User3@org2, imported from
+// the label of a wallet of the Fabric SDKs
+type Identity struct {
+	As     string `json:"as"`
+	Wallet string `json:"wallet"`
+	Label  string `json:"label"`
+}
+
+// Schedule is a transaction submitted periodically
+type Schedule struct {
+	Name      string          `json:"name"`
+	TxName    string          `json:"txName"`
+	Args      json.RawMessage `json:"args,omitempty"`
+	Every     string          `json:"every"`
+	User      string          `json:"user,omitempty"`
+	Channel   string          `json:"channel,omitempty"`
+	Chaincode string          `json:"chaincode,omitempty"`
+}
+
+// Change is a difference between the CCAPI and the file
+type Change struct {
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Action   string `json:"action"`
+}
+
+// Submitter submits a scheduled transaction
+type Submitter func(channel, chaincode, txName, user string, args []string) error
+
+var (
+	mutex     sync.Mutex
+	submitter Submitter
+
+	// schedules holds the schedules applied, by name
+	schedules = make(map[string]Schedule)
+)
+
+// Enabled reports whether the CCAPI is bootstrapped from BOOTSTRAP_CONFIG
+func Enabled() bool {
+	return os.Getenv("BOOTSTRAP_CONFIG") != ""
+}
+
+// Start reconciles the CCAPI with the file, stopping it if the file is
+// invalid, and then again every BOOTSTRAP_INTERVAL, if set. Scheduled
+// transactions are submitted with submit.
+func Start(submit Submitter) {
+	if !Enabled() {
+		return
+	}
+
+	mutex.Lock()
+	submitter = submit
+	mutex.Unlock()
+
+	_, err := Reconcile(true)
+	if err != nil {
+		log.Fatalln("failed to bootstrap: ", err)
+	}
+	if getInterval() > 0 {
+		schedule()
+	}
+}
+
+// getInterval returns the time between reconciliations from
+// BOOTSTRAP_INTERVAL, zero if they only happen at startup
+func getInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("BOOTSTRAP_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 0
+	}
+	return interval
+}
+
+func schedule() {
+	scheduler.ScheduleLocal(jobID, time.Now().Add(getInterval()), func() error {
+		_, err := Reconcile(true)
+		if err != nil {
+			log.Println("error reconciling bootstrap configuration: ", err)
+		}
+		schedule()
+		return nil
+	})
+}
+
+// readConfig reads the file, expanding the environment variables in it
+func readConfig() (*Config, error) {
+	data, err := os.ReadFile(os.Getenv("BOOTSTRAP_CONFIG"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bootstrap configuration")
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(os.ExpandEnv(string(data)))))
+	decoder.DisallowUnknownFields()
+	var config Config
+	err = decoder.Decode(&config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal bootstrap configuration")
+	}
+	return &config, nil
+}
+
+// Reconcile returns the changes that make the CCAPI match the file, sorted
+// by resource and name, applying them if apply is set. Nothing is applied
+// if the file is invalid.
+func Reconcile(apply bool) ([]Change, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	config, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0)
+	var steps []func() error
+	for _, diff := range []func(*Config) ([]Change, func() error, error){
+		diffIdentities,
+		diffWebhooks,
+		diffAPIKeys,
+		diffSchedules,
+		diffFeatures,
+	} {
+		c, step, err := diff(config)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c...)
+		if len(c) > 0 {
+			steps = append(steps, step)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Resource != changes[j].Resource {
+			return changes[i].Resource < changes[j].Resource
+		}
+		return changes[i].Name < changes[j].Name
+	})
+
+	if !apply {
+		return changes, nil
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range changes {
+		log.Printf("bootstrap: %s %s '%s'\n", change.Action, change.Resource, change.Name)
+	}
+	return changes, nil
+}
+
+// diffIdentities compares the credentials of the identities with those of
+// the wallets
+func diffIdentities(config *Config) ([]Change, func() error, error) {
+	changes := make([]Change, 0)
+	imports := make(map[string]wallet.Identity)
+	for _, declared := range config.Identities {
+		if declared.As == "" || declared.Wallet == "" || declared.Label == "" {
+			return nil, nil, errors.New("identities need 'as', 'wallet' and 'label'")
+		}
+		ids, err := wallet.ReadDir(declared.Wallet)
+		if err != nil {
+			return nil, nil, err
+		}
+		var id *wallet.Identity
+		for i := range ids {
+			if ids[i].Label == declared.Label {
+				id = &ids[i]
+			}
+		}
+		if id == nil {
+			return nil, nil, errors.Errorf("wallet %s has no identity %s", declared.Wallet, declared.Label)
+		}
+
+		certPath, keyPath, _, err := common.IdentityFiles(declared.As)
+		if err != nil {
+			return nil, nil, err
+		}
+		cert, certErr := os.ReadFile(certPath)
+		key, keyErr := os.ReadFile(keyPath)
+		switch {
+		case os.IsNotExist(certErr) || os.IsNotExist(keyErr):
+			changes = append(changes, Change{Resource: "identity", Name: declared.As, Action: ActionCreate})
+		case certErr != nil || keyErr != nil || string(cert) != id.Certificate || string(key) != id.PrivateKey:
+			changes = append(changes, Change{Resource: "identity", Name: declared.As, Action: ActionUpdate})
+		default:
+			continue
+		}
+		imports[declared.As] = *id
+	}
+
+	return changes, func() error {
+		for as, id := range imports {
+			if err := wallet.Import(id, as, true); err != nil {
+				return errors.Wrapf(err, "failed to import identity %s", as)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// diffWebhooks compares the webhooks set at runtime with those of the file
+func diffWebhooks(config *Config) ([]Change, func() error, error) {
+	if config.Webhooks == nil {
+		return nil, nil, nil
+	}
+	current := make(map[string]bool)
+	for _, url := range webhook.ManagedURLs() {
+		current[url] = true
+	}
+	declared := make(map[string]bool)
+	for _, url := range config.Webhooks {
+		declared[url] = true
+	}
+
+	changes := make([]Change, 0)
+	for url := range declared {
+		if !current[url] {
+			changes = append(changes, Change{Resource: "webhook", Name: url, Action: ActionCreate})
+		}
+	}
+	for url := range current {
+		if !declared[url] {
+			changes = append(changes, Change{Resource: "webhook", Name: url, Action: ActionDelete})
+		}
+	}
+	return changes, func() error {
+		webhook.SetManagedURLs(config.Webhooks)
+		return nil
+	}, nil
+}
+
+// diffAPIKeys compares the named API keys with those of the file. Keys are
+// only reported by name.
+func diffAPIKeys(config *Config) ([]Change, func() error, error) {
+	if config.APIKeys == nil {
+		return nil, nil, nil
+	}
+	current := pipeline.APIKeys()
+	changes := make([]Change, 0)
+	for name, key := range config.APIKeys {
+		if key == "" {
+			return nil, nil, errors.Errorf("API key '%s' is empty, check its environment variable", name)
+		}
+		old, ok := current[name]
+		if !ok {
+			changes = append(changes, Change{Resource: "apiKey", Name: name, Action: ActionCreate})
+		} else if old != key {
+			changes = append(changes, Change{Resource: "apiKey", Name: name, Action: ActionUpdate})
+		}
+	}
+	for name := range current {
+		if _, ok := config.APIKeys[name]; !ok {
+			changes = append(changes, Change{Resource: "apiKey", Name: name, Action: ActionDelete})
+		}
+	}
+	return changes, func() error {
+		pipeline.SetAPIKeys(config.APIKeys)
+		return nil
+	}, nil
+}
+
+// diffSchedules compares the scheduled transactions with those of the file
+func diffSchedules(config *Config) ([]Change, func() error, error) {
+	if config.Schedules == nil {
+		return nil, nil, nil
+	}
+	declared := make(map[string]Schedule)
+	for _, s := range config.Schedules {
+		if s.Name == "" || s.TxName == "" {
+			return nil, nil, errors.New("schedules need 'name' and 'txName'")
+		}
+		if _, ok := declared[s.Name]; ok {
+			return nil, nil, errors.Errorf("schedule '%s' is repeated", s.Name)
+		}
+		if every, err := time.ParseDuration(s.Every); err != nil || every <= 0 {
+			return nil, nil, errors.Errorf("schedule '%s' must run 'every' a positive duration, such as 1h", s.Name)
+		}
+		if len(s.Args) > 0 && !json.Valid(s.Args) {
+			return nil, nil, errors.Errorf("invalid args of schedule '%s'", s.Name)
+		}
+		declared[s.Name] = s
+	}
+
+	changes := make([]Change, 0)
+	for name, s := range declared {
+		old, ok := schedules[name]
+		if !ok {
+			changes = append(changes, Change{Resource: "schedule", Name: name, Action: ActionCreate})
+		} else if !reflect.DeepEqual(old, s) {
+			changes = append(changes, Change{Resource: "schedule", Name: name, Action: ActionUpdate})
+		}
+	}
+	for name := range schedules {
+		if _, ok := declared[name]; !ok {
+			changes = append(changes, Change{Resource: "schedule", Name: name, Action: ActionDelete})
+		}
+	}
+	return changes, func() error {
+		for name := range schedules {
+			if _, ok := declared[name]; !ok {
+				scheduler.Cancel(scheduleJobID(name))
+				delete(schedules, name)
+			}
+		}
+		for name, s := range declared {
+			if old, ok := schedules[name]; !ok || !reflect.DeepEqual(old, s) {
+				schedules[name] = s
+				scheduleTx(s)
+			}
+		}
+		return nil
+	}, nil
+}
+
+func scheduleJobID(name string) string {
+	return "bootstrap:" + name
+}
+
+// scheduleTx submits the transaction of the schedule after its period, and
+// then again for as long as it is the schedule applied
+func scheduleTx(s Schedule) {
+	every, _ := time.ParseDuration(s.Every)
+	scheduler.Schedule(scheduleJobID(s.Name), time.Now().Add(every), func() error {
+		channel, chaincode, user := s.Channel, s.Chaincode, s.User
+		if channel == "" {
+			channel = os.Getenv("CHANNEL")
+		}
+		if chaincode == "" {
+			chaincode = os.Getenv("CCNAME")
+		}
+		if user == "" {
+			user = os.Getenv("USER")
+		}
+		args := s.Args
+		if len(args) == 0 {
+			args = json.RawMessage("{}")
+		}
+
+		mutex.Lock()
+		submit := submitter
+		mutex.Unlock()
+		if submit != nil {
+			err := submit(channel, chaincode, s.TxName, user, []string{string(args)})
+			if err != nil {
+				log.Printf("error submitting scheduled transaction '%s': %s\n", s.Name, err)
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if current, ok := schedules[s.Name]; ok && reflect.DeepEqual(current, s) {
+			scheduleTx(s)
+		}
+		return nil
+	})
+}
+
+// diffFeatures compares the flags with those of the file
+func diffFeatures(config *Config) ([]Change, func() error, error) {
+	current := make(map[string]bool)
+	for _, flag := range features.List() {
+		current[flag.Name] = flag.Enabled
+	}
+
+	changes := make([]Change, 0)
+	for name, enabled := range config.Features {
+		old, ok := current[name]
+		if !ok {
+			return nil, nil, errors.Errorf("unknown feature '%s'", name)
+		}
+		if old != enabled {
+			changes = append(changes, Change{Resource: "feature", Name: name, Action: ActionUpdate})
+		}
+	}
+	return changes, func() error {
+		for name, enabled := range config.Features {
+			if _, err := features.Set(name, enabled); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
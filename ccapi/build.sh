@@ -0,0 +1,121 @@
+#!/usr/bin/env bash
+
+# Builds static binaries of the CCAPI for each platform, with the build
+# metadata served by /version and printed by "ccapi version"
+
+set -e
+
+# Default values for the flags
+FLAG_VERSION=""
+FLAG_PLATFORMS="linux/amd64,linux/arm64"
+FLAG_OUT="dist"
+FLAG_TOOLS=false
+
+# Process command-line arguments
+while [[ $# -gt 0 ]]; do
+    case $1 in
+        --version | -v)
+            if [[ $# -gt 1 ]]; then
+                FLAG_VERSION=$2
+                shift 2
+            else
+                echo "Error: --version flag requires a value."
+                exit 1
+            fi
+            ;;
+        --platforms | -p)
+            if [[ $# -gt 1 ]]; then
+                FLAG_PLATFORMS=$2
+                shift 2
+            else
+                echo "Error: --platforms flag requires a value."
+                exit 1
+            fi
+            ;;
+        --out | -o)
+            if [[ $# -gt 1 ]]; then
+                FLAG_OUT=$2
+                shift 2
+            else
+                echo "Error: --out flag requires a value."
+                exit 1
+            fi
+            ;;
+        --tools | -t)
+            FLAG_TOOLS=true
+            shift 1
+            ;;
+        --help | -h)
+            echo "Usage: ./build.sh [--version <version>] [--platforms <os/arch,...>] [--out <dir>] [--tools]"
+            echo "  --help     , -h: Show this help message."
+            echo "  --out      , -o: Directory of the binaries. Default is ${FLAG_OUT}."
+            echo "  --platforms, -p: Comma-separated platforms to build for. Default is ${FLAG_PLATFORMS}."
+            echo "  --tools    , -t: Also build the commands of ./cmd (wallet, seed, replay...)."
+            echo "  --version  , -v: Version of the CCAPI. Default is the git tag or commit."
+            echo "The commit is read from git unless COMMIT is set, and the chaincode version from"
+            echo "../chaincode/header/header.go unless CHAINCODE_VERSION is set."
+            exit 0
+            ;;
+        *)
+            # Ignore unrecognized arguments
+            shift
+            ;;
+    esac
+done
+
+cd "$(dirname "$0")"
+
+# Build metadata
+if [ -z "$COMMIT" ]
+then
+    COMMIT=$(git rev-parse HEAD 2>/dev/null || true)
+fi
+if [ -z "$FLAG_VERSION" ]
+then
+    FLAG_VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo dev)
+fi
+BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+if [ -z "$CHAINCODE_VERSION" ] && [ -f ../chaincode/header/header.go ]
+then
+    CHAINCODE_VERSION=$(sed -n 's/^var Version = "\(.*\)"/\1/p' ../chaincode/header/header.go)
+fi
+
+PKG=github.com/hyperledger-labs/ccapi/buildinfo
+LDFLAGS="-s -w -X ${PKG}.Version=${FLAG_VERSION} -X ${PKG}.Commit=${COMMIT} -X ${PKG}.BuildTime=${BUILD_TIME} -X ${PKG}.ChaincodeVersion=${CHAINCODE_VERSION}"
+# Warn about the protobuf types the Fabric SDK registers twice instead of
+# panicking, without GOLANG_PROTOBUF_REGISTRATION_CONFLICT=warn
+LDFLAGS="${LDFLAGS} -X google.golang.org/protobuf/reflect/protoregistry.conflictPolicy=warn"
+
+COMMANDS=(.)
+if [ "$FLAG_TOOLS" = true ]
+then
+    for dir in ./cmd/*/
+    do
+        COMMANDS+=("${dir%/}")
+    done
+fi
+
+mkdir -p "$FLAG_OUT"
+for platform in ${FLAG_PLATFORMS//,/ }
+do
+    GOOS=${platform%/*}
+    GOARCH=${platform#*/}
+    EXT=""
+    if [ "$GOOS" = windows ]
+    then
+        EXT=".exe"
+    fi
+
+    for cmd in "${COMMANDS[@]}"
+    do
+        name=ccapi
+        if [ "$cmd" != . ]
+        then
+            name=$(basename "$cmd")
+        fi
+        out="${FLAG_OUT}/${name}-${GOOS}-${GOARCH}${EXT}"
+        echo "Building ${out}"
+        # Static binaries: no cgo, so they run on any distribution or scratch
+        CGO_ENABLED=0 GOOS=$GOOS GOARCH=$GOARCH GOWORK=off go build -trimpath -ldflags "$LDFLAGS" -o "$out" "$cmd"
+    done
+done
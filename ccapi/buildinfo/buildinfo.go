@@ -0,0 +1,104 @@
+// Package buildinfo describes the build of the running CCAPI binary, so a
+// rollout can be checked against the commit it was meant to ship. The
+// values are set at link time by build.sh:
+//
+//	go build -ldflags "-X github.com/hyperledger-labs/ccapi/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// Binaries built without them, such as with go run, fall back to the
+// version control information Go embeds, when there is any.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Set with -ldflags "-X github.com/hyperledger-labs/ccapi/buildinfo.Name=value"
+var (
+	// Version is the release of the CCAPI, such as 1.4.0
+	Version = "dev"
+	// Commit is the git SHA the binary was built from
+	Commit = ""
+	// BuildTime is when the binary was built, in RFC 3339
+	BuildTime = ""
+	// ChaincodeVersion is the version of the chaincode the binary was built
+	// with, whose major version the CCAPI expects to be deployed
+	ChaincodeVersion = ""
+)
+
+// Info is the build of the binary
+type Info struct {
+	Version          string `json:"version"`
+	Commit           string `json:"commit,omitempty"`
+	Modified         bool   `json:"modified,omitempty"`
+	BuildTime        string `json:"buildTime,omitempty"`
+	ChaincodeVersion string `json:"chaincodeVersion,omitempty"`
+	GoVersion        string `json:"goVersion"`
+	Platform         string `json:"platform"`
+	// Static is whether the binary was built without cgo, so it does not
+	// link the C library of the host
+	Static bool `json:"static"`
+}
+
+// Get returns the build of the binary
+func Get() Info {
+	info := Info{
+		Version:          Version,
+		Commit:           Commit,
+		BuildTime:        BuildTime,
+		ChaincodeVersion: ChaincodeVersion,
+		GoVersion:        runtime.Version(),
+		Platform:         runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "CGO_ENABLED":
+			info.Static = setting.Value == "0"
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// String returns the build on one line, for the version command
+func (i Info) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ccapi %s", i.Version)
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if i.Modified {
+			commit += "-dirty"
+		}
+		fmt.Fprintf(&b, " (%s)", commit)
+	}
+	if i.BuildTime != "" {
+		fmt.Fprintf(&b, " built %s", i.BuildTime)
+	}
+	if i.ChaincodeVersion != "" {
+		fmt.Fprintf(&b, " for chaincode %s", i.ChaincodeVersion)
+	}
+	fmt.Fprintf(&b, " with %s %s", i.GoVersion, i.Platform)
+	if i.Static {
+		b.WriteString(" static")
+	}
+	return b.String()
+}
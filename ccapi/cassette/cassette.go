@@ -0,0 +1,277 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/redact"
+	"github.com/pkg/errors"
+)
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	User   string `json:"user,omitempty"`
+	Org    string `json:"org,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+// key identifies equivalent requests, which act as the same user. JSON
+// bodies are re-encoded so that key order and whitespace do not matter.
+func (r Request) key() string {
+	body := r.Body
+	var v interface{}
+	if json.Unmarshal([]byte(body), &v) == nil {
+		b, _ := json.Marshal(v)
+		body = string(b)
+	}
+	return r.Method + " " + r.Path + "?" + r.Query + " " + r.User + "@" + r.Org + " " + body
+}
+
+const (
+	modeRecord   = "record"
+	modePlayback = "playback"
+)
+
+// getMode returns CASSETTE_MODE, which can be "record" or "playback".
+// Any other value disables the cassette.
+func getMode() string {
+	return os.Getenv("CASSETTE_MODE")
+}
+
+func getPath() string {
+	path := os.Getenv("CASSETTE_PATH")
+	if path == "" {
+		path = "cassette.json"
+	}
+	return path
+}
+
+// Playback reports whether responses are served from the cassette,
+// in which case no Fabric network is needed
+func Playback() bool {
+	return getMode() == modePlayback
+}
+
+// Middleware records or plays back requests according to CASSETTE_MODE
+func Middleware() gin.HandlerFunc {
+	switch getMode() {
+	case modeRecord:
+		return newRecorder(getPath()).handle
+	case modePlayback:
+		player, err := newPlayer(getPath())
+		if err != nil {
+			log.Panic(err)
+		}
+		return player.handle
+	default:
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+}
+
+func readRequest(c *gin.Context) Request {
+	var body []byte
+	if c.Request.Body != nil {
+		body, _ = io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	}
+
+	return Request{
+		Method: c.Request.Method,
+		Path:   c.Request.URL.Path,
+		Query:  c.Request.URL.RawQuery,
+		User:   c.GetHeader("User"),
+		Org:    c.GetHeader("Org"),
+		Body:   redactTransient(string(body)),
+	}
+}
+
+// redactTransient masks the values of the transient properties of JSON
+// bodies, prefixed with ~, which must not be written to the cassette.
+// Requests are matched by the masked body, so playback is not affected.
+func redactTransient(body string) string {
+	var v map[string]interface{}
+	if json.Unmarshal([]byte(body), &v) != nil {
+		return body
+	}
+
+	redacted := false
+	for key := range v {
+		if strings.HasPrefix(key, "~") {
+			v[key] = redact.Mask
+			redacted = true
+		}
+	}
+	if !redacted {
+		return body
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return string(b)
+}
+
+// recorder appends each interaction to the cassette as a line of JSON
+type recorder struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func newRecorder(path string) *recorder {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		log.Panic(errors.Wrap(err, "failed to open cassette"))
+	}
+
+	log.Printf("recording requests to cassette '%s'\n", path)
+	return &recorder{
+		file: file,
+	}
+}
+
+// bodyWriter copies the response body while it is written
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w bodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (r *recorder) handle(c *gin.Context) {
+	req := readRequest(c)
+
+	writer := bodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+	c.Writer = writer
+	c.Next()
+
+	b, err := json.Marshal(Interaction{
+		Request: req,
+		Response: Response{
+			Status:      writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.String(),
+		},
+	})
+	if err != nil {
+		log.Println("error writing cassette: ", err)
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, err = r.file.Write(append(b, '\n'))
+	if err != nil {
+		log.Println("error writing cassette: ", err)
+	}
+}
+
+type player struct {
+	mutex        sync.Mutex
+	interactions map[string][]Response
+	played       map[string]int
+}
+
+func newPlayer(path string) (*player, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cassette")
+	}
+
+	interactions, err := readInteractions(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal cassette")
+	}
+
+	p := &player{
+		interactions: make(map[string][]Response),
+		played:       make(map[string]int),
+	}
+	for _, i := range interactions {
+		key := i.Request.key()
+		p.interactions[key] = append(p.interactions[key], i.Response)
+	}
+
+	log.Printf("playing back %d interactions from cassette '%s'\n", len(interactions), path)
+	return p, nil
+}
+
+// readInteractions reads the interactions of the cassette, one per line, or
+// all in an array as written by older versions
+func readInteractions(b []byte) ([]Interaction, error) {
+	var interactions []Interaction
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		err := json.Unmarshal(b, &interactions)
+		return interactions, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var i Interaction
+		err := decoder.Decode(&i)
+		if err == io.EOF {
+			return interactions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		interactions = append(interactions, i)
+	}
+}
+
+// handle serves equivalent requests with their recorded responses in
+// the order they were recorded, repeating the last one when exhausted
+func (p *player) handle(c *gin.Context) {
+	key := readRequest(c).key()
+
+	p.mutex.Lock()
+	responses, ok := p.interactions[key]
+	index := p.played[key]
+	if ok && index < len(responses)-1 {
+		p.played[key]++
+	}
+	p.mutex.Unlock()
+
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusNotImplemented, gin.H{
+			"status": http.StatusNotImplemented,
+			"error":  "request not found in cassette",
+		})
+		return
+	}
+
+	res := responses[index]
+	contentType := res.ContentType
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Data(res.Status, contentType, []byte(res.Body))
+	c.Abort()
+}
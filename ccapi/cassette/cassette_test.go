@@ -0,0 +1,61 @@
+package cassette
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecordAndPlayback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	serve := func(handler gin.HandlerFunc, user, body string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(handler)
+		r.POST("/api/invoke/createAsset", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"user": c.GetHeader("User")})
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/invoke/createAsset", strings.NewReader(body))
+		req.Header.Set("User", user)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	rec := newRecorder(path)
+	serve(rec.handle, "Admin", `{"asset": [], "~password": "hunter2"}`)
+	serve(rec.handle, "User1", `{"asset": []}`)
+	rec.file.Close()
+
+	// Each interaction is a line, without the transient values
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(b, []byte("\n")) != 2 || bytes.Contains(b, []byte("hunter2")) {
+		t.Fatalf("unexpected cassette %s", b)
+	}
+
+	p, err := newPlayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(p.handle, "Admin", `{"~password": "other", "asset": []}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Admin") {
+		t.Fatalf("expected the response of Admin, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Requests of other users are not matched
+	w = serve(p.handle, "User2", `{"asset": []}`)
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", w.Code)
+	}
+}
@@ -0,0 +1,84 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// evaluation is an evaluation in flight, whose result is shared by the
+// requests waiting for it
+type evaluation struct {
+	done    chan struct{}
+	result  []byte
+	err     error
+	waiters int
+}
+
+var (
+	// evaluationsMutex guards the evaluations in flight, by key
+	evaluationsMutex sync.Mutex
+	evaluations      = make(map[string]*evaluation)
+)
+
+// coalescingEnabled reports whether identical concurrent evaluations are
+// coalesced, unless COALESCE_EVALUATE is false
+func coalescingEnabled() bool {
+	return os.Getenv("COALESCE_EVALUATE") != "false"
+}
+
+// evaluationKey identifies the evaluations that return the same result. The
+// user is part of it, since the chaincode may answer each identity
+// differently.
+func evaluationKey(channelName, chaincodeName, txName, user string, args []string) string {
+	key, _ := json.Marshal(append([]string{channelName, chaincodeName, txName, user}, args...))
+	return string(key)
+}
+
+// coalesce calls evaluate, unless an identical evaluation is in flight, in
+// which case it waits for that one and shares its result. Waiters get their
+// own copy of the result.
+func coalesce(key string, evaluate func() ([]byte, error)) ([]byte, error) {
+	evaluationsMutex.Lock()
+	if e, ok := evaluations[key]; ok {
+		e.waiters++
+		evaluationsMutex.Unlock()
+
+		<-e.done
+		if e.err != nil {
+			return nil, e.err
+		}
+		return append([]byte(nil), e.result...), nil
+	}
+
+	// The error is kept if the evaluation panics
+	e := &evaluation{
+		done: make(chan struct{}),
+		err:  errors.New("evaluation did not complete"),
+	}
+	evaluations[key] = e
+	evaluationsMutex.Unlock()
+
+	shared := false
+	func() {
+		// Release the waiters even if the evaluation panics
+		defer func() {
+			evaluationsMutex.Lock()
+			delete(evaluations, key)
+			shared = e.waiters > 0
+			evaluationsMutex.Unlock()
+			close(e.done)
+		}()
+		e.result, e.err = evaluate()
+	}()
+
+	if e.err != nil {
+		return nil, e.err
+	}
+	if shared {
+		return append([]byte(nil), e.result...), nil
+	}
+	return e.result, nil
+}
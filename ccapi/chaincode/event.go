@@ -6,21 +6,102 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/hyperledger-labs/ccapi/checkpoint"
 	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/dashboard"
+	"github.com/hyperledger-labs/ccapi/eventschema"
+	"github.com/hyperledger-labs/ccapi/leader"
+	"github.com/hyperledger-labs/ccapi/redact"
+	"github.com/hyperledger-labs/ccapi/webhook"
 	ev "github.com/hyperledger/fabric-sdk-go/pkg/client/event"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
+	"github.com/pkg/errors"
 )
 
-func getEventClient(channelName string) (*ev.Client, error) {
+// Delays before subscribing again to the events of a consumer whose stream
+// was closed, doubled on every attempt that receives no event
+const (
+	resubscribeMinBackoff = time.Second
+	resubscribeMaxBackoff = 30 * time.Second
+)
+
+// errNotLeader stops the event streams of replicas no longer the leader
+var errNotLeader = errors.New("no longer the leader")
+
+// EventStream is the state of the event stream of a consumer
+type EventStream struct {
+	Consumer string    `json:"consumer"`
+	State    string    `json:"state"`
+	Since    time.Time `json:"since"`
+	// Resubscriptions counts the times the stream was opened again after
+	// being closed
+	Resubscriptions int `json:"resubscriptions"`
+}
+
+var (
+	// eventStreamsMutex guards the state of the event streams, by consumer
+	eventStreamsMutex sync.Mutex
+	eventStreams      = make(map[string]*EventStream)
+)
+
+// EventStreams returns the state of the event streams, sorted by consumer
+func EventStreams() []EventStream {
+	eventStreamsMutex.Lock()
+	defer eventStreamsMutex.Unlock()
+
+	streams := make([]EventStream, 0, len(eventStreams))
+	for _, stream := range eventStreams {
+		streams = append(streams, *stream)
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].Consumer < streams[j].Consumer
+	})
+	return streams
+}
+
+// setEventStreamState records the state of the event stream of the consumer
+func setEventStreamState(consumer, state string, resubscribed bool) {
+	eventStreamsMutex.Lock()
+	defer eventStreamsMutex.Unlock()
+
+	stream, ok := eventStreams[consumer]
+	if !ok {
+		stream = &EventStream{Consumer: consumer}
+		eventStreams[consumer] = stream
+	}
+	stream.State = state
+	stream.Since = time.Now().UTC()
+	if resubscribed {
+		stream.Resubscriptions++
+	}
+}
+
+// getEventClient returns an event client of the channel delivering blocks
+// from the checkpoint of the consumer or, if it has none, from the start
+// block if set, else from the newest block
+func getEventClient(channelName, consumer string, start *uint64) (*ev.Client, error) {
 	// create channel manager
 	fabMngr, err := common.NewFabricChClient(channelName, os.Getenv("USER"), os.Getenv("ORG"))
 	if err != nil {
 		return nil, err
 	}
 
+	opts := []ev.ClientOption{ev.WithBlockEvents()}
+	if cp, ok := checkpoint.Get(consumer); ok {
+		log.Printf("resuming events of %s from block %d\n", consumer, cp.BlockNumber)
+		opts = append(opts, ev.WithSeekType(seek.FromBlock), ev.WithBlockNum(cp.BlockNumber))
+	} else if start != nil {
+		opts = append(opts, ev.WithSeekType(seek.FromBlock), ev.WithBlockNum(*start))
+	}
+
 	// Create event client
-	ec, err := ev.New(fabMngr.Provider, ev.WithBlockEvents())
+	ec, err := ev.New(fabMngr.Provider, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -28,52 +109,172 @@ func getEventClient(channelName string) (*ev.Client, error) {
 	return ec, nil
 }
 
-func WaitForEvent(channelName, ccName, eventName string, fn func(*fab.CCEvent)) {
-	ec, err := getEventClient(channelName)
-	if err != nil {
-		log.Println("error getting event client: ", err)
-		return
+// consumerName identifies the consumer of a chaincode event in the
+// checkpoints
+func consumerName(channelName, ccName, eventName string) string {
+	return channelName + "/" + ccName + "/" + eventName
+}
+
+// consume executes fn on every chaincode event with the name, skipping the
+// events the consumer processed before a restart and checkpointing each one
+// after it is processed. The SDK reconnects to the event source by itself,
+// and when it gives up and closes the stream, the consumer subscribes again
+// from its checkpoint, on whichever event source peer of the SDK config is
+// available, so no event is missed. With leader election only the leader
+// consumes the events, and the other replicas stand by.
+func consume(channelName, ccName, eventName string, fn func(*fab.CCEvent)) {
+	consumer := consumerName(channelName, ccName, eventName)
+	if !leader.IsLeader() {
+		setEventStreamState(consumer, "standby", false)
 	}
+	lost := leader.Wait()
 
-	for {
-		// Register chaincode event
-		registration, notifier, err := ec.RegisterChaincodeEvent(ccName, eventName)
+	// Consumers without a checkpoint start from the next block, so the
+	// events committed while they subscribe again are not missed either
+	var start *uint64
+	if _, ok := checkpoint.Get(consumer); !ok {
+		height, err := ChainHeight(channelName, os.Getenv("USER"))
 		if err != nil {
-			log.Println("error registering chaincode event: ", err)
-			return
+			log.Printf("error getting the height of %s, events of %s start from the newest block: %s\n", channelName, consumer, err)
+		} else {
+			start = &height
 		}
+	}
 
-		// Execute handler function on event notification
-		ccEvent := <-notifier
-		fmt.Printf("Received CC event: %v\n", ccEvent)
-		fn(ccEvent)
+	backoff := resubscribeMinBackoff
+	for resubscribed := false; ; resubscribed = true {
+		setEventStreamState(consumer, "connecting", resubscribed)
+		received, err := subscribe(channelName, ccName, eventName, consumer, start, fn, lost)
+		if err == errNotLeader {
+			log.Printf("event stream of %s stopped, this replica is no longer the leader\n", consumer)
+			setEventStreamState(consumer, "standby", false)
+			lost = leader.Wait()
+			backoff = resubscribeMinBackoff
+			continue
+		}
+		if err != nil {
+			log.Printf("error subscribing to the events of %s: %s\n", consumer, err)
+		} else {
+			log.Printf("event stream of %s closed\n", consumer)
+		}
+		if received {
+			backoff = resubscribeMinBackoff
+		}
 
-		ec.Unregister(registration)
+		setEventStreamState(consumer, "reconnecting", false)
+		log.Printf("subscribing to the events of %s again in %s\n", consumer, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > resubscribeMaxBackoff {
+			backoff = resubscribeMaxBackoff
+		}
 	}
 }
 
-func HandleEvent(channelName, ccName string, event EventHandler) {
-	ec, err := getEventClient(channelName)
+// subscribe delivers the events of the consumer to fn until the stream is
+// closed, or lost is closed as the replica is no longer the leader,
+// reporting whether any event was received
+func subscribe(channelName, ccName, eventName, consumer string, start *uint64, fn func(*fab.CCEvent), lost <-chan struct{}) (bool, error) {
+	ec, err := getEventClient(channelName, consumer, start)
 	if err != nil {
-		log.Println("error getting event client: ", err)
-		return
+		return false, errors.Wrap(err, "failed to get event client")
+	}
+
+	// Register chaincode event
+	registration, notifier, err := ec.RegisterChaincodeEvent(ccName, eventName)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to register chaincode event")
 	}
+	defer ec.Unregister(registration)
+	setEventStreamState(consumer, "connected", false)
 
+	received := false
 	for {
-		// Register chaincode event
-		registration, notifier, err := ec.RegisterChaincodeEvent(ccName, event.Tag)
-		if err != nil {
-			log.Println("error registering chaincode event: ", err)
-			return
+		var ccEvent *fab.CCEvent
+		select {
+		case <-lost:
+			return received, errNotLeader
+		case event, ok := <-notifier:
+			if !ok {
+				return received, nil
+			}
+			ccEvent = event
+		}
+		received = true
+		if checkpoint.Processed(consumer, ccEvent.BlockNumber, ccEvent.TxID) {
+			continue
 		}
 
 		// Execute handler function on event notification
-		ccEvent := <-notifier
-		fmt.Printf("Received CC event: %v\n", ccEvent)
-		event.Execute(ccEvent)
+		log.Printf("Received CC event: %v\n", ccEvent)
+		recordEvent(ccEvent)
+		if validateEvent(ccEvent) {
+			forwardEvent(ccEvent)
+			fn(ccEvent)
+		}
+
+		err = checkpoint.Commit(consumer, ccEvent.BlockNumber, ccEvent.TxID)
+		if err != nil {
+			log.Println("error checkpointing event: ", err)
+		}
+	}
+}
+
+func WaitForEvent(channelName, ccName, eventName string, fn func(*fab.CCEvent)) {
+	consume(channelName, ccName, eventName, fn)
+}
+
+func HandleEvent(channelName, ccName string, event EventHandler) {
+	consumer := consumerName(channelName, ccName, event.Tag)
+	consume(channelName, ccName, event.Tag, func(ccEvent *fab.CCEvent) {
+		event.Execute(consumer, ccEvent)
+	})
+}
+
+// recordEvent keeps the event to be shown in the admin dashboard
+func recordEvent(ccEvent *fab.CCEvent) {
+	dashboard.RecordEvent(dashboard.Event{
+		Time:        time.Now(),
+		Name:        ccEvent.EventName,
+		TxID:        ccEvent.TxID,
+		BlockNumber: ccEvent.BlockNumber,
+		Payload:     redact.JSON(string(ccEvent.Payload)),
+	})
+}
+
+// validateEvent checks the payload of the event against the schema of the
+// event in the registry, reporting whether the event should be handled.
+// Invalid events are only dropped in strict mode.
+func validateEvent(ccEvent *fab.CCEvent) bool {
+	violations, err := eventschema.Validate(ccEvent.EventName, ccEvent.Payload)
+	if err != nil {
+		log.Println("error validating event: ", err)
+		return !eventschema.Strict()
+	}
+	if len(violations) == 0 {
+		return true
+	}
+
+	log.Printf("event '%s' of tx %s violates its schema: %s", ccEvent.EventName, ccEvent.TxID, strings.Join(violations, "; "))
+	return !eventschema.Strict()
+}
+
+// forwardEvent posts the event to the webhooks as chaincode.{eventName}, if
+// WEBHOOK_CHAINCODE_EVENTS is true
+func forwardEvent(ccEvent *fab.CCEvent) {
+	if os.Getenv("WEBHOOK_CHAINCODE_EVENTS") != "true" {
+		return
+	}
 
-		ec.Unregister(registration)
+	var payload interface{}
+	if json.Unmarshal(ccEvent.Payload, &payload) != nil {
+		payload = string(ccEvent.Payload)
 	}
+	webhook.Notify("chaincode."+ccEvent.EventName, map[string]interface{}{
+		"txId":        ccEvent.TxID,
+		"blockNumber": ccEvent.BlockNumber,
+		"payload":     payload,
+	})
 }
 
 func RegisterForEvents() {
@@ -1,11 +1,18 @@
 package chaincode
 
 import (
+	"context"
 	b64 "encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 
+	"github.com/hyperledger-labs/ccapi/checkpoint"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/policy"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 )
 
@@ -28,7 +35,10 @@ type EventHandler struct {
 	ReadOnly    bool
 }
 
-func (event EventHandler) Execute(ccEvent *fab.CCEvent) {
+// Execute handles the event for the consumer. The transactions submitted by
+// the handlers are submitted once for each event, even if the event is
+// delivered again after a crash.
+func (event EventHandler) Execute(consumer string, ccEvent *fab.CCEvent) {
 	if len(event.BaseLog) > 0 {
 		fmt.Println(event.BaseLog)
 	}
@@ -54,14 +64,14 @@ func (event EventHandler) Execute(ccEvent *fab.CCEvent) {
 			cc = event.Chaincode
 		}
 
-		res, _, err := Invoke(ch, cc, event.Transaction, os.Getenv("USER"), [][]byte{ccEvent.Payload}, nil)
+		res, err := submitOnce(consumer, ccEvent, ch, cc, event.Transaction, []string{string(ccEvent.Payload)})
 		if err != nil {
 			fmt.Println("error invoking transaction: ", err)
 			return
 		}
 
 		var response map[string]interface{}
-		nerr := json.Unmarshal(res.Payload, &response)
+		nerr := json.Unmarshal(res, &response)
 		if nerr != nil {
 			fmt.Println("error unmarshalling response: ", nerr)
 			return
@@ -86,7 +96,7 @@ func (event EventHandler) Execute(ccEvent *fab.CCEvent) {
 			txName = "runEvent"
 		}
 
-		_, _, err := Invoke(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), txName, os.Getenv("USER"), [][]byte{args}, nil)
+		_, err := submitOnce(consumer, ccEvent, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), txName, []string{string(args)})
 		if err != nil {
 			fmt.Println("error invoking transaction: ", err)
 			return
@@ -95,3 +105,62 @@ func (event EventHandler) Execute(ccEvent *fab.CCEvent) {
 		fmt.Println("Event type not supported")
 	}
 }
+
+// submitOnce submits the transaction handling the event of the consumer and
+// returns its result. Its signed proposal is kept in the checkpoint of the
+// consumer before it is submitted, so when a crash interrupts the handler
+// and the event is delivered again, the same transaction is looked up in
+// the ledger and submitted again only if it was not committed. The peers
+// refuse a transaction id committed before, so the handler takes effect
+// once.
+func submitOnce(consumer string, ccEvent *fab.CCEvent, channelName, ccName, txName string, args []string) ([]byte, error) {
+	user := os.Getenv("USER")
+	gw, err := common.GetGateway(user)
+	if err != nil {
+		return nil, err
+	}
+
+	var proposal *client.Proposal
+	if proposalBytes, ok := checkpoint.PendingProposal(consumer, ccEvent.TxID); ok {
+		proposal, err = gw.NewProposal(proposalBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		// The transaction may have been committed before the crash
+		res, _, err := committedResult(channelName, proposal.TransactionID(), user)
+		if err == nil {
+			return res.Result, nil
+		}
+		if apiErr, ok := err.(*common.APIError); !ok || apiErr.Status != http.StatusNotFound {
+			return nil, err
+		}
+	} else {
+		contract := gw.GetNetwork(channelName).GetContract(ccName)
+		proposal, err = contract.NewProposal(txName, proposalOptions(canonicalArgs(args), nil, nil)...)
+		if err != nil {
+			return nil, err
+		}
+		proposalBytes, err := proposal.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		err = checkpoint.Begin(consumer, ccEvent.BlockNumber, ccEvent.TxID, proposalBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var res *SubmitResult
+	err = policy.Attempt(txName, func(ctx context.Context) error {
+		return txqueue.Do(ctx, txqueue.Batch, func() error {
+			var err error
+			res, err = submitProposal(ctx, proposal)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Result, nil
+}
@@ -2,16 +2,32 @@ package chaincode
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 )
 
-func Invoke(channelName, ccName, txName, user string, txArgs [][]byte, transientRequest []byte) (*channel.Response, int, error) {
+func Invoke(channelName, ccName, txName, user string, txArgs [][]byte, transient map[string][]byte) (*channel.Response, int, error) {
+	txArgs = canonicalByteArgs(txArgs)
+	transient = canonicalTransient(transient)
+
+	if mock.Enabled() {
+		msp, err := mockMSPID(user)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return mockResponse(mock.Submit(msp, txName, bytesToStrings(txArgs), transient))
+	}
+
+	user, org, err := sdkIdentity(user)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
 	// create channel manager
-	fabMngr, err := common.NewFabricChClient(channelName, user, os.Getenv("ORG"))
+	fabMngr, err := common.NewFabricChClient(channelName, user, org)
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
@@ -22,10 +38,8 @@ func Invoke(channelName, ccName, txName, user string, txArgs [][]byte, transient
 		rq.Args = txArgs
 	}
 
-	if len(transientRequest) != 0 {
-		transientMap := make(map[string][]byte)
-		transientMap["@request"] = transientRequest
-		rq.TransientMap = transientMap
+	if len(transient) != 0 {
+		rq.TransientMap = transient
 	}
 
 	res, err := fabMngr.Client.Execute(rq, channel.WithRetry(retry.DefaultChannelOpts))
@@ -1,61 +1,221 @@
 package chaincode
 
 import (
-	"os"
+	"context"
+	"time"
 
+	"github.com/hyperledger-labs/ccapi/chaos"
 	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger-labs/ccapi/policy"
+	"github.com/hyperledger-labs/ccapi/txqueue"
 	"github.com/hyperledger/fabric-gateway/pkg/client"
-	"github.com/pkg/errors"
 )
 
-func InvokeGateway(channelName, chaincodeName, txName, user string, args []string, transientArgs []byte, endorsingOrgs []string) ([]byte, error) {
-	// Gateway endpoint
-	endpoint := os.Getenv("FABRIC_GATEWAY_ENDPOINT")
+// SubmitResult holds the result of a committed transaction
+type SubmitResult struct {
+	Result      []byte
+	TxID        string
+	BlockNumber uint64
+}
 
-	// Create client grpc connection
-	grpcConn, err := common.CreateGrpcConnection(endpoint)
+func InvokeGateway(channelName, chaincodeName, txName, user string, args []string, transient map[string][]byte, endorsingOrgs []string) ([]byte, error) {
+	res, err := SubmitGateway(channelName, chaincodeName, txName, user, args, transient, endorsingOrgs)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create grpc connection")
+		return nil, err
+	}
+
+	return res.Result, nil
+}
+
+// SubmitGateway submits a transaction and waits for its commit, returning
+// the transaction id and block number alongside the result. The timeout and
+// retries of the policy of the transaction apply to the whole submission.
+func SubmitGateway(channelName, chaincodeName, txName, user string, args []string, transient map[string][]byte, endorsingOrgs []string) (*SubmitResult, error) {
+	return SubmitGatewayClass(txqueue.Interactive, channelName, chaincodeName, txName, user, args, transient, endorsingOrgs)
+}
+
+// SubmitGatewayClass is SubmitGateway in the priority class, each attempt
+// waiting for a slot of its budget within the timeout of the policy
+func SubmitGatewayClass(class txqueue.Class, channelName, chaincodeName, txName, user string, args []string, transient map[string][]byte, endorsingOrgs []string) (*SubmitResult, error) {
+	args = canonicalArgs(args)
+	transient = canonicalTransient(transient)
+
+	var res *SubmitResult
+	err := policy.Do(txName, func(ctx context.Context) error {
+		return txqueue.Do(ctx, class, func() error {
+			var err error
+			res, err = submitGateway(ctx, channelName, chaincodeName, txName, user, args, transient, endorsingOrgs)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// submitGateway makes one attempt to submit the transaction
+func submitGateway(ctx context.Context, channelName, chaincodeName, txName, user string, args []string, transient map[string][]byte, endorsingOrgs []string) (*SubmitResult, error) {
+	// Fault injection for resilience tests
+	if err := chaos.MVCCConflict(); err != nil {
+		return nil, err
+	}
+
+	if mock.Enabled() {
+		msp, err := mockMSPID(user)
+		if err != nil {
+			return nil, err
+		}
+		result, txID, blockNumber, err := mock.Submit(msp, txName, args, transient)
+		if err != nil {
+			return nil, err
+		}
+		return &SubmitResult{Result: result, TxID: txID, BlockNumber: blockNumber}, nil
 	}
-	defer grpcConn.Close()
 
-	// Create gateway connection
-	gw, err := common.CreateGatewayConnection(grpcConn, user)
+	// Get the gateway session of the user, shared with other requests
+	gw, err := common.GetGateway(user)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create gateway connection")
+		return nil, err
 	}
-	defer gw.Close()
 
 	// Obtain smart contract deployed on the network.
 	network := gw.GetNetwork(channelName)
 	contract := network.GetContract(chaincodeName)
 
-	// Make transient request
-	transientMap := make(map[string][]byte)
-	transientMap["@request"] = transientArgs
+	// Endorse and submit the transaction
+	proposal, err := contract.NewProposal(txName, proposalOptions(args, transient, endorsingOrgs)...)
+	if err != nil {
+		return nil, err
+	}
+	return submitProposal(ctx, proposal)
+}
+
+// asyncCommitTimeout bounds the wait for the commit of transactions
+// submitted without waiting for it
+const asyncCommitTimeout = 5 * time.Minute
+
+// SubmitAsyncClass endorses the transaction in the priority class and sends
+// it to the orderer without waiting for its commit, returning its id. The
+// outcome of the commit is passed to done, with the id, once the commit
+// status is known.
+// Failures before the transaction is sent are returned right away, as it
+// cannot be committed anymore.
+func SubmitAsyncClass(class txqueue.Class, channelName, chaincodeName, txName, user string, args []string, transient map[string][]byte, endorsingOrgs []string, done func(string, *SubmitResult, error)) (string, error) {
+	args = canonicalArgs(args)
+	transient = canonicalTransient(transient)
+
+	if mock.Enabled() {
+		res, err := submitGateway(context.Background(), channelName, chaincodeName, txName, user, args, transient, endorsingOrgs)
+		if err != nil {
+			return "", err
+		}
+		go done(res.TxID, res, nil)
+		return res.TxID, nil
+	}
+
+	// Fault injection for resilience tests
+	if err := chaos.MVCCConflict(); err != nil {
+		return "", err
+	}
+
+	gw, err := common.GetGateway(user)
+	if err != nil {
+		return "", err
+	}
+	contract := gw.GetNetwork(channelName).GetContract(chaincodeName)
+	proposal, err := contract.NewProposal(txName, proposalOptions(args, transient, endorsingOrgs)...)
+	if err != nil {
+		return "", err
+	}
+
+	var transaction *client.Transaction
+	var commit *client.Commit
+	err = policy.Attempt(txName, func(ctx context.Context) error {
+		return txqueue.Do(ctx, class, func() error {
+			var err error
+			transaction, err = proposal.EndorseWithContext(ctx)
+			if err != nil {
+				return err
+			}
+			commit, err = transaction.SubmitWithContext(ctx)
+			return err
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	txID := commit.TransactionID()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), asyncCommitTimeout)
+		defer cancel()
+		status, err := commit.StatusWithContext(ctx)
+		if err != nil {
+			done(txID, nil, err)
+			return
+		}
+		if !status.Successful {
+			done(txID, nil, &client.CommitError{
+				TransactionID: status.TransactionID,
+				Code:          status.Code,
+			})
+			return
+		}
+		done(txID, &SubmitResult{
+			Result:      transaction.Result(),
+			TxID:        status.TransactionID,
+			BlockNumber: status.BlockNumber,
+		}, nil)
+	}()
+	return txID, nil
+}
 
-	// Invoke transaction
-	if transientArgs != nil && len(endorsingOrgs) > 0 {
-		return contract.Submit(txName,
-			client.WithArguments(args...),
-			client.WithTransient(transientMap),
-			client.WithEndorsingOrganizations(endorsingOrgs...),
-		)
+// proposalOptions returns the options of the proposal of a transaction
+func proposalOptions(args []string, transient map[string][]byte, endorsingOrgs []string) []client.ProposalOption {
+	options := []client.ProposalOption{
+		client.WithArguments(args...),
 	}
 
-	if transientArgs != nil {
-		return contract.Submit(txName,
-			client.WithArguments(args...),
-			client.WithTransient(transientMap),
-		)
+	if len(transient) > 0 {
+		options = append(options, client.WithTransient(transient))
 	}
 
 	if len(endorsingOrgs) > 0 {
-		return contract.Submit(txName,
-			client.WithArguments(args...),
-			client.WithEndorsingOrganizations(endorsingOrgs...),
-		)
+		options = append(options, client.WithEndorsingOrganizations(endorsingOrgs...))
+	}
+	return options
+}
+
+// submitProposal endorses the proposal, submits the transaction and waits
+// for its commit
+func submitProposal(ctx context.Context, proposal *client.Proposal) (*SubmitResult, error) {
+	transaction, err := proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := transaction.SubmitWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for commit
+	status, err := commit.StatusWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !status.Successful {
+		return nil, &client.CommitError{
+			TransactionID: status.TransactionID,
+			Code:          status.Code,
+		}
 	}
 
-	return contract.SubmitTransaction(txName, args...)
+	return &SubmitResult{
+		Result:      transaction.Result(),
+		TxID:        status.TransactionID,
+		BlockNumber: status.BlockNumber,
+	}, nil
 }
@@ -0,0 +1,262 @@
+package chaincode
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/blockdecode"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// defaultMetadataTTL is how long metadata is cached when METADATA_CACHE_TTL
+// is not set
+const defaultMetadataTTL = 5 * time.Minute
+
+// lifecycleNamespace is the namespace of the chaincode lifecycle, written
+// when a chaincode definition is committed to a channel
+const lifecycleNamespace = "_lifecycle"
+
+// metadataTxs are the transactions of cc-tools that describe the chaincode,
+// whose results only change when the chaincode is upgraded and are the same
+// for every identity
+var metadataTxs = map[string]bool{
+	"getHeader":    true,
+	"getSchema":    true,
+	"getDataTypes": true,
+	"getTx":        true,
+}
+
+// metadataEntry is a cached metadata result
+type metadataEntry struct {
+	channel   string
+	chaincode string
+	result    []byte
+	expiresAt time.Time
+}
+
+var (
+	// metadataMutex guards the cached metadata, by evaluation key, the
+	// versions of the chaincodes last seen in their headers, the number of
+	// times each chaincode was invalidated and the invalidation hooks
+	metadataMutex       sync.Mutex
+	metadataCache       = make(map[string]*metadataEntry)
+	metadataVersions    = make(map[string]string)
+	metadataGenerations = make(map[string]int)
+	upgradeHooks        []func(channelName, chaincodeName string)
+)
+
+// metadataTTL returns how long metadata is cached, from METADATA_CACHE_TTL.
+// Zero disables the cache.
+func metadataTTL() time.Duration {
+	value := os.Getenv("METADATA_CACHE_TTL")
+	if value == "" {
+		return defaultMetadataTTL
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("invalid METADATA_CACHE_TTL %q, using %s\n", value, defaultMetadataTTL)
+		return defaultMetadataTTL
+	}
+	return d
+}
+
+// MetadataCacheEnabled reports whether chaincode metadata is cached, unless
+// METADATA_CACHE_TTL is zero
+func MetadataCacheEnabled() bool {
+	return metadataTTL() > 0
+}
+
+// isMetadata reports whether the transaction returns chaincode metadata
+// that is cached
+func isMetadata(txName string) bool {
+	return metadataTxs[txName] && MetadataCacheEnabled()
+}
+
+// OnUpgrade registers a hook called when the metadata of a chaincode is
+// invalidated, so state derived from it, such as generated definitions, is
+// rebuilt too
+func OnUpgrade(hook func(channelName, chaincodeName string)) {
+	metadataMutex.Lock()
+	defer metadataMutex.Unlock()
+
+	upgradeHooks = append(upgradeHooks, hook)
+}
+
+// InvalidateMetadata drops the cached metadata of the chaincode and calls
+// the upgrade hooks
+func InvalidateMetadata(channelName, chaincodeName string) {
+	metadataMutex.Lock()
+	for key, entry := range metadataCache {
+		if entry.channel == channelName && entry.chaincode == chaincodeName {
+			delete(metadataCache, key)
+		}
+	}
+	delete(metadataVersions, channelName+"/"+chaincodeName)
+	metadataGenerations[channelName+"/"+chaincodeName]++
+	hooks := append([]func(string, string){}, upgradeHooks...)
+	metadataMutex.Unlock()
+
+	log.Printf("metadata of %s on %s invalidated\n", chaincodeName, channelName)
+	for _, hook := range hooks {
+		hook(channelName, chaincodeName)
+	}
+}
+
+// cachedMetadata returns the metadata result cached under the key, or
+// evaluates it and caches it for the TTL. Results evaluated while the
+// chaincode was invalidated are not cached, since they may predate the
+// upgrade. Callers get their own copy.
+func cachedMetadata(key, channelName, chaincodeName, txName string, evaluate func() ([]byte, error)) ([]byte, error) {
+	metadataMutex.Lock()
+	entry, ok := metadataCache[key]
+	if ok && time.Now().Before(entry.expiresAt) {
+		metadataMutex.Unlock()
+		return append([]byte(nil), entry.result...), nil
+	}
+	generation := metadataGenerations[channelName+"/"+chaincodeName]
+	metadataMutex.Unlock()
+
+	result, err := evaluate()
+	if err != nil {
+		return nil, err
+	}
+
+	// A header with another version means the chaincode was upgraded since
+	// the metadata was cached
+	if txName == "getHeader" && versionChanged(channelName, chaincodeName, result) {
+		InvalidateMetadata(channelName, chaincodeName)
+		recordVersion(channelName, chaincodeName, result)
+		generation++
+	}
+
+	metadataMutex.Lock()
+	if metadataGenerations[channelName+"/"+chaincodeName] == generation {
+		metadataCache[key] = &metadataEntry{
+			channel:   channelName,
+			chaincode: chaincodeName,
+			result:    result,
+			expiresAt: time.Now().Add(metadataTTL()),
+		}
+	}
+	metadataMutex.Unlock()
+
+	return append([]byte(nil), result...), nil
+}
+
+// headerVersion returns the version in the header of the chaincode
+func headerVersion(header []byte) string {
+	var h struct {
+		Version string `json:"version"`
+	}
+	json.Unmarshal(header, &h)
+	return h.Version
+}
+
+// versionChanged records the version in the header of the chaincode,
+// reporting whether another version was seen before
+func versionChanged(channelName, chaincodeName string, header []byte) bool {
+	version := headerVersion(header)
+	if version == "" {
+		return false
+	}
+
+	metadataMutex.Lock()
+	defer metadataMutex.Unlock()
+
+	key := channelName + "/" + chaincodeName
+	previous, ok := metadataVersions[key]
+	metadataVersions[key] = version
+	return ok && previous != version
+}
+
+// recordVersion records the version in the header of the chaincode, after
+// the versions were reset by an invalidation
+func recordVersion(channelName, chaincodeName string, header []byte) {
+	if version := headerVersion(header); version != "" {
+		metadataMutex.Lock()
+		metadataVersions[channelName+"/"+chaincodeName] = version
+		metadataMutex.Unlock()
+	}
+}
+
+// WatchUpgrades invalidates the cached metadata of the chaincodes whose
+// definitions are committed on the channel, following its blocks from the
+// newest one. The stream is opened again whenever it is closed.
+func WatchUpgrades(channelName string) {
+	backoff := resubscribeMinBackoff
+	for {
+		received, err := watchBlocks(channelName)
+		if err != nil {
+			log.Printf("error watching chaincode upgrades on %s: %s\n", channelName, err)
+		}
+		if received {
+			backoff = resubscribeMinBackoff
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > resubscribeMaxBackoff {
+			backoff = resubscribeMaxBackoff
+		}
+	}
+}
+
+// watchBlocks reads the blocks of the channel until the stream is closed,
+// reporting whether any block was received
+func watchBlocks(channelName string) (bool, error) {
+	gw, err := common.GetGateway(os.Getenv("USER"))
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks, err := gw.GetNetwork(channelName).BlockEvents(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	received := false
+	for b := range blocks {
+		received = true
+		block, err := blockdecode.FromBlock(b)
+		if err != nil {
+			log.Printf("error decoding block %d of %s: %s\n", b.GetHeader().GetNumber(), channelName, err)
+			continue
+		}
+		for _, chaincodeName := range upgradedChaincodes(block) {
+			InvalidateMetadata(channelName, chaincodeName)
+		}
+	}
+	return received, nil
+}
+
+// upgradedChaincodes returns the chaincodes whose definitions were committed
+// by the valid transactions of the block, found by their writes to the
+// lifecycle namespace, under namespaces/fields/{chaincode}/Sequence
+func upgradedChaincodes(block *blockdecode.Block) []string {
+	names := make([]string, 0)
+	for _, tx := range block.Transactions {
+		if !tx.Valid {
+			continue
+		}
+		for _, rwset := range tx.RWSets {
+			if rwset.Namespace != lifecycleNamespace {
+				continue
+			}
+			for _, write := range rwset.Writes {
+				name := strings.TrimPrefix(write.Key, "namespaces/fields/")
+				if name != write.Key && strings.HasSuffix(name, "/Sequence") {
+					names = append(names, strings.TrimSuffix(name, "/Sequence"))
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,277 @@
+package chaincode
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/blockdecode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger-labs/ccapi/policy"
+	"github.com/hyperledger-labs/ccapi/state"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// defaultPreparedTTL is how long prepared transactions wait to be submitted,
+// and submitted ones keep their outcome for duplicate submissions
+const defaultPreparedTTL = 24 * time.Hour
+
+// prepared is a transaction whose id was given to the client before its
+// submission
+type prepared struct {
+	channelName string
+	txName      string
+	user        string
+	proposal    *client.Proposal
+	expiresAt   time.Time
+
+	// submitted is set by the first submission, done is closed once it
+	// completes with the result or error
+	submitted bool
+	done      chan struct{}
+	result    *SubmitResult
+	err       error
+}
+
+var (
+	// preparedMutex guards the prepared transactions, by transaction id
+	preparedMutex sync.Mutex
+	preparedTxs   = make(map[string]*prepared)
+)
+
+// sharedPrepared is a prepared transaction kept in the shared state, so
+// the replicas that did not prepare it can submit it
+type sharedPrepared struct {
+	TxName    string    `json:"txName"`
+	User      string    `json:"user"`
+	Proposal  []byte    `json:"proposal"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func preparedKey(txID string) string {
+	return "prepared:" + txID
+}
+
+// getPreparedTTL returns how long prepared transactions are kept, from
+// PREPARED_TX_TTL
+func getPreparedTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("PREPARED_TX_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return defaultPreparedTTL
+}
+
+// purgePrepared drops the expired prepared transactions. The caller must
+// hold the mutex.
+func purgePrepared(now time.Time) {
+	for txID, p := range preparedTxs {
+		if now.After(p.expiresAt) {
+			delete(preparedTxs, txID)
+		}
+	}
+}
+
+// PrepareGateway builds and signs the proposal of a transaction without
+// submitting it, returning its transaction id and until when it can be
+// submitted with SubmitPrepared
+func PrepareGateway(channelName, chaincodeName, txName, user string, args []string, transient map[string][]byte, endorsingOrgs []string) (string, time.Time, error) {
+	if mock.Enabled() {
+		return "", time.Time{}, common.NewAPIError(http.StatusNotImplemented, "prepared transactions require a Fabric network")
+	}
+
+	args = canonicalArgs(args)
+	transient = canonicalTransient(transient)
+
+	// Get the gateway session of the user, shared with other requests
+	gw, err := common.GetGateway(user)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	contract := gw.GetNetwork(channelName).GetContract(chaincodeName)
+
+	proposal, err := contract.NewProposal(txName, proposalOptions(args, transient, endorsingOrgs)...)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	p := &prepared{
+		channelName: channelName,
+		txName:      txName,
+		user:        user,
+		proposal:    proposal,
+		expiresAt:   now.Add(getPreparedTTL()),
+		done:        make(chan struct{}),
+	}
+
+	if state.Shared() {
+		proposalBytes, err := proposal.Bytes()
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		data, _ := json.Marshal(sharedPrepared{
+			TxName:    txName,
+			User:      user,
+			Proposal:  proposalBytes,
+			ExpiresAt: p.expiresAt,
+		})
+		err = state.Default().Set(preparedKey(proposal.TransactionID()), data, getPreparedTTL())
+		if err != nil {
+			return "", time.Time{}, err
+		}
+	}
+
+	preparedMutex.Lock()
+	purgePrepared(now)
+	preparedTxs[proposal.TransactionID()] = p
+	preparedMutex.Unlock()
+
+	return proposal.TransactionID(), p.expiresAt, nil
+}
+
+// SubmitPrepared submits the prepared transaction with the id, as the user
+// who prepared it, and waits for its commit. The transaction is submitted
+// once: later submissions of the id wait for the first one and get its
+// outcome, reporting they are duplicates. Transactions no longer kept, such
+// as those prepared before a restart, are looked up in the ledger.
+//
+// Replicas sharing their state submit the transactions prepared by the
+// others, and the first submission claims the transaction for all of them.
+func SubmitPrepared(txID, user string) (*SubmitResult, bool, error) {
+	preparedMutex.Lock()
+	purgePrepared(time.Now())
+	p, ok := preparedTxs[txID]
+	if !ok {
+		preparedMutex.Unlock()
+		if state.Shared() {
+			return submitShared(txID, user)
+		}
+		return committedResult(os.Getenv("CHANNEL"), txID, user)
+	}
+	if p.user != user {
+		preparedMutex.Unlock()
+		return nil, false, common.NewAPIError(http.StatusForbidden, "the transaction was prepared by another user")
+	}
+	if p.submitted {
+		preparedMutex.Unlock()
+		<-p.done
+		return p.result, true, p.err
+	}
+	p.submitted = true
+	preparedMutex.Unlock()
+
+	defer close(p.done)
+	if state.Shared() {
+		if p.err = claimPrepared(txID); p.err != nil {
+			p.result, _, p.err = duplicateResult(txID, user, p.err)
+			return p.result, true, p.err
+		}
+	}
+	p.err = policy.Attempt(p.txName, func(ctx context.Context) error {
+		return txqueue.Do(ctx, txqueue.Interactive, func() error {
+			var err error
+			p.result, err = submitProposal(ctx, p.proposal)
+			return err
+		})
+	})
+	return p.result, false, p.err
+}
+
+// submitShared submits the transaction prepared by another replica, unless
+// it was already submitted
+func submitShared(txID, user string) (*SubmitResult, bool, error) {
+	data, ok, err := state.Default().Get(preparedKey(txID))
+	if err != nil {
+		return nil, false, err
+	}
+	var sp sharedPrepared
+	if !ok || json.Unmarshal(data, &sp) != nil {
+		return committedResult(os.Getenv("CHANNEL"), txID, user)
+	}
+	if sp.User != user {
+		return nil, false, common.NewAPIError(http.StatusForbidden, "the transaction was prepared by another user")
+	}
+	if err := claimPrepared(txID); err != nil {
+		return duplicateResult(txID, user, err)
+	}
+
+	gw, err := common.GetGateway(user)
+	if err != nil {
+		return nil, false, err
+	}
+	proposal, err := gw.NewProposal(sp.Proposal)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var res *SubmitResult
+	err = policy.Attempt(sp.TxName, func(ctx context.Context) error {
+		return txqueue.Do(ctx, txqueue.Interactive, func() error {
+			var err error
+			res, err = submitProposal(ctx, proposal)
+			return err
+		})
+	})
+	return res, false, err
+}
+
+// claimPrepared claims the submission of the transaction for the replica,
+// failing if another replica claimed it
+func claimPrepared(txID string) error {
+	claimed, err := state.Default().SetNX(preparedKey(txID)+":submitted", []byte(txID), getPreparedTTL())
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return common.NewAPIError(http.StatusConflict, "the transaction is being submitted by another request")
+	}
+	return nil
+}
+
+// duplicateResult returns the outcome of a transaction submitted by another
+// replica from the ledger, or the error of the claim while it is not
+// committed
+func duplicateResult(txID, user string, claimErr error) (*SubmitResult, bool, error) {
+	res, duplicate, err := committedResult(os.Getenv("CHANNEL"), txID, user)
+	if apiErr, ok := err.(*common.APIError); ok && apiErr.Status == http.StatusNotFound {
+		return nil, true, claimErr
+	}
+	return res, duplicate, err
+}
+
+// committedResult returns the outcome of a transaction of the channel from
+// the ledger, as a duplicate submission
+func committedResult(channelName, txID, user string) (*SubmitResult, bool, error) {
+	result, err := QueryGateway(channelName, "qscc", "GetBlockByTxID", user, []string{channelName, txID})
+	if err != nil {
+		return nil, false, common.NewAPIError(http.StatusNotFound, "unknown or expired prepared transaction")
+	}
+
+	block, err := blockdecode.DecodeBlock(result)
+	if err != nil {
+		return nil, false, err
+	}
+	tx := block.Find(txID)
+	if tx == nil {
+		return nil, false, common.NewAPIError(http.StatusNotFound, "unknown or expired prepared transaction")
+	}
+
+	if !tx.Valid {
+		return nil, true, &client.CommitError{
+			TransactionID: txID,
+			Code:          peer.TxValidationCode(peer.TxValidationCode_value[tx.ValidationCode]),
+		}
+	}
+
+	res := &SubmitResult{TxID: txID, BlockNumber: tx.BlockNumber}
+	if tx.Response != nil {
+		res.Result = tx.Response.Payload
+	}
+	return res, true, nil
+}
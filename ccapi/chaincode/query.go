@@ -2,16 +2,32 @@ package chaincode
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 )
 
 func Query(channelName, ccName, txName, user string, txArgs [][]byte) (*channel.Response, int, error) {
+	txArgs = canonicalByteArgs(txArgs)
+
+	if mock.Enabled() {
+		msp, err := mockMSPID(user)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		result, err := mock.Evaluate(msp, txName, bytesToStrings(txArgs))
+		return mockResponse(result, "", 0, err)
+	}
+
+	user, org, err := sdkIdentity(user)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
 	// create channel manager
-	fabMngr, err := common.NewFabricChClient(channelName, user, os.Getenv("ORG"))
+	fabMngr, err := common.NewFabricChClient(channelName, user, org)
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
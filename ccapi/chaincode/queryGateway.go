@@ -1,38 +1,109 @@
 package chaincode
 
 import (
-	"os"
+	"context"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/hyperledger-labs/ccapi/common"
-	"github.com/pkg/errors"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger-labs/ccapi/policy"
+	"github.com/hyperledger-labs/ccapi/rbac"
+	"github.com/hyperledger-labs/ccapi/slowquery"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
 )
 
+// QueryGatewayFor evaluates a transaction as the user of the request, once
+// the roles of the user allow it. Every evaluation made for a request goes
+// through it, so the routes reading the ledger on their own, such as the
+// diffs and snapshots of assets or the blocks of qscc, are restricted like
+// the transaction routes.
+func QueryGatewayFor(c *gin.Context, channelName, chaincodeName, txName string, args []string) ([]byte, error) {
+	err := rbac.Check(c, RoleTransaction(chaincodeName, txName))
+	if err != nil {
+		return nil, err
+	}
+	return QueryGateway(channelName, chaincodeName, txName, common.GetUser(c), args)
+}
+
+// RoleTransaction returns the name roles grant the transaction by. The
+// functions of qscc are prefixed, as in qscc.GetBlockByNumber, so they are
+// not mistaken for transactions of the chaincode.
+func RoleTransaction(chaincodeName, txName string) string {
+	if chaincodeName == "qscc" {
+		return chaincodeName + "." + txName
+	}
+	return txName
+}
+
+// QueryGateway evaluates a transaction, following the timeout and retries
+// of its policy. It does not check the roles of the user, so it is only
+// called directly for reads of the CCAPI itself, such as its schedulers;
+// requests use QueryGatewayFor. Identical evaluations made at the same time by the same
+// user are coalesced into a single gateway call, whose result they share.
+// Chaincode metadata, which is the same for every user, is cached until
+// it expires or the chaincode is upgraded.
 func QueryGateway(channelName, chaincodeName, txName, user string, args []string) ([]byte, error) {
-	// Gateway endpoint
-	endpoint := os.Getenv("FABRIC_GATEWAY_ENDPOINT")
+	args = canonicalArgs(args)
+
+	if isMetadata(txName) {
+		key := evaluationKey(channelName, chaincodeName, txName, "", args)
+		return cachedMetadata(key, channelName, chaincodeName, txName, func() ([]byte, error) {
+			return coalesce(key, func() ([]byte, error) {
+				return evaluateGateway(channelName, chaincodeName, txName, user, args)
+			})
+		})
+	}
 
-	// Create client grpc connection
-	grpcConn, err := common.CreateGrpcConnection(endpoint)
+	if !coalescingEnabled() {
+		return evaluateGateway(channelName, chaincodeName, txName, user, args)
+	}
+	key := evaluationKey(channelName, chaincodeName, txName, user, args)
+	return coalesce(key, func() ([]byte, error) {
+		return evaluateGateway(channelName, chaincodeName, txName, user, args)
+	})
+}
+
+// evaluateGateway evaluates the transaction following its policy. The
+// durations of rich queries are tracked by the shape of their selector.
+func evaluateGateway(channelName, chaincodeName, txName, user string, args []string) ([]byte, error) {
+	start := time.Now()
+	var res []byte
+	err := policy.Do(txName, func(ctx context.Context) error {
+		var err error
+		res, err = queryGateway(ctx, channelName, chaincodeName, txName, user, args)
+		return err
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create grpc connection")
+		return nil, err
 	}
-	defer grpcConn.Close()
+	if txName == "search" {
+		slowquery.Record(channelName, chaincodeName, args, res, time.Since(start))
+	}
+	return res, nil
+}
 
-	// Create gateway connection
-	gw, err := common.CreateGatewayConnection(grpcConn, user)
+// queryGateway makes one attempt to evaluate the transaction
+func queryGateway(ctx context.Context, channelName, chaincodeName, txName, user string, args []string) ([]byte, error) {
+	if mock.Enabled() {
+		msp, err := mockMSPID(user)
+		if err != nil {
+			return nil, err
+		}
+		return mock.Evaluate(msp, txName, args)
+	}
+
+	// Get the gateway session of the user for evaluations, shared with
+	// other requests
+	gw, err := common.GetQueryGateway(user)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create gateway connection")
+		return nil, err
 	}
-	defer gw.Close()
 
 	// Obtain smart contract deployed on the network.
 	network := gw.GetNetwork(channelName)
 	contract := network.GetContract(chaincodeName)
 
 	// Query transaction
-	if len(args) == 0 {
-		return contract.EvaluateTransaction(txName)
-	}
-
-	return contract.EvaluateTransaction(txName, args...)
+	return contract.EvaluateWithContext(ctx, txName, client.WithArguments(args...))
 }
@@ -0,0 +1,85 @@
+package chaincode
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	protos "github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// replayIdleTimeout is how long a replay waits for the next event before
+// assuming there are no more events in its range
+var replayIdleTimeout = 5 * time.Second
+
+// ChainHeight returns the number of blocks of the channel
+func ChainHeight(channelName, user string) (uint64, error) {
+	result, err := QueryGateway(channelName, "qscc", "GetChainInfo", user, []string{channelName})
+	if err != nil {
+		return 0, err
+	}
+
+	var chainInfo protos.BlockchainInfo
+	err = proto.Unmarshal(result, &chainInfo)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to unmarshal chain info")
+	}
+	return chainInfo.Height, nil
+}
+
+// ReplayEvents executes fn on the chaincode events committed from block
+// from to block to, inclusive, in ledger order. The events are read with the
+// start block option of the gateway, so they are delivered again even if
+// they were processed before. It stops on the first error returned by fn,
+// when the context is done, or when no event arrives for a while, since the
+// event stream never ends by itself.
+func ReplayEvents(ctx context.Context, channelName, ccName, user string, from, to uint64, fn func(*client.ChaincodeEvent) error) error {
+	if mock.Enabled() {
+		return errors.New("event replay requires a Fabric network")
+	}
+
+	// Get the gateway session of the user, shared with other requests
+	gw, err := common.GetGateway(user)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	network := gw.GetNetwork(channelName)
+	events, err := network.ChaincodeEvents(ctx, ccName, client.WithStartBlock(from))
+	if err != nil {
+		return errors.Wrap(err, "failed to read chaincode events")
+	}
+
+	idle := time.NewTimer(replayIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return ctx.Err()
+			}
+			if event.BlockNumber > to {
+				return nil
+			}
+			err = fn(event)
+			if err != nil {
+				return err
+			}
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(replayIdleTimeout)
+		case <-idle.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
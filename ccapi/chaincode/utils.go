@@ -1,10 +1,19 @@
 package chaincode
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
+	"os"
 	"regexp"
 	"strconv"
+
+	"github.com/hyperledger-labs/ccapi/canonical"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
 )
 
 func extractStatusCode(msg string) int {
@@ -24,3 +33,103 @@ func extractStatusCode(msg string) int {
 
 	return statusCode
 }
+
+// canonicalArgs serializes the JSON arguments in canonical form, so the
+// same request always produces the same proposal, whichever client built
+// it. Other arguments are left as they are.
+func canonicalArgs(args []string) []string {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		res[i] = string(canonicalBytes([]byte(arg)))
+	}
+	return res
+}
+
+func canonicalByteArgs(args [][]byte) [][]byte {
+	res := make([][]byte, len(args))
+	for i, arg := range args {
+		res[i] = canonicalBytes(arg)
+	}
+	return res
+}
+
+func canonicalBytes(arg []byte) []byte {
+	trimmed := bytes.TrimSpace(arg)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return arg
+	}
+
+	data, err := canonical.Transform(trimmed)
+	if err != nil {
+		return arg
+	}
+	return data
+}
+
+// TransientKey is the transient data key of the request cc-tools reads the
+// transient arguments of a transaction from
+const TransientKey = "@request"
+
+// canonicalTransient returns a copy of the transient map with the request
+// in canonical form. Other keys are passed as they are, since they may
+// hold binary data.
+func canonicalTransient(transient map[string][]byte) map[string][]byte {
+	if transient == nil {
+		return nil
+	}
+
+	res := make(map[string][]byte, len(transient))
+	for key, value := range transient {
+		if key == TransientKey {
+			value = canonicalBytes(value)
+		}
+		res[key] = value
+	}
+	return res
+}
+
+func bytesToStrings(args [][]byte) []string {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = string(arg)
+	}
+	return strs
+}
+
+// mockResponse adapts a mock ledger result to the fabric sdk client response
+func mockResponse(result []byte, txID string, _ uint64, err error) (*channel.Response, int, error) {
+	if err != nil {
+		if mockErr, ok := err.(*mock.Error); ok {
+			return nil, mockErr.Status, errors.New(mockErr.Message)
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return &channel.Response{
+		Payload:       result,
+		TransactionID: fab.TransactionID(txID),
+	}, http.StatusOK, nil
+}
+
+// mockMSPID returns the MSP the identity acts as on the mock ledger, empty
+// for users without org profile
+func mockMSPID(identity string) (string, error) {
+	_, org, err := common.SplitIdentity(identity)
+	if err != nil || org == nil {
+		return "", err
+	}
+	return org.MSPID, nil
+}
+
+// sdkIdentity returns the user and organization of the identity for the
+// fabric sdk, which must have the organization in its configuration
+func sdkIdentity(identity string) (string, string, error) {
+	user, org, err := common.SplitIdentity(identity)
+	if err != nil {
+		return "", "", err
+	}
+	if org == nil {
+		return user, os.Getenv("ORG"), nil
+	}
+	return user, org.Name, nil
+}
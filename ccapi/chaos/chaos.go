@@ -0,0 +1,112 @@
+package chaos
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// config holds the fault injection settings, read from environment:
+//
+//	CHAOS_ENABLED=true     enables fault injection
+//	CHAOS_LATENCY=200ms    fixed latency added to every request
+//	CHAOS_JITTER=300ms     random latency added on top of CHAOS_LATENCY
+//	CHAOS_DROP_RATE=0.05   probability of dropping the client connection
+//	CHAOS_MVCC_RATE=0.1    probability of failing a submit with an MVCC conflict
+type config struct {
+	enabled  bool
+	latency  time.Duration
+	jitter   time.Duration
+	dropRate float64
+	mvccRate float64
+}
+
+var (
+	cfg     config
+	cfgOnce sync.Once
+)
+
+func getConfig() config {
+	cfgOnce.Do(func() {
+		cfg = config{
+			enabled:  os.Getenv("CHAOS_ENABLED") == "true",
+			latency:  parseDuration("CHAOS_LATENCY"),
+			jitter:   parseDuration("CHAOS_JITTER"),
+			dropRate: parseRate("CHAOS_DROP_RATE"),
+			mvccRate: parseRate("CHAOS_MVCC_RATE"),
+		}
+		if cfg.enabled {
+			log.Printf("chaos enabled: latency %s, jitter %s, drop rate %.2f, mvcc rate %.2f\n",
+				cfg.latency, cfg.jitter, cfg.dropRate, cfg.mvccRate)
+		}
+	})
+	return cfg
+}
+
+func parseDuration(env string) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(env))
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+func parseRate(env string) float64 {
+	r, err := strconv.ParseFloat(os.Getenv(env), 64)
+	if err != nil || r < 0 {
+		return 0
+	}
+	return r
+}
+
+// Middleware delays requests and randomly drops client connections.
+// It does nothing unless CHAOS_ENABLED is set.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := getConfig()
+		if !cfg.enabled {
+			c.Next()
+			return
+		}
+
+		delay := cfg.latency
+		if cfg.jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(cfg.jitter)))
+		}
+		time.Sleep(delay)
+
+		if rand.Float64() < cfg.dropRate {
+			conn, _, err := c.Writer.Hijack()
+			if err == nil {
+				log.Println("chaos: dropping connection for ", c.Request.URL.Path)
+				conn.Close()
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// MVCCConflict randomly returns the commit error produced by a read
+// conflict, to be returned by gateway submits in place of the real call
+func MVCCConflict() error {
+	cfg := getConfig()
+	if !cfg.enabled || rand.Float64() >= cfg.mvccRate {
+		return nil
+	}
+
+	log.Println("chaos: forcing MVCC read conflict")
+	return &client.CommitError{
+		TransactionID: "chaos",
+		Code:          peer.TxValidationCode_MVCC_READ_CONFLICT,
+	}
+}
@@ -0,0 +1,281 @@
+// Package checkpoint keeps the position in the ledger of each event consumer
+// of the CCAPI, so that after a restart consumers resume from the block of
+// the last event they processed instead of from the newest block.
+//
+// Events are delivered again from the checkpointed block, so the
+// transactions of that block already processed are kept along with it and
+// skipped. Handlers submitting a transaction record its signed proposal
+// with Begin before submitting it, so that after a crash the same
+// transaction, and not a new one, is submitted again for the event.
+// Checkpoints are written, atomically, right after each event is
+// processed, to the EVENT_CHECKPOINT_PATH file (default checkpoints.json),
+// or, when EVENT_CHECKPOINT_PLUGIN names a storage plugin, to its
+// "checkpoints" key. Replicas sharing their state keep them in the shared
+// state instead, where they are read again before each use.
+package checkpoint
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/plugins"
+	"github.com/hyperledger-labs/ccapi/state"
+	"github.com/pkg/errors"
+)
+
+// storeKey is the key of the checkpoints in a storage plugin or the shared
+// state
+const storeKey = "checkpoints"
+
+// Checkpoint is the position of a consumer in the ledger
+type Checkpoint struct {
+	Consumer    string    `json:"consumer"`
+	BlockNumber uint64    `json:"blockNumber"`
+	TxIDs       []string  `json:"txIds"`
+	Pending     *Pending  `json:"pending,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Pending is an event of the block whose handling started and was not
+// committed, with the proposal of the transaction its handler submits
+type Pending struct {
+	TxID     string `json:"txId"`
+	Proposal []byte `json:"proposal"`
+}
+
+var (
+	mutex       sync.Mutex
+	checkpoints map[string]*Checkpoint
+	loaded      bool
+)
+
+// getPath returns the file checkpoints are kept in
+func getPath() string {
+	if path := os.Getenv("EVENT_CHECKPOINT_PATH"); path != "" {
+		return path
+	}
+	return "checkpoints.json"
+}
+
+// load reads the checkpoint file once, or the shared state every time, as
+// other replicas change it. The caller must hold the mutex.
+func load() {
+	if loaded && !state.Shared() {
+		return
+	}
+
+	data, err := read()
+	if err != nil {
+		log.Println("error reading event checkpoints: ", err)
+		// Keep the checkpoints read before, so they are not overwritten
+		if !loaded {
+			checkpoints = make(map[string]*Checkpoint)
+			loaded = true
+		}
+		return
+	}
+	loaded = true
+
+	checkpoints = make(map[string]*Checkpoint)
+	if data == nil {
+		return
+	}
+	err = json.Unmarshal(data, &checkpoints)
+	if err != nil {
+		log.Println("error unmarshalling event checkpoints: ", err)
+		checkpoints = make(map[string]*Checkpoint)
+	}
+}
+
+// read returns the checkpoints kept, or nil if none are
+func read() ([]byte, error) {
+	if name := os.Getenv("EVENT_CHECKPOINT_PLUGIN"); name != "" {
+		storage, err := plugins.Lookup(name, plugins.KindStorage)
+		if err != nil {
+			return nil, err
+		}
+		data, _, err := storage.Get(storeKey)
+		return data, err
+	}
+	if state.Shared() {
+		data, _, err := state.Default().Get(storeKey)
+		return data, err
+	}
+
+	data, err := os.ReadFile(getPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// save writes the checkpoint file, replacing it only once fully written so
+// a crash never leaves it truncated. The caller must hold the mutex.
+func save() error {
+	data, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal event checkpoints")
+	}
+
+	if name := os.Getenv("EVENT_CHECKPOINT_PLUGIN"); name != "" {
+		storage, err := plugins.Lookup(name, plugins.KindStorage)
+		if err == nil {
+			err = storage.Put(storeKey, data)
+		}
+		return errors.Wrap(err, "failed to write event checkpoints")
+	}
+	if state.Shared() {
+		return errors.Wrap(state.Default().Set(storeKey, data, 0), "failed to write event checkpoints")
+	}
+
+	path := getPath()
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".checkpoints-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to write event checkpoints")
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write event checkpoints")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), path), "failed to write event checkpoints")
+}
+
+// Get returns the checkpoint of the consumer, if any
+func Get(consumer string) (Checkpoint, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	cp, ok := checkpoints[consumer]
+	if !ok {
+		return Checkpoint{}, false
+	}
+	return *cp, true
+}
+
+// Processed reports whether the consumer already processed the event of the
+// transaction in the block
+func Processed(consumer string, blockNumber uint64, txID string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	cp, ok := checkpoints[consumer]
+	if !ok || blockNumber > cp.BlockNumber {
+		return false
+	}
+	if blockNumber < cp.BlockNumber {
+		return true
+	}
+	for _, id := range cp.TxIDs {
+		if id == txID {
+			return true
+		}
+	}
+	return false
+}
+
+// position returns the checkpoint of the consumer, moved to the block if
+// the block follows it. The caller must hold the mutex.
+func position(consumer string, blockNumber uint64) *Checkpoint {
+	cp, ok := checkpoints[consumer]
+	if !ok || blockNumber > cp.BlockNumber {
+		cp = &Checkpoint{
+			Consumer:    consumer,
+			BlockNumber: blockNumber,
+			TxIDs:       make([]string, 0),
+		}
+		checkpoints[consumer] = cp
+	}
+	return cp
+}
+
+// Begin records the signed proposal of the transaction the consumer is
+// about to submit to handle the event of the transaction in the block
+func Begin(consumer string, blockNumber uint64, txID string, proposal []byte) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	cp := position(consumer, blockNumber)
+	cp.Pending = &Pending{
+		TxID:     txID,
+		Proposal: proposal,
+	}
+	cp.UpdatedAt = time.Now().UTC()
+	return save()
+}
+
+// PendingProposal returns the proposal recorded by Begin for the event of
+// the transaction, if the consumer did not commit the event since
+func PendingProposal(consumer, txID string) ([]byte, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	cp, ok := checkpoints[consumer]
+	if !ok || cp.Pending == nil || cp.Pending.TxID != txID {
+		return nil, false
+	}
+	return cp.Pending.Proposal, true
+}
+
+// Commit records that the consumer processed the event of the transaction
+// in the block
+func Commit(consumer string, blockNumber uint64, txID string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	cp := position(consumer, blockNumber)
+	cp.TxIDs = append(cp.TxIDs, txID)
+	cp.Pending = nil
+	cp.UpdatedAt = time.Now().UTC()
+	return save()
+}
+
+// Set moves the consumer to the block, so it processes again the events
+// from that block on after a restart
+func Set(consumer string, blockNumber uint64) (Checkpoint, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	cp := &Checkpoint{
+		Consumer:    consumer,
+		BlockNumber: blockNumber,
+		TxIDs:       make([]string, 0),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	checkpoints[consumer] = cp
+	return *cp, save()
+}
+
+// List returns the checkpoints of every consumer, sorted by consumer
+func List() []Checkpoint {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	res := make([]Checkpoint, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		res = append(res, *cp)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Consumer < res[j].Consumer
+	})
+	return res
+}
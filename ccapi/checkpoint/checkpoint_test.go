@@ -0,0 +1,56 @@
+package checkpoint
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPendingEvents(t *testing.T) {
+	t.Setenv("EVENT_CHECKPOINT_PATH", filepath.Join(t.TempDir(), "checkpoints.json"))
+	consumer := "mainchannel/cc-tools-demo/bookBorrowedLog"
+
+	err := Commit(consumer, 10, "tx1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The proposal of an event being handled is kept until it is committed
+	err = Begin(consumer, 10, "tx2", []byte("proposal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Processed(consumer, 10, "tx2") {
+		t.Fatal("expected tx2 to be delivered again")
+	}
+
+	// As if the CCAPI restarted
+	loaded = false
+	proposal, ok := PendingProposal(consumer, "tx2")
+	if !ok || string(proposal) != "proposal" {
+		t.Fatalf("expected the pending proposal, got %q", proposal)
+	}
+	if _, ok := PendingProposal(consumer, "tx3"); ok {
+		t.Fatal("expected no proposal for tx3")
+	}
+
+	err = Commit(consumer, 10, "tx2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := PendingProposal(consumer, "tx2"); ok {
+		t.Fatal("expected the proposal to be dropped once committed")
+	}
+	if !Processed(consumer, 10, "tx2") || !Processed(consumer, 9, "tx0") {
+		t.Fatal("expected tx2 and earlier blocks to be processed")
+	}
+
+	// Events of the next blocks move the checkpoint
+	err = Begin(consumer, 11, "tx4", []byte("proposal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, _ := Get(consumer)
+	if cp.BlockNumber != 11 || len(cp.TxIDs) != 0 || cp.Pending == nil {
+		t.Fatalf("unexpected checkpoint %+v", cp)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/contracttest"
+)
+
+// Gentests generates table-driven contract tests from the metadata of the
+// deployed chaincode. The generated tests call the ccapi at CCAPI_URL.
+//
+// Example:
+//
+//	go run ./cmd/gentests -out contracttests/contract_test.go -fixtures fixtures.json
+//	CCAPI_URL=http://localhost:80 go test ./contracttests
+func main() {
+	out := flag.String("out", "contracttests/contract_test.go", "Path of the generated test file")
+	fixturesPath := flag.String("fixtures", "", "JSON file mapping transaction names to valid request bodies")
+	includeMeta := flag.Bool("meta", false, "Also generate cases for cc-tools meta transactions")
+	exclude := flag.String("exclude", "", "Comma separated list of transactions to skip")
+	flag.Parse()
+
+	fixtures := contracttest.Fixtures{}
+	if *fixturesPath != "" {
+		b, err := os.ReadFile(*fixturesPath)
+		if err != nil {
+			log.Fatalln("error reading fixtures: ", err)
+		}
+		err = json.Unmarshal(b, &fixtures)
+		if err != nil {
+			log.Fatalln("error unmarshalling fixtures: ", err)
+		}
+	}
+
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "Admin"
+	}
+
+	txs, err := contracttest.FetchTransactions(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), user)
+	common.CloseSDK()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	excluded := make(map[string]bool)
+	for _, name := range strings.Split(*exclude, ",") {
+		excluded[name] = true
+	}
+
+	selected := make([]contracttest.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if excluded[tx.Tag] || (tx.MetaTx && !*includeMeta) {
+			continue
+		}
+		selected = append(selected, tx)
+	}
+
+	cases := contracttest.BuildCases(selected, fixtures)
+
+	pkg := filepath.Base(filepath.Dir(*out))
+	src, err := contracttest.Render(pkg, cases)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(*out), 0755)
+	if err == nil {
+		err = os.WriteFile(*out, src, 0644)
+	}
+	if err != nil {
+		log.Fatalln("error writing test file: ", err)
+	}
+
+	log.Printf("generated %d cases for %d transactions in %s\n", len(cases), len(selected), *out)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// Replay re-submits transactions recorded on the audit log against the network
+// configured by the environment (SDK_PATH, FABRIC_GATEWAY_ENDPOINT...).
+//
+// Example:
+//
+//	go run ./cmd/replay -log audit.log -tx createAsset,createNewLibrary -map txids.json
+func main() {
+	logPath := flag.String("log", os.Getenv("AUDIT_LOG_PATH"), "Path of the audit log")
+	txNames := flag.String("tx", "", "Comma separated list of transactions to replay (default all)")
+	from := flag.String("from", "", "Replay only entries recorded after this RFC3339 timestamp")
+	to := flag.String("to", "", "Replay only entries recorded before this RFC3339 timestamp")
+	includeFailed := flag.Bool("failed", false, "Also replay transactions that failed originally")
+	channel := flag.String("channel", "", "Channel to replay on (default as recorded)")
+	ccName := flag.String("chaincode", "", "Chaincode to replay on (default as recorded)")
+	user := flag.String("user", "", "User to submit as (default as recorded)")
+	dryRun := flag.Bool("dry-run", false, "Only print what would be replayed")
+	mapPath := flag.String("map", "", "File to write the old->new transaction id mapping to")
+	flag.Parse()
+
+	if *logPath == "" {
+		log.Fatalln("an audit log must be provided with -log or AUDIT_LOG_PATH")
+	}
+
+	filter := audit.Filter{
+		IncludeFailed: *includeFailed,
+	}
+	if *txNames != "" {
+		filter.TxNames = strings.Split(*txNames, ",")
+	}
+	if *from != "" {
+		t, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			log.Fatalln("invalid -from timestamp: ", err)
+		}
+		filter.From = t
+	}
+	if *to != "" {
+		t, err := time.Parse(time.RFC3339, *to)
+		if err != nil {
+			log.Fatalln("invalid -to timestamp: ", err)
+		}
+		filter.To = t
+	}
+
+	entries, err := audit.ReadFile(*logPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	defer common.CloseSDK()
+
+	txIDMap, err := audit.Replay(entries, filter, audit.ReplayOptions{
+		Channel:   *channel,
+		Chaincode: *ccName,
+		User:      *user,
+		DryRun:    *dryRun,
+	})
+	if *mapPath != "" {
+		b, merr := json.MarshalIndent(txIDMap, "", "  ")
+		if merr == nil {
+			merr = os.WriteFile(*mapPath, b, 0644)
+		}
+		if merr != nil {
+			log.Println("error writing transaction id mapping: ", merr)
+		}
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("replayed %d transactions\n", len(txIDMap))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/seed"
+)
+
+// Seed loads a deterministic dataset on the ledger and reports how long it took.
+// It uses the same environment variables as the ccapi (SDK_PATH, CHANNEL, CCNAME...).
+//
+// Example:
+//
+//	go run ./cmd/seed -seed 42 -people 100 -books 500 -libraries 10 -batch 50
+func main() {
+	seedFlag := flag.Int64("seed", 1, "Seed of the random generator")
+	people := flag.Int("people", 10, "Number of people to generate")
+	books := flag.Int("books", 50, "Number of books to generate")
+	libraries := flag.Int("libraries", 5, "Number of libraries to generate")
+	batch := flag.Int("batch", 10, "Number of assets per createAsset invoke")
+	verifyOnly := flag.Bool("verify", false, "Only verify the dataset previously loaded with the same options")
+	flag.Parse()
+
+	defer common.CloseSDK()
+
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "Admin"
+	}
+	// Each asset type is created by the organization allowed to
+	writers, err := seed.WriterIdentities(user, common.GetMSPID())
+	if err != nil {
+		log.Fatalln("error finding the writers of the dataset: ", err)
+	}
+	target := seed.Target{
+		Channel:   os.Getenv("CHANNEL"),
+		Chaincode: os.Getenv("CCNAME"),
+		User:      user,
+		Writers:   writers,
+	}
+
+	ds := seed.Generate(seed.Options{
+		Seed:      *seedFlag,
+		People:    *people,
+		Books:     *books,
+		Libraries: *libraries,
+	})
+
+	if !*verifyOnly {
+		stats, err := seed.Load(target, ds, *batch)
+		if err != nil {
+			log.Fatalln("error loading dataset: ", err)
+		}
+		log.Printf("loaded %d assets in %d batches in %s (%.2f assets/s)\n",
+			stats.Assets, stats.Batches, stats.Duration, float64(stats.Assets)/stats.Duration.Seconds())
+	}
+
+	err = seed.Verify(target, ds)
+	if err != nil {
+		log.Fatalln("error verifying dataset: ", err)
+	}
+}
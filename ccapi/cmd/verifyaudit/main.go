@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hyperledger-labs/ccapi/audit"
+)
+
+// VerifyAudit checks the hash chain and the seals of an audit log, printing
+// the report and exiting with 1 when lines were modified, inserted or
+// removed, or a seal is not signed by the key.
+//
+// Example:
+//
+//	go run ./cmd/verifyaudit -log audit.log -key audit-signing-cert.pem
+func main() {
+	logPath := flag.String("log", os.Getenv("AUDIT_LOG_PATH"), "Path of the audit log")
+	keyPath := flag.String("key", "", "PEM public key or certificate verifying the seals (default the seals are not verified)")
+	requireSeal := flag.Bool("require-seal", false, "Also fail when entries follow the last valid seal")
+	flag.Parse()
+
+	if *logPath == "" {
+		log.Fatalln("an audit log must be provided with -log or AUDIT_LOG_PATH")
+	}
+
+	var key interface{}
+	if *keyPath != "" {
+		data, err := os.ReadFile(*keyPath)
+		if err != nil {
+			log.Fatalln("error reading key: ", err)
+		}
+		key, err = audit.ParsePublicKey(data)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	report, err := audit.Verify(*logPath, key)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	b, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(b))
+
+	if len(report.Problems) > 0 {
+		os.Exit(1)
+	}
+	if *requireSeal && (*keyPath == "" || report.Unsealed > 0) {
+		log.Println("entries are not covered by a valid seal")
+		os.Exit(1)
+	}
+}
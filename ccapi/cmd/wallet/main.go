@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/wallet"
+)
+
+// Wallet imports identities from the filesystem wallets of the Fabric SDKs
+// into the CCAPI and exports the CCAPI identities to them. It uses the same
+// environment variables as the ccapi (SDK_PATH, ORG, DOMAIN, ORGS_CONFIG...)
+// to find where the credentials of each identity are kept.
+//
+// Example:
+//
+//	go run ./cmd/wallet list -wallet ../wallet
+//	go run ./cmd/wallet import -wallet ../wallet -label appUser -as User3
+//	go run ./cmd/wallet export -wallet ./exported -identities Admin,User1@org2
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	defer common.CloseSDK()
+
+	switch os.Args[1] {
+	case "list":
+		list(os.Args[2:])
+	case "import":
+		importIdentities(os.Args[2:])
+	case "export":
+		exportIdentities(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: wallet list|import|export -wallet DIR [options]")
+	os.Exit(2)
+}
+
+// list prints the identities of the wallet
+func list(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	dir := fs.String("wallet", "wallet", "Directory of the wallet")
+	fs.Parse(args)
+
+	ids, err := wallet.ReadDir(*dir)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, id := range ids {
+		fmt.Printf("%s\t%s\n", id.Label, id.MSPID)
+	}
+}
+
+// importIdentities stores the identities of the wallet as CCAPI identities
+func importIdentities(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dir := fs.String("wallet", "wallet", "Directory of the wallet")
+	label := fs.String("label", "", "Label of the identity to import (default all)")
+	as := fs.String("as", "", "CCAPI identity to import a single label as, such as User3 or User3@org2 (default the label)")
+	org := fs.String("org", "", "Org profile to import the identities into (default the organization of the environment)")
+	overwrite := fs.Bool("overwrite", false, "Replace existing credentials")
+	fs.Parse(args)
+
+	if *as != "" && *label == "" {
+		log.Fatalln("-as requires -label")
+	}
+
+	ids, err := wallet.ReadDir(*dir)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	imported := 0
+	for _, id := range ids {
+		if *label != "" && id.Label != *label {
+			continue
+		}
+
+		target := *as
+		if target == "" {
+			target = id.Label
+			if *org != "" {
+				target += "@" + *org
+			}
+		}
+		err := wallet.Import(id, target, *overwrite)
+		if err != nil {
+			log.Fatalf("error importing %s: %s\n", id.Label, err)
+		}
+		log.Printf("imported %s as %s\n", id.Label, target)
+		imported++
+	}
+
+	if imported == 0 {
+		log.Fatalln("no identity to import")
+	}
+}
+
+// exportIdentities writes the CCAPI identities to the wallet, labeled with
+// their names
+func exportIdentities(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dir := fs.String("wallet", "wallet", "Directory of the wallet")
+	identities := fs.String("identities", os.Getenv("USER"), "Comma separated CCAPI identities to export, such as Admin,User1@org2")
+	fs.Parse(args)
+
+	for _, as := range strings.Split(*identities, ",") {
+		as = strings.TrimSpace(as)
+		if as == "" {
+			continue
+		}
+
+		id, err := wallet.Export(as, as)
+		if err != nil {
+			log.Fatalf("error exporting %s: %s\n", as, err)
+		}
+		err = wallet.WriteFile(*dir, id)
+		if err != nil {
+			log.Fatalf("error exporting %s: %s\n", as, err)
+		}
+		log.Printf("exported %s\n", as)
+	}
+}
@@ -1,29 +1,148 @@
 package common
 
 import (
+	"math"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/i18n"
+	"github.com/hyperledger-labs/ccapi/redact"
 )
 
+// Context keys for response metadata
+const (
+	startTimeKey   = "ccapi.startTime"
+	txIDKey        = "ccapi.txId"
+	blockNumberKey = "ccapi.blockNumber"
+	warningsKey    = "ccapi.warnings"
+)
+
+// envelopeEnabled reports whether responses are wrapped in the standard
+// envelope ({data, error, meta}), enabled by RESPONSE_ENVELOPE=true
+func envelopeEnabled() bool {
+	return os.Getenv("RESPONSE_ENVELOPE") == "true"
+}
+
+// Timer stores the time the request was received, used to report latency
+func Timer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(startTimeKey, time.Now())
+		c.Next()
+	}
+}
+
+// SetTxMeta stores information of the transaction executed by the request
+func SetTxMeta(c *gin.Context, txID string, blockNumber uint64) {
+	c.Set(txIDKey, txID)
+	c.Set(blockNumberKey, blockNumber)
+}
+
+// AddWarning warns the client about how the request was served, in a
+// Warning header and in the metadata of enveloped responses
+func AddWarning(c *gin.Context, warning string) {
+	c.Writer.Header().Add("Warning", "299 ccapi "+strconv.Quote(warning))
+	warnings := c.GetStringSlice(warningsKey)
+	c.Set(warningsKey, append(warnings, warning))
+}
+
+// GetTxID returns the id of the transaction executed by the request, if any
+func GetTxID(c *gin.Context) string {
+	return c.GetString(txIDKey)
+}
+
+func meta(c *gin.Context) gin.H {
+	m := gin.H{}
+	if txID, ok := c.Get(txIDKey); ok {
+		m["txid"] = txID
+	}
+	if blockNumber, ok := c.Get(blockNumberKey); ok {
+		m["blockNumber"] = blockNumber
+	}
+	if start, ok := c.Get(startTimeKey); ok {
+		m["latency"] = time.Since(start.(time.Time)).String()
+	}
+	if warnings := c.GetStringSlice(warningsKey); len(warnings) > 0 {
+		m["warnings"] = warnings
+	}
+	return m
+}
+
 func Abort(c *gin.Context, status int, err error) {
-	c.JSON(status, gin.H{
-		"status": status,
-		"error":  err.Error(),
-	})
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		apiErr = NewAPIError(status, err.Error())
+	}
+
+	if apiErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(apiErr.RetryAfter.Seconds()))))
+	}
+
+	// Translate message to the client language
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", lang)
+	apiErr = &APIError{
+		Status:  apiErr.Status,
+		Code:    apiErr.Code,
+		Message: redact.String(i18n.Translate(lang, apiErr.Message)),
+		Details: apiErr.Details,
+	}
+
+	if acceptsProblem(c) {
+		c.Render(status, problemRender{body: problem(c, status, apiErr)})
+	} else if envelopeEnabled() {
+		c.JSON(status, gin.H{
+			"data": nil,
+			"error": gin.H{
+				"code":    apiErr.Code,
+				"message": apiErr.Message,
+				"details": apiErr.Details,
+			},
+			"meta": meta(c),
+		})
+	} else {
+		body := gin.H{
+			"status": status,
+			"code":   apiErr.Code,
+			"error":  apiErr.Message,
+		}
+		if apiErr.Details != nil {
+			body["details"] = apiErr.Details
+		}
+		c.JSON(status, body)
+	}
 	c.Error(err)
 }
 
 func Respond(c *gin.Context, res interface{}, status int, err error) {
 	if err != nil {
+		if envelopeEnabled() || acceptsProblem(c) {
+			Abort(c, status, err)
+			return
+		}
+
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		c.Header("Content-Language", lang)
 		c.JSON(status, gin.H{
 			"response": res,
 			"status":   status,
-			"error":    err.Error(),
+			"code":     CodeFromStatus(status),
+			"error":    redact.String(i18n.Translate(lang, err.Error())),
 		})
 		c.Error(err)
 		return
 	}
 
+	if envelopeEnabled() {
+		c.JSON(http.StatusOK, gin.H{
+			"data":  res,
+			"error": nil,
+			"meta":  meta(c),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// Delays before a connection stuck in transient failure is dialed again,
+// doubled on every failed redial
+const (
+	redialMinBackoff = time.Second
+	redialMaxBackoff = 30 * time.Second
+)
+
+// ConnState is the connectivity of the connection to a gateway peer
+type ConnState struct {
+	// Pool is the org profile of the peer, empty for the organization
+	// configured in the environment, followed by #query for query peers
+	Pool     string    `json:"pool"`
+	Endpoint string    `json:"endpoint"`
+	State    string    `json:"state"`
+	Since    time.Time `json:"since"`
+	Redials  int       `json:"redials"`
+}
+
+var (
+	// connStatesMutex guards the connectivity of the connections, by pool
+	connStatesMutex sync.Mutex
+	connStates      = make(map[string]*ConnState)
+)
+
+// ConnStates returns the connectivity of the connections to the gateway
+// peers opened so far, sorted by pool
+func ConnStates() []ConnState {
+	connStatesMutex.Lock()
+	defer connStatesMutex.Unlock()
+
+	states := make([]ConnState, 0, len(connStates))
+	for _, state := range connStates {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Pool < states[j].Pool
+	})
+	return states
+}
+
+// ConnectionsReady reports whether no connection to a gateway peer is
+// failing. Connections not used yet are idle and count as ready.
+func ConnectionsReady() bool {
+	for _, state := range ConnStates() {
+		if state.State == connectivity.TransientFailure.String() || state.State == connectivity.Shutdown.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// setConnState records a state transition of the connection of the pool
+func setConnState(target gatewayPeer, state connectivity.State) {
+	connStatesMutex.Lock()
+	defer connStatesMutex.Unlock()
+
+	s, ok := connStates[target.pool]
+	if !ok {
+		s = &ConnState{Pool: target.pool}
+		connStates[target.pool] = s
+	}
+	if s.State != state.String() || s.Endpoint != target.endpoint {
+		s.Endpoint = target.endpoint
+		s.State = state.String()
+		s.Since = time.Now().UTC()
+	}
+}
+
+// countRedial counts a new connection dialed for the pool
+func countRedial(pool string) {
+	connStatesMutex.Lock()
+	defer connStatesMutex.Unlock()
+
+	if s, ok := connStates[pool]; ok {
+		s.Redials++
+	}
+}
+
+// monitorConnection follows the state transitions of the connection to the
+// gateway peer. gRPC reconnects by itself, but a connection still in
+// transient failure after the backoff is replaced by a new one, along with
+// the sessions using it, so a peer that was restarted or moved does not
+// require restarting the CCAPI.
+func monitorConnection(target gatewayPeer, grpcConn *grpc.ClientConn, backoff time.Duration) {
+	for {
+		state := grpcConn.GetState()
+		setConnState(target, state)
+
+		switch state {
+		case connectivity.Shutdown:
+			return
+		case connectivity.Ready:
+			backoff = redialMinBackoff
+		case connectivity.TransientFailure:
+			ctx, cancel := context.WithTimeout(context.Background(), backoff)
+			changed := grpcConn.WaitForStateChange(ctx, state)
+			cancel()
+			if !changed {
+				redial(target, grpcConn, backoff)
+				return
+			}
+			continue
+		}
+
+		grpcConn.WaitForStateChange(context.Background(), state)
+	}
+}
+
+// redial replaces the failing connection of the pool with a new one,
+// dropping the sessions that used it, and monitors the new connection with
+// a longer backoff
+func redial(target gatewayPeer, failed *grpc.ClientConn, backoff time.Duration) {
+	next := backoff * 2
+	if next > redialMaxBackoff {
+		next = redialMaxBackoff
+	}
+
+	sessionMutex.Lock()
+	if grpcConns[target.pool] != failed {
+		// Replaced already
+		sessionMutex.Unlock()
+		failed.Close()
+		return
+	}
+
+	log.Printf("connection to the gateway peer %s is failing, dialing again\n", target.endpoint)
+	grpcConn, err := dialGatewayPeer(target)
+	if err != nil {
+		sessionMutex.Unlock()
+		log.Printf("failed to dial the gateway peer %s: %s\n", target.endpoint, err)
+		time.Sleep(next)
+		redial(target, failed, next)
+		return
+	}
+
+	grpcConns[target.pool] = grpcConn
+	for key := range gateways {
+		if strings.HasPrefix(key, target.pool+"/") {
+			delete(gateways, key)
+		}
+	}
+	sessionMutex.Unlock()
+
+	failed.Close()
+	countRedial(target.pool)
+	grpcConn.Connect()
+	go monitorConnection(target, grpcConn, next)
+}
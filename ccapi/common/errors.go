@@ -0,0 +1,79 @@
+package common
+
+import (
+	"net/http"
+	"time"
+)
+
+// Error codes returned by the API, so clients can tell failures apart
+// without parsing messages
+const (
+	ErrCodeBadRequest          = "BAD_REQUEST"
+	ErrCodeUnauthorized        = "UNAUTHORIZED"
+	ErrCodeForbidden           = "FORBIDDEN"
+	ErrCodeNotFound            = "NOT_FOUND"
+	ErrCodeConflict            = "CONFLICT"
+	ErrCodeUnavailable         = "UNAVAILABLE"
+	ErrCodeInternal            = "INTERNAL_ERROR"
+	ErrCodeEndorsementFailed   = "ENDORSEMENT_FAILED"
+	ErrCodeSubmitFailed        = "SUBMIT_FAILED"
+	ErrCodeCommitStatusFailed  = "COMMIT_STATUS_FAILED"
+	ErrCodeCommitStatusTimeout = "COMMIT_STATUS_TIMEOUT"
+	ErrCodeCommitFailed        = "COMMIT_FAILED"
+	ErrCodeMVCCConflict        = "MVCC_CONFLICT"
+	ErrCodeQuotaExceeded       = "QUOTA_EXCEEDED"
+	ErrCodeSecretDetected      = "SECRET_DETECTED"
+	ErrCodePayloadTooLarge     = "PAYLOAD_TOO_LARGE"
+	ErrCodeTimeout             = "TIMEOUT"
+	ErrCodeRateLimited         = "RATE_LIMITED"
+	ErrCodeOverloaded          = "OVERLOADED"
+
+	ErrCodeIncompatibleChaincode = "INCOMPATIBLE_CHAINCODE"
+	ErrCodeInvalidBody           = "INVALID_BODY"
+)
+
+// APIError is an error with the HTTP status and code to be returned to clients
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Details map[string]interface{}
+	// RetryAfter is sent in the Retry-After header, if set
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// NewAPIError creates an error with the code matching the HTTP status
+func NewAPIError(status int, msg string) *APIError {
+	return &APIError{
+		Status:  status,
+		Code:    CodeFromStatus(status),
+		Message: msg,
+	}
+}
+
+// CodeFromStatus returns the generic error code for an HTTP status
+func CodeFromStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	}
+
+	if status >= 400 && status < 500 {
+		return ErrCodeBadRequest
+	}
+	return ErrCodeInternal
+}
@@ -4,53 +4,210 @@ import (
 	"context"
 	"crypto/x509"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/hyperledger-labs/ccapi/plugins"
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
 	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 )
 
 var (
-	gatewayTLSCredentials *credentials.TransportCredentials
+	// gatewayTLSCredentials are kept by connection pool
+	gatewayTLSCredentials = make(map[string]credentials.TransportCredentials)
+	gatewayTLSMutex       sync.Mutex
+
+	// sessionMutex guards the shared connections, kept by pool, and the
+	// gateway sessions, kept by pool and identity
+	sessionMutex sync.Mutex
+	grpcConns    = make(map[string]*grpc.ClientConn)
+	gateways     = make(map[string]*client.Gateway)
 )
 
-func CreateGrpcConnection(endpoint string) (*grpc.ClientConn, error) {
+// gatewayPeer is a gateway peer the CCAPI connects to
+type gatewayPeer struct {
+	// pool names the connection to the peer: the org profile, empty for the
+	// default organization, followed by #query for the query peer
+	pool       string
+	endpoint   string
+	serverName string
+	// tlsCACert is empty for the peers of the default organization, whose
+	// TLS CA certificate is read from the SDK configuration
+	tlsCACert string
+}
+
+// getGatewayPeer returns the gateway peer of the organization that takes
+// the submissions or, for queries, the evaluations. Organizations without a
+// query peer evaluate on their gateway peer.
+func getGatewayPeer(org *OrgProfile, query bool) gatewayPeer {
+	if org == nil {
+		if query && os.Getenv("FABRIC_GATEWAY_QUERY_ENDPOINT") != "" {
+			return gatewayPeer{
+				pool:       "#query",
+				endpoint:   os.Getenv("FABRIC_GATEWAY_QUERY_ENDPOINT"),
+				serverName: os.Getenv("FABRIC_GATEWAY_QUERY_NAME"),
+			}
+		}
+		return gatewayPeer{
+			endpoint:   os.Getenv("FABRIC_GATEWAY_ENDPOINT"),
+			serverName: os.Getenv("FABRIC_GATEWAY_NAME"),
+		}
+	}
+
+	if query && org.QueryEndpoint != "" {
+		return gatewayPeer{
+			pool:       org.Name + "#query",
+			endpoint:   org.QueryEndpoint,
+			serverName: org.QueryServerName,
+			tlsCACert:  org.TLSCACert,
+		}
+	}
+	return gatewayPeer{
+		pool:       org.Name,
+		endpoint:   org.Endpoint,
+		serverName: org.ServerName,
+		tlsCACert:  org.TLSCACert,
+	}
+}
+
+// CreateGrpcConnection connects to the gateway peer of the organization of
+// the identity
+func CreateGrpcConnection(identity string) (*grpc.ClientConn, error) {
+	_, org, err := SplitIdentity(identity)
+	if err != nil {
+		return nil, err
+	}
+	return dialGatewayPeer(getGatewayPeer(org, false))
+}
+
+// dialGatewayPeer creates a connection to the gateway peer
+func dialGatewayPeer(p gatewayPeer) (*grpc.ClientConn, error) {
+	var err error
+
 	// Check TLS credential was created
-	if gatewayTLSCredentials == nil {
-		gatewayServerName := os.Getenv("FABRIC_GATEWAY_NAME")
+	gatewayTLSMutex.Lock()
+	cred, ok := gatewayTLSCredentials[p.pool]
+	if !ok {
+		tlsCACert := p.tlsCACert
+		if tlsCACert == "" {
+			tlsCACert = GetTLSCACert()
+		}
+		cred, err = createTransportCredential(tlsCACert, p.serverName)
+		if err == nil {
+			gatewayTLSCredentials[p.pool] = cred
+		}
+	}
+	gatewayTLSMutex.Unlock()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create tls credentials")
+	}
+
+	// Create client grpc connection
+	return grpc.Dial(p.endpoint, grpc.WithTransportCredentials(cred))
+}
 
-		cred, err := createTransportCredential(GetTLSCACert(), gatewayServerName)
+// GetGateway returns the gateway session of the identity, connecting to the
+// gateway peer of its organization on first use. Sessions and their
+// connections are shared by every request and kept open, so only the first
+// request of an identity pays for the connection setup; they must not be
+// closed by callers.
+func GetGateway(identity string) (*client.Gateway, error) {
+	gw, _, err := getSession(identity, false)
+	return gw, err
+}
+
+// GetQueryGateway returns the gateway session of the identity for
+// evaluations, which connects to the query peer of its organization, if one
+// is configured, so reads do not compete with endorsements. Like those of
+// GetGateway, the sessions are shared and must not be closed.
+func GetQueryGateway(identity string) (*client.Gateway, error) {
+	gw, _, err := getSession(identity, true)
+	return gw, err
+}
+
+// Preconnect opens the gateway sessions of the identity and dials their
+// connections right away, instead of on the first call, waiting until the
+// connections are ready or the context is done
+func Preconnect(ctx context.Context, identity string) error {
+	for _, query := range []bool{false, true} {
+		_, grpcConn, err := getSession(identity, query)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to create tls credentials")
+			return err
 		}
 
-		gatewayTLSCredentials = &cred
+		grpcConn.Connect()
+		for state := grpcConn.GetState(); state != connectivity.Ready; state = grpcConn.GetState() {
+			if !grpcConn.WaitForStateChange(ctx, state) {
+				return errors.Errorf("connection to the gateway is %s", strings.ToLower(state.String()))
+			}
+		}
 	}
+	return nil
+}
 
-	// Create client grpc connection
-	return grpc.Dial(endpoint, grpc.WithTransportCredentials(*gatewayTLSCredentials))
+// getSession returns the gateway session of the identity, for evaluations
+// if query is set, and the connection it shares with the other identities
+// of its organization
+func getSession(identity string, query bool) (*client.Gateway, *grpc.ClientConn, error) {
+	_, org, err := SplitIdentity(identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	target := getGatewayPeer(org, query)
+
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+
+	grpcConn, ok := grpcConns[target.pool]
+	if !ok {
+		grpcConn, err = dialGatewayPeer(target)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to create grpc connection")
+		}
+		grpcConns[target.pool] = grpcConn
+		go monitorConnection(target, grpcConn, redialMinBackoff)
+	}
+
+	key := target.pool + "/" + identity
+	if gw, ok := gateways[key]; ok {
+		return gw, grpcConn, nil
+	}
+	gw, err := CreateGatewayConnection(grpcConn, identity)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create gateway connection")
+	}
+	gateways[key] = gw
+	return gw, grpcConn, nil
 }
 
-func CreateGatewayConnection(grpcConn *grpc.ClientConn, user string) (*client.Gateway, error) {
+func CreateGatewayConnection(grpcConn *grpc.ClientConn, identity string) (*client.Gateway, error) {
+	certPath, keyPath, mspID, err := IdentityFiles(identity)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create identity
-	id, err := newIdentity(getSignCert(user), GetMSPID())
+	id, err := newIdentity(certPath, mspID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create new identity")
 	}
 	gatewayId := id
 
 	// Create sign function
-	sign, err := newSign(getSignKey(user))
+	sign, err := newSign(keyPath)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create new sign function")
 	}
@@ -71,6 +228,22 @@ func CreateGatewayConnection(grpcConn *grpc.ClientConn, user string) (*client.Ga
 	)
 }
 
+// IdentityFiles returns the files of the certificate and the private key of
+// the identity, with its MSP ID: those in the crypto path of the SDK config
+// for users of the organization configured in the environment, or those of
+// its org profile
+func IdentityFiles(identity string) (certPath, keyPath, mspID string, err error) {
+	user, org, err := SplitIdentity(identity)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if org != nil {
+		return strings.ReplaceAll(org.Cert, "{username}", user), strings.ReplaceAll(org.Key, "{username}", user), org.MSPID, nil
+	}
+	return getSignCert(user), getSignKey(user), GetMSPID(), nil
+}
+
 // Create transport credential
 func createTransportCredential(tlsCertPath, serverName string) (credentials.TransportCredentials, error) {
 	certificate, err := loadCertificate(tlsCertPath)
@@ -99,7 +272,19 @@ func newIdentity(certPath, mspID string) (*identity.X509Identity, error) {
 }
 
 // Creates a function that generates a digital signature from a message digest using a private key.
+// When SIGNER_PLUGIN names a signer plugin, the plugin signs with the key it maps to the key path
+// instead, so private keys can be kept in an HSM or a key vault.
 func newSign(keyPath string) (identity.Sign, error) {
+	if name := os.Getenv("SIGNER_PLUGIN"); name != "" {
+		signer, err := plugins.Lookup(name, plugins.KindSigner)
+		if err != nil {
+			return nil, err
+		}
+		return func(digest []byte) ([]byte, error) {
+			return signer.Sign(keyPath, digest)
+		}, nil
+	}
+
 	privateKeyPEM, err := os.ReadFile(keyPath)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to read private key file")
@@ -118,43 +303,87 @@ func newSign(keyPath string) (identity.Sign, error) {
 	return sign, nil
 }
 
-// Returns error and status code
+// Returns error and status code. The returned error is an *APIError
+// carrying the error code for the failure
 func ParseError(err error) (error, int) {
-	var errMsg string
+	apiErr := &APIError{
+		Status: http.StatusInternalServerError,
+		Code:   ErrCodeInternal,
+	}
 
 	switch err := err.(type) {
+	case *APIError:
+		return err, err.Status
 	case *client.EndorseError:
-		errMsg = "endorse error for transaction"
+		apiErr.Code = ErrCodeEndorsementFailed
+		apiErr.Message = "endorse error for transaction"
+		apiErr.Details = map[string]interface{}{"txId": err.TransactionID}
 	case *client.SubmitError:
-		errMsg = "submit error for transaction"
+		apiErr.Code = ErrCodeSubmitFailed
+		apiErr.Message = "submit error for transaction"
+		apiErr.Details = map[string]interface{}{"txId": err.TransactionID}
 	case *client.CommitStatusError:
 		if errors.Is(err, context.DeadlineExceeded) {
-			errMsg = "timeout waiting for transaction commit status"
+			apiErr.Status = http.StatusGatewayTimeout
+			apiErr.Code = ErrCodeCommitStatusTimeout
+			apiErr.Message = "timeout waiting for transaction commit status"
 		} else {
-			errMsg = "error obtaining commit status for transaction"
+			apiErr.Code = ErrCodeCommitStatusFailed
+			apiErr.Message = "error obtaining commit status for transaction"
 		}
+		apiErr.Details = map[string]interface{}{"txId": err.TransactionID}
 	case *client.CommitError:
-		errMsg = "transaction failed to commit"
+		apiErr.Code = ErrCodeCommitFailed
+		apiErr.Message = "transaction failed to commit"
+		if err.Code == peer.TxValidationCode_MVCC_READ_CONFLICT || err.Code == peer.TxValidationCode_PHANTOM_READ_CONFLICT {
+			apiErr.Status = http.StatusConflict
+			apiErr.Code = ErrCodeMVCCConflict
+		}
+		apiErr.Details = map[string]interface{}{
+			"txId":           err.TransactionID,
+			"validationCode": err.Code.String(),
+		}
+		return apiErr, apiErr.Status
 	default:
-		errMsg = "unexpected error type:" + err.Error()
+		apiErr.Message = "unexpected error type:" + err.Error()
 	}
 
 	statusErr := status.Convert(err)
 
-	details := statusErr.Details()
-	if len(details) == 0 {
-		return errors.New(errMsg), http.StatusInternalServerError
-	}
-
-	for _, detail := range details {
+	// Collect the error reported by each peer, so users can see which
+	// organization rejected the proposal
+	var peerErrors []map[string]interface{}
+	for _, detail := range statusErr.Details() {
 		switch detail := detail.(type) {
 		case *gateway.ErrorDetail:
-			status, msg := extractStatusAndMessage(detail.Message)
-			return errors.New(msg), status
+			log.Printf("peer %s (%s) error: %s\n", detail.Address, detail.MspId, detail.Message)
+
+			peerStatus, msg := extractStatusAndMessage(detail.Message)
+			if peerErrors == nil {
+				apiErr.Status = peerStatus
+				apiErr.Message = msg
+				if peerStatus != http.StatusInternalServerError {
+					apiErr.Code = CodeFromStatus(peerStatus)
+				}
+			}
+
+			peerErrors = append(peerErrors, map[string]interface{}{
+				"address": detail.Address,
+				"mspId":   detail.MspId,
+				"status":  peerStatus,
+				"message": msg,
+			})
+		}
+	}
+
+	if peerErrors != nil {
+		if apiErr.Details == nil {
+			apiErr.Details = map[string]interface{}{}
 		}
+		apiErr.Details["peers"] = peerErrors
 	}
 
-	return errors.New(errMsg), http.StatusInternalServerError
+	return apiErr, apiErr.Status
 }
 
 func extractStatusAndMessage(msg string) (int, string) {
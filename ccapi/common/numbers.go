@@ -0,0 +1,42 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ExactNumbers reports whether JSON numbers of requests and chaincode
+// results are kept as their literal text (json.Number) instead of being
+// converted to float64, which loses precision on large integers and
+// decimals. It is set by JSON_NUMBERS, "exact" (default) or "float".
+func ExactNumbers() bool {
+	return os.Getenv("JSON_NUMBERS") != "float"
+}
+
+// UnmarshalJSON works as json.Unmarshal, keeping numbers exact unless
+// disabled by JSON_NUMBERS
+func UnmarshalJSON(data []byte, v interface{}) error {
+	return DecodeJSON(bytes.NewReader(data), v)
+}
+
+// DecodeJSON decodes a single JSON value from the reader, keeping numbers
+// exact unless disabled by JSON_NUMBERS
+func DecodeJSON(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	if ExactNumbers() {
+		decoder.UseNumber()
+	}
+
+	err := decoder.Decode(v)
+	if err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
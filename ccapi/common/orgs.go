@@ -0,0 +1,131 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// OrgProfile holds the connection and identity settings of an organization
+// the CCAPI can act as. The {username} placeholder of the certificate and
+// key paths is replaced by the user of the request.
+type OrgProfile struct {
+	Name       string `json:"-"`
+	MSPID      string `json:"mspId"`
+	Endpoint   string `json:"endpoint"`
+	ServerName string `json:"serverName"`
+	TLSCACert  string `json:"tlsCACert"`
+	Cert       string `json:"cert"`
+	Key        string `json:"key"`
+	// QueryEndpoint and QueryServerName set a peer for evaluations, so
+	// reads do not compete with endorsements on the gateway peer
+	QueryEndpoint   string `json:"queryEndpoint,omitempty"`
+	QueryServerName string `json:"queryServerName,omitempty"`
+}
+
+var (
+	orgProfiles     map[string]*OrgProfile
+	orgProfilesErr  error
+	orgProfilesOnce sync.Once
+)
+
+// GetOrgProfiles returns the organization profiles from the file set by
+// ORGS_CONFIG, if any
+func GetOrgProfiles() (map[string]*OrgProfile, error) {
+	orgProfilesOnce.Do(func() {
+		orgProfiles = make(map[string]*OrgProfile)
+
+		path := os.Getenv("ORGS_CONFIG")
+		if path == "" {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			orgProfilesErr = errors.Wrap(err, "failed to read org profiles")
+			return
+		}
+		err = json.Unmarshal(data, &orgProfiles)
+		if err != nil {
+			orgProfilesErr = errors.Wrap(err, "failed to unmarshal org profiles")
+			return
+		}
+
+		for name, org := range orgProfiles {
+			if org.MSPID == "" || org.Endpoint == "" || org.Cert == "" || org.Key == "" {
+				orgProfilesErr = errors.Errorf("org profile '%s' must have mspId, endpoint, cert and key", name)
+				return
+			}
+			org.Name = name
+		}
+	})
+
+	return orgProfiles, orgProfilesErr
+}
+
+// OrgNames returns the names of the organization profiles, sorted
+func OrgNames() []string {
+	profiles, _ := GetOrgProfiles()
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IdentityKey is the context key of the identity of a logged in session,
+// which takes precedence over the User and Org headers
+const IdentityKey = "identity"
+
+// SessionUserKey is the context key of the user of a logged in session
+const SessionUserKey = "sessionUser"
+
+// GetUser returns the identity the request acts as: the user in the User
+// header (default Admin), qualified by the organization profile in the Org
+// header as user@org
+func GetUser(c *gin.Context) string {
+	if identity := c.GetString(IdentityKey); identity != "" {
+		return identity
+	}
+
+	user := c.GetHeader("User")
+	if user == "" {
+		user = "Admin"
+	}
+
+	if org := c.GetHeader("Org"); org != "" {
+		user += "@" + org
+	}
+	return user
+}
+
+// SplitIdentity separates the user from its organization profile. The
+// profile is nil for unqualified users, which act as the organization
+// configured in the environment.
+func SplitIdentity(identity string) (string, *OrgProfile, error) {
+	i := strings.LastIndex(identity, "@")
+	if i < 0 {
+		return identity, nil, nil
+	}
+
+	user, name := identity[:i], identity[i+1:]
+	profiles, err := GetOrgProfiles()
+	if err != nil {
+		return "", nil, err
+	}
+
+	org, ok := profiles[name]
+	if !ok {
+		return "", nil, NewAPIError(http.StatusBadRequest, fmt.Sprintf("unknown org profile '%s'", name))
+	}
+	return user, org, nil
+}
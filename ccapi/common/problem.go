@@ -0,0 +1,89 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// Titles of the problem types, by error code
+var problemTitles = map[string]string{
+	ErrCodeBadRequest:          "Bad request",
+	ErrCodeUnauthorized:        "Unauthorized",
+	ErrCodeForbidden:           "Forbidden",
+	ErrCodeNotFound:            "Not found",
+	ErrCodeConflict:            "Conflict",
+	ErrCodeUnavailable:         "Service unavailable",
+	ErrCodeInternal:            "Internal error",
+	ErrCodeEndorsementFailed:   "Endorsement failed",
+	ErrCodeSubmitFailed:        "Submit failed",
+	ErrCodeCommitStatusFailed:  "Commit status failed",
+	ErrCodeCommitStatusTimeout: "Commit status timeout",
+	ErrCodeCommitFailed:        "Commit failed",
+	ErrCodeMVCCConflict:        "MVCC read conflict",
+	ErrCodeQuotaExceeded:       "Quota exceeded",
+	ErrCodeSecretDetected:      "Secret detected",
+	ErrCodePayloadTooLarge:     "Payload too large",
+	ErrCodeTimeout:             "Timeout",
+	ErrCodeRateLimited:         "Rate limited",
+
+	ErrCodeIncompatibleChaincode: "Incompatible chaincode",
+	ErrCodeInvalidBody:           "Invalid body",
+}
+
+// acceptsProblem reports whether the client asked for RFC 7807 error bodies
+func acceptsProblem(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), problemContentType)
+}
+
+// problemType returns the type URI of an error code. The base URI can be
+// set with PROBLEM_TYPE_BASE (default "urn:ccapi:error:")
+func problemType(code string) string {
+	base := os.Getenv("PROBLEM_TYPE_BASE")
+	if base == "" {
+		base = "urn:ccapi:error:"
+	}
+	return base + strings.ReplaceAll(strings.ToLower(code), "_", "-")
+}
+
+func problem(c *gin.Context, status int, apiErr *APIError) gin.H {
+	title, ok := problemTitles[apiErr.Code]
+	if !ok {
+		title = http.StatusText(status)
+	}
+
+	body := gin.H{
+		"type":     problemType(apiErr.Code),
+		"title":    title,
+		"status":   status,
+		"detail":   apiErr.Message,
+		"instance": c.Request.URL.Path,
+		"code":     apiErr.Code,
+	}
+	if apiErr.Details != nil {
+		body["details"] = apiErr.Details
+	}
+	if txID, ok := c.Get(txIDKey); ok {
+		body["txid"] = txID
+	}
+	return body
+}
+
+// problemRender writes a JSON body with the problem+json content type
+type problemRender struct {
+	body gin.H
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.body)
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", problemContentType)
+}
@@ -0,0 +1,307 @@
+// Package compat negotiates the version of the chaincode the CCAPI talks
+// to. The major version the CCAPI expects is set by CHAINCODE_MAJOR_VERSION,
+// by default the one of the chaincode version the binary was built with, and
+// compared with the version in the header of the chaincode of CHANNEL and
+// CCNAME on startup and then every CHAINCODE_VERSION_INTERVAL, so an upgrade
+// that breaks the contract does not go unnoticed. While the major
+// versions differ, the chaincode routes are refused with 503 or, when
+// CHAINCODE_VERSION_MISMATCH is shim, translated with the shim declared for
+// the deployed major version in the file set by CHAINCODE_SHIMS:
+//
+//	{
+//	  "1": {
+//	    "transactions": {"updateBookTenant": "setBookTenant"}
+//	  }
+//	}
+//
+// Transactions of the expected version are renamed to the name they have in
+// the deployed one.
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/buildinfo"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// defaultInterval is how often the version is checked when
+// CHAINCODE_VERSION_INTERVAL is not set
+const defaultInterval = time.Minute
+
+// Shim adapts the requests of the expected version to a deployed one
+type Shim struct {
+	// Transactions maps the transactions of the expected version to their
+	// name in the deployed one
+	Transactions map[string]string `json:"transactions"`
+}
+
+// Status is the outcome of the last version check
+type Status struct {
+	Expected   int       `json:"expected"`
+	Deployed   string    `json:"deployed,omitempty"`
+	Compatible bool      `json:"compatible"`
+	Shim       bool      `json:"shim,omitempty"`
+	CheckedAt  time.Time `json:"checkedAt,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+var (
+	// statusMutex guards the status of the last check and its shim
+	statusMutex sync.RWMutex
+	status      *Status
+	activeShim  *Shim
+
+	shims     map[string]*Shim
+	shimsErr  error
+	shimsOnce sync.Once
+)
+
+// Enabled reports whether the version of the chaincode is checked, when
+// CHAINCODE_MAJOR_VERSION is set or the binary was built for a chaincode
+// version, unless CHAINCODE_MAJOR_VERSION is none
+func Enabled() bool {
+	expected := os.Getenv("CHAINCODE_MAJOR_VERSION")
+	if expected == "" {
+		return buildinfo.ChaincodeVersion != ""
+	}
+	return expected != "none"
+}
+
+// expectedMajor returns the major version the CCAPI expects, by default the
+// one of the chaincode version of the build
+func expectedMajor() (int, error) {
+	expected := os.Getenv("CHAINCODE_MAJOR_VERSION")
+	if expected == "" {
+		return majorVersion(buildinfo.ChaincodeVersion)
+	}
+	major, err := strconv.Atoi(expected)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid CHAINCODE_MAJOR_VERSION")
+	}
+	return major, nil
+}
+
+// getInterval returns how often the version is checked, from
+// CHAINCODE_VERSION_INTERVAL
+func getInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("CHAINCODE_VERSION_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return defaultInterval
+}
+
+// shimEnabled reports whether mismatching versions are served through shims
+// instead of refused
+func shimEnabled() bool {
+	return os.Getenv("CHAINCODE_VERSION_MISMATCH") == "shim"
+}
+
+// getShims returns the shims by deployed major version, read once from the
+// file set by CHAINCODE_SHIMS
+func getShims() (map[string]*Shim, error) {
+	shimsOnce.Do(func() {
+		shims = make(map[string]*Shim)
+		path := os.Getenv("CHAINCODE_SHIMS")
+		if path == "" {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			shimsErr = errors.Wrap(err, "failed to read chaincode shims")
+			return
+		}
+		err = json.Unmarshal(data, &shims)
+		if err != nil {
+			shimsErr = errors.Wrap(err, "failed to unmarshal chaincode shims")
+		}
+	})
+
+	return shims, shimsErr
+}
+
+// majorVersion returns the major version of a version such as 1.4.2 or
+// v2.0
+func majorVersion(version string) (int, error) {
+	major := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)[0]
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, errors.Errorf("invalid chaincode version %q", version)
+	}
+	return n, nil
+}
+
+// Start checks the version of the chaincode in the background now, then
+// periodically and whenever an upgrade of the chaincode is detected, if
+// enabled
+func Start() {
+	if !Enabled() {
+		return
+	}
+	chaincode.OnUpgrade(func(channelName, chaincodeName string) {
+		if channelName == os.Getenv("CHANNEL") && chaincodeName == os.Getenv("CCNAME") {
+			go Check()
+		}
+	})
+	go func() {
+		Check()
+		for range time.Tick(getInterval()) {
+			Check()
+		}
+	}()
+}
+
+// Check reads the version of the chaincode and compares its major version
+// with the expected one. A failed read keeps the outcome of the previous
+// check, so the routes are not refused while the peers are unreachable.
+func Check() Status {
+	s := check()
+	if s.Error != "" {
+		log.Printf("chaincode version check failed: %s\n", s.Error)
+	} else if !s.Compatible {
+		log.Printf("chaincode version %s does not match the expected major version %d, chaincode routes are refused\n", s.Deployed, s.Expected)
+	} else if s.Shim {
+		log.Printf("chaincode version %s is served through its shim\n", s.Deployed)
+	}
+	return s
+}
+
+func check() Status {
+	expected, err := expectedMajor()
+	if err != nil {
+		return setError(expected, err)
+	}
+
+	header, err := chaincode.QueryGateway(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "getHeader", os.Getenv("USER"), nil)
+	if err != nil {
+		err, _ := common.ParseError(err)
+		return setError(expected, err)
+	}
+	var h struct {
+		Version string `json:"version"`
+	}
+	err = json.Unmarshal(header, &h)
+	if err != nil {
+		return setError(expected, errors.Wrap(err, "failed to unmarshal chaincode header"))
+	}
+	deployed, err := majorVersion(h.Version)
+	if err != nil {
+		return setError(expected, err)
+	}
+
+	s := Status{
+		Expected:   expected,
+		Deployed:   h.Version,
+		Compatible: deployed == expected,
+		CheckedAt:  time.Now().UTC(),
+	}
+	var shim *Shim
+	if !s.Compatible && shimEnabled() {
+		all, err := getShims()
+		if err != nil {
+			return setError(expected, err)
+		}
+		shim = all[strconv.Itoa(deployed)]
+		s.Compatible, s.Shim = shim != nil, shim != nil
+	}
+
+	statusMutex.Lock()
+	status, activeShim = &s, shim
+	statusMutex.Unlock()
+	return s
+}
+
+// setError records the failed check, keeping the outcome of the previous one
+func setError(expected int, err error) Status {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+
+	s := Status{Expected: expected}
+	if status != nil {
+		s = *status
+	}
+	s.Error = err.Error()
+	status = &s
+	return s
+}
+
+// Get returns the outcome of the last check, or nil if the version was not
+// checked yet
+func Get() *Status {
+	statusMutex.RLock()
+	defer statusMutex.RUnlock()
+
+	if status == nil {
+		return nil
+	}
+	s := *status
+	return &s
+}
+
+// Ready reports whether the chaincode routes are served, that is, unless the
+// last check found an incompatible version
+func Ready() bool {
+	s := Get()
+	return s == nil || s.Deployed == "" || s.Compatible
+}
+
+// Middleware refuses the requests to the chaincode of CHANNEL and CCNAME
+// with 503 while its version is incompatible, and renames their
+// transaction when it is served through a shim. Other chaincodes, named in
+// the path, are not checked.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled() || !isDefaultChaincode(c) {
+			c.Next()
+			return
+		}
+
+		s := Get()
+		if s != nil && s.Deployed != "" && !s.Compatible {
+			common.Abort(c, http.StatusServiceUnavailable, &common.APIError{
+				Status:  http.StatusServiceUnavailable,
+				Code:    common.ErrCodeIncompatibleChaincode,
+				Message: fmt.Sprintf("the deployed chaincode version %s is not compatible with the expected major version %d", s.Deployed, s.Expected),
+				Details: map[string]interface{}{
+					"expected": s.Expected,
+					"deployed": s.Deployed,
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		statusMutex.RLock()
+		shim := activeShim
+		statusMutex.RUnlock()
+		if shim != nil {
+			for i, param := range c.Params {
+				if name, ok := shim.Transactions[param.Value]; ok && param.Key == "txname" {
+					c.Params[i].Value = name
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// isDefaultChaincode reports whether the request goes to the chaincode of
+// CHANNEL and CCNAME
+func isDefaultChaincode(c *gin.Context) bool {
+	channelName, chaincodeName := c.Param("channelName"), c.Param("chaincodeName")
+	return (channelName == "" || channelName == os.Getenv("CHANNEL")) &&
+		(chaincodeName == "" || chaincodeName == os.Getenv("CCNAME"))
+}
@@ -0,0 +1,152 @@
+// Package console serves an interactive API console (Swagger UI) bound to
+// an OpenAPI document generated from the transactions of the chaincode
+package console
+
+import (
+	"strings"
+
+	"github.com/hyperledger-labs/ccapi/contracttest"
+	"github.com/hyperledger-labs/ccapi/txschema"
+)
+
+// Document builds the OpenAPI document of the gateway routes, with one
+// operation per chaincode transaction. Transactions with a body schema in
+// TX_SCHEMAS are documented with it, and the ones missing from the metadata
+// of the chaincode are added as invoke routes.
+func Document(txs []contracttest.Transaction) map[string]interface{} {
+	paths := make(map[string]interface{})
+	documented := make(map[string]bool)
+	for _, tx := range txs {
+		method, path := contracttest.Route(tx)
+		paths[path] = map[string]interface{}{
+			strings.ToLower(method): operation(tx),
+		}
+		documented[tx.Tag] = true
+	}
+
+	names, _ := txschema.Names()
+	for _, name := range names {
+		if documented[name] {
+			continue
+		}
+		tx := contracttest.Transaction{Tag: name}
+		method, path := contracttest.Route(tx)
+		paths[path] = map[string]interface{}{
+			strings.ToLower(method): operation(tx),
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":       "CCAPI Console",
+			"description": "Transactions of the chaincode, generated from its metadata. Set the User and Org headers in Authorize to choose the identity used on the network.",
+			"version":     "1.0",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"url": "/"},
+		},
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"basicAuth": map[string]interface{}{
+					"type":   "http",
+					"scheme": "basic",
+				},
+				"user": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "User",
+				},
+				"org": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "Org",
+				},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"basicAuth": []string{}},
+			map[string]interface{}{"user": []string{}, "org": []string{}},
+		},
+		"paths": paths,
+	}
+}
+
+func operation(tx contracttest.Transaction) map[string]interface{} {
+	tag := "Invoke"
+	if tx.ReadOnly {
+		tag = "Query"
+	}
+
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	for _, arg := range tx.Args {
+		properties[arg.Tag] = schema(arg.DataType)
+		if arg.Required {
+			required = append(required, arg.Tag)
+		}
+	}
+
+	body := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		body["required"] = required
+	}
+	if declared, ok, _ := txschema.Get(tx.Tag); ok {
+		body = declared
+	}
+
+	return map[string]interface{}{
+		"tags":        []string{tag},
+		"operationId": tx.Tag,
+		"summary":     tx.Tag,
+		"requestBody": map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": body,
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{"description": "OK"},
+			"4XX": map[string]interface{}{"description": "Bad Request"},
+			"5XX": map[string]interface{}{"description": "Internal error"},
+		},
+	}
+}
+
+// schema maps a cc-tools data type to a JSON schema
+func schema(dataType string) map[string]interface{} {
+	switch {
+	case strings.HasPrefix(dataType, "[]"):
+		return map[string]interface{}{
+			"type":  "array",
+			"items": schema(strings.TrimPrefix(dataType, "[]")),
+		}
+	case strings.HasPrefix(dataType, "->"):
+		return map[string]interface{}{
+			"type":        "object",
+			"description": "Reference to a " + strings.TrimPrefix(dataType, "->") + " asset",
+			"properties": map[string]interface{}{
+				"@key": map[string]interface{}{"type": "string"},
+			},
+		}
+	case dataType == "@object", dataType == "@asset", dataType == "@update", dataType == "@key", dataType == "@query":
+		return map[string]interface{}{"type": "object"}
+	case dataType == "string":
+		return map[string]interface{}{"type": "string"}
+	case dataType == "number":
+		return map[string]interface{}{"type": "number"}
+	case dataType == "integer":
+		return map[string]interface{}{"type": "integer"}
+	case dataType == "boolean":
+		return map[string]interface{}{"type": "boolean"}
+	case dataType == "datetime":
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	default:
+		// Custom data types are validated by the chaincode
+		return map[string]interface{}{"description": dataType}
+	}
+}
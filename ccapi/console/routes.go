@@ -0,0 +1,46 @@
+package console
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/contracttest"
+	"github.com/hyperledger-labs/ccapi/txschema"
+	swaggerfiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// AddRoutes registers the console page and its OpenAPI document
+func AddRoutes(rg *gin.RouterGroup) {
+	rg.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, c.FullPath()+"ui/index.html")
+	})
+
+	rg.GET("/openapi.json", openAPI)
+
+	// Authorization entered in the console is kept on the browser and sent
+	// on every "try it out" request
+	url := ginSwagger.URL(rg.BasePath() + "/openapi.json")
+	rg.GET("/ui/*any", ginSwagger.WrapHandler(swaggerfiles.Handler, url,
+		ginSwagger.PersistAuthorization(true),
+		ginSwagger.DocExpansion("none"),
+	))
+}
+
+// openAPI generates the document from the transactions currently defined
+// in the chaincode
+func openAPI(c *gin.Context) {
+	user := common.GetUser(c)
+
+	// Chaincodes without the metadata of cc-tools are documented by the
+	// schemas of their transactions alone
+	txs, err := contracttest.FetchTransactions(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), user)
+	if err != nil && !txschema.Enabled() {
+		common.Abort(c, http.StatusBadGateway, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Document(txs))
+}
@@ -0,0 +1,257 @@
+package contracttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/format"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// Case is a single generated request against the ccapi
+type Case struct {
+	Name       string
+	Method     string
+	Path       string
+	Body       string
+	WantStatus int
+	// Skip holds the reason why the case cannot run, if any
+	Skip string
+}
+
+// Fixtures maps transaction names to valid request bodies, used for
+// the happy path of transactions whose arguments cannot be synthesized
+// (asset references, custom data types...)
+type Fixtures map[string]map[string]interface{}
+
+// RunID is replaced in the request bodies by an id unique to each run of
+// the generated tests, so the assets they create do not exist yet when
+// the tests run again
+const RunID = "$RUN"
+
+// BuildCases creates a happy path case and one type violation case per
+// argument for every transaction
+func BuildCases(txs []Transaction, fixtures Fixtures) []Case {
+	cases := make([]Case, 0)
+
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Tag < txs[j].Tag
+	})
+
+	for _, tx := range txs {
+		method, path := Route(tx)
+
+		// Happy path
+		valid, ok := fixtures[tx.Tag]
+		skip := ""
+		if !ok {
+			valid, ok = synthesize(tx.Args)
+			if !ok {
+				skip = "no fixture for " + tx.Tag
+			}
+		}
+		cases = append(cases, Case{
+			Name:       tx.Tag + "/valid",
+			Method:     method,
+			Path:       path,
+			Body:       toJSON(valid),
+			WantStatus: http.StatusOK,
+			Skip:       skip,
+		})
+
+		// Type violations
+		for _, arg := range tx.Args {
+			// Without a valid body, the other arguments could be the ones
+			// refused, so the violation would not be tested
+			invalid := copyMap(valid)
+			invalid[arg.Tag] = wrongValue(arg.DataType)
+			cases = append(cases, Case{
+				Name:       tx.Tag + "/invalid_" + arg.Tag,
+				Method:     method,
+				Path:       path,
+				Body:       toJSON(invalid),
+				WantStatus: http.StatusBadRequest,
+				Skip:       skip,
+			})
+
+			if arg.Required {
+				missing := copyMap(valid)
+				delete(missing, arg.Tag)
+				cases = append(cases, Case{
+					Name:       tx.Tag + "/missing_" + arg.Tag,
+					Method:     method,
+					Path:       path,
+					Body:       toJSON(missing),
+					WantStatus: http.StatusBadRequest,
+					Skip:       skip,
+				})
+			}
+		}
+	}
+
+	return cases
+}
+
+// Route returns how a transaction is called on the ccapi gateway routes
+func Route(tx Transaction) (string, string) {
+	if tx.ReadOnly {
+		return http.MethodPost, "/api/gateway/query/" + tx.Tag
+	}
+
+	method := strings.ToUpper(tx.Method)
+	switch method {
+	case http.MethodPut, http.MethodDelete:
+	default:
+		method = http.MethodPost
+	}
+	return method, "/api/gateway/invoke/" + tx.Tag
+}
+
+// synthesize creates valid values for arguments with primitive data types.
+// Strings are unique to each run, as they may be asset keys.
+func synthesize(args []Argument) (map[string]interface{}, bool) {
+	req := make(map[string]interface{})
+	for _, arg := range args {
+		if !arg.Required {
+			continue
+		}
+
+		var value interface{}
+		switch arg.DataType {
+		case "string":
+			value = "contract-test-" + RunID
+		case "number", "integer":
+			value = 1
+		case "boolean":
+			value = true
+		case "datetime":
+			value = "2020-01-01T00:00:00Z"
+		case "[]string":
+			value = []string{"contract-test-" + RunID}
+		default:
+			return req, false
+		}
+		req[arg.Tag] = value
+	}
+	return req, true
+}
+
+// wrongValue returns a value that violates the data type
+func wrongValue(dataType string) interface{} {
+	switch {
+	case strings.HasPrefix(dataType, "[]"):
+		return "not-an-array"
+	case dataType == "string":
+		return map[string]interface{}{"not": "a string"}
+	case dataType == "boolean":
+		return "not-a-boolean"
+	case dataType == "datetime":
+		return "not-a-datetime"
+	case strings.HasPrefix(dataType, "->"), strings.HasPrefix(dataType, "@"):
+		return 12345
+	default:
+		return []interface{}{"not", "a", dataType}
+	}
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func toJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+var testTemplate = template.Must(template.New("contract").Parse(`// Code generated by cmd/gentests. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestContract runs against the ccapi at CCAPI_URL, e.g. http://localhost:80
+func TestContract(t *testing.T) {
+	baseURL := os.Getenv("CCAPI_URL")
+	if baseURL == "" {
+		t.Skip("CCAPI_URL not set")
+	}
+
+	// Bodies use an id of the run, so the assets of previous runs are not
+	// created again
+	runID := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		skip       string
+	}{
+{{- range .Cases }}
+		{ {{ printf "%q" .Name }}, {{ printf "%q" .Method }}, {{ printf "%q" .Path }}, {{ printf "%q" .Body }}, {{ .WantStatus }}, {{ printf "%q" .Skip }} },
+{{- end }}
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.skip != "" {
+				t.Skip(tc.skip)
+			}
+
+			req, err := http.NewRequest(tc.method, baseURL+tc.path, bytes.NewBufferString(strings.ReplaceAll(tc.body, {{ printf "%q" .RunID }}, runID)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.wantStatus {
+				body, _ := io.ReadAll(res.Body)
+				t.Errorf("got status %d, expected %d: %s", res.StatusCode, tc.wantStatus, body)
+			}
+		})
+	}
+}
+`))
+
+// Render generates the source of a Go test file running the cases
+func Render(pkg string, cases []Case) ([]byte, error) {
+	var buf bytes.Buffer
+	err := testTemplate.Execute(&buf, map[string]interface{}{
+		"Package": pkg,
+		"Cases":   cases,
+		"RunID":   RunID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render test file")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to format test file")
+	}
+	return src, nil
+}
@@ -0,0 +1,38 @@
+package contracttest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildCases(t *testing.T) {
+	txs := []Transaction{
+		{
+			Tag:    "createNewLibrary",
+			Method: "POST",
+			Args:   []Argument{{Tag: "name", DataType: "string", Required: true}},
+		},
+		{
+			Tag:    "updateBookTenant",
+			Method: "PUT",
+			Args: []Argument{
+				{Tag: "book", DataType: "->book", Required: true},
+				{Tag: "tenant", DataType: "->person", Required: true},
+			},
+		},
+	}
+
+	for _, c := range BuildCases(txs, Fixtures{}) {
+		// Synthesized strings are unique to each run
+		if c.Name == "createNewLibrary/valid" && c.Body != `{"name":"contract-test-$RUN"}` {
+			t.Fatalf("unexpected body %s", c.Body)
+		}
+
+		// The violations of transactions without a valid body are skipped
+		// along with their happy path
+		skipped := strings.HasPrefix(c.Name, "updateBookTenant/")
+		if skipped != (c.Skip != "") {
+			t.Fatalf("case %s skipped: %q", c.Name, c.Skip)
+		}
+	}
+}
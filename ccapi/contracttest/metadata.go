@@ -0,0 +1,65 @@
+package contracttest
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// Argument is a transaction argument as described by getTx
+type Argument struct {
+	Tag      string `json:"tag"`
+	DataType string `json:"dataType"`
+	Required bool   `json:"required"`
+	Private  bool   `json:"private"`
+}
+
+// Transaction is a transaction definition as described by getTx
+type Transaction struct {
+	Tag      string     `json:"tag"`
+	Method   string     `json:"method"`
+	ReadOnly bool       `json:"readOnly"`
+	MetaTx   bool       `json:"metaTx"`
+	Args     []Argument `json:"args"`
+}
+
+// FetchTransactions reads the definition of every transaction of the chaincode
+func FetchTransactions(channel, ccName, user string) ([]Transaction, error) {
+	listBytes, err := chaincode.QueryGateway(channel, ccName, "getTx", user, []string{"{}"})
+	if err != nil {
+		err, _ := common.ParseError(err)
+		return nil, errors.Wrap(err, "failed to fetch transaction list")
+	}
+
+	var list []struct {
+		Tag string `json:"tag"`
+	}
+	err = json.Unmarshal(listBytes, &list)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal transaction list")
+	}
+
+	txs := make([]Transaction, 0, len(list))
+	for _, item := range list {
+		args, _ := json.Marshal(map[string]interface{}{
+			"txName": item.Tag,
+		})
+
+		txBytes, err := chaincode.QueryGateway(channel, ccName, "getTx", user, []string{string(args)})
+		if err != nil {
+			err, _ := common.ParseError(err)
+			return nil, errors.Wrap(err, "failed to fetch definition of "+item.Tag)
+		}
+
+		var tx Transaction
+		err = json.Unmarshal(txBytes, &tx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal definition of "+item.Tag)
+		}
+		txs = append(txs, tx)
+	}
+
+	return txs, nil
+}
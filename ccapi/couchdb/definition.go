@@ -0,0 +1,134 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Definition is an index definition, as in the files of the META-INF
+// directory of the chaincode and the body of the CouchDB _index endpoint
+type Definition struct {
+	Index struct {
+		Fields []interface{} `json:"fields"`
+	} `json:"index"`
+	DDoc string `json:"ddoc,omitempty"`
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
+// Declared is an index definition deployed with the chaincode
+type Declared struct {
+	File       string      `json:"file"`
+	Definition *Definition `json:"definition,omitempty"`
+	Errors     []string    `json:"errors,omitempty"`
+}
+
+// indexesPath returns the directory of the index definitions of the
+// chaincode, from COUCHDB_INDEXES_PATH, default the one of the chaincode of
+// the repository
+func indexesPath() string {
+	if path := os.Getenv("COUCHDB_INDEXES_PATH"); path != "" {
+		return path
+	}
+	return "../chaincode/META-INF/statedb/couchdb/indexes"
+}
+
+// ReadDeclared reads the index definitions deployed with the chaincode,
+// with the errors of those that are invalid. There are none if the
+// directory does not exist.
+func ReadDeclared() ([]Declared, error) {
+	files, err := filepath.Glob(filepath.Join(indexesPath(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	declared := make([]Declared, 0, len(files))
+	for _, file := range files {
+		d := Declared{File: filepath.Base(file)}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		def := &Definition{}
+		if err := json.Unmarshal(data, def); err != nil {
+			d.Errors = []string{"invalid JSON: " + err.Error()}
+		} else {
+			d.Definition = def
+			d.Errors = def.Validate()
+		}
+		declared = append(declared, d)
+	}
+	return declared, nil
+}
+
+// FieldNames returns the names of the fields of the index, in order
+func (d *Definition) FieldNames() []string {
+	names := make([]string, 0, len(d.Index.Fields))
+	for _, field := range d.Index.Fields {
+		switch f := field.(type) {
+		case string:
+			names = append(names, f)
+		case map[string]interface{}:
+			for name := range f {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Validate returns what CouchDB or Fabric would refuse in the definition:
+// fields must be names or objects of a name and its sort direction, all in
+// the same direction, and the index must be of the json type
+func (d *Definition) Validate() []string {
+	errs := make([]string, 0)
+	if len(d.Index.Fields) == 0 {
+		errs = append(errs, "index.fields must list at least one field")
+	}
+	if d.Type != "" && d.Type != "json" {
+		errs = append(errs, fmt.Sprintf("type must be json, not '%s'", d.Type))
+	}
+
+	directions := make(map[string]bool)
+	seen := make(map[string]bool)
+	for i, field := range d.Index.Fields {
+		var name string
+		switch f := field.(type) {
+		case string:
+			name = f
+			directions["asc"] = true
+		case map[string]interface{}:
+			if len(f) != 1 {
+				errs = append(errs, fmt.Sprintf("field %d must have a single name", i))
+				continue
+			}
+			for n, dir := range f {
+				name = n
+				if dir != "asc" && dir != "desc" {
+					errs = append(errs, fmt.Sprintf("field '%s' must be sorted asc or desc", n))
+				} else {
+					directions[dir.(string)] = true
+				}
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("field %d must be a name or an object of a name and asc or desc", i))
+			continue
+		}
+
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Sprintf("field %d has an empty name", i))
+		} else if seen[name] {
+			errs = append(errs, fmt.Sprintf("field '%s' is listed twice", name))
+		}
+		seen[name] = true
+	}
+	if len(directions) > 1 {
+		errs = append(errs, "fields must all be sorted in the same direction")
+	}
+	return errs
+}
@@ -0,0 +1,177 @@
+package couchdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkTTL is how long whether queries of a shape are indexed is kept,
+// unless an index is created meanwhile
+const checkTTL = 5 * time.Minute
+
+// maxUnindexed limits the shapes of unindexed queries kept
+const maxUnindexed = 100
+
+// Unindexed is the shape of queries that ran without an index: the fields
+// of their selector and their sort. Values are not kept, as they may be
+// personal data.
+type Unindexed struct {
+	Fields    []string    `json:"fields"`
+	Sort      interface{} `json:"sort,omitempty"`
+	Count     uint64      `json:"count"`
+	FirstSeen time.Time   `json:"firstSeen"`
+	LastSeen  time.Time   `json:"lastSeen"`
+}
+
+type check struct {
+	indexed bool
+	at      time.Time
+}
+
+var (
+	checksMutex sync.Mutex
+	checks      = make(map[string]check)
+	unindexed   = make(map[string]*Unindexed)
+)
+
+// Check reports whether the rich query would run without an index, as a
+// warning for its caller, and records its shape if so. Queries of a shape
+// are explained to CouchDB once every few minutes. Queries are assumed
+// indexed when no state database is configured or it cannot explain them.
+func Check(query map[string]interface{}) string {
+	if _, ok := query["selector"]; !Enabled() || !ok {
+		return ""
+	}
+	fields, shape := Shape(query)
+
+	checksMutex.Lock()
+	c, ok := checks[shape]
+	checksMutex.Unlock()
+	if !ok || time.Since(c.at) > checkTTL {
+		index, err := Explain(query)
+		if err != nil {
+			log.Println("failed to explain query to the state database: ", err)
+		}
+		c = check{indexed: err != nil || index != nil, at: time.Now()}
+		checksMutex.Lock()
+		checks[shape] = c
+		checksMutex.Unlock()
+	}
+	if c.indexed {
+		return ""
+	}
+
+	now := time.Now().UTC()
+	checksMutex.Lock()
+	u, ok := unindexed[shape]
+	if !ok {
+		if len(unindexed) >= maxUnindexed {
+			evictOldest()
+		}
+		u = &Unindexed{Fields: fields, Sort: query["sort"], FirstSeen: now}
+		unindexed[shape] = u
+		log.Printf("query on %s runs without an index\n", strings.Join(fields, ", "))
+	}
+	u.Count++
+	u.LastSeen = now
+	checksMutex.Unlock()
+
+	return fmt.Sprintf("the query on %s runs without an index of the state database", strings.Join(fields, ", "))
+}
+
+// evictOldest drops the shape seen least recently. The caller must hold the
+// mutex.
+func evictOldest() {
+	var oldest string
+	for shape, u := range unindexed {
+		if oldest == "" || u.LastSeen.Before(unindexed[oldest].LastSeen) {
+			oldest = shape
+		}
+	}
+	delete(unindexed, oldest)
+}
+
+// resetChecks forgets whether queries are indexed, once indexes change
+func resetChecks() {
+	checksMutex.Lock()
+	defer checksMutex.Unlock()
+	checks = make(map[string]check)
+}
+
+// GetUnindexed returns the shapes of the queries that ran without an index,
+// the most frequent first
+func GetUnindexed() []Unindexed {
+	checksMutex.Lock()
+	defer checksMutex.Unlock()
+	list := make([]Unindexed, 0, len(unindexed))
+	for _, u := range unindexed {
+		list = append(list, *u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Count > list[j].Count
+	})
+	return list
+}
+
+// Shape returns the fields the selector of the query matches, sorted, and a
+// key identifying queries on the same fields with the same sort, whatever
+// their values
+func Shape(query map[string]interface{}) ([]string, string) {
+	fields := selectorFields(query["selector"], "", nil)
+	sort.Strings(fields)
+	sortJSON, _ := json.Marshal(query["sort"])
+	return fields, strings.Join(fields, ",") + "|" + string(sortJSON)
+}
+
+// selectorFields returns the dotted paths of the fields the selector
+// matches, through its combination operators
+func selectorFields(selector interface{}, prefix string, fields []string) []string {
+	switch s := selector.(type) {
+	case map[string]interface{}:
+		for key, value := range s {
+			if strings.HasPrefix(key, "$") {
+				fields = selectorFields(value, prefix, fields)
+				continue
+			}
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			if nested, ok := value.(map[string]interface{}); ok && !isOperatorObject(nested) {
+				fields = selectorFields(nested, path, fields)
+			} else if !contains(fields, path) {
+				fields = append(fields, path)
+			}
+		}
+	case []interface{}:
+		for _, item := range s {
+			fields = selectorFields(item, prefix, fields)
+		}
+	}
+	return fields
+}
+
+// isOperatorObject reports whether the object holds operators, such as
+// {"$gt": 1}, rather than nested fields
+func isOperatorObject(object map[string]interface{}) bool {
+	for key := range object {
+		if strings.HasPrefix(key, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Package dashboard serves a small admin web dashboard with the recent
+// transactions, chaincode events, health status and latency of the CCAPI
+package dashboard
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// Number of requests and events kept in memory
+const historySize = 200
+
+// Request is an API call handled by the CCAPI
+type Request struct {
+	Time    time.Time `json:"time"`
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	TxName  string    `json:"txName"`
+	TxID    string    `json:"txId,omitempty"`
+	Status  int       `json:"status"`
+	Latency float64   `json:"latencyMs"`
+}
+
+// Event is a chaincode event received by the CCAPI
+type Event struct {
+	Time        time.Time `json:"time"`
+	Name        string    `json:"name"`
+	TxID        string    `json:"txId"`
+	BlockNumber uint64    `json:"blockNumber"`
+	Payload     string    `json:"payload"`
+}
+
+// LatencyStats summarizes the latency of a transaction in milliseconds
+type LatencyStats struct {
+	TxName string  `json:"txName"`
+	Count  int     `json:"count"`
+	Errors int     `json:"errors"`
+	Avg    float64 `json:"avg"`
+	P95    float64 `json:"p95"`
+	Max    float64 `json:"max"`
+}
+
+var (
+	mu        sync.Mutex
+	requests  []Request
+	events    []Event
+	startTime = time.Now()
+)
+
+// Middleware records every request handled by the group
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		RecordRequest(Request{
+			Time:    start,
+			Method:  c.Request.Method,
+			Path:    c.Request.URL.Path,
+			TxName:  c.Param("txname"),
+			TxID:    common.GetTxID(c),
+			Status:  c.Writer.Status(),
+			Latency: float64(time.Since(start).Microseconds()) / 1000,
+		})
+	}
+}
+
+// RecordRequest stores a request, discarding the oldest one when full
+func RecordRequest(r Request) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	requests = append(requests, r)
+	if len(requests) > historySize {
+		requests = requests[len(requests)-historySize:]
+	}
+}
+
+// RecordEvent stores a chaincode event, discarding the oldest one when full
+func RecordEvent(e Event) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	events = append(events, e)
+	if len(events) > historySize {
+		events = events[len(events)-historySize:]
+	}
+}
+
+// RecentRequests returns the stored requests, newest first
+func RecentRequests() []Request {
+	mu.Lock()
+	defer mu.Unlock()
+
+	res := make([]Request, len(requests))
+	for i, r := range requests {
+		res[len(requests)-1-i] = r
+	}
+	return res
+}
+
+// RecentEvents returns the stored events, newest first
+func RecentEvents() []Event {
+	mu.Lock()
+	defer mu.Unlock()
+
+	res := make([]Event, len(events))
+	for i, e := range events {
+		res[len(events)-1-i] = e
+	}
+	return res
+}
+
+// Latencies returns the latency statistics of the stored requests by
+// transaction name
+func Latencies() []LatencyStats {
+	mu.Lock()
+	byTx := make(map[string][]Request)
+	for _, r := range requests {
+		name := r.TxName
+		if name == "" {
+			name = strings.TrimPrefix(r.Path, "/api/")
+		}
+		byTx[name] = append(byTx[name], r)
+	}
+	mu.Unlock()
+
+	stats := make([]LatencyStats, 0, len(byTx))
+	for name, reqs := range byTx {
+		latencies := make([]float64, len(reqs))
+		st := LatencyStats{TxName: name, Count: len(reqs)}
+		sum := 0.0
+		for i, r := range reqs {
+			latencies[i] = r.Latency
+			sum += r.Latency
+			if r.Status >= 400 {
+				st.Errors++
+			}
+		}
+		sort.Float64s(latencies)
+
+		st.Avg = sum / float64(len(reqs))
+		st.P95 = latencies[(len(latencies)*95-1)/100]
+		st.Max = latencies[len(latencies)-1]
+		stats = append(stats, st)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].TxName < stats[j].TxName
+	})
+	return stats
+}
+
+// Uptime returns how long the CCAPI has been running
+func Uptime() time.Duration {
+	return time.Since(startTime)
+}
@@ -0,0 +1,225 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/cassette"
+	"github.com/hyperledger-labs/ccapi/checkpoint"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/maintenance"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger-labs/ccapi/oidc"
+	"github.com/hyperledger-labs/ccapi/siem"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/hyperledger-labs/ccapi/webhook"
+	"github.com/pkg/errors"
+)
+
+//go:embed static
+var staticFS embed.FS
+
+// Enabled reports whether the dashboard is served. It requires the admin
+// password to be set in DASHBOARD_ADMIN_PASSWORD, or login with an OpenID
+// Connect provider
+func Enabled() bool {
+	return os.Getenv("DASHBOARD_ADMIN_PASSWORD") != "" || oidc.Enabled()
+}
+
+// AddRoutes registers the dashboard pages and data endpoints, protected by
+// a login of an admin when OpenID Connect is enabled, or else by basic
+// authentication with the admin credentials
+func AddRoutes(rg *gin.RouterGroup) {
+	// Forward the changes made by admins to the SIEM
+	rg.Use(siem.AdminActions())
+
+	if oidc.Enabled() {
+		rg.Use(oidc.RequireAdmin())
+	} else {
+		adminUser := os.Getenv("DASHBOARD_ADMIN_USER")
+		if adminUser == "" {
+			adminUser = "admin"
+		}
+		rg.Use(gin.BasicAuth(gin.Accounts{
+			adminUser: os.Getenv("DASHBOARD_ADMIN_PASSWORD"),
+		}))
+	}
+
+	static, _ := fs.Sub(staticFS, "static")
+	rg.StaticFS("/ui", http.FS(static))
+	rg.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, c.FullPath()+"ui/")
+	})
+
+	rg.GET("/api/transactions", func(c *gin.Context) {
+		c.JSON(http.StatusOK, RecentRequests())
+	})
+	rg.GET("/api/events", func(c *gin.Context) {
+		c.JSON(http.StatusOK, RecentEvents())
+	})
+	rg.GET("/api/latency", func(c *gin.Context) {
+		c.JSON(http.StatusOK, Latencies())
+	})
+	rg.GET("/api/health", health)
+	rg.GET("/api/queues", func(c *gin.Context) {
+		c.JSON(http.StatusOK, txqueue.GetStats())
+	})
+	rg.GET("/api/maintenance", func(c *gin.Context) {
+		c.JSON(http.StatusOK, maintenance.Get())
+	})
+	rg.PUT("/api/maintenance", setMaintenance)
+	rg.GET("/api/features", func(c *gin.Context) {
+		c.JSON(http.StatusOK, features.List())
+	})
+	rg.PUT("/api/features/:name", setFeature)
+	rg.GET("/api/deadletters", func(c *gin.Context) {
+		c.JSON(http.StatusOK, webhook.DeadLetters(c.Query("type")))
+	})
+	rg.POST("/api/deadletters/:id/redrive", redriveDeadLetter)
+	rg.DELETE("/api/deadletters/:id", discardDeadLetter)
+	rg.GET("/api/checkpoints", func(c *gin.Context) {
+		c.JSON(http.StatusOK, checkpoint.List())
+	})
+	rg.PUT("/api/checkpoints", setCheckpoint)
+}
+
+// setCheckpoint rewinds or fast-forwards an event consumer to a block,
+// taking effect when the CCAPI restarts
+func setCheckpoint(c *gin.Context) {
+	var body struct {
+		Consumer    string  `json:"consumer"`
+		BlockNumber *uint64 `json:"blockNumber"`
+	}
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	if body.Consumer == "" || body.BlockNumber == nil {
+		common.Abort(c, http.StatusBadRequest, errors.New("missing 'consumer' or 'blockNumber'"))
+		return
+	}
+
+	cp, err := checkpoint.Set(body.Consumer, *body.BlockNumber)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	log.Printf("checkpoint of %s set to block %d\n", cp.Consumer, cp.BlockNumber)
+	c.JSON(http.StatusOK, cp)
+}
+
+// redriveDeadLetter delivers a dead-lettered webhook event again
+func redriveDeadLetter(c *gin.Context) {
+	letter, err := webhook.Redrive(c.Param("id"))
+	if _, ok := err.(*common.APIError); ok {
+		common.Abort(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"delivered":  false,
+			"deadLetter": letter,
+		})
+		return
+	}
+	log.Printf("dead letter %s redriven to %s\n", letter.ID, letter.URL)
+	c.JSON(http.StatusOK, gin.H{
+		"delivered":  true,
+		"deadLetter": letter,
+	})
+}
+
+// discardDeadLetter drops a dead-lettered webhook event
+func discardDeadLetter(c *gin.Context) {
+	err := webhook.Discard(c.Param("id"))
+	if err != nil {
+		common.Abort(c, http.StatusNotFound, err)
+		return
+	}
+	log.Printf("dead letter %s discarded\n", c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
+
+// setFeature enables or disables an experimental feature
+func setFeature(c *gin.Context) {
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	if body.Enabled == nil {
+		common.Abort(c, http.StatusBadRequest, errors.New("missing 'enabled'"))
+		return
+	}
+
+	flag, err := features.Set(c.Param("name"), *body.Enabled)
+	if err != nil {
+		common.Abort(c, http.StatusNotFound, err)
+		return
+	}
+	log.Printf("feature '%s' set to %t\n", flag.Name, flag.Enabled)
+	c.JSON(http.StatusOK, flag)
+}
+
+// setMaintenance turns read-only mode on or off
+func setMaintenance(c *gin.Context) {
+	var body struct {
+		ReadOnly bool       `json:"readOnly"`
+		Reason   string     `json:"reason"`
+		Until    *time.Time `json:"until"`
+	}
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	state := maintenance.Set(body.ReadOnly, body.Reason, body.Until)
+	log.Printf("read-only mode set to %t: %s\n", state.ReadOnly, state.Reason)
+	c.JSON(http.StatusOK, state)
+}
+
+func health(c *gin.Context) {
+	backend := "fabric"
+	if mock.Enabled() {
+		backend = "mock"
+	} else if cassette.Playback() {
+		backend = "cassette"
+	}
+
+	total, errors := 0, 0
+	for _, r := range RecentRequests() {
+		total++
+		if r.Status >= 500 {
+			errors++
+		}
+	}
+
+	status := "ok"
+	if total > 0 && errors*2 > total {
+		status = "degraded"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       status,
+		"uptime":       Uptime().Round(1e9).String(),
+		"backend":      backend,
+		"channel":      os.Getenv("CHANNEL"),
+		"chaincode":    os.Getenv("CCNAME"),
+		"gateway":      os.Getenv("FABRIC_GATEWAY_ENDPOINT"),
+		"queryGateway": os.Getenv("FABRIC_GATEWAY_QUERY_ENDPOINT"),
+		"requests":     total,
+		"serverErrors": errors,
+		"readOnly":     maintenance.Get().ReadOnly,
+	})
+}
@@ -0,0 +1,128 @@
+// Dashboard data is polled from the endpoints under ../api
+const api = (path) => fetch('../api/' + path).then((res) => res.json());
+
+const cell = (text, className) => {
+  const td = document.createElement('td');
+  td.textContent = text === undefined || text === null ? '' : text;
+  if (className) td.className = className;
+  return td;
+};
+
+const fillTable = (id, rows, toCells) => {
+  const tbody = document.querySelector('#' + id + ' tbody');
+  tbody.replaceChildren(...rows.map((row) => {
+    const tr = document.createElement('tr');
+    tr.append(...toCells(row));
+    return tr;
+  }));
+};
+
+const time = (t) => new Date(t).toLocaleTimeString();
+
+const drawChart = (stats) => {
+  const svg = document.getElementById('latency-chart');
+  const ns = 'http://www.w3.org/2000/svg';
+  const width = svg.clientWidth || 600;
+  const height = 200;
+  const max = Math.max(1, ...stats.map((s) => s.p95));
+  const slot = stats.length ? width / stats.length : width;
+  const bar = Math.min(40, slot / 3);
+
+  svg.replaceChildren();
+  stats.forEach((s, i) => {
+    [['avg', s.avg, 0], ['p95', s.p95, bar]].forEach(([name, value, offset]) => {
+      const h = (value / max) * (height - 20);
+      const rect = document.createElementNS(ns, 'rect');
+      rect.setAttribute('class', name);
+      rect.setAttribute('x', i * slot + offset + 4);
+      rect.setAttribute('y', height - 20 - h);
+      rect.setAttribute('width', bar);
+      rect.setAttribute('height', h);
+      const title = document.createElementNS(ns, 'title');
+      title.textContent = s.txName + ' ' + name + ': ' + value.toFixed(1) + ' ms';
+      rect.append(title);
+      svg.append(rect);
+    });
+
+    const label = document.createElementNS(ns, 'text');
+    label.setAttribute('x', i * slot + 4);
+    label.setAttribute('y', height - 4);
+    label.setAttribute('font-size', '11');
+    label.textContent = s.txName;
+    svg.append(label);
+  });
+};
+
+const showMaintenance = (state) => {
+  const status = document.getElementById('maintenance-status');
+  status.className = state.readOnly ? 'error' : '';
+  status.textContent = state.readOnly
+    ? 'Read-only since ' + time(state.since) + (state.reason ? ': ' + state.reason : '')
+    : 'Accepting transactions';
+  document.getElementById('maintenance-toggle').textContent = state.readOnly ? 'Disable read-only mode' : 'Enable read-only mode';
+  document.getElementById('maintenance').dataset.readOnly = state.readOnly;
+};
+
+document.getElementById('maintenance').addEventListener('submit', async (e) => {
+  e.preventDefault();
+  const until = document.getElementById('maintenance-until').value;
+  const res = await fetch('../api/maintenance', {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({
+      readOnly: e.target.dataset.readOnly !== 'true',
+      reason: document.getElementById('maintenance-reason').value,
+      until: until ? new Date(until).toISOString() : null,
+    }),
+  });
+  showMaintenance(await res.json());
+});
+
+const toggle = (flag) => {
+  const td = document.createElement('td');
+  const input = document.createElement('input');
+  input.type = 'checkbox';
+  input.checked = flag.enabled;
+  input.addEventListener('change', async () => {
+    await fetch('../api/features/' + flag.name, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ enabled: input.checked }),
+    });
+  });
+  td.append(input);
+  return td;
+};
+
+const refresh = async () => {
+  try {
+    const [health, latency, transactions, events, maintenance, features] = await Promise.all([
+      api('health'), api('latency'), api('transactions'), api('events'), api('maintenance'), api('features'),
+    ]);
+    showMaintenance(maintenance);
+    fillTable('features', features, (f) => [
+      cell(f.name, 'mono'), cell(f.description), cell(f.default ? 'on' : 'off'), toggle(f),
+    ]);
+
+    const h = document.getElementById('health');
+    h.className = 'health ' + health.status;
+    h.textContent = health.status + ' | ' + health.backend + ' | ' + health.channel + '/' + health.chaincode + ' | up ' + health.uptime;
+
+    drawChart(latency);
+    fillTable('latency', latency, (s) => [
+      cell(s.txName), cell(s.count), cell(s.errors), cell(s.avg.toFixed(1)), cell(s.p95.toFixed(1)), cell(s.max.toFixed(1)),
+    ]);
+    fillTable('transactions', transactions, (r) => [
+      cell(time(r.time)), cell(r.method), cell(r.txName || r.path), cell(r.status, r.status >= 400 ? 'error' : ''),
+      cell(r.latencyMs.toFixed(1)), cell(r.txId, 'mono'),
+    ]);
+    fillTable('events', events, (e) => [
+      cell(time(e.time)), cell(e.name), cell(e.blockNumber), cell(e.txId, 'mono'), cell(e.payload, 'mono'),
+    ]);
+  } catch (err) {
+    document.getElementById('health').textContent = 'unreachable';
+  }
+};
+
+refresh();
+setInterval(refresh, 3000);
@@ -0,0 +1,258 @@
+// Package did resolves did:fabric identifiers of the organizations of a
+// channel to W3C DID documents (https://www.w3.org/TR/did-core/), so
+// systems outside the network can verify who the MSP in @lastTouchBy is.
+// The DID of an organization is did:fabric:{channel}:{mspId}, and its
+// document lists the keys of the root and intermediate CAs of the MSP, as
+// found in the configuration of the channel, which certify the identities
+// of its members.
+package did
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	protos "github.com/hyperledger/fabric-protos-go-apiv2/common"
+	mspprotos "github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// Method is the DID method of the identifiers
+const Method = "fabric"
+
+// MSP is the membership service provider of an organization of a channel
+type MSP struct {
+	ID                string
+	RootCerts         []*x509.Certificate
+	IntermediateCerts []*x509.Certificate
+}
+
+// ID returns the DID of the organization of the channel with the MSP
+func ID(channel, mspID string) string {
+	return "did:" + Method + ":" + channel + ":" + mspID
+}
+
+// Parse returns the channel and MSP of a DID of an organization
+func Parse(did string) (channel, mspID string, err error) {
+	parts := strings.Split(did, ":")
+	if len(parts) != 4 || parts[0] != "did" || parts[1] != Method || parts[2] == "" || parts[3] == "" {
+		return "", "", common.NewAPIError(http.StatusBadRequest, "invalid DID, expected did:"+Method+":{channel}:{mspId}")
+	}
+	return parts[2], parts[3], nil
+}
+
+// MSPs returns the MSPs of the organizations in the configuration block of
+// a channel, by id, including those of the orderers
+func MSPs(block []byte) (map[string]*MSP, error) {
+	var b protos.Block
+	err := proto.Unmarshal(block, &b)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal config block")
+	}
+	if b.Data == nil || len(b.Data.Data) == 0 {
+		return nil, errors.New("config block has no transactions")
+	}
+
+	var envelope protos.Envelope
+	if err := proto.Unmarshal(b.Data.Data[0], &envelope); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal config envelope")
+	}
+	var payload protos.Payload
+	if err := proto.Unmarshal(envelope.Payload, &payload); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal config payload")
+	}
+	var config protos.ConfigEnvelope
+	if err := proto.Unmarshal(payload.Data, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal channel config")
+	}
+
+	msps := make(map[string]*MSP)
+	if config.Config == nil || config.Config.ChannelGroup == nil {
+		return msps, nil
+	}
+	for _, section := range []string{"Application", "Orderer"} {
+		group := config.Config.ChannelGroup.Groups[section]
+		if group == nil {
+			continue
+		}
+		for _, org := range group.Groups {
+			msp, err := orgMSP(org)
+			if err != nil {
+				return nil, err
+			}
+			if msp != nil {
+				msps[msp.ID] = msp
+			}
+		}
+	}
+	return msps, nil
+}
+
+// orgMSP returns the MSP of the config group of an organization, nil if it
+// has none
+func orgMSP(org *protos.ConfigGroup) (*MSP, error) {
+	value := org.Values["MSP"]
+	if value == nil {
+		return nil, nil
+	}
+	var mspConfig mspprotos.MSPConfig
+	if err := proto.Unmarshal(value.Value, &mspConfig); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal MSP config")
+	}
+	var fabricConfig mspprotos.FabricMSPConfig
+	if err := proto.Unmarshal(mspConfig.Config, &fabricConfig); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal Fabric MSP config")
+	}
+
+	msp := &MSP{ID: fabricConfig.Name}
+	var err error
+	msp.RootCerts, err = parseCerts(fabricConfig.RootCerts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid root certificate of %s", msp.ID)
+	}
+	msp.IntermediateCerts, err = parseCerts(fabricConfig.IntermediateCerts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid intermediate certificate of %s", msp.ID)
+	}
+	return msp, nil
+}
+
+// parseCerts parses PEM encoded certificates
+func parseCerts(pems [][]byte) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0, len(pems))
+	for _, data := range pems {
+		cert, err := identity.CertificateFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// Document returns the DID document of the organization of the channel with
+// the MSP
+func Document(channel string, msp *MSP) map[string]interface{} {
+	id := ID(channel, msp.ID)
+	methods := make([]map[string]interface{}, 0)
+	refs := make([]string, 0)
+	for _, cert := range append(append([]*x509.Certificate{}, msp.RootCerts...), msp.IntermediateCerts...) {
+		jwk := publicKeyJWK(cert)
+		if jwk == nil {
+			continue
+		}
+		methodID := id + "#" + Fingerprint(cert)[:16]
+		methods = append(methods, map[string]interface{}{
+			"id":           methodID,
+			"type":         "JsonWebKey2020",
+			"controller":   id,
+			"publicKeyJwk": jwk,
+		})
+		refs = append(refs, methodID)
+	}
+
+	return map[string]interface{}{
+		"@context": []string{
+			"https://www.w3.org/ns/did/v1",
+			"https://w3id.org/security/suites/jws-2020/v1",
+		},
+		"id":                 id,
+		"alsoKnownAs":        []string{"urn:ccapi:msp:" + msp.ID},
+		"verificationMethod": methods,
+		"assertionMethod":    refs,
+	}
+}
+
+// Verify checks that the certificate was issued by a CA of the MSP,
+// returning why it was not
+func (msp *MSP) Verify(cert *x509.Certificate) []string {
+	roots := x509.NewCertPool()
+	for _, c := range msp.RootCerts {
+		roots.AddCert(c)
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range msp.IntermediateCerts {
+		intermediates.AddCert(c)
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return []string{err.Error()}
+	}
+	return []string{}
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the certificate, hex
+// encoded
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// SortedIDs returns the ids of the MSPs, sorted
+func SortedIDs(msps map[string]*MSP) []string {
+	ids := make([]string, 0, len(msps))
+	for id := range msps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// publicKeyJWK returns the public key of the certificate as a JWK, with the
+// certificate in x5c, nil if its type is not supported
+func publicKeyJWK(cert *x509.Certificate) map[string]interface{} {
+	var jwk map[string]interface{}
+	switch k := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk = map[string]interface{}{
+			"kty": "EC",
+			"crv": k.Curve.Params().Name,
+			"x":   b64(padded(k.X, size)),
+			"y":   b64(padded(k.Y, size)),
+		}
+	case ed25519.PublicKey:
+		jwk = map[string]interface{}{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   b64(k),
+		}
+	case *rsa.PublicKey:
+		jwk = map[string]interface{}{
+			"kty": "RSA",
+			"n":   b64(k.N.Bytes()),
+			"e":   b64(big.NewInt(int64(k.E)).Bytes()),
+		}
+	default:
+		return nil
+	}
+	jwk["x5c"] = []string{base64.StdEncoding.EncodeToString(cert.Raw)}
+	return jwk
+}
+
+// padded returns the big-endian bytes of n, left padded to size
+func padded(n *big.Int, size int) []byte {
+	out := make([]byte, size)
+	return n.FillBytes(out)
+}
+
+// b64 encodes data as base64url without padding, as in JWKs
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,180 @@
+// Package eventschema keeps a registry of JSON Schemas of the payloads of
+// the chaincode events, so event consumers can code against stable
+// contracts. Incoming events are validated against the schema of their
+// name, and the registry is served by the API.
+//
+// The schemas of the events of the demo chaincode are built in, and
+// EVENT_SCHEMAS may name a directory of {eventName}.json files adding
+// schemas or replacing the built-in ones.
+package eventschema
+
+import (
+	"embed"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed schemas/*.json
+var builtinFS embed.FS
+
+// Entry is a schema of the registry with its validation statistics
+type Entry struct {
+	Event       string `json:"event"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Source      string `json:"source"`
+
+	Validated      int        `json:"validated"`
+	Invalid        int        `json:"invalid"`
+	LastInvalidAt  *time.Time `json:"lastInvalidAt,omitempty"`
+	LastViolations []string   `json:"lastViolations,omitempty"`
+
+	schema Schema
+}
+
+var (
+	registry     map[string]*Entry
+	registryErr  error
+	registryOnce sync.Once
+	mutex        sync.Mutex
+)
+
+// Strict reports whether events violating their schema are dropped instead
+// of handled, set by EVENT_SCHEMA_STRICT
+func Strict() bool {
+	return os.Getenv("EVENT_SCHEMA_STRICT") == "true"
+}
+
+// load reads the built-in schemas and then the ones in EVENT_SCHEMAS
+func load() (map[string]*Entry, error) {
+	registryOnce.Do(func() {
+		registry = make(map[string]*Entry)
+
+		files, _ := builtinFS.ReadDir("schemas")
+		for _, file := range files {
+			data, _ := builtinFS.ReadFile("schemas/" + file.Name())
+			registryErr = add(file.Name(), data, "builtin")
+			if registryErr != nil {
+				return
+			}
+		}
+
+		dir := os.Getenv("EVENT_SCHEMAS")
+		if dir == "" {
+			return
+		}
+		paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			registryErr = errors.Wrap(err, "failed to list event schemas")
+			return
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				registryErr = errors.Wrap(err, "failed to read event schema")
+				return
+			}
+			registryErr = add(filepath.Base(path), data, path)
+			if registryErr != nil {
+				return
+			}
+		}
+	})
+
+	return registry, registryErr
+}
+
+// add registers the schema in the file named after its event
+func add(fileName string, data []byte, source string) error {
+	event := strings.TrimSuffix(fileName, ".json")
+
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return errors.Wrapf(err, "invalid schema of event '%s'", event)
+	}
+
+	entry := &Entry{
+		Event:  event,
+		Source: source,
+		schema: schema,
+	}
+	entry.ID, _ = schema["$id"].(string)
+	entry.Title, _ = schema["title"].(string)
+	entry.Description, _ = schema["description"].(string)
+	registry[event] = entry
+	return nil
+}
+
+// List returns the registered schemas, sorted by event
+func List() ([]Entry, error) {
+	entries, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	res := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		res = append(res, *entry)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Event < res[j].Event
+	})
+	return res, nil
+}
+
+// Get returns the schema of the event
+func Get(event string) (Schema, bool, error) {
+	entries, err := load()
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry, ok := entries[event]
+	if !ok {
+		return nil, false, nil
+	}
+	return entry.schema, true, nil
+}
+
+// Validate checks the payload of an event received from the chaincode
+// against the schema of the event, returning the violations. Events without
+// a schema are not validated.
+func Validate(event string, payload []byte) ([]string, error) {
+	entries, err := load()
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := entries[event]
+	if !ok {
+		return nil, nil
+	}
+
+	var value interface{}
+	violations := []string{"$: payload is not valid JSON"}
+	if json.Unmarshal(payload, &value) == nil {
+		violations = entry.schema.Validate(value)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	entry.Validated++
+	if len(violations) > 0 {
+		now := time.Now()
+		entry.Invalid++
+		entry.LastInvalidAt = &now
+		entry.LastViolations = violations
+	}
+	return violations, nil
+}
@@ -0,0 +1,242 @@
+package eventschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schema is a JSON Schema. The validator implements the keywords used to
+// describe event payloads: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, format (date-time), minimum and maximum. Other keywords, such as
+// title, description and $id, are kept in the registry but not validated.
+type Schema map[string]interface{}
+
+// ParseSchema parses a JSON Schema, checking the validator can apply it
+func ParseSchema(data []byte) (Schema, error) {
+	var schema Schema
+	err := json.Unmarshal(data, &schema)
+	if err != nil {
+		return nil, err
+	}
+	err = schema.check("$")
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// knownTypes are the values of the type keyword
+var knownTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true,
+	"object": true, "array": true, "null": true,
+}
+
+// check reports keywords with values the validator cannot apply, so broken
+// schemas fail when they are loaded instead of when an event arrives
+func (s Schema) check(path string) error {
+	for _, t := range s.types() {
+		if !knownTypes[t] {
+			return errors.Errorf("%s: unknown type '%s'", path, t)
+		}
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.Errorf("%s: invalid pattern: %s", path, err)
+		}
+	}
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		for name, prop := range props {
+			sub, ok := prop.(map[string]interface{})
+			if !ok {
+				return errors.Errorf("%s.%s: schema must be an object", path, name)
+			}
+			if err := Schema(sub).check(path + "." + name); err != nil {
+				return err
+			}
+		}
+	}
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		if err := Schema(items).check(path + "[]"); err != nil {
+			return err
+		}
+	}
+	if additional, ok := s["additionalProperties"].(map[string]interface{}); ok {
+		if err := Schema(additional).check(path + ".*"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// types returns the types allowed by the schema, empty for any type
+func (s Schema) types() []string {
+	switch t := s["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if str, ok := item.(string); ok {
+				types = append(types, str)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// Validate returns the violations of the schema by the value, which is a
+// decoded JSON document. Each violation names the path of the value, $ being
+// the whole payload.
+func (s Schema) Validate(value interface{}) []string {
+	violations := make([]string, 0)
+	s.validate("$", value, &violations)
+	return violations
+}
+
+func (s Schema) validate(path string, value interface{}, violations *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if types := s.types(); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if typeOf(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("must be %s", strings.Join(types, " or "))
+			return
+		}
+	}
+
+	if constant, ok := s["const"]; ok && !reflect.DeepEqual(value, constant) {
+		fail("must be %v", constant)
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range enum {
+			if reflect.DeepEqual(value, option) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %v", enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := s["minLength"].(float64); ok && length < min {
+			fail("must have at least %v characters", min)
+		}
+		if max, ok := s["maxLength"].(float64); ok && length > max {
+			fail("must have at most %v characters", max)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			if matched, _ := regexp.MatchString(pattern, v); !matched {
+				fail("must match %s", pattern)
+			}
+		}
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				fail("must be a RFC 3339 date-time")
+			}
+		}
+	case float64:
+		if min, ok := s["minimum"].(float64); ok && v < min {
+			fail("must be at least %v", min)
+		}
+		if max, ok := s["maximum"].(float64); ok && v > max {
+			fail("must be at most %v", max)
+		}
+	case []interface{}:
+		if min, ok := s["minItems"].(float64); ok && float64(len(v)) < min {
+			fail("must have at least %v items", min)
+		}
+		if max, ok := s["maxItems"].(float64); ok && float64(len(v)) > max {
+			fail("must have at most %v items", max)
+		}
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				Schema(items).validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	case map[string]interface{}:
+		s.validateObject(path, v, violations)
+	}
+}
+
+func (s Schema) validateObject(path string, obj map[string]interface{}, violations *[]string) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				*violations = append(*violations, fmt.Sprintf("%s.%s: is required", path, name))
+			}
+		}
+	}
+
+	props, _ := s["properties"].(map[string]interface{})
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if prop, ok := props[name].(map[string]interface{}); ok {
+			Schema(prop).validate(path+"."+name, obj[name], violations)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*violations = append(*violations, fmt.Sprintf("%s.%s: is not allowed", path, name))
+			}
+		case map[string]interface{}:
+			Schema(additional).validate(path+"."+name, obj[name], violations)
+		}
+	}
+}
+
+// typeOf reports whether the decoded JSON value is of the JSON Schema type
+func typeOf(value interface{}, t string) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+// Package features gates experimental endpoints behind flags, so operators
+// can enable them per environment with FEATURE_FLAGS, e.g.
+// "rangeProofs=false,anchors=true", and toggle them at runtime from the
+// dashboard, without rebuilding the CCAPI.
+package features
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// Flag gates an experimental feature
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// flags lists the experimental features. Features released before the
+// flags existed are enabled by default, new ones should not be.
+var flags = []*Flag{
+	{Name: "rangeProofs", Description: "Range proofs over committed values (/api/rangeproofs)", Default: true},
+	{Name: "inclusionProofs", Description: "Transaction inclusion proofs (/api/{channel}/proof/{txid})", Default: true},
+	{Name: "anchors", Description: "Anchoring the ledger to an external endpoint (/api/anchors)", Default: true},
+	{Name: "binaryPayloads", Description: "CBOR and protobuf request and response bodies (/api/schema.proto)"},
+	{Name: "jsonLD", Description: "JSON-LD context of the assets and JSON-LD responses (/api/context.jsonld)"},
+	{Name: "verifiableCredentials", Description: "Verifiable credentials attesting to ledger state (/api/credentials)"},
+	{Name: "didResolution", Description: "DID documents of the organizations of the channels (/api/did)"},
+	{Name: "tokenSDK", Description: "Token operations proxied to a Fabric Token SDK application (/api/tokensdk)"},
+	{Name: "assetQRCodes", Description: "QR codes of asset labels and the resolver of scanned codes (/api/assets/resolve)"},
+	{Name: "transactionReceipts", Description: "JSON and PDF receipts of committed transactions (/api/{channel}/receipt/{txid})"},
+}
+
+var (
+	mutex sync.RWMutex
+	once  sync.Once
+)
+
+// initFlags sets the flags from their defaults and FEATURE_FLAGS
+func initFlags() {
+	once.Do(func() {
+		for _, flag := range flags {
+			flag.Enabled = flag.Default
+		}
+
+		config := os.Getenv("FEATURE_FLAGS")
+		if config == "" {
+			return
+		}
+		for _, setting := range strings.Split(config, ",") {
+			parts := strings.SplitN(strings.TrimSpace(setting), "=", 2)
+			enabled := true
+			if len(parts) == 2 {
+				var err error
+				enabled, err = strconv.ParseBool(parts[1])
+				if err != nil {
+					log.Printf("ignoring feature flag '%s': %s\n", setting, err)
+					continue
+				}
+			}
+
+			flag := find(parts[0])
+			if flag == nil {
+				log.Printf("ignoring unknown feature flag '%s'\n", parts[0])
+				continue
+			}
+			flag.Enabled = enabled
+		}
+	})
+}
+
+func find(name string) *Flag {
+	for _, flag := range flags {
+		if flag.Name == name {
+			return flag
+		}
+	}
+	return nil
+}
+
+// List returns the flags with their current state
+func List() []Flag {
+	initFlags()
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	list := make([]Flag, 0, len(flags))
+	for _, flag := range flags {
+		list = append(list, *flag)
+	}
+	return list
+}
+
+// Enabled reports whether the feature is enabled
+func Enabled(name string) bool {
+	initFlags()
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	flag := find(name)
+	return flag != nil && flag.Enabled
+}
+
+// Set enables or disables the feature at runtime
+func Set(name string, enabled bool) (Flag, error) {
+	initFlags()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	flag := find(name)
+	if flag == nil {
+		return Flag{}, common.NewAPIError(http.StatusNotFound, fmt.Sprintf("unknown feature '%s'", name))
+	}
+	flag.Enabled = enabled
+	return *flag, nil
+}
+
+// Require only lets requests through while the feature is enabled, and
+// answers 404 otherwise
+func Require(name string) gin.HandlerFunc {
+	if find(name) == nil {
+		log.Panicf("unknown feature '%s'", name)
+	}
+
+	return func(c *gin.Context) {
+		if !Enabled(name) {
+			common.Abort(c, http.StatusNotFound, errors.Errorf("feature '%s' is disabled", name))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
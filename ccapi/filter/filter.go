@@ -0,0 +1,403 @@
+// Package filter translates the filter and sort query syntax of the asset
+// listing routes into CouchDB selectors and sort definitions, for example:
+//
+//	?filter=published>2020 AND genres=="scifi"&sort=-title
+//
+// Comparisons (==, !=, >, >=, <, <=, =~ for regular expressions) can be
+// combined with AND, OR, NOT and parentheses. Values are JSON strings,
+// numbers, true, false or null.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Fields maps the properties that can be filtered to their cc-tools data type
+type Fields map[string]string
+
+// metadataFields are set by cc-tools on every asset
+var metadataFields = Fields{
+	"@key":         "string",
+	"@assetType":   "string",
+	"@lastTouchBy": "string",
+	"@lastTx":      "string",
+	"@lastUpdated": "datetime",
+}
+
+// dataType returns the data type of a possibly dotted field, which is only
+// allowed on asset references
+func (f Fields) dataType(field string) (string, error) {
+	parts := strings.SplitN(field, ".", 2)
+	dataType, ok := f[parts[0]]
+	if !ok {
+		dataType, ok = metadataFields[parts[0]]
+	}
+	if !ok {
+		return "", errors.Errorf("unknown field '%s'", parts[0])
+	}
+
+	if len(parts) == 2 {
+		if !strings.HasPrefix(strings.TrimPrefix(dataType, "[]"), "->") {
+			return "", errors.Errorf("field '%s' is not an asset reference", parts[0])
+		}
+		return "", nil
+	}
+	return dataType, nil
+}
+
+// Parse translates a filter expression into a CouchDB selector, checking
+// fields and values against the asset properties
+func Parse(expr string, fields Fields) (map[string]interface{}, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: fields}
+	selector, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, errors.Errorf("unexpected '%s'", p.tokens[p.pos].text)
+	}
+	return selector, nil
+}
+
+// ParseSort translates a comma separated list of fields, prefixed by '-'
+// for descending order, into a CouchDB sort definition
+func ParseSort(sort string, fields Fields) ([]map[string]string, error) {
+	res := make([]map[string]string, 0)
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		order := "asc"
+		if strings.HasPrefix(field, "-") {
+			field = field[1:]
+			order = "desc"
+		} else {
+			field = strings.TrimPrefix(field, "+")
+		}
+		if field == "" {
+			continue
+		}
+
+		if _, err := fields.dataType(field); err != nil {
+			return nil, err
+		}
+		res = append(res, map[string]string{field: order})
+	}
+	return res, nil
+}
+
+type tokenKind int
+
+const (
+	tokenField tokenKind = iota
+	tokenValue
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+}
+
+var operators = []string{"==", "!=", ">=", "<=", "=~", ">", "<"}
+
+func tokenize(expr string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(expr); {
+		ch := rune(expr[i])
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+		case ch == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, errors.New("unterminated string")
+			}
+			str, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, errors.Errorf("invalid string %s", expr[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokenValue, text: expr[i : end+1], value: str})
+			i = end + 1
+		case ch == '-' || unicode.IsDigit(ch):
+			end := i + 1
+			for end < len(expr) && (unicode.IsDigit(rune(expr[end])) || expr[end] == '.') {
+				end++
+			}
+			n, err := strconv.ParseFloat(expr[i:end], 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid number %s", expr[i:end])
+			}
+			tokens = append(tokens, token{kind: tokenValue, text: expr[i:end], value: n})
+			i = end
+		case strings.ContainsRune("=!<>", ch):
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, errors.Errorf("invalid operator at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op})
+			i += len(op)
+		case ch == '@' || ch == '_' || unicode.IsLetter(ch):
+			end := i + 1
+			for end < len(expr) && isFieldChar(rune(expr[end])) {
+				end++
+			}
+			word := expr[i:end]
+			switch strings.ToUpper(word) {
+			case "AND":
+				tokens = append(tokens, token{kind: tokenAnd, text: word})
+			case "OR":
+				tokens = append(tokens, token{kind: tokenOr, text: word})
+			case "NOT":
+				tokens = append(tokens, token{kind: tokenNot, text: word})
+			default:
+				switch word {
+				case "true":
+					tokens = append(tokens, token{kind: tokenValue, text: word, value: true})
+				case "false":
+					tokens = append(tokens, token{kind: tokenValue, text: word, value: false})
+				case "null":
+					tokens = append(tokens, token{kind: tokenValue, text: word, value: nil})
+				default:
+					tokens = append(tokens, token{kind: tokenField, text: word})
+				}
+			}
+			i = end
+		default:
+			return nil, errors.Errorf("unexpected character '%c' at position %d", ch, i)
+		}
+	}
+	return tokens, nil
+}
+
+func isFieldChar(ch rune) bool {
+	return ch == '@' || ch == '_' || ch == '.' || unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields Fields
+}
+
+func (p *parser) next(kind tokenKind) (token, bool) {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind {
+		p.pos++
+		return p.tokens[p.pos-1], true
+	}
+	return token{}, false
+}
+
+func (p *parser) or() (map[string]interface{}, error) {
+	terms, err := p.list(tokenOr, p.and)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return map[string]interface{}{"$or": terms}, nil
+}
+
+func (p *parser) and() (map[string]interface{}, error) {
+	terms, err := p.list(tokenAnd, p.unary)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return map[string]interface{}{"$and": terms}, nil
+}
+
+// list parses terms separated by the operator
+func (p *parser) list(separator tokenKind, term func() (map[string]interface{}, error)) ([]map[string]interface{}, error) {
+	terms := make([]map[string]interface{}, 0)
+	for {
+		t, err := term()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+
+		if _, ok := p.next(separator); !ok {
+			return terms, nil
+		}
+	}
+}
+
+func (p *parser) unary() (map[string]interface{}, error) {
+	if _, ok := p.next(tokenNot); ok {
+		sub, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$not": sub}, nil
+	}
+
+	if _, ok := p.next(tokenOpen); ok {
+		sub, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.next(tokenClose); !ok {
+			return nil, errors.New("missing ')'")
+		}
+		return sub, nil
+	}
+
+	return p.comparison()
+}
+
+func (p *parser) comparison() (map[string]interface{}, error) {
+	field, ok := p.next(tokenField)
+	if !ok {
+		return nil, errors.New(p.expected("field"))
+	}
+	op, ok := p.next(tokenOperator)
+	if !ok {
+		return nil, errors.New(p.expected("operator"))
+	}
+	value, ok := p.next(tokenValue)
+	if !ok {
+		return nil, errors.New(p.expected("value"))
+	}
+
+	dataType, err := p.fields.dataType(field.text)
+	if err != nil {
+		return nil, err
+	}
+	return condition(field.text, dataType, op.text, value.value)
+}
+
+func (p *parser) expected(what string) string {
+	if p.pos < len(p.tokens) {
+		return fmt.Sprintf("expected %s, found '%s'", what, p.tokens[p.pos].text)
+	}
+	return fmt.Sprintf("expected %s at end of filter", what)
+}
+
+var mangoOperators = map[string]string{
+	"==": "$eq",
+	"!=": "$ne",
+	">":  "$gt",
+	">=": "$gte",
+	"<":  "$lt",
+	"<=": "$lte",
+	"=~": "$regex",
+}
+
+// condition builds the selector of a comparison. Comparisons on arrays
+// match any of their elements, and numbers compared to datetimes are years.
+func condition(field, dataType, op string, value interface{}) (map[string]interface{}, error) {
+	elemType := strings.TrimPrefix(dataType, "[]")
+	isArray := elemType != dataType
+
+	if op == "=~" {
+		if _, ok := value.(string); !ok {
+			return nil, errors.Errorf("'%s' must be compared to a regular expression string", field)
+		}
+	} else if year, ok := value.(float64); ok && elemType == "datetime" {
+		return yearCondition(field, op, int(year), isArray), nil
+	} else if err := checkValue(field, elemType, value); err != nil {
+		return nil, err
+	}
+
+	cond := map[string]interface{}{mangoOperators[op]: value}
+	if isArray {
+		cond = map[string]interface{}{"$elemMatch": cond}
+	}
+	return map[string]interface{}{field: cond}, nil
+}
+
+// yearCondition compares a datetime field to the whole year
+func yearCondition(field, op string, year int, isArray bool) map[string]interface{} {
+	start := fmt.Sprintf("%04d-01-01T00:00:00Z", year)
+	end := fmt.Sprintf("%04d-01-01T00:00:00Z", year+1)
+
+	var cond map[string]interface{}
+	switch op {
+	case ">":
+		cond = map[string]interface{}{"$gte": end}
+	case ">=":
+		cond = map[string]interface{}{"$gte": start}
+	case "<":
+		cond = map[string]interface{}{"$lt": start}
+	case "<=":
+		cond = map[string]interface{}{"$lt": end}
+	case "!=":
+		inYear := map[string]interface{}{field: map[string]interface{}{"$gte": start, "$lt": end}}
+		if isArray {
+			inYear = map[string]interface{}{field: map[string]interface{}{"$elemMatch": map[string]interface{}{"$gte": start, "$lt": end}}}
+		}
+		return map[string]interface{}{"$not": inYear}
+	default:
+		cond = map[string]interface{}{"$gte": start, "$lt": end}
+	}
+
+	if isArray {
+		cond = map[string]interface{}{"$elemMatch": cond}
+	}
+	return map[string]interface{}{field: cond}
+}
+
+// checkValue validates the value against the data type of the field.
+// Custom data types and reference sub-fields are validated by the chaincode.
+func checkValue(field, dataType string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	var ok bool
+	switch dataType {
+	case "string", "datetime":
+		_, ok = value.(string)
+	case "number":
+		_, ok = value.(float64)
+	case "integer":
+		var n float64
+		n, ok = value.(float64)
+		ok = ok && n == float64(int64(n))
+	case "boolean":
+		_, ok = value.(bool)
+	default:
+		ok = true
+	}
+
+	if !ok {
+		return errors.Errorf("'%s' must be compared to a %s", field, dataType)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+var bookFields = Fields{
+	"title":         "string",
+	"author":        "string",
+	"published":     "datetime",
+	"genres":        "[]string",
+	"bookType":      "integer",
+	"height":        "number",
+	"available":     "boolean",
+	"currentTenant": "->person",
+	"editions":      "[]datetime",
+}
+
+type m = map[string]interface{}
+type l = []map[string]interface{}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name     string
+		expr     string
+		expected m
+	}{
+		{"equal", `title=="Dune"`, m{"title": m{"$eq": "Dune"}}},
+		{"not equal", `title != "Dune"`, m{"title": m{"$ne": "Dune"}}},
+		{"greater", `height>1.5`, m{"height": m{"$gt": 1.5}}},
+		{"greater or equal", `height>=1.5`, m{"height": m{"$gte": 1.5}}},
+		{"less", `bookType<2`, m{"bookType": m{"$lt": 2.0}}},
+		{"less or equal", `bookType<=-2`, m{"bookType": m{"$lte": -2.0}}},
+		{"regex", `title=~"^Du"`, m{"title": m{"$regex": "^Du"}}},
+		{"boolean", `available==true`, m{"available": m{"$eq": true}}},
+		{"null", `author==null`, m{"author": m{"$eq": nil}}},
+		{"metadata", `@lastTouchBy=="org1MSP"`, m{"@lastTouchBy": m{"$eq": "org1MSP"}}},
+		{"reference field", `currentTenant.name=="Maria"`, m{"currentTenant.name": m{"$eq": "Maria"}}},
+		{"array", `genres=="scifi"`, m{"genres": m{"$elemMatch": m{"$eq": "scifi"}}}},
+
+		// Quoting
+		{"escaped quotes", `title=="The \"Best\" Book"`, m{"title": m{"$eq": `The "Best" Book`}}},
+		{"keywords in strings", `title=="NOT AND OR ( )"`, m{"title": m{"$eq": "NOT AND OR ( )"}}},
+		{"case insensitive keywords", `title=="a" and author=="b"`, m{"$and": l{
+			{"title": m{"$eq": "a"}},
+			{"author": m{"$eq": "b"}},
+		}}},
+
+		// Precedence
+		{"and before or", `title=="a" OR title=="b" AND author=="c"`, m{"$or": l{
+			{"title": m{"$eq": "a"}},
+			{"$and": l{
+				{"title": m{"$eq": "b"}},
+				{"author": m{"$eq": "c"}},
+			}},
+		}}},
+		{"parentheses", `(title=="a" OR title=="b") AND author=="c"`, m{"$and": l{
+			{"$or": l{
+				{"title": m{"$eq": "a"}},
+				{"title": m{"$eq": "b"}},
+			}},
+			{"author": m{"$eq": "c"}},
+		}}},
+		{"not before and", `NOT title=="a" AND author=="c"`, m{"$and": l{
+			{"$not": m{"title": m{"$eq": "a"}}},
+			{"author": m{"$eq": "c"}},
+		}}},
+		{"not of group", `NOT (title=="a" OR NOT author=="c")`, m{"$not": m{"$or": l{
+			{"title": m{"$eq": "a"}},
+			{"$not": m{"author": m{"$eq": "c"}}},
+		}}}},
+
+		// Datetimes compared to numbers are years
+		{"year equal", `published==2020`, m{"published": m{"$gte": "2020-01-01T00:00:00Z", "$lt": "2021-01-01T00:00:00Z"}}},
+		{"year greater", `published>2020`, m{"published": m{"$gte": "2021-01-01T00:00:00Z"}}},
+		{"year greater or equal", `published>=2020`, m{"published": m{"$gte": "2020-01-01T00:00:00Z"}}},
+		{"year less", `published<2020`, m{"published": m{"$lt": "2020-01-01T00:00:00Z"}}},
+		{"year less or equal", `published<=2020`, m{"published": m{"$lt": "2021-01-01T00:00:00Z"}}},
+		{"year not equal", `published!=2020`, m{"$not": m{"published": m{"$gte": "2020-01-01T00:00:00Z", "$lt": "2021-01-01T00:00:00Z"}}}},
+		{"year of array", `editions>=2020`, m{"editions": m{"$elemMatch": m{"$gte": "2020-01-01T00:00:00Z"}}}},
+		{"datetime string", `published>="2020-05-01T00:00:00Z"`, m{"published": m{"$gte": "2020-05-01T00:00:00Z"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			selector, err := Parse(c.expr, bookFields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(selector, c.expected) {
+				t.Fatalf("expected %#v, got %#v", c.expected, selector)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []struct {
+		name string
+		expr string
+		err  string
+	}{
+		{"empty", ``, "expected field at end of filter"},
+		{"unknown field", `isbn=="123"`, "unknown field 'isbn'"},
+		{"field of non reference", `title.length==3`, "field 'title' is not an asset reference"},
+		{"missing operator", `title "Dune"`, `expected operator, found '"Dune"'`},
+		{"missing value", `title==`, "expected value at end of filter"},
+		{"invalid operator", `title=<"a"`, "invalid operator at position 5"},
+		{"unterminated string", `title=="Dune`, "unterminated string"},
+		{"invalid number", `height>1.5.3`, "invalid number 1.5.3"},
+		{"unexpected character", `title=="a" & author=="b"`, "unexpected character '&' at position 11"},
+		{"missing parenthesis", `(title=="a"`, "missing ')'"},
+		{"trailing tokens", `title=="a")`, "unexpected ')'"},
+		{"dangling and", `title=="a" AND`, "expected field at end of filter"},
+		{"unquoted date", `published>2020-05-01`, "unexpected '-05'"},
+		{"string type", `title==3`, "'title' must be compared to a string"},
+		{"integer type", `bookType==1.5`, "'bookType' must be compared to a integer"},
+		{"number type", `height=="tall"`, "'height' must be compared to a number"},
+		{"boolean type", `available=="yes"`, "'available' must be compared to a boolean"},
+		{"array element type", `genres==1`, "'genres' must be compared to a string"},
+		{"regex type", `title=~1`, "'title' must be compared to a regular expression string"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Parse(c.expr, bookFields)
+			if err == nil || err.Error() != c.err {
+				t.Fatalf("expected error %q, got %v", c.err, err)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	cases := []struct {
+		sort     string
+		expected []map[string]string
+		err      string
+	}{
+		{"title", []map[string]string{{"title": "asc"}}, ""},
+		{"-published, +title", []map[string]string{{"published": "desc"}, {"title": "asc"}}, ""},
+		{"@lastUpdated,,", []map[string]string{{"@lastUpdated": "asc"}}, ""},
+		{"currentTenant.name", []map[string]string{{"currentTenant.name": "asc"}}, ""},
+		{"-isbn", nil, "unknown field 'isbn'"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.sort, func(t *testing.T) {
+			sort, err := ParseSort(c.sort, bookFields)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Fatalf("expected error %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sort, c.expected) {
+				t.Fatalf("expected %#v, got %#v", c.expected, sort)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Package format renders asset properties for display, following the
+// formatting options given in the query of a request:
+//
+//	?locale=pt-BR&dates=date&tz=America/Sao_Paulo&currency=BRL&cpf=masked
+//
+// The original value of every formatted property is kept under the _raw
+// property of the asset, so clients can still send it back to the API.
+package format
+
+import (
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// RawKey is the property of the asset holding the original values
+const RawKey = "_raw"
+
+// locale holds the conventions of a language for dates and numbers
+type locale struct {
+	Date     string
+	DateTime string
+	Decimal  string
+	Thousand string
+}
+
+var locales = map[language.Tag]*locale{
+	language.English: {
+		Date:     "01/02/2006",
+		DateTime: "01/02/2006 3:04:05 PM",
+		Decimal:  ".",
+		Thousand: ",",
+	},
+	language.BrazilianPortuguese: {
+		Date:     "02/01/2006",
+		DateTime: "02/01/2006 15:04:05",
+		Decimal:  ",",
+		Thousand: ".",
+	},
+}
+
+// supported are the locales of the formatting, the first one is the fallback
+// of the matcher
+var supported = []language.Tag{language.English, language.BrazilianPortuguese}
+
+var matcher = language.NewMatcher(supported)
+
+// currencySymbols are the symbols of the common currencies, other currencies
+// are shown by their ISO 4217 code
+var currencySymbols = map[string]string{
+	"BRL": "R$",
+	"USD": "US$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// Options are the formatting options of a request
+type Options struct {
+	locale   *locale
+	dates    string
+	location *time.Location
+	currency string
+	cpf      string
+}
+
+// Parse reads the formatting options from the query of a request. It
+// returns nil if no formatting was requested.
+func Parse(query url.Values) (*Options, error) {
+	opts := &Options{
+		dates:    query.Get("dates"),
+		location: time.UTC,
+		currency: strings.ToUpper(query.Get("currency")),
+		cpf:      query.Get("cpf"),
+	}
+
+	if lang := query.Get("locale"); lang != "" {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return nil, errors.Errorf("invalid locale '%s'", lang)
+		}
+		_, index, confidence := matcher.Match(tag)
+		if confidence == language.No {
+			return nil, errors.Errorf("unsupported locale '%s'", lang)
+		}
+		opts.locale = locales[supported[index]]
+	}
+
+	switch opts.dates {
+	case "", "date", "datetime":
+	default:
+		return nil, errors.Errorf("dates must be date or datetime")
+	}
+
+	if tz := query.Get("tz"); tz != "" {
+		location, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, errors.Errorf("unknown time zone '%s'", tz)
+		}
+		opts.location = location
+	}
+
+	if opts.currency != "" && len(opts.currency) != 3 {
+		return nil, errors.Errorf("currency must be an ISO 4217 code")
+	}
+
+	switch opts.cpf {
+	case "", "masked", "unmasked":
+	default:
+		return nil, errors.Errorf("cpf must be masked or unmasked")
+	}
+
+	if opts.locale == nil {
+		if opts.dates != "" || opts.currency != "" || query.Get("tz") != "" {
+			return nil, errors.New("dates, tz and currency require a locale")
+		}
+		if opts.cpf == "" {
+			return nil, nil
+		}
+	}
+	if opts.dates == "" {
+		opts.dates = "datetime"
+	}
+	return opts, nil
+}
+
+// Asset formats the properties of the asset, given the data types of its
+// properties. Assets that already went through Asset are left unchanged.
+func (o *Options) Asset(types map[string]string, asset map[string]interface{}) {
+	if o == nil {
+		return
+	}
+	if _, done := asset[RawKey]; done {
+		return
+	}
+
+	raw := make(map[string]interface{})
+	for prop, dataType := range types {
+		value, ok := asset[prop]
+		if !ok || value == nil {
+			continue
+		}
+
+		elemType := strings.TrimPrefix(dataType, "[]")
+		if list, ok := value.([]interface{}); ok && elemType != dataType {
+			formatted := make([]interface{}, len(list))
+			changed := false
+			for i, item := range list {
+				formatted[i], ok = o.value(elemType, item)
+				changed = changed || ok
+			}
+			if changed {
+				raw[prop] = value
+				asset[prop] = formatted
+			}
+			continue
+		}
+
+		if formatted, ok := o.value(dataType, value); ok {
+			raw[prop] = value
+			asset[prop] = formatted
+		}
+	}
+
+	if len(raw) > 0 {
+		asset[RawKey] = raw
+	}
+}
+
+// value formats a single value of the data type, reporting whether it was
+// changed
+func (o *Options) value(dataType string, value interface{}) (interface{}, bool) {
+	switch dataType {
+	case "datetime":
+		if o.locale == nil {
+			return value, false
+		}
+		str, _ := value.(string)
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return value, false
+		}
+		layout := o.locale.DateTime
+		if o.dates == "date" {
+			layout = o.locale.Date
+		}
+		return t.In(o.location).Format(layout), true
+	case "number", "integer", "decimal":
+		if o.locale == nil {
+			return value, false
+		}
+		num, ok := numberString(value)
+		if !ok {
+			return value, false
+		}
+		if dataType == "decimal" && o.currency != "" {
+			return o.money(num, o.currency, 2)
+		}
+		return o.number(num), true
+	case "money":
+		m, ok := value.(map[string]interface{})
+		if !ok || o.locale == nil {
+			return value, false
+		}
+		amount, _ := m["amount"].(string)
+		currency, _ := m["currency"].(string)
+		// The amount already has the digits of the minor unit of its currency
+		_, fraction, _ := strings.Cut(amount, ".")
+		return o.money(amount, currency, len(fraction))
+	case "cpf":
+		return formatCPF(value, o.cpf)
+	}
+	return value, false
+}
+
+// numberString returns the decimal representation of a JSON number or of a
+// decimal string
+func numberString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case interface{ String() string }:
+		return v.String(), true
+	case string:
+		if _, ok := new(big.Rat).SetString(v); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// number groups the thousands of the integer part and uses the decimal
+// separator of the locale
+func (o *Options) number(num string) string {
+	sign := ""
+	if strings.HasPrefix(num, "-") {
+		sign, num = "-", num[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(num, ".")
+
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(o.locale.Thousand)
+		}
+		b.WriteRune(digit)
+	}
+	if hasFraction {
+		b.WriteString(o.locale.Decimal)
+		b.WriteString(fraction)
+	}
+	return sign + b.String()
+}
+
+// money formats an amount of the currency, rounded to the decimal places
+func (o *Options) money(num, currency string, places int) (interface{}, bool) {
+	amount, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return num, false
+	}
+
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		symbol = currency
+	}
+
+	formatted := symbol + " " + o.number(strings.TrimPrefix(amount.FloatString(places), "-"))
+	if amount.Sign() < 0 {
+		formatted = "-" + formatted
+	}
+	return formatted, true
+}
+
+// formatCPF shows a CPF with (000.000.000-00) or without its punctuation
+func formatCPF(value interface{}, mode string) (interface{}, bool) {
+	str, ok := value.(string)
+	if !ok || mode == "" {
+		return value, false
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, str)
+	if len(digits) != 11 {
+		return value, false
+	}
+
+	formatted := digits
+	if mode == "masked" {
+		formatted = digits[:3] + "." + digits[3:6] + "." + digits[6:9] + "-" + digits[9:]
+	}
+	return formatted, formatted != str
+}
@@ -0,0 +1,142 @@
+// Package geo validates the geopoint data type of the chaincode and
+// computes the distances and bounding boxes of radius searches
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DataType is the name of the geopoint data type in the chaincode
+const DataType = "geopoint"
+
+// earthRadius is the mean radius of the Earth in meters
+const earthRadius = 6371008.8
+
+// Point is a location in decimal degrees
+type Point struct {
+	Lat  float64 `json:"lat"`
+	Long float64 `json:"long"`
+}
+
+// Parse validates a geopoint with the rules of the chaincode: an object with
+// lat and long or a "lat,long" string, within the range of the coordinates
+func Parse(value interface{}) (Point, error) {
+	var lat, long interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		lat, long = v["lat"], v["long"]
+	case string:
+		parts := strings.Split(v, ",")
+		if len(parts) != 2 {
+			return Point{}, errors.New("geopoint must be a latitude and a longitude separated by a comma")
+		}
+		lat, long = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	default:
+		return Point{}, errors.New("property must be an object with lat and long")
+	}
+
+	var p Point
+	var err error
+	p.Lat, err = coordinate("lat", lat, 90)
+	if err != nil {
+		return Point{}, err
+	}
+	p.Long, err = coordinate("long", long, 180)
+	if err != nil {
+		return Point{}, err
+	}
+	return p, nil
+}
+
+// coordinate parses a latitude or longitude, which must be within
+// [-max, max] degrees. Numbers may be float64 or json.Number.
+func coordinate(name string, value interface{}, max float64) (float64, error) {
+	var n float64
+	var err error
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case string:
+		n, err = strconv.ParseFloat(v, 64)
+	case fmt.Stringer:
+		n, err = strconv.ParseFloat(v.String(), 64)
+	default:
+		err = errors.New("not a number")
+	}
+	// NaN passes any range check and ParseFloat accepts "NaN" and "Inf"
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, errors.Errorf("%s must be a number", name)
+	}
+
+	if n < -max || n > max {
+		return 0, errors.Errorf("%s must be between %g and %g", name, -max, max)
+	}
+	return n, nil
+}
+
+// Distance returns the great-circle distance between the points in meters
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLong := radians(b.Long - a.Long)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Box is a range of latitudes and longitudes. MinLong is greater than
+// MaxLong when the box crosses the antimeridian.
+type Box struct {
+	MinLat, MaxLat   float64
+	MinLong, MaxLong float64
+}
+
+// BoundingBox returns the smallest box holding the circle with the radius in
+// meters around the center, to narrow down a search before the distances
+// are computed
+func BoundingBox(center Point, radius float64) Box {
+	dLat := degrees(radius / earthRadius)
+	box := Box{
+		MinLat:  math.Max(center.Lat-dLat, -90),
+		MaxLat:  math.Min(center.Lat+dLat, 90),
+		MinLong: -180,
+		MaxLong: 180,
+	}
+
+	// Circles around a pole span every longitude
+	if box.MinLat == -90 || box.MaxLat == 90 {
+		return box
+	}
+
+	dLong := degrees(math.Asin(math.Sin(radius/earthRadius) / math.Cos(radians(center.Lat))))
+	if math.IsNaN(dLong) || dLong >= 180 {
+		return box
+	}
+	box.MinLong = wrap(center.Long - dLong)
+	box.MaxLong = wrap(center.Long + dLong)
+	return box
+}
+
+// wrap brings a longitude back to [-180, 180]
+func wrap(long float64) float64 {
+	if long < -180 {
+		return long + 360
+	}
+	if long > 180 {
+		return long - 360
+	}
+	return long
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
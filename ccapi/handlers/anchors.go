@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/anchor"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// ListAnchors returns the receipts of the anchors of the ledger, oldest first
+func ListAnchors(c *gin.Context) {
+	receipts, err := anchor.Receipts()
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	common.Respond(c, receipts, http.StatusOK, nil)
+}
+
+// AnchorLedger anchors the latest block of the channel right away
+func AnchorLedger(c *gin.Context) {
+	if !anchor.Enabled() {
+		common.Abort(c, http.StatusNotFound, errors.New("anchoring is disabled, set ANCHOR_URL to enable it"))
+		return
+	}
+
+	receipt, err := anchor.Anchor()
+	if err != nil {
+		common.Abort(c, http.StatusBadGateway, err)
+		return
+	}
+
+	common.Respond(c, receipt, http.StatusOK, nil)
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/filter"
+	"github.com/pkg/errors"
+)
+
+// assetKey returns the key object of the asset in the :key path parameter,
+// which may omit the asset type prefix
+func assetKey(c *gin.Context, assetType string) map[string]interface{} {
+	key := c.Param("key")
+	if !strings.HasPrefix(key, assetType+":") {
+		key = assetType + ":" + key
+	}
+
+	return map[string]interface{}{
+		"@assetType": assetType,
+		"@key":       key,
+	}
+}
+
+// ListAssets searches the assets of the type, filtered and sorted by the
+// filter and sort query parameters and paginated by the limit and bookmark
+// query parameters, or by cursor for snapshot listings. With stream=true
+// every page is streamed as newline delimited JSON.
+func ListAssets(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		selector := map[string]interface{}{
+			"@assetType": assetType,
+		}
+		query := map[string]interface{}{
+			"selector": selector,
+		}
+
+		if c.Query("filter") != "" || c.Query("sort") != "" {
+			fields, err := filterFields(c, assetType)
+			if err != nil {
+				err, status := common.ParseError(err)
+				common.Abort(c, status, err)
+				return
+			}
+
+			if expr := c.Query("filter"); expr != "" {
+				filterSelector, err := filter.Parse(expr, fields)
+				if err != nil {
+					common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid filter"))
+					return
+				}
+				query["selector"] = map[string]interface{}{
+					"$and": []interface{}{selector, filterSelector},
+				}
+			}
+
+			if sort := c.Query("sort"); sort != "" {
+				sortFields, err := filter.ParseSort(sort, fields)
+				if err != nil {
+					common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid sort"))
+					return
+				}
+				query["sort"] = sortFields
+			}
+		}
+
+		if limit := c.Query("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil {
+				common.Abort(c, http.StatusBadRequest, errors.New("the limit query parameter must be an integer"))
+				return
+			}
+			query["limit"] = n
+		}
+		if bookmark := c.Query("bookmark"); bookmark != "" {
+			query["bookmark"] = bookmark
+		}
+
+		opts, ok := formatOptions(c)
+		if !ok {
+			return
+		}
+
+		if c.Query("stream") == "true" {
+			if c.Query("cursor") != "" || c.Query("snapshot") == "true" {
+				common.Abort(c, http.StatusBadRequest, errors.New("streamed listings cannot be snapshots"))
+				return
+			}
+			streamAssets(c, assetType, query, opts)
+			return
+		}
+
+		// Snapshot listings keep the assets as of the first page
+		var snap *snapshot
+		if cursor := c.Query("cursor"); cursor != "" {
+			s, err := parseCursor(cursor)
+			if err != nil {
+				common.Abort(c, http.StatusBadRequest, err)
+				return
+			}
+			snap = &s
+			query["bookmark"] = s.Bookmark
+		} else if c.Query("snapshot") == "true" {
+			s, err := newSnapshot(c, os.Getenv("CHANNEL"))
+			if err != nil {
+				err, status := common.ParseError(err)
+				common.Abort(c, status, err)
+				return
+			}
+			snap = s
+		}
+
+		args, _ := json.Marshal(map[string]interface{}{
+			"query": query,
+		})
+		payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", args)
+		if !ok {
+			return
+		}
+
+		if res, ok := payload.(map[string]interface{}); ok {
+			list, _ := res["result"].([]interface{})
+			if snap != nil {
+				list = snap.asOf(c, list)
+				res["result"] = list
+
+				next := *snap
+				next.Bookmark = ""
+				if metadata, ok := res["metadata"].(map[string]interface{}); ok {
+					next.Bookmark, _ = metadata["bookmark"].(string)
+				}
+				res["snapshot"] = gin.H{
+					"height": snap.Height,
+					"time":   snap.Time,
+					"cursor": next.cursor(),
+				}
+			}
+			decryptAssets(assetType, list)
+			if !expandAssets(c, list...) {
+				return
+			}
+			for _, item := range list {
+				if asset, ok := item.(map[string]interface{}); ok {
+					addLinks(c, assetType, asset)
+					formatAsset(c, opts, assetType, asset)
+				}
+			}
+		}
+		payload = selectFields(c, "search", payload)
+
+		common.Respond(c, payload, http.StatusOK, nil)
+	}
+}
+
+// filterFields returns the data types of the properties of the asset type
+func filterFields(c *gin.Context, assetType string) (filter.Fields, error) {
+	schema, err := getAssetSchema(c, assetType)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(filter.Fields)
+	for _, prop := range schema.Props {
+		fields[prop.Tag] = prop.DataType
+	}
+	return fields, nil
+}
+
+// ReadAsset reads the asset of the type with the key in the path
+func ReadAsset(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, ok := formatOptions(c)
+		if !ok {
+			return
+		}
+
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": assetKey(c, assetType),
+		})
+		payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+		if !ok {
+			return
+		}
+		if asset, ok := payload.(map[string]interface{}); ok {
+			decryptAsset(assetType, asset)
+		}
+
+		if !expandAssets(c, payload) {
+			return
+		}
+		if asset, ok := payload.(map[string]interface{}); ok {
+			addLinks(c, assetType, asset)
+			formatAsset(c, opts, assetType, asset)
+		}
+		payload = selectFields(c, "readAsset", payload)
+
+		common.Respond(c, payload, http.StatusOK, nil)
+	}
+}
+
+// ReadAssetHistory reads the history of the asset of the type with the key
+// in the path
+func ReadAssetHistory(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, ok := formatOptions(c)
+		if !ok {
+			return
+		}
+
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": assetKey(c, assetType),
+		})
+		payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAssetHistory", args)
+		if !ok {
+			return
+		}
+		if list, ok := payload.([]interface{}); ok {
+			decryptAssets(assetType, list)
+			for _, item := range list {
+				if asset, ok := item.(map[string]interface{}); ok {
+					formatAsset(c, opts, assetType, asset)
+				}
+			}
+		}
+		payload = selectFields(c, "readAssetHistory", payload)
+
+		common.Respond(c, payload, http.StatusOK, nil)
+	}
+}
+
+// CreateAsset creates an asset of the type from the request body
+func CreateAsset(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		asset := make(map[string]interface{})
+		err := c.BindJSON(&asset)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+		asset["@assetType"] = assetType
+
+		err = validateGeoPoints(c, assetType, asset)
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+
+		err = encryptAsset(assetType, asset, nil)
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+
+		req := map[string]interface{}{
+			"asset": []interface{}{asset},
+		}
+		submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "createAsset", req)
+	}
+}
+
+// UpdateAsset updates the asset of the type with the key in the path with
+// the properties in the request body, or with a JSON Patch document when
+// sent as application/json-patch+json
+func UpdateAsset(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.ContentType() == jsonPatchContentType {
+			patchAsset(c, assetType)
+			return
+		}
+
+		update := make(map[string]interface{})
+		err := c.BindJSON(&update)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+		for k, v := range assetKey(c, assetType) {
+			update[k] = v
+		}
+
+		err = validateGeoPoints(c, assetType, update)
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+
+		// Personal data is encrypted with the key of the current subject
+		if HasEncryptedFields(assetType) {
+			args, _ := json.Marshal(map[string]interface{}{
+				"key": assetKey(c, assetType),
+			})
+			payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+			if !ok {
+				return
+			}
+			current, _ := payload.(map[string]interface{})
+
+			err = encryptAsset(assetType, update, current)
+			if err != nil {
+				err, status := common.ParseError(err)
+				common.Abort(c, status, err)
+				return
+			}
+		}
+
+		req := map[string]interface{}{
+			"update": update,
+		}
+		submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "updateAsset", req)
+	}
+}
+
+// DeleteAsset deletes the asset of the type with the key in the path
+func DeleteAsset(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := map[string]interface{}{
+			"key": assetKey(c, assetType),
+		}
+		submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "deleteAsset", req)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/bootstrap"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/pkg/errors"
+)
+
+// BootstrapPlan returns the changes that would make the CCAPI match the
+// bootstrap configuration, without applying them
+func BootstrapPlan(c *gin.Context) {
+	reconcileBootstrap(c, false)
+}
+
+// ApplyBootstrap makes the CCAPI match the bootstrap configuration right
+// away, returning the changes applied
+func ApplyBootstrap(c *gin.Context) {
+	reconcileBootstrap(c, true)
+}
+
+func reconcileBootstrap(c *gin.Context, apply bool) {
+	if !bootstrap.Enabled() {
+		common.Abort(c, http.StatusNotFound, errors.New("bootstrap is disabled, set BOOTSTRAP_CONFIG"))
+		return
+	}
+
+	changes, err := bootstrap.Reconcile(apply)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.Respond(c, gin.H{
+		"applied": apply,
+		"changes": changes,
+	}, http.StatusOK, nil)
+}
+
+// SubmitScheduled submits a transaction scheduled in the bootstrap
+// configuration, recording it in the audit log
+func SubmitScheduled(channel, chaincode, txName, user string, args []string) error {
+	_, _, err := submitAudited(audit.Entry{
+		Channel:   channel,
+		Chaincode: chaincode,
+		TxName:    txName,
+		User:      user,
+		Args:      args,
+	}, nil, txqueue.Batch)
+	return err
+}
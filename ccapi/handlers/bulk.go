@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/filter"
+	"github.com/hyperledger-labs/ccapi/quota"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultBulkChunkSize = 10
+	maxBulkChunkSize     = 100
+	bulkPageSize         = 100
+)
+
+type bulkUpdateRequest struct {
+	AssetType string                 `json:"assetType" binding:"required"`
+	Filter    string                 `json:"filter"`
+	Update    map[string]interface{} `json:"update" binding:"required"`
+	ChunkSize int                    `json:"chunkSize"`
+}
+
+// bulkResult is the outcome of the update of a single asset
+type bulkResult struct {
+	Key       string `json:"@key"`
+	Status    int    `json:"status"`
+	TxID      string `json:"txId,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"`
+}
+
+// BulkUpdateAssets applies the same changes to every asset of a type matching
+// a filter. Each asset is updated by its own transaction, submitted
+// concurrently in chunks, and the outcome of each one is returned so that
+// failed updates can be retried.
+func BulkUpdateAssets(c *gin.Context) {
+	var req bulkUpdateRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	if !authorize(c, "search") || !authorize(c, "updateAsset") {
+		return
+	}
+	if req.ChunkSize <= 0 {
+		req.ChunkSize = defaultBulkChunkSize
+	}
+	if req.ChunkSize > maxBulkChunkSize {
+		req.ChunkSize = maxBulkChunkSize
+	}
+
+	schema, err := getAssetSchema(c, req.AssetType)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	for field := range req.Update {
+		if strings.HasPrefix(field, "@") {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("metadata field '%s' cannot be updated", field))
+			return
+		}
+	}
+
+	fields := make(filter.Fields)
+	for _, prop := range schema.Props {
+		fields[prop.Tag] = prop.DataType
+		if _, ok := req.Update[prop.Tag]; ok && prop.IsKey {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("key property '%s' cannot be updated", prop.Tag))
+			return
+		}
+	}
+
+	selector := map[string]interface{}{
+		"@assetType": req.AssetType,
+	}
+	if req.Filter != "" {
+		filterSelector, err := filter.Parse(req.Filter, fields)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid filter"))
+			return
+		}
+		selector = map[string]interface{}{
+			"$and": []interface{}{selector, filterSelector},
+		}
+	}
+
+	keys, err := searchKeys(c, selector)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	user := common.GetUser(c)
+
+	results := make([]bulkResult, len(keys))
+	for start := 0; start < len(keys); start += req.ChunkSize {
+		end := start + req.ChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = bulkUpdate(req.AssetType, keys[i], req.Update, user)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	succeeded := 0
+	retry := make([]string, 0)
+	for _, r := range results {
+		if r.Status == http.StatusOK {
+			succeeded++
+		} else if r.Retryable {
+			retry = append(retry, r.Key)
+		}
+	}
+
+	common.Respond(c, gin.H{
+		"matched":   len(keys),
+		"succeeded": succeeded,
+		"failed":    len(keys) - succeeded,
+		"retryKeys": retry,
+		"results":   results,
+	}, http.StatusOK, nil)
+}
+
+// searchKeys returns the keys of every asset matching the selector
+func searchKeys(c *gin.Context, selector map[string]interface{}) ([]string, error) {
+	keys := make([]string, 0)
+	bookmark := ""
+	for {
+		args, _ := json.Marshal(map[string]interface{}{
+			"query": map[string]interface{}{
+				"selector": selector,
+				"fields":   []string{"@key"},
+				"limit":    bulkPageSize,
+				"bookmark": bookmark,
+			},
+		})
+		result, err := chaincode.QueryGatewayFor(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", []string{string(args)})
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Result []struct {
+				Key string `json:"@key"`
+			} `json:"result"`
+			Metadata struct {
+				Bookmark string `json:"bookmark"`
+			} `json:"metadata"`
+		}
+		err = json.Unmarshal(result, &page)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal search result")
+		}
+
+		for _, r := range page.Result {
+			keys = append(keys, r.Key)
+		}
+		if len(page.Result) < bulkPageSize || page.Metadata.Bookmark == "" || page.Metadata.Bookmark == bookmark {
+			return keys, nil
+		}
+		bookmark = page.Metadata.Bookmark
+	}
+}
+
+// bulkUpdate submits the update of a single asset
+func bulkUpdate(assetType, key string, changes map[string]interface{}, user string) bulkResult {
+	update := map[string]interface{}{
+		"@assetType": assetType,
+		"@key":       key,
+	}
+	for k, v := range changes {
+		update[k] = v
+	}
+	args, _ := json.Marshal(map[string]interface{}{
+		"update": update,
+	})
+
+	entry := audit.Entry{
+		Channel:   os.Getenv("CHANNEL"),
+		Chaincode: os.Getenv("CCNAME"),
+		TxName:    "updateAsset",
+		User:      user,
+		Args:      []string{string(args)},
+	}
+	// Assets past the quota of the user are left for a retry next month
+	release, err := quota.Reserve(user)
+	if err != nil {
+		return bulkResult{
+			Key:    key,
+			Status: http.StatusTooManyRequests,
+			Code:   common.ErrCodeQuotaExceeded,
+			Error:  err.Error(),
+		}
+	}
+
+	result, status, err := submitAudited(entry, map[string][]byte{chaincode.TransientKey: []byte("{}")}, txqueue.Batch)
+	if err != nil {
+		release()
+		res := bulkResult{
+			Key:    key,
+			Status: status,
+			Code:   common.CodeFromStatus(status),
+			Error:  err.Error(),
+		}
+		if apiErr, ok := err.(*common.APIError); ok {
+			res.Code = apiErr.Code
+		}
+		res.Retryable = retryable(res.Code, status)
+		return res
+	}
+
+	return bulkResult{
+		Key:    key,
+		Status: http.StatusOK,
+		TxID:   result.TxID,
+	}
+}
+
+// retryable reports whether a failed update may succeed if submitted again
+func retryable(code string, status int) bool {
+	switch code {
+	case common.ErrCodeMVCCConflict, common.ErrCodeCommitStatusTimeout, common.ErrCodeSubmitFailed, common.ErrCodeUnavailable:
+		return true
+	}
+	return status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
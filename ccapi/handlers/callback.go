@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/hyperledger-labs/ccapi/webhook"
+	"github.com/pkg/errors"
+)
+
+// Events posted to the callback of a transaction submitted without waiting
+// for its commit
+const (
+	EventTxCommitted = "transaction.committed"
+	EventTxFailed    = "transaction.failed"
+)
+
+// callbackOutcome is the data of the events posted to callbacks
+type callbackOutcome struct {
+	TxID        string      `json:"txId"`
+	TxName      string      `json:"txName"`
+	Channel     string      `json:"channel"`
+	Chaincode   string      `json:"chaincode"`
+	Status      int         `json:"status"`
+	BlockNumber uint64      `json:"blockNumber,omitempty"`
+	Result      interface{} `json:"result,omitempty"`
+	Code        string      `json:"code,omitempty"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// callbackURL checks the URL of the @callback query parameter, which must
+// be http or https and on one of the comma separated hosts of
+// CALLBACK_ALLOWED_HOSTS. Without them callbacks are refused, so the CCAPI
+// cannot be made to post to internal services.
+func callbackURL(raw string) (string, error) {
+	return outboundURL(raw, "the @callback query parameter", "CALLBACK_ALLOWED_HOSTS")
+}
+
+// outboundURL checks a URL given by a request for the CCAPI to post to,
+// which must be http or https and on one of the hosts of the variable
+func outboundURL(raw, name, allowedHostsVar string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.Errorf("%s must be an http or https URL", name)
+	}
+	if !allowedHost(u.Hostname(), os.Getenv(allowedHostsVar)) {
+		return "", errors.Errorf("posting to host '%s' is not allowed, set %s", u.Hostname(), allowedHostsVar)
+	}
+	return u.String(), nil
+}
+
+// allowedHost reports whether the host is one of the comma separated hosts,
+// none being allowed when there are none
+func allowedHost(host, allowed string) bool {
+	for _, h := range strings.Split(allowed, ",") {
+		if h = strings.TrimSpace(h); h != "" && strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// submitWithCallback submits the transaction with the request body as
+// argument and answers with its id without waiting for its commit. The
+// outcome of the commit is posted to the callback, and recorded in the
+// audit log, once known.
+func submitWithCallback(c *gin.Context, channelName, chaincodeName, txName string, req map[string]interface{}) {
+	callback, err := callbackURL(c.Query("@callback"))
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	class, err := txqueue.ParseClass(c.Query("@priority"))
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	entry, transient, ok := submissionEntry(c, channelName, chaincodeName, txName, req)
+	if !ok {
+		return
+	}
+	status, err := checkSubmission(entry)
+	if err != nil {
+		common.Abort(c, status, err)
+		return
+	}
+
+	release, ok := reserveQuota(c, entry.User)
+	if !ok {
+		return
+	}
+	txID, err := chaincode.SubmitAsyncClass(class, entry.Channel, entry.Chaincode, entry.TxName, entry.User, entry.Args, transient, entry.Endorsers, func(txID string, result *chaincode.SubmitResult, err error) {
+		committed := entry
+		committed.TxID = txID
+		notifyCallback(callback, committed, result, err)
+	})
+	if err != nil {
+		release()
+		err, status := common.ParseError(err)
+		entry.Status = status
+		entry.Error = err.Error()
+		audit.Record(entry)
+		common.Abort(c, status, err)
+		return
+	}
+
+	common.Respond(c, gin.H{
+		"txId":     txID,
+		"status":   "submitted",
+		"callback": callback,
+	}, http.StatusAccepted, nil)
+}
+
+// notifyCallback records the outcome of the commit of the transaction in
+// the audit log and posts it to the callback
+func notifyCallback(callback string, entry audit.Entry, result *chaincode.SubmitResult, err error) {
+	outcome := callbackOutcome{
+		TxID:      entry.TxID,
+		TxName:    entry.TxName,
+		Channel:   entry.Channel,
+		Chaincode: entry.Chaincode,
+	}
+	eventType := EventTxCommitted
+	if err != nil {
+		apiErr, status := common.ParseError(err)
+		entry.Status = status
+		entry.Error = apiErr.Error()
+		outcome.Status = status
+		outcome.Code = apiErr.(*common.APIError).Code
+		outcome.Error = apiErr.Error()
+		eventType = EventTxFailed
+	} else {
+		entry.Status = http.StatusOK
+		outcome.Status = http.StatusOK
+		outcome.BlockNumber = result.BlockNumber
+		common.UnmarshalJSON(result.Result, &outcome.Result)
+	}
+	audit.Record(entry)
+
+	// Failed deliveries are retried, and then dead-lettered
+	webhook.Send(callback, eventType, outcome)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import "testing"
+
+func TestCallbackURL(t *testing.T) {
+	// Callbacks are refused unless their host is allowed
+	t.Setenv("CALLBACK_ALLOWED_HOSTS", "")
+	for _, raw := range []string{"http://169.254.169.254/latest/meta-data", "http://localhost/hook", "https://hooks.example.com/tx"} {
+		if _, err := callbackURL(raw); err == nil {
+			t.Fatalf("expected %s to be refused", raw)
+		}
+	}
+
+	t.Setenv("CALLBACK_ALLOWED_HOSTS", "hooks.example.com, ci.example.com")
+	if _, err := callbackURL("https://Hooks.example.com/tx"); err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range []string{"http://127.0.0.1/hook", "ftp://hooks.example.com/tx", "hooks.example.com"} {
+		if _, err := callbackURL(raw); err == nil {
+			t.Fatalf("expected %s to be refused", raw)
+		}
+	}
+}
+
+func TestReplaySinkURL(t *testing.T) {
+	// The webhook sink of replays has its own allowlist
+	t.Setenv("CALLBACK_ALLOWED_HOSTS", "hooks.example.com")
+	t.Setenv("REPLAY_ALLOWED_HOSTS", "")
+	if _, err := outboundURL("https://hooks.example.com/replay", "url", "REPLAY_ALLOWED_HOSTS"); err == nil {
+		t.Fatal("expected the sink to be refused")
+	}
+
+	t.Setenv("REPLAY_ALLOWED_HOSTS", "sink.example.com")
+	if _, err := outboundURL("https://sink.example.com/replay", "url", "REPLAY_ALLOWED_HOSTS"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := outboundURL("http://10.0.0.1/replay", "url", "REPLAY_ALLOWED_HOSTS"); err == nil {
+		t.Fatal("expected the sink to be refused")
+	}
+}
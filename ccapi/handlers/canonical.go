@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/canonical"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// Canonicalize returns the canonical JSON (RFC 8785) of the request body,
+// as sent in chaincode arguments, and its SHA-256, so clients can check
+// the hashes and idempotency keys they compute
+func Canonicalize(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	data, err := canonical.Transform(body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid JSON"))
+		return
+	}
+	sum := sha256.Sum256(data)
+
+	common.Respond(c, gin.H{
+		"canonical": string(data),
+		"sha256":    hex.EncodeToString(sum[:]),
+	}, http.StatusOK, nil)
+}
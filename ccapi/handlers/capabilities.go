@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/maintenance"
+	"github.com/hyperledger-labs/ccapi/oidc"
+	"github.com/hyperledger-labs/ccapi/quota"
+	"github.com/hyperledger-labs/ccapi/rbac"
+	"github.com/hyperledger-labs/ccapi/sizeguard"
+	"github.com/hyperledger-labs/ccapi/versioning"
+)
+
+// Capabilities describes what this CCAPI serves to the user, so client SDKs
+// can adapt at runtime instead of being configured for each deployment: the
+// feature flags, the transactions and asset types of the chaincode, the
+// channels and organization profiles, how requests are authenticated and
+// the limits they are subject to. Transactions and asset types are left out
+// when the chaincode cannot be read, and listed in unavailable.
+func Capabilities(c *gin.Context) {
+	user := common.GetUser(c)
+	channelName, ccName := os.Getenv("CHANNEL"), os.Getenv("CCNAME")
+	unavailable := make([]string, 0)
+
+	var txs []gin.H
+	txList, err := chaincode.QueryGateway(channelName, ccName, "getTx", user, []string{"{}"})
+	if err == nil {
+		txs, err = capabilityTransactions(txList, user)
+	}
+	if err != nil {
+		log.Println("error listing transactions for capabilities: ", err)
+		unavailable = append(unavailable, "transactions")
+	}
+
+	var assetTypes []gin.H
+	schema, err := chaincode.QueryGateway(channelName, ccName, "getSchema", user, []string{"{}"})
+	if err == nil {
+		err = json.Unmarshal(schema, &assetTypes)
+	}
+	if err != nil {
+		log.Println("error listing asset types for capabilities: ", err)
+		unavailable = append(unavailable, "assetTypes")
+	}
+
+	limits := gin.H{
+		"transientMaxSize": getTransientMaxSize(),
+		"streamMaxResults": streamMaxResults(),
+		"assetSizeMode":    sizeguard.Mode(),
+	}
+	if limit, err := sizeguard.Limit(user); err == nil {
+		limits["assetSizeLimit"] = limit
+	}
+
+	authModes := []string{"headers"}
+	if oidc.Enabled() {
+		authModes = append(authModes, "oidc")
+	}
+
+	res := gin.H{
+		"channel":      channelName,
+		"chaincode":    ccName,
+		"orgs":         common.OrgNames(),
+		"features":     features.List(),
+		"transactions": txs,
+		"assetTypes":   assetTypes,
+		"auth": gin.H{
+			"modes": authModes,
+			"rbac":  rbac.Enabled(),
+		},
+		"limits":      limits,
+		"quotas":      quota.Enabled(),
+		"readOnly":    maintenance.Get().ReadOnly,
+		"unavailable": unavailable,
+	}
+	if cfg, err := versioning.GetConfig(); err == nil && cfg != nil {
+		res["apiVersions"] = cfg.Names()
+	}
+
+	common.Respond(c, res, http.StatusOK, nil)
+}
+
+// capabilityTransactions lists the transactions of the getTx result, with
+// whether the user may call them when roles restrict transactions
+func capabilityTransactions(txList []byte, user string) ([]gin.H, error) {
+	var list []struct {
+		Tag         string `json:"tag"`
+		Label       string `json:"label"`
+		Description string `json:"description"`
+	}
+	err := json.Unmarshal(txList, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]gin.H, 0, len(list))
+	for _, item := range list {
+		tx := gin.H{
+			"tag":         item.Tag,
+			"label":       item.Label,
+			"description": item.Description,
+		}
+		if rbac.Enabled() {
+			allowed, err := rbac.Allowed(user, item.Tag)
+			if err != nil {
+				return nil, err
+			}
+			tx["allowed"] = allowed
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// ListCollectibles searches the collectibles, optionally of the owner query
+// parameter
+func ListCollectibles(c *gin.Context) {
+	selector := map[string]interface{}{
+		"@assetType": "collectible",
+	}
+	if owner := c.Query("owner"); owner != "" {
+		selector["owner"] = owner
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"selector": selector,
+		},
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", args)
+}
+
+// MintCollectible creates a collectible owned by the organization of the
+// request
+func MintCollectible(c *gin.Context) {
+	req := make(map[string]interface{})
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "mintCollectible", req)
+}
+
+// ReadCollectible reads the collectible with the key in the path
+func ReadCollectible(c *gin.Context) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"key": assetKey(c, "collectible"),
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+}
+
+// UpdateCollectible updates the name and description of the collectible with
+// the key in the path, which must be owned by the organization of the request
+func UpdateCollectible(c *gin.Context) {
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	err := c.BindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	req := map[string]interface{}{
+		"collectible": assetKey(c, "collectible"),
+	}
+	if body.Name != "" {
+		req["name"] = body.Name
+	}
+	if body.Description != "" {
+		req["description"] = body.Description
+	}
+
+	submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "updateCollectible", req)
+}
+
+// TransferCollectible transfers the collectible with the key in the path to
+// the organization in the to field of the request body
+func TransferCollectible(c *gin.Context) {
+	var body struct {
+		To string `json:"to" binding:"required"`
+	}
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	req := map[string]interface{}{
+		"collectible": assetKey(c, "collectible"),
+		"newOwner":    body.To,
+	}
+	submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "transferOwnership", req)
+}
+
+// CollectibleTransfers returns the ownership changes of the collectible with
+// the key in the path, oldest first, read from its history
+func CollectibleTransfers(c *gin.Context) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"key": assetKey(c, "collectible"),
+	})
+	payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAssetHistory", args)
+	if !ok {
+		return
+	}
+
+	history, _ := payload.([]interface{})
+	transfers := make([]gin.H, 0)
+	var owner interface{}
+	for i := len(history) - 1; i >= 0; i-- {
+		version, _ := history[i].(map[string]interface{})
+		if isDelete, _ := version["_isDelete"].(bool); isDelete {
+			continue
+		}
+		if version["owner"] == owner {
+			continue
+		}
+
+		transfers = append(transfers, gin.H{
+			"from":      owner,
+			"to":        version["owner"],
+			"txId":      version["_txId"],
+			"timestamp": version["_timestamp"],
+		})
+		owner = version["owner"]
+	}
+
+	common.Respond(c, transfers, http.StatusOK, nil)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger-labs/ccapi/vc"
+	"github.com/pkg/errors"
+)
+
+// credentialRequest asks for a credential attesting to the state of an
+// asset
+type credentialRequest struct {
+	Key map[string]interface{} `json:"key" binding:"required"`
+	// Properties attested, default all the properties of the asset
+	Properties []string `json:"properties"`
+	// Subject is the id of the subject of the credential, such as the DID
+	// of the holder, default the asset
+	Subject  string `json:"subject"`
+	ValidFor string `json:"validFor"`
+}
+
+// IssueCredential issues a verifiable credential attesting to the state of
+// the asset with the key in the body as of the last block of the channel
+func IssueCredential(c *gin.Context) {
+	var req credentialRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	var validFor time.Duration
+	if req.ValidFor != "" {
+		validFor, err = time.ParseDuration(req.ValidFor)
+		if err != nil || validFor < 0 {
+			common.Abort(c, http.StatusBadRequest, errors.New("validFor must be a duration, such as 720h"))
+			return
+		}
+	}
+
+	if !authorize(c, "issueCredential") {
+		return
+	}
+	channelName := os.Getenv("CHANNEL")
+	chaincodeName := os.Getenv("CCNAME")
+
+	asset, err := readAsset(c, channelName, chaincodeName, req.Key)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	height, err := ledgerHeight(c, channelName)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	attested := map[string]interface{}{
+		"@assetType": asset["@assetType"],
+		"@key":       asset["@key"],
+	}
+	if len(req.Properties) == 0 {
+		for prop, value := range asset {
+			if !strings.HasPrefix(prop, "@") {
+				attested[prop] = value
+			}
+		}
+	}
+	for _, prop := range req.Properties {
+		value, ok := asset[prop]
+		if !ok {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("the asset has no property '%s'", prop))
+			return
+		}
+		attested[prop] = value
+	}
+
+	key, _ := asset["@key"].(string)
+	subject := req.Subject
+	if subject == "" {
+		subject = "urn:ccapi:asset:" + key
+	}
+	claims := map[string]interface{}{
+		"id":    subject,
+		"asset": attested,
+		"ledger": map[string]interface{}{
+			"channel":     channelName,
+			"chaincode":   chaincodeName,
+			"blockNumber": height - 1,
+			"lastTx":      asset["@lastTx"],
+			"lastUpdated": asset["@lastUpdated"],
+		},
+	}
+
+	cred, jwt, err := vc.Issue(claims, validFor)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	common.Respond(c, gin.H{"credential": cred, "jwt": jwt}, http.StatusOK, nil)
+}
+
+// VerifyCredential verifies the JWT of a credential in the body. With
+// current=true it also reports whether the attested properties still hold
+// on the ledger.
+func VerifyCredential(c *gin.Context) {
+	var req struct {
+		JWT string `json:"jwt" binding:"required"`
+	}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := vc.Verify(req.JWT)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	if c.Query("current") != "true" || !res.Valid {
+		common.Respond(c, res, http.StatusOK, nil)
+		return
+	}
+
+	attested, _ := res.Credential.CredentialSubject["asset"].(map[string]interface{})
+	ledger, _ := res.Credential.CredentialSubject["ledger"].(map[string]interface{})
+	channelName, _ := ledger["channel"].(string)
+	chaincodeName, _ := ledger["chaincode"].(string)
+	if attested == nil {
+		common.Abort(c, http.StatusBadRequest, errors.New("the credential does not attest to an asset"))
+		return
+	}
+
+	key := map[string]interface{}{
+		"@assetType": attested["@assetType"],
+		"@key":       attested["@key"],
+	}
+	changed := make([]string, 0)
+	asset, err := readAsset(c, channelName, chaincodeName, key)
+	if err != nil {
+		apiErr, status := common.ParseError(err)
+		if status != http.StatusNotFound {
+			common.Abort(c, status, apiErr)
+			return
+		}
+		changed = append(changed, "@key")
+	}
+	for prop, value := range attested {
+		if asset != nil && !reflect.DeepEqual(asset[prop], value) {
+			changed = append(changed, prop)
+		}
+	}
+
+	common.Respond(c, gin.H{
+		"valid":      res.Valid,
+		"errors":     res.Errors,
+		"credential": res.Credential,
+		"current":    len(changed) == 0,
+		"changed":    changed,
+	}, http.StatusOK, nil)
+}
+
+// CredentialIssuer returns the issuer of the credentials and its
+// certificate, for verifiers outside the network
+func CredentialIssuer(c *gin.Context) {
+	iss, err := vc.GetIssuer()
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	common.Respond(c, gin.H{
+		"id":          iss.ID,
+		"mspId":       iss.MSPID,
+		"certificate": iss.CertificatePEM(),
+	}, http.StatusOK, nil)
+}
+
+// readAsset reads the asset with the key as the user of the request
+func readAsset(c *gin.Context, channelName, chaincodeName string, key map[string]interface{}) (map[string]interface{}, error) {
+	args, _ := json.Marshal(map[string]interface{}{"key": key})
+	result, err := chaincode.QueryGatewayFor(c, channelName, chaincodeName, "readAsset", []string{string(args)})
+	if err != nil {
+		return nil, err
+	}
+
+	var asset map[string]interface{}
+	err = json.Unmarshal(result, &asset)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal asset")
+	}
+	return asset, nil
+}
+
+// ledgerHeight returns the number of blocks of the channel
+func ledgerHeight(c *gin.Context, channelName string) (uint64, error) {
+	if mock.Enabled() {
+		return mock.Height()
+	}
+	return chaincode.ChainHeight(channelName, common.GetUser(c))
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// validateValue is a value to check against a data type of the chaincode
+type validateValue struct {
+	DataType string      `json:"dataType" binding:"required"`
+	Value    interface{} `json:"value"`
+}
+
+// ValidateValues checks the values of the request body against the data
+// types of the chaincode, such as cpf, so front-ends reuse the exact rules of
+// the ledger. Each value gets its own result, with the value as it would be
+// stored or the validation error.
+func ValidateValues(c *gin.Context) {
+	var body struct {
+		Values []validateValue `json:"values" binding:"required,dive"`
+	}
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"values": body.Values,
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "validateValues", args)
+}
+
+// ValidateValue checks the value query parameter against the data type in
+// the path
+func ValidateValue(c *gin.Context) {
+	value, ok := c.GetQuery("value")
+	if !ok {
+		common.Abort(c, http.StatusBadRequest, errors.New("missing value query parameter"))
+		return
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"values": []validateValue{{DataType: c.Param("dataType"), Value: value}},
+	})
+	payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "validateValues", args)
+	if !ok {
+		return
+	}
+
+	results, _ := payload.([]interface{})
+	if len(results) != 1 {
+		common.Abort(c, http.StatusInternalServerError, errors.New("unexpected validation result"))
+		return
+	}
+	common.Respond(c, results[0], http.StatusOK, nil)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/did"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+// ListDIDs returns the DIDs of the organizations of the channel, default
+// the channel of the CCAPI
+func ListDIDs(c *gin.Context) {
+	channelName := c.DefaultQuery("channel", os.Getenv("CHANNEL"))
+	msps, err := channelMSPs(c, channelName)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	orgs := make([]gin.H, 0, len(msps))
+	for _, id := range did.SortedIDs(msps) {
+		orgs = append(orgs, gin.H{
+			"mspId": id,
+			"did":   did.ID(channelName, id),
+		})
+	}
+	common.Respond(c, orgs, http.StatusOK, nil)
+}
+
+// ResolveDID returns the DID document of an organization as a DID
+// resolution result, the format of Universal Resolver drivers
+func ResolveDID(c *gin.Context) {
+	id := c.Param("did")
+	channelName, mspID, err := did.Parse(id)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	msp, err := channelMSP(c, channelName, mspID)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	common.Respond(c, gin.H{
+		"@context":    "https://w3id.org/did-resolution/v1",
+		"didDocument": did.Document(channelName, msp),
+		"didResolutionMetadata": gin.H{
+			"contentType": "application/did+ld+json",
+		},
+		"didDocumentMetadata": gin.H{},
+	}, http.StatusOK, nil)
+}
+
+// VerifyDIDCertificate checks that the certificate in the body, such as the
+// one of the creator of a transaction, belongs to the organization of the
+// DID
+func VerifyDIDCertificate(c *gin.Context) {
+	var req struct {
+		DID         string `json:"did" binding:"required"`
+		Certificate string `json:"certificate" binding:"required"`
+	}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	cert, err := identity.CertificateFromPEM([]byte(req.Certificate))
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid certificate"))
+		return
+	}
+	channelName, mspID, err := did.Parse(req.DID)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	msp, err := channelMSP(c, channelName, mspID)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	errs := msp.Verify(cert)
+	common.Respond(c, gin.H{
+		"did":         req.DID,
+		"valid":       len(errs) == 0,
+		"errors":      errs,
+		"subject":     cert.Subject.String(),
+		"fingerprint": did.Fingerprint(cert),
+	}, http.StatusOK, nil)
+}
+
+// channelMSP returns the MSP of an organization of the channel
+func channelMSP(c *gin.Context, channelName, mspID string) (*did.MSP, error) {
+	msps, err := channelMSPs(c, channelName)
+	if err != nil {
+		return nil, err
+	}
+	msp, ok := msps[mspID]
+	if !ok {
+		return nil, common.NewAPIError(http.StatusNotFound, "organization '"+mspID+"' is not a member of channel '"+channelName+"'")
+	}
+	return msp, nil
+}
+
+// channelMSPs returns the MSPs of the organizations of the channel, read
+// from its configuration block
+func channelMSPs(c *gin.Context, channelName string) (map[string]*did.MSP, error) {
+	if mock.Enabled() {
+		return nil, common.NewAPIError(http.StatusServiceUnavailable, "DID resolution requires a Fabric network")
+	}
+	block, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetConfigBlock", []string{channelName})
+	if err != nil {
+		return nil, err
+	}
+	return did.MSPs(block)
+}
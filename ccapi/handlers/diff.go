@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/jsonpatch"
+	"github.com/pkg/errors"
+)
+
+// DiffAsset compares two versions of the asset of the type with the key in
+// the path, written by the transactions in the from and to query parameters.
+// By default to is the latest version and from is the version before it.
+func DiffAsset(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": assetKey(c, assetType),
+		})
+		result, err := chaincode.QueryGatewayFor(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAssetHistory", []string{string(args)})
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+
+		var history []map[string]interface{}
+		err = common.UnmarshalJSON(result, &history)
+		if err != nil {
+			common.Abort(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Peers return the newest versions first
+		for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+			history[i], history[j] = history[j], history[i]
+		}
+
+		to := len(history) - 1
+		if txID := c.Query("to"); txID != "" {
+			to = findVersion(history, txID)
+			if to < 0 {
+				common.Abort(c, http.StatusNotFound, errors.Errorf("transaction %s not found in the asset history", txID))
+				return
+			}
+		}
+		from := to - 1
+		if txID := c.Query("from"); txID != "" {
+			from = findVersion(history, txID)
+			if from < 0 {
+				common.Abort(c, http.StatusNotFound, errors.Errorf("transaction %s not found in the asset history", txID))
+				return
+			}
+		}
+
+		var fromDoc, toDoc map[string]interface{}
+		res := gin.H{}
+		if from >= 0 {
+			res["from"], fromDoc = splitVersion(history[from])
+		}
+		res["to"], toDoc = splitVersion(history[to])
+		res["changes"] = fieldChanges(fromDoc, toDoc)
+		res["patch"] = jsonpatch.Diff(fromDoc, toDoc)
+
+		common.Respond(c, res, http.StatusOK, nil)
+	}
+}
+
+// findVersion returns the index of the version written by the transaction
+func findVersion(history []map[string]interface{}, txID string) int {
+	for i, version := range history {
+		if version["_txId"] == txID {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitVersion separates the history metadata of a version from the asset,
+// which is nil when the version is a deletion
+func splitVersion(version map[string]interface{}) (gin.H, map[string]interface{}) {
+	meta := gin.H{
+		"txId":      version["_txId"],
+		"timestamp": version["_timestamp"],
+		"isDelete":  version["_isDelete"],
+	}
+	if version["_isDelete"] == true {
+		return meta, nil
+	}
+
+	asset := make(map[string]interface{}, len(version))
+	for k, v := range version {
+		switch k {
+		case "_txId", "_timestamp", "_isDelete":
+		default:
+			asset[k] = v
+		}
+	}
+	return meta, asset
+}
+
+// fieldChanges lists the properties with different values in both versions
+func fieldChanges(from, to map[string]interface{}) []gin.H {
+	fields := make(map[string]bool)
+	for k := range from {
+		fields[k] = true
+	}
+	for k := range to {
+		fields[k] = true
+	}
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	changes := make([]gin.H, 0)
+	for _, name := range names {
+		a, b := from[name], to[name]
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		changes = append(changes, gin.H{
+			"field": name,
+			"from":  a,
+			"to":    b,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// ReadEndorsementPolicy returns the organizations that must endorse changes
+// to the asset of the type with the key in the path, empty when the
+// chaincode endorsement policy applies
+func ReadEndorsementPolicy(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": assetKey(c, assetType),
+		})
+		evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "getEndorsementPolicy", args)
+	}
+}
+
+// SetEndorsementPolicy sets the organizations in the orgs field of the
+// request body as the endorsers of the asset of the type with the key in the
+// path. An empty list removes the key-level endorsement policy.
+func SetEndorsementPolicy(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Orgs []string `json:"orgs" binding:"required"`
+		}
+		err := c.ShouldBindJSON(&body)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+
+		req := map[string]interface{}{
+			"key":  assetKey(c, assetType),
+			"orgs": body.Orgs,
+		}
+		submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "setEndorsementPolicy", req)
+	}
+}
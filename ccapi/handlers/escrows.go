@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/leader"
+	"github.com/hyperledger-labs/ccapi/scheduler"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/hyperledger-labs/ccapi/webhook"
+	"github.com/pkg/errors"
+)
+
+// Webhook events of the escrow workflow
+const (
+	EventEscrowLocked    = "escrow.locked"
+	EventEscrowConfirmed = "escrow.confirmed"
+	EventEscrowReleased  = "escrow.released"
+)
+
+// escrowSweepInterval is how often the leader looks for the locked escrows
+// whose release it did not schedule, such as those locked through other
+// replicas
+const escrowSweepInterval = time.Minute
+
+// ListEscrows searches escrows by the status, seller and buyer query
+// parameters
+func ListEscrows(c *gin.Context) {
+	selector := map[string]interface{}{
+		"@assetType": "escrow",
+	}
+	for _, field := range []string{"status", "seller", "buyer"} {
+		if value := c.Query(field); value != "" {
+			selector[field] = value
+		}
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"selector": selector,
+		},
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", args)
+}
+
+// LockEscrow locks a collectible of the organization of the request until
+// the buyer confirms it. The expiration may be given as a timeout, such as
+// "24h", instead of the expiresAt date. The release of the escrow is
+// scheduled for when it expires, by the leader.
+func LockEscrow(c *gin.Context) {
+	req := make(map[string]interface{})
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if timeout, ok := req["timeout"]; ok {
+		timeoutStr, _ := timeout.(string)
+		d, err := time.ParseDuration(timeoutStr)
+		if err != nil || d <= 0 {
+			common.Abort(c, http.StatusBadRequest, errors.New("timeout must be a positive duration, such as 24h"))
+			return
+		}
+		delete(req, "timeout")
+		req["expiresAt"] = time.Now().Add(d).UTC().Format(time.RFC3339)
+	}
+
+	payload, ok := submit(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "lockEscrow", req)
+	if !ok {
+		return
+	}
+	if leader.IsLeader() {
+		scheduleEscrowRelease(payload)
+	}
+	webhook.Notify(EventEscrowLocked, payload)
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// ReadEscrow reads the escrow with the key in the path
+func ReadEscrow(c *gin.Context) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"key": assetKey(c, "escrow"),
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+}
+
+// SettleEscrow confirms or releases, depending on the transaction, the
+// escrow with the key in the path, cancelling its scheduled release
+func SettleEscrow(txName, event string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := map[string]interface{}{
+			"escrow": assetKey(c, "escrow"),
+		}
+
+		payload, ok := submit(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), txName, req)
+		if !ok {
+			return
+		}
+		if escrow, ok := payload.(map[string]interface{}); ok {
+			key, _ := escrow["@key"].(string)
+			scheduler.Cancel(escrowJobID(key))
+		}
+		webhook.Notify(event, payload)
+
+		common.Respond(c, payload, http.StatusOK, nil)
+	}
+}
+
+// ListScheduledJobs returns the jobs waiting to run, such as escrow releases
+func ListScheduledJobs(c *gin.Context) {
+	common.Respond(c, scheduler.Jobs(), http.StatusOK, nil)
+}
+
+// ScheduleEscrowReleases schedules the release of the locked escrows while
+// the replica is the leader, including those locked before the CCAPI
+// started, as scheduled jobs are kept in memory, and those locked through
+// other replicas, looked for every minute. The releases are dropped when
+// the replica is no longer the leader, so only one replica submits them.
+func ScheduleEscrowReleases() {
+	for {
+		lost := leader.Wait()
+		scheduleLockedEscrows()
+
+		ticker := time.NewTicker(escrowSweepInterval)
+		for leading := true; leading; {
+			select {
+			case <-ticker.C:
+				scheduleLockedEscrows()
+			case <-lost:
+				leading = false
+			}
+		}
+		ticker.Stop()
+
+		for _, job := range scheduler.Jobs() {
+			if strings.HasPrefix(job.ID, escrowJobID("")) {
+				scheduler.Cancel(job.ID)
+			}
+		}
+	}
+}
+
+// scheduleLockedEscrows schedules the release of the locked escrows whose
+// release is not scheduled yet
+func scheduleLockedEscrows() {
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"@assetType": "escrow",
+				"status":     "locked",
+			},
+		},
+	})
+
+	result, err := chaincode.QueryGateway(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", os.Getenv("USER"), []string{string(args)})
+	if err != nil {
+		log.Println("error searching locked escrows: ", err)
+		return
+	}
+
+	var response struct {
+		Result []interface{} `json:"result"`
+	}
+	err = json.Unmarshal(result, &response)
+	if err != nil {
+		log.Println("error unmarshalling locked escrows: ", err)
+		return
+	}
+
+	for _, escrow := range response.Result {
+		escrowMap, _ := escrow.(map[string]interface{})
+		key, _ := escrowMap["@key"].(string)
+		if !scheduler.Scheduled(escrowJobID(key)) {
+			scheduleEscrowRelease(escrow)
+		}
+	}
+}
+
+func escrowJobID(key string) string {
+	return "releaseEscrow:" + key
+}
+
+// scheduleEscrowRelease submits releaseEscrow as USER when the escrow
+// expires, unless it is settled before. The release is marked as expired,
+// so the chaincode refuses it until the escrow expires by the timestamp of
+// the transaction, whatever the clock of the CCAPI and the organization of
+// USER, which could otherwise release it early as the buyer.
+func scheduleEscrowRelease(escrow interface{}) {
+	escrowMap, _ := escrow.(map[string]interface{})
+	key, _ := escrowMap["@key"].(string)
+	expiresAtStr, _ := escrowMap["expiresAt"].(string)
+	expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
+	if key == "" || err != nil {
+		log.Println("cannot schedule release of escrow without key and expiration date")
+		return
+	}
+
+	scheduler.Schedule(escrowJobID(key), expiresAt, func() error {
+		args, _ := json.Marshal(map[string]interface{}{
+			"escrow": map[string]interface{}{
+				"@key": key,
+			},
+			"expired": true,
+		})
+		entry := audit.Entry{
+			Channel:   os.Getenv("CHANNEL"),
+			Chaincode: os.Getenv("CCNAME"),
+			TxName:    "releaseEscrow",
+			User:      os.Getenv("USER"),
+			Args:      []string{string(args)},
+		}
+
+		result, status, err := submitAudited(entry, nil, txqueue.Batch)
+		if status == http.StatusConflict {
+			// Settled in the meantime, or not expired yet by the time of
+			// the transaction, in which case the next sweep schedules it
+			// again
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var payload interface{}
+		common.UnmarshalJSON(result.Result, &payload)
+		webhook.Notify(EventEscrowReleased, payload)
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/eventschema"
+	"github.com/pkg/errors"
+)
+
+// ListEventSchemas returns the schemas of the registry of event payloads,
+// with how many received events were validated against them
+func ListEventSchemas(c *gin.Context) {
+	entries, err := eventschema.List()
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.Respond(c, entries, http.StatusOK, nil)
+}
+
+// GetEventSchema returns the JSON Schema of the payload of the event in the
+// path
+func GetEventSchema(c *gin.Context) {
+	schema, ok := eventSchema(c)
+	if !ok {
+		return
+	}
+
+	data, _ := json.Marshal(schema)
+	c.Data(http.StatusOK, "application/schema+json", data)
+}
+
+// ValidateEventPayload validates the body against the schema of the event
+// in the path, so producers and consumers can check payloads against the
+// contract of the event
+func ValidateEventPayload(c *gin.Context) {
+	schema, ok := eventSchema(c)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	var payload interface{}
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "payload must be JSON"))
+		return
+	}
+
+	violations := schema.Validate(payload)
+	common.Respond(c, gin.H{
+		"event":      c.Param("event"),
+		"valid":      len(violations) == 0,
+		"violations": violations,
+	}, http.StatusOK, nil)
+}
+
+// eventSchema returns the schema of the event in the path, responding with
+// 404 if it is not registered
+func eventSchema(c *gin.Context) (eventschema.Schema, bool) {
+	schema, ok, err := eventschema.Get(c.Param("event"))
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if !ok {
+		common.Abort(c, http.StatusNotFound, common.NewAPIError(http.StatusNotFound, fmt.Sprintf("no schema registered for event '%s'", c.Param("event"))))
+		return nil, false
+	}
+	return schema, true
+}
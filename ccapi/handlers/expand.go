@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// maxExpandDepth limits how many levels of references are expanded
+const maxExpandDepth = 3
+
+// expander resolves the references listed in the expand query parameter
+// (ex: ?expand=currentTenant or ?expand=books.currentTenant), reading each
+// referenced asset at most once per request
+type expander struct {
+	c     *gin.Context
+	cache map[string]map[string]interface{}
+}
+
+// expandPaths parses the expand query parameter into dotted paths
+func expandPaths(c *gin.Context) ([][]string, error) {
+	expand := c.Query("expand")
+	if expand == "" {
+		return nil, nil
+	}
+
+	paths := make([][]string, 0)
+	for _, path := range strings.Split(expand, ",") {
+		parts := strings.Split(strings.TrimSpace(path), ".")
+		if len(parts) > maxExpandDepth {
+			return nil, errors.Errorf("cannot expand '%s', references can only be expanded %d levels deep", path, maxExpandDepth)
+		}
+		paths = append(paths, parts)
+	}
+	return paths, nil
+}
+
+func newExpander(c *gin.Context) *expander {
+	return &expander{
+		c:     c,
+		cache: make(map[string]map[string]interface{}),
+	}
+}
+
+// expand replaces the references of the asset in the paths by the assets
+// they point to. References to an asset already being expanded are kept,
+// which prevents cycles.
+func (e *expander) expand(asset map[string]interface{}, paths [][]string, visited map[string]bool) error {
+	key, _ := asset["@key"].(string)
+	visited[key] = true
+	defer delete(visited, key)
+
+	// Group paths by their first property
+	byProp := make(map[string][][]string)
+	for _, path := range paths {
+		byProp[path[0]] = append(byProp[path[0]], path[1:])
+	}
+
+	for prop, subpaths := range byProp {
+		rest := make([][]string, 0)
+		for _, p := range subpaths {
+			if len(p) > 0 {
+				rest = append(rest, p)
+			}
+		}
+
+		switch value := asset[prop].(type) {
+		case map[string]interface{}:
+			expanded, err := e.resolve(value, rest, visited)
+			if err != nil {
+				return err
+			}
+			asset[prop] = expanded
+		case []interface{}:
+			for i, item := range value {
+				ref, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				expanded, err := e.resolve(ref, rest, visited)
+				if err != nil {
+					return err
+				}
+				value[i] = expanded
+			}
+		}
+	}
+	return nil
+}
+
+// resolve reads the referenced asset and expands its own references
+func (e *expander) resolve(ref map[string]interface{}, paths [][]string, visited map[string]bool) (map[string]interface{}, error) {
+	key, ok := ref["@key"].(string)
+	if !ok || visited[key] {
+		return ref, nil
+	}
+
+	asset, ok := e.cache[key]
+	if !ok {
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": map[string]interface{}{
+				"@assetType": strings.SplitN(key, ":", 2)[0],
+				"@key":       key,
+			},
+		})
+		result, err := chaincode.QueryGatewayFor(e.c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", []string{string(args)})
+		if err != nil {
+			return nil, err
+		}
+
+		err = common.UnmarshalJSON(result, &asset)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal "+key)
+		}
+		e.cache[key] = asset
+	}
+
+	// Copy so the same asset can be expanded differently in other places
+	expanded := make(map[string]interface{}, len(asset))
+	for k, v := range asset {
+		expanded[k] = v
+	}
+	if len(paths) > 0 {
+		err := e.expand(expanded, paths, visited)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// expandAssets expands the references of the assets according to the
+// expand query parameter. On failure the request is aborted and ok is false.
+func expandAssets(c *gin.Context, assets ...interface{}) (ok bool) {
+	paths, err := expandPaths(c)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return false
+	}
+	if len(paths) == 0 {
+		return true
+	}
+
+	e := newExpander(c)
+	for _, item := range assets {
+		asset, isMap := item.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		err := e.expand(asset, paths, make(map[string]bool))
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/features"
+)
+
+// ListFeatures returns the experimental features and whether they are
+// enabled, so clients can tell a disabled endpoint from a missing one
+func ListFeatures(c *gin.Context) {
+	common.Respond(c, features.List(), http.StatusOK, nil)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// selectFields keeps only the asset properties listed in the fields query
+// parameter (ex: ?fields=name,author) on the result of readAsset, search and
+// readAssetHistory. Metadata such as @key and _links is always kept.
+func selectFields(c *gin.Context, txName string, payload interface{}) interface{} {
+	fields := c.Query("fields")
+	if fields == "" {
+		return payload
+	}
+
+	keep := make(map[string]bool)
+	for _, f := range strings.Split(fields, ",") {
+		keep[strings.TrimSpace(f)] = true
+	}
+
+	switch txName {
+	case "readAsset":
+		project(payload, keep)
+	case "search":
+		if res, ok := payload.(map[string]interface{}); ok {
+			list, _ := res["result"].([]interface{})
+			for _, item := range list {
+				project(item, keep)
+			}
+		}
+	case "readAssetHistory":
+		list, _ := payload.([]interface{})
+		for _, item := range list {
+			project(item, keep)
+		}
+	}
+
+	return payload
+}
+
+// project removes the properties of the asset that are not kept
+func project(asset interface{}, keep map[string]bool) {
+	m, ok := asset.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for key := range m {
+		if keep[key] || strings.HasPrefix(key, "@") || strings.HasPrefix(key, "_") {
+			continue
+		}
+		delete(m, key)
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/format"
+	"github.com/pkg/errors"
+)
+
+// formatOptions reads the formatting options of the request, aborting it if
+// they are invalid. The options are nil if no formatting was requested.
+func formatOptions(c *gin.Context) (*format.Options, bool) {
+	opts, err := format.Parse(c.Request.URL.Query())
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid formatting options"))
+		return nil, false
+	}
+	return opts, true
+}
+
+// formatAsset formats the properties of the asset for display, keeping
+// their original values under _raw
+func formatAsset(c *gin.Context, opts *format.Options, assetType string, asset map[string]interface{}) {
+	if opts == nil {
+		return
+	}
+
+	fields, err := filterFields(c, assetType)
+	if err != nil {
+		return
+	}
+	opts.Asset(fields, asset)
+}
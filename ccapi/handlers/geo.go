@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/filter"
+	"github.com/hyperledger-labs/ccapi/geo"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultNearLimit = 100
+	maxNearLimit     = 1000
+)
+
+// validateGeoPoints checks the geopoint properties of an asset in a request
+// body, so invalid locations fail before reaching the chaincode
+func validateGeoPoints(c *gin.Context, assetType string, asset map[string]interface{}) error {
+	schema, err := getAssetSchema(c, assetType)
+	if err != nil {
+		return err
+	}
+
+	for _, prop := range schema.Props {
+		value, ok := asset[prop.Tag]
+		if prop.DataType != geo.DataType || !ok || value == nil {
+			continue
+		}
+		if _, err := geo.Parse(value); err != nil {
+			return common.NewAPIError(http.StatusBadRequest, errors.Wrapf(err, "invalid '%s'", prop.Tag).Error())
+		}
+	}
+	return nil
+}
+
+// NearAssets lists the assets of the type within the radius query parameter,
+// in meters, of the point given by lat and long, closest first. The search
+// narrows the assets down with a bounding box on the geopoint property in
+// field, which can be omitted if the type has only one, and computes the
+// exact distance of the candidates, returned in _distance.
+func NearAssets(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, ok := formatOptions(c)
+		if !ok {
+			return
+		}
+
+		center, err := geo.Parse(c.Query("lat") + "," + c.Query("long"))
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+		radius, err := strconv.ParseFloat(c.Query("radius"), 64)
+		if err != nil || radius <= 0 {
+			common.Abort(c, http.StatusBadRequest, errors.New("the radius query parameter must be a positive number of meters"))
+			return
+		}
+		limit := defaultNearLimit
+		if l := c.Query("limit"); l != "" {
+			limit, err = strconv.Atoi(l)
+			if err != nil || limit <= 0 {
+				common.Abort(c, http.StatusBadRequest, errors.New("the limit query parameter must be a positive integer"))
+				return
+			}
+		}
+		if limit > maxNearLimit {
+			limit = maxNearLimit
+		}
+
+		fields, err := filterFields(c, assetType)
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+		field, err := geoField(fields, c.Query("field"))
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+
+		box := geo.BoundingBox(center, radius)
+		longRange := map[string]interface{}{field + ".long": map[string]interface{}{"$gte": box.MinLong, "$lte": box.MaxLong}}
+		if box.MinLong > box.MaxLong {
+			longRange = map[string]interface{}{"$or": []interface{}{
+				map[string]interface{}{field + ".long": map[string]interface{}{"$gte": box.MinLong}},
+				map[string]interface{}{field + ".long": map[string]interface{}{"$lte": box.MaxLong}},
+			}}
+		}
+		terms := []interface{}{
+			map[string]interface{}{"@assetType": assetType},
+			map[string]interface{}{field + ".lat": map[string]interface{}{"$gte": box.MinLat, "$lte": box.MaxLat}},
+			longRange,
+		}
+		if expr := c.Query("filter"); expr != "" {
+			filterSelector, err := filter.Parse(expr, fields)
+			if err != nil {
+				common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid filter"))
+				return
+			}
+			terms = append(terms, filterSelector)
+		}
+
+		candidates, err := searchAll(c, map[string]interface{}{"$and": terms})
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+
+		type match struct {
+			asset    map[string]interface{}
+			distance float64
+		}
+		matches := make([]match, 0)
+		for _, item := range candidates {
+			asset, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			point, err := geo.Parse(asset[field])
+			if err != nil {
+				continue
+			}
+			if d := geo.Distance(center, point); d <= radius {
+				matches = append(matches, match{asset, d})
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].distance < matches[j].distance
+		})
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+
+		result := make([]interface{}, 0, len(matches))
+		for _, m := range matches {
+			decryptAsset(assetType, m.asset)
+			addLinks(c, assetType, m.asset)
+			formatAsset(c, opts, assetType, m.asset)
+			m.asset["_distance"] = m.distance
+			result = append(result, selectFields(c, "readAsset", m.asset))
+		}
+
+		common.Respond(c, gin.H{
+			"result": result,
+			"metadata": gin.H{
+				"count":   len(result),
+				"scanned": len(candidates),
+			},
+		}, http.StatusOK, nil)
+	}
+}
+
+// geoField returns the geopoint property to search, which defaults to the
+// only one of the asset type
+func geoField(fields filter.Fields, field string) (string, error) {
+	if field != "" {
+		if fields[field] != geo.DataType {
+			return "", errors.Errorf("'%s' is not a %s property", field, geo.DataType)
+		}
+		return field, nil
+	}
+
+	for tag, dataType := range fields {
+		if dataType != geo.DataType {
+			continue
+		}
+		if field != "" {
+			return "", errors.New("the field query parameter must name the geopoint property to search")
+		}
+		field = tag
+	}
+	if field == "" {
+		return "", errors.Errorf("the asset type has no %s property", geo.DataType)
+	}
+	return field, nil
+}
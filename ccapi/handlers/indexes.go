@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/couchdb"
+	"github.com/hyperledger-labs/ccapi/slowquery"
+)
+
+// internalProps are the properties cc-tools adds to every asset
+var internalProps = []string{"@assetType", "@key", "@lastTouchBy", "@lastTx", "@lastUpdated"}
+
+// warnUnindexed warns the caller of a search of the chaincode whose rich
+// query would run without an index of the state database
+func warnUnindexed(c *gin.Context, channelName, chaincodeName string, args []byte) {
+	if !couchdb.Enabled() || channelName != os.Getenv("CHANNEL") || chaincodeName != os.Getenv("CCNAME") {
+		return
+	}
+	var req struct {
+		Query map[string]interface{} `json:"query"`
+	}
+	if json.Unmarshal(args, &req) != nil || req.Query == nil {
+		return
+	}
+	if warning := couchdb.Check(req.Query); warning != "" {
+		common.AddWarning(c, warning)
+	}
+}
+
+// ListIndexes lists the indexes of the state database of the chaincode and
+// those deployed with it, with whether each of them is in the other list
+// and the errors of invalid definitions
+func ListIndexes(c *gin.Context) {
+	deployed, err := couchdb.List()
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	declared, err := couchdb.ReadDeclared()
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	files := make(map[string]string)
+	declaredList := make([]gin.H, 0, len(declared))
+	for _, d := range declared {
+		found := false
+		if d.Definition != nil {
+			for _, index := range deployed {
+				if index.Name == d.Definition.Name && (d.Definition.DDoc == "" || index.DDoc == d.Definition.DDoc) {
+					found = true
+					files[index.DDoc+"/"+index.Name] = d.File
+				}
+			}
+		}
+		declaredList = append(declaredList, gin.H{
+			"file":       d.File,
+			"definition": d.Definition,
+			"errors":     d.Errors,
+			"deployed":   found,
+		})
+	}
+
+	deployedList := make([]gin.H, 0, len(deployed))
+	for _, index := range deployed {
+		deployedList = append(deployedList, gin.H{
+			"ddoc":   index.DDoc,
+			"name":   index.Name,
+			"type":   index.Type,
+			"fields": index.Fields,
+			"file":   files[index.DDoc+"/"+index.Name],
+		})
+	}
+
+	common.Respond(c, gin.H{
+		"database": couchdb.Database(),
+		"deployed": deployedList,
+		"declared": declaredList,
+	}, http.StatusOK, nil)
+}
+
+// CreateIndex creates the index of the definition in the body in the state
+// database, once it is valid
+func CreateIndex(c *gin.Context) {
+	def, errs, ok := bindIndexDefinition(c)
+	if !ok {
+		return
+	}
+	if len(errs) > 0 {
+		common.Abort(c, http.StatusBadRequest, &common.APIError{
+			Status:  http.StatusBadRequest,
+			Code:    common.ErrCodeBadRequest,
+			Message: "invalid index definition",
+			Details: map[string]interface{}{"errors": errs},
+		})
+		return
+	}
+
+	existed, err := couchdb.Create(def)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	common.Respond(c, gin.H{
+		"database": couchdb.Database(),
+		"ddoc":     def.DDoc,
+		"name":     def.Name,
+		"created":  !existed,
+	}, http.StatusOK, nil)
+}
+
+// ValidateIndex checks the index definition in the body without creating
+// it. Fields that are not properties of any asset type are warnings, as
+// queries may still use them.
+func ValidateIndex(c *gin.Context) {
+	def, errs, ok := bindIndexDefinition(c)
+	if !ok {
+		return
+	}
+
+	warnings := make([]string, 0)
+	props, err := assetTypeProps(c)
+	if err != nil {
+		warnings = append(warnings, "fields not checked against the asset types: "+err.Error())
+	} else {
+		for _, name := range def.FieldNames() {
+			if !props[strings.SplitN(name, ".", 2)[0]] {
+				warnings = append(warnings, fmt.Sprintf("field '%s' is not a property of any asset type", name))
+			}
+		}
+	}
+
+	common.Respond(c, gin.H{
+		"valid":    len(errs) == 0,
+		"errors":   errs,
+		"warnings": warnings,
+	}, http.StatusOK, nil)
+}
+
+// ExplainQuery returns the index the state database would use to run the
+// rich query in the body, if any
+func ExplainQuery(c *gin.Context) {
+	query := make(map[string]interface{})
+	if err := c.ShouldBindJSON(&query); err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	index, err := couchdb.Explain(query)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	common.Respond(c, gin.H{
+		"indexed": index != nil,
+		"index":   index,
+	}, http.StatusOK, nil)
+}
+
+// ListUnindexedQueries lists the shapes of the rich queries that ran
+// without an index since the CCAPI started
+func ListUnindexedQueries(c *gin.Context) {
+	common.Respond(c, couchdb.GetUnindexed(), http.StatusOK, nil)
+}
+
+// bindIndexDefinition reads the index definition of the body and its
+// errors. On failure the request is aborted and ok is false.
+func bindIndexDefinition(c *gin.Context) (def *couchdb.Definition, errs []string, ok bool) {
+	def = &couchdb.Definition{}
+	if err := c.ShouldBindJSON(def); err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return nil, nil, false
+	}
+	return def, def.Validate(), true
+}
+
+// assetTypeProps returns the names of the properties of the asset types of
+// the chaincode, along with those of every asset
+func assetTypeProps(c *gin.Context) (map[string]bool, error) {
+	user := common.GetUser(c)
+	res, err := chaincode.QueryGateway(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "getSchema", user, []string{"{}"})
+	if err != nil {
+		return nil, err
+	}
+	var assetTypes []struct {
+		Tag string `json:"tag"`
+	}
+	if err := json.Unmarshal(res, &assetTypes); err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]bool)
+	for _, p := range internalProps {
+		props[p] = true
+	}
+	for _, assetType := range assetTypes {
+		schema, err := loadAssetSchema(assetType.Tag, user)
+		if err != nil {
+			return nil, err
+		}
+		for _, prop := range schema.Props {
+			props[prop.Tag] = true
+		}
+	}
+	return props, nil
+}
+
+// SlowQueryReport returns the durations of the rich queries by the shape of
+// their selector, with suggestions of indexes and pagination
+func SlowQueryReport(c *gin.Context) {
+	common.Respond(c, slowquery.Report(), http.StatusOK, nil)
+}
+
+// ResetSlowQueries forgets the durations of the rich queries, to measure
+// again once indexes are added
+func ResetSlowQueries(c *gin.Context) {
+	slowquery.Reset()
+	c.Status(http.StatusNoContent)
+}
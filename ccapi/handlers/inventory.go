@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// GetBookCopies returns the copies of the book with the key in the path and
+// how many of them are available
+func GetBookCopies(c *gin.Context) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"book": assetKey(c, "book"),
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "getBookCopies", args)
+}
+
+// AddBookCopies adds the amount in the body to the copies of the book with
+// the key in the path, removing available copies if it is negative
+func AddBookCopies(c *gin.Context) {
+	req := make(map[string]interface{})
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	req["book"] = assetKey(c, "book")
+
+	submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "addBookCopies", req)
+}
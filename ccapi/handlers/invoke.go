@@ -4,11 +4,12 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
 	"github.com/hyperledger-labs/ccapi/chaincode"
 	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
 )
 
 func Invoke(c *gin.Context) {
@@ -28,17 +29,13 @@ func Invoke(c *gin.Context) {
 	if collectionsQuery != "" {
 		collectionsByte, err := base64.StdEncoding.DecodeString(collectionsQuery)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "the @collections query parameter must be a base64-encoded JSON array of strings",
-			})
+			common.Abort(c, http.StatusBadRequest, errors.New("the @collections query parameter must be a base64-encoded JSON array of strings"))
 			return
 		}
 
 		err = json.Unmarshal(collectionsByte, &collections)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "the @collections query parameter must be a base64-encoded JSON array of strings",
-			})
+			common.Abort(c, http.StatusBadRequest, errors.New("the @collections query parameter must be a base64-encoded JSON array of strings"))
 			return
 		}
 	} else {
@@ -50,26 +47,20 @@ func Invoke(c *gin.Context) {
 		}
 	}
 
-	transientMap := make(map[string]interface{})
-	for key, value := range req {
-		if key[0] == '~' {
-			keyTrimmed := strings.TrimPrefix(key, "~")
-			transientMap[keyTrimmed] = value
-			delete(req, key)
-		}
+	if !checkBody(c, txName, req) {
+		return
 	}
 
-	args, err := json.Marshal(req)
+	transient, err := extractTransient(req)
 	if err != nil {
-		common.Abort(c, http.StatusInternalServerError, err)
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
 		return
 	}
 
-	transientMapByte, err := json.Marshal(transientMap)
+	args, err := json.Marshal(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		common.Abort(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -78,19 +69,58 @@ func Invoke(c *gin.Context) {
 		argList = append(argList, args)
 	}
 
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
+	if !authorize(c, txName) {
+		return
+	}
+	if !checkWritable(c) {
+		return
+	}
+	if !checkSecrets(c, txName, []string{string(args)}) {
+		return
 	}
 
-	res, status, err := chaincode.Invoke(channelName, chaincodeName, txName, user, argList, transientMapByte)
+	user := common.GetUser(c)
+	if !checkQuota(c, user) {
+		return
+	}
+	if !checkAssetSize(c, user, txName, []string{string(args)}) {
+		return
+	}
+	if err := checkPersonalData([]string{string(args)}); err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	entry := audit.Entry{
+		Channel:      channelName,
+		Chaincode:    chaincodeName,
+		TxName:       txName,
+		User:         user,
+		Args:         []string{string(args)},
+		HasTransient: hasTransient(transient),
+	}
+
+	release, ok := reserveQuota(c, user)
+	if !ok {
+		return
+	}
+	res, status, err := chaincode.Invoke(channelName, chaincodeName, txName, user, argList, transient)
 	if err != nil {
+		release()
+		entry.Status = status
+		entry.Error = err.Error()
+		audit.Record(entry)
 		common.Abort(c, status, err)
 		return
 	}
 
+	entry.TxID = string(res.TransactionID)
+	entry.Status = status
+	audit.Record(entry)
+
 	var payload interface{}
-	err = json.Unmarshal(res.Payload, &payload)
+	err = common.UnmarshalJSON(res.Payload, &payload)
 	if err != nil {
 		common.Abort(c, http.StatusInternalServerError, err)
 		return
@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
-	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
 	"github.com/hyperledger-labs/ccapi/chaincode"
 	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/maintenance"
+	"github.com/hyperledger-labs/ccapi/secretscan"
+	"github.com/hyperledger-labs/ccapi/sizeguard"
+	"github.com/hyperledger-labs/ccapi/txqueue"
 	"github.com/pkg/errors"
 )
 
@@ -38,69 +42,174 @@ func invokeGateway(c *gin.Context, channelName, chaincodeName string) {
 
 	txName := c.Param("txname")
 
+	submitGateway(c, channelName, chaincodeName, txName, req)
+}
+
+// submitGateway submits the transaction with the request body as argument and
+// writes the response, or only its id if the commit is notified to the
+// @callback URL
+func submitGateway(c *gin.Context, channelName, chaincodeName, txName string, req map[string]interface{}) {
+	if c.Query("@callback") != "" {
+		submitWithCallback(c, channelName, chaincodeName, txName, req)
+		return
+	}
+
+	payload, ok := submit(c, channelName, chaincodeName, txName, req)
+	if !ok {
+		return
+	}
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// submit submits the transaction and returns its parsed result. On failure
+// the request is aborted and ok is false
+func submit(c *gin.Context, channelName, chaincodeName, txName string, req map[string]interface{}) (payload interface{}, ok bool) {
+	entry, transient, ok := submissionEntry(c, channelName, chaincodeName, txName, req)
+	if !ok {
+		return nil, false
+	}
+	class, err := txqueue.ParseClass(c.Query("@priority"))
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	release, ok := reserveQuota(c, entry.User)
+	if !ok {
+		return nil, false
+	}
+	result, status, err := submitAudited(entry, transient, class)
+	if err != nil {
+		release()
+		common.Abort(c, status, err)
+		return nil, false
+	}
+	common.SetTxMeta(c, result.TxID, result.BlockNumber)
+
+	// Parse response
+	err = common.UnmarshalJSON(result.Result, &payload)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	return payload, true
+}
+
+// submissionEntry authorizes the submission of the transaction with the
+// request body as argument and describes it in an audit entry, along with
+// its transient data. On failure the request is aborted and ok is false
+func submissionEntry(c *gin.Context, channelName, chaincodeName, txName string, req map[string]interface{}) (entry audit.Entry, transient map[string][]byte, ok bool) {
+	if !authorize(c, txName) {
+		return entry, nil, false
+	}
+
 	// Get endorsers names
 	var endorsers []string
 	endorsersQuery := c.Query("@endorsers")
 	if endorsersQuery != "" {
 		endorsersByte, err := base64.StdEncoding.DecodeString(endorsersQuery)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "the @endorsers query parameter must be a base64-encoded JSON array of strings",
-			})
-			return
+			common.Abort(c, http.StatusBadRequest, errors.New("the @endorsers query parameter must be a base64-encoded JSON array of strings"))
+			return entry, nil, false
 		}
 
 		err = json.Unmarshal(endorsersByte, &endorsers)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "the @endorsers query parameter must be a base64-encoded JSON array of strings",
-			})
-			return
+			common.Abort(c, http.StatusBadRequest, errors.New("the @endorsers query parameter must be a base64-encoded JSON array of strings"))
+			return entry, nil, false
 		}
 	}
 
-	// Make transient request
-	transientMap := make(map[string]interface{})
-	for key, value := range req {
-		if key[0] == '~' {
-			keyTrimmed := strings.TrimPrefix(key, "~")
-			transientMap[keyTrimmed] = value
-			delete(req, key)
-		}
+	if !checkBody(c, txName, req) {
+		return entry, nil, false
 	}
 
-	transientBytes, _ := json.Marshal(transientMap)
-	if len(transientMap) == 0 {
-		transientMap = nil
+	// Make transient request
+	transient, err := extractTransient(req)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return entry, nil, false
 	}
 
 	// Make args
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		common.Abort(c, http.StatusInternalServerError, errors.Wrap(err, "failed to marshal req body"))
-		return
+		return entry, nil, false
+	}
+
+	user := common.GetUser(c)
+	if !checkQuota(c, user) {
+		return entry, nil, false
 	}
 
-	// Invoke
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
+	entry = audit.Entry{
+		Channel:      channelName,
+		Chaincode:    chaincodeName,
+		TxName:       txName,
+		User:         user,
+		Args:         []string{string(reqBytes)},
+		Endorsers:    endorsers,
+		HasTransient: hasTransient(transient),
 	}
+	return entry, transient, true
+}
 
-	result, err := chaincode.InvokeGateway(channelName, chaincodeName, txName, user, []string{string(reqBytes)}, transientBytes, endorsers)
+// checkSubmission checks the transaction described by the audit entry can
+// be submitted, returning the error and its status otherwise
+func checkSubmission(entry audit.Entry) (int, error) {
+	// Transactions are not submitted during maintenance
+	err := maintenance.Check()
 	if err != nil {
 		err, status := common.ParseError(err)
-		common.Abort(c, status, err)
-		return
+		return status, err
 	}
 
-	// Parse response
-	var payload interface{}
-	err = json.Unmarshal(result, &payload)
+	// Nor are secrets written to the ledger, if blocked
+	err = secretscan.Check(entry.TxName, entry.Args)
 	if err != nil {
-		common.Abort(c, http.StatusInternalServerError, err)
-		return
+		err, status := common.ParseError(err)
+		return status, err
 	}
 
-	common.Respond(c, payload, http.StatusOK, nil)
+	// Nor assets too large for the state database
+	err = sizeguard.Check(entry.User, entry.TxName, entry.Args)
+	if err != nil {
+		err, status := common.ParseError(err)
+		return status, err
+	}
+
+	// Nor personal data in plaintext
+	err = checkPersonalData(entry.Args)
+	if err != nil {
+		err, status := common.ParseError(err)
+		return status, err
+	}
+	return http.StatusOK, nil
+}
+
+// submitAudited submits the transaction described by the audit entry in the
+// priority class and records its outcome in the audit log
+func submitAudited(entry audit.Entry, transient map[string][]byte, class txqueue.Class) (*chaincode.SubmitResult, int, error) {
+	status, err := checkSubmission(entry)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := chaincode.SubmitGatewayClass(class, entry.Channel, entry.Chaincode, entry.TxName, entry.User, entry.Args, transient, entry.Endorsers)
+	if err != nil {
+		err, status := common.ParseError(err)
+		entry.Status = status
+		entry.Error = err.Error()
+		audit.Record(entry)
+		return nil, status, err
+	}
+
+	entry.TxID = result.TxID
+	entry.Status = http.StatusOK
+	audit.Record(entry)
+	return result, http.StatusOK, nil
 }
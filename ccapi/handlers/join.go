@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/filter"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultJoinLimit = 100
+	maxJoinLimit     = 1000
+)
+
+// joinSide is an asset type query of a join
+type joinSide struct {
+	AssetType string `json:"assetType" binding:"required"`
+	Filter    string `json:"filter"`
+}
+
+type joinRequest struct {
+	From joinSide `json:"from" binding:"required"`
+	Join struct {
+		joinSide
+		On    string `json:"on" binding:"required"`
+		As    string `json:"as"`
+		Inner bool   `json:"inner"`
+	} `json:"join" binding:"required"`
+	Limit    int    `json:"limit"`
+	Bookmark string `json:"bookmark"`
+}
+
+// JoinAssets joins a page of the assets of one type with the assets of
+// another type on a reference property, so clients get books with their
+// tenants in two queries instead of one read per book. The reference can be
+// a property of either type: books are joined with their currentTenant, and
+// people with the books whose currentTenant they are.
+func JoinAssets(c *gin.Context) {
+	var req joinRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	opts, ok := formatOptions(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, "search") {
+		return
+	}
+	if req.Join.As == "" {
+		req.Join.As = req.Join.On
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultJoinLimit
+	}
+	if req.Limit > maxJoinLimit {
+		req.Limit = maxJoinLimit
+	}
+
+	// The reference is on the left assets, or on the right ones pointing
+	// back to them
+	leftType, rightType := req.From.AssetType, req.Join.AssetType
+	reverse := false
+	prop, err := referenceProp(c, leftType, req.Join.On, rightType)
+	if err != nil {
+		reverse = true
+		prop, err = referenceProp(c, rightType, req.Join.On, leftType)
+	}
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	isArray := strings.HasPrefix(prop.DataType, "[]")
+
+	leftSelector, err := joinSelector(c, req.From, nil)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	page, err := searchQuery(c, map[string]interface{}{
+		"selector": leftSelector,
+		"limit":    req.Limit,
+		"bookmark": req.Bookmark,
+	})
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	left := make([]map[string]interface{}, 0, len(page.Result))
+	for _, item := range page.Result {
+		if asset, ok := item.(map[string]interface{}); ok {
+			left = append(left, asset)
+		}
+	}
+
+	// Keys of the right assets to read, or of the left assets they must
+	// reference
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, asset := range left {
+		var refs []string
+		if reverse {
+			key, _ := asset["@key"].(string)
+			refs = []string{key}
+		} else {
+			refs = referencedKeys(asset[req.Join.On])
+		}
+		for _, key := range refs {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	right := make([]interface{}, 0)
+	if len(keys) > 0 {
+		var cond map[string]interface{}
+		switch {
+		case !reverse:
+			cond = map[string]interface{}{"@key": map[string]interface{}{"$in": keys}}
+		case isArray:
+			cond = map[string]interface{}{req.Join.On: map[string]interface{}{
+				"$elemMatch": map[string]interface{}{"@key": map[string]interface{}{"$in": keys}},
+			}}
+		default:
+			cond = map[string]interface{}{req.Join.On + ".@key": map[string]interface{}{"$in": keys}}
+		}
+
+		rightSelector, err := joinSelector(c, req.Join.joinSide, cond)
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+		right, err = searchAll(c, rightSelector)
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+	}
+
+	// Index the right assets by the left keys they match
+	matches := make(map[string][]interface{})
+	for _, item := range right {
+		asset, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		decryptAsset(rightType, asset)
+		addLinks(c, rightType, asset)
+		formatAsset(c, opts, rightType, asset)
+
+		if reverse {
+			for _, key := range referencedKeys(asset[req.Join.On]) {
+				matches[key] = append(matches[key], asset)
+			}
+		} else if key, ok := asset["@key"].(string); ok {
+			matches[key] = append(matches[key], asset)
+		}
+	}
+
+	result := make([]interface{}, 0, len(left))
+	for _, asset := range left {
+		var joined interface{}
+		found := false
+		switch {
+		case reverse:
+			key, _ := asset["@key"].(string)
+			list := matches[key]
+			if list == nil {
+				list = make([]interface{}, 0)
+			}
+			joined, found = list, len(list) > 0
+		case isArray:
+			list := make([]interface{}, 0)
+			for _, key := range referencedKeys(asset[req.Join.On]) {
+				list = append(list, matches[key]...)
+			}
+			joined, found = list, len(list) > 0
+		default:
+			refs := referencedKeys(asset[req.Join.On])
+			if len(refs) > 0 && len(matches[refs[0]]) > 0 {
+				joined, found = matches[refs[0]][0], true
+			}
+		}
+		if req.Join.Inner && !found {
+			continue
+		}
+
+		decryptAsset(leftType, asset)
+		addLinks(c, leftType, asset)
+		formatAsset(c, opts, leftType, asset)
+		asset[req.Join.As] = joined
+		result = append(result, asset)
+	}
+
+	common.Respond(c, gin.H{
+		"result": result,
+		"metadata": gin.H{
+			"fetchedRecordsCount": len(page.Result),
+			"bookmark":            page.Metadata.Bookmark,
+		},
+	}, http.StatusOK, nil)
+}
+
+// referenceProp returns the property of the asset type referencing the
+// other type
+func referenceProp(c *gin.Context, assetType, tag, refType string) (*assetProp, error) {
+	schema, err := getAssetSchema(c, assetType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, prop := range schema.Props {
+		if prop.Tag == tag && strings.TrimPrefix(prop.DataType, "[]") == "->"+refType {
+			return &prop, nil
+		}
+	}
+	return nil, common.NewAPIError(http.StatusBadRequest, "'"+tag+"' is not a reference between '"+assetType+"' and '"+refType+"'")
+}
+
+// joinSelector returns the selector of one side of a join, with its filter
+// and an extra condition
+func joinSelector(c *gin.Context, side joinSide, cond map[string]interface{}) (map[string]interface{}, error) {
+	terms := []interface{}{
+		map[string]interface{}{"@assetType": side.AssetType},
+	}
+	if cond != nil {
+		terms = append(terms, cond)
+	}
+	if side.Filter != "" {
+		fields, err := filterFields(c, side.AssetType)
+		if err != nil {
+			return nil, err
+		}
+		filterSelector, err := filter.Parse(side.Filter, fields)
+		if err != nil {
+			return nil, common.NewAPIError(http.StatusBadRequest, errors.Wrapf(err, "invalid filter of '%s'", side.AssetType).Error())
+		}
+		terms = append(terms, filterSelector)
+	}
+
+	if len(terms) == 1 {
+		return terms[0].(map[string]interface{}), nil
+	}
+	return map[string]interface{}{"$and": terms}, nil
+}
+
+// referencedKeys returns the keys of a reference or a list of references
+func referencedKeys(value interface{}) []string {
+	keys := make([]string, 0)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if key, ok := v["@key"].(string); ok {
+			keys = append(keys, key)
+		}
+	case []interface{}:
+		for _, item := range v {
+			keys = append(keys, referencedKeys(item)...)
+		}
+	}
+	return keys
+}
+
+// searchQuery evaluates a page of the search transaction
+func searchQuery(c *gin.Context, query map[string]interface{}) (*searchPage, error) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": query,
+	})
+	result, err := chaincode.QueryGatewayFor(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", []string{string(args)})
+	if err != nil {
+		return nil, err
+	}
+
+	var page searchPage
+	err = common.UnmarshalJSON(result, &page)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal search result")
+	}
+	return &page, nil
+}
+
+// searchAll returns every asset matching the selector
+func searchAll(c *gin.Context, selector map[string]interface{}) ([]interface{}, error) {
+	assets := make([]interface{}, 0)
+	bookmark := ""
+	for {
+		page, err := searchQuery(c, map[string]interface{}{
+			"selector": selector,
+			"limit":    bulkPageSize,
+			"bookmark": bookmark,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		assets = append(assets, page.Result...)
+		if len(page.Result) < bulkPageSize || page.Metadata.Bookmark == "" || page.Metadata.Bookmark == bookmark {
+			return assets, nil
+		}
+		bookmark = page.Metadata.Bookmark
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/jsonld"
+)
+
+// JSONLDContext returns the JSON-LD context generated from the asset schema
+func JSONLDContext(c *gin.Context) {
+	context, err := jsonld.Context()
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	data, _ := json.Marshal(context)
+	c.Data(http.StatusOK, jsonld.MIMEJSONLD, data)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
+)
+
+// assetSchema is the definition of an asset type as returned by getSchema
+type assetSchema struct {
+	Tag   string      `json:"tag"`
+	Props []assetProp `json:"props"`
+}
+
+type assetProp struct {
+	Tag      string   `json:"tag"`
+	DataType string   `json:"dataType"`
+	IsKey    bool     `json:"isKey"`
+	Writers  []string `json:"writers"`
+}
+
+var (
+	schemaMutex sync.Mutex
+	schemas     = make(map[string]*assetSchema)
+)
+
+// getAssetSchema reads the definition of the asset type, which is kept
+// in memory after the first read
+func getAssetSchema(c *gin.Context, assetType string) (*assetSchema, error) {
+	return loadAssetSchema(assetType, common.GetUser(c))
+}
+
+// loadAssetSchema reads the definition of the asset type as the user, unless
+// it is already in memory
+func loadAssetSchema(assetType, user string) (*assetSchema, error) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+
+	if schema, ok := schemas[assetType]; ok {
+		return schema, nil
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"assetType": assetType,
+	})
+	res, err := chaincode.QueryGateway(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "getSchema", user, []string{string(args)})
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &assetSchema{}
+	err = json.Unmarshal(res, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas[assetType] = schema
+	return schema, nil
+}
+
+// callerMSP returns the MSP of the identity of the request
+func callerMSP(c *gin.Context) string {
+	_, org, err := common.SplitIdentity(common.GetUser(c))
+	if err == nil && org != nil {
+		return org.MSPID
+	}
+	if mock.Enabled() {
+		return mock.MSPID()
+	}
+	return common.GetMSPID()
+}
+
+// canWrite reports whether the MSP is allowed by the writers of a property,
+// which may be regular expressions prefixed by '$'
+func canWrite(writers []string, msp string) bool {
+	if len(writers) == 0 {
+		return true
+	}
+
+	for _, w := range writers {
+		if w == "" {
+			continue
+		}
+		if w[0] == '$' {
+			if ok, _ := regexp.MatchString(w[1:], msp); ok {
+				return true
+			}
+		} else if w == msp {
+			return true
+		}
+	}
+	return false
+}
+
+// assetsBase returns the path under which the asset resources are served
+func assetsBase(c *gin.Context, assetType string) string {
+	path := c.FullPath()
+	path = strings.TrimSuffix(path, "/history")
+	path = strings.TrimSuffix(path, "/diff")
+	path = strings.TrimSuffix(path, "/:key")
+	path = strings.TrimSuffix(path, "/read-many")
+	path = strings.TrimSuffix(path, "/join")
+	path = strings.TrimSuffix(path, "/near")
+	path = strings.TrimSuffix(path, "/resolve")
+	return strings.TrimSuffix(path, "/"+assetType)
+}
+
+// addLinks adds hypermedia links to the asset: its own resource, its history,
+// the assets it references and the update and delete actions the CCAPI
+// identity is allowed to execute
+func addLinks(c *gin.Context, assetType string, asset map[string]interface{}) {
+	key, ok := asset["@key"].(string)
+	if !ok {
+		return
+	}
+
+	base := assetsBase(c, assetType)
+	self := base + "/" + assetType + "/" + key
+	links := gin.H{
+		"self":    gin.H{"href": self},
+		"history": gin.H{"href": self + "/history"},
+	}
+
+	schema, err := getAssetSchema(c, assetType)
+	if err != nil {
+		asset["_links"] = links
+		return
+	}
+
+	msp := callerMSP(c)
+	canUpdate, canDelete := false, true
+	references := make([]gin.H, 0)
+	for _, prop := range schema.Props {
+		value, present := asset[prop.Tag]
+		allowed := canWrite(prop.Writers, msp)
+		if !prop.IsKey && allowed {
+			canUpdate = true
+		}
+		if present && !allowed {
+			canDelete = false
+		}
+
+		if !present || !strings.HasPrefix(strings.TrimPrefix(prop.DataType, "[]"), "->") {
+			continue
+		}
+		for _, ref := range referenceKeys(value) {
+			references = append(references, gin.H{
+				"name": prop.Tag,
+				"href": base + "/" + strings.SplitN(ref, ":", 2)[0] + "/" + ref,
+			})
+		}
+	}
+
+	if len(references) > 0 {
+		links["references"] = references
+	}
+	if canUpdate {
+		links["update"] = gin.H{"href": self, "method": http.MethodPut}
+	}
+	if canDelete {
+		links["delete"] = gin.H{"href": self, "method": http.MethodDelete}
+	}
+	asset["_links"] = links
+}
+
+// referenceKeys returns the keys of a reference or list of references
+func referenceKeys(value interface{}) []string {
+	keys := make([]string, 0)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if key, ok := v["@key"].(string); ok {
+			keys = append(keys, key)
+		}
+	case []interface{}:
+		for _, item := range v {
+			keys = append(keys, referenceKeys(item)...)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/scheduler"
+	"github.com/hyperledger-labs/ccapi/webhook"
+	"github.com/pkg/errors"
+)
+
+// Webhook events of the book loans
+const (
+	EventLoanBorrowed = "loan.borrowed"
+	EventLoanReturned = "loan.returned"
+	EventLoanOverdue  = "loan.overdue"
+)
+
+// ListLoans searches loans by the status and borrower query parameters
+func ListLoans(c *gin.Context) {
+	selector := map[string]interface{}{
+		"@assetType": "loan",
+	}
+	if status := c.Query("status"); status != "" {
+		selector["status"] = status
+	}
+	if borrower := c.Query("borrower"); borrower != "" {
+		selector["borrower.@key"] = borrower
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"selector": selector,
+		},
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", args)
+}
+
+// BorrowBook lends a book to a person until the due date. The loan period
+// may be given in days instead of the dueDate. A webhook notice is scheduled
+// for when the loan becomes overdue.
+func BorrowBook(c *gin.Context) {
+	req := make(map[string]interface{})
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	err = setDueDate(req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, ok := submit(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "borrowBook", req)
+	if !ok {
+		return
+	}
+	scheduleOverdueNotice(payload)
+	webhook.Notify(EventLoanBorrowed, payload)
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// setDueDate replaces the loan period in days of the request, if any, by
+// its due date. Numbers of requests are json.Number unless JSON_NUMBERS is
+// float.
+func setDueDate(req map[string]interface{}) error {
+	days, ok := req["days"]
+	if !ok {
+		return nil
+	}
+
+	var n float64
+	switch d := days.(type) {
+	case json.Number:
+		n, _ = strconv.ParseFloat(d.String(), 64)
+	case float64:
+		n = d
+	}
+	if n < 1 || n != float64(int(n)) {
+		return errors.New("days must be a positive integer")
+	}
+	delete(req, "days")
+	req["dueDate"] = time.Now().AddDate(0, 0, int(n)).UTC().Format(time.RFC3339)
+	return nil
+}
+
+// ReadLoan reads the loan with the key in the path
+func ReadLoan(c *gin.Context) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"key": assetKey(c, "loan"),
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+}
+
+// ReturnLoan returns the book of the loan with the key in the path,
+// cancelling its overdue notice. If the book was reserved, the loan holds
+// the reservation fulfilled by the return.
+func ReturnLoan(c *gin.Context) {
+	req := map[string]interface{}{
+		"loan": assetKey(c, "loan"),
+	}
+
+	payload, ok := submit(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "returnBook", req)
+	if !ok {
+		return
+	}
+	if loan, ok := payload.(map[string]interface{}); ok {
+		key, _ := loan["@key"].(string)
+		scheduler.Cancel(loanJobID(key))
+	}
+	webhook.Notify(EventLoanReturned, payload)
+	notifyHold(payload)
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// ListOverdueLoans lists the active loans past their due date, optionally
+// of the borrower in the query
+func ListOverdueLoans(c *gin.Context) {
+	req := make(map[string]interface{})
+	if borrower := c.Query("borrower"); borrower != "" {
+		req["borrower"] = map[string]interface{}{
+			"@assetType": "person",
+			"@key":       borrower,
+		}
+	}
+	if bookmark := c.Query("bookmark"); bookmark != "" {
+		req["bookmark"] = bookmark
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, errors.New("the limit query parameter must be an integer"))
+			return
+		}
+		req["limit"] = n
+	}
+
+	args, _ := json.Marshal(req)
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "listOverdueLoans", args)
+}
+
+// ScheduleOverdueNotices schedules the overdue notices of the active loans,
+// which is needed after the CCAPI restarts as scheduled jobs are kept in
+// memory
+func ScheduleOverdueNotices() {
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"@assetType": "loan",
+				"status":     "active",
+			},
+		},
+	})
+
+	result, err := chaincode.QueryGateway(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", os.Getenv("USER"), []string{string(args)})
+	if err != nil {
+		log.Println("error searching active loans: ", err)
+		return
+	}
+
+	var response struct {
+		Result []interface{} `json:"result"`
+	}
+	err = json.Unmarshal(result, &response)
+	if err != nil {
+		log.Println("error unmarshalling active loans: ", err)
+		return
+	}
+
+	for _, loan := range response.Result {
+		scheduleOverdueNotice(loan)
+	}
+}
+
+func loanJobID(key string) string {
+	return "overdueLoan:" + key
+}
+
+// scheduleOverdueNotice notifies the loan.overdue webhook event when the
+// loan reaches its due date, unless the book is returned before. Loans
+// already overdue are notified right away.
+func scheduleOverdueNotice(loan interface{}) {
+	loanMap, _ := loan.(map[string]interface{})
+	key, _ := loanMap["@key"].(string)
+	dueDateStr, _ := loanMap["dueDate"].(string)
+	dueDate, err := time.Parse(time.RFC3339, dueDateStr)
+	if key == "" || err != nil {
+		log.Println("cannot schedule overdue notice of loan without key and due date")
+		return
+	}
+
+	scheduler.Schedule(loanJobID(key), dueDate, func() error {
+		webhook.Notify(EventLoanOverdue, loanMap)
+		return nil
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestBorrowBookDaysExactNumbers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JSON_NUMBERS", "exact")
+	binding.EnableDecoderUseNumber = true
+	defer func() { binding.EnableDecoderUseNumber = false }()
+
+	// Periods that are not a positive integer are refused
+	for _, days := range []string{"0", "-3", "1.5", `"14"`} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/loans", strings.NewReader(`{"days": `+days+`}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		BorrowBook(c)
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "days must be a positive integer") {
+			t.Fatalf("days %s: expected 400, got %d %s", days, w.Code, w.Body.String())
+		}
+	}
+
+	// Whole periods, bound as json.Number, become the due date
+	for _, days := range []string{"14", "14.0"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/loans", strings.NewReader(`{"days": `+days+`}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		req := make(map[string]interface{})
+		err := c.BindJSON(&req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := req["days"].(json.Number); !ok {
+			t.Fatalf("days %s: expected json.Number, got %T", days, req["days"])
+		}
+
+		err = setDueDate(req)
+		if err != nil {
+			t.Fatalf("days %s: %s", days, err)
+		}
+		if _, ok := req["days"]; ok {
+			t.Fatalf("days %s: days sent to the chaincode", days)
+		}
+		dueDate, err := time.Parse(time.RFC3339, req["dueDate"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Until(dueDate); d < 13*24*time.Hour || d > 14*24*time.Hour {
+			t.Fatalf("days %s: unexpected due date %s", days, dueDate)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/maintenance"
+)
+
+// checkWritable checks that the API is not in read-only mode. Otherwise the
+// request is aborted and false is returned.
+func checkWritable(c *gin.Context) bool {
+	err := maintenance.Check()
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return false
+	}
+	return true
+}
+
+// MaintenanceStatus returns whether the API is in read-only mode, with the
+// reason and the expected end of the maintenance
+func MaintenanceStatus(c *gin.Context) {
+	common.Respond(c, maintenance.Get(), http.StatusOK, nil)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// ListOrgs returns the organization profiles that can be selected with the
+// Org header
+func ListOrgs(c *gin.Context) {
+	profiles, err := common.GetOrgProfiles()
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	orgs := make([]gin.H, 0, len(profiles))
+	for _, name := range common.OrgNames() {
+		org := gin.H{
+			"name":     name,
+			"mspId":    profiles[name].MSPID,
+			"endpoint": profiles[name].Endpoint,
+		}
+		if profiles[name].QueryEndpoint != "" {
+			org["queryEndpoint"] = profiles[name].QueryEndpoint
+		}
+		orgs = append(orgs, org)
+	}
+
+	common.Respond(c, orgs, http.StatusOK, nil)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/jsonpatch"
+	"github.com/pkg/errors"
+)
+
+// jsonPatchContentType is the media type of JSON Patch (RFC 6902) documents
+const jsonPatchContentType = "application/json-patch+json"
+
+// patchAsset reads the asset of the type with the key in the path, applies
+// the JSON Patch in the request body and submits the result as an update
+func patchAsset(c *gin.Context, assetType string) {
+	var ops []jsonpatch.Operation
+	err := common.DecodeJSON(c.Request.Body, &ops)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid JSON Patch document"))
+		return
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"key": assetKey(c, assetType),
+	})
+	payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+	if !ok {
+		return
+	}
+	current, _ := payload.(map[string]interface{})
+
+	res, err := jsonpatch.Apply(current, ops)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Cause(err) == jsonpatch.ErrTestFailed {
+			status = http.StatusConflict
+		}
+		common.Abort(c, status, err)
+		return
+	}
+
+	patched, ok := res.(map[string]interface{})
+	if !ok {
+		common.Abort(c, http.StatusBadRequest, errors.New("patched asset must be an object"))
+		return
+	}
+
+	// Keys identify the asset and cannot be patched
+	keyProps := []string{"@key", "@assetType"}
+	if schema, err := getAssetSchema(c, assetType); err == nil {
+		for _, prop := range schema.Props {
+			if prop.IsKey {
+				keyProps = append(keyProps, prop.Tag)
+			}
+		}
+	}
+	for _, prop := range keyProps {
+		if !reflect.DeepEqual(current[prop], patched[prop]) {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("key property '%s' cannot be patched", prop))
+			return
+		}
+	}
+
+	// updateAsset ignores null values, so properties cannot be removed
+	for k := range current {
+		if _, ok := patched[k]; !ok || patched[k] == nil {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("property '%s' cannot be removed", k))
+			return
+		}
+	}
+
+	update := make(map[string]interface{})
+	for k, v := range patched {
+		update[k] = v
+	}
+	delete(update, "@lastTouchBy")
+	delete(update, "@lastTx")
+	delete(update, "@lastUpdated")
+
+	err = encryptAsset(assetType, update, current)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	req := map[string]interface{}{
+		"update": update,
+	}
+	submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "updateAsset", req)
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/payload"
+)
+
+// ProtoSchema returns the protobuf definitions of the asset types, used to
+// encode the bodies of the asset resources as application/x-protobuf
+func ProtoSchema(c *gin.Context) {
+	source, err := payload.ProtoFile()
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(source))
+}
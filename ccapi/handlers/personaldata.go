@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/keyring"
+	"github.com/pkg/errors"
+)
+
+// encryptedFields returns the properties of the asset type encrypted with
+// the keyring before reaching the ledger, set by ENCRYPTED_FIELDS as a
+// comma separated list of type.property. None are encrypted by default.
+func encryptedFields(assetType string) []string {
+	fields := make([]string, 0)
+	for _, field := range strings.Split(os.Getenv("ENCRYPTED_FIELDS"), ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), ".", 2)
+		if len(parts) == 2 && parts[0] == assetType {
+			fields = append(fields, parts[1])
+		}
+	}
+	return fields
+}
+
+// HasEncryptedFields reports whether the asset type has personal data
+// encrypted with the keyring
+func HasEncryptedFields(assetType string) bool {
+	return len(encryptedFields(assetType)) > 0
+}
+
+// encryptAsset encrypts the personal data of the asset being written, with
+// the key of the data subject of the current version of the asset or of a
+// new subject
+func encryptAsset(assetType string, asset, current map[string]interface{}) error {
+	fields := encryptedFields(assetType)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	subject := ""
+	for _, field := range fields {
+		value, _ := current[field].(string)
+		if s, ok := keyring.Subject(value); ok {
+			subject = s
+			break
+		}
+	}
+	if subject == "" {
+		subject = keyring.NewSubject()
+	}
+
+	for _, field := range fields {
+		value, ok := asset[field].(string)
+		if !ok || keyring.IsEncrypted(value) {
+			continue
+		}
+
+		encrypted, err := keyring.Encrypt(subject, assetType+"."+field, value)
+		if errors.Cause(err) == keyring.ErrShredded {
+			return common.NewAPIError(http.StatusConflict, "the personal data of the asset was shredded")
+		}
+		if err != nil {
+			return err
+		}
+		asset[field] = encrypted
+	}
+	return nil
+}
+
+// checkPersonalData refuses the arguments of a transaction writing, in
+// plaintext, properties that must be encrypted. Only the asset routes
+// encrypt them, so other routes, such as bulk updates, the generic
+// transaction routes and scripts, would put them on the ledger for good.
+func checkPersonalData(args []string) error {
+	if os.Getenv("ENCRYPTED_FIELDS") == "" {
+		return nil
+	}
+	for _, arg := range args {
+		var value interface{}
+		if json.Unmarshal([]byte(arg), &value) != nil {
+			continue
+		}
+		err := findPlaintext(value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findPlaintext walks the value for assets, identified by their @assetType
+// or the type in their @key, with personal data not encrypted
+func findPlaintext(value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		assetType, _ := v["@assetType"].(string)
+		if key, ok := v["@key"].(string); ok && assetType == "" {
+			assetType = strings.SplitN(key, ":", 2)[0]
+		}
+		for _, field := range encryptedFields(assetType) {
+			if plaintext, ok := v[field].(string); ok && !keyring.IsEncrypted(plaintext) {
+				return common.NewAPIError(http.StatusBadRequest, fmt.Sprintf("%s.%s is personal data, which is only written through the asset routes, as /api/assets/%s", assetType, field, assetType))
+			}
+		}
+		for _, item := range v {
+			if err := findPlaintext(item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := findPlaintext(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decryptAsset decrypts the personal data of an asset read from the ledger.
+// Shredded properties are set to null and listed in @shredded.
+func decryptAsset(assetType string, asset map[string]interface{}) {
+	shredded := make([]string, 0)
+	for _, field := range encryptedFields(assetType) {
+		value, ok := asset[field].(string)
+		if !ok {
+			continue
+		}
+
+		plaintext, err := keyring.Decrypt(assetType+"."+field, value)
+		if errors.Cause(err) == keyring.ErrShredded {
+			asset[field] = nil
+			shredded = append(shredded, field)
+			continue
+		}
+		if err != nil {
+			log.Println("error decrypting personal data: ", err)
+			continue
+		}
+		asset[field] = plaintext
+	}
+
+	if len(shredded) > 0 {
+		asset["@shredded"] = shredded
+	}
+}
+
+// decryptAssets decrypts the assets in a list
+func decryptAssets(assetType string, list []interface{}) {
+	for _, item := range list {
+		if asset, ok := item.(map[string]interface{}); ok {
+			decryptAsset(assetType, asset)
+		}
+	}
+}
+
+// ShredPersonalData deletes the keys of the data subjects of the asset of
+// the type with the key in the path, leaving its personal data on the
+// ledger unreadable. As this cannot be undone, the request must confirm it
+// with ?confirm={@key}. Each deleted key is recorded in the audit log.
+func ShredPersonalData(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := assetKey(c, assetType)
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": key,
+		})
+		payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+		if !ok {
+			return
+		}
+		current, _ := payload.(map[string]interface{})
+
+		subjects := make([]string, 0)
+		seen := make(map[string]bool)
+		for _, field := range encryptedFields(assetType) {
+			value, _ := current[field].(string)
+			subject, ok := keyring.Subject(value)
+			if ok && !seen[subject] {
+				subjects = append(subjects, subject)
+				seen[subject] = true
+			}
+		}
+		if len(subjects) == 0 {
+			common.Abort(c, http.StatusNotFound, errors.New("the asset has no encrypted personal data"))
+			return
+		}
+
+		if c.Query("confirm") != key["@key"] {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("shredding cannot be undone, confirm it with ?confirm=%s", key["@key"]))
+			return
+		}
+
+		shreddedAt := make(map[string]time.Time)
+		for _, subject := range subjects {
+			deletedAt, err := keyring.Shred(subject)
+
+			entry := audit.Entry{
+				Channel:   os.Getenv("CHANNEL"),
+				Chaincode: os.Getenv("CCNAME"),
+				TxName:    "shredPersonalData",
+				User:      common.GetUser(c),
+				Args:      []string{key["@key"].(string), subject},
+				Status:    http.StatusOK,
+			}
+			if err != nil {
+				entry.Status = http.StatusInternalServerError
+				entry.Error = err.Error()
+			}
+			audit.Record(entry)
+
+			if err != nil {
+				common.Abort(c, http.StatusInternalServerError, err)
+				return
+			}
+			shreddedAt[subject] = deletedAt
+		}
+
+		common.Respond(c, gin.H{
+			"@key":       key["@key"],
+			"shreddedAt": shreddedAt,
+		}, http.StatusOK, nil)
+	}
+}
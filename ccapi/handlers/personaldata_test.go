@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestCheckPersonalData(t *testing.T) {
+	t.Setenv("ENCRYPTED_FIELDS", "person.name")
+
+	// Plaintext personal data is refused, whether the asset is named by its
+	// type or its key, and wherever it is in the arguments
+	for _, args := range []string{
+		`{"asset": [{"@assetType": "person", "id": "31820792048", "name": "Maria"}]}`,
+		`{"update": {"@key": "person:47061146-c642-51a1-844a-bf0b17cb5e19", "name": "Maria"}}`,
+		`{"assets": {"list": [{"@assetType": "person", "name": "Maria"}]}}`,
+	} {
+		if checkPersonalData([]string{args}) == nil {
+			t.Fatalf("expected %s to be refused", args)
+		}
+	}
+
+	// Ciphertexts, references and other asset types are written
+	for _, args := range []string{
+		`{"asset": [{"@assetType": "person", "id": "31820792048", "name": "enc:v1:subject:AAAA"}]}`,
+		`{"asset": [{"@assetType": "loan", "borrower": {"@key": "person:47061146-c642-51a1-844a-bf0b17cb5e19"}}]}`,
+		`{"asset": [{"@assetType": "book", "title": "Meu Nome é Maria", "name": "Maria"}]}`,
+	} {
+		if err := checkPersonalData([]string{args}); err != nil {
+			t.Fatalf("expected %s to be written: %s", args, err)
+		}
+	}
+}
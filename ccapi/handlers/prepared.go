@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/maintenance"
+	"github.com/pkg/errors"
+)
+
+// preparedEntry is the audit entry of a prepared transaction, recorded when
+// it is submitted
+type preparedEntry struct {
+	entry     audit.Entry
+	expiresAt time.Time
+}
+
+var (
+	// preparedEntriesMutex guards the entries of the prepared transactions,
+	// by transaction id
+	preparedEntriesMutex sync.Mutex
+	preparedEntries      = make(map[string]preparedEntry)
+)
+
+// PrepareGatewayDefault prepares a transaction of the chaincode of the CCAPI
+func PrepareGatewayDefault(c *gin.Context) {
+	prepareGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"))
+}
+
+// PrepareGatewayCustom prepares a transaction of the chaincode in the path
+func PrepareGatewayCustom(c *gin.Context) {
+	prepareGateway(c, c.Param("channelName"), c.Param("chaincodeName"))
+}
+
+// prepareGateway builds the proposal of the transaction with the request body
+// as argument, as the invoke routes do, and returns its transaction id
+// without submitting it. The client can keep the id before submitting the
+// transaction with SubmitPrepared, and submit it again safely if it does not
+// know whether a submission went through.
+func prepareGateway(c *gin.Context, channelName, chaincodeName string) {
+	req := make(map[string]interface{})
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	txName := c.Param("txname")
+	entry, transient, ok := submissionEntry(c, channelName, chaincodeName, txName, req)
+	if !ok {
+		return
+	}
+
+	status, err := checkSubmission(entry)
+	if err != nil {
+		common.Abort(c, status, err)
+		return
+	}
+
+	txID, expiresAt, err := chaincode.PrepareGateway(channelName, chaincodeName, txName, entry.User, entry.Args, transient, entry.Endorsers)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	now := time.Now()
+	preparedEntriesMutex.Lock()
+	for id, prepared := range preparedEntries {
+		if now.After(prepared.expiresAt) {
+			delete(preparedEntries, id)
+		}
+	}
+	preparedEntries[txID] = preparedEntry{entry: entry, expiresAt: expiresAt}
+	preparedEntriesMutex.Unlock()
+
+	common.Respond(c, gin.H{
+		"txId":      txID,
+		"txName":    txName,
+		"expiresAt": expiresAt.UTC(),
+	}, http.StatusCreated, nil)
+}
+
+// SubmitPrepared submits the prepared transaction with the txid in the path
+// and returns its result. Submitting the same transaction again does not
+// fail with a duplicate transaction error: it returns the outcome of the
+// first submission, with the Duplicate-Submission header set to true.
+func SubmitPrepared(c *gin.Context) {
+	txID := c.Param("txid")
+	user := common.GetUser(c)
+
+	preparedEntriesMutex.Lock()
+	prepared, known := preparedEntries[txID]
+	preparedEntriesMutex.Unlock()
+
+	if known {
+		if prepared.entry.User != user {
+			common.Abort(c, http.StatusForbidden, errors.New("the transaction was prepared by another user"))
+			return
+		}
+
+		// Transactions are not submitted during maintenance
+		if err := maintenance.Check(); err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+	}
+
+	release := func() {}
+	if known {
+		var ok bool
+		release, ok = reserveQuota(c, prepared.entry.User)
+		if !ok {
+			return
+		}
+	}
+	result, duplicate, err := chaincode.SubmitPrepared(txID, user)
+	if err != nil || duplicate {
+		release()
+	}
+	if known && !duplicate {
+		preparedEntriesMutex.Lock()
+		delete(preparedEntries, txID)
+		preparedEntriesMutex.Unlock()
+	}
+	if err != nil {
+		err, status := common.ParseError(err)
+		if known && !duplicate {
+			prepared.entry.TxID = txID
+			prepared.entry.Status = status
+			prepared.entry.Error = err.Error()
+			audit.Record(prepared.entry)
+		}
+		if duplicate {
+			c.Header("Duplicate-Submission", "true")
+		}
+		common.Abort(c, status, err)
+		return
+	}
+
+	if known && !duplicate {
+		prepared.entry.TxID = result.TxID
+		prepared.entry.Status = http.StatusOK
+		audit.Record(prepared.entry)
+	}
+	if duplicate {
+		c.Header("Duplicate-Submission", "true")
+	}
+	common.SetTxMeta(c, result.TxID, result.BlockNumber)
+
+	var payload interface{}
+	if len(result.Result) > 0 {
+		err = common.UnmarshalJSON(result.Result, &payload)
+		if err != nil {
+			common.Abort(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	common.Respond(c, payload, http.StatusOK, nil)
+}
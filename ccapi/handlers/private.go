@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// ReadPrivateAsset reads the private asset of the type with the key in the
+// path. Organizations that are not readers of its collection only get its
+// hash, in the @hash field.
+func ReadPrivateAsset(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": assetKey(c, assetType),
+		})
+		evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readPrivateAsset", args)
+	}
+}
+
+// VerifyPrivateAsset checks the plaintext in the request body against the
+// hash stored on the channel ledger for the private asset of the type with
+// the key in the path. A string plaintext is hashed as is, other values are
+// hashed as the JSON the chaincode stores, with sorted keys.
+func VerifyPrivateAsset(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Plaintext interface{} `json:"plaintext"`
+		}
+		err := c.ShouldBindJSON(&body)
+		if err != nil || body.Plaintext == nil {
+			common.Abort(c, http.StatusBadRequest, errors.New("the request body must have the plaintext to verify"))
+			return
+		}
+
+		plaintext, ok := body.Plaintext.(string)
+		if !ok {
+			plaintextBytes, err := json.Marshal(body.Plaintext)
+			if err != nil {
+				common.Abort(c, http.StatusBadRequest, err)
+				return
+			}
+			plaintext = string(plaintextBytes)
+		}
+
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": assetKey(c, assetType),
+		})
+		payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readPrivateAsset", args)
+		if !ok {
+			return
+		}
+		asset, _ := payload.(map[string]interface{})
+		expected, _ := asset["@hash"].(string)
+
+		hash := sha256.Sum256([]byte(plaintext))
+		computed := hex.EncodeToString(hash[:])
+
+		common.Respond(c, gin.H{
+			"@key":     asset["@key"],
+			"match":    computed == expected,
+			"hash":     computed,
+			"expected": expected,
+		}, http.StatusOK, nil)
+	}
+}
+
+// collectionConfig is an entry of the private data collections config
+type collectionConfig struct {
+	Name        string `json:"name"`
+	BlockToLive int    `json:"blockToLive"`
+}
+
+// getCollectionConfig reads the config of the collection from the file set
+// by COLLECTIONS_CONFIG, default the one generated for the chaincode. It is
+// nil when the file or the collection is not found.
+func getCollectionConfig(name string) *collectionConfig {
+	path := os.Getenv("COLLECTIONS_CONFIG")
+	if path == "" {
+		path = "../chaincode/collections.json"
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var collections []collectionConfig
+	if json.Unmarshal(data, &collections) != nil {
+		return nil
+	}
+
+	for _, collection := range collections {
+		if collection.Name == name {
+			return &collection
+		}
+	}
+	return nil
+}
+
+// PurgePreview reports what purging the private asset of the type with the
+// key in the path would do: the assets referencing it, whether the peers
+// can purge its history and the blockToLive of its collection, with
+// warnings for the caller to review before purging.
+func PurgePreview(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		args, _ := json.Marshal(map[string]interface{}{
+			"key":    assetKey(c, assetType),
+			"dryRun": true,
+		})
+		payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "purgePrivateAsset", args)
+		if !ok {
+			return
+		}
+		preview, _ := payload.(map[string]interface{})
+
+		warnings := make([]string, 0)
+		if referrers, _ := preview["referrers"].([]interface{}); len(referrers) > 0 {
+			keys := make([]string, 0, len(referrers))
+			for _, referrer := range referrers {
+				keys = append(keys, fmt.Sprint(referrer))
+			}
+			warnings = append(warnings, fmt.Sprintf("the asset is referenced by %s, purging requires force and leaves their references dangling", strings.Join(keys, ", ")))
+		}
+
+		// Collections are named after their asset type
+		collection := getCollectionConfig(assetType)
+		if collection != nil {
+			preview["blockToLive"] = collection.BlockToLive
+		}
+		if supported, _ := preview["purgeSupported"].(bool); !supported {
+			switch {
+			case collection == nil:
+				warnings = append(warnings, "the peers cannot purge the history of the asset, which is kept until the blockToLive of its collection")
+			case collection.BlockToLive == 0:
+				warnings = append(warnings, "the peers cannot purge the history of the asset and its collection keeps private data forever (blockToLive 0)")
+			default:
+				warnings = append(warnings, fmt.Sprintf("the peers cannot purge the history of the asset, which is kept until %d blocks after it was written", collection.BlockToLive))
+			}
+		}
+		preview["warnings"] = warnings
+
+		common.Respond(c, preview, http.StatusOK, nil)
+	}
+}
+
+// PurgePrivateAsset deletes the private asset of the type with the key in
+// the path and purges its history where the peers support it. Referenced
+// assets are only purged with ?force=true.
+func PurgePrivateAsset(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := map[string]interface{}{
+			"key":   assetKey(c, assetType),
+			"force": c.Query("force") == "true",
+		}
+		submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "purgePrivateAsset", req)
+	}
+}
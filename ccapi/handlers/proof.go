@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	protos "github.com/hyperledger/fabric-protos-go-apiv2/common"
+	mspprotos "github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	peerprotos "github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxProofDepth limits the following blocks linked by an inclusion proof
+const maxProofDepth = 100
+
+// InclusionProof returns the proof that the transaction with the txid in the
+// path is included in its block: the envelopes whose hash is the data hash of
+// the block header, the header hash and the orderer signatures over the
+// header. The depth query parameter adds the headers of the following
+// blocks, each linked to the previous one by its hash.
+func InclusionProof(c *gin.Context) {
+	channelName := c.Param("channelName")
+	txID := c.Param("txid")
+
+	depth := 0
+	if depthStr := c.Query("depth"); depthStr != "" {
+		var err error
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 0 || depth > maxProofDepth {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("the depth query parameter must be an integer between 0 and %d", maxProofDepth))
+			return
+		}
+	}
+
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByTxID", []string{channelName, txID})
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	var block protos.Block
+	err = proto.Unmarshal(result, &block)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, errors.Wrap(err, "failed to unmarshal block"))
+		return
+	}
+
+	proof, err := blockInclusionProof(&block, txID)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	proof["channel"] = channelName
+
+	// Link the block to the following ones
+	chain := make([]gin.H, 0)
+	previousHash := proof["block"].(gin.H)["hash"]
+	for number := block.Header.Number + 1; number <= block.Header.Number+uint64(depth); number++ {
+		result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByNumber", []string{channelName, strconv.FormatUint(number, 10)})
+		if err != nil {
+			// The end of the chain
+			break
+		}
+
+		var next protos.Block
+		err = proto.Unmarshal(result, &next)
+		if err != nil {
+			common.Abort(c, http.StatusInternalServerError, errors.Wrap(err, "failed to unmarshal block"))
+			return
+		}
+
+		header := blockHeaderProof(next.Header)
+		if header["previousHash"] != previousHash {
+			common.Abort(c, http.StatusInternalServerError, errors.Errorf("block %d is not linked to the previous block", number))
+			return
+		}
+		chain = append(chain, header)
+		previousHash = header["hash"]
+	}
+	proof["chain"] = chain
+
+	common.Respond(c, proof, http.StatusOK, nil)
+}
+
+// blockInclusionProof builds the inclusion proof of the transaction in the
+// block, checking the data hash of the block header
+func blockInclusionProof(block *protos.Block, txID string) (gin.H, error) {
+	if block.Header == nil || block.Data == nil {
+		return nil, errors.New("block has no header or data")
+	}
+
+	index := -1
+	envelopes := make([][]byte, len(block.Data.Data))
+	for i, data := range block.Data.Data {
+		envelopes[i] = data
+
+		var envelope protos.Envelope
+		err := proto.Unmarshal(data, &envelope)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal envelope")
+		}
+		var payload protos.Payload
+		err = proto.Unmarshal(envelope.Payload, &payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal payload")
+		}
+		if payload.Header == nil {
+			continue
+		}
+		var channelHeader protos.ChannelHeader
+		err = proto.Unmarshal(payload.Header.ChannelHeader, &channelHeader)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal channel header")
+		}
+		if channelHeader.TxId == txID {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, errors.Errorf("transaction %s not found in block %d", txID, block.Header.Number)
+	}
+
+	// The data hash is the hash of the concatenated envelopes
+	dataHash := sha256.Sum256(bytes.Join(block.Data.Data, nil))
+	if !bytes.Equal(dataHash[:], block.Header.DataHash) {
+		return nil, errors.Errorf("data hash of block %d does not match its envelopes", block.Header.Number)
+	}
+	envelopeHash := sha256.Sum256(block.Data.Data[index])
+
+	validationCode := peerprotos.TxValidationCode_NOT_VALIDATED
+	metadata := block.GetMetadata().GetMetadata()
+	if int(protos.BlockMetadataIndex_TRANSACTIONS_FILTER) < len(metadata) {
+		filter := metadata[protos.BlockMetadataIndex_TRANSACTIONS_FILTER]
+		if index < len(filter) {
+			validationCode = peerprotos.TxValidationCode(filter[index])
+		}
+	}
+
+	signatures, err := blockSignatures(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"txId": txID,
+		"transaction": gin.H{
+			"index":          index,
+			"envelopeHash":   hex.EncodeToString(envelopeHash[:]),
+			"validationCode": validationCode.String(),
+		},
+		"block":      blockHeaderProof(block.Header),
+		"envelopes":  envelopes,
+		"signatures": signatures,
+	}, nil
+}
+
+// blockHeaderProof returns the header fields with the ASN.1 encoding of the
+// header, whose hash is the block hash
+func blockHeaderProof(header *protos.BlockHeader) gin.H {
+	headerBytes := blockHeaderBytes(header)
+	hash := sha256.Sum256(headerBytes)
+
+	return gin.H{
+		"number":       header.Number,
+		"previousHash": hex.EncodeToString(header.PreviousHash),
+		"dataHash":     hex.EncodeToString(header.DataHash),
+		"headerBytes":  headerBytes,
+		"hash":         hex.EncodeToString(hash[:]),
+	}
+}
+
+// blockHeaderBytes encodes the header as the orderers do before hashing and
+// signing it
+func blockHeaderBytes(header *protos.BlockHeader) []byte {
+	asn1Header := struct {
+		Number       *big.Int
+		PreviousHash []byte
+		DataHash     []byte
+	}{
+		Number:       new(big.Int).SetUint64(header.Number),
+		PreviousHash: header.PreviousHash,
+		DataHash:     header.DataHash,
+	}
+
+	// Marshalling this struct does not fail
+	headerBytes, _ := asn1.Marshal(asn1Header)
+	return headerBytes
+}
+
+// blockSignatures returns the orderer signatures of the block, each over
+// the concatenation of the metadata value, the signature header and the
+// header bytes
+func blockSignatures(block *protos.Block) ([]gin.H, error) {
+	signatures := make([]gin.H, 0)
+
+	metadata := block.GetMetadata().GetMetadata()
+	if int(protos.BlockMetadataIndex_SIGNATURES) >= len(metadata) {
+		return signatures, nil
+	}
+
+	var md protos.Metadata
+	err := proto.Unmarshal(metadata[protos.BlockMetadataIndex_SIGNATURES], &md)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal block signatures")
+	}
+
+	headerBytes := blockHeaderBytes(block.Header)
+	for _, sig := range md.Signatures {
+		signature := gin.H{
+			"signatureHeader": sig.SignatureHeader,
+			"signature":       sig.Signature,
+			"signedBytes":     bytes.Join([][]byte{md.Value, sig.SignatureHeader, headerBytes}, nil),
+		}
+
+		var sigHeader protos.SignatureHeader
+		err := proto.Unmarshal(sig.SignatureHeader, &sigHeader)
+		if err == nil {
+			var creator mspprotos.SerializedIdentity
+			err = proto.Unmarshal(sigHeader.Creator, &creator)
+			if err == nil {
+				signature["mspId"] = creator.Mspid
+				signature["certificate"] = string(creator.IdBytes)
+			}
+		}
+
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
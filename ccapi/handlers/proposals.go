@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/webhook"
+	"github.com/pkg/errors"
+)
+
+// Webhook events of the approval workflow
+const (
+	EventProposalSubmitted = "proposal.submitted"
+	EventProposalApproved  = "proposal.approved"
+	EventProposalRejected  = "proposal.rejected"
+)
+
+// SubmitProposal submits a proposal to be approved by the organization in
+// the approver field of the request body
+func SubmitProposal(c *gin.Context) {
+	req := make(map[string]interface{})
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, ok := submit(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "submitProposal", req)
+	if !ok {
+		return
+	}
+	webhook.Notify(EventProposalSubmitted, payload)
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// ListProposals searches proposals by the status, proposer and approver
+// query parameters
+func ListProposals(c *gin.Context) {
+	selector := map[string]interface{}{
+		"@assetType": "proposal",
+	}
+	for _, field := range []string{"status", "proposer", "approver"} {
+		if value := c.Query(field); value != "" {
+			selector[field] = value
+		}
+	}
+
+	searchProposals(c, selector)
+}
+
+// ListPendingProposals searches the proposals awaiting the approval of the
+// organization of the request
+func ListPendingProposals(c *gin.Context) {
+	searchProposals(c, map[string]interface{}{
+		"@assetType": "proposal",
+		"status":     "pending",
+		"approver":   callerMSP(c),
+	})
+}
+
+func searchProposals(c *gin.Context, selector map[string]interface{}) {
+	query := map[string]interface{}{
+		"selector": selector,
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, errors.New("the limit query parameter must be an integer"))
+			return
+		}
+		query["limit"] = n
+	}
+	if bookmark := c.Query("bookmark"); bookmark != "" {
+		query["bookmark"] = bookmark
+	}
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": query,
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", args)
+}
+
+// DecideProposal approves or rejects, depending on the transaction, the
+// proposal with the key in the path, with an optional reason in the body
+func DecideProposal(txName, event string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if c.Request.ContentLength != 0 {
+			err := c.ShouldBindJSON(&body)
+			if err != nil {
+				common.Abort(c, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		req := map[string]interface{}{
+			"proposal": assetKey(c, "proposal"),
+		}
+		if body.Reason != "" {
+			req["reason"] = body.Reason
+		}
+
+		payload, ok := submit(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), txName, req)
+		if !ok {
+			return
+		}
+		webhook.Notify(event, payload)
+
+		common.Respond(c, payload, http.StatusOK, nil)
+	}
+}
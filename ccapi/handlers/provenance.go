@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/prov"
+	"github.com/pkg/errors"
+)
+
+// AssetProvenance exports the history of the asset of the type with the key
+// in the path as a W3C PROV-JSON document
+func AssetProvenance(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channelName := os.Getenv("CHANNEL")
+		chaincodeName := os.Getenv("CCNAME")
+
+		key := assetKey(c, assetType)
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": key,
+		})
+		result, err := chaincode.QueryGatewayFor(c, channelName, chaincodeName, "readAssetHistory", []string{string(args)})
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+
+		var history []map[string]interface{}
+		err = common.UnmarshalJSON(result, &history)
+		if err != nil {
+			common.Abort(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		if len(history) == 0 {
+			common.Abort(c, http.StatusNotFound, errors.New("the asset has no history"))
+			return
+		}
+
+		doc := prov.Document(channelName, chaincodeName, key["@key"].(string), history)
+		common.Respond(c, doc, http.StatusOK, nil)
+	}
+}
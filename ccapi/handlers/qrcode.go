@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/qrcode"
+	"github.com/pkg/errors"
+)
+
+// assetURNPrefix prefixes asset keys in URNs, as in JSON-LD documents and
+// verifiable credentials
+const assetURNPrefix = "urn:ccapi:asset:"
+
+// maxQRScale limits the pixels per module of QR code images
+const maxQRScale = 32
+
+var qrLevels = map[string]qrcode.Level{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.Quartile,
+	"H": qrcode.High,
+}
+
+// publicBaseURL returns the URL the CCAPI is reached at by clients, from the
+// environment variable or else the scheme and host of the request
+func publicBaseURL(c *gin.Context, variable string) string {
+	base := os.Getenv(variable)
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return strings.TrimSuffix(base, "/")
+}
+
+// AssetQRCode renders a QR code for the label of the asset of the type with
+// the key in the path. By default it encodes the URL of the resolver with
+// the key, so phones open the asset, and with content=key just the key, for
+// scanners of inventory systems. The format (png or svg), size (pixels per
+// module) and level (L, M, Q or H error correction) query parameters style
+// it.
+func AssetQRCode(assetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := assetKey(c, assetType)["@key"].(string)
+
+		var content string
+		switch c.DefaultQuery("content", "url") {
+		case "url":
+			assets := strings.TrimSuffix(c.FullPath(), "/"+assetType+"/:key/qrcode")
+			content = publicBaseURL(c, "QR_BASE_URL") + assets + "/resolve?code=" + url.QueryEscape(key)
+		case "key":
+			content = key
+		default:
+			common.Abort(c, http.StatusBadRequest, errors.New("the content query parameter must be url or key"))
+			return
+		}
+
+		level, ok := qrLevels[strings.ToUpper(c.DefaultQuery("level", "M"))]
+		if !ok {
+			common.Abort(c, http.StatusBadRequest, errors.New("the level query parameter must be L, M, Q or H"))
+			return
+		}
+		scale, err := strconv.Atoi(c.DefaultQuery("size", "8"))
+		if err != nil || scale < 1 || scale > maxQRScale {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("the size query parameter must be an integer between 1 and %d", maxQRScale))
+			return
+		}
+
+		code, err := qrcode.Encode([]byte(content), level)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+
+		switch c.DefaultQuery("format", "png") {
+		case "png":
+			image, err := code.PNG(scale)
+			if err != nil {
+				common.Abort(c, http.StatusInternalServerError, err)
+				return
+			}
+			c.Data(http.StatusOK, "image/png", image)
+		case "svg":
+			c.Data(http.StatusOK, "image/svg+xml", code.SVG(scale))
+		default:
+			common.Abort(c, http.StatusBadRequest, errors.New("the format query parameter must be png or svg"))
+		}
+	}
+}
+
+// ResolveAsset reads the asset a scanned QR code refers to, from the code
+// query parameter or the code property of the JSON body. Codes may be
+// asset keys, asset URNs, URLs of the resolver and URLs of asset resources,
+// so labels printed by other deployments of the CCAPI resolve too.
+func ResolveAsset(c *gin.Context) {
+	code := c.Query("code")
+	if c.Request.Method == http.MethodPost {
+		var body struct {
+			Code string `json:"code"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+		code = body.Code
+	}
+
+	key, err := scannedKey(code)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	assetType := strings.SplitN(key, ":", 2)[0]
+	if _, err := getAssetSchema(c, assetType); err != nil {
+		common.Abort(c, http.StatusNotFound, errors.Errorf("unknown asset type '%s'", assetType))
+		return
+	}
+
+	opts, ok := formatOptions(c)
+	if !ok {
+		return
+	}
+	args, _ := json.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"@assetType": assetType,
+			"@key":       key,
+		},
+	})
+	payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+	if !ok {
+		return
+	}
+	if asset, ok := payload.(map[string]interface{}); ok {
+		decryptAsset(assetType, asset)
+		addLinks(c, assetType, asset)
+		formatAsset(c, opts, assetType, asset)
+	}
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// scannedKey returns the asset key the payload of a scanned QR code refers
+// to
+func scannedKey(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", errors.New("the scanned code is required")
+	}
+
+	if u, err := url.Parse(code); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		if inner := u.Query().Get("code"); inner != "" {
+			code = inner
+		} else {
+			// URL of the resource of the asset, .../assets/{type}/{key}
+			segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+			code = ""
+			for i := len(segments) - 3; i >= 0; i-- {
+				if segments[i] == "assets" {
+					assetType, key := segments[i+1], segments[i+2]
+					if !strings.HasPrefix(key, assetType+":") {
+						key = assetType + ":" + key
+					}
+					code = key
+					break
+				}
+			}
+			if code == "" {
+				return "", errors.New("the scanned URL does not refer to an asset")
+			}
+		}
+	}
+
+	key := strings.TrimPrefix(code, assetURNPrefix)
+	parts := strings.SplitN(key, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", errors.Errorf("'%s' is not an asset key", code)
+	}
+	return key, nil
+}
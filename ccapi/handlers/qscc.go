@@ -6,6 +6,7 @@ import (
 	"net/http"
 	
 	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/blockdecode"
 	"github.com/hyperledger-labs/ccapi/chaincode"
 	"github.com/hyperledger-labs/ccapi/common"
 	protos "github.com/hyperledger/fabric-protos-go-apiv2/common"
@@ -39,12 +40,7 @@ func QueryQSCC(c *gin.Context) {
 
 func getChainInfo(c *gin.Context, channelName string) {
 	// Query
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
-	}
-
-	result, err := chaincode.QueryGateway(channelName, "qscc", "GetChainInfo", user, []string{channelName})
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetChainInfo", []string{channelName})
 	if err != nil {
 		err, status := common.ParseError(err)
 		common.Abort(c, status, err)
@@ -69,70 +65,42 @@ func getChainInfo(c *gin.Context, channelName string) {
 }
 
 func getBlockByNumber(c *gin.Context, channelName string) {
-	// Query
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
-	}
-
 	number, ok := c.GetQuery("number")
 	if !ok {
 		common.Abort(c, http.StatusBadRequest, fmt.Errorf("missing number"))
 		return
 	}
 
-	result, err := chaincode.QueryGateway(channelName, "qscc", "GetBlockByNumber", user, []string{channelName, number})
+	// Query
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByNumber", []string{channelName, number})
 	if err != nil {
 		err, status := common.ParseError(err)
 		common.Abort(c, status, err)
 		return
 	}
 
-	blockMap, err := decodeBlock(result)
-	if err != nil {
-		common.Abort(c, http.StatusInternalServerError, err)
-		return
-	}
-
-	common.Respond(c, blockMap, http.StatusOK, nil)
+	respondBlock(c, result)
 }
 
 func getBlockByTxID(c *gin.Context, channelName string) {
-	// Query
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
-	}
-
 	txid, ok := c.GetQuery("txid")
 	if !ok {
 		common.Abort(c, http.StatusBadRequest, fmt.Errorf("missing number"))
 		return
 	}
 
-	result, err := chaincode.QueryGateway(channelName, "qscc", "GetBlockByTxID", user, []string{channelName, txid})
+	// Query
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByTxID", []string{channelName, txid})
 	if err != nil {
 		err, status := common.ParseError(err)
 		common.Abort(c, status, err)
 		return
 	}
 
-	blockMap, err := decodeBlock(result)
-	if err != nil {
-		common.Abort(c, http.StatusInternalServerError, err)
-		return
-	}
-
-	common.Respond(c, blockMap, http.StatusOK, nil)
+	respondBlock(c, result)
 }
 
 func getBlockByHash(c *gin.Context, channelName string) {
-	// Query
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
-	}
-
 	hash, ok := c.GetQuery("hash")
 	if !ok {
 		common.Abort(c, http.StatusBadRequest, fmt.Errorf("missing hash"))
@@ -146,7 +114,8 @@ func getBlockByHash(c *gin.Context, channelName string) {
 		return
 	}
 
-	result, err := chaincode.QueryGateway(channelName, "qscc", "GetBlockByHash", user, []string{channelName, string(hashBytes)})
+	// Query
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByHash", []string{channelName, string(hashBytes)})
 	
 	if err != nil {
 		err, status := common.ParseError(err)
@@ -154,22 +123,10 @@ func getBlockByHash(c *gin.Context, channelName string) {
 		return
 	}
 
-	blockMap, err := decodeBlock(result)
-	if err != nil {
-		common.Abort(c, http.StatusInternalServerError, err)
-		return
-	}
-
-	common.Respond(c, blockMap, http.StatusOK, nil)
+	respondBlock(c, result)
 }
 
 func getTransactionByID(c *gin.Context, channelName string) {
-	// Query
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
-	}
-
 	fmt.Println("getting txid")
 	txid, ok := c.GetQuery("txid")
 	if !ok {
@@ -178,7 +135,8 @@ func getTransactionByID(c *gin.Context, channelName string) {
 	}
 
 	fmt.Println("calling GetTransactionByID")
-	result, err := chaincode.QueryGateway(channelName, "qscc", "GetTransactionByID", user, []string{channelName, txid})
+	// Query
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetTransactionByID", []string{channelName, txid})
 	if err != nil {
 		fmt.Println("error calling GetTransactionByID: ", err)
 		err, status := common.ParseError(err)
@@ -186,6 +144,16 @@ func getTransactionByID(c *gin.Context, channelName string) {
 		return
 	}
 
+	if c.Query("decoded") == "true" {
+		tx, err := blockdecode.DecodeProcessedTransaction(result)
+		if err != nil {
+			common.Abort(c, http.StatusInternalServerError, err)
+			return
+		}
+		common.Respond(c, tx, http.StatusOK, nil)
+		return
+	}
+
 	fmt.Println("decoding transaction")
 	m, err := decodeProcessedTransaction(result)
 	if err != nil {
@@ -198,6 +166,28 @@ func getTransactionByID(c *gin.Context, channelName string) {
 	common.Respond(c, m, http.StatusOK, nil)
 }
 
+// respondBlock responds with the block, decoded into the structs of
+// blockdecode if the decoded query parameter is true, or with the fields of
+// its protobuf messages otherwise
+func respondBlock(c *gin.Context, b []byte) {
+	if c.Query("decoded") == "true" {
+		block, err := blockdecode.DecodeBlock(b)
+		if err != nil {
+			common.Abort(c, http.StatusInternalServerError, err)
+			return
+		}
+		common.Respond(c, block, http.StatusOK, nil)
+		return
+	}
+
+	blockMap, err := decodeBlock(b)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.Respond(c, blockMap, http.StatusOK, nil)
+}
+
 func decodeBlock(b []byte) (map[string]interface{}, error) {
 	var block protos.Block
 
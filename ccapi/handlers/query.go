@@ -38,11 +38,12 @@ func Query(c *gin.Context) {
 		argList = append(argList, args)
 	}
 
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
+	if !authorize(c, txName) {
+		return
 	}
 
+	user := common.GetUser(c)
+
 	res, status, err := chaincode.Query(channelName, chaincodeName, txName, user, argList)
 	if err != nil {
 		common.Abort(c, status, err)
@@ -50,11 +51,13 @@ func Query(c *gin.Context) {
 	}
 
 	var payload interface{}
-	err = json.Unmarshal(res.Payload, &payload)
+	err = common.UnmarshalJSON(res.Payload, &payload)
 	if err != nil {
 		common.Abort(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	payload = selectFields(c, txName, payload)
+
 	common.Respond(c, payload, status, err)
 }
@@ -47,26 +47,43 @@ func queryGateway(c *gin.Context, channelName, chaincodeName string) {
 
 	txName := c.Param("txname")
 
-	// Query
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
+	evaluateGateway(c, channelName, chaincodeName, txName, args)
+}
+
+// evaluateGateway evaluates the transaction with the given arguments and
+// writes the response
+func evaluateGateway(c *gin.Context, channelName, chaincodeName, txName string, args []byte) {
+	payload, ok := evaluate(c, channelName, chaincodeName, txName, args)
+	if !ok {
+		return
+	}
+
+	payload = selectFields(c, txName, payload)
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// evaluate evaluates the transaction and returns its parsed result. On
+// failure the request is aborted and ok is false
+func evaluate(c *gin.Context, channelName, chaincodeName, txName string, args []byte) (payload interface{}, ok bool) {
+	if txName == "search" {
+		warnUnindexed(c, channelName, chaincodeName, args)
 	}
 
-	result, err := chaincode.QueryGateway(channelName, chaincodeName, txName, user, []string{string(args)})
+	// Query
+	result, err := chaincode.QueryGatewayFor(c, channelName, chaincodeName, txName, []string{string(args)})
 	if err != nil {
 		err, status := common.ParseError(err)
 		common.Abort(c, status, err)
-		return
+		return nil, false
 	}
 
 	// Parse response
-	var payload interface{}
-	err = json.Unmarshal(result, &payload)
+	err = common.UnmarshalJSON(result, &payload)
 	if err != nil {
 		common.Abort(c, http.StatusInternalServerError, err)
-		return
+		return nil, false
 	}
 
-	common.Respond(c, payload, http.StatusOK, nil)
+	return payload, true
 }
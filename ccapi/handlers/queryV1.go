@@ -39,11 +39,12 @@ func QueryV1(c *gin.Context) {
 		argList = append(argList, args)
 	}
 
-	user := c.GetHeader("User")
-	if user == "" {
-		user = "Admin"
+	if !authorize(c, txName) {
+		return
 	}
 
+	user := common.GetUser(c)
+
 	res, status, err := chaincode.Query(channelName, chaincodeName, txName, user, argList)
 	if err != nil {
 		common.Abort(c, status, err)
@@ -51,11 +52,13 @@ func QueryV1(c *gin.Context) {
 	}
 
 	var payload interface{}
-	err = json.Unmarshal(res.Payload, &payload)
+	err = common.UnmarshalJSON(res.Payload, &payload)
 	if err != nil {
 		common.Abort(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	payload = selectFields(c, txName, payload)
+
 	common.Respond(c, payload, status, err)
 }
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/quota"
+	"github.com/pkg/errors"
+)
+
+// checkQuota checks that the identity has not used its monthly quota.
+// Otherwise the request is aborted and false is returned.
+func checkQuota(c *gin.Context, identity string) bool {
+	err := quota.Check(identity)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return false
+	}
+	return true
+}
+
+// reserveQuota counts the transaction the identity is about to submit
+// against its monthly quota. Otherwise the request is aborted and ok is
+// false. The release function gives the transaction back if the submission
+// fails.
+func reserveQuota(c *gin.Context, identity string) (release func(), ok bool) {
+	release, err := quota.Reserve(identity)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return nil, false
+	}
+	return release, true
+}
+
+// UsageReport returns the transactions submitted by each identity in the
+// month of the month query parameter (YYYY-MM), default the current one,
+// with their quota
+func UsageReport(c *gin.Context) {
+	if !quota.Enabled() {
+		common.Abort(c, http.StatusNotFound, errors.New("quotas are disabled, set QUOTA_CONFIG to enable them"))
+		return
+	}
+
+	month := c.DefaultQuery("month", quota.CurrentMonth())
+	report, err := quota.Report(month)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	common.Respond(c, gin.H{
+		"month": month,
+		"usage": report,
+	}, http.StatusOK, nil)
+}
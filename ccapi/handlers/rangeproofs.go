@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/rangeproof"
+	"github.com/pkg/errors"
+)
+
+// CommitValue creates a range proof commitment to the value in the request
+// body, to be stored in an asset field, and the secret its holder needs to
+// prove claims about it. Nothing is kept by the CCAPI.
+func CommitValue(c *gin.Context) {
+	var body struct {
+		Value *int64 `json:"value" binding:"required"`
+		Max   *int64 `json:"max" binding:"required"`
+	}
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	commitment, secret, err := rangeproof.Commit(*body.Value, *body.Max)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	common.Respond(c, gin.H{
+		"commitment": commitment.String(),
+		"secret":     secret,
+	}, http.StatusOK, nil)
+}
+
+// ProveClaim proves the claim in the request body about the value of the
+// secret, failing if it is false
+func ProveClaim(c *gin.Context) {
+	var body struct {
+		Secret rangeproof.Secret `json:"secret"`
+		Claim  rangeproof.Claim  `json:"claim"`
+	}
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	proof, err := rangeproof.Prove(body.Secret, body.Claim)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	common.Respond(c, gin.H{
+		"commitment": body.Secret.Commitment,
+		"claim":      body.Claim,
+		"proof":      proof,
+	}, http.StatusOK, nil)
+}
+
+// VerifyClaim checks the proof of the claim in the request body against the
+// commitment, given in the body or read from the field of an asset
+func VerifyClaim(c *gin.Context) {
+	var body struct {
+		Commitment string           `json:"commitment"`
+		AssetType  string           `json:"assetType"`
+		Key        string           `json:"key"`
+		Field      string           `json:"field"`
+		Claim      rangeproof.Claim `json:"claim"`
+		Proof      string           `json:"proof" binding:"required"`
+	}
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if body.Commitment == "" {
+		if body.AssetType == "" || body.Key == "" || body.Field == "" {
+			common.Abort(c, http.StatusBadRequest, errors.New("either commitment or assetType, key and field are required"))
+			return
+		}
+
+		key := body.Key
+		if !strings.HasPrefix(key, body.AssetType+":") {
+			key = body.AssetType + ":" + key
+		}
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": map[string]interface{}{
+				"@assetType": body.AssetType,
+				"@key":       key,
+			},
+		})
+		payload, ok := evaluate(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+		if !ok {
+			return
+		}
+
+		asset, _ := payload.(map[string]interface{})
+		body.Commitment, _ = asset[body.Field].(string)
+		if body.Commitment == "" {
+			common.Abort(c, http.StatusNotFound, errors.Errorf("asset has no commitment in field '%s'", body.Field))
+			return
+		}
+	}
+
+	commitment, err := rangeproof.ParseCommitment(body.Commitment)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	valid, err := rangeproof.Verify(commitment, body.Claim, body.Proof)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	common.Respond(c, gin.H{
+		"claim": body.Claim.String(),
+		"valid": valid,
+	}, http.StatusOK, nil)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/pkg/errors"
+)
+
+// rawRequest is the body of a call to a function of a chaincode not built
+// with cc-tools: its string arguments, passed as they are, and its transient
+// data, where values that are not strings are passed as JSON
+type rawRequest struct {
+	Args      []string               `json:"args"`
+	Transient map[string]interface{} `json:"transient"`
+}
+
+func RawGatewayDefault(c *gin.Context) {
+	channelName := os.Getenv("CHANNEL")
+	chaincodeName := os.Getenv("CCNAME")
+
+	rawGateway(c, channelName, chaincodeName)
+}
+
+func RawGatewayCustom(c *gin.Context) {
+	channelName := c.Param("channelName")
+	chaincodeName := c.Param("chaincodeName")
+
+	rawGateway(c, channelName, chaincodeName)
+}
+
+// rawGateway calls the function with the arguments of the body, evaluating
+// it if it matches RAW_QUERY_FUNCTIONS and submitting it otherwise
+func rawGateway(c *gin.Context, channelName, chaincodeName string) {
+	var req rawRequest
+	if c.Request.ContentLength != 0 {
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if req.Args == nil {
+		req.Args = []string{}
+	}
+
+	fn := c.Param("txname")
+	user := common.GetUser(c)
+
+	if rawQuery(fn) {
+		if len(req.Transient) > 0 {
+			common.Abort(c, http.StatusBadRequest, errors.New("transient data is only sent to submitted functions"))
+			return
+		}
+
+		result, err := chaincode.QueryGatewayFor(c, channelName, chaincodeName, fn, req.Args)
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+		common.Respond(c, rawResult(result), http.StatusOK, nil)
+		return
+	}
+
+	transient := make(map[string][]byte, len(req.Transient))
+	for key, value := range req.Transient {
+		if s, ok := value.(string); ok {
+			transient[key] = []byte(s)
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+		transient[key] = data
+	}
+
+	if !authorize(c, fn) || !checkQuota(c, user) {
+		return
+	}
+
+	entry := audit.Entry{
+		Channel:      channelName,
+		Chaincode:    chaincodeName,
+		TxName:       fn,
+		User:         user,
+		Args:         req.Args,
+		HasTransient: len(transient) > 0,
+	}
+	class, err := txqueue.ParseClass(c.Query("@priority"))
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	release, ok := reserveQuota(c, user)
+	if !ok {
+		return
+	}
+	result, status, err := submitAudited(entry, transient, class)
+	if err != nil {
+		release()
+		common.Abort(c, status, err)
+		return
+	}
+	common.SetTxMeta(c, result.TxID, result.BlockNumber)
+
+	common.Respond(c, rawResult(result.Result), http.StatusOK, nil)
+}
+
+// rawQuery reports whether the function is evaluated instead of submitted,
+// matching one of the comma separated patterns of RAW_QUERY_FUNCTIONS, such
+// as ReadAsset,Get*,*Exists
+func rawQuery(fn string) bool {
+	for _, pattern := range strings.Split(os.Getenv("RAW_QUERY_FUNCTIONS"), ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if ok, _ := path.Match(pattern, fn); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// rawResult returns the result of a function as JSON if it is valid JSON,
+// as a string otherwise, or nil if empty
+func rawResult(result []byte) interface{} {
+	if len(result) == 0 {
+		return nil
+	}
+
+	var payload interface{}
+	err := common.UnmarshalJSON(result, &payload)
+	if err != nil {
+		return string(result)
+	}
+	return payload
+}
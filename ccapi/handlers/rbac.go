@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/rbac"
+	"github.com/pkg/errors"
+)
+
+// authorize checks that the user of the request can call the transaction.
+// Otherwise the request is aborted and false is returned.
+func authorize(c *gin.Context, txName string) bool {
+	err := rbac.Check(c, txName)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return false
+	}
+	return true
+}
+
+// RBACStatus returns the groups and roles of the user of the request and
+// the outcome of the last group sync
+func RBACStatus(c *gin.Context) {
+	if !rbac.Enabled() {
+		common.Abort(c, http.StatusNotFound, errors.New("roles are disabled, set RBAC_CONFIG to enable them"))
+		return
+	}
+
+	status, err := rbac.GetStatus(c.GetString(common.SessionUserKey))
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	common.Respond(c, status, http.StatusOK, nil)
+}
+
+// SyncRBAC syncs the groups from the SCIM server right away. Only users
+// allowed to call every transaction can request it.
+func SyncRBAC(c *gin.Context) {
+	if !rbac.Enabled() {
+		common.Abort(c, http.StatusNotFound, errors.New("roles are disabled, set RBAC_CONFIG to enable them"))
+		return
+	}
+	if !authorize(c, rbac.AllTransactions) {
+		return
+	}
+
+	err := rbac.Sync()
+	if err != nil {
+		common.Abort(c, http.StatusBadGateway, err)
+		return
+	}
+
+	RBACStatus(c)
+}
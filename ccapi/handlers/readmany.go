@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultReadManyConcurrency = 10
+	maxReadManyConcurrency     = 50
+	maxReadManyKeys            = 1000
+)
+
+type readManyRequest struct {
+	Keys        []interface{} `json:"keys" binding:"required"`
+	Concurrency int           `json:"concurrency"`
+}
+
+// readManyResult is the outcome of the read of a single asset
+type readManyResult struct {
+	Key    interface{} `json:"@key"`
+	Status int         `json:"status"`
+	Asset  interface{} `json:"asset,omitempty"`
+	Code   string      `json:"code,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ReadManyAssets reads a list of assets, given by their keys or by objects
+// with their asset type and key properties. The reads are evaluated
+// concurrently by a bounded pool of workers and the results are returned in
+// the order of the keys, each with its own status.
+func ReadManyAssets(c *gin.Context) {
+	var req readManyRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Keys) > maxReadManyKeys {
+		common.Abort(c, http.StatusBadRequest, errors.Errorf("at most %d keys can be read at once", maxReadManyKeys))
+		return
+	}
+	opts, ok := formatOptions(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, "readAsset") {
+		return
+	}
+	if req.Concurrency <= 0 {
+		req.Concurrency = defaultReadManyConcurrency
+	}
+	if req.Concurrency > maxReadManyConcurrency {
+		req.Concurrency = maxReadManyConcurrency
+	}
+
+	results := make([]readManyResult, len(req.Keys))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < req.Concurrency && w < len(req.Keys); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = readOne(c, req.Keys[i])
+			}
+		}()
+	}
+	for i := range req.Keys {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	// The context is not safe for concurrent use, so the assets are only
+	// decrypted and linked once every read is done
+	found := 0
+	for i, r := range results {
+		if r.Status != http.StatusOK {
+			continue
+		}
+		if asset, ok := r.Asset.(map[string]interface{}); ok {
+			assetType, _ := asset["@assetType"].(string)
+			decryptAsset(assetType, asset)
+			addLinks(c, assetType, asset)
+			formatAsset(c, opts, assetType, asset)
+		}
+		results[i].Asset = selectFields(c, "readAsset", r.Asset)
+		found++
+	}
+
+	common.Respond(c, gin.H{
+		"found":   found,
+		"failed":  len(results) - found,
+		"results": results,
+	}, http.StatusOK, nil)
+}
+
+// readOne reads a single asset for ReadManyAssets
+func readOne(c *gin.Context, item interface{}) readManyResult {
+	var key map[string]interface{}
+	switch k := item.(type) {
+	case string:
+		key = map[string]interface{}{
+			"@assetType": strings.SplitN(k, ":", 2)[0],
+			"@key":       k,
+		}
+	case map[string]interface{}:
+		key = k
+	default:
+		return readManyResult{
+			Key:    item,
+			Status: http.StatusBadRequest,
+			Code:   common.CodeFromStatus(http.StatusBadRequest),
+			Error:  "keys must be strings or objects",
+		}
+	}
+
+	res := readManyResult{
+		Key: key["@key"],
+	}
+	args, _ := json.Marshal(map[string]interface{}{
+		"key": key,
+	})
+	result, err := chaincode.QueryGatewayFor(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", []string{string(args)})
+	if err == nil {
+		err = common.UnmarshalJSON(result, &res.Asset)
+	}
+	if err != nil {
+		err, status := common.ParseError(err)
+		res.Status = status
+		res.Code = common.CodeFromStatus(status)
+		if apiErr, ok := err.(*common.APIError); ok {
+			res.Code = apiErr.Code
+		}
+		res.Error = err.Error()
+		res.Asset = nil
+		return res
+	}
+
+	if asset, ok := res.Asset.(map[string]interface{}); ok {
+		res.Key = asset["@key"]
+	}
+	res.Status = http.StatusOK
+	return res
+}
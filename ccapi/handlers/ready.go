@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/compat"
+	"github.com/hyperledger-labs/ccapi/leader"
+)
+
+// Ready reports whether the CCAPI can reach the Fabric network, for
+// readiness probes: it fails with 503 while a connection to a gateway peer
+// is failing or the deployed chaincode version is incompatible. The
+// connections and the event streams are listed with their state, event
+// streams being resubscribed without affecting readiness, and so is the
+// leader election, as every replica serves requests.
+func Ready(c *gin.Context) {
+	status, code := "ready", http.StatusOK
+	if !common.ConnectionsReady() || !compat.Ready() {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+
+	res := gin.H{
+		"status":       status,
+		"connections":  common.ConnStates(),
+		"eventStreams": chaincode.EventStreams(),
+	}
+	if compat.Enabled() {
+		res["chaincodeVersion"] = compat.Get()
+	}
+	if leader.Enabled() {
+		res["leader"] = leader.GetStatus()
+	}
+	c.JSON(code, res)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/blockdecode"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/receipt"
+	"github.com/pkg/errors"
+)
+
+// TransactionReceipt returns the receipt of the committed transaction with
+// the txid in the path, as JSON or, with ?format=pdf or an Accept header of
+// application/pdf, as a PDF document to download. The QR code of the
+// receipt links to the inclusion proof of the transaction.
+func TransactionReceipt(c *gin.Context) {
+	channelName := c.Param("channelName")
+	txID := c.Param("txid")
+
+	format := c.Query("format")
+	if format == "" && strings.Contains(c.GetHeader("Accept"), "application/pdf") {
+		format = "pdf"
+	}
+	if format != "" && format != "json" && format != "pdf" {
+		common.Abort(c, http.StatusBadRequest, errors.Errorf("unknown receipt format '%s', use json or pdf", format))
+		return
+	}
+
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByTxID", []string{channelName, txID})
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	block, err := blockdecode.DecodeBlock(result)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	tx := block.Find(txID)
+	if tx == nil {
+		common.Abort(c, http.StatusNotFound, errors.New("transaction not found in its block"))
+		return
+	}
+	if !tx.Valid {
+		common.Abort(c, http.StatusConflict, errors.Errorf("transaction was not committed: %s", tx.ValidationCode))
+		return
+	}
+
+	r, err := receipt.New(channelName, tx, verificationURL(c, channelName, txID))
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if format == "pdf" {
+		c.Header("Content-Disposition", `attachment; filename="receipt-`+txID+`.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", r.PDF())
+		return
+	}
+	common.Respond(c, r, http.StatusOK, nil)
+}
+
+// verificationURL returns the URL of the inclusion proof of the transaction,
+// under RECEIPT_BASE_URL or else the scheme and host of the request
+func verificationURL(c *gin.Context, channelName, txID string) string {
+	prefix := strings.TrimSuffix(c.FullPath(), "/:channelName/receipt/:txid")
+	return publicBaseURL(c, "RECEIPT_BASE_URL") + prefix + "/" + url.PathEscape(channelName) + "/proof/" + url.PathEscape(txID)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"log"
+	"os"
+
+	"github.com/hyperledger-labs/ccapi/redact"
+)
+
+// RedactSensitiveProps masks, in logs, audit records and error messages, the
+// properties of the asset type whose data type is one of REDACT_DATATYPES
+func RedactSensitiveProps(assetType string) {
+	dataTypes := redact.DataTypes()
+	if len(dataTypes) == 0 {
+		return
+	}
+
+	schema, err := loadAssetSchema(assetType, os.Getenv("USER"))
+	if err != nil {
+		log.Printf("failed to get schema of %s to redact its properties: %s\n", assetType, err)
+		return
+	}
+
+	for _, prop := range schema.Props {
+		for _, dataType := range dataTypes {
+			if prop.DataType == dataType || prop.DataType == "[]"+dataType {
+				redact.AddFields(assetType + "." + prop.Tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger-labs/ccapi/webhook"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/pkg/errors"
+)
+
+// replayRequest selects the chaincode events to replay and where to
+type replayRequest struct {
+	From   *uint64  `json:"from"`
+	To     *uint64  `json:"to"`
+	Events []string `json:"events"`
+	Sink   string   `json:"sink"`
+	URL    string   `json:"url"`
+}
+
+// replayJob is a replay to a webhook running in the background
+type replayJob struct {
+	ID         string     `json:"id"`
+	From       uint64     `json:"from"`
+	To         uint64     `json:"to"`
+	Events     []string   `json:"events,omitempty"`
+	URL        string     `json:"url"`
+	Status     string     `json:"status"`
+	Count      int        `json:"count"`
+	LastBlock  uint64     `json:"lastBlock,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+var (
+	replayMutex sync.Mutex
+	replayJobs  = make([]*replayJob, 0)
+)
+
+// replayedEvent is a chaincode event as sent to the sinks
+func replayedEvent(event *client.ChaincodeEvent) gin.H {
+	var payload interface{}
+	if json.Unmarshal(event.Payload, &payload) != nil {
+		payload = string(event.Payload)
+	}
+	return gin.H{
+		"blockNumber": event.BlockNumber,
+		"txId":        event.TransactionID,
+		"eventName":   event.EventName,
+		"payload":     payload,
+	}
+}
+
+// ReplayEvents delivers again the chaincode events committed in a range of
+// blocks, so downstream projections can be rebuilt. The events go either to
+// the response, as newline delimited JSON, or to a webhook in the
+// background. The range ends at the current height of the channel if to is
+// omitted.
+func ReplayEvents(c *gin.Context) {
+	if !authorize(c, "replayEvents") {
+		return
+	}
+	if mock.Enabled() {
+		common.Abort(c, http.StatusNotImplemented, errors.New("event replay requires a Fabric network"))
+		return
+	}
+
+	var req replayRequest
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.From == nil {
+		common.Abort(c, http.StatusBadRequest, errors.New("missing from block"))
+		return
+	}
+	switch req.Sink {
+	case "stream":
+	case "webhook":
+		if req.URL == "" {
+			common.Abort(c, http.StatusBadRequest, errors.New("webhook sink requires a url"))
+			return
+		}
+		req.URL, err = outboundURL(req.URL, "the url of the webhook sink", "REPLAY_ALLOWED_HOSTS")
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+	case "":
+		common.Abort(c, http.StatusBadRequest, errors.New("missing sink, must be stream or webhook"))
+		return
+	default:
+		common.Abort(c, http.StatusBadRequest, errors.Errorf("unsupported sink '%s', must be stream or webhook", req.Sink))
+		return
+	}
+
+	channelName := os.Getenv("CHANNEL")
+	user := common.GetUser(c)
+
+	var to uint64
+	if req.To != nil {
+		to = *req.To
+	} else {
+		height, err := chaincode.ChainHeight(channelName, user)
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+		if height > 0 {
+			to = height - 1
+		}
+	}
+	if to < *req.From {
+		common.Abort(c, http.StatusBadRequest, errors.New("to must not be before from"))
+		return
+	}
+
+	if req.Sink == "webhook" {
+		job := startReplayJob(channelName, user, *req.From, to, req.Events, req.URL)
+		common.Respond(c, job, http.StatusAccepted, nil)
+		return
+	}
+	streamReplay(c, channelName, user, *req.From, to, req.Events)
+}
+
+// ListReplays returns the replays to webhooks since the CCAPI started,
+// oldest first
+func ListReplays(c *gin.Context) {
+	replayMutex.Lock()
+	defer replayMutex.Unlock()
+
+	jobs := make([]replayJob, 0, len(replayJobs))
+	for _, job := range replayJobs {
+		jobs = append(jobs, *job)
+	}
+	common.Respond(c, jobs, http.StatusOK, nil)
+}
+
+// wantEvent reports whether the event is one of the names, or any event if
+// there are no names
+func wantEvent(names []string, event string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if name == event {
+			return true
+		}
+	}
+	return false
+}
+
+// streamReplay writes the replayed events to the response as they arrive.
+// The last line holds the count of events and the last block, or an @error
+// if the replay failed after the response started.
+func streamReplay(c *gin.Context, channelName, user string, from, to uint64, names []string) {
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+
+	count := 0
+	var lastBlock uint64
+	err := chaincode.ReplayEvents(c.Request.Context(), channelName, os.Getenv("CCNAME"), user, from, to, func(event *client.ChaincodeEvent) error {
+		lastBlock = event.BlockNumber
+		if !wantEvent(names, event.EventName) {
+			return nil
+		}
+
+		err := encoder.Encode(replayedEvent(event))
+		if err != nil {
+			// The client is gone
+			return err
+		}
+		c.Writer.Flush()
+		count++
+		return nil
+	})
+	if c.Request.Context().Err() != nil {
+		return
+	}
+	if err != nil {
+		apiErr, status := common.ParseError(err)
+		encoder.Encode(gin.H{"@error": gin.H{
+			"status": status,
+			"error":  apiErr.Error(),
+		}})
+		return
+	}
+
+	encoder.Encode(gin.H{"@metadata": gin.H{
+		"count":     count,
+		"lastBlock": lastBlock,
+	}})
+}
+
+// startReplayJob replays the events to the webhook in the background, one
+// at a time so they arrive in ledger order, as replay.{eventName} events
+func startReplayJob(channelName, user string, from, to uint64, names []string, url string) replayJob {
+	id := make([]byte, 8)
+	rand.Read(id)
+	job := &replayJob{
+		ID:        hex.EncodeToString(id),
+		From:      from,
+		To:        to,
+		Events:    names,
+		URL:       url,
+		Status:    "running",
+		StartedAt: time.Now().UTC(),
+	}
+
+	replayMutex.Lock()
+	replayJobs = append(replayJobs, job)
+	snapshot := *job
+	replayMutex.Unlock()
+
+	go func() {
+		err := chaincode.ReplayEvents(context.Background(), channelName, os.Getenv("CCNAME"), user, from, to, func(event *client.ChaincodeEvent) error {
+			replayMutex.Lock()
+			job.LastBlock = event.BlockNumber
+			replayMutex.Unlock()
+			if !wantEvent(names, event.EventName) {
+				return nil
+			}
+
+			err := webhook.Send(url, "replay."+event.EventName, replayedEvent(event))
+			if err != nil {
+				return err
+			}
+			replayMutex.Lock()
+			job.Count++
+			replayMutex.Unlock()
+			return nil
+		})
+
+		replayMutex.Lock()
+		defer replayMutex.Unlock()
+		finished := time.Now().UTC()
+		job.FinishedAt = &finished
+		job.Status = "done"
+		if err != nil {
+			log.Printf("error replaying events to %s: %s\n", url, err)
+			job.Status = "failed"
+			job.Error = err.Error()
+		}
+	}()
+
+	return snapshot
+}
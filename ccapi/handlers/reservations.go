@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/webhook"
+	"github.com/pkg/errors"
+)
+
+// Webhook events of the book reservations
+const (
+	EventReservationCreated   = "reservation.created"
+	EventReservationCancelled = "reservation.cancelled"
+	EventReservationFulfilled = "reservation.fulfilled"
+)
+
+// ListReservations returns the hold and the reservation queue of the book
+// with the key in the path
+func ListReservations(c *gin.Context) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"book": assetKey(c, "book"),
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "listReservations", args)
+}
+
+// ReserveBook adds the person in the body to the reservation queue of the
+// book with the key in the path
+func ReserveBook(c *gin.Context) {
+	req := make(map[string]interface{})
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	req["book"] = assetKey(c, "book")
+
+	payload, ok := submit(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "reserveBook", req)
+	if !ok {
+		return
+	}
+	webhook.Notify(EventReservationCreated, payload)
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// CancelReservation removes the person in the query from the reservation
+// queue of the book with the key in the path
+func CancelReservation(c *gin.Context) {
+	person := c.Query("person")
+	if person == "" {
+		common.Abort(c, http.StatusBadRequest, errors.New("the person query parameter is required"))
+		return
+	}
+	if !strings.HasPrefix(person, "person:") {
+		person = "person:" + person
+	}
+
+	req := map[string]interface{}{
+		"book": assetKey(c, "book"),
+		"person": map[string]interface{}{
+			"@assetType": "person",
+			"@key":       person,
+		},
+	}
+
+	payload, ok := submit(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "cancelReservation", req)
+	if !ok {
+		return
+	}
+	webhook.Notify(EventReservationCancelled, payload)
+	notifyHold(payload)
+
+	common.Respond(c, payload, http.StatusOK, nil)
+}
+
+// notifyHold notifies the reservation.fulfilled webhook event when the
+// response of a transaction holds a book for the next person in its queue
+func notifyHold(payload interface{}) {
+	response, _ := payload.(map[string]interface{})
+	if hold, ok := response["hold"].(map[string]interface{}); ok {
+		webhook.Notify(EventReservationFulfilled, hold)
+	}
+}
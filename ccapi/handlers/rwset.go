@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/blockdecode"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// TransactionRWSet returns the keys the committed transaction with the txid
+// in the path read, with their versions, and wrote, with their values, so
+// developers can see what it changed. Invalid transactions also get the
+// keys they read that earlier transactions of their block overwrote, which
+// explains most read conflicts. Private data collections only have the hash
+// of their read-write set.
+func TransactionRWSet(c *gin.Context) {
+	channelName := c.Param("channelName")
+	txID := c.Param("txid")
+
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByTxID", []string{channelName, txID})
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	block, err := blockdecode.DecodeBlock(result)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	tx := block.Find(txID)
+	if tx == nil {
+		common.Abort(c, http.StatusNotFound, errors.New("transaction not found in its block"))
+		return
+	}
+
+	res := gin.H{
+		"txId":           tx.TxID,
+		"blockNumber":    tx.BlockNumber,
+		"index":          tx.Index,
+		"validationCode": tx.ValidationCode,
+		"valid":          tx.Valid,
+		"function":       tx.Function,
+		"rwsets":         tx.RWSets,
+	}
+	if !tx.Valid {
+		res["conflicts"] = block.Conflicts(tx)
+	}
+	common.Respond(c, res, http.StatusOK, nil)
+}
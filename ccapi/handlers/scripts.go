@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/quota"
+	"github.com/hyperledger-labs/ccapi/rbac"
+	"github.com/hyperledger-labs/ccapi/scripting"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/hyperledger-labs/ccapi/txschema"
+	"github.com/pkg/errors"
+)
+
+// ListScripts returns the custom endpoints defined by scripts
+func ListScripts(scripts []*scripting.Script) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		common.Respond(c, scripts, http.StatusOK, nil)
+	}
+}
+
+// RunScript handles the request with the script, which calls the chaincode
+// as the user of the request
+func RunScript(script *scripting.Script) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body interface{}
+		if c.Request.ContentLength != 0 && c.Request.Method != http.MethodGet {
+			err := c.ShouldBindJSON(&body)
+			if err != nil {
+				common.Abort(c, http.StatusBadRequest, err)
+				return
+			}
+		}
+		query := make(map[string]string)
+		for name, values := range c.Request.URL.Query() {
+			if len(values) > 0 {
+				query[name] = values[0]
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), scripting.Timeout())
+		defer cancel()
+		req := scripting.Request{Body: body, Query: query, User: common.GetUser(c)}
+		res, err := script.Run(ctx, req, scriptPrimitives{c: c})
+		if err != nil {
+			err, status := common.ParseError(err)
+			common.Abort(c, status, err)
+			return
+		}
+
+		common.Respond(c, res, http.StatusOK, nil)
+	}
+}
+
+// scriptPrimitives call the chaincode for a script with the checks of the
+// invoke and query endpoints, returning their errors to the script
+type scriptPrimitives struct {
+	c *gin.Context
+}
+
+func (p scriptPrimitives) Invoke(channelName, chaincodeName, txName string, req map[string]interface{}) (interface{}, error) {
+	err := rbac.Check(p.c, txName)
+	if err != nil {
+		return nil, err
+	}
+	err = txschema.Check(txName, req)
+	if err != nil {
+		return nil, err
+	}
+	transient, err := extractTransient(req)
+	if err != nil {
+		return nil, err
+	}
+	args, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal args")
+	}
+	user := common.GetUser(p.c)
+	class, err := txqueue.ParseClass(p.c.Query("@priority"))
+	if err != nil {
+		return nil, err
+	}
+	release, err := quota.Reserve(user)
+	if err != nil {
+		return nil, err
+	}
+
+	result, _, err := submitAudited(audit.Entry{
+		Channel:      channelName,
+		Chaincode:    chaincodeName,
+		TxName:       txName,
+		User:         user,
+		Args:         []string{string(args)},
+		HasTransient: hasTransient(transient),
+	}, transient, class)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	common.SetTxMeta(p.c, result.TxID, result.BlockNumber)
+
+	var payload interface{}
+	err = common.UnmarshalJSON(result.Result, &payload)
+	return payload, err
+}
+
+func (p scriptPrimitives) Evaluate(channelName, chaincodeName, txName string, req map[string]interface{}) (interface{}, error) {
+	err := rbac.Check(p.c, txName)
+	if err != nil {
+		return nil, err
+	}
+	args, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal args")
+	}
+	result, err := chaincode.QueryGatewayFor(p.c, channelName, chaincodeName, txName, []string{string(args)})
+	if err != nil {
+		return nil, err
+	}
+
+	var payload interface{}
+	err = common.UnmarshalJSON(result, &payload)
+	return payload, err
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/secretscan"
+)
+
+// checkSecrets scans the public arguments of the transaction for secrets.
+// If they are blocked, the request is aborted and false is returned.
+func checkSecrets(c *gin.Context, txName string, args []string) bool {
+	err := secretscan.Check(txName, args)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return false
+	}
+	return true
+}
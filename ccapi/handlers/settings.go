@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/buildinfo"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/settings"
+)
+
+// EffectiveConfig returns the value in effect of every setting, with secrets
+// masked, where it came from and why it is invalid, along with the unknown
+// variables that look like misspelled settings, the feature flags and the
+// build. The settings can be filtered by area and source.
+func EffectiveConfig(c *gin.Context) {
+	area, source := c.Query("area"), c.Query("source")
+	list := make([]settings.Value, 0)
+	invalid := 0
+	for _, v := range settings.List() {
+		if (area != "" && v.Area != area) || (source != "" && v.Source != source) {
+			continue
+		}
+		if v.Error != "" {
+			invalid++
+		}
+		list = append(list, v)
+	}
+
+	common.Respond(c, gin.H{
+		"settings": list,
+		"invalid":  invalid,
+		"unknown":  settings.GetUnknown(),
+		"features": features.List(),
+		"build":    buildinfo.Get(),
+	}, http.StatusOK, nil)
+}
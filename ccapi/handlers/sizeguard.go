@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/sizeguard"
+)
+
+// checkAssetSize checks the size of the assets the user writes with the
+// transaction. If any is too large, the request is aborted and false is
+// returned.
+func checkAssetSize(c *gin.Context, user, txName string, args []string) bool {
+	err := sizeguard.Check(user, txName, args)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return false
+	}
+	return true
+}
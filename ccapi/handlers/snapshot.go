@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/mock"
+	protos "github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// snapshot is the state of the ledger a listing is paginated over, recorded
+// when its first page is read: the height of the channel and the timestamp
+// of its last transaction, taken from the ledger like the timestamps of the
+// asset history it is compared with
+type snapshot struct {
+	Height   uint64    `json:"height"`
+	Time     time.Time `json:"time"`
+	Bookmark string    `json:"bookmark,omitempty"`
+}
+
+// cursor encodes the snapshot and the bookmark of the next page
+func (s snapshot) cursor() string {
+	b, _ := json.Marshal(s)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func parseCursor(cursor string) (snapshot, error) {
+	var s snapshot
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(b, &s)
+	}
+	if err != nil || s.Height == 0 {
+		return s, errors.New("invalid cursor")
+	}
+	return s, nil
+}
+
+// newSnapshot records the current height of the channel and the timestamp
+// of the last transaction of its last block
+func newSnapshot(c *gin.Context, channelName string) (*snapshot, error) {
+	if mock.Enabled() {
+		height, err := mock.Height()
+		if err != nil {
+			return nil, err
+		}
+		lastTxTime, err := mock.LastTxTime()
+		if err != nil {
+			return nil, err
+		}
+		return &snapshot{Height: height, Time: lastTxTime}, nil
+	}
+
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetChainInfo", []string{channelName})
+	if err != nil {
+		return nil, err
+	}
+	var chainInfo protos.BlockchainInfo
+	err = proto.Unmarshal(result, &chainInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal chain info")
+	}
+	if chainInfo.Height == 0 {
+		return nil, errors.New("the channel has no blocks")
+	}
+
+	result, err = chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByNumber", []string{channelName, strconv.FormatUint(chainInfo.Height-1, 10)})
+	if err != nil {
+		return nil, err
+	}
+	var block protos.Block
+	err = proto.Unmarshal(result, &block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal block")
+	}
+	data := block.GetData().GetData()
+	if len(data) == 0 {
+		return nil, errors.New("the last block has no transactions")
+	}
+
+	var envelope protos.Envelope
+	var payload protos.Payload
+	var channelHeader protos.ChannelHeader
+	err = proto.Unmarshal(data[len(data)-1], &envelope)
+	if err == nil {
+		err = proto.Unmarshal(envelope.GetPayload(), &payload)
+	}
+	if err == nil {
+		err = proto.Unmarshal(payload.GetHeader().GetChannelHeader(), &channelHeader)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the last transaction")
+	}
+
+	return &snapshot{
+		Height: chainInfo.Height,
+		Time:   channelHeader.GetTimestamp().AsTime().UTC(),
+	}, nil
+}
+
+// txBlock returns the number of the block of the transaction
+func txBlock(c *gin.Context, channelName, txID string) (uint64, error) {
+	if mock.Enabled() {
+		return mock.TxBlock(txID)
+	}
+
+	result, err := chaincode.QueryGatewayFor(c, channelName, "qscc", "GetBlockByTxID", []string{channelName, txID})
+	if err != nil {
+		return 0, err
+	}
+	var block protos.Block
+	err = proto.Unmarshal(result, &block)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to unmarshal block")
+	}
+	return block.GetHeader().GetNumber(), nil
+}
+
+// asOf replaces the assets updated after the snapshot by the version they had
+// at that time, read from their history, and drops those created after it
+func (s snapshot) asOf(c *gin.Context, list []interface{}) []interface{} {
+	res := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		asset, ok := item.(map[string]interface{})
+		if !ok {
+			res = append(res, item)
+			continue
+		}
+
+		// Timestamps only have a precision of seconds, so assets updated in
+		// the second of the snapshot are checked against its height
+		lastUpdated, _ := asset["@lastUpdated"].(string)
+		updated, err := time.Parse(time.RFC3339, lastUpdated)
+		if err != nil || updated.Before(s.Time.Truncate(time.Second)) {
+			res = append(res, asset)
+			continue
+		}
+
+		args, _ := json.Marshal(map[string]interface{}{
+			"key": map[string]interface{}{
+				"@assetType": asset["@assetType"],
+				"@key":       asset["@key"],
+			},
+		})
+		result, err := chaincode.QueryGatewayFor(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAssetHistory", []string{string(args)})
+		if err != nil {
+			continue
+		}
+
+		var history []map[string]interface{}
+		if common.UnmarshalJSON(result, &history) != nil {
+			continue
+		}
+		version := s.versionAt(c, history)
+		if version == nil {
+			continue
+		}
+		delete(version, "_txId")
+		delete(version, "_isDelete")
+		delete(version, "_timestamp")
+		res = append(res, version)
+	}
+	return res
+}
+
+// versionAt returns the latest version of the history written up to the
+// snapshot, or nil if the asset did not exist. Peers return the newest
+// versions first, which are kept among those of the same second. Versions
+// written in the second of the snapshot are in it if their transaction is
+// in a block below its height.
+func (s snapshot) versionAt(c *gin.Context, history []map[string]interface{}) map[string]interface{} {
+	cutoff := s.Time.Truncate(time.Second)
+	var version map[string]interface{}
+	var versionTime time.Time
+	for _, entry := range history {
+		timestamp, _ := entry["_timestamp"].(string)
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil || t.After(cutoff) || (version != nil && !t.After(versionTime)) {
+			continue
+		}
+		if t.Equal(cutoff) {
+			txID, _ := entry["_txId"].(string)
+			number, err := txBlock(c, os.Getenv("CHANNEL"), txID)
+			if err != nil || number >= s.Height {
+				continue
+			}
+		}
+		version, versionTime = entry, t
+	}
+
+	if version == nil || version["_isDelete"] == true {
+		return nil
+	}
+	return version
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/format"
+)
+
+const (
+	streamPageSize          = 200
+	defaultStreamMaxResults = 10000
+)
+
+// streamMaxResults returns the most assets a streamed listing returns, set
+// by STREAM_MAX_RESULTS
+func streamMaxResults() int {
+	if n, err := strconv.Atoi(os.Getenv("STREAM_MAX_RESULTS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultStreamMaxResults
+}
+
+// searchPage is a page of the search transaction
+type searchPage struct {
+	Result   []interface{} `json:"result"`
+	Metadata struct {
+		Bookmark string `json:"bookmark"`
+	} `json:"metadata"`
+}
+
+// streamAssets pages the search query and writes the assets as they arrive
+// as newline delimited JSON, up to the limit query parameter or
+// STREAM_MAX_RESULTS. The last line holds the count of assets and, if more
+// remain, the bookmark to resume from. Errors after the first page can no
+// longer change the status, so they are written as a last @error line.
+func streamAssets(c *gin.Context, assetType string, query map[string]interface{}, opts *format.Options) {
+	if !authorize(c, "search") {
+		return
+	}
+
+	max := streamMaxResults()
+	if limit, ok := query["limit"].(int); ok && limit > 0 && limit < max {
+		max = limit
+	}
+	paths, err := expandPaths(c)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+
+	bookmark, _ := query["bookmark"].(string)
+	nextPage := func() (*searchPage, error) {
+		query["limit"] = streamPageSize
+		query["bookmark"] = bookmark
+		return searchQuery(c, query)
+	}
+
+	// The first page is read before the response starts, so that invalid
+	// queries still fail with their status
+	page, err := nextPage()
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	writeError := func(err error) {
+		apiErr, status := common.ParseError(err)
+		encoder.Encode(gin.H{"@error": gin.H{
+			"status": status,
+			"error":  apiErr.Error(),
+		}})
+	}
+
+	e := newExpander(c)
+	count := 0
+	truncated := false
+	for {
+		for _, item := range page.Result {
+			if count == max {
+				truncated = true
+				break
+			}
+
+			if asset, ok := item.(map[string]interface{}); ok {
+				decryptAsset(assetType, asset)
+				if len(paths) > 0 {
+					err := e.expand(asset, paths, make(map[string]bool))
+					if err != nil {
+						writeError(err)
+						return
+					}
+				}
+				addLinks(c, assetType, asset)
+				formatAsset(c, opts, assetType, asset)
+			}
+			item = selectFields(c, "readAsset", item)
+
+			err := encoder.Encode(item)
+			if err != nil {
+				// The client is gone
+				return
+			}
+			count++
+		}
+		c.Writer.Flush()
+
+		last := len(page.Result) < streamPageSize || page.Metadata.Bookmark == "" || page.Metadata.Bookmark == bookmark
+		if truncated || last || c.Request.Context().Err() != nil {
+			break
+		}
+		bookmark = page.Metadata.Bookmark
+
+		page, err = nextPage()
+		if err != nil {
+			writeError(err)
+			return
+		}
+	}
+
+	metadata := gin.H{
+		"count":     count,
+		"truncated": truncated,
+	}
+	if truncated {
+		// Resuming from the bookmark of the page repeats its assets
+		// already sent, which clients skip by key
+		metadata["bookmark"] = bookmark
+	}
+	encoder.Encode(gin.H{"@metadata": metadata})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// ListTokens searches the tokens of the chaincode
+func ListTokens(c *gin.Context) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"@assetType": "token",
+			},
+		},
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "search", args)
+}
+
+// ReadToken reads the token with the symbol in the path
+func ReadToken(c *gin.Context) {
+	args, _ := json.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"@assetType": "token",
+			"symbol":     c.Param("symbol"),
+		},
+	})
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "readAsset", args)
+}
+
+// MintToken mints the amount in the request body to the organization of the
+// request, creating the token on its first mint
+func MintToken(c *gin.Context) {
+	tokenTx(c, "mint")
+}
+
+// TransferToken transfers the amount in the request body from the
+// organization of the request to the account in the to field
+func TransferToken(c *gin.Context) {
+	tokenTx(c, "transfer")
+}
+
+// tokenTx submits the token transaction with the request body and the symbol
+// in the path as arguments
+func tokenTx(c *gin.Context, txName string) {
+	req := make(map[string]interface{})
+	err := c.BindJSON(&req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return
+	}
+	req["symbol"] = c.Param("symbol")
+
+	submitGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), txName, req)
+}
+
+// TokenBalance returns the balance of the account query parameter, by
+// default the organization of the request
+func TokenBalance(c *gin.Context) {
+	req := map[string]interface{}{
+		"symbol": c.Param("symbol"),
+	}
+	if account := c.Query("account"); account != "" {
+		req["account"] = account
+	}
+
+	args, _ := json.Marshal(req)
+	evaluateGateway(c, os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "balanceOf", args)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/tokensdk"
+)
+
+// TokenSDKStatus lists the nodes of the Token SDK application and whether
+// they are healthy
+func TokenSDKStatus(c *gin.Context) {
+	nodes := make([]gin.H, 0)
+	for _, node := range tokensdk.Nodes() {
+		status := gin.H{
+			"name":    node.Name,
+			"role":    node.Role,
+			"healthy": true,
+		}
+		if err := tokensdk.Health(node); err != nil {
+			status["healthy"] = false
+			status["error"] = err.Error()
+		}
+		nodes = append(nodes, status)
+	}
+
+	common.Respond(c, gin.H{"nodes": nodes}, http.StatusOK, nil)
+}
+
+// IssueTokens issues the amount in the request body to the account of the
+// counterparty, from the issuer node
+func IssueTokens(c *gin.Context) {
+	var req tokensdk.IssueRequest
+	if !bindTokenRequest(c, "tokenSDK.issue", &req) {
+		return
+	}
+
+	res, err := tokensdk.Issue(req)
+	respondToken(c, res, err)
+}
+
+// TransferTokens transfers the amount in the request body from the account
+// in the path to the counterparty
+func TransferTokens(c *gin.Context) {
+	var req tokensdk.TransferRequest
+	if !bindTokenRequest(c, "tokenSDK.transfer", &req) {
+		return
+	}
+
+	res, err := tokensdk.Transfer(c.Param("node"), c.Param("account"), req)
+	respondToken(c, res, err)
+}
+
+// RedeemTokens redeems the amount in the request body from the account in
+// the path
+func RedeemTokens(c *gin.Context) {
+	var req tokensdk.RedeemRequest
+	if !bindTokenRequest(c, "tokenSDK.redeem", &req) {
+		return
+	}
+
+	res, err := tokensdk.Redeem(c.Param("node"), c.Param("account"), req)
+	respondToken(c, res, err)
+}
+
+// TokenSDKBalance returns the balances of the account, optionally of the
+// token type in the code query parameter
+func TokenSDKBalance(c *gin.Context) {
+	if !authorize(c, "tokenSDK.balance") {
+		return
+	}
+
+	res, err := tokensdk.Balance(c.Param("node"), c.Param("account"), c.Query("code"))
+	respondToken(c, res, err)
+}
+
+// TokenSDKTransactions returns the transactions of the account
+func TokenSDKTransactions(c *gin.Context) {
+	if !authorize(c, "tokenSDK.transactions") {
+		return
+	}
+
+	res, err := tokensdk.Transactions(c.Param("node"), c.Param("account"))
+	respondToken(c, res, err)
+}
+
+// bindTokenRequest authorizes a token operation, which writes to the ledger,
+// and reads its body. On failure the request is aborted and false is
+// returned.
+func bindTokenRequest(c *gin.Context, operation string, req interface{}) bool {
+	if !authorize(c, operation) {
+		return false
+	}
+	if !checkWritable(c) {
+		return false
+	}
+
+	err := c.ShouldBindJSON(req)
+	if err != nil {
+		common.Abort(c, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// respondToken writes the response of a token node
+func respondToken(c *gin.Context, res interface{}, err error) {
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return
+	}
+	common.Respond(c, res, http.StatusOK, nil)
+}
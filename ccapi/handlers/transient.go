@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// defaultTransientMaxSize is the largest value of a transient data key by
+// default, in bytes
+const defaultTransientMaxSize = 1 << 20
+
+// getTransientMaxSize returns the largest value of a transient data key, from
+// TRANSIENT_MAX_SIZE
+func getTransientMaxSize() int {
+	if n, err := strconv.Atoi(os.Getenv("TRANSIENT_MAX_SIZE")); err == nil && n > 0 {
+		return n
+	}
+	return defaultTransientMaxSize
+}
+
+// extractTransient removes the transient data from the request body and
+// returns the transient map of the transaction:
+//   - the ~ prefixed properties, as the JSON object cc-tools reads from the
+//     @request key
+//   - the values of the @transient object, as JSON
+//   - the values of the @transientBase64 object, decoded, for binary data
+//
+// Values over the size limit are refused with a 413 error.
+func extractTransient(req map[string]interface{}) (map[string][]byte, error) {
+	request := make(map[string]interface{})
+	for key, value := range req {
+		if strings.HasPrefix(key, "~") {
+			request[strings.TrimPrefix(key, "~")] = value
+			delete(req, key)
+		}
+	}
+
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusBadRequest, "failed to marshal the transient request")
+	}
+	transient := map[string][]byte{chaincode.TransientKey: requestBytes}
+
+	if value, ok := req["@transient"]; ok {
+		delete(req, "@transient")
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, common.NewAPIError(http.StatusBadRequest, "@transient must be an object")
+		}
+		for key, value := range values {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, common.NewAPIError(http.StatusBadRequest, fmt.Sprintf("failed to marshal the transient value of '%s'", key))
+			}
+			err = addTransient(transient, key, data)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if value, ok := req["@transientBase64"]; ok {
+		delete(req, "@transientBase64")
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, common.NewAPIError(http.StatusBadRequest, "@transientBase64 must be an object")
+		}
+		for key, value := range values {
+			encoded, ok := value.(string)
+			if !ok {
+				return nil, common.NewAPIError(http.StatusBadRequest, fmt.Sprintf("the transient value of '%s' must be a base64 string", key))
+			}
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, common.NewAPIError(http.StatusBadRequest, fmt.Sprintf("the transient value of '%s' must be a base64 string", key))
+			}
+			err = addTransient(transient, key, data)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = checkTransientSize(transient)
+	if err != nil {
+		return nil, err
+	}
+	return transient, nil
+}
+
+// addTransient adds a value to the transient map, refusing empty keys and
+// keys set twice, such as the key of the request
+func addTransient(transient map[string][]byte, key string, value []byte) error {
+	if key == "" {
+		return common.NewAPIError(http.StatusBadRequest, "transient data keys must not be empty")
+	}
+	if _, ok := transient[key]; ok {
+		return common.NewAPIError(http.StatusBadRequest, fmt.Sprintf("the transient data key '%s' is set twice", key))
+	}
+	transient[key] = value
+	return nil
+}
+
+// checkTransientSize returns a 413 error listing the keys of the transient
+// map over the size limit
+func checkTransientSize(transient map[string][]byte) error {
+	limit := getTransientMaxSize()
+
+	oversized := make([]map[string]interface{}, 0)
+	for key, value := range transient {
+		if len(value) > limit {
+			oversized = append(oversized, map[string]interface{}{
+				"key":  key,
+				"size": len(value),
+			})
+		}
+	}
+	if len(oversized) == 0 {
+		return nil
+	}
+
+	sort.Slice(oversized, func(i, j int) bool {
+		return oversized[i]["key"].(string) < oversized[j]["key"].(string)
+	})
+	return &common.APIError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    common.ErrCodePayloadTooLarge,
+		Message: fmt.Sprintf("transient data values must not be larger than %d bytes", limit),
+		Details: map[string]interface{}{
+			"limit":     limit,
+			"oversized": oversized,
+		},
+	}
+}
+
+// hasTransient reports whether the transient map holds data besides an
+// empty request
+func hasTransient(transient map[string][]byte) bool {
+	for key, value := range transient {
+		if key != chaincode.TransientKey || string(value) != "{}" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// TxRoute is a transaction served on a custom method and path, declared in
+// the routes configuration file
+type TxRoute struct {
+	Tx        string   `json:"tx"`
+	Method    string   `json:"method"`
+	Path      string   `json:"path"`
+	ReadOnly  bool     `json:"readOnly"`
+	Channel   string   `json:"channel,omitempty"`
+	Chaincode string   `json:"chaincode,omitempty"`
+	Keys      []string `json:"keys,omitempty"`
+	Params    []string `json:"params,omitempty"`
+}
+
+// ConfiguredTx evaluates or submits the transaction of the route. Path
+// parameters, and the query parameters the route declares for requests
+// without body, are added to the transaction arguments.
+func ConfiguredTx(route TxRoute) gin.HandlerFunc {
+	channelName := route.Channel
+	if channelName == "" {
+		channelName = os.Getenv("CHANNEL")
+	}
+	chaincodeName := route.Chaincode
+	if chaincodeName == "" {
+		chaincodeName = os.Getenv("CCNAME")
+	}
+
+	return func(c *gin.Context) {
+		req, err := txRouteArgs(c, route)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if !route.ReadOnly {
+			submitGateway(c, channelName, chaincodeName, route.Tx, req)
+			return
+		}
+
+		args, err := json.Marshal(req)
+		if err != nil {
+			common.Abort(c, http.StatusInternalServerError, err)
+			return
+		}
+		evaluateGateway(c, channelName, chaincodeName, route.Tx, args)
+	}
+}
+
+// txRouteArgs assembles the transaction arguments of the request. Query
+// parameters are only taken when declared in the route params, so the ones
+// of other middlewares such as fields or limit are not sent, and parsed as
+// JSON when possible. Path values are always strings, and those listed in
+// the route keys are sent as asset keys.
+func txRouteArgs(c *gin.Context, route TxRoute) (map[string]interface{}, error) {
+	req := make(map[string]interface{})
+
+	if c.Request.ContentLength != 0 && c.Request.Method != http.MethodGet {
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			return nil, err
+		}
+	} else if request := c.Query("@request"); request != "" {
+		args, err := base64.StdEncoding.DecodeString(request)
+		if err != nil {
+			return nil, errors.New("the @request query parameter must be a base64-encoded JSON object")
+		}
+		err = json.Unmarshal(args, &req)
+		if err != nil {
+			return nil, errors.New("the @request query parameter must be a base64-encoded JSON object")
+		}
+	} else {
+		for _, name := range route.Params {
+			if value, ok := c.GetQuery(name); ok {
+				req[name] = paramValue(value)
+			}
+		}
+	}
+
+	for _, param := range c.Params {
+		req[param.Key] = param.Value
+	}
+
+	for _, name := range route.Keys {
+		if key, ok := req[name].(string); ok {
+			req[name] = map[string]interface{}{
+				"@key": key,
+			}
+		}
+	}
+
+	return req, nil
+}
+
+func paramValue(value string) interface{} {
+	var v interface{}
+	if err := common.UnmarshalJSON([]byte(value), &v); err != nil {
+		return value
+	}
+	return v
+}
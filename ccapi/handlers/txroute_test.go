@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTxRouteArgs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	route := TxRoute{
+		Tx:     "getBooksByAuthor",
+		Path:   "/authors/:authorName/books",
+		Params: []string{"limit"},
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/authors/007/books?limit=10&fields=title&@format=csv", nil)
+	c.Params = gin.Params{{Key: "authorName", Value: "007"}}
+
+	// Only the declared query parameters are sent, and path values stay
+	// strings
+	req, err := txRouteArgs(c, route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"authorName": "007",
+		"limit":      json.Number("10"),
+	}
+	if !reflect.DeepEqual(req, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, req)
+	}
+
+	// Keys are sent as asset keys
+	route = TxRoute{
+		Tx:   "getNumberOfBooksFromLibrary",
+		Path: "/libraries/:library/books/count",
+		Keys: []string{"library"},
+	}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/libraries/library:1/books/count?limit=10", nil)
+	c.Params = gin.Params{{Key: "library", Value: "library:1"}}
+	req, err = txRouteArgs(c, route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]interface{}{
+		"library": map[string]interface{}{"@key": "library:1"},
+	}
+	if !reflect.DeepEqual(req, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, req)
+	}
+}
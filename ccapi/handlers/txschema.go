@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/txschema"
+)
+
+// checkBody validates the request body against the schema declared for the
+// transaction, if any. If it does not match, the request is aborted and
+// false is returned.
+func checkBody(c *gin.Context, txName string, req map[string]interface{}) bool {
+	err := txschema.Check(txName, req)
+	if err != nil {
+		err, status := common.ParseError(err)
+		common.Abort(c, status, err)
+		return false
+	}
+	return true
+}
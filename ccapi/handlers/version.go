@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/buildinfo"
+	"github.com/hyperledger-labs/ccapi/compat"
+)
+
+// Version returns the build of the running binary, with the outcome of the
+// last chaincode version check, so a rollout can be verified on every
+// replica
+func Version(c *gin.Context) {
+	res := gin.H{"build": buildinfo.Get()}
+	if compat.Enabled() {
+		res["chaincodeVersion"] = compat.Get()
+	}
+	c.JSON(http.StatusOK, res)
+}
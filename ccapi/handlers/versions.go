@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/versioning"
+)
+
+// APIVersions returns the current API version, the one served to clients
+// that do not choose one and the older versions still supported
+func APIVersions(c *gin.Context) {
+	cfg, err := versioning.GetConfig()
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	if cfg == nil {
+		common.Abort(c, http.StatusNotFound, common.NewAPIError(http.StatusNotFound, "API versioning is not configured"))
+		return
+	}
+
+	versions := make([]gin.H, 0)
+	for _, name := range cfg.Names() {
+		version := gin.H{"name": name}
+		if v, ok := cfg.Versions[name]; ok && v.Sunset != "" {
+			version["sunset"] = v.Sunset
+		}
+		versions = append(versions, version)
+	}
+
+	common.Respond(c, gin.H{
+		"current":  cfg.Current,
+		"default":  cfg.Default,
+		"versions": versions,
+	}, http.StatusOK, nil)
+}
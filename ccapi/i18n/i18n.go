@@ -0,0 +1,107 @@
+// Package i18n translates API error and validation messages to the
+// language requested by the client
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"log"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var localesFS embed.FS
+
+// Supported languages, the first one is the language of the original messages
+var supported = []language.Tag{
+	language.English,
+	language.BrazilianPortuguese,
+}
+
+var matcher = language.NewMatcher(supported)
+
+type pattern struct {
+	Match   string `json:"match"`
+	Message string `json:"message"`
+
+	reg *regexp.Regexp
+}
+
+type catalog struct {
+	Messages map[string]string `json:"messages"`
+	Patterns []*pattern        `json:"patterns"`
+}
+
+var catalogs = make(map[string]*catalog)
+
+func init() {
+	for _, tag := range supported[1:] {
+		data, err := localesFS.ReadFile("locales/" + tag.String() + ".json")
+		if err != nil {
+			log.Println("missing locale file: ", tag)
+			continue
+		}
+
+		cat := &catalog{}
+		err = json.Unmarshal(data, cat)
+		if err != nil {
+			log.Println("invalid locale file: ", tag, err)
+			continue
+		}
+
+		for _, p := range cat.Patterns {
+			p.reg = regexp.MustCompile("^" + p.Match + "$")
+		}
+
+		catalogs[tag.String()] = cat
+	}
+}
+
+// Negotiate returns the supported language that best matches an
+// Accept-Language header
+func Negotiate(acceptLanguage string) string {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := matcher.Match(tags...)
+
+	return supported[index].String()
+}
+
+// Translate translates a message to the given language. Error messages are
+// chains of "context: cause" segments, each one is translated on its own and
+// unknown segments are kept as is
+func Translate(lang, msg string) string {
+	cat, ok := catalogs[lang]
+	if !ok {
+		return msg
+	}
+
+	if translated, ok := cat.translate(msg); ok {
+		return translated
+	}
+
+	segments := strings.Split(msg, ": ")
+	for i, segment := range segments {
+		if translated, ok := cat.translate(segment); ok {
+			segments[i] = translated
+		}
+	}
+
+	return strings.Join(segments, ": ")
+}
+
+func (cat *catalog) translate(msg string) (string, bool) {
+	if translated, ok := cat.Messages[msg]; ok {
+		return translated, true
+	}
+
+	for _, p := range cat.Patterns {
+		if p.reg.MatchString(msg) {
+			return p.reg.ReplaceAllString(msg, p.Message), true
+		}
+	}
+
+	return msg, false
+}
@@ -0,0 +1,275 @@
+// Package jsonld describes the assets of the chaincode as linked data. A
+// JSON-LD context (https://www.w3.org/TR/json-ld11/) is generated from the
+// asset schema, with a type-scoped context for each asset type, and
+// responses are returned as JSON-LD to clients accepting
+// application/ld+json: assets and references get an @id and an @type, and
+// the metadata set by cc-tools is mapped to terms of the CCAPI vocabulary.
+//
+// Terms are named in the vocabulary in JSONLD_VOCAB, default
+// urn:ccapi:schema:{ccName}:, and can be mapped to other vocabularies, such
+// as schema.org, with the JSON file in JSONLD_TERMS, e.g.
+// {"book": "https://schema.org/Book", "book.title": "https://schema.org/name"}.
+package jsonld
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/did"
+	"github.com/pkg/errors"
+)
+
+// MIMEJSONLD is the media type of JSON-LD documents
+const MIMEJSONLD = "application/ld+json"
+
+// Namespace is the vocabulary of the asset metadata
+const Namespace = "https://github.com/hyperledger-labs/ccapi/vocab#"
+
+// ContextPath is the path the context is served at
+const ContextPath = "/api/context.jsonld"
+
+type schemaProp struct {
+	Tag      string `json:"tag"`
+	DataType string `json:"dataType"`
+}
+
+type schemaAssetType struct {
+	Tag   string       `json:"tag"`
+	Props []schemaProp `json:"props"`
+}
+
+var (
+	contextMutex sync.Mutex
+	context      map[string]interface{}
+)
+
+func init() {
+	// Generate the context again once the chaincode is upgraded
+	chaincode.OnUpgrade(func(channelName, chaincodeName string) {
+		if channelName != os.Getenv("CHANNEL") || chaincodeName != os.Getenv("CCNAME") {
+			return
+		}
+		contextMutex.Lock()
+		context = nil
+		contextMutex.Unlock()
+	})
+}
+
+// Context returns the JSON-LD context of the assets, which is kept in
+// memory after the first read until the chaincode is upgraded
+func Context() (map[string]interface{}, error) {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+
+	if context != nil {
+		return context, nil
+	}
+
+	assetTypes, dataTypes, err := readSchema()
+	if err != nil {
+		return nil, err
+	}
+	terms, err := readTerms()
+	if err != nil {
+		return nil, err
+	}
+	context = buildContext(assetTypes, dataTypes, terms)
+	return context, nil
+}
+
+// vocab returns the vocabulary of the asset types and properties
+func vocab() string {
+	if v := os.Getenv("JSONLD_VOCAB"); v != "" {
+		return v
+	}
+	return "urn:ccapi:schema:" + os.Getenv("CCNAME") + ":"
+}
+
+// readTerms reads the IRIs of the terms mapped to other vocabularies
+func readTerms() (map[string]string, error) {
+	terms := make(map[string]string)
+	path := os.Getenv("JSONLD_TERMS")
+	if path == "" {
+		return terms, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read JSON-LD terms")
+	}
+	err = json.Unmarshal(data, &terms)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal JSON-LD terms")
+	}
+	return terms, nil
+}
+
+// readSchema reads the asset types with their properties and the accepted
+// formats of the data types from the chaincode
+func readSchema() ([]schemaAssetType, map[string][]string, error) {
+	user := os.Getenv("USER")
+	query := func(txName string, args interface{}, v interface{}) error {
+		argsBytes, _ := json.Marshal(args)
+		res, err := chaincode.QueryGateway(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), txName, user, []string{string(argsBytes)})
+		if err != nil {
+			return errors.Wrap(err, "failed to read "+txName)
+		}
+		return json.Unmarshal(res, v)
+	}
+
+	var list []schemaAssetType
+	err := query("getSchema", map[string]interface{}{}, &list)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range list {
+		err = query("getSchema", map[string]interface{}{"assetType": list[i].Tag}, &list[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var dataTypes map[string]struct {
+		AcceptedFormats []string `json:"acceptedFormats"`
+	}
+	err = query("getDataTypes", map[string]interface{}{}, &dataTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	formats := make(map[string][]string)
+	for name, dataType := range dataTypes {
+		formats[name] = dataType.AcceptedFormats
+	}
+	return list, formats, nil
+}
+
+// buildContext generates a term for each asset type, with a context scoped
+// to the type defining its properties, and the terms of the metadata
+func buildContext(assetTypes []schemaAssetType, dataTypes map[string][]string, terms map[string]string) map[string]interface{} {
+	iri := func(term string) string {
+		if v, ok := terms[term]; ok {
+			return v
+		}
+		return vocab() + term[strings.LastIndex(term, ".")+1:]
+	}
+
+	ctx := map[string]interface{}{
+		"@version":    1.1,
+		"xsd":         "http://www.w3.org/2001/XMLSchema#",
+		"ccapi":       Namespace,
+		"lastTouchBy": map[string]interface{}{"@id": "ccapi:lastTouchBy", "@type": "@id"},
+		"lastTx":      "ccapi:lastTx",
+		"lastUpdated": map[string]interface{}{"@id": "ccapi:lastUpdated", "@type": "xsd:dateTime"},
+		// Results of searches and lists of assets
+		"result": map[string]interface{}{"@id": "ccapi:result", "@container": "@set"},
+	}
+	for _, assetType := range assetTypes {
+		scoped := make(map[string]interface{})
+		for _, prop := range assetType.Props {
+			definition := map[string]interface{}{
+				"@id": iri(assetType.Tag + "." + prop.Tag),
+			}
+			dataType := prop.DataType
+			if strings.HasPrefix(dataType, "[]") {
+				definition["@container"] = "@set"
+				dataType = strings.TrimPrefix(dataType, "[]")
+			}
+			if t := valueType(dataType, dataTypes); t != "" {
+				definition["@type"] = t
+			}
+			scoped[prop.Tag] = definition
+		}
+		ctx[assetType.Tag] = map[string]interface{}{
+			"@id":      iri(assetType.Tag),
+			"@context": scoped,
+		}
+	}
+	return map[string]interface{}{"@context": ctx}
+}
+
+// valueType maps a cc-tools data type to the type of the values of a term.
+// Custom data types take the type of their first accepted format, and
+// references are node objects, which need no type.
+func valueType(dataType string, dataTypes map[string][]string) string {
+	switch {
+	case strings.HasPrefix(dataType, "->"):
+		return ""
+	case dataType == "number":
+		return "xsd:decimal"
+	case dataType == "integer":
+		return "xsd:integer"
+	case dataType == "datetime":
+		return "xsd:dateTime"
+	case dataType == "@object":
+		return "@json"
+	case dataType == "string", dataType == "boolean":
+		return ""
+	}
+	if formats := dataTypes[dataType]; len(formats) > 0 && formats[0] != dataType {
+		return valueType(formats[0], dataTypes)
+	}
+	return ""
+}
+
+// Document returns a response body as a JSON-LD document using the context
+// at contextURL. Arrays are returned in the @graph of the document.
+func Document(v interface{}, channel, contextURL string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		doc := link(v, channel).(map[string]interface{})
+		doc["@context"] = contextURL
+		return doc
+	case []interface{}:
+		return map[string]interface{}{
+			"@context": contextURL,
+			"@graph":   link(v, channel),
+		}
+	}
+	return v
+}
+
+// link adds an @id and an @type to the assets and references in v, and
+// maps their metadata to terms of the context, keeping the metadata of
+// cc-tools, which JSON-LD processors ignore, for JSON clients
+func link(v interface{}, channel string) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = link(v[i], channel)
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = link(v[k], channel)
+		}
+		key, ok := v["@key"].(string)
+		if !ok {
+			return v
+		}
+		v["@id"] = "urn:ccapi:asset:" + key
+		if assetType, ok := v["@assetType"].(string); ok {
+			v["@type"] = assetType
+		}
+		setTerm(v, "lastTouchBy", v["@lastTouchBy"], func(msp string) interface{} {
+			return did.ID(channel, msp)
+		})
+		setTerm(v, "lastTx", v["@lastTx"], nil)
+		setTerm(v, "lastUpdated", v["@lastUpdated"], nil)
+	}
+	return v
+}
+
+// setTerm sets the term of a metadata value, unless the asset has a
+// property with its name
+func setTerm(asset map[string]interface{}, term string, value interface{}, convert func(string) interface{}) {
+	s, ok := value.(string)
+	if _, exists := asset[term]; !ok || s == "" || exists {
+		return
+	}
+	if convert != nil {
+		asset[term] = convert(s)
+		return
+	}
+	asset[term] = s
+}
@@ -0,0 +1,119 @@
+package jsonld
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/features"
+)
+
+// Middleware returns the JSON responses of the group as JSON-LD to clients
+// accepting application/ld+json, and links the context from the others,
+// while the jsonLD feature is enabled
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !features.Enabled("jsonLD") {
+			c.Next()
+			return
+		}
+
+		contextURL := ContextURL(c)
+		if c.NegotiateFormat(gin.MIMEJSON, MIMEJSONLD) != MIMEJSONLD {
+			c.Header("Link", "<"+contextURL+`>; rel="http://www.w3.org/ns/json-ld#context"; type="`+MIMEJSONLD+`"`)
+			c.Next()
+			return
+		}
+
+		writer := &documentWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.body == nil {
+			return
+		}
+		body := writer.body.Bytes()
+		if writer.Status() < http.StatusBadRequest {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			var v interface{}
+			err := decoder.Decode(&v)
+			if err != nil {
+				log.Println("error returning JSON-LD response: ", err)
+			} else {
+				channel := c.Param("channelName")
+				if channel == "" {
+					channel = os.Getenv("CHANNEL")
+				}
+				body, _ = json.Marshal(Document(v, channel, contextURL))
+				c.Writer.Header().Set("Content-Type", MIMEJSONLD)
+			}
+		}
+		c.Writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		c.Writer.WriteHeaderNow()
+		c.Writer.Write(body)
+	}
+}
+
+// ContextURL returns the URL of the context, under JSONLD_BASE_URL or the
+// host of the request
+func ContextURL(c *gin.Context) string {
+	base := os.Getenv("JSONLD_BASE_URL")
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return strings.TrimSuffix(base, "/") + ContextPath
+}
+
+// documentWriter holds JSON responses so they can be returned as JSON-LD.
+// Other responses, such as streams, are written as they are.
+type documentWriter struct {
+	gin.ResponseWriter
+	body        *bytes.Buffer
+	passThrough bool
+}
+
+func (w *documentWriter) buffer() bool {
+	if w.body == nil && !w.passThrough {
+		mediaType, _, _ := mime.ParseMediaType(w.ResponseWriter.Header().Get("Content-Type"))
+		if mediaType == gin.MIMEJSON {
+			w.body = &bytes.Buffer{}
+		} else {
+			w.passThrough = true
+		}
+	}
+	return w.body != nil
+}
+
+func (w *documentWriter) Write(b []byte) (int, error) {
+	if w.buffer() {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *documentWriter) WriteString(s string) (int, error) {
+	if w.buffer() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// WriteHeaderNow is deferred for buffered responses, whose length is only
+// known once converted
+func (w *documentWriter) WriteHeaderNow() {
+	if !w.buffer() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,310 @@
+// Package jsonpatch creates and applies JSON Patch (RFC 6902) documents on
+// decoded JSON values
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTestFailed is returned when a test operation does not match
+var ErrTestFailed = errors.New("test operation failed")
+
+// Operation is a single JSON Patch operation
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON writes the value of add, replace and test operations even if
+// it is null, false, zero or empty, and leaves it out of the others
+func (o Operation) MarshalJSON() ([]byte, error) {
+	switch o.Op {
+	case "add", "replace", "test":
+		type operation Operation
+		return json.Marshal(operation(o))
+	}
+	return json.Marshal(struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+		From string `json:"from,omitempty"`
+	}{o.Op, o.Path, o.From})
+}
+
+// Diff returns the operations that transform a into b. Objects are compared
+// member by member and other values, including arrays, are replaced whole.
+func Diff(a, b interface{}) []Operation {
+	return diff("", a, b, make([]Operation, 0))
+}
+
+func diff(path string, a, b interface{}, ops []Operation) []Operation {
+	am, aIsMap := a.(map[string]interface{})
+	bm, bIsMap := b.(map[string]interface{})
+	if !aIsMap || !bIsMap {
+		if !reflect.DeepEqual(a, b) {
+			ops = append(ops, Operation{Op: "replace", Path: path, Value: b})
+		}
+		return ops
+	}
+
+	for _, key := range sortedKeys(am) {
+		if _, ok := bm[key]; !ok {
+			ops = append(ops, Operation{Op: "remove", Path: path + "/" + escape(key)})
+		}
+	}
+	for _, key := range sortedKeys(bm) {
+		av, ok := am[key]
+		if !ok {
+			ops = append(ops, Operation{Op: "add", Path: path + "/" + escape(key), Value: bm[key]})
+			continue
+		}
+		ops = diff(path+"/"+escape(key), av, bm[key], ops)
+	}
+	return ops
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escape encodes a member name as a JSON Pointer reference token
+func escape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+// Apply applies the operations in order to a copy of the document
+func Apply(doc interface{}, ops []Operation) (interface{}, error) {
+	doc = deepCopy(doc)
+
+	var err error
+	for i, op := range ops {
+		switch op.Op {
+		case "add":
+			doc, err = add(doc, op.Path, deepCopy(op.Value))
+		case "remove":
+			doc, _, err = remove(doc, op.Path)
+		case "replace":
+			doc, _, err = remove(doc, op.Path)
+			if err == nil {
+				doc, err = add(doc, op.Path, deepCopy(op.Value))
+			}
+		case "move":
+			var value interface{}
+			doc, value, err = remove(doc, op.From)
+			if err == nil {
+				doc, err = add(doc, op.Path, value)
+			}
+		case "copy":
+			var value interface{}
+			value, err = get(doc, op.From)
+			if err == nil {
+				doc, err = add(doc, op.Path, deepCopy(value))
+			}
+		case "test":
+			var value interface{}
+			value, err = get(doc, op.Path)
+			if err == nil && !reflect.DeepEqual(value, op.Value) {
+				err = errors.Wrap(ErrTestFailed, op.Path)
+			}
+		default:
+			err = errors.Errorf("unknown operation '%s'", op.Op)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "operation %d", i)
+		}
+	}
+	return doc, nil
+}
+
+// split parses a JSON Pointer into its reference tokens
+func split(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, errors.Errorf("invalid path '%s'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = unescape(t)
+	}
+	return tokens, nil
+}
+
+// index parses an array index, where "-" is the end of the array when allowed
+func index(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && token[0] == '0') {
+		return 0, errors.Errorf("invalid array index '%s'", token)
+	}
+	if i > length || (i == length && !allowEnd) {
+		return 0, errors.Errorf("array index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+func get(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := split(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, t := range tokens {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			value, ok := v[t]
+			if !ok {
+				return nil, errors.Errorf("path %s not found", pointer)
+			}
+			current = value
+		case []interface{}:
+			i, err := index(t, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			current = v[i]
+		default:
+			return nil, errors.Errorf("path %s not found", pointer)
+		}
+	}
+	return current, nil
+}
+
+// update replaces the value at the pointer by the result of fn, which
+// receives the parent container and the last reference token
+func update(doc interface{}, tokens []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		child, ok := v[tokens[0]]
+		if !ok {
+			return nil, errors.Errorf("member '%s' not found", tokens[0])
+		}
+		updated, err := update(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		v[tokens[0]] = updated
+		return v, nil
+	case []interface{}:
+		i, err := index(tokens[0], len(v), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(v[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = updated
+		return v, nil
+	default:
+		return nil, errors.Errorf("cannot traverse '%s'", tokens[0])
+	}
+}
+
+func add(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := split(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return update(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = value
+			return p, nil
+		case []interface{}:
+			i, err := index(token, len(p), true)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		default:
+			return nil, errors.Errorf("cannot add to path %s", pointer)
+		}
+	})
+}
+
+func remove(doc interface{}, pointer string) (interface{}, interface{}, error) {
+	tokens, err := split(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	var removed interface{}
+	doc, err = update(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			value, ok := p[token]
+			if !ok {
+				return nil, errors.Errorf("path %s not found", pointer)
+			}
+			removed = value
+			delete(p, token)
+			return p, nil
+		case []interface{}:
+			i, err := index(token, len(p), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = p[i]
+			return append(p[:i], p[i+1:]...), nil
+		default:
+			return nil, errors.Errorf("path %s not found", pointer)
+		}
+	})
+	return doc, removed, err
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			c[k] = deepCopy(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(value))
+		for i, item := range value {
+			c[i] = deepCopy(item)
+		}
+		return c
+	default:
+		return v
+	}
+}
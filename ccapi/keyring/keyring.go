@@ -0,0 +1,275 @@
+// Package keyring keeps the data keys used to encrypt personal data fields
+// before they are written to the ledger, one key per data subject. Deleting
+// the key of a subject shreds their data: the ciphertexts stay on the
+// ledger, which cannot forget them, but can no longer be decrypted.
+//
+// The keyring is a file of the replica, not kept in the shared state
+// backend. Replicas may share it on a volume, as changes to the file are
+// read again, but only one of them should write assets with encrypted
+// fields, since keys created at the same time by two replicas overwrite
+// each other.
+//
+// Encrypted values are strings of the form enc:v1:{subject}:{base64}, with
+// the AES-256-GCM nonce followed by the ciphertext.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const prefix = "enc:v1:"
+
+// ErrShredded is returned for data of subjects whose key was deleted
+var ErrShredded = errors.New("the key of the data subject was deleted")
+
+// entry holds the key of a subject, or when it was deleted
+type entry struct {
+	Key       []byte     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+var (
+	mutex sync.Mutex
+
+	// cache holds the entries of the keyring file as of its modification
+	// time and size, so it is only read again when it changes
+	cache     map[string]*entry
+	cacheTime time.Time
+	cacheSize int64
+)
+
+// getPath returns the keyring file from KEYRING_PATH, which must be kept
+// off the ledger and backed up separately
+func getPath() string {
+	if path := os.Getenv("KEYRING_PATH"); path != "" {
+		return path
+	}
+	return "keyring.json"
+}
+
+// load returns the entries of the keyring file, read again only if the
+// file changed since it was last read or written. The caller must hold the
+// mutex.
+func load() (map[string]*entry, error) {
+	info, err := os.Stat(getPath())
+	if os.IsNotExist(err) {
+		cache = nil
+		return make(map[string]*entry), nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read keyring")
+	}
+	if cache != nil && info.ModTime().Equal(cacheTime) && info.Size() == cacheSize {
+		return cache, nil
+	}
+
+	data, err := os.ReadFile(getPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read keyring")
+	}
+	entries := make(map[string]*entry)
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal keyring")
+	}
+
+	cache, cacheTime, cacheSize = entries, info.ModTime(), info.Size()
+	return entries, nil
+}
+
+// save writes the keyring file and caches its entries. On failure the
+// cache is dropped, as the entries were changed without being written. The
+// caller must hold the mutex.
+func save(entries map[string]*entry) error {
+	cache = nil
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal keyring")
+	}
+
+	// Replace the file at once so a failed write does not lose the keys
+	tmp := getPath() + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to write keyring")
+	}
+	err = os.Rename(tmp, getPath())
+	if err != nil {
+		return errors.Wrap(err, "failed to write keyring")
+	}
+
+	info, err := os.Stat(getPath())
+	if err == nil {
+		cache, cacheTime, cacheSize = entries, info.ModTime(), info.Size()
+	}
+	return nil
+}
+
+// NewSubject returns a random identifier for a data subject
+func NewSubject() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// IsEncrypted reports whether the value was encrypted by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Subject returns the data subject of an encrypted value
+func Subject(value string) (string, bool) {
+	if !IsEncrypted(value) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, prefix), ":", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// Encrypt encrypts the plaintext with the key of the subject, created on
+// first use. The context, such as the field name, must be given again to
+// decrypt, so ciphertexts cannot be moved between fields.
+func Encrypt(subject, context, plaintext string) (string, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	entries, err := load()
+	if err != nil {
+		return "", err
+	}
+
+	e, ok := entries[subject]
+	if ok && e.DeletedAt != nil {
+		return "", ErrShredded
+	}
+	if !ok {
+		key := make([]byte, 32)
+		_, err = rand.Read(key)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to generate key")
+		}
+		e = &entry{Key: key, CreatedAt: time.Now().UTC()}
+		entries[subject] = e
+
+		err = save(entries)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	gcm, err := newGCM(e.Key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate nonce")
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(context))
+	return prefix + subject + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt with the same context.
+// Values that are not encrypted are returned as is.
+func Decrypt(context, value string) (string, error) {
+	subject, ok := Subject(value)
+	if !ok {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(prefix)+len(subject)+1:])
+	if err != nil {
+		return "", errors.Wrap(err, "invalid encrypted value")
+	}
+
+	key, err := getKey(subject)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(context))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decrypt value")
+	}
+	return string(plaintext), nil
+}
+
+// getKey returns the key of the subject
+func getKey(subject string) ([]byte, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	entries, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	e, ok := entries[subject]
+	if !ok {
+		return nil, errors.Errorf("no key for data subject %s", subject)
+	}
+	if e.DeletedAt != nil {
+		return nil, ErrShredded
+	}
+	return e.Key, nil
+}
+
+// Shred deletes the key of the subject, keeping when it was deleted so the
+// subject is not given a new key. It returns when the key was deleted.
+func Shred(subject string) (time.Time, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	entries, err := load()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	e, ok := entries[subject]
+	if !ok {
+		return time.Time{}, errors.Errorf("no key for data subject %s", subject)
+	}
+	if e.DeletedAt != nil {
+		return *e.DeletedAt, nil
+	}
+
+	now := time.Now().UTC()
+	e.Key = nil
+	e.DeletedAt = &now
+	return now, save(entries)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	return gcm, nil
+}
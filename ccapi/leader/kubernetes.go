@@ -0,0 +1,197 @@
+package leader
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The service account of the pod, mounted by Kubernetes
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// microTime is the layout of the times of Leases
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// lease is a coordination.k8s.io/v1 Lease, with the fields of the election
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions"`
+}
+
+// kubernetesElector holds a Lease of the API server of the cluster, with
+// the service account of the pod. Updates carry the resource version of
+// the Lease read, so only one replica takes an expired Lease.
+type kubernetesElector struct {
+	url    string
+	name   string
+	client *http.Client
+}
+
+// newKubernetesElector finds the API server, namespace and credentials of
+// the pod. LEADER_LEASE_NAMESPACE (default the namespace of the pod) and
+// LEADER_LEASE_NAME (default ccapi) name the Lease.
+func newKubernetesElector() (*kubernetesElector, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes cluster")
+	}
+	if _, err := os.Stat(serviceAccountDir + "/token"); err != nil {
+		return nil, errors.Wrap(err, "no service account token")
+	}
+
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the cluster CA")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid cluster CA")
+	}
+
+	namespace := os.Getenv("LEADER_LEASE_NAMESPACE")
+	if namespace == "" {
+		ns, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the namespace, set LEADER_LEASE_NAMESPACE")
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+	name := os.Getenv("LEADER_LEASE_NAME")
+	if name == "" {
+		name = "ccapi"
+	}
+
+	return &kubernetesElector{
+		url: fmt.Sprintf("https://%s/apis/coordination.k8s.io/v1/namespaces/%s/leases",
+			net.JoinHostPort(host, port), namespace),
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+		name: name,
+	}, nil
+}
+
+func (e *kubernetesElector) campaign(identity string, duration time.Duration) (bool, error) {
+	name := e.name
+	now := time.Now().UTC()
+
+	var current lease
+	status, err := e.do(http.MethodGet, "/"+name, nil, &current)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusNotFound {
+		l := lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: name},
+			Spec: leaseSpec{
+				HolderIdentity:       identity,
+				LeaseDurationSeconds: int(duration.Seconds()),
+				AcquireTime:          now.Format(microTime),
+				RenewTime:            now.Format(microTime),
+			},
+		}
+		status, err = e.do(http.MethodPost, "", l, nil)
+		if err != nil {
+			return false, err
+		}
+		// Another replica created it first
+		return status == http.StatusCreated, nil
+	}
+	if status != http.StatusOK {
+		return false, errors.Errorf("failed to read Lease '%s': status %d", name, status)
+	}
+
+	spec := &current.Spec
+	if spec.HolderIdentity != identity {
+		renewed, err := time.Parse(time.RFC3339Nano, spec.RenewTime)
+		expires := renewed.Add(time.Duration(spec.LeaseDurationSeconds) * time.Second)
+		if spec.HolderIdentity != "" && err == nil && now.Before(expires) {
+			return false, nil
+		}
+		spec.HolderIdentity = identity
+		spec.AcquireTime = now.Format(microTime)
+		spec.LeaseTransitions++
+	}
+	spec.LeaseDurationSeconds = int(duration.Seconds())
+	spec.RenewTime = now.Format(microTime)
+
+	status, err = e.do(http.MethodPut, "/"+name, current, nil)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusConflict {
+		// Another replica updated it since it was read
+		return false, nil
+	}
+	if status != http.StatusOK {
+		return false, errors.Errorf("failed to update Lease '%s': status %d", name, status)
+	}
+	return true, nil
+}
+
+// do sends the request to the Leases of the namespace, decoding successful
+// replies into out. The token is read on every request, as Kubernetes
+// rotates it.
+func (e *kubernetesElector) do(method, path string, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, e.url+path, body)
+	if err != nil {
+		return 0, err
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read the service account token")
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to reach the Kubernetes API")
+	}
+	defer res.Body.Close()
+	if out != nil && res.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return 0, errors.Wrap(err, "invalid Lease")
+		}
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,203 @@
+// Package leader elects one of the replicas of the CCAPI to run the
+// background work that must run once, such as the scheduled jobs and the
+// chaincode event consumers, while every replica serves requests.
+//
+// LEADER_ELECTION chooses how: redis holds a lease in the shared state,
+// which requires STATE_BACKEND=redis, and kubernetes holds a Lease object
+// of the coordination.k8s.io API, with the service account of the pod.
+// Without it every replica is the leader, which suits a single replica.
+package leader
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/state"
+)
+
+// Status is the state of the election
+type Status struct {
+	Enabled  bool       `json:"enabled"`
+	Backend  string     `json:"backend,omitempty"`
+	Identity string     `json:"identity"`
+	Leader   bool       `json:"leader"`
+	Since    *time.Time `json:"since,omitempty"`
+}
+
+// elector campaigns for a lease of the duration, reporting whether the
+// replica holds it afterwards. Holders renew it the same way.
+type elector interface {
+	campaign(identity string, duration time.Duration) (bool, error)
+}
+
+var (
+	mutex    sync.Mutex
+	leading  bool
+	since    time.Time
+	acquired = make(chan struct{})
+	lost     = make(chan struct{})
+
+	startOnce sync.Once
+)
+
+// Enabled reports whether the replicas elect a leader, with LEADER_ELECTION
+func Enabled() bool {
+	return os.Getenv("LEADER_ELECTION") != ""
+}
+
+// getIdentity returns the identity of the replica from LEADER_ID, or else
+// its host name, which is the pod name in Kubernetes, and a random suffix
+var getIdentity = func() func() string {
+	var identity string
+	var once sync.Once
+	return func() string {
+		once.Do(func() {
+			identity = os.Getenv("LEADER_ID")
+			if identity != "" {
+				return
+			}
+			identity, _ = os.Hostname()
+			b := make([]byte, 4)
+			rand.Read(b)
+			identity += "-" + hex.EncodeToString(b)
+		})
+		return identity
+	}
+}()
+
+// getLeaseDuration returns how long a lease lasts without being renewed,
+// from LEADER_LEASE_DURATION (default 15s). It is renewed three times as
+// often.
+func getLeaseDuration() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("LEADER_LEASE_DURATION"))
+	if err != nil || d < time.Second {
+		return 15 * time.Second
+	}
+	return d
+}
+
+// Start campaigns for the lease in the background, or makes the replica
+// the leader if the election is disabled
+func Start() {
+	startOnce.Do(func() {
+		if !Enabled() {
+			setLeading(true)
+			return
+		}
+
+		var e elector
+		switch backend := os.Getenv("LEADER_ELECTION"); backend {
+		case "redis":
+			if !state.Shared() {
+				log.Fatalln("leader election with redis requires STATE_BACKEND=redis")
+			}
+			e = &redisElector{}
+		case "kubernetes":
+			k, err := newKubernetesElector()
+			if err != nil {
+				log.Fatalln("invalid Kubernetes leader election: ", err)
+			}
+			e = k
+		default:
+			log.Fatalf("unknown LEADER_ELECTION '%s', use redis or kubernetes\n", backend)
+		}
+
+		log.Printf("electing the leader with %s as %s\n", os.Getenv("LEADER_ELECTION"), getIdentity())
+		go campaign(e)
+	})
+}
+
+// campaign keeps campaigning for the lease. Leaders that fail to renew it
+// step down right away, as another replica may take it once it expires.
+func campaign(e elector) {
+	duration := getLeaseDuration()
+	for {
+		held, err := e.campaign(getIdentity(), duration)
+		if err != nil {
+			log.Println("error campaigning for leadership: ", err)
+		}
+		setLeading(held && err == nil)
+		time.Sleep(duration / 3)
+	}
+}
+
+// setLeading records whether the replica is the leader, waking those
+// waiting for the change
+func setLeading(leader bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if leader == leading {
+		return
+	}
+
+	leading = leader
+	if leader {
+		since = time.Now().UTC()
+		log.Println("this replica is now the leader")
+		close(acquired)
+		lost = make(chan struct{})
+	} else {
+		log.Println("this replica is no longer the leader")
+		close(lost)
+		acquired = make(chan struct{})
+	}
+}
+
+// IsLeader reports whether the replica is the leader
+func IsLeader() bool {
+	Start()
+	mutex.Lock()
+	defer mutex.Unlock()
+	return leading
+}
+
+// Wait blocks until the replica is the leader, and returns a channel closed
+// once it no longer is
+func Wait() <-chan struct{} {
+	Start()
+	for {
+		mutex.Lock()
+		if leading {
+			l := lost
+			mutex.Unlock()
+			return l
+		}
+		a := acquired
+		mutex.Unlock()
+		<-a
+	}
+}
+
+// GetStatus returns the state of the election
+func GetStatus() Status {
+	Start()
+	mutex.Lock()
+	defer mutex.Unlock()
+	s := Status{
+		Enabled:  Enabled(),
+		Backend:  os.Getenv("LEADER_ELECTION"),
+		Identity: getIdentity(),
+		Leader:   leading,
+	}
+	if leading {
+		t := since
+		s.Since = &t
+	}
+	return s
+}
+
+// redisElector holds the lease in the shared state
+type redisElector struct{}
+
+func (e *redisElector) campaign(identity string, duration time.Duration) (bool, error) {
+	store := state.Default()
+	held, err := store.Extend("leader", []byte(identity), duration)
+	if err != nil || held {
+		return held, err
+	}
+	return store.SetNX("leader", []byte(identity), duration)
+}
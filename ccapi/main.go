@@ -2,39 +2,126 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/hyperledger-labs/ccapi/accesslog"
+	"github.com/hyperledger-labs/ccapi/anchor"
+	"github.com/hyperledger-labs/ccapi/audit"
+	"github.com/hyperledger-labs/ccapi/bootstrap"
+	"github.com/hyperledger-labs/ccapi/buildinfo"
+	"github.com/hyperledger-labs/ccapi/cassette"
 	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/compat"
+	"github.com/hyperledger-labs/ccapi/handlers"
+	"github.com/hyperledger-labs/ccapi/leader"
+	"github.com/hyperledger-labs/ccapi/mock"
+	"github.com/hyperledger-labs/ccapi/plugins"
+	"github.com/hyperledger-labs/ccapi/rbac"
+	"github.com/hyperledger-labs/ccapi/redact"
 	"github.com/hyperledger-labs/ccapi/server"
+	"github.com/hyperledger-labs/ccapi/settings"
+	"github.com/hyperledger-labs/ccapi/siem"
+	"github.com/hyperledger-labs/ccapi/warmup"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 )
 
 func main() {
+	// Print the build and exit, with "ccapi version" or "ccapi version -json"
+	if len(os.Args) > 1 && (os.Args[1] == "version" || os.Args[1] == "-version" || os.Args[1] == "--version") {
+		printVersion(len(os.Args) > 2 && (os.Args[2] == "-json" || os.Args[2] == "--json"))
+		return
+	}
+
+	// Read the settings set with files, such as secrets, before anything
+	// reads them
+	settings.Load()
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Create gin handler and start server
-	r := gin.Default()
+	// Keep the numbers of request bodies exact, as in chaincode results
+	binding.EnableDecoderUseNumber = common.ExactNumbers()
+
+	// Mask sensitive values in the logs
+	log.SetOutput(redact.Writer(os.Stderr))
+	gin.DefaultWriter = redact.Writer(os.Stdout)
+	gin.DefaultErrorWriter = redact.Writer(os.Stderr)
+
+	// Create gin handler and start server, logging the requests to the
+	// access log
+	r := gin.New()
+	r.Use(accesslog.Middleware(), gin.Recovery())
 	r.Use(cors.New(cors.Config{
 		AllowOrigins: []string{
 			"http://localhost:8080", // Test addresses
 			"*",
 		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Authorization", "Origin", "Content-Type"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Authorization", "Origin", "Content-Type", "User", "Org"},
 		AllowCredentials: true,
 	}))
+
+	// Forward authentication failures and denied requests to the SIEM
+	r.Use(siem.Middleware())
+
+	// Start the plugins before the routes use them
+	plugins.Start()
+
+	// Campaign to run the background jobs, if replicas elect a leader
+	leader.Start()
+
+	// Provision the identities, webhooks, API keys, schedules and feature
+	// flags of the bootstrap configuration before serving requests
+	bootstrap.Start(handlers.SubmitScheduled)
+
 	go server.Serve(r, ctx)
 
-	// Register to chaincode events
-	go chaincode.WaitForEvent(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "eventName", func(ccEvent *fab.CCEvent) {
-		log.Println("Received CC event: ", ccEvent)
-	})
+	// Register to chaincode events, which are not emitted by the mock ledger
+	// nor needed when playing back a cassette
+	if !mock.Enabled() && !cassette.Playback() {
+		go chaincode.WaitForEvent(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "eventName", func(ccEvent *fab.CCEvent) {
+			log.Println("Received CC event: ", ccEvent)
+		})
+
+		chaincode.RegisterForEvents()
 
-	chaincode.RegisterForEvents()
+		// Drop the cached chaincode metadata when the chaincode is upgraded
+		if chaincode.MetadataCacheEnabled() {
+			go chaincode.WatchUpgrades(os.Getenv("CHANNEL"))
+		}
+
+		// Connect to the gateway before the first requests arrive
+		warmup.Start()
+	}
+
+	// Check the version of the chaincode, which is not deployed when
+	// playing back a cassette
+	if !cassette.Playback() {
+		compat.Start()
+	}
+
+	// Schedule the release of the locked escrows while this replica is the
+	// leader
+	go handlers.ScheduleEscrowReleases()
+
+	// Schedule the overdue notices of the loans made before the CCAPI started
+	go handlers.ScheduleOverdueNotices()
+
+	// Periodically sign the audit log, if enabled
+	audit.Start()
+
+	// Periodically anchor the ledger, if enabled
+	anchor.Start()
+
+	// Periodically sync the groups that grant roles, if enabled
+	rbac.Start()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
@@ -42,3 +129,14 @@ func main() {
 	<-quit
 	cancel()
 }
+
+// printVersion prints the build of the binary, on one line or as JSON
+func printVersion(asJSON bool) {
+	info := buildinfo.Get()
+	if !asJSON {
+		fmt.Println(info)
+		return
+	}
+	out, _ := json.MarshalIndent(info, "", "  ")
+	fmt.Println(string(out))
+}
@@ -0,0 +1,103 @@
+// Package maintenance puts the API in read-only mode, e.g. during chaincode
+// upgrades or channel maintenance windows: transactions can still be
+// evaluated, but submissions are rejected with the reason.
+package maintenance
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// State is the maintenance mode of the API
+type State struct {
+	ReadOnly bool       `json:"readOnly"`
+	Reason   string     `json:"reason,omitempty"`
+	Since    *time.Time `json:"since,omitempty"`
+	Until    *time.Time `json:"until,omitempty"`
+}
+
+var (
+	mutex sync.RWMutex
+	state State
+	once  sync.Once
+)
+
+// initState starts in read-only mode when MAINTENANCE_MODE is true, with
+// the reason in MAINTENANCE_REASON
+func initState() {
+	once.Do(func() {
+		if os.Getenv("MAINTENANCE_MODE") == "true" {
+			now := time.Now().UTC()
+			state = State{
+				ReadOnly: true,
+				Reason:   os.Getenv("MAINTENANCE_REASON"),
+				Since:    &now,
+			}
+		}
+	})
+}
+
+// Get returns the current maintenance mode
+func Get() State {
+	initState()
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return state
+}
+
+// Set turns read-only mode on, with the reason and the expected end of the
+// maintenance, if known, or off
+func Set(readOnly bool, reason string, until *time.Time) State {
+	initState()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !readOnly {
+		state = State{}
+		return state
+	}
+
+	since := state.Since
+	if !state.ReadOnly {
+		now := time.Now().UTC()
+		since = &now
+	}
+	state = State{
+		ReadOnly: true,
+		Reason:   reason,
+		Since:    since,
+		Until:    until,
+	}
+	return state
+}
+
+// Check returns an error with status 503 when the API is read-only
+func Check() error {
+	s := Get()
+	if !s.ReadOnly {
+		return nil
+	}
+
+	msg := "the API is in read-only mode for maintenance"
+	if s.Reason != "" {
+		msg += ": " + s.Reason
+	}
+	details := map[string]interface{}{
+		"since": s.Since,
+	}
+	if s.Until != nil {
+		details["until"] = s.Until
+	}
+	return &common.APIError{
+		Status:  http.StatusServiceUnavailable,
+		Code:    common.ErrCodeUnavailable,
+		Message: msg,
+		Details: details,
+	}
+}
@@ -0,0 +1,82 @@
+// Package metrics exposes the metrics of the CCAPI to Prometheus, such as
+// the depth of the transaction queues, so operators see the pressure on the
+// CCAPI before latency blows past the timeouts of clients.
+package metrics
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/txqueue"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	queuedDesc = prometheus.NewDesc(
+		"ccapi_tx_queue_queued",
+		"Transactions waiting for a slot of the budget of their priority class",
+		[]string{"class"}, nil,
+	)
+	runningDesc = prometheus.NewDesc(
+		"ccapi_tx_queue_running",
+		"Transactions of the priority class being submitted",
+		[]string{"class"}, nil,
+	)
+	concurrencyDesc = prometheus.NewDesc(
+		"ccapi_tx_queue_concurrency",
+		"Budget of the priority class, zero if unlimited",
+		[]string{"class"}, nil,
+	)
+	maxQueuedDesc = prometheus.NewDesc(
+		"ccapi_tx_queue_max_queued",
+		"Queued transactions of the priority class past which submissions are refused, zero if unbounded",
+		[]string{"class"}, nil,
+	)
+	rejectedDesc = prometheus.NewDesc(
+		"ccapi_tx_queue_rejected_total",
+		"Transactions of the priority class refused because its queue was full",
+		[]string{"class"}, nil,
+	)
+)
+
+// queueCollector reads the state of the transaction queues when scraped
+type queueCollector struct{}
+
+func (queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queuedDesc
+	ch <- runningDesc
+	ch <- concurrencyDesc
+	ch <- maxQueuedDesc
+	ch <- rejectedDesc
+}
+
+func (queueCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range txqueue.GetStats() {
+		class := string(s.Class)
+		ch <- prometheus.MustNewConstMetric(queuedDesc, prometheus.GaugeValue, float64(s.Queued), class)
+		ch <- prometheus.MustNewConstMetric(runningDesc, prometheus.GaugeValue, float64(s.Running), class)
+		ch <- prometheus.MustNewConstMetric(concurrencyDesc, prometheus.GaugeValue, float64(s.Concurrency), class)
+		ch <- prometheus.MustNewConstMetric(maxQueuedDesc, prometheus.GaugeValue, float64(s.MaxQueued), class)
+		ch <- prometheus.MustNewConstMetric(rejectedDesc, prometheus.CounterValue, float64(s.Rejected), class)
+	}
+}
+
+var (
+	registry     *prometheus.Registry
+	registryOnce sync.Once
+)
+
+// Handler serves the metrics in the Prometheus text format, along with
+// those of the Go runtime and the process
+func Handler() gin.HandlerFunc {
+	registryOnce.Do(func() {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(
+			queueCollector{},
+			prometheus.NewGoCollector(),
+			prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		)
+	})
+	return gin.WrapH(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+}
@@ -0,0 +1,34 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is a chaincode error response from the mock ledger
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("chaincode response %d, %s", e.Status, e.Message)
+}
+
+// GRPCStatus presents the error the same way the Fabric gateway does,
+// so it can be parsed by common.ParseError
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(codes.Aborted, "failed to evaluate transaction")
+	detailed, err := st.WithDetails(&gateway.ErrorDetail{
+		Address: "mock",
+		MspId:   MSPID(),
+		Message: e.Error(),
+	})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
@@ -0,0 +1,305 @@
+package mock
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	ccerrors "github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+	"github.com/hyperledger-labs/ccapi/buildinfo"
+	"github.com/hyperledger-labs/ccapi/geo"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	mspproto "github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// defaultSchema mirrors the demo chaincode asset types, using only
+// primitive data types since custom ones are not available here, except for
+// the ones the CCAPI validates itself
+//
+//go:embed schema.json
+var defaultSchema []byte
+
+// Enabled reports whether the ccapi should run against the in-memory
+// mock ledger instead of a Fabric network
+func Enabled() bool {
+	return os.Getenv("MOCK_LEDGER") == "true"
+}
+
+// ledger is an in-memory ledger backed by the cc-tools mock stub
+type ledger struct {
+	mutex       sync.Mutex
+	stub        *mock.MockStub
+	history     map[string][]*queryresult.KeyModification
+	blockNumber uint64
+
+	// txBlocks are the blocks of the submitted transactions, by id, and
+	// lastTxTime the timestamp of the last one
+	txBlocks   map[string]uint64
+	lastTxTime time.Time
+
+	// readOnly is set while evaluating, so writes are discarded
+	readOnly bool
+}
+
+var (
+	instance *ledger
+	once     sync.Once
+	initErr  error
+)
+
+func getLedger() (*ledger, error) {
+	once.Do(func() {
+		initErr = setup()
+	})
+	return instance, initErr
+}
+
+// setup initializes cc-tools with the asset types from MOCK_SCHEMA_PATH,
+// or from the embedded demo schema, and creates the mock stub
+func setup() error {
+	schema := defaultSchema
+	if path := os.Getenv("MOCK_SCHEMA_PATH"); path != "" {
+		var err error
+		schema, err = os.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "failed to read mock schema")
+		}
+	}
+
+	var assetTypes []interface{}
+	err := json.Unmarshal(schema, &assetTypes)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal mock schema")
+	}
+
+	// The mock stands in for the chaincode the binary was built for, so the
+	// version check passes
+	version := "0.0.0"
+	if buildinfo.ChaincodeVersion != "" {
+		version = buildinfo.ChaincodeVersion
+	}
+	tx.InitHeader(tx.Header{
+		Name:    "CCAPI Mock Ledger",
+		Version: version,
+	})
+	tx.InitTxList([]tx.Transaction{
+		tx.CreateAsset,
+		tx.UpdateAsset,
+		tx.DeleteAsset,
+	})
+	err = assets.CustomDataTypes(customDataTypes)
+	if err != nil {
+		return errors.Wrap(err, "invalid mock data types")
+	}
+	assets.InitAssetList(assets.AssetTypeListFromArray(assetTypes))
+
+	cerr := assets.StartupCheck()
+	if cerr != nil {
+		return errors.Wrap(cerr, "invalid mock schema")
+	}
+	cerr = tx.StartupCheck()
+	if cerr != nil {
+		return errors.Wrap(cerr, "invalid mock transactions")
+	}
+
+	msp := MSPID()
+
+	instance = &ledger{
+		history:  make(map[string][]*queryresult.KeyModification),
+		txBlocks: make(map[string]uint64),
+	}
+	instance.stub = mock.NewMockStub(msp, &mockChaincode{ledger: instance})
+	log.Printf("running against mock ledger as %s\n", msp)
+
+	return nil
+}
+
+// customDataTypes are the chaincode data types the CCAPI implements
+var customDataTypes = map[string]assets.DataType{
+	geo.DataType: {
+		AcceptedFormats: []string{"@object", "string"},
+		Parse: func(data interface{}) (string, interface{}, ccerrors.ICCError) {
+			p, err := geo.Parse(data)
+			if err != nil {
+				return "", nil, ccerrors.NewCCError(err.Error(), http.StatusBadRequest)
+			}
+			key := strconv.FormatFloat(p.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(p.Long, 'f', -1, 64)
+			return key, map[string]interface{}{"lat": p.Lat, "long": p.Long}, nil
+		},
+	},
+}
+
+// Submit executes a transaction on the mock ledger as the MSP, or the
+// default one if empty, persisting its writes
+func Submit(msp, txName string, args []string, transient map[string][]byte) ([]byte, string, uint64, error) {
+	l, err := getLedger()
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	txID := newTxID()
+	res, err := l.run(msp, txID, txName, args, transient, false)
+	if err != nil {
+		return nil, txID, 0, err
+	}
+
+	l.blockNumber++
+	l.txBlocks[txID] = l.blockNumber
+	l.lastTxTime = l.stub.TxTimestamp.AsTime().UTC()
+	return res, txID, l.blockNumber, nil
+}
+
+// Height returns the number of blocks of the mock ledger, each submitted
+// transaction being a block
+func Height() (uint64, error) {
+	l, err := getLedger()
+	if err != nil {
+		return 0, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.blockNumber + 1, nil
+}
+
+// LastTxTime returns the timestamp of the last submitted transaction, or the
+// zero time if none was
+func LastTxTime() (time.Time, error) {
+	l, err := getLedger()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.lastTxTime, nil
+}
+
+// TxBlock returns the number of the block of the submitted transaction
+func TxBlock(txID string) (uint64, error) {
+	l, err := getLedger()
+	if err != nil {
+		return 0, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	number, ok := l.txBlocks[txID]
+	if !ok {
+		return 0, &Error{Status: http.StatusNotFound, Message: "transaction " + txID + " not found"}
+	}
+	return number, nil
+}
+
+// Evaluate executes a transaction on the mock ledger as the MSP, or the
+// default one if empty, discarding its writes
+func Evaluate(msp, txName string, args []string) ([]byte, error) {
+	l, err := getLedger()
+	if err != nil {
+		return nil, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.run(msp, newTxID(), txName, args, nil, true)
+}
+
+func (l *ledger) run(msp, txID, txName string, args []string, transient map[string][]byte, readOnly bool) ([]byte, error) {
+	if msp == "" {
+		msp = MSPID()
+	}
+	creator, err := proto.Marshal(&mspproto.SerializedIdentity{Mspid: msp})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal creator")
+	}
+	l.stub.Creator = creator
+
+	invokeArgs := [][]byte{[]byte(txName)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+
+	if transient == nil {
+		transient = make(map[string][]byte)
+	}
+	l.stub.SetTransient(transient)
+
+	l.readOnly = readOnly
+	res := l.stub.MockInvoke(txID, invokeArgs)
+	if res.Status != http.StatusOK {
+		return nil, &Error{
+			Status:  int(res.Status),
+			Message: res.Message,
+		}
+	}
+
+	return res.Payload, nil
+}
+
+func newTxID() string {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return fmt.Sprint(time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// mockChaincode runs cc-tools transactions on a stub that supports
+// rich queries and key history
+type mockChaincode struct {
+	ledger *ledger
+}
+
+func (cc *mockChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (cc *mockChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	ls := &ledgerStub{
+		ChaincodeStubInterface: stub,
+		ledger:                 cc.ledger,
+	}
+	result, err := tx.Run(ls)
+	if err != nil {
+		return err.GetErrorResponse()
+	}
+
+	// Failed transactions leave no writes behind
+	cerr := ls.commit()
+	if cerr != nil {
+		return shim.Error(cerr.Error())
+	}
+	return shim.Success(result)
+}
+
+// MSPID returns the MSP of the caller identity of the mock ledger
+func MSPID() string {
+	msp := os.Getenv("MOCK_MSP")
+	if msp == "" {
+		msp = "orgMSP"
+	}
+	return msp
+}
@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"testing"
+)
+
+func TestSubmitFailedWrites(t *testing.T) {
+	l, err := getLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, _, err = Submit("", "createAsset", []string{`{"asset":[{"@assetType":"person","id":"318.207.920-48","name":"Maria"}]}`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := len(l.stub.State)
+
+	// The first person is written before the second is found to exist,
+	// and neither write is kept
+	_, _, _, err = Submit("", "createAsset", []string{`{"asset":[{"@assetType":"person","id":"427.815.090-27","name":"Jose"},{"@assetType":"person","id":"318.207.920-48","name":"Maria"}]}`}, nil)
+	if err == nil {
+		t.Fatal("expected the transaction to fail")
+	}
+	if len(l.stub.State) != keys {
+		t.Fatalf("expected %d keys, got %d", keys, len(l.stub.State))
+	}
+
+	// Evaluated transactions leave no writes behind either
+	_, err = Evaluate("", "createAsset", []string{`{"asset":[{"@assetType":"person","id":"427.815.090-27","name":"Jose"}]}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.stub.State) != keys {
+		t.Fatalf("expected %d keys, got %d", keys, len(l.stub.State))
+	}
+}
@@ -0,0 +1,162 @@
+package mock
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// matchSelector reports whether doc matches a CouchDB Mango selector.
+// Only the most common operators are supported: $eq, $ne, $gt, $gte,
+// $lt, $lte, $in, $nin, $exists, $regex, $elemMatch, $and, $or and $not.
+func matchSelector(selector map[string]interface{}, doc map[string]interface{}) bool {
+	for field, condition := range selector {
+		switch field {
+		case "$and":
+			for _, sub := range toList(condition) {
+				if !matchSelector(toMap(sub), doc) {
+					return false
+				}
+			}
+		case "$or":
+			matched := false
+			for _, sub := range toList(condition) {
+				if matchSelector(toMap(sub), doc) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		case "$not":
+			if matchSelector(toMap(condition), doc) {
+				return false
+			}
+		default:
+			value, exists := lookupField(doc, field)
+			if !matchCondition(condition, value, exists) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchCondition(condition, value interface{}, exists bool) bool {
+	operators, ok := condition.(map[string]interface{})
+	if !ok || !isOperatorMap(operators) {
+		return exists && reflect.DeepEqual(condition, value)
+	}
+
+	for op, arg := range operators {
+		var matched bool
+		switch op {
+		case "$eq":
+			matched = exists && reflect.DeepEqual(arg, value)
+		case "$ne":
+			matched = !reflect.DeepEqual(arg, value)
+		case "$gt":
+			matched = exists && compare(value, arg) > 0
+		case "$gte":
+			matched = exists && compare(value, arg) >= 0
+		case "$lt":
+			matched = exists && compare(value, arg) < 0
+		case "$lte":
+			matched = exists && compare(value, arg) <= 0
+		case "$in":
+			matched = exists && contains(toList(arg), value)
+		case "$nin":
+			matched = !contains(toList(arg), value)
+		case "$exists":
+			want, _ := arg.(bool)
+			matched = exists == want
+		case "$regex":
+			str, isStr := value.(string)
+			pattern, _ := arg.(string)
+			if isStr {
+				matched, _ = regexp.MatchString(pattern, str)
+			}
+		case "$elemMatch":
+			for _, item := range toList(value) {
+				if matchCondition(arg, item, true) {
+					matched = true
+					break
+				}
+			}
+		default:
+			matched = false
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// compare returns -1, 0 or 1. Values of different types are ordered by
+// type name, which keeps the comparison total without panicking.
+func compare(a, b interface{}) int {
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			switch {
+			case av < bv:
+				return -1
+			case av > bv:
+				return 1
+			}
+			return 0
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv)
+		}
+	}
+	return strings.Compare(fmt.Sprintf("%T", a), fmt.Sprintf("%T", b))
+}
+
+// lookupField resolves dotted field names on nested objects
+func lookupField(doc map[string]interface{}, field string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, part := range strings.Split(field, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func isOperatorMap(m map[string]interface{}) bool {
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return len(m) > 0
+}
+
+func contains(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func toList(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	return list
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
@@ -0,0 +1,276 @@
+package mock
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// ledgerStub extends the cc-tools mock stub with the features it lacks:
+// rich queries, key history, read-only execution and writes applied only
+// when the transaction succeeds
+type ledgerStub struct {
+	shim.ChaincodeStubInterface
+	ledger *ledger
+
+	// writes are buffered until the routine succeeds, as a peer only
+	// commits the write set of valid transactions, with the index of the
+	// last write of each key
+	writes  []write
+	written map[string]int
+}
+
+// write is a buffered write of the transaction, to a private data
+// collection if it has one
+type write struct {
+	collection string
+	key        string
+	value      []byte
+	isDelete   bool
+}
+
+func (s *ledgerStub) PutState(key string, value []byte) error {
+	s.buffer(write{key: key, value: value, isDelete: len(value) == 0})
+	return nil
+}
+
+func (s *ledgerStub) DelState(key string) error {
+	s.buffer(write{key: key, isDelete: true})
+	return nil
+}
+
+func (s *ledgerStub) PutPrivateData(collection, key string, value []byte) error {
+	s.buffer(write{collection: collection, key: key, value: value})
+	return nil
+}
+
+// buffer keeps the write, replacing an earlier one to the same key since
+// only the last is committed
+func (s *ledgerStub) buffer(w write) {
+	if s.written == nil {
+		s.written = make(map[string]int)
+	}
+	id := w.collection + "\x00" + w.key
+	if i, ok := s.written[id]; ok {
+		s.writes[i] = w
+		return
+	}
+	s.written[id] = len(s.writes)
+	s.writes = append(s.writes, w)
+}
+
+// commit applies the buffered writes to the ledger, unless evaluating
+func (s *ledgerStub) commit() error {
+	if s.ledger.readOnly {
+		return nil
+	}
+
+	for _, w := range s.writes {
+		var err error
+		switch {
+		case w.collection != "":
+			err = s.ChaincodeStubInterface.PutPrivateData(w.collection, w.key, w.value)
+		case w.isDelete:
+			err = s.ChaincodeStubInterface.DelState(w.key)
+		default:
+			err = s.ChaincodeStubInterface.PutState(w.key, w.value)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to write %s", w.key)
+		}
+
+		if w.collection == "" {
+			s.recordHistory(w.key, w.value, w.isDelete)
+		}
+	}
+	return nil
+}
+
+func (s *ledgerStub) recordHistory(key string, value []byte, isDelete bool) {
+	timestamp, _ := s.GetTxTimestamp()
+
+	// Newest modifications come first, as returned by the peer
+	modification := &queryresult.KeyModification{
+		TxId:      s.GetTxID(),
+		Value:     value,
+		Timestamp: timestamp,
+		IsDelete:  isDelete,
+	}
+	s.ledger.history[key] = append([]*queryresult.KeyModification{modification}, s.ledger.history[key]...)
+}
+
+func (s *ledgerStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{
+		items: s.ledger.history[key],
+	}, nil
+}
+
+func (s *ledgerStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := s.query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stateIterator{items: results}, nil
+}
+
+func (s *ledgerStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, err := s.query(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Bookmarks are the offset of the next page
+	start := 0
+	if bookmark != "" {
+		start, err = strconv.Atoi(bookmark)
+		if err != nil {
+			return nil, nil, errors.New("invalid bookmark")
+		}
+	}
+	if start > len(results) {
+		start = len(results)
+	}
+
+	end := len(results)
+	if pageSize > 0 && start+int(pageSize) < end {
+		end = start + int(pageSize)
+	}
+
+	page := results[start:end]
+	metadata := &pb.QueryResponseMetadata{
+		FetchedRecordsCount: int32(len(page)),
+		Bookmark:            strconv.Itoa(end),
+	}
+
+	return &stateIterator{items: page}, metadata, nil
+}
+
+// query evaluates a CouchDB-like query over the whole world state
+func (s *ledgerStub) query(query string) ([]*queryresult.KV, error) {
+	var q struct {
+		Selector map[string]interface{} `json:"selector"`
+		Sort     []map[string]string    `json:"sort"`
+		Limit    int                    `json:"limit"`
+	}
+	err := json.Unmarshal([]byte(query), &q)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal query")
+	}
+
+	keys := make([]string, 0, len(s.ledger.stub.State))
+	for key := range s.ledger.stub.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	results := make([]*queryresult.KV, 0)
+	docs := make([]map[string]interface{}, 0)
+	for _, key := range keys {
+		value := s.ledger.stub.State[key]
+
+		var doc map[string]interface{}
+		if json.Unmarshal(value, &doc) != nil {
+			continue
+		}
+
+		if matchSelector(q.Selector, doc) {
+			results = append(results, &queryresult.KV{
+				Key:   key,
+				Value: value,
+			})
+			docs = append(docs, doc)
+		}
+	}
+
+	if len(q.Sort) > 0 {
+		sortResults(results, docs, q.Sort)
+	}
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+
+	return results, nil
+}
+
+// sortResults orders the results by the fields of the query sort, each
+// mapped to "asc" or "desc"
+func sortResults(results []*queryresult.KV, docs []map[string]interface{}, fields []map[string]string) {
+	indexes := make([]int, len(results))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		for _, field := range fields {
+			for name, order := range field {
+				a, _ := lookupField(docs[indexes[i]], name)
+				b, _ := lookupField(docs[indexes[j]], name)
+				cmp := compare(a, b)
+				if cmp == 0 {
+					continue
+				}
+				if order == "desc" {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+		}
+		return false
+	})
+
+	sorted := make([]*queryresult.KV, len(results))
+	for i, index := range indexes {
+		sorted[i] = results[index]
+	}
+	copy(results, sorted)
+}
+
+type stateIterator struct {
+	items []*queryresult.KV
+	index int
+}
+
+func (it *stateIterator) HasNext() bool {
+	return it.index < len(it.items)
+}
+
+func (it *stateIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, errors.New("no more items")
+	}
+	item := it.items[it.index]
+	it.index++
+	return item, nil
+}
+
+func (it *stateIterator) Close() error {
+	return nil
+}
+
+type historyIterator struct {
+	items []*queryresult.KeyModification
+	index int
+}
+
+func (it *historyIterator) HasNext() bool {
+	return it.index < len(it.items)
+}
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, errors.New("no more items")
+	}
+	item := it.items[it.index]
+	it.index++
+	return item, nil
+}
+
+func (it *historyIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,317 @@
+// Package oidc logs browser users into the API console and the dashboard
+// with an OpenID Connect provider, such as Keycloak or Auth0, using the
+// authorization code flow with PKCE. Logged in users get a session cookie
+// and act as the Fabric identity their user is mapped to.
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// discovery holds the endpoints of the provider
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// jwk is a public key of the provider
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+var (
+	client = &http.Client{Timeout: 10 * time.Second}
+
+	// providerMutex guards the discovered endpoints and keys, fetched on
+	// first use so the CCAPI starts even if the provider is down
+	providerMutex sync.Mutex
+	provider      *discovery
+	keys          map[string]crypto.PublicKey
+)
+
+// Enabled reports whether login is configured with OIDC_ISSUER and
+// OIDC_CLIENT_ID
+func Enabled() bool {
+	return os.Getenv("OIDC_ISSUER") != "" && os.Getenv("OIDC_CLIENT_ID") != ""
+}
+
+// getScopes returns the scopes requested from OIDC_SCOPES
+func getScopes() string {
+	if scopes := os.Getenv("OIDC_SCOPES"); scopes != "" {
+		return scopes
+	}
+	return "openid profile email"
+}
+
+// getDiscovery returns the endpoints of the provider from its discovery
+// document
+func getDiscovery() (*discovery, error) {
+	providerMutex.Lock()
+	defer providerMutex.Unlock()
+
+	if provider != nil {
+		return provider, nil
+	}
+
+	issuer := strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/")
+	var d discovery
+	err := getJSON(issuer+"/.well-known/openid-configuration", &d)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to discover provider")
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("provider discovery document is missing endpoints")
+	}
+
+	provider = &d
+	return provider, nil
+}
+
+// getKey returns the public key with the kid, fetching the keys of the
+// provider again when it is unknown, as they may have been rotated
+func getKey(kid string) (crypto.PublicKey, error) {
+	d, err := getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	providerMutex.Lock()
+	defer providerMutex.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err = getJSON(d.JWKSURI, &set)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch provider keys")
+	}
+
+	keys = make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, errors.Errorf("unknown key '%s'", kid)
+	}
+	return key, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	}
+	return nil, errors.Errorf("unsupported key type '%s'", k.Kty)
+}
+
+// exchangeCode redeems the authorization code for the ID token
+func exchangeCode(code, verifier string) (string, error) {
+	d, err := getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {os.Getenv("OIDC_REDIRECT_URL")},
+		"client_id":     {os.Getenv("OIDC_CLIENT_ID")},
+		"code_verifier": {verifier},
+	}
+	if secret := os.Getenv("OIDC_CLIENT_SECRET"); secret != "" {
+		form.Set("client_secret", secret)
+	}
+
+	res, err := client.PostForm(d.TokenEndpoint, form)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to redeem authorization code")
+	}
+	defer res.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&token)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode token response")
+	}
+	if token.Error != "" {
+		return "", errors.Errorf("provider refused authorization code: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return token.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// the ID token and returns its claims
+func verifyIDToken(idToken, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed ID token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed ID token signature")
+	}
+
+	key, err := getKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	valid := false
+	switch header.Alg {
+	case "RS256":
+		if pub, ok := key.(*rsa.PublicKey); ok {
+			valid = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+		}
+	case "ES256":
+		if pub, ok := key.(*ecdsa.PublicKey); ok && len(signature) == 64 {
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			valid = ecdsa.Verify(pub, digest[:], r, s)
+		}
+	default:
+		return nil, errors.Errorf("unsupported ID token algorithm '%s'", header.Alg)
+	}
+	if !valid {
+		return nil, errors.New("invalid ID token signature")
+	}
+
+	var claims map[string]interface{}
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed ID token claims")
+	}
+
+	d, err := getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	if claims["iss"] != d.Issuer {
+		return nil, errors.New("ID token was issued by another provider")
+	}
+	if !hasAudience(claims["aud"], os.Getenv("OIDC_CLIENT_ID")) {
+		return nil, errors.New("ID token was issued to another client")
+	}
+	exp, _ := claims["exp"].(float64)
+	if time.Now().After(time.Unix(int64(exp), 0).Add(time.Minute)) {
+		return nil, errors.New("ID token expired")
+	}
+	if claims["nonce"] != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	return claims, nil
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func getJSON(url string, v interface{}) error {
+	res, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("%s answered with status %d", url, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,337 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/state"
+	"github.com/pkg/errors"
+)
+
+const cookieName = "ccapi_session"
+
+// loginTimeout limits the time to complete a login at the provider
+const loginTimeout = 10 * time.Minute
+
+// Session is a logged in browser user
+type Session struct {
+	User      string    `json:"user"`
+	Identity  string    `json:"identity"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// login is a login waiting for the provider to redirect back
+type login struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	Redirect string `json:"redirect"`
+}
+
+// The sessions and the pending logins are kept in the shared state, so a
+// login can complete and a session be used on any replica
+const (
+	sessionPrefix = "oidc:session:"
+	loginPrefix   = "oidc:login:"
+)
+
+var (
+	identities     map[string]string
+	identitiesErr  error
+	identitiesOnce sync.Once
+)
+
+// getSessionTTL returns the lifetime of sessions from OIDC_SESSION_TTL,
+// default 8 hours
+func getSessionTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("OIDC_SESSION_TTL"))
+	if err != nil || ttl <= 0 {
+		return 8 * time.Hour
+	}
+	return ttl
+}
+
+// getUserClaim returns the claim naming the user from OIDC_USER_CLAIM
+func getUserClaim() string {
+	if claim := os.Getenv("OIDC_USER_CLAIM"); claim != "" {
+		return claim
+	}
+	return "preferred_username"
+}
+
+// mapIdentity returns the Fabric identity, user or user@org, of the user,
+// from the JSON object in the file set by OIDC_IDENTITIES or else
+// OIDC_DEFAULT_IDENTITY. Users with no identity cannot log in.
+func mapIdentity(user string) (string, error) {
+	identitiesOnce.Do(func() {
+		identities = make(map[string]string)
+
+		path := os.Getenv("OIDC_IDENTITIES")
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			identitiesErr = errors.Wrap(err, "failed to read identity mapping")
+			return
+		}
+		identitiesErr = errors.Wrap(json.Unmarshal(data, &identities), "failed to unmarshal identity mapping")
+	})
+	if identitiesErr != nil {
+		return "", identitiesErr
+	}
+
+	if identity, ok := identities[user]; ok {
+		return identity, nil
+	}
+	return os.Getenv("OIDC_DEFAULT_IDENTITY"), nil
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// AddRoutes registers the login, callback, logout and session routes
+func AddRoutes(rg *gin.RouterGroup) {
+	rg.GET("/login", loginHandler)
+	rg.GET("/callback", callbackHandler)
+	rg.GET("/logout", logoutHandler)
+	rg.POST("/logout", logoutHandler)
+	rg.GET("/session", func(c *gin.Context) {
+		session := getSession(c)
+		if session == nil {
+			common.Abort(c, http.StatusUnauthorized, errors.New("not logged in"))
+			return
+		}
+		c.JSON(http.StatusOK, session)
+	})
+}
+
+// loginHandler redirects to the provider, which redirects back to the
+// callback. The redirect query parameter is the page to return to.
+func loginHandler(c *gin.Context) {
+	d, err := getDiscovery()
+	if err != nil {
+		common.Abort(c, http.StatusBadGateway, err)
+		return
+	}
+
+	// Only pages of the CCAPI can be returned to
+	redirect := c.Query("redirect")
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") {
+		redirect = "/console/"
+	}
+
+	loginState := randomString()
+	l := &login{
+		Verifier: randomString(),
+		Nonce:    randomString(),
+		Redirect: redirect,
+	}
+	data, _ := json.Marshal(l)
+	err = state.Default().Set(loginPrefix+loginState, data, loginTimeout)
+	if err != nil {
+		common.Abort(c, http.StatusServiceUnavailable, errors.Wrap(err, "failed to start login"))
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(l.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {os.Getenv("OIDC_CLIENT_ID")},
+		"redirect_uri":          {os.Getenv("OIDC_REDIRECT_URL")},
+		"scope":                 {getScopes()},
+		"state":                 {loginState},
+		"nonce":                 {l.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	c.Redirect(http.StatusFound, d.AuthorizationEndpoint+separator+query.Encode())
+}
+
+// callbackHandler completes the login started by loginHandler and starts
+// the session
+func callbackHandler(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		common.Abort(c, http.StatusUnauthorized, errors.Errorf("login failed: %s %s", e, c.Query("error_description")))
+		return
+	}
+
+	store := state.Default()
+	data, ok, err := store.Get(loginPrefix + c.Query("state"))
+	if err != nil {
+		common.Abort(c, http.StatusServiceUnavailable, errors.Wrap(err, "failed to complete login"))
+		return
+	}
+	store.Delete(loginPrefix + c.Query("state"))
+	var l login
+	if !ok || json.Unmarshal(data, &l) != nil {
+		common.Abort(c, http.StatusBadRequest, errors.New("unknown or expired login, try again"))
+		return
+	}
+
+	idToken, err := exchangeCode(c.Query("code"), l.Verifier)
+	if err != nil {
+		common.Abort(c, http.StatusBadGateway, err)
+		return
+	}
+	claims, err := verifyIDToken(idToken, l.Nonce)
+	if err != nil {
+		common.Abort(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	user := fmt.Sprint(claims[getUserClaim()])
+	if claims[getUserClaim()] == nil {
+		user = fmt.Sprint(claims["sub"])
+	}
+	identity, err := mapIdentity(user)
+	if err != nil {
+		common.Abort(c, http.StatusInternalServerError, err)
+		return
+	}
+	if identity == "" {
+		common.Abort(c, http.StatusForbidden, errors.Errorf("user '%s' is not mapped to a Fabric identity", user))
+		return
+	}
+
+	id := randomString()
+	session := &Session{
+		User:      user,
+		Identity:  identity,
+		ExpiresAt: time.Now().Add(getSessionTTL()).UTC(),
+	}
+	data, _ = json.Marshal(session)
+	err = store.Set(sessionPrefix+id, data, getSessionTTL())
+	if err != nil {
+		common.Abort(c, http.StatusServiceUnavailable, errors.Wrap(err, "failed to start session"))
+		return
+	}
+	log.Printf("user '%s' logged in as '%s'\n", user, identity)
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     cookieName,
+		Value:    id,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(os.Getenv("OIDC_REDIRECT_URL"), "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	c.Redirect(http.StatusFound, l.Redirect)
+}
+
+// logoutHandler ends the session and, if the provider supports it, the
+// session at the provider
+func logoutHandler(c *gin.Context) {
+	if id, err := c.Cookie(cookieName); err == nil {
+		state.Default().Delete(sessionPrefix + id)
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     cookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	if d, err := getDiscovery(); err == nil && d.EndSessionEndpoint != "" {
+		c.Redirect(http.StatusFound, d.EndSessionEndpoint+"?client_id="+url.QueryEscape(os.Getenv("OIDC_CLIENT_ID")))
+		return
+	}
+	c.Redirect(http.StatusFound, "/")
+}
+
+// getSession returns the session of the request cookie, if valid
+func getSession(c *gin.Context) *Session {
+	id, err := c.Cookie(cookieName)
+	if err != nil {
+		return nil
+	}
+
+	data, ok, err := state.Default().Get(sessionPrefix + id)
+	if err != nil {
+		log.Println("error reading session: ", err)
+		return nil
+	}
+	var session Session
+	if !ok || json.Unmarshal(data, &session) != nil || time.Now().After(session.ExpiresAt) {
+		return nil
+	}
+	return &session
+}
+
+// Middleware makes requests with a session act as its Fabric identity,
+// instead of the one in the User and Org headers
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled() {
+			c.Next()
+			return
+		}
+
+		if session := getSession(c); session != nil {
+			c.Set(common.IdentityKey, session.Identity)
+			c.Set(common.SessionUserKey, session.User)
+		}
+		c.Next()
+	}
+}
+
+// RequireSession sends browsers without a session to the login, which
+// returns them to the requested page
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := getSession(c)
+		if session == nil {
+			c.Redirect(http.StatusFound, "/auth/login?redirect="+url.QueryEscape(c.Request.URL.RequestURI()))
+			c.Abort()
+			return
+		}
+
+		c.Set(common.IdentityKey, session.Identity)
+		c.Set(common.SessionUserKey, session.User)
+		c.Next()
+	}
+}
+
+// RequireAdmin only allows sessions mapped to the Admin user of an
+// organization
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := getSession(c)
+		if session == nil {
+			c.Redirect(http.StatusFound, "/auth/login?redirect="+url.QueryEscape(c.Request.URL.RequestURI()))
+			c.Abort()
+			return
+		}
+
+		user := strings.SplitN(session.Identity, "@", 2)[0]
+		if user != "Admin" {
+			common.Abort(c, http.StatusForbidden, errors.Errorf("user '%s' is not an admin", session.User))
+			return
+		}
+
+		c.Set(common.IdentityKey, session.Identity)
+		c.Set(common.SessionUserKey, session.User)
+		c.Next()
+	}
+}
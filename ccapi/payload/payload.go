@@ -0,0 +1,202 @@
+// Package payload lets bandwidth-sensitive or embedded clients exchange
+// CBOR (application/cbor) or protobuf (application/x-protobuf) bodies
+// instead of JSON. Request bodies are converted to JSON before reaching the
+// handlers, and JSON responses are converted to the encoding negotiated by
+// the Accept header.
+//
+// Protobuf messages are generated from the asset schema: bodies of the asset
+// resources are encoded as the message of their asset type, and other bodies
+// as a google.protobuf.Value. The message type is set in the proto parameter
+// of the content type, e.g. application/x-protobuf; proto=cctools.Book.
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+)
+
+// Media types of the supported encodings
+const (
+	MIMEJSON     = "application/json"
+	MIMECBOR     = "application/cbor"
+	MIMEProtobuf = "application/x-protobuf"
+)
+
+var cborHandle = &codec.CborHandle{}
+
+func init() {
+	cborHandle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+}
+
+// Middleware converts CBOR and protobuf requests and responses of the group
+// from and to JSON, while the binaryPayloads feature is enabled
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !features.Enabled("binaryPayloads") {
+			c.Next()
+			return
+		}
+
+		if c.Request.Body != nil && c.Request.ContentLength != 0 {
+			mediaType, params, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+			if mediaType == MIMECBOR || mediaType == MIMEProtobuf || mediaType == "application/protobuf" {
+				body, err := io.ReadAll(c.Request.Body)
+				if err == nil {
+					body, err = decodeRequest(c, mediaType, params["proto"], body)
+				}
+				if err != nil {
+					common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "invalid "+mediaType+" body"))
+					c.Abort()
+					return
+				}
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+				c.Request.ContentLength = int64(len(body))
+				c.Request.Header.Set("Content-Type", MIMEJSON)
+			}
+		}
+
+		format := c.NegotiateFormat(MIMEJSON, MIMECBOR, MIMEProtobuf)
+		if format != MIMECBOR && format != MIMEProtobuf {
+			c.Next()
+			return
+		}
+
+		writer := &encodingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.body == nil {
+			return
+		}
+		body, contentType, err := encodeResponse(c, format, writer.body.Bytes())
+		if err != nil {
+			log.Println("error encoding response: ", err)
+			body, contentType = writer.body.Bytes(), writer.contentType
+		}
+		c.Writer.Header().Set("Content-Type", contentType)
+		c.Writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		c.Writer.WriteHeaderNow()
+		c.Writer.Write(body)
+	}
+}
+
+// encodingWriter holds JSON responses so they can be encoded before being
+// sent. Other responses, such as streams, are written as they are.
+type encodingWriter struct {
+	gin.ResponseWriter
+	body        *bytes.Buffer
+	contentType string
+	passThrough bool
+}
+
+func (w *encodingWriter) buffer() bool {
+	if w.body == nil && !w.passThrough {
+		w.contentType = w.ResponseWriter.Header().Get("Content-Type")
+		mediaType, _, _ := mime.ParseMediaType(w.contentType)
+		if mediaType == MIMEJSON || strings.HasSuffix(mediaType, "+json") {
+			w.body = &bytes.Buffer{}
+		} else {
+			w.passThrough = true
+		}
+	}
+	return w.body != nil
+}
+
+func (w *encodingWriter) Write(b []byte) (int, error) {
+	if w.buffer() {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *encodingWriter) WriteString(s string) (int, error) {
+	if w.buffer() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// WriteHeaderNow is deferred for buffered responses, whose length is only
+// known once encoded
+func (w *encodingWriter) WriteHeaderNow() {
+	if !w.buffer() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// decodeRequest converts a CBOR or protobuf request body to JSON
+func decodeRequest(c *gin.Context, mediaType, messageType string, body []byte) ([]byte, error) {
+	if mediaType == MIMECBOR {
+		var v interface{}
+		err := codec.NewDecoderBytes(body, cborHandle).Decode(&v)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	}
+
+	if messageType == "" {
+		messageType = routeMessageType(c)
+	}
+	return protoToJSON(messageType, body)
+}
+
+// encodeResponse converts a JSON response body to CBOR or protobuf,
+// returning it with its content type
+func encodeResponse(c *gin.Context, format string, body []byte) ([]byte, string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	err := decoder.Decode(&v)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if format == MIMECBOR {
+		var res []byte
+		err = codec.NewEncoderBytes(&res, cborHandle).Encode(numbers(v))
+		return res, MIMECBOR, err
+	}
+
+	res, messageType, err := jsonToProto(v)
+	if err != nil {
+		return nil, "", err
+	}
+	return res, MIMEProtobuf + "; proto=" + messageType, nil
+}
+
+// numbers converts JSON numbers to integers when they have no fraction, so
+// they are encoded as CBOR integers, and to floats otherwise
+func numbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = numbers(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = numbers(v[k])
+		}
+	}
+	return v
+}
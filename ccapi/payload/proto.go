@@ -0,0 +1,389 @@
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// protoPackage is the package of the messages generated from the schema
+const protoPackage = "cctools"
+
+// valueMessage is the message of bodies that are not assets
+const valueMessage = "google.protobuf.Value"
+
+// metadataFields are the fields cc-tools sets on every asset, numbered
+// before the asset properties
+var metadataFields = []struct {
+	name, jsonName string
+}{
+	{"_key", "@key"},
+	{"_asset_type", "@assetType"},
+	{"_last_touch_by", "@lastTouchBy"},
+	{"_last_tx", "@lastTx"},
+	{"_last_updated", "@lastUpdated"},
+}
+
+// firstPropField is the number of the field of the first asset property
+const firstPropField = 16
+
+type schemaProp struct {
+	Tag      string `json:"tag"`
+	DataType string `json:"dataType"`
+}
+
+type schemaAssetType struct {
+	Tag   string       `json:"tag"`
+	Props []schemaProp `json:"props"`
+}
+
+var (
+	schemaMutex sync.Mutex
+	file        protoreflect.FileDescriptor
+	fileSource  string
+)
+
+func init() {
+	// Generate the definitions again once the chaincode is upgraded
+	chaincode.OnUpgrade(func(channelName, chaincodeName string) {
+		if channelName != os.Getenv("CHANNEL") || chaincodeName != os.Getenv("CCNAME") {
+			return
+		}
+		schemaMutex.Lock()
+		file, fileSource = nil, ""
+		schemaMutex.Unlock()
+	})
+}
+
+// messageName returns the name of the message of the asset type
+func messageName(assetType string) string {
+	return strings.ToUpper(assetType[:1]) + assetType[1:]
+}
+
+// getFile returns the protobuf definitions generated from the asset schema,
+// which are kept in memory after the first read until the chaincode is
+// upgraded
+func getFile() (protoreflect.FileDescriptor, string, error) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+
+	if file != nil {
+		return file, fileSource, nil
+	}
+
+	assetTypes, dataTypes, err := readSchema()
+	if err != nil {
+		return nil, "", err
+	}
+
+	fdp := buildFile(assetTypes, dataTypes)
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to build protobuf definitions")
+	}
+	file, fileSource = fd, printFile(fdp)
+	return file, fileSource, nil
+}
+
+// readSchema reads the asset types with their properties and the accepted
+// formats of the data types from the chaincode
+func readSchema() ([]schemaAssetType, map[string][]string, error) {
+	user := os.Getenv("USER")
+	query := func(txName string, args interface{}, v interface{}) error {
+		argsBytes, _ := json.Marshal(args)
+		res, err := chaincode.QueryGateway(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), txName, user, []string{string(argsBytes)})
+		if err != nil {
+			return errors.Wrap(err, "failed to read "+txName)
+		}
+		return json.Unmarshal(res, v)
+	}
+
+	var list []schemaAssetType
+	err := query("getSchema", map[string]interface{}{}, &list)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range list {
+		err = query("getSchema", map[string]interface{}{"assetType": list[i].Tag}, &list[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var dataTypes map[string]struct {
+		AcceptedFormats []string `json:"acceptedFormats"`
+	}
+	err = query("getDataTypes", map[string]interface{}{}, &dataTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	formats := make(map[string][]string)
+	for name, dataType := range dataTypes {
+		formats[name] = dataType.AcceptedFormats
+	}
+	return list, formats, nil
+}
+
+// buildFile generates a message for each asset type, and one for the
+// references between assets
+func buildFile(assetTypes []schemaAssetType, dataTypes map[string][]string) *descriptorpb.FileDescriptorProto {
+	field := func(name, jsonName string, number int32, repeated bool, t descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     t.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+
+	reference := &descriptorpb.DescriptorProto{
+		Name: proto.String("Reference"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("_key", "@key", 1, false, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			field("_asset_type", "@assetType", 2, false, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		},
+	}
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(protoPackage + ".proto"),
+		Package:     proto.String(protoPackage),
+		Syntax:      proto.String("proto2"),
+		Dependency:  []string{"google/protobuf/struct.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{reference},
+	}
+
+	for _, assetType := range assetTypes {
+		msg := &descriptorpb.DescriptorProto{
+			Name: proto.String(messageName(assetType.Tag)),
+		}
+		for i, meta := range metadataFields {
+			msg.Field = append(msg.Field, field(meta.name, meta.jsonName, int32(i+1), false, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""))
+		}
+		for i, prop := range assetType.Props {
+			repeated := strings.HasPrefix(prop.DataType, "[]")
+			t, typeName := fieldType(strings.TrimPrefix(prop.DataType, "[]"), dataTypes)
+			msg.Field = append(msg.Field, field(prop.Tag, prop.Tag, int32(firstPropField+i), repeated, t, typeName))
+		}
+		fdp.MessageType = append(fdp.MessageType, msg)
+	}
+	return fdp
+}
+
+// fieldType maps a cc-tools data type to a protobuf type. Custom data types
+// take the type of their first accepted format.
+func fieldType(dataType string, dataTypes map[string][]string) (descriptorpb.FieldDescriptorProto_Type, string) {
+	if strings.HasPrefix(dataType, "->") {
+		return descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, "." + protoPackage + ".Reference"
+	}
+
+	format := dataType
+	switch dataType {
+	case "string", "number", "integer", "boolean", "datetime", "@object":
+	default:
+		if formats := dataTypes[dataType]; len(formats) > 0 {
+			format = formats[0]
+		}
+	}
+
+	switch format {
+	case "number":
+		return descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, ""
+	case "integer":
+		return descriptorpb.FieldDescriptorProto_TYPE_INT64, ""
+	case "boolean":
+		return descriptorpb.FieldDescriptorProto_TYPE_BOOL, ""
+	case "@object":
+		return descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Struct"
+	default:
+		return descriptorpb.FieldDescriptorProto_TYPE_STRING, ""
+	}
+}
+
+// printFile writes the definitions in the protobuf language
+func printFile(fdp *descriptorpb.FileDescriptorProto) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "syntax = %q;\n\npackage %s;\n\n", fdp.GetSyntax(), fdp.GetPackage())
+	for _, dep := range fdp.Dependency {
+		fmt.Fprintf(&b, "import %q;\n", dep)
+	}
+
+	for _, msg := range fdp.MessageType {
+		fmt.Fprintf(&b, "\nmessage %s {\n", msg.GetName())
+		for _, f := range msg.Field {
+			typeName := strings.TrimPrefix(f.GetTypeName(), "."+protoPackage+".")
+			if typeName == "" {
+				typeName = strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+			} else {
+				typeName = strings.TrimPrefix(typeName, ".")
+			}
+			label := strings.ToLower(strings.TrimPrefix(f.GetLabel().String(), "LABEL_"))
+			fmt.Fprintf(&b, "  %s %s %s = %d [json_name = %q];\n", label, typeName, f.GetName(), f.GetNumber(), f.GetJsonName())
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// ProtoFile returns the protobuf definitions generated from the asset schema
+func ProtoFile() (string, error) {
+	_, source, err := getFile()
+	return source, err
+}
+
+// findMessage returns the message of the asset type, if any
+func findMessage(assetType string) protoreflect.MessageDescriptor {
+	if assetType == "" {
+		return nil
+	}
+	fd, _, err := getFile()
+	if err != nil {
+		return nil
+	}
+	return fd.Messages().ByName(protoreflect.Name(messageName(assetType)))
+}
+
+// routeMessageType returns the message of the bodies of the asset resource
+// of the route, or of a google.protobuf.Value for other routes
+func routeMessageType(c *gin.Context) string {
+	path := strings.TrimPrefix(c.FullPath(), "/api/assets/")
+	if path == c.FullPath() {
+		return valueMessage
+	}
+	assetType := strings.SplitN(path, "/", 2)[0]
+	if md := findMessage(assetType); md != nil {
+		return string(md.FullName())
+	}
+	return valueMessage
+}
+
+// protoToJSON decodes a message of the type and encodes it as JSON
+func protoToJSON(messageType string, body []byte) ([]byte, error) {
+	if messageType == valueMessage {
+		var value structpb.Value
+		err := proto.Unmarshal(body, &value)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(value.AsInterface())
+	}
+
+	md := findMessage(strings.TrimPrefix(messageType, protoPackage+"."))
+	if md == nil || string(md.FullName()) != messageType {
+		return nil, errors.Errorf("unknown message type '%s'", messageType)
+	}
+	msg := dynamicpb.NewMessage(md)
+	err := proto.Unmarshal(body, msg)
+	if err != nil {
+		return nil, err
+	}
+	v, err := messageToJSON(msg)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// messageToJSON converts the message to a JSON object, keeping 64-bit
+// integers as numbers, unlike protojson
+func messageToJSON(msg protoreflect.Message) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsList() {
+			list := make([]interface{}, v.List().Len())
+			for i := range list {
+				list[i], err = fieldToJSON(fd, v.List().Get(i))
+				if err != nil {
+					return false
+				}
+			}
+			res[fd.JSONName()] = list
+			return true
+		}
+		res[fd.JSONName()], err = fieldToJSON(fd, v)
+		return err == nil
+	})
+	return res, err
+}
+
+func fieldToJSON(fd protoreflect.FieldDescriptor, v protoreflect.Value) (interface{}, error) {
+	if fd.Kind() != protoreflect.MessageKind {
+		return v.Interface(), nil
+	}
+	if fd.Message().FullName() == "google.protobuf.Struct" {
+		data, err := protojson.Marshal(v.Message().Interface())
+		if err != nil {
+			return nil, err
+		}
+		var obj interface{}
+		err = json.Unmarshal(data, &obj)
+		return obj, err
+	}
+	return messageToJSON(v.Message())
+}
+
+// jsonToProto encodes the JSON value as the message of its asset type, or
+// as a google.protobuf.Value if it is not an asset
+func jsonToProto(v interface{}) ([]byte, string, error) {
+	if obj, ok := v.(map[string]interface{}); ok {
+		assetType, _ := obj["@assetType"].(string)
+		if md := findMessage(assetType); md != nil {
+			data, _ := json.Marshal(obj)
+			msg := dynamicpb.NewMessage(md)
+			err := protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+			if err != nil {
+				return nil, "", err
+			}
+			res, err := proto.Marshal(msg)
+			return res, string(md.FullName()), err
+		}
+	}
+
+	value, err := structpb.NewValue(numbersToFloats(v))
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := proto.Marshal(value)
+	return res, valueMessage, err
+}
+
+// numbersToFloats converts JSON numbers to the floats of protobuf values
+func numbersToFloats(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = numbersToFloats(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = numbersToFloats(v[k])
+		}
+	}
+	return v
+}
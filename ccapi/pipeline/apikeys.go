@@ -0,0 +1,40 @@
+package pipeline
+
+import "sync"
+
+var (
+	apiKeysMutex sync.RWMutex
+	apiKeys      = make(map[string]string)
+)
+
+// SetAPIKeys replaces the named API keys the auth middlewares accept by
+// name, so they can be rotated while the CCAPI runs
+func SetAPIKeys(keys map[string]string) {
+	apiKeysMutex.Lock()
+	defer apiKeysMutex.Unlock()
+	apiKeys = make(map[string]string, len(keys))
+	for name, key := range keys {
+		apiKeys[name] = key
+	}
+}
+
+// APIKeys returns the named API keys
+func APIKeys() map[string]string {
+	apiKeysMutex.RLock()
+	defer apiKeysMutex.RUnlock()
+	keys := make(map[string]string, len(apiKeys))
+	for name, key := range apiKeys {
+		keys[name] = key
+	}
+	return keys
+}
+
+// namedAPIKey returns the API key of the name, if set
+func namedAPIKey(name string) []byte {
+	apiKeysMutex.RLock()
+	defer apiKeysMutex.RUnlock()
+	if key := apiKeys[name]; key != "" {
+		return []byte(key)
+	}
+	return nil
+}
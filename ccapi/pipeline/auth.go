@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// auth only lets through requests with one of the API keys of the options,
+// or of the named API keys set at runtime, as a bearer token or in the
+// header of the options, or with a browser session, when the oidc
+// middleware runs before it
+func auth(data json.RawMessage) (gin.HandlerFunc, error) {
+	var opts struct {
+		Keys    []string `json:"keys"`
+		APIKeys []string `json:"apiKeys"`
+		Header  string   `json:"header"`
+	}
+	if err := options(data, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Header == "" {
+		opts.Header = "Authorization"
+	}
+	keys := make([][]byte, 0, len(opts.Keys))
+	for _, key := range opts.Keys {
+		if key != "" {
+			keys = append(keys, []byte(key))
+		}
+	}
+	if len(opts.Keys) > 0 && len(keys) == 0 {
+		return nil, errors.New("keys are empty, check their environment variables")
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := c.Get(common.SessionUserKey); ok {
+			c.Next()
+			return
+		}
+
+		value := c.GetHeader(opts.Header)
+		if strings.EqualFold(opts.Header, "Authorization") {
+			value = strings.TrimPrefix(value, "Bearer ")
+		}
+		if value != "" {
+			for _, key := range keys {
+				if subtle.ConstantTimeCompare([]byte(value), key) == 1 {
+					c.Next()
+					return
+				}
+			}
+			for _, name := range opts.APIKeys {
+				if key := namedAPIKey(name); key != nil && subtle.ConstantTimeCompare([]byte(value), key) == 1 {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.Header("WWW-Authenticate", "Bearer")
+		common.Abort(c, http.StatusUnauthorized, errors.New("a valid API key is required"))
+		c.Abort()
+	}, nil
+}
@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/cassette"
+	"github.com/hyperledger-labs/ccapi/chaos"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/compat"
+	"github.com/hyperledger-labs/ccapi/dashboard"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/jsonld"
+	"github.com/hyperledger-labs/ccapi/oidc"
+	"github.com/hyperledger-labs/ccapi/payload"
+	"github.com/hyperledger-labs/ccapi/transform"
+	"github.com/hyperledger-labs/ccapi/versioning"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	// Middlewares of the CCAPI, which take no options
+	for name, middleware := range map[string]func() gin.HandlerFunc{
+		"timer":      common.Timer,
+		"dashboard":  dashboard.Middleware,
+		"cassette":   cassette.Middleware,
+		"compat":     compat.Middleware,
+		"chaos":      chaos.Middleware,
+		"payload":    payload.Middleware,
+		"jsonld":     jsonld.Middleware,
+		"versioning": versioning.Middleware,
+		"transform":  transform.Middleware,
+		"oidc":       oidc.Middleware,
+		"session":    oidc.RequireSession,
+		"admin":      oidc.RequireAdmin,
+	} {
+		Register(name, noOptions(middleware))
+	}
+
+	Register("feature", func(data json.RawMessage) (gin.HandlerFunc, error) {
+		var opts struct {
+			Name string `json:"name"`
+		}
+		if err := options(data, &opts); err != nil {
+			return nil, err
+		}
+		if opts.Name == "" {
+			return nil, errors.New("name is required")
+		}
+		return features.Require(opts.Name), nil
+	})
+	Register("auth", auth)
+	Register("rateLimit", rateLimit)
+	Register("cache", cache)
+	Register("validation", validation)
+	Register("plugin", pluginAuth)
+}
+
+// noOptions registers a middleware without options
+func noOptions(middleware func() gin.HandlerFunc) Factory {
+	return func(data json.RawMessage) (gin.HandlerFunc, error) {
+		if len(data) > 0 && string(data) != "null" {
+			return nil, errors.New("takes no options")
+		}
+		return middleware(), nil
+	}
+}
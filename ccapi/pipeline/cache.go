@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// maxCachedSize is the largest response body cached
+const maxCachedSize = 1 << 20
+
+// cachedResponse is a response kept by a cache middleware
+type cachedResponse struct {
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// cache keeps the successful GET responses of the group for the ttl of the
+// options, for each user. Any successful request with another method, which
+// may change what the responses return, empties it.
+func cache(data json.RawMessage) (gin.HandlerFunc, error) {
+	opts := struct {
+		TTL        string `json:"ttl"`
+		MaxEntries int    `json:"maxEntries"`
+	}{TTL: "5s", MaxEntries: 1000}
+	if err := options(data, &opts); err != nil {
+		return nil, err
+	}
+	ttl, err := time.ParseDuration(opts.TTL)
+	if err != nil || ttl <= 0 {
+		return nil, errors.New("ttl must be a positive duration, such as 10s")
+	}
+	if opts.MaxEntries <= 0 {
+		return nil, errors.New("maxEntries must be positive")
+	}
+
+	var mutex sync.Mutex
+	entries := make(map[string]*cachedResponse)
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			if c.Writer.Status() < http.StatusBadRequest {
+				mutex.Lock()
+				entries = make(map[string]*cachedResponse)
+				mutex.Unlock()
+			}
+			return
+		}
+
+		key := common.GetUser(c) + " " + c.GetHeader("Accept") + " " + c.Request.URL.RequestURI()
+		now := time.Now()
+		mutex.Lock()
+		entry, ok := entries[key]
+		mutex.Unlock()
+		if ok && now.Before(entry.expires) {
+			c.Header("X-Cache", "HIT")
+			c.Data(http.StatusOK, entry.contentType, entry.body)
+			c.Abort()
+			return
+		}
+
+		c.Header("X-Cache", "MISS")
+		writer := &teeWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.Status() != http.StatusOK || writer.body.Len() > maxCachedSize {
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if len(entries) >= opts.MaxEntries {
+			for k, e := range entries {
+				if now.After(e.expires) {
+					delete(entries, k)
+				}
+			}
+			// Drop any entry if none expired
+			for k := range entries {
+				if len(entries) < opts.MaxEntries {
+					break
+				}
+				delete(entries, k)
+			}
+		}
+		entries[key] = &cachedResponse{
+			contentType: writer.Header().Get("Content-Type"),
+			body:        writer.body.Bytes(),
+			expires:     now.Add(ttl),
+		}
+	}, nil
+}
+
+// teeWriter writes the response and keeps a copy of its body, up to the
+// largest body cached
+type teeWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *teeWriter) Write(b []byte) (int, error) {
+	if w.body.Len() <= maxCachedSize {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *teeWriter) WriteString(s string) (int, error) {
+	if w.body.Len() <= maxCachedSize {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,171 @@
+// Package pipeline composes the middlewares of each route group from the
+// file set by PIPELINE_CONFIG, so deployments can add authentication, rate
+// limits, caching or validation to some routes without code changes. The
+// file maps the path of a group to its middlewares, by name or with their
+// options, run in order:
+//
+//	{
+//	  "/api": ["timer", "dashboard", "cassette", "compat", "chaos", "payload", "jsonld", "versioning", "transform", "oidc",
+//	           {"name": "rateLimit", "options": {"rate": 20, "burst": 40, "by": "user"}}],
+//	  "/api/assets": [{"name": "cache", "options": {"ttl": "10s"}}],
+//	  "/api/tokens": [{"name": "auth", "options": {"keys": ["${TOKENS_API_KEY}"]}}]
+//	}
+//
+// Groups run the middlewares of their parent group first. Groups not in the
+// file use their default middlewares, and a group in the file replaces them.
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// Factory creates a middleware with its options, which are nil if none are
+// set
+type Factory func(options json.RawMessage) (gin.HandlerFunc, error)
+
+// Middleware is a middleware of a group in the configuration
+type Middleware struct {
+	Name    string          `json:"name"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// UnmarshalJSON accepts a middleware as its name alone
+func (m *Middleware) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &m.Name)
+	}
+	type middleware Middleware
+	return json.Unmarshal(data, (*middleware)(m))
+}
+
+var (
+	factoriesMutex sync.Mutex
+	factories      = make(map[string]Factory)
+
+	config     map[string][]Middleware
+	configOnce sync.Once
+
+	// used holds the groups registered, to report the unknown groups of
+	// the configuration
+	used = make(map[string]bool)
+)
+
+// Register makes a middleware available to the configuration under the
+// name
+func Register(name string, factory Factory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	factories[name] = factory
+}
+
+// Names returns the names of the middlewares available, sorted
+func Names() []string {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getConfig returns the middlewares of the groups in the file set by
+// PIPELINE_CONFIG, read once
+func getConfig() map[string][]Middleware {
+	configOnce.Do(func() {
+		config = make(map[string][]Middleware)
+		path := os.Getenv("PIPELINE_CONFIG")
+		if path == "" {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalln("invalid pipeline configuration: ", errors.Wrap(err, "failed to read file"))
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&config)
+		if err != nil {
+			log.Fatalln("invalid pipeline configuration: ", errors.Wrap(err, "failed to unmarshal file"))
+		}
+		log.Printf("composing route middlewares from '%s'\n", path)
+	})
+	return config
+}
+
+// Group creates the route group at the path of the parent with its
+// middlewares, from the configuration or the defaults
+func Group(parent *gin.RouterGroup, path string, defaults ...string) *gin.RouterGroup {
+	fullPath := strings.TrimSuffix(parent.BasePath(), "/") + path
+	used[fullPath] = true
+
+	middlewares, ok := getConfig()[fullPath]
+	if !ok {
+		for _, name := range defaults {
+			middlewares = append(middlewares, Middleware{Name: name})
+		}
+	}
+
+	handlers, err := build(middlewares)
+	if err != nil {
+		log.Fatalf("invalid pipeline configuration of '%s': %s\n", fullPath, err)
+	}
+	return parent.Group(path, handlers...)
+}
+
+// Check warns of the groups of the configuration that were not registered,
+// which are misspelled or disabled
+func Check() {
+	for path := range getConfig() {
+		if !used[path] {
+			log.Printf("pipeline configuration: route group '%s' is not registered\n", path)
+		}
+	}
+}
+
+// build creates the middlewares
+func build(middlewares []Middleware) ([]gin.HandlerFunc, error) {
+	handlers := make([]gin.HandlerFunc, 0, len(middlewares))
+	for _, m := range middlewares {
+		factoriesMutex.Lock()
+		factory, ok := factories[m.Name]
+		factoriesMutex.Unlock()
+		if !ok {
+			return nil, errors.Errorf("unknown middleware '%s', available: %s", m.Name, strings.Join(Names(), ", "))
+		}
+
+		handler, err := factory(m.Options)
+		if err != nil {
+			return nil, errors.Wrapf(err, "middleware '%s'", m.Name)
+		}
+		handlers = append(handlers, handler)
+	}
+	return handlers, nil
+}
+
+// options unmarshals the options of a middleware into v, expanding the
+// environment variables in them, so secrets can be kept out of the file
+func options(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	expanded := os.ExpandEnv(string(data))
+	decoder := json.NewDecoder(strings.NewReader(expanded))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		return errors.Wrap(err, "invalid options")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/plugins"
+	"github.com/pkg/errors"
+)
+
+// pluginAuth lets through the requests allowed by the authenticator plugin
+// of the options, acting as the identity it returns, if any
+func pluginAuth(data json.RawMessage) (gin.HandlerFunc, error) {
+	var opts struct {
+		Plugin string `json:"plugin"`
+	}
+	if err := options(data, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Plugin == "" {
+		return nil, errors.New("plugin is required")
+	}
+	p, err := plugins.Lookup(opts.Plugin, plugins.KindAuthenticator)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		headers := make(map[string]string, len(c.Request.Header))
+		for k := range c.Request.Header {
+			headers[k] = c.Request.Header.Get(k)
+		}
+		res, err := p.Authenticate(plugins.AuthRequest{
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Headers:  headers,
+			ClientIP: c.ClientIP(),
+		})
+		if err != nil {
+			common.Abort(c, http.StatusServiceUnavailable, errors.Wrap(err, "failed to authenticate request"))
+			c.Abort()
+			return
+		}
+		if !res.Allowed {
+			if res.Message == "" {
+				res.Message = "authentication failed"
+			}
+			common.Abort(c, http.StatusUnauthorized, errors.New(res.Message))
+			c.Abort()
+			return
+		}
+
+		if res.Identity != "" {
+			c.Set(common.IdentityKey, res.Identity)
+		}
+		c.Next()
+	}, nil
+}
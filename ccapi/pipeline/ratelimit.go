@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/state"
+	"github.com/pkg/errors"
+)
+
+// rateLimits counts the rateLimit middlewares, so each keeps its own
+// buckets. The pipeline is built in the same order by every replica.
+var rateLimits int32
+
+// rateLimit limits the requests of each client, by IP or by user, to the
+// rate per second of the options, allowing bursts of up to burst requests.
+// The buckets are kept in the shared state, so the limit applies to the
+// requests of a client to every replica.
+func rateLimit(data json.RawMessage) (gin.HandlerFunc, error) {
+	var opts struct {
+		Rate  float64 `json:"rate"`
+		Burst int     `json:"burst"`
+		By    string  `json:"by"`
+	}
+	if err := options(data, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Rate <= 0 {
+		return nil, errors.New("rate must be positive")
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = int(math.Ceil(opts.Rate))
+	}
+	switch opts.By {
+	case "":
+		opts.By = "ip"
+	case "ip", "user":
+	default:
+		return nil, errors.Errorf("by must be ip or user, not '%s'", opts.By)
+	}
+
+	id := atomic.AddInt32(&rateLimits, 1)
+
+	return func(c *gin.Context) {
+		client := c.ClientIP()
+		if opts.By == "user" {
+			client = common.GetUser(c)
+		}
+
+		allowed, wait, err := state.Default().Take(fmt.Sprintf("ratelimit:%d:%s", id, client), opts.Rate, opts.Burst)
+		if err != nil {
+			// Requests are let through while the shared state is unreachable
+			log.Println("error checking rate limit: ", err)
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			common.Abort(c, http.StatusTooManyRequests, &common.APIError{
+				Status:  http.StatusTooManyRequests,
+				Code:    common.ErrCodeRateLimited,
+				Message: fmt.Sprintf("rate limit of %g requests per second exceeded", opts.Rate),
+				Details: map[string]interface{}{
+					"rate":  opts.Rate,
+					"burst": opts.Burst,
+				},
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}, nil
+}
@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// validation rejects request bodies over maxBodySize bytes, with a content
+// type not in contentTypes, or, with json set, that are not valid JSON
+func validation(data json.RawMessage) (gin.HandlerFunc, error) {
+	var opts struct {
+		MaxBodySize  int64    `json:"maxBodySize"`
+		ContentTypes []string `json:"contentTypes"`
+		JSON         bool     `json:"json"`
+	}
+	if err := options(data, &opts); err != nil {
+		return nil, err
+	}
+	if opts.MaxBodySize < 0 {
+		return nil, errors.New("maxBodySize must not be negative")
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if len(opts.ContentTypes) > 0 && !contains(opts.ContentTypes, mediaType) {
+			common.Abort(c, http.StatusUnsupportedMediaType, errors.Errorf("content type '%s' is not accepted", mediaType))
+			c.Abort()
+			return
+		}
+
+		if opts.MaxBodySize == 0 && !opts.JSON {
+			c.Next()
+			return
+		}
+
+		reader := io.Reader(c.Request.Body)
+		if opts.MaxBodySize > 0 {
+			reader = io.LimitReader(reader, opts.MaxBodySize+1)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			common.Abort(c, http.StatusBadRequest, errors.Wrap(err, "failed to read body"))
+			c.Abort()
+			return
+		}
+		if opts.MaxBodySize > 0 && int64(len(body)) > opts.MaxBodySize {
+			common.Abort(c, http.StatusRequestEntityTooLarge, &common.APIError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Code:    common.ErrCodePayloadTooLarge,
+				Message: fmt.Sprintf("body is over the limit of %d bytes", opts.MaxBodySize),
+				Details: map[string]interface{}{"limit": opts.MaxBodySize},
+			})
+			c.Abort()
+			return
+		}
+		if opts.JSON && (mediaType == "" || mediaType == gin.MIMEJSON) && !json.Valid(body) {
+			common.Abort(c, http.StatusBadRequest, &common.APIError{
+				Status:  http.StatusBadRequest,
+				Code:    common.ErrCodeInvalidBody,
+				Message: "body is not valid JSON",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Next()
+	}, nil
+}
+
+// contains reports whether the list has the value
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
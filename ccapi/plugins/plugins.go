@@ -0,0 +1,304 @@
+// Package plugins runs the external plugin binaries of the CCAPI, so
+// organizations add proprietary integrations, such as their identity
+// provider, message bus, object store or HSM, without forking it. Plugins
+// implement stable interfaces (Authenticator, EventSink, Storage and
+// Signer) and are started by the CCAPI as child processes, listed in the
+// JSON file set by PLUGINS_CONFIG:
+//
+//	[
+//	  {"name": "vault", "path": "/plugins/vault-signer", "env": {"VAULT_ADDR": "${VAULT_ADDR}"}},
+//	  {"name": "kafka", "path": "/plugins/kafka-sink", "args": ["--topic", "ccapi"]}
+//	]
+//
+// The CCAPI calls the plugins with JSON-RPC over their standard input and
+// output, so they can be written in any language, and in Go with Serve.
+// Plugins that exit are started again on the next call.
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// handshakeTimeout is how long a plugin has to answer its handshake once
+// started
+const handshakeTimeout = 10 * time.Second
+
+// Config is a plugin of the configuration
+type Config struct {
+	Name string            `json:"name"`
+	Path string            `json:"path"`
+	Args []string          `json:"args,omitempty"`
+	Env  map[string]string `json:"env,omitempty"`
+}
+
+// Plugin is a plugin binary, started on demand. It implements the
+// interfaces of the plugin kinds, which fail for the kinds it is not.
+type Plugin struct {
+	config Config
+
+	mutex  sync.Mutex
+	cmd    *exec.Cmd
+	client *rpc.Client
+	info   Info
+}
+
+var (
+	plugins     map[string]*Plugin
+	pluginsOnce sync.Once
+)
+
+// getPlugins returns the plugins of the file set by PLUGINS_CONFIG, read
+// once
+func getPlugins() map[string]*Plugin {
+	pluginsOnce.Do(func() {
+		plugins = make(map[string]*Plugin)
+		path := os.Getenv("PLUGINS_CONFIG")
+		if path == "" {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalln("invalid plugins configuration: ", errors.Wrap(err, "failed to read file"))
+		}
+		var configs []Config
+		err = json.Unmarshal([]byte(os.ExpandEnv(string(data))), &configs)
+		if err != nil {
+			log.Fatalln("invalid plugins configuration: ", errors.Wrap(err, "failed to unmarshal file"))
+		}
+		for _, config := range configs {
+			if config.Name == "" || config.Path == "" {
+				log.Fatalln("invalid plugins configuration: plugins need a name and a path")
+			}
+			if _, ok := plugins[config.Name]; ok {
+				log.Fatalf("invalid plugins configuration: plugin '%s' is repeated\n", config.Name)
+			}
+			plugins[config.Name] = &Plugin{config: config}
+		}
+	})
+	return plugins
+}
+
+// Start starts the plugins of the configuration, so a plugin that cannot
+// start stops the CCAPI instead of failing its first requests
+func Start() {
+	for _, name := range Names() {
+		p := getPlugins()[name]
+		p.mutex.Lock()
+		_, err := p.start()
+		info := p.info
+		p.mutex.Unlock()
+		if err != nil {
+			log.Fatalln("failed to start plugin: ", err)
+		}
+		log.Printf("started plugin '%s' (%v)\n", name, info.Kinds)
+	}
+}
+
+// Names returns the names of the plugins of the configuration, sorted
+func Names() []string {
+	names := make([]string, 0, len(getPlugins()))
+	for name := range getPlugins() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the plugin of the name, which must be of the kind
+func Lookup(name, kind string) (*Plugin, error) {
+	p, ok := getPlugins()[name]
+	if !ok {
+		return nil, errors.Errorf("plugin '%s' is not configured", name)
+	}
+
+	p.mutex.Lock()
+	_, err := p.start()
+	info := p.info
+	p.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range info.Kinds {
+		if k == kind {
+			return p, nil
+		}
+	}
+	return nil, errors.Errorf("plugin '%s' is not a %s", name, kind)
+}
+
+// ByKind returns the plugins of the kind, sorted by name. Plugins that
+// cannot start are left out.
+func ByKind(kind string) []*Plugin {
+	res := make([]*Plugin, 0)
+	for _, name := range Names() {
+		p, err := Lookup(name, kind)
+		if err == nil {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// Name returns the name of the plugin
+func (p *Plugin) Name() string {
+	return p.config.Name
+}
+
+// start runs the plugin binary, if not running, and checks its handshake.
+// The caller must hold the mutex.
+func (p *Plugin) start() (*rpc.Client, error) {
+	if p.client != nil {
+		return p.client, nil
+	}
+
+	cmd := exec.Command(p.config.Path, p.config.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range p.config.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = &logWriter{prefix: fmt.Sprintf("plugin '%s': ", p.config.Name)}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to start plugin '%s'", p.config.Name)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to start plugin '%s'", p.config.Name)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to start plugin '%s'", p.config.Name)
+	}
+
+	client := jsonrpc.NewClient(&conn{ReadCloser: stdout, WriteCloser: stdin})
+	var info Info
+	call := client.Go("Plugin.Info", Empty{}, &info, nil)
+	select {
+	case <-call.Done:
+		err = call.Error
+	case <-time.After(handshakeTimeout):
+		err = errors.New("timeout")
+	}
+	if err == nil && info.ProtocolVersion != ProtocolVersion {
+		err = errors.Errorf("protocol version %d is not %d", info.ProtocolVersion, ProtocolVersion)
+	}
+	if err != nil {
+		client.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, errors.Wrapf(err, "handshake of plugin '%s' failed", p.config.Name)
+	}
+
+	go func() {
+		err := cmd.Wait()
+		log.Printf("plugin '%s' exited: %v\n", p.config.Name, err)
+	}()
+
+	p.cmd = cmd
+	p.client = client
+	p.info = info
+	return client, nil
+}
+
+// call calls the method of the plugin, starting it if needed. A plugin
+// that fails to answer is started again on the next call.
+func (p *Plugin) call(method string, args, reply interface{}) error {
+	p.mutex.Lock()
+	client, err := p.start()
+	p.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = client.Call("Plugin."+method, args, reply)
+	if err == nil {
+		return nil
+	}
+	if serverErr, ok := err.(rpc.ServerError); ok {
+		return errors.Errorf("plugin '%s': %s", p.config.Name, string(serverErr))
+	}
+
+	p.mutex.Lock()
+	if p.client == client {
+		client.Close()
+		p.cmd.Process.Kill()
+		p.client = nil
+	}
+	p.mutex.Unlock()
+	return errors.Wrapf(err, "plugin '%s' is unavailable", p.config.Name)
+}
+
+// Authenticate authenticates the request with the plugin
+func (p *Plugin) Authenticate(req AuthRequest) (AuthResult, error) {
+	var res AuthResult
+	err := p.call("Authenticate", req, &res)
+	return res, err
+}
+
+// Publish sends the event to the plugin
+func (p *Plugin) Publish(event Event) error {
+	return p.call("Publish", event, &Empty{})
+}
+
+// Get returns the value of the key in the plugin, and whether it is found
+func (p *Plugin) Get(key string) ([]byte, bool, error) {
+	var res StorageResponse
+	err := p.call("Get", StorageRequest{Key: key}, &res)
+	return res.Value, res.Found, err
+}
+
+// Put sets the value of the key in the plugin
+func (p *Plugin) Put(key string, value []byte) error {
+	return p.call("Put", StorageRequest{Key: key, Value: value}, &Empty{})
+}
+
+// Delete removes the key from the plugin
+func (p *Plugin) Delete(key string) error {
+	return p.call("Delete", StorageRequest{Key: key}, &Empty{})
+}
+
+// List returns the keys of the plugin with the prefix
+func (p *Plugin) List(prefix string) ([]string, error) {
+	var res StorageResponse
+	err := p.call("List", StorageRequest{Prefix: prefix}, &res)
+	return res.Keys, err
+}
+
+// Sign signs the digest with the key of the ID in the plugin
+func (p *Plugin) Sign(keyID string, digest []byte) ([]byte, error) {
+	var res SignResponse
+	err := p.call("Sign", SignRequest{KeyID: keyID, Digest: digest}, &res)
+	return res.Signature, err
+}
+
+// logWriter logs the output of a plugin, line by line
+type logWriter struct {
+	prefix string
+	buf    []byte
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	w.buf = append(w.buf, b...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		log.Println(w.prefix + string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(b), nil
+}
@@ -0,0 +1,102 @@
+package plugins
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProtocolVersion is the version of the protocol between the CCAPI and its
+// plugins, changed only by incompatible changes of the interfaces
+const ProtocolVersion = 1
+
+// Kinds of plugins, one for each interface
+const (
+	KindAuthenticator = "authenticator"
+	KindEventSink     = "eventSink"
+	KindStorage       = "storage"
+	KindSigner        = "signer"
+)
+
+// Authenticator decides whether requests are let through, and as which
+// identity
+type Authenticator interface {
+	Authenticate(req AuthRequest) (AuthResult, error)
+}
+
+// EventSink receives the events of the CCAPI, such as those posted to the
+// webhooks
+type EventSink interface {
+	Publish(event Event) error
+}
+
+// Storage is a key-value store
+type Storage interface {
+	Get(key string) ([]byte, bool, error)
+	Put(key string, value []byte) error
+	Delete(key string) error
+	List(prefix string) ([]string, error)
+}
+
+// Signer signs digests with the private key of an identity, such as one
+// kept in an HSM or a key vault
+type Signer interface {
+	Sign(keyID string, digest []byte) ([]byte, error)
+}
+
+// Info is the handshake of a plugin
+type Info struct {
+	Name            string   `json:"name"`
+	Kinds           []string `json:"kinds"`
+	ProtocolVersion int      `json:"protocolVersion"`
+}
+
+// AuthRequest is the request authenticated
+type AuthRequest struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers"`
+	ClientIP string            `json:"clientIp"`
+}
+
+// AuthResult is the decision of an authenticator. The identity, if set, is
+// the user the request acts as, such as user@org.
+type AuthResult struct {
+	Allowed  bool   `json:"allowed"`
+	Identity string `json:"identity,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Event is an event of the CCAPI
+type Event struct {
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Empty is the argument or reply of calls without one
+type Empty struct{}
+
+// StorageRequest is the argument of the calls of a storage
+type StorageRequest struct {
+	Key    string `json:"key,omitempty"`
+	Value  []byte `json:"value,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// StorageResponse is the reply of the calls of a storage
+type StorageResponse struct {
+	Value []byte   `json:"value,omitempty"`
+	Found bool     `json:"found"`
+	Keys  []string `json:"keys,omitempty"`
+}
+
+// SignRequest is the argument of the calls of a signer
+type SignRequest struct {
+	KeyID  string `json:"keyId"`
+	Digest []byte `json:"digest"`
+}
+
+// SignResponse is the reply of the calls of a signer
+type SignResponse struct {
+	Signature []byte `json:"signature"`
+}
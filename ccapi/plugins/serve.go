@@ -0,0 +1,151 @@
+package plugins
+
+import (
+	"io"
+	"log"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Serve answers the calls of the CCAPI to the plugin, implementing any of
+// Authenticator, EventSink, Storage and Signer, until the CCAPI stops it. It
+// is called from the main function of the plugin binary:
+//
+//	func main() {
+//	    plugins.Serve("vault", &vaultSigner{})
+//	}
+//
+// Standard output carries the calls, so what the plugin prints there is
+// sent to standard error instead, where the CCAPI logs it.
+func Serve(name string, impl interface{}) {
+	s := &server{name: name, impl: impl}
+	if len(s.kinds()) == 0 {
+		log.Fatalf("plugin '%s' implements none of the plugin interfaces\n", name)
+	}
+
+	server := rpc.NewServer()
+	err := server.RegisterName("Plugin", s)
+	if err != nil {
+		log.Fatalln("failed to register plugin: ", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	server.ServeCodec(jsonrpc.NewServerCodec(&conn{ReadCloser: os.Stdin, WriteCloser: stdout}))
+}
+
+// conn joins the output and the input of a plugin process
+type conn struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (c *conn) Close() error {
+	err := c.WriteCloser.Close()
+	if readErr := c.ReadCloser.Close(); err == nil {
+		err = readErr
+	}
+	return err
+}
+
+// server dispatches the calls to the plugin to its implementation
+type server struct {
+	name string
+	impl interface{}
+}
+
+// kinds returns the kinds of the implementation
+func (s *server) kinds() []string {
+	kinds := make([]string, 0)
+	if _, ok := s.impl.(Authenticator); ok {
+		kinds = append(kinds, KindAuthenticator)
+	}
+	if _, ok := s.impl.(EventSink); ok {
+		kinds = append(kinds, KindEventSink)
+	}
+	if _, ok := s.impl.(Storage); ok {
+		kinds = append(kinds, KindStorage)
+	}
+	if _, ok := s.impl.(Signer); ok {
+		kinds = append(kinds, KindSigner)
+	}
+	return kinds
+}
+
+// notImplemented is the error of calls to an interface the plugin does not
+// implement
+func (s *server) notImplemented(kind string) error {
+	return errors.Errorf("plugin '%s' is not a %s", s.name, kind)
+}
+
+func (s *server) Info(_ Empty, reply *Info) error {
+	*reply = Info{Name: s.name, Kinds: s.kinds(), ProtocolVersion: ProtocolVersion}
+	return nil
+}
+
+func (s *server) Authenticate(req AuthRequest, reply *AuthResult) error {
+	a, ok := s.impl.(Authenticator)
+	if !ok {
+		return s.notImplemented(KindAuthenticator)
+	}
+	res, err := a.Authenticate(req)
+	*reply = res
+	return err
+}
+
+func (s *server) Publish(event Event, _ *Empty) error {
+	sink, ok := s.impl.(EventSink)
+	if !ok {
+		return s.notImplemented(KindEventSink)
+	}
+	return sink.Publish(event)
+}
+
+func (s *server) Get(req StorageRequest, reply *StorageResponse) error {
+	storage, ok := s.impl.(Storage)
+	if !ok {
+		return s.notImplemented(KindStorage)
+	}
+	value, found, err := storage.Get(req.Key)
+	*reply = StorageResponse{Value: value, Found: found}
+	return err
+}
+
+func (s *server) Put(req StorageRequest, _ *Empty) error {
+	storage, ok := s.impl.(Storage)
+	if !ok {
+		return s.notImplemented(KindStorage)
+	}
+	return storage.Put(req.Key, req.Value)
+}
+
+func (s *server) Delete(req StorageRequest, _ *Empty) error {
+	storage, ok := s.impl.(Storage)
+	if !ok {
+		return s.notImplemented(KindStorage)
+	}
+	return storage.Delete(req.Key)
+}
+
+func (s *server) List(req StorageRequest, reply *StorageResponse) error {
+	storage, ok := s.impl.(Storage)
+	if !ok {
+		return s.notImplemented(KindStorage)
+	}
+	keys, err := storage.List(req.Prefix)
+	*reply = StorageResponse{Keys: keys}
+	return err
+}
+
+func (s *server) Sign(req SignRequest, reply *SignResponse) error {
+	signer, ok := s.impl.(Signer)
+	if !ok {
+		return s.notImplemented(KindSigner)
+	}
+	signature, err := signer.Sign(req.KeyID, req.Digest)
+	*reply = SignResponse{Signature: signature}
+	return err
+}
@@ -0,0 +1,255 @@
+// Package policy applies timeout and retry policies to the gateway calls of
+// transactions. The policies are read from the JSON file set by
+// POLICY_CONFIG, with a default policy and one for each transaction that
+// needs another behavior, such as more retries for contended assets or a
+// shorter timeout for interactive queries:
+//
+//	{
+//	  "default": {"timeout": "30s"},
+//	  "transactions": {
+//	    "createAsset": {"retries": 3, "backoff": "200ms", "maxBackoff": "2s"},
+//	    "search": {"timeout": "5s", "retries": 1, "retryOn": ["UNAVAILABLE", "TIMEOUT"]}
+//	  }
+//	}
+//
+// Settings left out of a transaction policy are taken from the default one.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// defaultRetryOn are the error codes retried when a policy does not set them
+var defaultRetryOn = []string{common.ErrCodeMVCCConflict, common.ErrCodeUnavailable}
+
+// defaultBackoff is the delay before the first retry when a policy does not
+// set it
+const defaultBackoff = 100 * time.Millisecond
+
+// Policy sets how the gateway calls of a transaction are made
+type Policy struct {
+	// Timeout bounds each attempt, from the proposal to the commit status
+	Timeout string `json:"timeout,omitempty"`
+	// Retries is how many times a failed attempt is retried
+	Retries *int `json:"retries,omitempty"`
+	// RetryOn are the error codes of the attempts that are retried
+	RetryOn []string `json:"retryOn,omitempty"`
+	// Backoff is the delay before the first retry, doubled on every retry
+	// up to MaxBackoff
+	Backoff    string `json:"backoff,omitempty"`
+	MaxBackoff string `json:"maxBackoff,omitempty"`
+}
+
+// Config sets the default policy and the policy of each transaction
+type Config struct {
+	Default      *Policy            `json:"default"`
+	Transactions map[string]*Policy `json:"transactions"`
+}
+
+// rule is a policy with its settings parsed
+type rule struct {
+	timeout    time.Duration
+	retries    int
+	retryOn    map[string]bool
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+var (
+	defaultRule *rule
+	txRules     map[string]*rule
+	configErr   error
+	configOnce  sync.Once
+)
+
+// Enabled reports whether policies are set in the file set by POLICY_CONFIG
+func Enabled() bool {
+	return os.Getenv("POLICY_CONFIG") != ""
+}
+
+func loadConfig() error {
+	configOnce.Do(func() {
+		data, err := os.ReadFile(os.Getenv("POLICY_CONFIG"))
+		if err != nil {
+			configErr = errors.Wrap(err, "failed to read policy config")
+			return
+		}
+
+		cfg := &Config{}
+		err = json.Unmarshal(data, cfg)
+		if err != nil {
+			configErr = errors.Wrap(err, "failed to unmarshal policy config")
+			return
+		}
+		if cfg.Default == nil {
+			cfg.Default = &Policy{}
+		}
+
+		defaultRule, err = parse(&Policy{}, cfg.Default)
+		if err != nil {
+			configErr = errors.Wrap(err, "invalid default policy")
+			return
+		}
+		txRules = make(map[string]*rule)
+		for txName, p := range cfg.Transactions {
+			txRules[txName], err = parse(cfg.Default, p)
+			if err != nil {
+				configErr = errors.Wrapf(err, "invalid policy of %s", txName)
+				return
+			}
+		}
+	})
+
+	return configErr
+}
+
+// parse checks the settings of the policy, taking those it leaves out from
+// the base policy
+func parse(base, p *Policy) (*rule, error) {
+	if p == nil {
+		p = &Policy{}
+	}
+	r := &rule{
+		backoff: defaultBackoff,
+		retryOn: make(map[string]bool),
+	}
+
+	var err error
+	if timeout := pick(p.Timeout, base.Timeout); timeout != "" {
+		r.timeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid timeout")
+		}
+	}
+	if backoff := pick(p.Backoff, base.Backoff); backoff != "" {
+		r.backoff, err = time.ParseDuration(backoff)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid backoff")
+		}
+	}
+	if maxBackoff := pick(p.MaxBackoff, base.MaxBackoff); maxBackoff != "" {
+		r.maxBackoff, err = time.ParseDuration(maxBackoff)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid maxBackoff")
+		}
+	}
+
+	if p.Retries != nil {
+		r.retries = *p.Retries
+	} else if base.Retries != nil {
+		r.retries = *base.Retries
+	}
+	if r.retries < 0 {
+		return nil, errors.New("retries must not be negative")
+	}
+
+	retryOn := p.RetryOn
+	if retryOn == nil {
+		retryOn = base.RetryOn
+	}
+	if retryOn == nil {
+		retryOn = defaultRetryOn
+	}
+	for _, code := range retryOn {
+		r.retryOn[code] = true
+	}
+	return r, nil
+}
+
+// pick returns the setting of the policy, or of the base policy if unset
+func pick(value, base string) string {
+	if value != "" {
+		return value
+	}
+	return base
+}
+
+// getRule returns the rule of the transaction, or the default one
+func getRule(txName string) (*rule, error) {
+	if err := loadConfig(); err != nil {
+		return nil, err
+	}
+	if r, ok := txRules[txName]; ok {
+		return r, nil
+	}
+	return defaultRule, nil
+}
+
+// Do makes the gateway call of the transaction following its policy. Each
+// attempt gets a context bounded by the timeout, and failed attempts are
+// retried while their error code is retryable. Without policies, the call
+// is made once with no timeout.
+func Do(txName string, call func(ctx context.Context) error) error {
+	if !Enabled() {
+		return call(context.Background())
+	}
+	r, err := getRule(txName)
+	if err != nil {
+		return err
+	}
+
+	delay := r.backoff
+	for attempt := 0; ; attempt++ {
+		err = attemptCall(txName, r.timeout, call)
+		if err == nil {
+			return nil
+		}
+
+		apiErr, _ := common.ParseError(err)
+		code := apiErr.(*common.APIError).Code
+		if attempt >= r.retries || !r.retryOn[code] {
+			return err
+		}
+
+		log.Printf("retrying %s in %s after %s (retry %d of %d)\n", txName, delay, code, attempt+1, r.retries)
+		time.Sleep(delay)
+		delay *= 2
+		if r.maxBackoff > 0 && delay > r.maxBackoff {
+			delay = r.maxBackoff
+		}
+	}
+}
+
+// attemptCall makes one attempt of the call within the timeout, if any
+func attemptCall(txName string, timeout time.Duration, call func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return call(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := call(ctx)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return &common.APIError{
+			Status:  http.StatusGatewayTimeout,
+			Code:    common.ErrCodeTimeout,
+			Message: fmt.Sprintf("%s did not complete within %s; if it was already submitted, it may still be committed", txName, timeout),
+		}
+	}
+	return err
+}
+
+// Attempt makes the gateway call of the transaction once, within the timeout
+// of its policy, for calls that cannot be retried, such as the submission of
+// a prepared transaction, whose id can only be used once
+func Attempt(txName string, call func(ctx context.Context) error) error {
+	if !Enabled() {
+		return call(context.Background())
+	}
+	r, err := getRule(txName)
+	if err != nil {
+		return err
+	}
+	return attemptCall(txName, r.timeout, call)
+}
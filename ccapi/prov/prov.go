@@ -0,0 +1,172 @@
+// Package prov describes the history of an asset as a W3C PROV-JSON
+// document (https://www.w3.org/Submission/prov-json/), so provenance
+// tooling can import it. Each version of the asset is an entity generated
+// by the transaction that wrote it, an activity associated with the MSP of
+// the organization that submitted it, an agent. Versions are derived from
+// the previous ones and specialize an entity of the asset itself, and the
+// transaction deleting the asset invalidates its last version.
+package prov
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Namespace is the namespace of the types and attributes of the documents
+const Namespace = "https://github.com/hyperledger-labs/ccapi/prov#"
+
+// Document builds the PROV-JSON document of the history of the asset, as
+// returned by readAssetHistory, newest version first
+func Document(channel, chaincode, key string, history []map[string]interface{}) map[string]interface{} {
+	doc := map[string]interface{}{
+		"prefix": map[string]interface{}{
+			"ccapi": Namespace,
+			"asset": "urn:ccapi:asset:",
+			"tx":    "urn:ccapi:tx:" + channel + ":",
+			"msp":   "urn:ccapi:msp:",
+		},
+	}
+	relations := 0
+	record := func(kind, id string, attrs map[string]interface{}) {
+		if id == "" {
+			relations++
+			id = fmt.Sprintf("_:r%d", relations)
+		}
+		records, ok := doc[kind].(map[string]interface{})
+		if !ok {
+			records = make(map[string]interface{})
+			doc[kind] = records
+		}
+		records[id] = attrs
+	}
+
+	asset := "asset:" + key
+	record("entity", asset, map[string]interface{}{
+		"prov:type":       qualified("ccapi:Asset"),
+		"ccapi:key":       key,
+		"ccapi:channel":   channel,
+		"ccapi:chaincode": chaincode,
+	})
+
+	// Peers return the newest versions first
+	previous := ""
+	for i := len(history) - 1; i >= 0; i-- {
+		version := history[i]
+		txID, _ := version["_txId"].(string)
+		timestamp, _ := version["_timestamp"].(string)
+		isDelete, _ := version["_isDelete"].(bool)
+
+		activity := "tx:" + txID
+		activityAttrs := map[string]interface{}{
+			"prov:type":      qualified("ccapi:Transaction"),
+			"ccapi:txId":     txID,
+			"prov:startTime": timestamp,
+			"prov:endTime":   timestamp,
+		}
+		record("activity", activity, activityAttrs)
+
+		if isDelete {
+			if previous != "" {
+				record("used", "", map[string]interface{}{
+					"prov:activity": activity,
+					"prov:entity":   previous,
+					"prov:time":     timestamp,
+				})
+				record("wasInvalidatedBy", "", map[string]interface{}{
+					"prov:entity":   previous,
+					"prov:activity": activity,
+					"prov:time":     timestamp,
+				})
+			}
+			previous = ""
+			continue
+		}
+
+		entity := fmt.Sprintf("asset:%s@%s", key, txID)
+		record("entity", entity, versionAttributes(version))
+		record("specializationOf", "", map[string]interface{}{
+			"prov:specificEntity": entity,
+			"prov:generalEntity":  asset,
+		})
+		record("wasGeneratedBy", "", map[string]interface{}{
+			"prov:entity":   entity,
+			"prov:activity": activity,
+			"prov:time":     timestamp,
+		})
+
+		if txName, ok := version["@lastTx"].(string); ok {
+			activityAttrs["ccapi:txName"] = txName
+		}
+		if msp, ok := version["@lastTouchBy"].(string); ok && msp != "" {
+			agent := "msp:" + msp
+			record("agent", agent, map[string]interface{}{
+				"prov:type":   qualified("prov:Organization"),
+				"ccapi:mspId": msp,
+			})
+			record("wasAssociatedWith", "", map[string]interface{}{
+				"prov:activity": activity,
+				"prov:agent":    agent,
+			})
+			record("wasAttributedTo", "", map[string]interface{}{
+				"prov:entity": entity,
+				"prov:agent":  agent,
+			})
+		}
+
+		if previous != "" {
+			record("used", "", map[string]interface{}{
+				"prov:activity": activity,
+				"prov:entity":   previous,
+				"prov:time":     timestamp,
+			})
+			record("wasDerivedFrom", "", map[string]interface{}{
+				"prov:generatedEntity": entity,
+				"prov:usedEntity":      previous,
+				"prov:activity":        activity,
+				"prov:type":            qualified("prov:Revision"),
+			})
+		}
+		previous = entity
+	}
+
+	return doc
+}
+
+// versionAttributes returns the attributes of the entity of a version: its
+// properties, with objects and arrays, such as references, as JSON
+func versionAttributes(version map[string]interface{}) map[string]interface{} {
+	attrs := map[string]interface{}{
+		"prov:type": qualified("ccapi:AssetVersion"),
+	}
+
+	for prop, value := range version {
+		switch prop {
+		case "_txId", "_timestamp", "_isDelete":
+			continue
+		}
+		// Metadata such as @assetType is named without its @, which is not
+		// allowed in qualified names
+		name := "ccapi:" + strings.TrimPrefix(prop, "@")
+		switch value := value.(type) {
+		case string, bool, float64, json.Number:
+			attrs[name] = value
+		case nil:
+		default:
+			data, _ := json.Marshal(value)
+			attrs[name] = map[string]interface{}{
+				"$":    string(data),
+				"type": "ccapi:json",
+			}
+		}
+	}
+	return attrs
+}
+
+// qualified returns a typed value holding a qualified name
+func qualified(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"$":    name,
+		"type": "prov:QUALIFIED_NAME",
+	}
+}
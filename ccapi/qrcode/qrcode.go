@@ -0,0 +1,541 @@
+// Package qrcode encodes data as QR codes (ISO/IEC 18004) in byte mode and
+// renders them as images, so documents such as transaction receipts can
+// link to the CCAPI from paper or a screen.
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/pkg/errors"
+)
+
+// Level is the error correction level of a code, which is how much of it
+// can be damaged and still be read
+type Level int
+
+const (
+	// Low recovers about 7% of the code
+	Low Level = iota
+	// Medium recovers about 15% of the code
+	Medium
+	// Quartile recovers about 25% of the code
+	Quartile
+	// High recovers about 30% of the code
+	High
+)
+
+// formatBits are the bits of the levels in the format information
+var formatBits = [4]int{1, 0, 3, 2}
+
+// eccCodewordsPerBlock and numEccBlocks are the error correction codewords
+// of each block and the number of blocks, by level and version
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var numEccBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Code is a QR code, a square of dark and light modules
+type Code struct {
+	// Size is the number of modules of each side, without the quiet zone
+	Size     int
+	version  int
+	level    Level
+	modules  []bool
+	function []bool
+}
+
+// Encode returns the smallest code holding the data at the level
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, errors.New("invalid error correction level")
+	}
+	for version := 1; version <= 40; version++ {
+		countBits := 8
+		if version > 9 {
+			countBits = 16
+		}
+		capacity := numDataCodewords(version, level) * 8
+		if 4+countBits+len(data)*8 > capacity {
+			continue
+		}
+		return newCode(version, level, encodeData(data, countBits, capacity), -1), nil
+	}
+	return nil, errors.Errorf("%d bytes are too long for a QR code", len(data))
+}
+
+// encodeData returns the data codewords of a single byte mode segment with
+// the data, padded up to the capacity in bits
+func encodeData(data []byte, countBits, capacity int) []byte {
+	var b bitBuffer
+	b.append(4, 4) // byte mode
+	b.append(len(data), countBits)
+	for _, d := range data {
+		b.append(int(d), 8)
+	}
+	// Terminator, then pad bytes up to the capacity
+	terminator := capacity - len(b)
+	if terminator > 4 {
+		terminator = 4
+	}
+	b.append(0, terminator)
+	b.append(0, (8-len(b)%8)%8)
+	for pad := 0xEC; len(b) < capacity; pad ^= 0xEC ^ 0x11 {
+		b.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return codewords
+}
+
+// newCode lays out the data codewords with their error correction in a code
+// of the version, masked with the mask, or the one with the lowest penalty
+// if negative
+func newCode(version int, level Level, codewords []byte, mask int) *Code {
+	q := &Code{
+		Size:    version*4 + 17,
+		version: version,
+		level:   level,
+	}
+	q.modules = make([]bool, q.Size*q.Size)
+	q.function = make([]bool, q.Size*q.Size)
+
+	q.drawFunctionPatterns()
+	q.drawCodewords(addEccAndInterleave(version, level, codewords))
+
+	if mask < 0 {
+		minPenalty := -1
+		for m := 0; m < 8; m++ {
+			q.applyMask(m)
+			q.drawFormatBits(m)
+			if penalty := q.penalty(); minPenalty < 0 || penalty < minPenalty {
+				mask, minPenalty = m, penalty
+			}
+			q.applyMask(m) // undo
+		}
+	}
+	q.applyMask(mask)
+	q.drawFormatBits(mask)
+	return q
+}
+
+// Black reports whether the module at the column x and row y is dark
+func (q *Code) Black(x, y int) bool {
+	return x >= 0 && y >= 0 && x < q.Size && y < q.Size && q.modules[y*q.Size+x]
+}
+
+func (q *Code) set(x, y int, dark bool) {
+	q.modules[y*q.Size+x] = dark
+	q.function[y*q.Size+x] = true
+}
+
+func (q *Code) drawFunctionPatterns() {
+	// Timing patterns
+	for i := 0; i < q.Size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns, with their separators
+	for _, c := range [][2]int{{3, 3}, {q.Size - 4, 3}, {3, q.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= q.Size || y >= q.Size {
+					continue
+				}
+				dist := max(abs(dx), abs(dy))
+				q.set(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	// Alignment patterns, except where the finder patterns are
+	positions := alignmentPositions(q.version)
+	last := len(positions) - 1
+	for i, y := range positions {
+		for j, x := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format information, drawn once the mask is chosen
+	q.drawFormatBits(0)
+
+	// Version information
+	if q.version >= 7 {
+		rem := q.version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := q.version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 != 0
+			a, b := q.Size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+func (q *Code) drawFormatBits(mask int) {
+	data := formatBits[q.level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	// Around the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	// Along the other finder patterns
+	for i := 0; i < 8; i++ {
+		q.set(q.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.Size-15+i, bit(i))
+	}
+	q.set(8, q.Size-8, true)
+}
+
+// drawCodewords fills the modules left by the function patterns in zigzag,
+// two columns at a time from the bottom right corner
+func (q *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if !q.function[y*q.Size+x] && i < len(data)*8 {
+					q.modules[y*q.Size+x] = (data[i>>3]>>(7-(i&7)))&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules the mask selects, so applying it twice
+// undoes it
+func (q *Code) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !q.function[y*q.Size+x] {
+				q.modules[y*q.Size+x] = !q.modules[y*q.Size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the features that make the code hard to read: runs and
+// blocks of modules of the same color, patterns that look like finders and
+// an unbalanced number of dark modules
+func (q *Code) penalty() int {
+	penalty := 0
+	finder := []bool{true, false, true, true, true, false, true}
+	light := []bool{false, false, false, false}
+
+	line := make([]bool, q.Size)
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < q.Size; a++ {
+			for b := 0; b < q.Size; b++ {
+				if vertical {
+					line[b] = q.modules[b*q.Size+a]
+				} else {
+					line[b] = q.modules[a*q.Size+b]
+				}
+			}
+
+			run := 1
+			for b := 1; b <= q.Size; b++ {
+				if b < q.Size && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+
+			for b := 0; b+len(finder) <= q.Size; b++ {
+				if !matches(line, b, finder) {
+					continue
+				}
+				if matches(line, b-len(light), light) || matches(line, b+len(finder), light) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y*q.Size+x] {
+				dark++
+			}
+			if x+1 < q.Size && y+1 < q.Size {
+				c := q.modules[y*q.Size+x]
+				if c == q.modules[y*q.Size+x+1] && c == q.modules[(y+1)*q.Size+x] && c == q.modules[(y+1)*q.Size+x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := q.Size * q.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	penalty += k * 10
+	return penalty
+}
+
+// matches reports whether the line has the pattern at the offset, the
+// modules past its ends being light
+func matches(line []bool, offset int, pattern []bool) bool {
+	for i, p := range pattern {
+		j := offset + i
+		if j >= 0 && j < len(line) {
+			if line[j] != p {
+				return false
+			}
+		} else if p {
+			return false
+		}
+	}
+	return true
+}
+
+// alignmentPositions returns the rows and columns of the centers of the
+// alignment patterns of the version
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	num := version/7 + 2
+	step := (version*8 + num*3 + 5) / (num*4 - 4) * 2
+	positions := make([]int, num)
+	positions[0] = 6
+	for i, pos := num-1, version*4+10; i > 0; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// numRawDataModules returns the modules of the version left for data and
+// error correction, including the remainder bits
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		num := version/7 + 2
+		result -= (25*num-10)*num - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numEccBlocks[level][version]
+}
+
+// addEccAndInterleave splits the data codewords in blocks, appends the
+// error correction codewords of each block and interleaves them
+func addEccAndInterleave(version int, level Level, data []byte) []byte {
+	numBlocks := numEccBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortBlockLen - eccLen
+		if i >= numShortBlocks {
+			n++
+		}
+		block := append([]byte{}, data[k:k+n]...)
+		k += n
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			// Skip the padding of short blocks
+			if i != shortBlockLen-eccLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// reedSolomonDivisor returns the generator polynomial of the degree, with
+// its coefficients from the highest power, the leading one omitted
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 != 0)
+	}
+}
+
+// quietZone is the light border readers need around codes, in modules
+const quietZone = 4
+
+// PNG renders the code as a PNG image with scale pixels per module, and
+// the quiet zone around it
+func (q *Code) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (q.Size + 2*quietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			if q.Black(x/scale-quietZone, y/scale-quietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the code as an SVG image with scale pixels per module, and
+// the quiet zone around it
+func (q *Code) SVG(scale int) []byte {
+	if scale < 1 {
+		scale = 1
+	}
+	side := q.Size + 2*quietZone
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		side*scale, side*scale, side, side)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, side, side)
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.Black(x, y) {
+				fmt.Fprintf(&buf, "M%d,%dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,296 @@
+// Package quota counts the transactions each identity submits per calendar
+// month (UTC) and rejects submissions beyond the monthly quota of the
+// identity, e.g. when the API is shared among student teams.
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// monthLayout formats the months of the usage report
+const monthLayout = "2006-01"
+
+// Config sets the monthly quota of each identity, user or user@org, and of
+// the identities not listed. Identities without a quota are unlimited.
+// AssetSize sets, the same way, the largest asset in bytes each identity
+// can write.
+type Config struct {
+	Default   *int           `json:"default"`
+	Users     map[string]int `json:"users"`
+	AssetSize *Limits        `json:"assetSize"`
+}
+
+// Limits sets a limit for each identity and for the identities not listed
+type Limits struct {
+	Default *int           `json:"default"`
+	Users   map[string]int `json:"users"`
+}
+
+// Usage is the number of transactions submitted by an identity in a month
+type Usage struct {
+	Identity  string `json:"identity"`
+	Used      int    `json:"used"`
+	Limit     *int   `json:"limit,omitempty"`
+	Remaining *int   `json:"remaining,omitempty"`
+}
+
+var (
+	config     *Config
+	configErr  error
+	configOnce sync.Once
+
+	// mutex guards the usage, by month and identity
+	mutex  sync.Mutex
+	usage  map[string]map[string]int
+	loaded bool
+)
+
+// Enabled reports whether quotas are set in the file set by QUOTA_CONFIG
+func Enabled() bool {
+	return os.Getenv("QUOTA_CONFIG") != ""
+}
+
+// getUsagePath returns the file the usage is kept in from QUOTA_USAGE_PATH
+func getUsagePath() string {
+	if path := os.Getenv("QUOTA_USAGE_PATH"); path != "" {
+		return path
+	}
+	return "usage.json"
+}
+
+func getConfig() (*Config, error) {
+	configOnce.Do(func() {
+		data, err := os.ReadFile(os.Getenv("QUOTA_CONFIG"))
+		if err != nil {
+			configErr = errors.Wrap(err, "failed to read quota config")
+			return
+		}
+
+		config = &Config{}
+		configErr = errors.Wrap(json.Unmarshal(data, config), "failed to unmarshal quota config")
+	})
+
+	return config, configErr
+}
+
+// limitOf returns the monthly quota of the identity, nil if unlimited
+func (cfg *Config) limitOf(identity string) *int {
+	if limit, ok := cfg.Users[identity]; ok {
+		return &limit
+	}
+	return cfg.Default
+}
+
+// AssetSizeLimit returns the largest asset in bytes the identity can write,
+// if the quota config sets it
+func AssetSizeLimit(identity string) (int, bool, error) {
+	if !Enabled() {
+		return 0, false, nil
+	}
+	cfg, err := getConfig()
+	if err != nil {
+		return 0, false, err
+	}
+	if cfg.AssetSize == nil {
+		return 0, false, nil
+	}
+
+	if limit, ok := cfg.AssetSize.Users[identity]; ok {
+		return limit, true, nil
+	}
+	if cfg.AssetSize.Default != nil {
+		return *cfg.AssetSize.Default, true, nil
+	}
+	return 0, false, nil
+}
+
+// load reads the usage file once. The caller must hold the mutex.
+func load() {
+	if loaded {
+		return
+	}
+	loaded = true
+
+	usage = make(map[string]map[string]int)
+	data, err := os.ReadFile(getUsagePath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("error reading quota usage: ", err)
+		}
+		return
+	}
+	err = json.Unmarshal(data, &usage)
+	if err != nil {
+		log.Println("error unmarshalling quota usage: ", err)
+		usage = make(map[string]map[string]int)
+	}
+}
+
+// save writes the usage file. The caller must hold the mutex.
+func save() {
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		log.Println("error marshalling quota usage: ", err)
+		return
+	}
+	err = os.WriteFile(getUsagePath(), data, 0644)
+	if err != nil {
+		log.Println("error writing quota usage: ", err)
+	}
+}
+
+// CurrentMonth returns the month usage is currently counted in
+func CurrentMonth() string {
+	return time.Now().UTC().Format(monthLayout)
+}
+
+// Check returns an error with status 429 when the identity has used its
+// quota for the current month. It lets requests fail early, before their
+// transaction is prepared; submissions are counted by Reserve, which checks
+// the quota again.
+func Check(identity string) error {
+	if !Enabled() {
+		return nil
+	}
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	limit := cfg.limitOf(identity)
+	if limit == nil {
+		return nil
+	}
+
+	mutex.Lock()
+	load()
+	used := usage[CurrentMonth()][identity]
+	mutex.Unlock()
+
+	if used < *limit {
+		return nil
+	}
+	return exceeded(identity, *limit, used)
+}
+
+// exceeded returns the error of an identity that used its quota
+func exceeded(identity string, limit, used int) error {
+	now := time.Now().UTC()
+	resetsAt := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	return &common.APIError{
+		Status:  http.StatusTooManyRequests,
+		Code:    common.ErrCodeQuotaExceeded,
+		Message: fmt.Sprintf("%s has used its quota of %d transactions this month", identity, limit),
+		Details: map[string]interface{}{
+			"limit":    limit,
+			"used":     used,
+			"resetsAt": resetsAt,
+		},
+	}
+}
+
+// Reserve counts a transaction the identity is about to submit, or returns
+// an error with status 429 if it has used its quota for the current month.
+// The usage is compared and incremented at once, so concurrent submissions
+// cannot go past the quota. The returned function gives the transaction
+// back if its submission fails.
+func Reserve(identity string) (release func(), err error) {
+	if !Enabled() {
+		return func() {}, nil
+	}
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	limit := cfg.limitOf(identity)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	month := CurrentMonth()
+	used := usage[month][identity]
+	if limit != nil && used >= *limit {
+		return nil, exceeded(identity, *limit, used)
+	}
+	if usage[month] == nil {
+		usage[month] = make(map[string]int)
+	}
+	usage[month][identity]++
+	save()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unreserve(month, identity)
+		})
+	}, nil
+}
+
+// unreserve gives back a transaction reserved in the month
+func unreserve(month, identity string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if usage[month][identity] > 0 {
+		usage[month][identity]--
+		save()
+	}
+}
+
+// Report returns the usage of every identity in the month, formatted as
+// 2006-01, along with the identities with a quota that did not use it
+func Report(month string) ([]Usage, error) {
+	if _, err := time.Parse(monthLayout, month); err != nil {
+		return nil, common.NewAPIError(http.StatusBadRequest, "month must be formatted as YYYY-MM")
+	}
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	mutex.Lock()
+	load()
+	used := make(map[string]int)
+	for identity, count := range usage[month] {
+		used[identity] = count
+	}
+	mutex.Unlock()
+
+	for identity := range cfg.Users {
+		if _, ok := used[identity]; !ok {
+			used[identity] = 0
+		}
+	}
+
+	report := make([]Usage, 0, len(used))
+	for identity, count := range used {
+		u := Usage{
+			Identity: identity,
+			Used:     count,
+			Limit:    cfg.limitOf(identity),
+		}
+		if u.Limit != nil {
+			remaining := *u.Limit - count
+			if remaining < 0 {
+				remaining = 0
+			}
+			u.Remaining = &remaining
+		}
+		report = append(report, u)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Identity < report[j].Identity
+	})
+	return report, nil
+}
@@ -0,0 +1,58 @@
+package quota
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestReserveConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "quota.json")
+	err := os.WriteFile(configPath, []byte(`{"default": 5}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("QUOTA_CONFIG", configPath)
+	t.Setenv("QUOTA_USAGE_PATH", filepath.Join(dir, "usage.json"))
+
+	// Concurrent submissions cannot go past the quota
+	var wg sync.WaitGroup
+	var reserved sync.Map
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			release, err := Reserve("alice")
+			if err == nil {
+				reserved.Store(i, release)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	count := 0
+	var release func()
+	reserved.Range(func(_, value interface{}) bool {
+		count++
+		release = value.(func())
+		return true
+	})
+	if count != 5 {
+		t.Fatalf("expected 5 reservations, got %d", count)
+	}
+	if Check("alice") == nil {
+		t.Fatal("expected the quota to be used")
+	}
+
+	// Failed submissions give their transaction back, once
+	release()
+	release()
+	if _, err := Reserve("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Reserve("alice"); err == nil {
+		t.Fatal("expected the quota to be used")
+	}
+}
@@ -0,0 +1,233 @@
+// Package rangeproof commits to non-negative integers and proves bounds on
+// them, such as "salary < 5000", without revealing them. It uses hash chains:
+// the commitment holds H^(v+1)(s1) and H^(max-v+1)(s2) for random seeds s1
+// and s2, and proving v >= x means revealing H^(v+1-x)(s1), which only hashes
+// to the commitment after x more rounds. Hashes cannot be inverted, so the
+// proof cannot be forged for larger bounds.
+//
+// This is a simple demonstration of the idea, not a succinct zero-knowledge
+// proof: proving and verifying take up to max hash rounds, and the holder of
+// the secret must be trusted to have committed to the true value.
+package rangeproof
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxBound limits the values that can be committed, as proofs take up to
+// max hash rounds
+const MaxBound = 1 << 20
+
+const prefix = "rp1"
+
+// Commitment is the public part, stored in place of the value
+type Commitment struct {
+	Max   int64
+	Lower []byte // H^(v+1)(s1), proves lower bounds
+	Upper []byte // H^(max-v+1)(s2), proves upper bounds
+}
+
+// Secret is kept by the holder of the value to create proofs
+type Secret struct {
+	Value      int64  `json:"value"`
+	Max        int64  `json:"max"`
+	LowerSeed  string `json:"lowerSeed"`
+	UpperSeed  string `json:"upperSeed"`
+	Commitment string `json:"commitment"`
+}
+
+// Claim is a bound on the committed value, with operator <, <=, > or >=
+type Claim struct {
+	Op    string `json:"op"`
+	Bound int64  `json:"bound"`
+}
+
+// String formats the claim, for example "value < 5000"
+func (c Claim) String() string {
+	return fmt.Sprintf("value %s %d", c.Op, c.Bound)
+}
+
+// String encodes the commitment as rp1:max:lower:upper
+func (c Commitment) String() string {
+	return fmt.Sprintf("%s:%d:%s:%s", prefix, c.Max, hex.EncodeToString(c.Lower), hex.EncodeToString(c.Upper))
+}
+
+// ParseCommitment decodes a commitment encoded by String
+func ParseCommitment(s string) (Commitment, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 4 || parts[0] != prefix {
+		return Commitment{}, errors.New("invalid commitment format")
+	}
+
+	max, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || max < 0 || max > MaxBound {
+		return Commitment{}, errors.New("invalid commitment bound")
+	}
+	lower, err := hex.DecodeString(parts[2])
+	if err != nil || len(lower) != sha256.Size {
+		return Commitment{}, errors.New("invalid commitment lower hash")
+	}
+	upper, err := hex.DecodeString(parts[3])
+	if err != nil || len(upper) != sha256.Size {
+		return Commitment{}, errors.New("invalid commitment upper hash")
+	}
+
+	return Commitment{Max: max, Lower: lower, Upper: upper}, nil
+}
+
+// Commit creates a commitment to the value, which must be between 0 and
+// max, and the secret needed to prove claims about it
+func Commit(value, max int64) (Commitment, Secret, error) {
+	if max < 0 || max > MaxBound {
+		return Commitment{}, Secret{}, errors.Errorf("max must be between 0 and %d", MaxBound)
+	}
+	if value < 0 || value > max {
+		return Commitment{}, Secret{}, errors.Errorf("value must be between 0 and %d", max)
+	}
+
+	lowerSeed, err := randomSeed()
+	if err != nil {
+		return Commitment{}, Secret{}, err
+	}
+	upperSeed, err := randomSeed()
+	if err != nil {
+		return Commitment{}, Secret{}, err
+	}
+
+	commitment := Commitment{
+		Max:   max,
+		Lower: hashN(lowerSeed, value+1),
+		Upper: hashN(upperSeed, max-value+1),
+	}
+	secret := Secret{
+		Value:      value,
+		Max:        max,
+		LowerSeed:  hex.EncodeToString(lowerSeed),
+		UpperSeed:  hex.EncodeToString(upperSeed),
+		Commitment: commitment.String(),
+	}
+	return commitment, secret, nil
+}
+
+// Prove creates the proof of the claim about the value of the secret,
+// failing if the claim is false
+func Prove(secret Secret, claim Claim) (string, error) {
+	err := checkSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	lower, x, err := normalize(claim, secret.Max)
+	if err != nil {
+		return "", err
+	}
+
+	if lower {
+		// value >= x
+		if secret.Value < x {
+			return "", errors.Errorf("%s is false", claim)
+		}
+		seed, err := hex.DecodeString(secret.LowerSeed)
+		if err != nil {
+			return "", errors.New("invalid secret lower seed")
+		}
+		return hex.EncodeToString(hashN(seed, secret.Value+1-x)), nil
+	}
+
+	// value < x, that is max-value >= max-x+1
+	if secret.Value >= x {
+		return "", errors.Errorf("%s is false", claim)
+	}
+	seed, err := hex.DecodeString(secret.UpperSeed)
+	if err != nil {
+		return "", errors.New("invalid secret upper seed")
+	}
+	return hex.EncodeToString(hashN(seed, x-secret.Value)), nil
+}
+
+// checkSecret checks the secret, sent back by its holder, against the
+// bounds of Commit and its commitment before any hash round is spent on it
+func checkSecret(secret Secret) error {
+	commitment, err := ParseCommitment(secret.Commitment)
+	if err != nil {
+		return errors.Wrap(err, "invalid secret commitment")
+	}
+	if secret.Max < 0 || secret.Max > MaxBound || secret.Max != commitment.Max {
+		return errors.Errorf("secret max must be the max of its commitment, between 0 and %d", MaxBound)
+	}
+	if secret.Value < 0 || secret.Value > secret.Max {
+		return errors.Errorf("secret value must be between 0 and %d", secret.Max)
+	}
+	return nil
+}
+
+// Verify checks the proof of the claim about the committed value
+func Verify(commitment Commitment, claim Claim, proof string) (bool, error) {
+	lower, x, err := normalize(claim, commitment.Max)
+	if err != nil {
+		return false, err
+	}
+	p, err := hex.DecodeString(proof)
+	if err != nil || len(p) != sha256.Size {
+		return false, errors.New("invalid proof format")
+	}
+
+	if lower {
+		return bytes.Equal(hashN(p, x), commitment.Lower), nil
+	}
+	return bytes.Equal(hashN(p, commitment.Max-x+1), commitment.Upper), nil
+}
+
+// normalize turns the claim into value >= x (lower is true) or value < x,
+// with x between 0 and max+1
+func normalize(claim Claim, max int64) (lower bool, x int64, err error) {
+	switch claim.Op {
+	case ">=":
+		lower, x = true, claim.Bound
+	case ">":
+		lower, x = true, claim.Bound+1
+	case "<":
+		lower, x = false, claim.Bound
+	case "<=":
+		lower, x = false, claim.Bound+1
+	default:
+		return false, 0, errors.Errorf("invalid claim operator '%s', must be <, <=, > or >=", claim.Op)
+	}
+
+	if x < 0 || x > max+1 {
+		return false, 0, errors.Errorf("claim bound must be between 0 and %d", max)
+	}
+	if lower && x > max {
+		return false, 0, errors.Errorf("%s can never hold, values are at most %d", claim, max)
+	}
+	if !lower && x == 0 {
+		return false, 0, errors.Errorf("%s can never hold, values are at least 0", claim)
+	}
+	return lower, x, nil
+}
+
+func randomSeed() ([]byte, error) {
+	seed := make([]byte, sha256.Size)
+	_, err := rand.Read(seed)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate seed")
+	}
+	return seed, nil
+}
+
+// hashN applies SHA-256 n times
+func hashN(data []byte, n int64) []byte {
+	h := data
+	for i := int64(0); i < n; i++ {
+		sum := sha256.Sum256(h)
+		h = sum[:]
+	}
+	return h
+}
@@ -0,0 +1,58 @@
+package rangeproof
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProve(t *testing.T) {
+	commitment, secret, err := Commit(3000, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := Prove(secret, Claim{Op: "<", Bound: 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := Verify(commitment, Claim{Op: "<", Bound: 5000}, proof)
+	if err != nil || !valid {
+		t.Fatalf("expected a valid proof, got %v %v", valid, err)
+	}
+
+	// Secrets outside the bounds of their commitment are refused before
+	// hashing
+	huge := secret
+	huge.Value, huge.Max = 9e18, 9e18
+	outOfRange := secret
+	outOfRange.Value = secret.Max + 1
+	negative := secret
+	negative.Value = -1
+	noCommitment := secret
+	noCommitment.Commitment = "rp1:10000"
+	otherMax := secret
+	otherMax.Max = 20000
+
+	for name, s := range map[string]Secret{
+		"huge":         huge,
+		"outOfRange":   outOfRange,
+		"negative":     negative,
+		"noCommitment": noCommitment,
+		"otherMax":     otherMax,
+	} {
+		_, err := Prove(s, Claim{Op: ">=", Bound: 0})
+		if err == nil || !strings.Contains(err.Error(), "secret") {
+			t.Fatalf("%s: expected the secret to be refused, got %v", name, err)
+		}
+	}
+
+	// A max of 0 is valid
+	_, secret, err = Commit(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Prove(secret, Claim{Op: "<=", Bound: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
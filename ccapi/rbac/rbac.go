@@ -0,0 +1,196 @@
+// Package rbac restricts the chaincode transactions each user can call to
+// those allowed by their roles. Roles are granted to users directly or
+// through their groups, which can be synced periodically from a SCIM
+// server so the enterprise identity management drives the permissions.
+//
+// Users are the ones logged in with OpenID Connect. Requests without a
+// session get the anonymous roles.
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// AllTransactions allows a role to call every transaction
+const AllTransactions = "*"
+
+// Config maps roles to the transactions they allow and grants them to
+// groups, users and anonymous requests
+type Config struct {
+	Roles     map[string][]string `json:"roles"`
+	Groups    map[string][]string `json:"groups"`
+	Users     map[string][]string `json:"users"`
+	Anonymous []string            `json:"anonymous"`
+}
+
+// Status describes the roles of a user and the last group sync
+type Status struct {
+	User      string     `json:"user,omitempty"`
+	Groups    []string   `json:"groups"`
+	Roles     []string   `json:"roles"`
+	LastSync  *time.Time `json:"lastSync,omitempty"`
+	SyncError string     `json:"syncError,omitempty"`
+}
+
+var (
+	config     *Config
+	configErr  error
+	configOnce sync.Once
+
+	// mutex guards the synced groups of each user
+	mutex      sync.RWMutex
+	userGroups = make(map[string][]string)
+	lastSync   *time.Time
+	syncErr    error
+)
+
+// Enabled reports whether transactions are restricted by the roles in the
+// file set by RBAC_CONFIG
+func Enabled() bool {
+	return os.Getenv("RBAC_CONFIG") != ""
+}
+
+// getConfig reads the roles from the file set by RBAC_CONFIG
+func getConfig() (*Config, error) {
+	configOnce.Do(func() {
+		data, err := os.ReadFile(os.Getenv("RBAC_CONFIG"))
+		if err != nil {
+			configErr = errors.Wrap(err, "failed to read RBAC config")
+			return
+		}
+
+		config = &Config{}
+		err = json.Unmarshal(data, config)
+		if err != nil {
+			configErr = errors.Wrap(err, "failed to unmarshal RBAC config")
+			return
+		}
+
+		configErr = config.checkRoles("anonymous", config.Anonymous)
+		for group, roles := range config.Groups {
+			if configErr == nil {
+				configErr = config.checkRoles("group '"+group+"'", roles)
+			}
+		}
+		for user, roles := range config.Users {
+			if configErr == nil {
+				configErr = config.checkRoles("user '"+user+"'", roles)
+			}
+		}
+	})
+
+	return config, configErr
+}
+
+func (cfg *Config) checkRoles(grantee string, roles []string) error {
+	for _, role := range roles {
+		if _, ok := cfg.Roles[role]; !ok {
+			return errors.Errorf("RBAC config grants unknown role '%s' to %s", role, grantee)
+		}
+	}
+	return nil
+}
+
+// groupsOf returns the synced groups of the user
+func groupsOf(user string) []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return append([]string{}, userGroups[user]...)
+}
+
+// rolesOf returns the roles of the user, granted directly or through their
+// groups, or the anonymous roles when the user is empty
+func rolesOf(cfg *Config, user string) []string {
+	if user == "" {
+		return cfg.Anonymous
+	}
+
+	set := make(map[string]bool)
+	for _, role := range cfg.Users[user] {
+		set[role] = true
+	}
+	for _, group := range groupsOf(user) {
+		for _, role := range cfg.Groups[group] {
+			set[role] = true
+		}
+	}
+
+	roles := make([]string, 0, len(set))
+	for role := range set {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Allowed reports whether the user, empty for anonymous requests, can call
+// the transaction
+func Allowed(user, txName string) (bool, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range rolesOf(cfg, user) {
+		for _, tx := range cfg.Roles[role] {
+			if tx == txName || tx == AllTransactions {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Check returns a forbidden error when the user of the request cannot call
+// the transaction
+func Check(c *gin.Context, txName string) error {
+	if !Enabled() {
+		return nil
+	}
+
+	user := c.GetString(common.SessionUserKey)
+	ok, err := Allowed(user, txName)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if user == "" {
+			user = "anonymous"
+		}
+		return common.NewAPIError(http.StatusForbidden, fmt.Sprintf("%s is not allowed to call %s", user, txName))
+	}
+	return nil
+}
+
+// GetStatus returns the groups and roles of the user and the last sync
+func GetStatus(user string) (Status, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{
+		User:   user,
+		Groups: groupsOf(user),
+		Roles:  rolesOf(cfg, user),
+	}
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+	status.LastSync = lastSync
+	if syncErr != nil {
+		status.SyncError = syncErr.Error()
+	}
+	return status, nil
+}
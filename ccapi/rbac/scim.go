@@ -0,0 +1,145 @@
+package rbac
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/scheduler"
+	"github.com/pkg/errors"
+)
+
+const syncJobID = "rbac-sync"
+
+// scimPageSize is the number of groups requested per page
+const scimPageSize = 100
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// scimGroups is a page of the SCIM /Groups listing
+type scimGroups struct {
+	TotalResults int `json:"totalResults"`
+	ItemsPerPage int `json:"itemsPerPage"`
+	Resources    []struct {
+		DisplayName string `json:"displayName"`
+		Members     []struct {
+			Value   string `json:"value"`
+			Display string `json:"display"`
+		} `json:"members"`
+	} `json:"Resources"`
+}
+
+// getSyncInterval returns the time between syncs from SCIM_SYNC_INTERVAL,
+// default 15 minutes
+func getSyncInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("SCIM_SYNC_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 15 * time.Minute
+	}
+	return interval
+}
+
+// Start syncs the groups from the SCIM server set by SCIM_URL, if any, and
+// schedules the following syncs
+func Start() {
+	if !Enabled() || os.Getenv("SCIM_URL") == "" {
+		return
+	}
+
+	go func() {
+		err := Sync()
+		if err != nil {
+			log.Println("error syncing groups: ", err)
+		}
+		schedule()
+	}()
+}
+
+func schedule() {
+	scheduler.ScheduleLocal(syncJobID, time.Now().Add(getSyncInterval()), func() error {
+		// The previous groups are kept when a sync fails, until the next one
+		err := Sync()
+		if err != nil {
+			log.Println("error syncing groups: ", err)
+		}
+		schedule()
+		return nil
+	})
+}
+
+// Sync replaces the groups of each user with the ones listed by the SCIM
+// server. Members are identified by their display name, or by their id
+// when SCIM_MEMBER_ATTRIBUTE is value.
+func Sync() error {
+	groups, err := fetchGroups()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	now := time.Now().UTC()
+	lastSync = &now
+	syncErr = err
+	if err != nil {
+		return err
+	}
+
+	useValue := os.Getenv("SCIM_MEMBER_ATTRIBUTE") == "value"
+	userGroups = make(map[string][]string)
+	for _, page := range groups {
+		for _, group := range page.Resources {
+			for _, member := range group.Members {
+				user := member.Display
+				if useValue || user == "" {
+					user = member.Value
+				}
+				userGroups[user] = append(userGroups[user], group.DisplayName)
+			}
+		}
+	}
+	log.Printf("synced groups of %d users\n", len(userGroups))
+	return nil
+}
+
+// fetchGroups reads every page of groups from the SCIM server
+func fetchGroups() ([]scimGroups, error) {
+	baseURL := strings.TrimSuffix(os.Getenv("SCIM_URL"), "/")
+	if baseURL == "" {
+		return nil, errors.New("SCIM_URL is not set")
+	}
+
+	pages := make([]scimGroups, 0)
+	for startIndex := 1; ; {
+		req, err := http.NewRequest(http.MethodGet, baseURL+"/Groups?startIndex="+strconv.Itoa(startIndex)+"&count="+strconv.Itoa(scimPageSize), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create SCIM request")
+		}
+		req.Header.Set("Accept", "application/scim+json")
+		if token := os.Getenv("SCIM_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list SCIM groups")
+		}
+		var page scimGroups
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("SCIM server answered with status %d", res.StatusCode)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode SCIM groups")
+		}
+		pages = append(pages, page)
+
+		startIndex += len(page.Resources)
+		if len(page.Resources) == 0 || startIndex > page.TotalResults {
+			return pages, nil
+		}
+	}
+}
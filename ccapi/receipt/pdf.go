@@ -0,0 +1,152 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layout of the PDF receipts, in points on an A4 page
+const (
+	pageWidth   = 595
+	pageHeight  = 842
+	margin      = 50
+	valueColumn = 160
+	lineHeight  = 16
+	qrWidth     = 180
+	// urlLineLength is where long values wrap, in characters of the
+	// monospaced font
+	urlLineLength = 64
+)
+
+// PDF renders the receipt as a single page PDF document, with the QR code
+// drawn as vectors so it prints sharp at any size
+func (r *Receipt) PDF() []byte {
+	var content bytes.Buffer
+	y := float64(pageHeight - margin - 20)
+
+	text(&content, "F2", 18, margin, y, "Transaction receipt")
+	y -= 2 * lineHeight
+
+	field := func(label string, values ...string) {
+		text(&content, "F1", 10, margin, y, label)
+		for _, v := range values {
+			text(&content, "F3", 10, valueColumn, y, v)
+			y -= lineHeight
+		}
+		if len(values) == 0 {
+			y -= lineHeight
+		}
+	}
+	field("Transaction ID", r.TxID)
+	field("Channel", r.Channel)
+	field("Chaincode", r.Chaincode)
+	field("Function", r.Function)
+	field("Block number", fmt.Sprint(r.BlockNumber))
+	field("Timestamp", r.Timestamp.Format(time.RFC3339Nano))
+	field("Validation code", r.ValidationCode)
+	field("Creator", identity(r.Creator.MSP, r.Creator.ID))
+	endorsers := make([]string, 0, len(r.Endorsers))
+	for _, e := range r.Endorsers {
+		endorsers = append(endorsers, identity(e.MSP, e.ID))
+	}
+	field("Endorsers", endorsers...)
+	field("Arguments SHA-256", r.ArgsHash)
+	field("Verification URL", wrap(r.VerificationURL, urlLineLength)...)
+	field("Issued at", r.IssuedAt.Format(time.RFC3339))
+
+	// QR code of the verification URL, with its quiet zone
+	y -= lineHeight
+	module := float64(qrWidth) / float64(r.code.Size+8)
+	top := y
+	for row := 0; row < r.code.Size; row++ {
+		for col := 0; col < r.code.Size; col++ {
+			if r.code.Black(col, row) {
+				x := margin + float64(col+4)*module
+				fmt.Fprintf(&content, "%.3f %.3f %.3f %.3f re\n", x, top-float64(row+5)*module, module, module)
+			}
+		}
+	}
+	content.WriteString("f\n")
+	text(&content, "F1", 9, margin+qrWidth+10, top-qrWidth/2, "Scan to verify that the transaction is included in the ledger")
+
+	return document(content.Bytes(), r.TxID)
+}
+
+// text writes a line of text in the font at the position
+func text(w *bytes.Buffer, font string, size, x, y float64, s string) {
+	fmt.Fprintf(w, "BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
+
+// escape encodes the text as a PDF literal string in WinAnsiEncoding,
+// replacing the characters it lacks
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7F:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func identity(msp, id string) string {
+	if id == "" {
+		return msp
+	}
+	return id + " (" + msp + ")"
+}
+
+// wrap splits the text into lines of at most n characters
+func wrap(s string, n int) []string {
+	var lines []string
+	for len(s) > n {
+		lines = append(lines, s[:n])
+		s = s[n:]
+	}
+	return append(lines, s)
+}
+
+// document assembles a PDF document of a single page with the content
+// stream, in the standard Helvetica and Courier fonts, which readers
+// provide
+func document(content []byte, title string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Contents 4 0 R "+
+			"/Resources << /Font << /F1 5 0 R /F2 6 0 R /F3 7 0 R >> >> >>", pageWidth, pageHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (Transaction receipt %s) /Producer (CCAPI) /CreationDate (D:%s) >>",
+			escape(title), time.Now().UTC().Format("20060102150405Z")),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, len(objects), xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,88 @@
+// Package receipt generates receipts of committed transactions, as JSON or
+// PDF documents, so users can keep a record of a transaction and hand it to
+// parties without access to the network. Receipts carry a QR code linking
+// to the inclusion proof of the transaction, which anyone can verify.
+package receipt
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/blockdecode"
+	"github.com/hyperledger-labs/ccapi/qrcode"
+	"github.com/pkg/errors"
+)
+
+// qrScale is the size of the modules of the QR codes of JSON receipts, in
+// pixels
+const qrScale = 4
+
+// Receipt is the record of a committed transaction
+type Receipt struct {
+	TxID            string                 `json:"txId"`
+	Channel         string                 `json:"channel"`
+	Chaincode       string                 `json:"chaincode"`
+	Function        string                 `json:"function"`
+	BlockNumber     uint64                 `json:"blockNumber"`
+	Timestamp       time.Time              `json:"timestamp"`
+	ValidationCode  string                 `json:"validationCode"`
+	Creator         blockdecode.Identity   `json:"creator"`
+	Endorsers       []blockdecode.Identity `json:"endorsers"`
+	ArgsHash        string                 `json:"argsHash"`
+	VerificationURL string                 `json:"verificationUrl"`
+	IssuedAt        time.Time              `json:"issuedAt"`
+	// QRCode is a PNG data URL of the QR code of the verification URL
+	QRCode string `json:"qrCode"`
+
+	code *qrcode.Code
+}
+
+// New returns the receipt of the transaction of the channel, with a QR
+// code linking to the verification URL
+func New(channel string, tx *blockdecode.Transaction, verificationURL string) (*Receipt, error) {
+	code, err := qrcode.Encode([]byte(verificationURL), qrcode.Medium)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the verification URL")
+	}
+	image, err := code.PNG(qrScale)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render the QR code")
+	}
+
+	endorsers := tx.Endorsers
+	if endorsers == nil {
+		endorsers = []blockdecode.Identity{}
+	}
+	return &Receipt{
+		TxID:            tx.TxID,
+		Channel:         channel,
+		Chaincode:       tx.Chaincode,
+		Function:        tx.Function,
+		BlockNumber:     tx.BlockNumber,
+		Timestamp:       tx.Timestamp,
+		ValidationCode:  tx.ValidationCode,
+		Creator:         blockdecode.Identity{MSP: tx.CreatorMSP, ID: tx.CreatorID},
+		Endorsers:       endorsers,
+		ArgsHash:        ArgsHash(tx.Args),
+		VerificationURL: verificationURL,
+		IssuedAt:        time.Now().UTC(),
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(image),
+		code:            code,
+	}, nil
+}
+
+// ArgsHash returns the hex encoded SHA-256 of the arguments of a
+// transaction as a JSON array of strings, without the function name, so
+// whoever holds the arguments can check them against a receipt without the
+// receipt disclosing them
+func ArgsHash(args []string) string {
+	if args == nil {
+		args = []string{}
+	}
+	data, _ := json.Marshal(args)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
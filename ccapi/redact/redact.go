@@ -0,0 +1,255 @@
+// Package redact masks sensitive values, such as CPFs and passwords, before
+// they are written to logs, audit records, the dashboard or error messages.
+//
+// The properties to mask are named in REDACT_FIELDS, either by themselves
+// (password) or as properties of an asset type (person.id), and the
+// properties of the asset types whose data type is in REDACT_DATATYPES are
+// added when the schema of the chaincode is loaded. Values matching the
+// regular expressions of REDACT_PATTERNS are masked wherever they appear in
+// text.
+package redact
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the redacted values
+const Mask = "[REDACTED]"
+
+const (
+	defaultFields    = "password,secret,token"
+	defaultDataTypes = "cpf"
+	// defaultPatterns matches CPFs, with or without punctuation
+	defaultPatterns = `\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`
+)
+
+var (
+	mutex       sync.RWMutex
+	fields      map[string]bool
+	patterns    []*regexp.Regexp
+	fieldRegexp *regexp.Regexp
+	loaded      bool
+)
+
+// getList returns the comma separated values of the variable, or the
+// default if it is not set
+func getList(name, def string) []string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		value = def
+	}
+
+	list := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// load reads the configuration once. The caller must hold the write lock.
+func load() {
+	if loaded {
+		return
+	}
+	loaded = true
+
+	fields = make(map[string]bool)
+	for _, field := range getList("REDACT_FIELDS", defaultFields) {
+		fields[strings.ToLower(field)] = true
+	}
+
+	for _, pattern := range getList("REDACT_PATTERNS", defaultPatterns) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("ignoring invalid redaction pattern '%s': %s\n", pattern, err)
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	compileFields()
+}
+
+// compileFields builds the expression matching the redacted fields in JSON
+// text, also when it is quoted inside another JSON string. The caller must
+// hold the write lock.
+func compileFields() {
+	// Properties of asset types are only masked in decoded values, where
+	// the type of their asset is known
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		if !strings.Contains(field, ".") {
+			names = append(names, regexp.QuoteMeta(field))
+		}
+	}
+	if len(names) == 0 {
+		fieldRegexp = nil
+		return
+	}
+	sort.Strings(names)
+	fieldRegexp = regexp.MustCompile(`(?i)(\\*")(` + strings.Join(names, "|") + `)\\*"\s*:\s*(\\*"[^"]*?\\*"|[^,}\]\s"]+)`)
+}
+
+// ensureLoaded loads the configuration if it was not loaded yet
+func ensureLoaded() {
+	mutex.RLock()
+	ok := loaded
+	mutex.RUnlock()
+	if !ok {
+		mutex.Lock()
+		load()
+		mutex.Unlock()
+	}
+}
+
+// AddFields adds properties to be masked, such as those of an asset type
+// with sensitive data types, named as type.prop
+func AddFields(names ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	load()
+	added := false
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if name != "" && !fields[name] {
+			fields[name] = true
+			added = true
+		}
+	}
+	if added {
+		compileFields()
+	}
+}
+
+// Fields returns the properties being masked, sorted
+func Fields() []string {
+	ensureLoaded()
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	res := make([]string, 0, len(fields))
+	for field := range fields {
+		res = append(res, field)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// DataTypes returns the data types whose properties are masked, from
+// REDACT_DATATYPES
+func DataTypes() []string {
+	return getList("REDACT_DATATYPES", defaultDataTypes)
+}
+
+// IsField reports whether the property of assets of the type is masked.
+// The type is empty for properties of other objects.
+func IsField(assetType, name string) bool {
+	ensureLoaded()
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	name = strings.ToLower(name)
+	if fields[name] {
+		return true
+	}
+	return assetType != "" && fields[strings.ToLower(assetType)+"."+name]
+}
+
+// Value returns a copy of the decoded JSON value with the redacted
+// properties masked, at any depth, and the strings masked by the patterns
+func Value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		assetType, _ := v["@assetType"].(string)
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if IsField(assetType, key) && item != nil {
+				res[key] = Mask
+				continue
+			}
+			res[key] = Value(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = Value(item)
+		}
+		return res
+	case string:
+		return String(v)
+	}
+	return value
+}
+
+// JSON masks the JSON document, such as a chaincode argument. Text that is
+// not JSON is masked as a string. Documents with nothing to mask are
+// returned unchanged.
+func JSON(doc string) string {
+	var value interface{}
+	if json.Unmarshal([]byte(doc), &value) != nil {
+		return String(doc)
+	}
+	if _, ok := value.(string); ok {
+		return String(doc)
+	}
+
+	original, err := json.Marshal(value)
+	if err != nil {
+		return String(doc)
+	}
+	res, err := json.Marshal(Value(value))
+	if err != nil {
+		return String(doc)
+	}
+	if string(res) == string(original) {
+		return doc
+	}
+	return string(res)
+}
+
+// String masks the redacted properties of the JSON found in free text, such
+// as log lines and error messages, and the values matching the patterns
+func String(s string) string {
+	ensureLoaded()
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if fieldRegexp != nil {
+		s = fieldRegexp.ReplaceAllString(s, `${1}${2}${1}:${1}`+Mask+`${1}`)
+	}
+	for _, re := range patterns {
+		s = re.ReplaceAllString(s, Mask)
+	}
+	return s
+}
+
+// writer masks what is written to another writer
+type writer struct {
+	w io.Writer
+}
+
+// Writer returns a writer masking the text written to w, to be set as the
+// output of loggers
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+// Write masks the text and writes it. It reports the length of p as
+// written, since masking changes the length of the text.
+func (r *writer) Write(p []byte) (int, error) {
+	_, err := r.w.Write([]byte(String(string(p))))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addAssetRoutes registers REST resources for each asset type defined in the
+// chaincode when the CCAPI starts
+func addAssetRoutes(rg *gin.RouterGroup) {
+	rg.PATCH("/bulk", handlers.BulkUpdateAssets)
+	rg.POST("/read-many", handlers.ReadManyAssets)
+	rg.POST("/join", handlers.JoinAssets)
+
+	// Resolver of the QR codes of asset labels
+	rg.GET("/resolve", features.Require("assetQRCodes"), handlers.ResolveAsset)
+	rg.POST("/resolve", features.Require("assetQRCodes"), handlers.ResolveAsset)
+
+	schema, err := chaincode.QueryGateway(os.Getenv("CHANNEL"), os.Getenv("CCNAME"), "getSchema", os.Getenv("USER"), []string{"{}"})
+	if err != nil {
+		log.Println("asset routes not registered, failed to get schema: ", err)
+		return
+	}
+
+	var assetTypes []struct {
+		Tag     string   `json:"tag"`
+		Readers []string `json:"readers"`
+	}
+	err = json.Unmarshal(schema, &assetTypes)
+	if err != nil {
+		log.Println("asset routes not registered, failed to unmarshal schema: ", err)
+		return
+	}
+
+	for _, assetType := range assetTypes {
+		path := "/" + assetType.Tag
+		rg.GET(path, handlers.ListAssets(assetType.Tag))
+		rg.POST(path, handlers.CreateAsset(assetType.Tag))
+		rg.GET(path+"/near", handlers.NearAssets(assetType.Tag))
+		rg.GET(path+"/:key", handlers.ReadAsset(assetType.Tag))
+		rg.GET(path+"/:key/history", handlers.ReadAssetHistory(assetType.Tag))
+		rg.GET(path+"/:key/diff", handlers.DiffAsset(assetType.Tag))
+		rg.GET(path+"/:key/provenance", handlers.AssetProvenance(assetType.Tag))
+		rg.PUT(path+"/:key", handlers.UpdateAsset(assetType.Tag))
+		rg.PATCH(path+"/:key", handlers.UpdateAsset(assetType.Tag))
+		rg.DELETE(path+"/:key", handlers.DeleteAsset(assetType.Tag))
+		rg.GET(path+"/:key/endorsement", handlers.ReadEndorsementPolicy(assetType.Tag))
+		rg.PUT(path+"/:key/endorsement", handlers.SetEndorsementPolicy(assetType.Tag))
+		rg.GET(path+"/:key/qrcode", features.Require("assetQRCodes"), handlers.AssetQRCode(assetType.Tag))
+
+		// Sensitive properties kept out of logs and audit records
+		handlers.RedactSensitiveProps(assetType.Tag)
+
+		// Crypto-shredding of personal data
+		if handlers.HasEncryptedFields(assetType.Tag) {
+			rg.DELETE(path+"/:key/personal-data", handlers.ShredPersonalData(assetType.Tag))
+		}
+
+		// Assets in private collections
+		if len(assetType.Readers) > 0 {
+			rg.GET(path+"/:key/private", handlers.ReadPrivateAsset(assetType.Tag))
+			rg.POST(path+"/:key/verify", handlers.VerifyPrivateAsset(assetType.Tag))
+			rg.GET(path+"/:key/purge", handlers.PurgePreview(assetType.Tag))
+			rg.DELETE(path+"/:key/purge", handlers.PurgePrivateAsset(assetType.Tag))
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addBootstrapRoutes registers the reconciliation with the bootstrap
+// configuration in the admin API of the dashboard
+func addBootstrapRoutes(rg *gin.RouterGroup) {
+	rg.GET("/api/bootstrap", handlers.BootstrapPlan)
+	rg.POST("/api/bootstrap", handlers.ApplyBootstrap)
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"github.com/hyperledger-labs/ccapi/features"
 	"github.com/hyperledger-labs/ccapi/handlers"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +21,17 @@ func addCCRoutes(rg *gin.RouterGroup) {
 	rg.POST("/gateway/query/:txname", handlers.QueryGatewayDefault)
 	rg.GET("/gateway/query/:txname", handlers.QueryGatewayDefault)
 
+	// Functions of chaincodes not built with cc-tools, called with their
+	// string arguments
+	rg.POST("/gateway/:channelName/:chaincodeName/raw/:txname", handlers.RawGatewayCustom)
+	rg.POST("/gateway/raw/:txname", handlers.RawGatewayDefault)
+
+	// Transactions prepared before their submission, which can be submitted
+	// again without duplicating them
+	rg.POST("/gateway/:channelName/:chaincodeName/prepare/:txname", handlers.PrepareGatewayCustom)
+	rg.POST("/gateway/prepare/:txname", handlers.PrepareGatewayDefault)
+	rg.POST("/gateway/submit/:txid", handlers.SubmitPrepared)
+
 	// Other
 	rg.POST("/:channelName/:chaincodeName/invoke/:txname", handlers.Invoke)
 	rg.PUT("/:channelName/:chaincodeName/invoke/:txname", handlers.Invoke)
@@ -39,4 +51,62 @@ func addCCRoutes(rg *gin.RouterGroup) {
 	rg.GET("/query/:txname", handlers.QueryV1)
 
 	rg.GET("/:channelName/qscc/:txname", handlers.QueryQSCC)
+
+	// Proof of inclusion of a transaction in its block
+	rg.GET("/:channelName/proof/:txid", features.Require("inclusionProofs"), handlers.InclusionProof)
+
+	// Keys read and written by a committed transaction
+	rg.GET("/:channelName/rwset/:txid", handlers.TransactionRWSet)
+
+	// Receipt of a committed transaction, linking to its inclusion proof
+	rg.GET("/:channelName/receipt/:txid", features.Require("transactionReceipts"), handlers.TransactionReceipt)
+
+	// Anchors of the ledger published to an external endpoint
+	rg.GET("/anchors", features.Require("anchors"), handlers.ListAnchors)
+	rg.POST("/anchors", features.Require("anchors"), handlers.AnchorLedger)
+
+	// Protobuf definitions of the binary payloads
+	rg.GET("/schema.proto", features.Require("binaryPayloads"), handlers.ProtoSchema)
+
+	// JSON-LD context of the assets
+	rg.GET("/context.jsonld", features.Require("jsonLD"), handlers.JSONLDContext)
+
+	// API versions served to older clients
+	rg.GET("/versions", handlers.APIVersions)
+
+	// Validation of values with the data types of the chaincode
+	rg.POST("/datatypes/validate", handlers.ValidateValues)
+	rg.GET("/datatypes/:dataType/validate", handlers.ValidateValue)
+
+	// Registry of the schemas of the chaincode event payloads
+	rg.GET("/events/schemas", handlers.ListEventSchemas)
+	rg.GET("/events/schemas/:event", handlers.GetEventSchema)
+	rg.POST("/events/schemas/:event/validate", handlers.ValidateEventPayload)
+
+	// Replay of the chaincode events of a range of blocks
+	rg.POST("/events/replay", handlers.ReplayEvents)
+	rg.GET("/events/replays", handlers.ListReplays)
+
+	// Canonical JSON of chaincode arguments
+	rg.POST("/canonical", handlers.Canonicalize)
+
+	// Experimental features and whether they are enabled
+	rg.GET("/features", handlers.ListFeatures)
+
+	// Features, transactions, asset types, auth modes and limits served,
+	// for clients to adapt at runtime
+	rg.GET("/capabilities", handlers.Capabilities)
+
+	// Read-only mode for maintenance, toggled in the dashboard
+	rg.GET("/maintenance", handlers.MaintenanceStatus)
+
+	// Transactions submitted by each identity this month
+	rg.GET("/usage", handlers.UsageReport)
+
+	// Roles of the user and group sync
+	rg.GET("/rbac", handlers.RBACStatus)
+	rg.POST("/rbac/sync", handlers.SyncRBAC)
+
+	// Organization profiles selectable with the Org header
+	rg.GET("/orgs", handlers.ListOrgs)
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addCollectibleRoutes registers the unique asset ownership operations
+func addCollectibleRoutes(rg *gin.RouterGroup) {
+	rg.GET("", handlers.ListCollectibles)
+	rg.POST("", handlers.MintCollectible)
+	rg.GET("/:key", handlers.ReadCollectible)
+	rg.PUT("/:key", handlers.UpdateCollectible)
+	rg.POST("/:key/transfer", handlers.TransferCollectible)
+	rg.GET("/:key/transfers", handlers.CollectibleTransfers)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addCredentialRoutes registers the issuance and verification of verifiable
+// credentials attesting to ledger state
+func addCredentialRoutes(rg *gin.RouterGroup) {
+	rg.Use(features.Require("verifiableCredentials"))
+	rg.POST("", handlers.IssueCredential)
+	rg.POST("/verify", handlers.VerifyCredential)
+	rg.GET("/issuer", handlers.CredentialIssuer)
+}
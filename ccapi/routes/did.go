@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addDIDRoutes registers the resolution of the DIDs of the organizations of
+// the channels
+func addDIDRoutes(rg *gin.RouterGroup) {
+	rg.Use(features.Require("didResolution"))
+	rg.GET("", handlers.ListDIDs)
+	rg.GET("/:did", handlers.ResolveDID)
+	rg.POST("/verify", handlers.VerifyDIDCertificate)
+}
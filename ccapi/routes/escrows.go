@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addEscrowRoutes registers the escrow workflow operations
+func addEscrowRoutes(rg *gin.RouterGroup) {
+	rg.GET("", handlers.ListEscrows)
+	rg.POST("", handlers.LockEscrow)
+	rg.GET("/scheduled", handlers.ListScheduledJobs)
+	rg.GET("/:key", handlers.ReadEscrow)
+	rg.POST("/:key/confirm", handlers.SettleEscrow("confirmEscrow", handlers.EventEscrowConfirmed))
+	rg.POST("/:key/release", handlers.SettleEscrow("releaseEscrow", handlers.EventEscrowReleased))
+}
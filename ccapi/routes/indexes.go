@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addIndexRoutes registers the management of the CouchDB indexes of the
+// chaincode, and the report of the queries needing them, in the admin API of
+// the dashboard
+func addIndexRoutes(rg *gin.RouterGroup) {
+	rg.GET("/api/indexes", handlers.ListIndexes)
+	rg.POST("/api/indexes", handlers.CreateIndex)
+	rg.POST("/api/indexes/validate", handlers.ValidateIndex)
+	rg.POST("/api/indexes/explain", handlers.ExplainQuery)
+	rg.GET("/api/indexes/unindexed", handlers.ListUnindexedQueries)
+	rg.GET("/api/queries", handlers.SlowQueryReport)
+	rg.DELETE("/api/queries", handlers.ResetSlowQueries)
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addInventoryRoutes registers the operations on the copies of the books,
+// keyed by book
+func addInventoryRoutes(rg *gin.RouterGroup) {
+	rg.GET("/:key", handlers.GetBookCopies)
+	rg.POST("/:key", handlers.AddBookCopies)
+}
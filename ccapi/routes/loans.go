@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addLoanRoutes registers the book loan operations
+func addLoanRoutes(rg *gin.RouterGroup) {
+	rg.GET("", handlers.ListLoans)
+	rg.POST("", handlers.BorrowBook)
+	rg.GET("/overdue", handlers.ListOverdueLoans)
+	rg.GET("/:key", handlers.ReadLoan)
+	rg.POST("/:key/return", handlers.ReturnLoan)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addProposalRoutes registers the cross-org approval workflow
+func addProposalRoutes(rg *gin.RouterGroup) {
+	rg.POST("", handlers.SubmitProposal)
+	rg.GET("", handlers.ListProposals)
+	rg.GET("/pending", handlers.ListPendingProposals)
+	rg.POST("/:key/approve", handlers.DecideProposal("approveProposal", handlers.EventProposalApproved))
+	rg.POST("/:key/reject", handlers.DecideProposal("rejectProposal", handlers.EventProposalRejected))
+}
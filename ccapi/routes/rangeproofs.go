@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addRangeProofRoutes registers the range proof helpers
+func addRangeProofRoutes(rg *gin.RouterGroup) {
+	rg.Use(features.Require("rangeProofs"))
+	rg.POST("/commit", handlers.CommitValue)
+	rg.POST("/prove", handlers.ProveClaim)
+	rg.POST("/verify", handlers.VerifyClaim)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addReservationRoutes registers the book reservation operations, keyed by
+// the reserved book
+func addReservationRoutes(rg *gin.RouterGroup) {
+	rg.GET("/:key", handlers.ListReservations)
+	rg.POST("/:key", handlers.ReserveBook)
+	rg.DELETE("/:key", handlers.CancelReservation)
+}
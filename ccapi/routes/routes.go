@@ -2,7 +2,13 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/console"
+	"github.com/hyperledger-labs/ccapi/dashboard"
 	"github.com/hyperledger-labs/ccapi/docs"
+	"github.com/hyperledger-labs/ccapi/handlers"
+	"github.com/hyperledger-labs/ccapi/metrics"
+	"github.com/hyperledger-labs/ccapi/oidc"
+	"github.com/hyperledger-labs/ccapi/pipeline"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -19,6 +25,15 @@ func AddRoutesToEngine(r *gin.Engine) {
 		})
 	})
 
+	// Readiness probe, failing while the connection to a peer is down
+	r.GET("/readyz", handlers.Ready)
+
+	// Build of the binary, to verify rollouts
+	r.GET("/version", handlers.Version)
+
+	// Prometheus metrics, with the depth of the transaction queues
+	r.GET("/metrics", metrics.Handler())
+
 	// serve swagger files
 	docs.SwaggerInfo.BasePath = "/api"
 	r.StaticFile("/swagger.yaml", "./docs/swagger.yaml")
@@ -26,11 +41,71 @@ func AddRoutesToEngine(r *gin.Engine) {
 	url := ginSwagger.URL("/swagger.yaml")
 	r.GET("/api-docs/*any", ginSwagger.WrapHandler(swaggerfiles.Handler, url))
 
+	// Browser login with an OpenID Connect provider
+	consoleRG := pipeline.Group(&r.RouterGroup, "/console")
+	if oidc.Enabled() {
+		oidc.AddRoutes(r.Group("/auth"))
+		consoleRG.Use(oidc.RequireSession())
+	}
+
+	// Interactive console generated from the chaincode transactions
+	console.AddRoutes(consoleRG)
+
 	// CHANNEL routes
-	chaincodeRG := r.Group("/api")
+	chaincodeRG := pipeline.Group(&r.RouterGroup, "/api", "timer", "dashboard", "cassette", "compat", "chaos", "payload", "jsonld", "versioning", "transform", "oidc")
 	addCCRoutes(chaincodeRG)
 
+	// Transaction routes declared in the routes configuration
+	addTxRoutes(chaincodeRG)
+
+	// Composite endpoints defined by scripts
+	addScriptRoutes(pipeline.Group(chaincodeRG, "/scripts"))
+
+	// Asset type resources
+	addAssetRoutes(pipeline.Group(chaincodeRG, "/assets"))
+
+	// Cross-org approval workflow
+	addProposalRoutes(pipeline.Group(chaincodeRG, "/proposals"))
+
+	// Fungible tokens
+	addTokenRoutes(pipeline.Group(chaincodeRG, "/tokens"))
+
+	// Tokens of a Fabric Token SDK application
+	addTokenSDKRoutes(pipeline.Group(chaincodeRG, "/tokensdk"))
+
+	// Unique asset ownership
+	addCollectibleRoutes(pipeline.Group(chaincodeRG, "/collectibles"))
+
+	// Escrow of collectibles
+	addEscrowRoutes(pipeline.Group(chaincodeRG, "/escrows"))
+
+	// Book loans
+	addLoanRoutes(pipeline.Group(chaincodeRG, "/loans"))
+	addReservationRoutes(pipeline.Group(chaincodeRG, "/reservations"))
+	addInventoryRoutes(pipeline.Group(chaincodeRG, "/inventory"))
+
+	// Verifiable credentials attesting to ledger state
+	addCredentialRoutes(pipeline.Group(chaincodeRG, "/credentials"))
+
+	// DIDs of the organizations, resolved from the channel configuration
+	addDIDRoutes(pipeline.Group(chaincodeRG, "/did"))
+
+	// Range proofs over committed values
+	addRangeProofRoutes(pipeline.Group(chaincodeRG, "/rangeproofs"))
+
+	// Admin dashboard
+	if dashboard.Enabled() {
+		dashboardRG := pipeline.Group(&r.RouterGroup, "/dashboard")
+		dashboard.AddRoutes(dashboardRG)
+		addBootstrapRoutes(dashboardRG)
+		addIndexRoutes(dashboardRG)
+		addSettingsRoutes(dashboardRG)
+	}
+
 	// Update SDK route
-	sdkRG := r.Group("/sdk")
+	sdkRG := pipeline.Group(&r.RouterGroup, "/sdk")
 	addSDKRoutes(sdkRG)
+
+	// Groups of the pipeline configuration must exist
+	pipeline.Check()
 }
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+	"github.com/hyperledger-labs/ccapi/scripting"
+)
+
+// addScriptRoutes registers the custom endpoints defined by the scripts of
+// SCRIPTS_DIR, if any
+func addScriptRoutes(rg *gin.RouterGroup) {
+	if !scripting.Enabled() {
+		return
+	}
+
+	scripts, err := scripting.Load()
+	if err != nil {
+		log.Fatalln("invalid scripts: ", err)
+	}
+
+	rg.GET("", handlers.ListScripts(scripts))
+	for _, script := range scripts {
+		rg.Handle(script.Method, "/"+script.Name, handlers.RunScript(script))
+	}
+	log.Printf("serving %d script endpoints\n", len(scripts))
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addSettingsRoutes registers the effective configuration in the admin API
+// of the dashboard
+func addSettingsRoutes(rg *gin.RouterGroup) {
+	rg.GET("/api/config", handlers.EffectiveConfig)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addTokenRoutes registers the fungible token operations
+func addTokenRoutes(rg *gin.RouterGroup) {
+	rg.GET("", handlers.ListTokens)
+	rg.GET("/:symbol", handlers.ReadToken)
+	rg.POST("/:symbol/mint", handlers.MintToken)
+	rg.POST("/:symbol/transfer", handlers.TransferToken)
+	rg.GET("/:symbol/balance", handlers.TokenBalance)
+}
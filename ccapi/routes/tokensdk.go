@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/features"
+	"github.com/hyperledger-labs/ccapi/handlers"
+)
+
+// addTokenSDKRoutes registers the operations proxied to the nodes of a
+// Fabric Token SDK application
+func addTokenSDKRoutes(rg *gin.RouterGroup) {
+	rg.Use(features.Require("tokenSDK"))
+	rg.GET("", handlers.TokenSDKStatus)
+	rg.POST("/issue", handlers.IssueTokens)
+	rg.GET("/:node/accounts/:account", handlers.TokenSDKBalance)
+	rg.GET("/:node/accounts/:account/transactions", handlers.TokenSDKTransactions)
+	rg.POST("/:node/accounts/:account/transfer", handlers.TransferTokens)
+	rg.POST("/:node/accounts/:account/redeem", handlers.RedeemTokens)
+}
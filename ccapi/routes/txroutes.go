@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/handlers"
+	"github.com/pkg/errors"
+)
+
+// addTxRoutes registers the transaction routes declared in the file set by
+// ROUTES_CONFIG, if any
+func addTxRoutes(rg *gin.RouterGroup) {
+	path := os.Getenv("ROUTES_CONFIG")
+	if path == "" {
+		return
+	}
+
+	txRoutes, err := loadTxRoutes(path)
+	if err != nil {
+		log.Fatalln("invalid routes configuration: ", err)
+	}
+
+	for _, route := range txRoutes {
+		rg.Handle(route.Method, route.Path, handlers.ConfiguredTx(route))
+	}
+}
+
+// loadTxRoutes reads and validates the routes configuration file
+func loadTxRoutes(path string) ([]handlers.TxRoute, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read file")
+	}
+
+	var txRoutes []handlers.TxRoute
+	err = json.Unmarshal(data, &txRoutes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal file")
+	}
+
+	for i, route := range txRoutes {
+		if route.Tx == "" {
+			return nil, errors.Errorf("route %d has no transaction", i)
+		}
+		if !strings.HasPrefix(route.Path, "/") {
+			return nil, errors.Errorf("path of route '%s' must start with '/'", route.Tx)
+		}
+
+		route.Method = strings.ToUpper(route.Method)
+		if route.Method == "" {
+			route.Method = http.MethodPost
+			if route.ReadOnly {
+				route.Method = http.MethodGet
+			}
+		}
+		switch route.Method {
+		case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return nil, errors.Errorf("invalid method '%s' for route '%s'", route.Method, route.Tx)
+		}
+		if route.Method == http.MethodGet && !route.ReadOnly {
+			return nil, errors.Errorf("route '%s' submits a transaction and cannot use GET", route.Tx)
+		}
+		txRoutes[i] = route
+	}
+
+	return txRoutes, nil
+}
@@ -0,0 +1,176 @@
+// Package scheduler runs jobs of the CCAPI at a given time, such as the
+// release of expired escrows. Jobs are kept in memory, so they must be
+// scheduled again when the CCAPI restarts.
+//
+// Replicas sharing their state may schedule the same jobs, so each run is
+// claimed in the shared state first, and only the replica that claims the
+// run of a job at a given time runs it. With leader election, jobs only run
+// on the leader, except local jobs, which refresh the memory of every
+// replica.
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/leader"
+	"github.com/hyperledger-labs/ccapi/state"
+)
+
+// Failed runs are retried after retryDelay, up to maxAttempts runs
+const (
+	maxAttempts = 3
+	retryDelay  = time.Minute
+)
+
+// claimTTL is how long the claim of a run is kept
+const claimTTL = time.Minute
+
+// Job is a function scheduled to run at a given time
+type Job struct {
+	ID        string    `json:"id"`
+	RunAt     time.Time `json:"runAt"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	Local     bool      `json:"local,omitempty"`
+
+	run   func() error
+	timer *time.Timer
+}
+
+var (
+	jobs   = make(map[string]*Job)
+	jobsMu sync.Mutex
+)
+
+// Schedule runs fn at the given time, or right away if it has passed,
+// replacing the job with the same id
+func Schedule(id string, at time.Time, fn func() error) {
+	schedule(&Job{ID: id, RunAt: at, run: fn})
+}
+
+// ScheduleLocal is Schedule for jobs every replica runs, such as refreshing
+// what it keeps in memory
+func ScheduleLocal(id string, at time.Time, fn func() error) {
+	schedule(&Job{ID: id, RunAt: at, Local: true, run: fn})
+}
+
+func schedule(job *Job) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	if old, ok := jobs[job.ID]; ok {
+		old.timer.Stop()
+	}
+
+	job.timer = time.AfterFunc(time.Until(job.RunAt), func() { execute(job) })
+	jobs[job.ID] = job
+}
+
+// Cancel removes the job, returning false if it was not scheduled
+func Cancel(id string) bool {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	job, ok := jobs[id]
+	if !ok {
+		return false
+	}
+	job.timer.Stop()
+	delete(jobs, id)
+	return true
+}
+
+// Scheduled reports whether the job is waiting to run
+func Scheduled(id string) bool {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	_, ok := jobs[id]
+	return ok
+}
+
+// Jobs returns the scheduled jobs, sorted by the time they run
+func Jobs() []Job {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	res := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		res = append(res, *job)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].RunAt.Before(res[j].RunAt)
+	})
+	return res
+}
+
+func execute(job *Job) {
+	if !job.Local {
+		// Replicas other than the leader keep the job until they lead
+		leader.Wait()
+		jobsMu.Lock()
+		scheduled := jobs[job.ID] == job
+		jobsMu.Unlock()
+		if !scheduled {
+			return
+		}
+	}
+
+	if !job.Local && !claim(job) {
+		jobsMu.Lock()
+		if jobs[job.ID] == job {
+			delete(jobs, job.ID)
+		}
+		jobsMu.Unlock()
+		return
+	}
+
+	err := job.run()
+
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	// The job was cancelled or replaced while running
+	if jobs[job.ID] != job {
+		return
+	}
+
+	job.Attempts++
+	if err == nil {
+		delete(jobs, job.ID)
+		return
+	}
+
+	job.LastError = err.Error()
+	if job.Attempts >= maxAttempts {
+		log.Printf("scheduled job %s failed %d times, giving up: %s\n", job.ID, job.Attempts, err)
+		delete(jobs, job.ID)
+		return
+	}
+
+	log.Printf("scheduled job %s failed, retrying in %s: %s\n", job.ID, retryDelay, err)
+	job.RunAt = time.Now().Add(retryDelay)
+	job.timer = time.AfterFunc(retryDelay, func() { execute(job) })
+}
+
+// claim reports whether the replica runs the job, which another replica
+// sharing the state may have claimed for the same time, such as the release
+// of an escrow scheduled by every replica. Jobs are run when the shared
+// state is unreachable, rather than skipped by every replica.
+func claim(job *Job) bool {
+	if !state.Shared() {
+		return true
+	}
+
+	key := fmt.Sprintf("job:%s:%d", job.ID, job.RunAt.Unix())
+	claimed, err := state.Default().SetNX(key, []byte(job.ID), claimTTL)
+	if err != nil {
+		log.Printf("error claiming scheduled job %s, running it: %s\n", job.ID, err)
+		return true
+	}
+	return claimed
+}
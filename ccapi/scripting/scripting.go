@@ -0,0 +1,274 @@
+// Package scripting serves custom composite endpoints defined by Starlark
+// scripts, so integrators can add small orchestrations, such as creating a
+// library with its books, without recompiling the CCAPI. Each {name}.star
+// file of the directory in SCRIPTS_DIR is served at /api/scripts/{name} and
+// defines the handler of the requests:
+//
+//	METHOD = "POST"  # optional, default POST
+//	DESCRIPTION = "Creates a library with its books"
+//
+//	def handle(request):
+//	    books = [invoke("createAsset", {"asset": [b]})[0] for b in request["body"]["books"]]
+//	    ...
+//	    return {"library": library, "books": books}
+//
+// The request holds the decoded JSON body, the query parameters and the
+// user, and the value returned is the JSON response. Scripts call the
+// chaincode with invoke(tx, args) and evaluate(tx, args), which take the
+// channel and chaincode keyword arguments, stop with error(status,
+// message), and can use the json module.
+package scripting
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+)
+
+// handlerName is the function of the scripts handling the requests
+const handlerName = "handle"
+
+// maxSteps limits the computation of a request, so a script cannot loop
+// forever
+const maxSteps = 10000000
+
+// Primitives call the chaincode on behalf of a script, as the user of the
+// request. Failures abort the script.
+type Primitives interface {
+	Invoke(channel, chaincode, txName string, args map[string]interface{}) (interface{}, error)
+	Evaluate(channel, chaincode, txName string, args map[string]interface{}) (interface{}, error)
+}
+
+// Request is the request passed to the handler of a script
+type Request struct {
+	Body  interface{}
+	Query map[string]string
+	User  string
+}
+
+// Script is a custom endpoint
+type Script struct {
+	Name        string `json:"name"`
+	Method      string `json:"method"`
+	Description string `json:"description,omitempty"`
+	handler     starlark.Callable
+}
+
+// Enabled reports whether scripts are served, from the directory set by
+// SCRIPTS_DIR
+func Enabled() bool {
+	return os.Getenv("SCRIPTS_DIR") != ""
+}
+
+// Load executes the scripts of the directory set by SCRIPTS_DIR, sorted by
+// name
+func Load() ([]*Script, error) {
+	files, err := filepath.Glob(filepath.Join(os.Getenv("SCRIPTS_DIR"), "*.star"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list scripts")
+	}
+	sort.Strings(files)
+
+	scripts := make([]*Script, 0, len(files))
+	for _, file := range files {
+		script, err := LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts, nil
+}
+
+// LoadFile executes a script file and looks up its handler
+func LoadFile(path string) (*Script, error) {
+	name := strings.TrimSuffix(filepath.Base(path), ".star")
+	thread := &starlark.Thread{Name: "load " + name}
+	globals, err := starlark.ExecFile(thread, path, nil, predeclared)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load script '%s'", name)
+	}
+
+	s := &Script{Name: name, Method: http.MethodPost}
+	fn, ok := globals[handlerName].(starlark.Callable)
+	if !ok {
+		return nil, errors.Errorf("script '%s' does not define %s(request)", name, handlerName)
+	}
+	s.handler = fn
+	if method, ok := globals["METHOD"].(starlark.String); ok {
+		s.Method = strings.ToUpper(string(method))
+	}
+	switch s.Method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil, errors.Errorf("invalid method '%s' of script '%s'", s.Method, name)
+	}
+	if description, ok := globals["DESCRIPTION"].(starlark.String); ok {
+		s.Description = string(description)
+	}
+	return s, nil
+}
+
+// Run calls the handler of the script with the request, returning the
+// response body. It stops once the context is done.
+func (s *Script) Run(ctx context.Context, req Request, prims Primitives) (interface{}, error) {
+	thread := &starlark.Thread{Name: s.Name}
+	thread.SetMaxExecutionSteps(maxSteps)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel("request cancelled")
+		case <-done:
+		}
+	}()
+
+	query := starlark.NewDict(len(req.Query))
+	for k, v := range req.Query {
+		query.SetKey(starlark.String(k), starlark.String(v))
+	}
+	body, err := toStarlark(thread, req.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode body")
+	}
+	request := starlark.NewDict(3)
+	request.SetKey(starlark.String("body"), body)
+	request.SetKey(starlark.String("query"), query)
+	request.SetKey(starlark.String("user"), starlark.String(req.User))
+
+	// Primitives are bound to the thread of the request, so requests share
+	// the handler but not their identity
+	thread.SetLocal("primitives", prims)
+
+	res, err := starlark.Call(thread, s.handler, starlark.Tuple{request}, nil)
+	if err != nil {
+		// Errors of the chaincode and of error() are returned as they are,
+		// so they keep their status
+		var callErr *primitiveError
+		if errors.As(err, &callErr) {
+			return nil, callErr.err
+		}
+		var scriptErr *common.APIError
+		if errors.As(err, &scriptErr) {
+			return nil, scriptErr
+		}
+		return nil, common.NewAPIError(http.StatusInternalServerError, fmt.Sprintf("script '%s' failed: %s", s.Name, err))
+	}
+	return fromStarlark(thread, res)
+}
+
+// predeclared are the modules and builtins available to the scripts
+var predeclared = starlark.StringDict{
+	"json":     json.Module,
+	"invoke":   starlark.NewBuiltin("invoke", call(true)),
+	"evaluate": starlark.NewBuiltin("evaluate", call(false)),
+	"error":    starlark.NewBuiltin("error", scriptError),
+}
+
+// call returns the builtin submitting or evaluating a transaction with the
+// primitives of the thread
+func call(submit bool) func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+	return func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var txName, channel, chaincode string
+		var txArgs starlark.Value = starlark.NewDict(0)
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "tx", &txName, "args?", &txArgs, "channel?", &channel, "chaincode?", &chaincode)
+		if err != nil {
+			return nil, err
+		}
+		prims, ok := thread.Local("primitives").(Primitives)
+		if !ok {
+			return nil, errors.Errorf("%s can only be called while handling a request", b.Name())
+		}
+		if channel == "" {
+			channel = os.Getenv("CHANNEL")
+		}
+		if chaincode == "" {
+			chaincode = os.Getenv("CCNAME")
+		}
+
+		v, err := fromStarlark(thread, txArgs)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid args")
+		}
+		req, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("args must be a dict")
+		}
+
+		var res interface{}
+		if submit {
+			res, err = prims.Invoke(channel, chaincode, txName, req)
+		} else {
+			res, err = prims.Evaluate(channel, chaincode, txName, req)
+		}
+		if err != nil {
+			return nil, &primitiveError{err: err}
+		}
+		return toStarlark(thread, res)
+	}
+}
+
+// primitiveError is an error of a primitive called by a script
+type primitiveError struct {
+	err error
+}
+
+func (e *primitiveError) Error() string {
+	return e.err.Error()
+}
+
+// scriptError stops the script with the status and message of the error
+func scriptError(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var status int
+	var message string
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "status", &status, "message", &message)
+	if err != nil {
+		return nil, err
+	}
+	if status < 400 || status > 599 {
+		return nil, fmt.Errorf("error: status %d is not an error status", status)
+	}
+	return nil, common.NewAPIError(status, message)
+}
+
+// toStarlark converts a decoded JSON value to Starlark
+func toStarlark(thread *starlark.Thread, v interface{}) (starlark.Value, error) {
+	data, err := gojson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Call(thread, json.Module.Members["decode"], starlark.Tuple{starlark.String(data)}, nil)
+}
+
+// fromStarlark converts a Starlark value to a decoded JSON value
+func fromStarlark(thread *starlark.Thread, v starlark.Value) (interface{}, error) {
+	encoded, err := starlark.Call(thread, json.Module.Members["encode"], starlark.Tuple{v}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	err = common.UnmarshalJSON([]byte(encoded.(starlark.String)), &res)
+	return res, err
+}
+
+// Timeout is how long a request can run a script, set by SCRIPTS_TIMEOUT
+// (default 1m)
+func Timeout() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("SCRIPTS_TIMEOUT"))
+	if err != nil || d <= 0 {
+		return time.Minute
+	}
+	return d
+}
@@ -0,0 +1,232 @@
+// Package secretscan looks for secrets, such as private keys and credit card
+// numbers, in the public arguments of transactions before they are
+// submitted, since whatever reaches the ledger can never be erased. Transient
+// data is not scanned, as it is not written to the ledger.
+//
+// SECRET_SCAN sets what happens when a secret is found: warn logs it and
+// lets the transaction through, block rejects the transaction. Scanning is
+// off by default.
+package secretscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// Modes of the scanning
+const (
+	ModeOff   = "off"
+	ModeWarn  = "warn"
+	ModeBlock = "block"
+)
+
+// Finding is a secret found in an argument
+type Finding struct {
+	// Kind is the detector that found the secret
+	Kind string `json:"kind"`
+	// Path is where the secret is in the argument, such as
+	// $[0].asset[1].notes, $[0] being the first argument
+	Path string `json:"path"`
+	// Hint shows the end of the secret, so it can be located without
+	// being repeated in full
+	Hint string `json:"hint"`
+}
+
+// detector finds one kind of secret in a string
+type detector struct {
+	kind  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+	// public is set when the match itself is not secret and is shown as
+	// the hint, such as the header of a private key
+	public bool
+}
+
+var builtinDetectors = []detector{
+	{
+		kind:   "privateKey",
+		re:     regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY( BLOCK)?-----`),
+		public: true,
+	},
+	{
+		kind:  "creditCard",
+		re:    regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		valid: luhn,
+	},
+	{
+		kind: "awsAccessKey",
+		re:   regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+}
+
+var (
+	detectorsOnce sync.Once
+	detectors     []detector
+)
+
+// getDetectors returns the builtin detectors and those of the regular
+// expressions in SECRET_SCAN_PATTERNS, separated by commas
+func getDetectors() []detector {
+	detectorsOnce.Do(func() {
+		detectors = append(detectors, builtinDetectors...)
+		for _, pattern := range strings.Split(os.Getenv("SECRET_SCAN_PATTERNS"), ",") {
+			if pattern = strings.TrimSpace(pattern); pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				log.Printf("ignoring invalid secret scan pattern '%s': %s\n", pattern, err)
+				continue
+			}
+			detectors = append(detectors, detector{kind: "pattern", re: re})
+		}
+	})
+	return detectors
+}
+
+// Mode returns the mode of the scanning from SECRET_SCAN
+func Mode() string {
+	switch mode := os.Getenv("SECRET_SCAN"); mode {
+	case ModeWarn, ModeBlock:
+		return mode
+	default:
+		return ModeOff
+	}
+}
+
+// luhn reports whether the digits of the number have a valid Luhn check
+// digit, as card numbers do
+func luhn(number string) bool {
+	sum := 0
+	double := false
+	digits := 0
+	for i := len(number) - 1; i >= 0; i-- {
+		ch := number[i]
+		if ch < '0' || ch > '9' {
+			continue
+		}
+		d := int(ch - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// hint returns the last characters of the secret
+func hint(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// Scan returns the secrets found in the arguments. JSON arguments are
+// scanned string by string, so findings have the path of the property.
+func Scan(args []string) []Finding {
+	findings := make([]Finding, 0)
+	for i, arg := range args {
+		path := fmt.Sprintf("$[%d]", i)
+
+		var value interface{}
+		if json.Unmarshal([]byte(arg), &value) != nil {
+			findings = append(findings, scanString(path, arg)...)
+			continue
+		}
+		findings = append(findings, scanValue(path, value)...)
+	}
+
+	// Properties are scanned in map order
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Path < findings[j].Path
+	})
+	return findings
+}
+
+// scanValue scans the strings of a decoded JSON value, keys included
+func scanValue(path string, value interface{}) []Finding {
+	findings := make([]Finding, 0)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			itemPath := path + "." + key
+			findings = append(findings, scanString(itemPath, key)...)
+			findings = append(findings, scanValue(itemPath, item)...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			findings = append(findings, scanValue(fmt.Sprintf("%s[%d]", path, i), item)...)
+		}
+	case string:
+		findings = append(findings, scanString(path, v)...)
+	}
+	return findings
+}
+
+// scanString runs the detectors on the string
+func scanString(path, s string) []Finding {
+	findings := make([]Finding, 0)
+	for _, d := range getDetectors() {
+		for _, match := range d.re.FindAllString(s, -1) {
+			if d.valid != nil && !d.valid(match) {
+				continue
+			}
+			finding := Finding{
+				Kind: d.kind,
+				Path: path,
+				Hint: hint(match),
+			}
+			if d.public {
+				finding.Hint = match
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// Check scans the arguments of the transaction. In block mode, it returns
+// a 422 error with the findings if secrets are found; in warn mode, it logs
+// them.
+func Check(txName string, args []string) error {
+	mode := Mode()
+	if mode == ModeOff {
+		return nil
+	}
+
+	findings := Scan(args)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	if mode == ModeWarn {
+		for _, f := range findings {
+			log.Printf("possible %s in the arguments of %s at %s (%s)\n", f.Kind, txName, f.Path, f.Hint)
+		}
+		return nil
+	}
+
+	return &common.APIError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    common.ErrCodeSecretDetected,
+		Message: fmt.Sprintf("the arguments of %s seem to hold secrets, which would be written to the ledger for good; send them as transient data instead", txName),
+		Details: map[string]interface{}{
+			"findings": findings,
+		},
+	}
+}
@@ -0,0 +1,133 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Options defines how many assets of each type are generated
+type Options struct {
+	Seed      int64
+	People    int
+	Books     int
+	Libraries int
+}
+
+// Dataset holds the generated assets, ready to be sent to createAsset
+type Dataset struct {
+	Prefix    string
+	People    []map[string]interface{}
+	Books     []map[string]interface{}
+	Libraries []map[string]interface{}
+}
+
+var genres = []string{"biography", "non-fiction", "fiction", "scifi", "fantasy", "romance", "horror", "poetry"}
+
+// Generate deterministically creates a dataset from the given options.
+// The same seed always produces the same assets, so results from
+// different runs (or Fabric versions) can be compared.
+func Generate(opts Options) *Dataset {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	prefix := Prefix(opts.Seed)
+
+	ds := &Dataset{
+		Prefix:    prefix,
+		People:    make([]map[string]interface{}, 0, opts.People),
+		Books:     make([]map[string]interface{}, 0, opts.Books),
+		Libraries: make([]map[string]interface{}, 0, opts.Libraries),
+	}
+
+	// Generate people with valid and unique CPFs
+	usedCPFs := make(map[string]bool)
+	for i := 0; i < opts.People; i++ {
+		cpf := randomCPF(rng)
+		for usedCPFs[cpf] {
+			cpf = randomCPF(rng)
+		}
+		usedCPFs[cpf] = true
+
+		birth := time.Date(1950+rng.Intn(50), time.Month(1+rng.Intn(12)), 1+rng.Intn(28), 0, 0, 0, 0, time.UTC)
+		ds.People = append(ds.People, map[string]interface{}{
+			"@assetType":  "person",
+			"id":          cpf,
+			"name":        fmt.Sprintf("%sperson-%d", prefix, i),
+			"dateOfBirth": birth.Format(time.RFC3339),
+			"height":      float64(150+rng.Intn(50)) / 100,
+		})
+	}
+
+	// Generate books, some of them rented by the generated people
+	for i := 0; i < opts.Books; i++ {
+		published := time.Date(1900+rng.Intn(120), time.Month(1+rng.Intn(12)), 1+rng.Intn(28), 0, 0, 0, 0, time.UTC)
+		book := map[string]interface{}{
+			"@assetType": "book",
+			"title":      fmt.Sprintf("%sbook-%d", prefix, i),
+			"author":     fmt.Sprintf("%sauthor-%d", prefix, rng.Intn(opts.Books/4+1)),
+			"genres":     []string{genres[rng.Intn(len(genres))]},
+			"published":  published.Format(time.RFC3339),
+			"bookType":   rng.Intn(3),
+		}
+		if len(ds.People) > 0 && rng.Intn(2) == 0 {
+			tenant := ds.People[rng.Intn(len(ds.People))]
+			book["currentTenant"] = map[string]interface{}{
+				"@assetType": "person",
+				"id":         tenant["id"],
+			}
+		}
+		ds.Books = append(ds.Books, book)
+	}
+
+	// Distribute books among the libraries
+	for i := 0; i < opts.Libraries; i++ {
+		ds.Libraries = append(ds.Libraries, map[string]interface{}{
+			"@assetType": "library",
+			"name":       fmt.Sprintf("%slibrary-%d", prefix, i),
+			"books":      []interface{}{},
+		})
+	}
+	if len(ds.Libraries) > 0 {
+		for _, book := range ds.Books {
+			library := ds.Libraries[rng.Intn(len(ds.Libraries))]
+			library["books"] = append(library["books"].([]interface{}), map[string]interface{}{
+				"@assetType": "book",
+				"title":      book["title"],
+				"author":     book["author"],
+			})
+		}
+	}
+
+	return ds
+}
+
+// Prefix returns the string prepended to the names of every asset
+// generated from seed, used to find them again on the ledger
+func Prefix(seed int64) string {
+	return fmt.Sprintf("seed-%d-", seed)
+}
+
+// randomCPF generates a CPF with valid verification digits
+func randomCPF(rng *rand.Rand) string {
+	digits := make([]int, 11)
+	for i := 0; i < 9; i++ {
+		digits[i] = rng.Intn(10)
+	}
+
+	for vd := 9; vd <= 10; vd++ {
+		sum := 0
+		for i := 0; i < vd; i++ {
+			sum += (vd + 1 - i) * digits[i]
+		}
+		d := 11 - sum%11
+		if d > 9 {
+			d = 0
+		}
+		digits[vd] = d
+	}
+
+	cpf := ""
+	for _, d := range digits {
+		cpf += fmt.Sprint(d)
+	}
+	return cpf
+}
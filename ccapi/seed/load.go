@@ -0,0 +1,184 @@
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// Target identifies where and as whom the dataset is loaded
+type Target struct {
+	Channel   string
+	Chaincode string
+	User      string
+
+	// Writers are the identities that create each asset type, as returned
+	// by WriterIdentities, User creating the types missing
+	Writers map[string]string
+}
+
+// writerMSPs are the organizations allowed to create each asset type of the
+// demo chaincode, which also allows orgMSP, the one of single org networks
+var writerMSPs = map[string]string{
+	"person":  "org1MSP",
+	"book":    "org2MSP",
+	"library": "org3MSP",
+}
+
+// WriterIdentities returns the identity of the user that creates each asset
+// type: the user itself when its MSP is allowed to, or the user in the org
+// profile of the allowed MSP otherwise
+func WriterIdentities(user, msp string) (map[string]string, error) {
+	profiles, err := common.GetOrgProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	writers := make(map[string]string)
+	for assetType, writer := range writerMSPs {
+		if msp == writer || msp == "orgMSP" {
+			writers[assetType] = user
+			continue
+		}
+
+		for _, name := range common.OrgNames() {
+			if profiles[name].MSPID == writer {
+				writers[assetType] = user + "@" + name
+				break
+			}
+		}
+		if writers[assetType] == "" {
+			return nil, errors.Errorf("%s assets are created by %s, which needs an org profile in ORGS_CONFIG", assetType, writer)
+		}
+	}
+	return writers, nil
+}
+
+// Stats holds the measurements of a load
+type Stats struct {
+	Assets   int
+	Batches  int
+	Duration time.Duration
+}
+
+// Load creates every asset of the dataset on the ledger using batched
+// createAsset invokes. People are created first, then books and finally
+// libraries, so that references are always valid, each by its writer.
+func Load(target Target, ds *Dataset, batchSize int) (*Stats, error) {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	stats := &Stats{}
+	begin := time.Now()
+
+	for _, list := range [][]map[string]interface{}{ds.People, ds.Books, ds.Libraries} {
+		if len(list) == 0 {
+			continue
+		}
+		user := target.User
+		if writer, ok := target.Writers[list[0]["@assetType"].(string)]; ok {
+			user = writer
+		}
+
+		for start := 0; start < len(list); start += batchSize {
+			end := start + batchSize
+			if end > len(list) {
+				end = len(list)
+			}
+
+			err := createBatch(target, user, list[start:end])
+			if err != nil {
+				return stats, err
+			}
+
+			stats.Assets += end - start
+			stats.Batches++
+		}
+	}
+
+	stats.Duration = time.Since(begin)
+	return stats, nil
+}
+
+func createBatch(target Target, user string, batch []map[string]interface{}) error {
+	args, err := json.Marshal(map[string]interface{}{
+		"asset": batch,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal batch")
+	}
+
+	_, err = chaincode.InvokeGateway(target.Channel, target.Chaincode, "createAsset", user, []string{string(args)}, nil, nil)
+	if err != nil {
+		err, status := common.ParseError(err)
+		return errors.Wrap(err, fmt.Sprintf("failed to create batch (status %d)", status))
+	}
+
+	return nil
+}
+
+// Verify checks that the ledger contains exactly the amount of
+// assets of each type generated for the dataset
+func Verify(target Target, ds *Dataset) error {
+	expected := []struct {
+		assetType string
+		prop      string
+		count     int
+	}{
+		{"person", "name", len(ds.People)},
+		{"book", "title", len(ds.Books)},
+		{"library", "name", len(ds.Libraries)},
+	}
+
+	for _, e := range expected {
+		count, err := countAssets(target, e.assetType, e.prop, ds.Prefix)
+		if err != nil {
+			return err
+		}
+
+		if count != e.count {
+			return fmt.Errorf("expected %d assets of type %s, found %d", e.count, e.assetType, count)
+		}
+		log.Printf("verified %d assets of type %s\n", count, e.assetType)
+	}
+
+	return nil
+}
+
+func countAssets(target Target, assetType, prop, prefix string) (int, error) {
+	args, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"@assetType": assetType,
+				prop: map[string]interface{}{
+					"$regex": "^" + prefix,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to marshal query")
+	}
+
+	result, err := chaincode.QueryGateway(target.Channel, target.Chaincode, "search", target.User, []string{string(args)})
+	if err != nil {
+		err, _ := common.ParseError(err)
+		return 0, errors.Wrap(err, "failed to search assets")
+	}
+
+	var response struct {
+		Result []interface{} `json:"result"`
+	}
+	err = json.Unmarshal(result, &response)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to unmarshal search response")
+	}
+
+	return len(response.Result), nil
+}
@@ -10,12 +10,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/hyperledger-labs/ccapi/common"
 	"github.com/hyperledger-labs/ccapi/routes"
+	"github.com/hyperledger-labs/ccapi/versioning"
 )
 
 func defaultServer(r *gin.Engine) *http.Server {
 	return &http.Server{
 		Addr:    ":80",
-		Handler: r,
+		Handler: versioning.Handler(r),
 	}
 }
 
@@ -0,0 +1,158 @@
+package settings
+
+// catalogue lists the settings the CCAPI reads from its environment, by
+// area. Defaults in braces are computed when the setting is read.
+var catalogue = []Setting{
+	// Fabric network
+	{Name: "SDK_PATH", Area: "fabric", Kind: KindFile, Default: "./config/configsdk.yaml", Description: "Connection profile of the Fabric SDK"},
+	{Name: "ORG", Area: "fabric", Description: "Organization of the CCAPI identities"},
+	{Name: "DOMAIN", Area: "fabric", Description: "Domain of the organization, in the names of the credentials"},
+	{Name: "USER", Area: "fabric", Description: "Identity of the CCAPI for its own transactions"},
+	{Name: "CHANNEL", Area: "fabric", Description: "Channel of the default chaincode"},
+	{Name: "CCNAME", Area: "fabric", Description: "Name of the default chaincode"},
+	{Name: "FABRIC_GATEWAY_ENDPOINT", Area: "fabric", Description: "Address of the gateway peer"},
+	{Name: "FABRIC_GATEWAY_NAME", Area: "fabric", Description: "TLS server name of the gateway peer"},
+	{Name: "FABRIC_GATEWAY_QUERY_ENDPOINT", Area: "fabric", Description: "Address of the peer evaluating queries, default the gateway peer"},
+	{Name: "FABRIC_GATEWAY_QUERY_NAME", Area: "fabric", Description: "TLS server name of the query peer"},
+	{Name: "ORGS_CONFIG", Area: "fabric", Kind: KindFile, Description: "Profiles of the organizations the CCAPI acts as"},
+	{Name: "SIGNER_PLUGIN", Area: "fabric", Description: "Plugin signing transactions instead of the private keys"},
+	{Name: "COLLECTIONS_CONFIG", Area: "fabric", Kind: KindFile, Description: "Private data collections of the chaincode"},
+
+	// Chaincode
+	{Name: "CHAINCODE_MAJOR_VERSION", Area: "chaincode", Default: "{major version of the build}", Description: "Major version of the chaincode the CCAPI expects, none to skip the check"},
+	{Name: "CHAINCODE_VERSION_INTERVAL", Area: "chaincode", Kind: KindDuration, Default: "1m", Description: "Time between chaincode version checks"},
+	{Name: "CHAINCODE_VERSION_MISMATCH", Area: "chaincode", Values: []string{"refuse", "shim"}, Default: "refuse", Description: "Refuse requests to an incompatible chaincode or serve them through shims"},
+	{Name: "CHAINCODE_SHIMS", Area: "chaincode", Kind: KindFile, Description: "Shims of the deployed chaincode versions"},
+	{Name: "METADATA_CACHE_TTL", Area: "chaincode", Kind: KindDuration, Default: "5m", Description: "How long chaincode metadata is cached, 0 to disable the cache"},
+	{Name: "COALESCE_EVALUATE", Area: "chaincode", Kind: KindBool, Default: "true", Description: "Share the result of identical concurrent queries"},
+	{Name: "PREPARED_TX_TTL", Area: "chaincode", Kind: KindDuration, Default: "24h", Description: "How long prepared transactions can be submitted"},
+	{Name: "RAW_QUERY_FUNCTIONS", Area: "chaincode", Kind: KindList, Description: "Patterns of the raw functions evaluated instead of submitted"},
+	{Name: "TX_QUEUE_INTERACTIVE_CONCURRENCY", Area: "chaincode", Kind: KindInt, Default: "0", Description: "Concurrent interactive submissions, 0 for no limit"},
+	{Name: "TX_QUEUE_INTERACTIVE_MAX_QUEUED", Area: "chaincode", Kind: KindInt, Default: "0", Description: "Interactive submissions waiting for their turn, 0 for no limit"},
+	{Name: "TX_QUEUE_BATCH_CONCURRENCY", Area: "chaincode", Kind: KindInt, Default: "0", Description: "Concurrent batch submissions, 0 for no limit"},
+	{Name: "TX_QUEUE_BATCH_MAX_QUEUED", Area: "chaincode", Kind: KindInt, Default: "0", Description: "Batch submissions waiting for their turn, 0 for no limit"},
+	{Name: "WARMUP", Area: "chaincode", Kind: KindBool, Default: "true", Description: "Connect to the gateway on startup"},
+	{Name: "WARMUP_IDENTITIES", Area: "chaincode", Kind: KindList, Default: "{USER},Admin and their org profiles", Description: "Identities connected on startup"},
+	{Name: "WARMUP_EVALUATE", Area: "chaincode", Kind: KindBool, Default: "false", Description: "Evaluate getHeader on startup to start the chaincode containers"},
+	{Name: "WARMUP_CHANNELS", Area: "chaincode", Kind: KindList, Description: "Channels evaluated on startup besides CHANNEL"},
+	{Name: "WARMUP_TIMEOUT", Area: "chaincode", Kind: KindDuration, Default: "10s", Description: "Time each identity is given to warm up"},
+
+	// Requests and responses
+	{Name: "RESPONSE_ENVELOPE", Area: "api", Kind: KindBool, Default: "false", Description: "Wrap responses in {data, meta}"},
+	{Name: "PROBLEM_TYPE_BASE", Area: "api", Default: "urn:ccapi:error:", Description: "Base URI of the types of the problem details"},
+	{Name: "JSON_NUMBERS", Area: "api", Values: []string{"exact", "float"}, Default: "exact", Description: "Keep JSON numbers exact or convert them to floats"},
+	{Name: "ASSET_SIZE_LIMIT", Area: "api", Kind: KindInt, Default: "1048576", Description: "Largest asset in bytes"},
+	{Name: "ASSET_SIZE_MODE", Area: "api", Values: []string{"off", "warn", "block"}, Default: "block", Description: "What happens to assets past the size limit"},
+	{Name: "TRANSIENT_MAX_SIZE", Area: "api", Kind: KindInt, Default: "1048576", Description: "Largest transient data value in bytes"},
+	{Name: "STREAM_MAX_RESULTS", Area: "api", Kind: KindInt, Default: "10000", Description: "Most results of a streamed listing"},
+	{Name: "ENCRYPTED_FIELDS", Area: "api", Kind: KindList, Description: "Asset properties encrypted with the keyring, as type.property"},
+	{Name: "KEYRING_PATH", Area: "api", Kind: KindPath, Default: "keyring.json", Description: "File of the encryption keys of personal data"},
+	{Name: "CALLBACK_ALLOWED_HOSTS", Area: "api", Kind: KindList, Description: "Hosts commit callbacks may be posted to, default none"},
+	{Name: "REPLAY_ALLOWED_HOSTS", Area: "api", Kind: KindList, Description: "Hosts the webhook sink of event replays may post to, default none"},
+	{Name: "QR_BASE_URL", Area: "api", Kind: KindURL, Default: "{scheme and host of the request}", Description: "Base URL of the asset resolver in QR codes"},
+	{Name: "RECEIPT_BASE_URL", Area: "api", Kind: KindURL, Default: "{scheme and host of the request}", Description: "Base URL of the verification links of receipts"},
+	{Name: "ROUTES_CONFIG", Area: "api", Kind: KindFile, Description: "Custom transaction routes"},
+	{Name: "PIPELINE_CONFIG", Area: "api", Kind: KindFile, Description: "Middlewares of the route groups"},
+	{Name: "VERSIONS_CONFIG", Area: "api", Kind: KindFile, Description: "Older API versions and their mappings"},
+	{Name: "TX_SCHEMAS", Area: "api", Kind: KindDir, Description: "JSON schemas of the transaction bodies"},
+	{Name: "TRANSFORM_SCRIPT", Area: "api", Kind: KindFile, Description: "Script transforming requests and responses"},
+	{Name: "SCRIPTS_DIR", Area: "api", Kind: KindDir, Description: "Scripts of the composite endpoints"},
+	{Name: "SCRIPTS_TIMEOUT", Area: "api", Kind: KindDuration, Default: "1m", Description: "How long a request can run a script"},
+	{Name: "PLUGINS_CONFIG", Area: "api", Kind: KindFile, Description: "Plugins started with the CCAPI"},
+	{Name: "FEATURE_FLAGS", Area: "api", Kind: KindList, Description: "Feature flags enabled or disabled, such as anchors=false"},
+	{Name: "MAINTENANCE_MODE", Area: "api", Kind: KindBool, Default: "false", Description: "Start in read-only mode"},
+	{Name: "MAINTENANCE_REASON", Area: "api", Description: "Reason of the read-only mode"},
+
+	// Security
+	{Name: "DASHBOARD_ADMIN_USER", Area: "security", Default: "admin", Description: "User of the admin dashboard"},
+	{Name: "DASHBOARD_ADMIN_PASSWORD", Area: "security", Secret: true, Description: "Password of the admin dashboard, which is disabled without it or OIDC"},
+	{Name: "OIDC_ISSUER", Area: "security", Kind: KindURL, Description: "OpenID Connect provider of the browser login"},
+	{Name: "OIDC_CLIENT_ID", Area: "security", Description: "Client of the CCAPI at the provider"},
+	{Name: "OIDC_CLIENT_SECRET", Area: "security", Secret: true, Description: "Secret of the client at the provider"},
+	{Name: "OIDC_REDIRECT_URL", Area: "security", Kind: KindURL, Description: "Callback URL of the login"},
+	{Name: "OIDC_SCOPES", Area: "security", Default: "openid profile email", Description: "Scopes requested from the provider"},
+	{Name: "OIDC_USER_CLAIM", Area: "security", Default: "preferred_username", Description: "Claim naming the user"},
+	{Name: "OIDC_SESSION_TTL", Area: "security", Kind: KindDuration, Default: "8h", Description: "Lifetime of the browser sessions"},
+	{Name: "OIDC_IDENTITIES", Area: "security", Kind: KindFile, Description: "Fabric identities of the users"},
+	{Name: "OIDC_DEFAULT_IDENTITY", Area: "security", Description: "Fabric identity of the users without one"},
+	{Name: "RBAC_CONFIG", Area: "security", Kind: KindFile, Description: "Roles and the groups granting them"},
+	{Name: "SCIM_URL", Area: "security", Kind: KindURL, Description: "SCIM server of the groups"},
+	{Name: "SCIM_TOKEN", Area: "security", Secret: true, Description: "Bearer token of the SCIM server"},
+	{Name: "SCIM_SYNC_INTERVAL", Area: "security", Kind: KindDuration, Default: "15m", Description: "Time between group syncs"},
+	{Name: "SCIM_MEMBER_ATTRIBUTE", Area: "security", Values: []string{"display", "value"}, Default: "display", Description: "Attribute of the group members matched to users"},
+	{Name: "POLICY_CONFIG", Area: "security", Kind: KindFile, Description: "Authorization policies of the requests"},
+	{Name: "QUOTA_CONFIG", Area: "security", Kind: KindFile, Description: "Quotas of the users"},
+	{Name: "QUOTA_USAGE_PATH", Area: "security", Kind: KindPath, Default: "usage.json", Description: "File of the quota usage"},
+	{Name: "SECRET_SCAN", Area: "security", Values: []string{"off", "warn", "block"}, Default: "off", Description: "What happens to requests holding secrets"},
+	{Name: "SECRET_SCAN_PATTERNS", Area: "security", Kind: KindList, Description: "Additional expressions of secrets"},
+	{Name: "REDACT_FIELDS", Area: "security", Kind: KindList, Default: "password,secret,token", Description: "Properties masked in logs"},
+	{Name: "REDACT_DATATYPES", Area: "security", Kind: KindList, Default: "cpf", Description: "Data types whose properties are masked in logs"},
+	{Name: "REDACT_PATTERNS", Area: "security", Kind: KindList, Default: `\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`, Description: "Expressions of values masked in logs"},
+
+	// Logs and audit
+	{Name: "ACCESS_LOG_FORMAT", Area: "logs", Values: []string{"gin", "json", "combined", "template", "off"}, Default: "gin", Description: "Format of the access log"},
+	{Name: "ACCESS_LOG_TEMPLATE", Area: "logs", Description: "Template of the access log lines"},
+	{Name: "ACCESS_LOG_PATH", Area: "logs", Kind: KindPath, Default: "-", Description: "File of the access log, - for the standard output"},
+	{Name: "ACCESS_LOG_EXCLUDE", Area: "logs", Kind: KindList, Default: "/ping,/readyz,/metrics", Description: "Paths left out of the access log"},
+	{Name: "ACCESS_LOG_SAMPLE", Area: "logs", Kind: KindFloat, Default: "1", Description: "Share of the requests logged"},
+	{Name: "AUDIT_LOG_PATH", Area: "logs", Kind: KindPath, Description: "File of the audit log, which is disabled without it"},
+	{Name: "AUDIT_SIGNING_KEY", Area: "logs", Kind: KindFile, Description: "Private key sealing the audit log"},
+	{Name: "AUDIT_SEAL_INTERVAL", Area: "logs", Kind: KindDuration, Default: "1m", Description: "Time between seals of the audit log"},
+	{Name: "SIEM_URL", Area: "logs", Kind: KindURL, Description: "Syslog endpoint of the SIEM"},
+	{Name: "SIEM_FORMAT", Area: "logs", Values: []string{"cef", "json"}, Default: "cef", Description: "Format of the SIEM events"},
+	{Name: "SLOW_QUERY_THRESHOLD", Area: "logs", Kind: KindDuration, Default: "1s", Description: "Duration past which queries are logged"},
+	{Name: "SLOW_QUERY_PAGE_SIZE", Area: "logs", Kind: KindInt, Default: "100", Description: "Results past which unlimited queries should be paged"},
+
+	// Events and webhooks
+	{Name: "WEBHOOK_URLS", Area: "events", Kind: KindURLs, Description: "Webhooks notified of transactions"},
+	{Name: "WEBHOOK_RETRIES", Area: "events", Kind: KindInt, Default: "3", Description: "Retries of a failed webhook delivery"},
+	{Name: "WEBHOOK_CHAINCODE_EVENTS", Area: "events", Kind: KindBool, Default: "false", Description: "Forward the chaincode events to the webhooks"},
+	{Name: "WEBHOOK_DEAD_LETTER_PATH", Area: "events", Kind: KindPath, Default: "deadletters.json", Description: "File of the undelivered webhook events"},
+	{Name: "EVENT_CHECKPOINT_PATH", Area: "events", Kind: KindPath, Default: "checkpoints.json", Description: "File of the event checkpoints"},
+	{Name: "EVENT_CHECKPOINT_PLUGIN", Area: "events", Description: "Storage plugin of the event checkpoints"},
+	{Name: "EVENT_SCHEMAS", Area: "events", Kind: KindDir, Description: "JSON schemas of the chaincode events"},
+	{Name: "EVENT_SCHEMA_STRICT", Area: "events", Kind: KindBool, Default: "false", Description: "Drop the events failing their schema"},
+
+	// Integrations
+	{Name: "ANCHOR_URL", Area: "integrations", Kind: KindURL, Description: "Endpoint the ledger is anchored to"},
+	{Name: "ANCHOR_INTERVAL", Area: "integrations", Kind: KindDuration, Default: "1h", Description: "Time between anchors"},
+	{Name: "ANCHOR_FORMAT", Area: "integrations", Values: []string{"json", "opentimestamps"}, Default: "json", Description: "Format of the anchors"},
+	{Name: "ANCHOR_RECEIPTS_PATH", Area: "integrations", Kind: KindPath, Default: "anchors.jsonl", Description: "File of the anchor receipts"},
+	{Name: "COUCHDB_URL", Area: "integrations", Kind: KindURL, Description: "CouchDB of a peer, to manage the indexes"},
+	{Name: "COUCHDB_DATABASE", Area: "integrations", Default: "{CHANNEL}_{CCNAME}", Description: "Database of the chaincode state"},
+	{Name: "COUCHDB_INDEXES_PATH", Area: "integrations", Kind: KindDir, Default: "../chaincode/META-INF/statedb/couchdb/indexes", Description: "Index definitions of the chaincode"},
+	{Name: "JSONLD_BASE_URL", Area: "integrations", Kind: KindURL, Default: "{scheme and host of the request}", Description: "Base URL of the JSON-LD context"},
+	{Name: "JSONLD_VOCAB", Area: "integrations", Default: "urn:ccapi:schema:{CCNAME}:", Description: "Vocabulary of the asset types"},
+	{Name: "JSONLD_TERMS", Area: "integrations", Kind: KindFile, Description: "IRIs of the terms of other vocabularies"},
+	{Name: "VC_ISSUER", Area: "integrations", Default: "urn:ccapi:{mspId}", Description: "Issuer of the verifiable credentials"},
+	{Name: "VC_IDENTITY", Area: "integrations", Default: "Admin", Description: "Identity signing the verifiable credentials"},
+	{Name: "VC_SIGNING_KEY", Area: "integrations", Kind: KindFile, Description: "Private key signing the verifiable credentials"},
+	{Name: "VC_SIGNING_CERT", Area: "integrations", Kind: KindFile, Description: "Certificate of the signing key"},
+	{Name: "TOKEN_SDK_ISSUER_URL", Area: "integrations", Kind: KindURL, Description: "Issuer node of the Token SDK application"},
+	{Name: "TOKEN_SDK_AUDITOR_URL", Area: "integrations", Kind: KindURL, Description: "Auditor node of the Token SDK application"},
+	{Name: "TOKEN_SDK_OWNERS", Area: "integrations", Kind: KindList, Description: "Owner nodes of the Token SDK application, as name=url"},
+	{Name: "BOOTSTRAP_CONFIG", Area: "integrations", Kind: KindFile, Description: "Declarative configuration reconciled on startup"},
+	{Name: "BOOTSTRAP_INTERVAL", Area: "integrations", Kind: KindDuration, Default: "0", Description: "Time between reconciliations, 0 for startup only"},
+
+	// Replicas
+	{Name: "STATE_BACKEND", Area: "replicas", Values: []string{"memory", "redis"}, Default: "memory", Description: "Store of the state shared by the replicas"},
+	{Name: "REDIS_URL", Area: "replicas", Kind: KindURL, Default: "redis://localhost:6379/0", Description: "Redis server of the shared state"},
+	{Name: "REDIS_KEY_PREFIX", Area: "replicas", Default: "ccapi:", Description: "Prefix of the Redis keys"},
+	{Name: "LEADER_ELECTION", Area: "replicas", Values: []string{"redis", "kubernetes"}, Description: "How the replicas elect the leader running background jobs"},
+	{Name: "LEADER_ID", Area: "replicas", Default: "{hostname}-{random}", Description: "Identity of the replica in the election"},
+	{Name: "LEADER_LEASE_DURATION", Area: "replicas", Kind: KindDuration, Default: "15s", Description: "Duration of the leader lease"},
+	{Name: "LEADER_LEASE_NAME", Area: "replicas", Default: "ccapi", Description: "Name of the Kubernetes lease"},
+	{Name: "LEADER_LEASE_NAMESPACE", Area: "replicas", Default: "{namespace of the pod}", Description: "Namespace of the Kubernetes lease"},
+
+	// Development
+	{Name: "MOCK_LEDGER", Area: "development", Kind: KindBool, Default: "false", Description: "Run against the in-memory mock ledger"},
+	{Name: "MOCK_SCHEMA_PATH", Area: "development", Kind: KindFile, Description: "Asset types of the mock ledger, default the demo ones"},
+	{Name: "MOCK_MSP", Area: "development", Default: "orgMSP", Description: "MSP of the mock ledger caller"},
+	{Name: "CASSETTE_MODE", Area: "development", Values: []string{"record", "playback"}, Description: "Record requests or play them back"},
+	{Name: "CASSETTE_PATH", Area: "development", Kind: KindPath, Default: "cassette.json", Description: "File of the recorded requests"},
+	{Name: "CHAOS_ENABLED", Area: "development", Kind: KindBool, Default: "false", Description: "Inject faults into the /api calls"},
+	{Name: "CHAOS_LATENCY", Area: "development", Kind: KindDuration, Default: "0", Description: "Latency added to every request"},
+	{Name: "CHAOS_JITTER", Area: "development", Kind: KindDuration, Default: "0", Description: "Random latency added on top of CHAOS_LATENCY"},
+	{Name: "CHAOS_DROP_RATE", Area: "development", Kind: KindFloat, Default: "0", Description: "Probability of dropping the client connection"},
+	{Name: "CHAOS_MVCC_RATE", Area: "development", Kind: KindFloat, Default: "0", Description: "Probability of failing a submit with an MVCC conflict"},
+}
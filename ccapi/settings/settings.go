@@ -0,0 +1,342 @@
+// Package settings describes the effective configuration of the CCAPI, so
+// misconfigurations are found by asking the running process rather than by
+// reading its deployment manifests. Each setting is read from its
+// environment variable or, when only NAME_FILE is set, from the file it
+// names, such as a Docker or Kubernetes secret:
+//
+//	DASHBOARD_ADMIN_PASSWORD_FILE=/run/secrets/dashboard_password
+//
+// The files are read once on startup, before the other packages read the
+// settings. Settings set neither way take their default.
+package settings
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/redact"
+	"github.com/pkg/errors"
+)
+
+// Kind is the type of the value of a setting, which it is checked against
+type Kind string
+
+// Kinds of settings
+const (
+	KindString   Kind = ""
+	KindBool     Kind = "bool"
+	KindInt      Kind = "int"
+	KindFloat    Kind = "float"
+	KindDuration Kind = "duration"
+	KindURL      Kind = "url"
+	// KindURLs is a comma separated list of URLs
+	KindURLs Kind = "urls"
+	// KindList is a comma separated list
+	KindList Kind = "list"
+	// KindFile is a file the CCAPI reads, which must exist
+	KindFile Kind = "file"
+	// KindDir is a directory the CCAPI reads, which must exist
+	KindDir Kind = "dir"
+	// KindPath is a file the CCAPI writes, created if missing
+	KindPath Kind = "path"
+)
+
+// Sources of the values
+const (
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+	// SourceUnset is a setting without a value or a default, which usually
+	// disables what it configures
+	SourceUnset = "unset"
+)
+
+// fileSuffix names the variable of the file holding a setting
+const fileSuffix = "_FILE"
+
+// Setting is a setting the CCAPI reads from its environment
+type Setting struct {
+	Name        string
+	Area        string
+	Description string
+	Kind        Kind
+	// Values are the values allowed, if they are a fixed set
+	Values  []string
+	Default string
+	// Secret settings are never shown
+	Secret bool
+}
+
+// Value is the effective value of a setting
+type Value struct {
+	Name        string `json:"name"`
+	Area        string `json:"area"`
+	Description string `json:"description"`
+	Kind        Kind   `json:"kind,omitempty"`
+	// Value is the value in effect, masked if secret
+	Value   string `json:"value"`
+	Source  string `json:"source"`
+	Default string `json:"default,omitempty"`
+	// File is the file the value was read from
+	File   string `json:"file,omitempty"`
+	Secret bool   `json:"secret,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Unknown is a variable named like the settings of the CCAPI but none of
+// them, likely a typo. Its value is not reported.
+type Unknown struct {
+	Name string `json:"name"`
+	// Suggestion is the setting it is closest to, if any is close
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+var (
+	filesMutex sync.RWMutex
+	// files are the files the settings were read from, by name
+	files = make(map[string]string)
+)
+
+// Load reads the settings set with NAME_FILE into the environment. A
+// setting set both ways keeps the value of its variable. Files that cannot
+// be read stop the CCAPI, as running without a secret is rarely intended.
+func Load() {
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+
+	for _, s := range catalogue {
+		path, ok := os.LookupEnv(s.Name + fileSuffix)
+		if !ok || path == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(s.Name); ok {
+			log.Printf("both %s and %s%s are set, using %s\n", s.Name, s.Name, fileSuffix, s.Name)
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalln(errors.Wrapf(err, "failed to read %s%s", s.Name, fileSuffix))
+		}
+		os.Setenv(s.Name, strings.TrimRight(string(data), "\r\n"))
+		files[s.Name] = path
+	}
+}
+
+// List returns the effective value of every setting, by area
+func List() []Value {
+	filesMutex.RLock()
+	defer filesMutex.RUnlock()
+
+	values := make([]Value, 0, len(catalogue))
+	for _, s := range catalogue {
+		v := Value{
+			Name:        s.Name,
+			Area:        s.Area,
+			Description: s.Description,
+			Kind:        s.Kind,
+			Default:     s.Default,
+			Secret:      s.Secret,
+		}
+		value, ok := os.LookupEnv(s.Name)
+		switch {
+		case ok && files[s.Name] != "":
+			v.Source, v.File = SourceFile, files[s.Name]
+		case ok:
+			v.Source = SourceEnv
+		case s.Default != "":
+			v.Source, value = SourceDefault, s.Default
+		default:
+			v.Source = SourceUnset
+		}
+
+		if v.Source == SourceEnv || v.Source == SourceFile {
+			if err := s.check(value); err != nil {
+				v.Error = err.Error()
+			}
+		}
+		v.Value = s.mask(value)
+		values = append(values, v)
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Area < values[j].Area
+	})
+	return values
+}
+
+// check returns why the value is not one the CCAPI accepts, which usually
+// makes it fall back to the default
+func (s *Setting) check(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(s.Values) > 0 {
+		for _, allowed := range s.Values {
+			if value == allowed {
+				return nil
+			}
+		}
+		return errors.Errorf("must be one of %s", strings.Join(s.Values, ", "))
+	}
+
+	switch s.Kind {
+	case KindBool:
+		if value != "true" && value != "false" {
+			return errors.New("must be true or false")
+		}
+	case KindInt:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return errors.New("must be an integer of 0 or more")
+		}
+	case KindFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.New("must be a number")
+		}
+	case KindDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return errors.New("must be a duration such as 30s or 5m")
+		}
+	case KindURL:
+		return checkURL(value)
+	case KindURLs:
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			if err := checkURL(item); err != nil {
+				return err
+			}
+		}
+	case KindFile, KindDir:
+		info, err := os.Stat(value)
+		if err != nil {
+			return errors.Errorf("%s does not exist or cannot be read", value)
+		}
+		if s.Kind == KindFile && info.IsDir() {
+			return errors.Errorf("%s is a directory", value)
+		}
+		if s.Kind == KindDir && !info.IsDir() {
+			return errors.Errorf("%s is not a directory", value)
+		}
+	}
+	return nil
+}
+
+func checkURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.Errorf("'%s' must be an absolute URL", redact.String(maskURL(value)))
+	}
+	return nil
+}
+
+// mask hides secret values and the passwords of URLs, and masks what the
+// redaction patterns match
+func (s *Setting) mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	if s.Secret {
+		return redact.Mask
+	}
+	if s.Kind == KindURL || s.Kind == KindURLs {
+		items := strings.Split(value, ",")
+		for i, item := range items {
+			items[i] = maskURL(item)
+		}
+		value = strings.Join(items, ",")
+	}
+	return redact.String(value)
+}
+
+// maskURL hides the password of the URL, keeping the user
+func maskURL(value string) string {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || u.User == nil {
+		return value
+	}
+	if _, ok := u.User.Password(); !ok {
+		return value
+	}
+	at := strings.Index(value, "@"+u.Host)
+	if at < 0 {
+		return value
+	}
+	return fmt.Sprintf("%s://%s:%s%s", u.Scheme, u.User.Username(), redact.Mask, value[at:])
+}
+
+// nameRegexp matches the names of environment variables
+var nameRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// GetUnknown returns the environment variables starting like the settings
+// of an area, such as OIDC_, that are none of them
+func GetUnknown() []Unknown {
+	known := make(map[string]bool)
+	prefixes := make(map[string]bool)
+	for _, s := range catalogue {
+		known[s.Name] = true
+		known[s.Name+fileSuffix] = true
+		if i := strings.Index(s.Name, "_"); i > 0 {
+			prefixes[s.Name[:i+1]] = true
+		}
+	}
+
+	unknown := make([]Unknown, 0)
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if known[name] || !nameRegexp.MatchString(name) {
+			continue
+		}
+		i := strings.Index(name, "_")
+		if i <= 0 || !prefixes[name[:i+1]] {
+			continue
+		}
+		unknown = append(unknown, Unknown{Name: name, Suggestion: closest(name)})
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Name < unknown[j].Name
+	})
+	return unknown
+}
+
+// closest returns the setting with the name closest to the name, if it is
+// a few edits away
+func closest(name string) string {
+	best, bestDistance := "", 4
+	for _, s := range catalogue {
+		if d := distance(name, s.Name); d < bestDistance {
+			best, bestDistance = s.Name, d
+		}
+	}
+	return best
+}
+
+// distance returns the Levenshtein distance between the names
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,88 @@
+package siem
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/redact"
+)
+
+// Middleware forwards the requests refused for authentication, with 401,
+// and those denied, with 403, such as the transactions a role does not
+// allow
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if !Enabled() {
+			return
+		}
+		switch c.Writer.Status() {
+		case http.StatusUnauthorized:
+			Send(requestEvent(c, TypeAuthFailure, "Authentication failed", 5))
+		case http.StatusForbidden:
+			Send(requestEvent(c, TypeAccessDenied, "Access denied", 5))
+		}
+	}
+}
+
+// AdminActions forwards the changes made by admins, the requests of the
+// group other than reads
+func AdminActions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if !Enabled() || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+		status := c.Writer.Status()
+		if status == http.StatusUnauthorized || status == http.StatusForbidden {
+			// Forwarded by Middleware
+			return
+		}
+		Send(requestEvent(c, TypeAdminAction, "Admin action "+c.Request.Method+" "+c.FullPath(), 4))
+	}
+}
+
+// requestEvent describes the handled request
+func requestEvent(c *gin.Context, eventType, name string, severity int) Event {
+	e := Event{
+		Type:     eventType,
+		Name:     name,
+		Severity: severity,
+		User:     requestUser(c),
+		SourceIP: c.ClientIP(),
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		Status:   c.Writer.Status(),
+		Outcome:  "success",
+	}
+	if e.Status >= 400 {
+		e.Outcome = "failure"
+	}
+	if len(c.Errors) > 0 {
+		e.Message = redact.String(c.Errors.Last().Error())
+	}
+	return e
+}
+
+// requestUser returns who made the request: the admin of the dashboard,
+// the user of the login session, the user given in basic authentication or
+// the identity of the request
+func requestUser(c *gin.Context) string {
+	if user := c.GetString(gin.AuthUserKey); user != "" {
+		return user
+	}
+	if user := c.GetString(common.SessionUserKey); user != "" {
+		return user
+	}
+	if user, _, ok := c.Request.BasicAuth(); ok {
+		return user
+	}
+	if strings.HasPrefix(c.Request.URL.Path, "/api") {
+		return common.GetUser(c)
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+// Package siem forwards security-relevant events to a SIEM over syslog:
+// the transactions of the audit log, authentication failures, denied
+// requests and the actions of admins in the dashboard. Events are sent to
+// SIEM_URL, such as udp://siem:514 or tcp://siem:601, as RFC 5424 syslog
+// messages holding either a CEF record, by default, or the event as JSON
+// when SIEM_FORMAT is json.
+package siem
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Types of the events
+const (
+	TypeTransaction  = "audit.transaction"
+	TypeAuthFailure  = "auth.failure"
+	TypeAccessDenied = "access.denied"
+	TypeAdminAction  = "admin.action"
+)
+
+// queueSize is how many events wait to be sent before new ones are dropped
+const queueSize = 1000
+
+// facility is the syslog facility of the events, security/authorization
+const facility = 10
+
+// Event is a security-relevant event
+type Event struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Name string    `json:"name"`
+	// Severity goes from 0 to 10, as in CEF
+	Severity int               `json:"severity"`
+	User     string            `json:"user,omitempty"`
+	SourceIP string            `json:"sourceIp,omitempty"`
+	Method   string            `json:"method,omitempty"`
+	Path     string            `json:"path,omitempty"`
+	Status   int               `json:"status,omitempty"`
+	Outcome  string            `json:"outcome,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+var (
+	queue     chan Event
+	startOnce sync.Once
+	hostname  string
+)
+
+// Enabled reports whether events are forwarded, when SIEM_URL is set
+func Enabled() bool {
+	return os.Getenv("SIEM_URL") != ""
+}
+
+// Send queues the event to be forwarded, if enabled. Events are dropped
+// when the SIEM is slower than the events are produced.
+func Send(e Event) {
+	if !Enabled() {
+		return
+	}
+	startOnce.Do(start)
+
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	select {
+	case queue <- e:
+	default:
+		log.Printf("siem: queue full, dropping %s event\n", e.Type)
+	}
+}
+
+// start creates the queue and the sender of the events
+func start() {
+	hostname, _ = os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	queue = make(chan Event, queueSize)
+	go deliver()
+}
+
+// deliver sends the queued events, dialing the SIEM again after a failed
+// write
+func deliver() {
+	var conn net.Conn
+	for e := range queue {
+		msg := []byte(format(e) + "\n")
+		for attempt := 0; attempt < 2; attempt++ {
+			if conn == nil {
+				var err error
+				conn, err = dial()
+				if err != nil {
+					log.Println("siem: error connecting: ", err)
+					break
+				}
+			}
+			_, err := conn.Write(msg)
+			if err == nil {
+				break
+			}
+			log.Println("siem: error sending event: ", err)
+			conn.Close()
+			conn = nil
+		}
+	}
+}
+
+// dial connects to SIEM_URL, over udp or tcp
+func dial() (net.Conn, error) {
+	u, err := url.Parse(os.Getenv("SIEM_URL"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid SIEM_URL")
+	}
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return nil, errors.Errorf("unsupported SIEM_URL scheme '%s', use udp or tcp", u.Scheme)
+	}
+	return net.DialTimeout(u.Scheme, u.Host, 5*time.Second)
+}
+
+// format returns the syslog message of the event
+func format(e Event) string {
+	var msg string
+	if os.Getenv("SIEM_FORMAT") == "json" {
+		b, _ := json.Marshal(e)
+		msg = string(b)
+	} else {
+		msg = CEF(e)
+	}
+
+	pri := facility*8 + syslogSeverity(e.Severity)
+	return fmt.Sprintf("<%d>1 %s %s ccapi %d %s - %s", pri, e.Time.Format(time.RFC3339Nano), hostname, os.Getpid(), e.Type, msg)
+}
+
+// syslogSeverity maps the CEF severity of the event to a syslog severity
+func syslogSeverity(severity int) int {
+	switch {
+	case severity >= 9:
+		return 2 // critical
+	case severity >= 7:
+		return 3 // error
+	case severity >= 5:
+		return 4 // warning
+	case severity >= 3:
+		return 5 // notice
+	default:
+		return 6 // informational
+	}
+}
+
+// CEF returns the event as a Common Event Format record
+func CEF(e Event) string {
+	ext := []string{"rt=" + strconv.FormatInt(e.Time.UnixMilli(), 10)}
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefValue(value))
+		}
+	}
+	add("suser", e.User)
+	add("src", e.SourceIP)
+	add("requestMethod", e.Method)
+	add("request", e.Path)
+	add("outcome", e.Outcome)
+	add("msg", e.Message)
+	if e.Status != 0 {
+		add("cn1Label", "status")
+		add("cn1", strconv.Itoa(e.Status))
+	}
+
+	// Custom fields with a value go in the six custom string extensions,
+	// in order
+	keys := make([]string, 0, len(e.Fields))
+	for key, value := range e.Fields {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		if i >= 6 {
+			break
+		}
+		n := strconv.Itoa(i + 1)
+		add("cs"+n+"Label", key)
+		add("cs"+n, e.Fields[key])
+	}
+
+	return fmt.Sprintf("CEF:0|Hyperledger Labs|CCAPI|1.0|%s|%s|%d|%s",
+		cefHeader(e.Type), cefHeader(e.Name), e.Severity, strings.Join(ext, " "))
+}
+
+// cefHeader escapes a header field of a CEF record
+func cefHeader(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", " ").Replace(s)
+}
+
+// cefValue escapes an extension value of a CEF record
+func cefValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
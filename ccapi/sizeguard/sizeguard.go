@@ -0,0 +1,167 @@
+// Package sizeguard keeps oversized assets out of the ledger. State database
+// documents of more than about 1MB degrade CouchDB, so the assets in the
+// arguments of a transaction are measured before it is endorsed, and those
+// over the limit are rejected or logged.
+//
+// ASSET_SIZE_LIMIT sets the limit in bytes (default 1048576), which the
+// assetSize of the quota config overrides for each identity.
+// ASSET_SIZE_MODE sets what happens to oversized assets: block (default)
+// rejects the transaction, warn logs it and lets it through, and off
+// disables the guard.
+package sizeguard
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/quota"
+)
+
+// Modes of the guard
+const (
+	ModeOff   = "off"
+	ModeWarn  = "warn"
+	ModeBlock = "block"
+)
+
+// defaultLimit is the largest asset written by default, in bytes
+const defaultLimit = 1 << 20
+
+// Oversized is an asset over the size limit
+type Oversized struct {
+	// Path is where the asset is in the arguments, such as $[0].asset[1]
+	Path      string `json:"path"`
+	AssetType string `json:"assetType,omitempty"`
+	Size      int    `json:"size"`
+}
+
+// Mode returns the mode of the guard from ASSET_SIZE_MODE
+func Mode() string {
+	switch mode := os.Getenv("ASSET_SIZE_MODE"); mode {
+	case ModeOff, ModeWarn:
+		return mode
+	default:
+		return ModeBlock
+	}
+}
+
+// Limit returns the largest asset in bytes the identity can write
+func Limit(identity string) (int, error) {
+	limit, ok, err := quota.AssetSizeLimit(identity)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return limit, nil
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("ASSET_SIZE_LIMIT")); err == nil && n > 0 {
+		return n, nil
+	}
+	return defaultLimit, nil
+}
+
+// Measure returns the assets of the arguments over the limit. Assets are the
+// objects with an @assetType; arguments without assets, as those of custom
+// transactions, are measured as a whole.
+func Measure(args []string, limit int) []Oversized {
+	oversized := make([]Oversized, 0)
+	for i, arg := range args {
+		path := fmt.Sprintf("$[%d]", i)
+
+		var value interface{}
+		if json.Unmarshal([]byte(arg), &value) != nil {
+			if len(arg) > limit {
+				oversized = append(oversized, Oversized{Path: path, Size: len(arg)})
+			}
+			continue
+		}
+
+		found, assets := measureValue(path, value, limit)
+		oversized = append(oversized, found...)
+		if assets == 0 && len(arg) > limit {
+			oversized = append(oversized, Oversized{Path: path, Size: len(arg)})
+		}
+	}
+
+	// Properties are measured in map order
+	sort.Slice(oversized, func(i, j int) bool {
+		return oversized[i].Path < oversized[j].Path
+	})
+	return oversized
+}
+
+// measureValue returns the assets of the decoded JSON value over the limit
+// and how many assets it has
+func measureValue(path string, value interface{}, limit int) ([]Oversized, int) {
+	oversized := make([]Oversized, 0)
+	assets := 0
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if assetType, ok := v["@assetType"].(string); ok {
+			doc, _ := json.Marshal(v)
+			if len(doc) > limit {
+				oversized = append(oversized, Oversized{
+					Path:      path,
+					AssetType: assetType,
+					Size:      len(doc),
+				})
+			}
+			return oversized, 1
+		}
+		for key, item := range v {
+			found, n := measureValue(path+"."+key, item, limit)
+			oversized = append(oversized, found...)
+			assets += n
+		}
+	case []interface{}:
+		for i, item := range v {
+			found, n := measureValue(fmt.Sprintf("%s[%d]", path, i), item, limit)
+			oversized = append(oversized, found...)
+			assets += n
+		}
+	}
+	return oversized, assets
+}
+
+// Check measures the assets the identity writes with the transaction. In
+// block mode, it returns a 413 error if any is over the limit; in warn mode,
+// it logs them.
+func Check(identity, txName string, args []string) error {
+	mode := Mode()
+	if mode == ModeOff {
+		return nil
+	}
+
+	limit, err := Limit(identity)
+	if err != nil {
+		return err
+	}
+	oversized := Measure(args, limit)
+	if len(oversized) == 0 {
+		return nil
+	}
+
+	if mode == ModeWarn {
+		for _, o := range oversized {
+			log.Printf("%s writes %d bytes at %s, over the limit of %d bytes\n", txName, o.Size, o.Path, limit)
+		}
+		return nil
+	}
+
+	return &common.APIError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    common.ErrCodePayloadTooLarge,
+		Message: fmt.Sprintf("%s writes assets larger than %d bytes, which degrade the state database; keep large content, such as files, off the ledger and store only its hash and location in the asset", txName, limit),
+		Details: map[string]interface{}{
+			"limit":     limit,
+			"oversized": oversized,
+		},
+	}
+}
@@ -0,0 +1,306 @@
+// Package slowquery tracks how long the rich queries of the chaincode take
+// to evaluate, by the shape of their selector, so operators find the
+// queries that need an index or pagination before users complain.
+//
+// Queries slower than SLOW_QUERY_THRESHOLD (default 1s) are logged with
+// their selector, with the sensitive properties redacted, and the number of
+// results they returned. The report lists every shape with suggestions:
+// an index when CouchDB would scan every document to run the queries, or
+// when they are slow and no state database is configured to tell, and
+// pagination when they return more than SLOW_QUERY_PAGE_SIZE (default 100)
+// results without a limit.
+package slowquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/hyperledger-labs/ccapi/couchdb"
+	"github.com/hyperledger-labs/ccapi/redact"
+)
+
+// maxShapes limits the shapes tracked, the least recent being dropped
+const maxShapes = 200
+
+// Stats are the evaluations of the queries of a shape since the CCAPI
+// started or the report was reset
+type Stats struct {
+	Channel   string      `json:"channel"`
+	Chaincode string      `json:"chaincode"`
+	Fields    []string    `json:"fields"`
+	Sort      interface{} `json:"sort,omitempty"`
+
+	Count      uint64  `json:"count"`
+	Slow       uint64  `json:"slow"`
+	AvgMs      float64 `json:"avgMs"`
+	MaxMs      float64 `json:"maxMs"`
+	TotalMs    float64 `json:"totalMs"`
+	AvgResults float64 `json:"avgResults"`
+	MaxResults int     `json:"maxResults"`
+	// Unlimited counts the queries without a limit
+	Unlimited uint64    `json:"unlimited"`
+	LastSeen  time.Time `json:"lastSeen"`
+
+	Suggestions []Suggestion `json:"suggestions"`
+
+	results uint64
+	// example is the last query of the shape, to explain to CouchDB. It is
+	// not reported, as its values may be personal data.
+	example map[string]interface{}
+}
+
+// Suggestion is a hint to make the queries of a shape faster
+type Suggestion struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	// Index is the definition of the suggested index, in the format of the
+	// index files of the chaincode
+	Index *couchdb.Definition `json:"index,omitempty"`
+}
+
+// Types of suggestions
+const (
+	SuggestIndex      = "index"
+	SuggestPagination = "pagination"
+)
+
+var (
+	mutex  sync.Mutex
+	shapes = make(map[string]*Stats)
+)
+
+// getThreshold returns the duration past which queries are logged, from
+// SLOW_QUERY_THRESHOLD (default 1s)
+func getThreshold() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("SLOW_QUERY_THRESHOLD"))
+	if err != nil || d <= 0 {
+		return time.Second
+	}
+	return d
+}
+
+// getPageSize returns the number of results past which queries without a
+// limit should be paginated, from SLOW_QUERY_PAGE_SIZE (default 100)
+func getPageSize() int {
+	n, err := strconv.Atoi(os.Getenv("SLOW_QUERY_PAGE_SIZE"))
+	if err != nil || n <= 0 {
+		return 100
+	}
+	return n
+}
+
+// Record tracks the evaluation of the search transaction of the chaincode
+// with the arguments, which took the duration and returned the result
+func Record(channel, chaincode string, args []string, result []byte, d time.Duration) {
+	if len(args) == 0 {
+		return
+	}
+	var req struct {
+		Query map[string]interface{} `json:"query"`
+	}
+	if json.Unmarshal([]byte(args[0]), &req) != nil || req.Query == nil {
+		return
+	}
+	var res struct {
+		Result []json.RawMessage `json:"result"`
+	}
+	json.Unmarshal(result, &res)
+	results := len(res.Result)
+
+	fields, shape := couchdb.Shape(req.Query)
+	key := channel + "/" + chaincode + "/" + shape
+	ms := float64(d.Microseconds()) / 1000
+
+	mutex.Lock()
+	s, ok := shapes[key]
+	if !ok {
+		if len(shapes) >= maxShapes {
+			evictOldest()
+		}
+		s = &Stats{
+			Channel:   channel,
+			Chaincode: chaincode,
+			Fields:    fields,
+			Sort:      req.Query["sort"],
+		}
+		shapes[key] = s
+	}
+	s.Count++
+	s.TotalMs += ms
+	if ms > s.MaxMs {
+		s.MaxMs = ms
+	}
+	s.results += uint64(results)
+	if results > s.MaxResults {
+		s.MaxResults = results
+	}
+	if _, ok := req.Query["limit"]; !ok {
+		s.Unlimited++
+	}
+	s.LastSeen = time.Now().UTC()
+	s.example = req.Query
+	slow := d >= getThreshold()
+	if slow {
+		s.Slow++
+	}
+	mutex.Unlock()
+
+	if slow {
+		selector, _ := json.Marshal(redact.Value(req.Query["selector"]))
+		log.Printf("slow query on %s/%s took %s and returned %d results: %s\n", channel, chaincode, d.Round(time.Millisecond), results, selector)
+	}
+}
+
+// evictOldest drops the shape seen least recently. The caller must hold the
+// mutex.
+func evictOldest() {
+	var oldest string
+	for key, s := range shapes {
+		if oldest == "" || s.LastSeen.Before(shapes[oldest].LastSeen) {
+			oldest = key
+		}
+	}
+	delete(shapes, oldest)
+}
+
+// Report returns the stats of every shape with their suggestions, those
+// taking the most time overall first. Shapes of the chaincode of the state
+// database at COUCHDB_URL are explained to it.
+func Report() []Stats {
+	mutex.Lock()
+	list := make([]Stats, 0, len(shapes))
+	for _, s := range shapes {
+		list = append(list, *s)
+	}
+	mutex.Unlock()
+
+	threshold := float64(getThreshold().Microseconds()) / 1000
+	pageSize := getPageSize()
+	for i := range list {
+		s := &list[i]
+		s.AvgMs = s.TotalMs / float64(s.Count)
+		s.AvgResults = float64(s.results) / float64(s.Count)
+		s.Suggestions = make([]Suggestion, 0)
+
+		if indexed, known := explain(s); known && !indexed {
+			s.Suggestions = append(s.Suggestions, Suggestion{
+				Type:    SuggestIndex,
+				Message: fmt.Sprintf("CouchDB scans every document to match %s, add an index on them", strings.Join(s.Fields, ", ")),
+				Index:   suggestIndex(s.Fields, s.Sort),
+			})
+		} else if !known && s.Slow > 0 && s.AvgMs >= threshold {
+			s.Suggestions = append(s.Suggestions, Suggestion{
+				Type:    SuggestIndex,
+				Message: fmt.Sprintf("queries take %.0fms on average, check that an index covers %s", s.AvgMs, strings.Join(s.Fields, ", ")),
+				Index:   suggestIndex(s.Fields, s.Sort),
+			})
+		}
+		if s.Unlimited > 0 && s.MaxResults > pageSize {
+			s.Suggestions = append(s.Suggestions, Suggestion{
+				Type:    SuggestPagination,
+				Message: fmt.Sprintf("queries without a limit returned up to %d results, page them with limit and bookmark", s.MaxResults),
+			})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].TotalMs > list[j].TotalMs
+	})
+	return list
+}
+
+// Reset forgets the stats of every shape
+func Reset() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	shapes = make(map[string]*Stats)
+}
+
+// explain asks CouchDB whether the queries of the shape are indexed, known
+// being false if it cannot tell
+func explain(s *Stats) (indexed, known bool) {
+	if !couchdb.Enabled() || s.Channel != os.Getenv("CHANNEL") || s.Chaincode != os.Getenv("CCNAME") {
+		return false, false
+	}
+	index, err := couchdb.Explain(s.example)
+	if err != nil {
+		log.Println("failed to explain query to the state database: ", err)
+		return false, false
+	}
+	return index != nil, true
+}
+
+// suggestIndex returns an index on the fields of the selector, followed by
+// the sort fields in their order and direction, as CouchDB requires of
+// indexes used to sort
+func suggestIndex(fields []string, sortFields interface{}) *couchdb.Definition {
+	direction := "asc"
+	var sorted []string
+	if list, ok := sortFields.([]interface{}); ok {
+		for _, item := range list {
+			switch f := item.(type) {
+			case string:
+				sorted = append(sorted, f)
+			case map[string]interface{}:
+				for name, dir := range f {
+					sorted = append(sorted, name)
+					if dir == "desc" {
+						direction = "desc"
+					}
+				}
+			}
+		}
+	}
+
+	def := &couchdb.Definition{Type: "json"}
+	var name strings.Builder
+	name.WriteString("index")
+	add := func(field string) {
+		for _, f := range def.Index.Fields {
+			if f.(map[string]interface{})[field] != nil {
+				return
+			}
+		}
+		def.Index.Fields = append(def.Index.Fields, map[string]interface{}{field: direction})
+		upper := true
+		for _, r := range field {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				upper = true
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			name.WriteRune(r)
+		}
+	}
+	for _, f := range fields {
+		if !contains(sorted, f) {
+			add(f)
+		}
+	}
+	for _, f := range sorted {
+		add(f)
+	}
+	def.Name = name.String()
+	def.DDoc = def.Name + "Doc"
+	return def
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
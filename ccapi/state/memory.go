@@ -0,0 +1,160 @@
+package state
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBuckets is the number of buckets kept before idle ones are dropped
+const maxBuckets = 10000
+
+// purgeInterval is the number of writes between purges of expired keys
+const purgeInterval = 1000
+
+// memoryEntry is a value of the memory store
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// bucket holds the tokens of a key, refilled at the rate of the limit
+type bucket struct {
+	tokens float64
+	last   time.Time
+	idle   time.Duration
+}
+
+// memoryStore keeps the state in the memory of the replica
+type memoryStore struct {
+	mutex   sync.Mutex
+	entries map[string]*memoryEntry
+	buckets map[string]*bucket
+	writes  int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		entries: make(map[string]*memoryEntry),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (s *memoryStore) Get(key string) ([]byte, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[key]
+	if !ok || e.expired(time.Now()) {
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (s *memoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.set(key, value, ttl)
+	return nil
+}
+
+func (s *memoryStore) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if e, ok := s.entries[key]; ok && !e.expired(time.Now()) {
+		return false, nil
+	}
+	s.set(key, value, ttl)
+	return true, nil
+}
+
+func (s *memoryStore) Extend(key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[key]
+	if !ok || e.expired(time.Now()) || !bytes.Equal(e.value, value) {
+		return false, nil
+	}
+	e.expires = time.Time{}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	return true, nil
+}
+
+// set sets the value, purging the expired keys every purgeInterval writes.
+// The caller must hold the mutex.
+func (s *memoryStore) set(key string, value []byte, ttl time.Duration) {
+	now := time.Now()
+	s.writes++
+	if s.writes%purgeInterval == 0 {
+		for k, e := range s.entries {
+			if e.expired(now) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	e := &memoryEntry{value: value}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	s.entries[key] = e
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryStore) Keys(prefix string) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	keys := make([]string, 0)
+	for k, e := range s.entries {
+		if strings.HasPrefix(k, prefix) && !e.expired(now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memoryStore) Take(key string, rate float64, burst int) (bool, time.Duration, error) {
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= maxBuckets {
+			// Full buckets of idle clients are the same as new ones
+			for k, other := range s.buckets {
+				if now.Sub(other.last) > other.idle {
+					delete(s.buckets, k)
+				}
+			}
+		}
+		b = &bucket{
+			tokens: float64(burst),
+			last:   now,
+			idle:   time.Duration(float64(burst) / rate * float64(time.Second)),
+		}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	return allowed, wait, nil
+}
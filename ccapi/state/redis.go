@@ -0,0 +1,339 @@
+package state
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Connections to Redis time out after redisTimeout, and up to maxIdleConns
+// are kept open between commands
+const (
+	redisTimeout = 5 * time.Second
+	maxIdleConns = 16
+)
+
+// takeScript is the token bucket of Take, run atomically by Redis with its
+// clock, so the replicas share the bucket
+const takeScript = `
+local rate, burst = tonumber(ARGV[1]), tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens, last = tonumber(b[1]), tonumber(b[2])
+if tokens == nil then
+  tokens, last = burst, now
+end
+tokens = math.min(burst, tokens + (now - last) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring((1 - tokens) / rate)}
+`
+
+// extendScript sets the ttl of a key holding the value of Extend
+const extendScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`
+
+// redisError is an error reply of Redis
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisStore keeps the state in a Redis server, talking RESP over a pool
+// of connections
+type redisStore struct {
+	addr     string
+	username string
+	password string
+	db       int
+	useTLS   bool
+	prefix   string
+	idle     chan *redisConn
+}
+
+// redisConn is a connection to Redis
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newRedisStore parses the URL, redis://[[user]:password@]host[:port][/db]
+func newRedisStore(rawURL, prefix string) (*redisStore, error) {
+	if rawURL == "" {
+		rawURL = "redis://localhost:6379/0"
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid REDIS_URL")
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, errors.Errorf("invalid REDIS_URL scheme '%s', use redis or rediss", u.Scheme)
+	}
+
+	s := &redisStore{
+		addr:   u.Host,
+		useTLS: u.Scheme == "rediss",
+		prefix: prefix,
+		idle:   make(chan *redisConn, maxIdleConns),
+	}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		s.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, errors.Errorf("invalid REDIS_URL database '%s'", db)
+		}
+	}
+	return s, nil
+}
+
+// dial opens a connection, authenticated and on the database of the URL
+func (s *redisStore) dial() (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if s.useTLS {
+		host, _, _ := net.SplitHostPort(s.addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", s.addr)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to Redis")
+	}
+	c := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if s.password != "" {
+		args := []interface{}{"AUTH", s.password}
+		if s.username != "" {
+			args = []interface{}{"AUTH", s.username, s.password}
+		}
+		if _, err := c.do(args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if s.db != 0 {
+		if _, err := c.do("SELECT", s.db); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// do runs the command on an idle connection, or a new one. Connections
+// that fail are closed.
+func (s *redisStore) do(args ...interface{}) (interface{}, error) {
+	var c *redisConn
+	select {
+	case c = <-s.idle:
+	default:
+		var err error
+		c, err = s.dial()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := c.do(args...)
+	if _, ok := err.(redisError); err != nil && !ok {
+		c.conn.Close()
+		return nil, errors.Wrap(err, "failed to talk to Redis")
+	}
+	select {
+	case s.idle <- c:
+	default:
+		c.conn.Close()
+	}
+	return res, err
+}
+
+// do writes the command and reads its reply
+func (c *redisConn) do(args ...interface{}) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		default:
+			s = fmt.Sprint(v)
+		}
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(s), s)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read reads a reply: strings, integers, bulk strings (nil if null) and
+// arrays
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("invalid Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		res := make([]interface{}, n)
+		for i := range res {
+			res[i], err = c.read()
+			if _, ok := err.(redisError); err != nil && !ok {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	return nil, errors.Errorf("invalid Redis reply '%s'", line)
+}
+
+func (s *redisStore) Get(key string) ([]byte, bool, error) {
+	res, err := s.do("GET", s.prefix+key)
+	if err != nil || res == nil {
+		return nil, false, err
+	}
+	value, _ := res.([]byte)
+	return value, true, nil
+}
+
+func (s *redisStore) Set(key string, value []byte, ttl time.Duration) error {
+	args := []interface{}{"SET", s.prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+	_, err := s.do(args...)
+	return err
+}
+
+func (s *redisStore) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	args := []interface{}{"SET", s.prefix + key, value, "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+	res, err := s.do(args...)
+	return err == nil && res != nil, err
+}
+
+func (s *redisStore) Extend(key string, value []byte, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		// Only leases are extended, and they always expire
+		return false, errors.New("Extend requires a positive ttl")
+	}
+	res, err := s.do("EVAL", extendScript, 1, s.prefix+key, value, ttl.Milliseconds())
+	n, _ := res.(int64)
+	return err == nil && n == 1, err
+}
+
+func (s *redisStore) Delete(key string) error {
+	_, err := s.do("DEL", s.prefix+key)
+	return err
+}
+
+func (s *redisStore) Keys(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	cursor := "0"
+	for {
+		res, err := s.do("SCAN", cursor, "MATCH", escapePattern(s.prefix+prefix)+"*", "COUNT", 100)
+		if err != nil {
+			return nil, err
+		}
+		reply, ok := res.([]interface{})
+		if !ok || len(reply) != 2 {
+			return nil, errors.New("invalid Redis reply to SCAN")
+		}
+		next, _ := reply[0].([]byte)
+		batch, _ := reply[1].([]interface{})
+		for _, k := range batch {
+			if key, ok := k.([]byte); ok {
+				keys = append(keys, strings.TrimPrefix(string(key), s.prefix))
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+func (s *redisStore) Take(key string, rate float64, burst int) (bool, time.Duration, error) {
+	res, err := s.do("EVAL", takeScript, 1, s.prefix+key, strconv.FormatFloat(rate, 'f', -1, 64), burst)
+	if err != nil {
+		return false, 0, err
+	}
+	reply, ok := res.([]interface{})
+	if !ok || len(reply) != 2 {
+		return false, 0, errors.New("invalid Redis reply to the token bucket")
+	}
+	allowed, _ := reply[0].(int64)
+	waitData, _ := reply[1].([]byte)
+	wait, _ := strconv.ParseFloat(string(waitData), 64)
+	return allowed == 1, time.Duration(wait * float64(time.Second)), nil
+}
+
+// escapePattern escapes the glob characters of Redis patterns
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+// Package state keeps the state the replicas of the CCAPI share to behave
+// consistently behind a load balancer: rate limits, browser sessions,
+// prepared transactions, runs of scheduled jobs and event checkpoints.
+//
+// The state is kept in memory by default, which suits a single replica.
+// With STATE_BACKEND=redis it is kept in the Redis server of REDIS_URL
+// (default redis://localhost:6379/0, rediss:// for TLS), under keys
+// prefixed with REDIS_KEY_PREFIX (default ccapi:), so every replica sees
+// the same state.
+package state
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store is a key-value store with expirations
+type Store interface {
+	// Get returns the value of the key, and whether it is set
+	Get(key string) ([]byte, bool, error)
+
+	// Set sets the value of the key, expiring after the ttl unless it is
+	// zero
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// SetNX sets the value of the key only if it is not set, reporting
+	// whether it was
+	SetNX(key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Extend sets the ttl of the key only if it holds the value, reporting
+	// whether it does
+	Extend(key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Delete removes the key
+	Delete(key string) error
+
+	// Keys returns the keys with the prefix, in no particular order
+	Keys(prefix string) ([]string, error)
+
+	// Take takes a token from the bucket of the key, refilled at rate
+	// tokens per second up to burst tokens, reporting whether one was left
+	// and otherwise how long until one is
+	Take(key string, rate float64, burst int) (bool, time.Duration, error)
+}
+
+var (
+	store     Store
+	shared    bool
+	storeOnce sync.Once
+)
+
+// Default returns the store of STATE_BACKEND, memory or redis
+func Default() Store {
+	storeOnce.Do(func() {
+		switch backend := os.Getenv("STATE_BACKEND"); backend {
+		case "", "memory":
+			store = newMemoryStore()
+		case "redis":
+			s, err := newRedisStore(os.Getenv("REDIS_URL"), getPrefix())
+			if err != nil {
+				log.Fatalln("invalid Redis configuration: ", err)
+			}
+			store = s
+			shared = true
+			log.Printf("keeping shared state in Redis at %s\n", s.addr)
+		default:
+			log.Fatalf("unknown STATE_BACKEND '%s', use memory or redis\n", backend)
+		}
+	})
+	return store
+}
+
+// Shared reports whether the state is shared with other replicas
+func Shared() bool {
+	Default()
+	return shared
+}
+
+// getPrefix returns the prefix of the Redis keys from REDIS_KEY_PREFIX
+func getPrefix() string {
+	if prefix, ok := os.LookupEnv("REDIS_KEY_PREFIX"); ok {
+		return prefix
+	}
+	return "ccapi:"
+}
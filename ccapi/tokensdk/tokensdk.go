@@ -0,0 +1,310 @@
+// Package tokensdk proxies token operations to the nodes of a Fabric Token
+// SDK application, such as the token-sdk sample of fabric-samples, so
+// CBDC-style flows can be driven through the same API as the chaincode. The
+// issuer issues tokens to the accounts kept by owner nodes, owners transfer
+// and redeem them, and the auditor sees every transaction.
+//
+// Nodes are set with TOKEN_SDK_ISSUER_URL, TOKEN_SDK_AUDITOR_URL and
+// TOKEN_SDK_OWNERS, a comma separated list of owner nodes as name=url, such
+// as "owner1=http://owner1:9200,owner2=http://owner2:9300".
+package tokensdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// Roles of the nodes
+const (
+	RoleIssuer  = "issuer"
+	RoleAuditor = "auditor"
+	RoleOwner   = "owner"
+)
+
+// maxResponseSize limits the responses of the nodes read by the proxy
+const maxResponseSize = 4 * 1024 * 1024
+
+var client = &http.Client{Timeout: 60 * time.Second}
+
+// Node is a node of the Token SDK application
+type Node struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+	URL  string `json:"url"`
+}
+
+// Amount is an amount of tokens of a type, such as EURX
+type Amount struct {
+	Code  string `json:"code"`
+	Value uint64 `json:"value"`
+}
+
+// Counterparty is the account of an owner node receiving tokens
+type Counterparty struct {
+	Node    string `json:"node"`
+	Account string `json:"account"`
+}
+
+// IssueRequest issues tokens to the account of the counterparty
+type IssueRequest struct {
+	Amount       Amount       `json:"amount"`
+	Counterparty Counterparty `json:"counterparty"`
+	Message      string       `json:"message,omitempty"`
+}
+
+// TransferRequest transfers tokens of an account to the counterparty
+type TransferRequest struct {
+	Amount       Amount       `json:"amount"`
+	Counterparty Counterparty `json:"counterparty"`
+	Message      string       `json:"message,omitempty"`
+}
+
+// RedeemRequest redeems tokens of an account, taking them out of circulation
+type RedeemRequest struct {
+	Amount  Amount `json:"amount"`
+	Message string `json:"message,omitempty"`
+}
+
+// Nodes returns the configured nodes, the issuer and auditor first and the
+// owners sorted by name
+func Nodes() []Node {
+	nodes := make([]Node, 0)
+	if u := os.Getenv("TOKEN_SDK_ISSUER_URL"); u != "" {
+		nodes = append(nodes, Node{Name: RoleIssuer, Role: RoleIssuer, URL: u})
+	}
+	if u := os.Getenv("TOKEN_SDK_AUDITOR_URL"); u != "" {
+		nodes = append(nodes, Node{Name: RoleAuditor, Role: RoleAuditor, URL: u})
+	}
+
+	owners := make([]Node, 0)
+	for _, item := range strings.Split(os.Getenv("TOKEN_SDK_OWNERS"), ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+		owners = append(owners, Node{Name: parts[0], Role: RoleOwner, URL: parts[1]})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].Name < owners[j].Name
+	})
+	return append(nodes, owners...)
+}
+
+// node returns the node with the name
+func node(name string) (Node, error) {
+	for _, n := range Nodes() {
+		if n.Name == name {
+			return n, nil
+		}
+	}
+	return Node{}, common.NewAPIError(http.StatusNotFound, fmt.Sprintf("token node '%s' is not configured", name))
+}
+
+// owner returns the owner node with the name
+func owner(name string) (Node, error) {
+	n, err := node(name)
+	if err != nil {
+		return n, err
+	}
+	if n.Role != RoleOwner {
+		return Node{}, common.NewAPIError(http.StatusBadRequest, fmt.Sprintf("token node '%s' is not an owner", name))
+	}
+	return n, nil
+}
+
+// check validates the amount and the counterparty of a request
+func (a Amount) check() error {
+	if a.Code == "" {
+		return common.NewAPIError(http.StatusBadRequest, "amount.code is required")
+	}
+	if a.Value == 0 {
+		return common.NewAPIError(http.StatusBadRequest, "amount.value must be positive")
+	}
+	return nil
+}
+
+func (cp Counterparty) check() error {
+	if cp.Account == "" {
+		return common.NewAPIError(http.StatusBadRequest, "counterparty.account is required")
+	}
+	if _, err := owner(cp.Node); err != nil {
+		return common.NewAPIError(http.StatusBadRequest, "counterparty.node: "+err.Error())
+	}
+	return nil
+}
+
+// Issue issues tokens from the issuer node, returning the id of the
+// transaction
+func Issue(req IssueRequest) (json.RawMessage, error) {
+	if err := req.Amount.check(); err != nil {
+		return nil, err
+	}
+	if err := req.Counterparty.check(); err != nil {
+		return nil, err
+	}
+	issuer, err := node(RoleIssuer)
+	if err != nil {
+		return nil, err
+	}
+	return call(issuer, http.MethodPost, "/issuer/issue", req)
+}
+
+// Transfer transfers tokens of the account of the owner node
+func Transfer(nodeName, account string, req TransferRequest) (json.RawMessage, error) {
+	if err := req.Amount.check(); err != nil {
+		return nil, err
+	}
+	if err := req.Counterparty.check(); err != nil {
+		return nil, err
+	}
+	n, err := owner(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return call(n, http.MethodPost, "/owner/accounts/"+url.PathEscape(account)+"/transfer", req)
+}
+
+// Redeem redeems tokens of the account of the owner node
+func Redeem(nodeName, account string, req RedeemRequest) (json.RawMessage, error) {
+	if err := req.Amount.check(); err != nil {
+		return nil, err
+	}
+	n, err := owner(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return call(n, http.MethodPost, "/owner/accounts/"+url.PathEscape(account)+"/redeem", req)
+}
+
+// Balance returns the balances of the account, as seen by the node: an
+// owner sees the accounts it keeps, the auditor sees every account
+func Balance(nodeName, account, code string) (json.RawMessage, error) {
+	n, err := node(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	path, err := accountPath(n, account)
+	if err != nil {
+		return nil, err
+	}
+	if code != "" {
+		path += "?code=" + url.QueryEscape(code)
+	}
+	return call(n, http.MethodGet, path, nil)
+}
+
+// Transactions returns the transactions of the account, as seen by the node
+func Transactions(nodeName, account string) (json.RawMessage, error) {
+	n, err := node(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	path, err := accountPath(n, account)
+	if err != nil {
+		return nil, err
+	}
+	return call(n, http.MethodGet, path+"/transactions", nil)
+}
+
+// accountPath returns the path of the account on the node
+func accountPath(n Node, account string) (string, error) {
+	switch n.Role {
+	case RoleOwner:
+		return "/owner/accounts/" + url.PathEscape(account), nil
+	case RoleAuditor:
+		return "/auditor/accounts/" + url.PathEscape(account), nil
+	default:
+		return "", common.NewAPIError(http.StatusBadRequest, fmt.Sprintf("token node '%s' does not keep accounts", n.Name))
+	}
+}
+
+// Health returns the error of the health check of the node, nil if healthy
+func Health(n Node) error {
+	_, err := call(n, http.MethodGet, "/healthz", nil)
+	return err
+}
+
+// call sends the request to the node and returns its response. Errors of
+// the node are returned with their status, while failures reaching it are
+// unavailable.
+func call(n Node, method, path string, body interface{}) (json.RawMessage, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal token request")
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(n.URL, "/")+path, reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create token request")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusServiceUnavailable, fmt.Sprintf("token node '%s' is unreachable: %s", n.Name, err))
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return nil, common.NewAPIError(http.StatusBadGateway, fmt.Sprintf("failed to read the response of token node '%s': %s", n.Name, err))
+	}
+
+	if res.StatusCode >= 300 {
+		status := res.StatusCode
+		if status >= 500 {
+			status = http.StatusBadGateway
+		}
+		return nil, &common.APIError{
+			Status:  status,
+			Code:    common.CodeFromStatus(status),
+			Message: fmt.Sprintf("token node '%s' failed: %s", n.Name, errorMessage(data, res.Status)),
+			Details: map[string]interface{}{
+				"node":   n.Name,
+				"status": res.StatusCode,
+			},
+		}
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 || !json.Valid(data) {
+		data, _ = json.Marshal(string(data))
+	}
+	return data, nil
+}
+
+// errorMessage returns the message of an error response of a node
+func errorMessage(data []byte, status string) string {
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		if body.Message != "" {
+			return body.Message
+		}
+		if body.Error != "" {
+			return body.Error
+		}
+	}
+	if text := strings.TrimSpace(string(data)); text != "" && len(text) < 512 {
+		return text
+	}
+	return status
+}
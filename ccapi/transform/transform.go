@@ -0,0 +1,179 @@
+// Package transform runs a Starlark script that can rewrite request bodies
+// before they reach the chaincode and response bodies before they are sent
+// to the client, e.g. to map legacy field names onto asset properties.
+//
+// The script is set by TRANSFORM_SCRIPT and may define:
+//
+//	def transform_request(tx, body):
+//	    return body
+//
+//	def transform_response(tx, status, body):
+//	    return body
+//
+// Bodies are passed as decoded JSON values and the json module is available.
+package transform
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+)
+
+const (
+	requestHook  = "transform_request"
+	responseHook = "transform_response"
+)
+
+// Script holds the hooks defined by a transformation script
+type Script struct {
+	request  starlark.Callable
+	response starlark.Callable
+}
+
+// Load executes the script file and looks up its hooks
+func Load(path string) (*Script, error) {
+	thread := &starlark.Thread{Name: "load"}
+	predeclared := starlark.StringDict{
+		"json": json.Module,
+	}
+	globals, err := starlark.ExecFile(thread, path, nil, predeclared)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load transform script")
+	}
+
+	s := &Script{}
+	if fn, ok := globals[requestHook].(starlark.Callable); ok {
+		s.request = fn
+	}
+	if fn, ok := globals[responseHook].(starlark.Callable); ok {
+		s.response = fn
+	}
+	if s.request == nil && s.response == nil {
+		return nil, errors.Errorf("transform script defines neither %s nor %s", requestHook, responseHook)
+	}
+	return s, nil
+}
+
+// TransformRequest applies the request hook to a JSON body
+func (s *Script) TransformRequest(txName string, body []byte) ([]byte, error) {
+	if s.request == nil {
+		return body, nil
+	}
+	return call(s.request, body, starlark.String(txName))
+}
+
+// TransformResponse applies the response hook to a JSON body
+func (s *Script) TransformResponse(txName string, status int, body []byte) ([]byte, error) {
+	if s.response == nil {
+		return body, nil
+	}
+	return call(s.response, body, starlark.String(txName), starlark.MakeInt(status))
+}
+
+// call decodes the body, passes it as the last argument of fn and encodes
+// the value returned
+func call(fn starlark.Callable, body []byte, args ...starlark.Value) ([]byte, error) {
+	thread := &starlark.Thread{Name: fn.Name()}
+
+	value, err := starlark.Call(thread, json.Module.Members["decode"], starlark.Tuple{starlark.String(body)}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode body")
+	}
+
+	res, err := starlark.Call(thread, fn, append(args, value), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, fn.Name()+" failed")
+	}
+
+	encoded, err := starlark.Call(thread, json.Module.Members["encode"], starlark.Tuple{res}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode "+fn.Name()+" result")
+	}
+	return []byte(encoded.(starlark.String)), nil
+}
+
+// Middleware applies the script in TRANSFORM_SCRIPT, if any, to the
+// requests and responses of the group
+func Middleware() gin.HandlerFunc {
+	path := os.Getenv("TRANSFORM_SCRIPT")
+	if path == "" {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	script, err := Load(path)
+	if err != nil {
+		log.Panic(err)
+	}
+	log.Printf("transforming requests with script '%s'\n", path)
+
+	return script.handle
+}
+
+// bufferWriter holds the response body so it can be transformed before
+// being sent
+type bufferWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w bufferWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w bufferWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (s *Script) handle(c *gin.Context) {
+	txName := c.Param("txname")
+
+	if s.request != nil && c.Request.Body != nil {
+		body, _ := io.ReadAll(c.Request.Body)
+		if len(bytes.TrimSpace(body)) > 0 {
+			transformed, err := s.TransformRequest(txName, body)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"status": http.StatusBadRequest,
+					"error":  err.Error(),
+				})
+				return
+			}
+			body = transformed
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		c.Request.ContentLength = int64(len(body))
+	}
+
+	if s.response == nil {
+		c.Next()
+		return
+	}
+
+	writer := bufferWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	body := writer.body.Bytes()
+	if len(body) > 0 {
+		transformed, err := s.TransformResponse(txName, writer.Status(), body)
+		if err != nil {
+			log.Println("error transforming response: ", err)
+		} else {
+			body = transformed
+		}
+	}
+
+	c.Writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	c.Writer.Write(body)
+}
@@ -0,0 +1,226 @@
+// Package txqueue queues the transactions submitted to the gateway in
+// priority classes, each with its own concurrency budget, so batch work
+// such as bulk updates and scheduled transactions cannot starve the
+// transactions of interactive users.
+//
+// TX_QUEUE_INTERACTIVE_CONCURRENCY and TX_QUEUE_BATCH_CONCURRENCY set how
+// many transactions of each class are submitted at once; the others wait
+// their turn, in the order they arrived. Classes without a budget submit
+// every transaction right away.
+//
+// TX_QUEUE_INTERACTIVE_MAX_QUEUED and TX_QUEUE_BATCH_MAX_QUEUED bound how
+// many transactions wait: past them, submissions fail right away with 503
+// and a Retry-After estimated from the time transactions take, instead of
+// waiting past the timeouts of their clients.
+package txqueue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// Class is the priority class of a transaction
+type Class string
+
+const (
+	// Interactive transactions are submitted on behalf of users waiting
+	// for them
+	Interactive Class = "interactive"
+	// Batch transactions are submitted by bulk operations and background
+	// jobs
+	Batch Class = "batch"
+)
+
+// Classes are the priority classes, by priority
+var Classes = []Class{Interactive, Batch}
+
+// Stats is the state of the queue of a class
+type Stats struct {
+	Class Class `json:"class"`
+	// Concurrency is the budget of the class, zero if unlimited
+	Concurrency int `json:"concurrency"`
+	Running     int `json:"running"`
+	Queued      int `json:"queued"`
+	// MaxQueued is the threshold of the class, zero if unbounded
+	MaxQueued int `json:"maxQueued"`
+	// Rejected counts the transactions refused since the CCAPI started
+	Rejected uint64 `json:"rejected"`
+}
+
+// averageWeight is the weight of each run in the average duration of runs
+const averageWeight = 0.2
+
+// queue hands the slots of the budget of a class to the transactions
+// waiting for one, first come first served
+type queue struct {
+	mutex     sync.Mutex
+	limit     int
+	maxQueued int
+	running   int
+	waiting   []chan struct{}
+	rejected  uint64
+	// average is the moving average of the duration of runs
+	average time.Duration
+}
+
+var (
+	queues     map[Class]*queue
+	queuesOnce sync.Once
+)
+
+func getQueue(class Class) *queue {
+	queuesOnce.Do(func() {
+		queues = make(map[Class]*queue)
+		for _, c := range Classes {
+			queues[c] = &queue{
+				limit:     getSetting(c, "CONCURRENCY"),
+				maxQueued: getSetting(c, "MAX_QUEUED"),
+			}
+		}
+	})
+	return queues[class]
+}
+
+// getSetting returns the setting of the class from TX_QUEUE_{CLASS}_{NAME},
+// zero if unset
+func getSetting(class Class, name string) int {
+	n, err := strconv.Atoi(os.Getenv(fmt.Sprintf("TX_QUEUE_%s_%s", strings.ToUpper(string(class)), name)))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// ParseClass returns the class with the name, interactive if empty
+func ParseClass(name string) (Class, error) {
+	if name == "" {
+		return Interactive, nil
+	}
+	for _, c := range Classes {
+		if string(c) == name {
+			return c, nil
+		}
+	}
+	return "", common.NewAPIError(http.StatusBadRequest, fmt.Sprintf("unknown priority class '%s', use interactive or batch", name))
+}
+
+// Do runs fn once the class has a slot in its budget, or fails with the
+// error of the context if it is done first. Submissions over the threshold
+// of queued transactions fail right away.
+func Do(ctx context.Context, class Class, fn func() error) error {
+	q := getQueue(class)
+	if q == nil {
+		q = getQueue(Interactive)
+		class = Interactive
+	}
+	if err := q.acquire(ctx, class); err != nil {
+		return err
+	}
+	start := time.Now()
+	defer func() { q.release(time.Since(start)) }()
+	return fn()
+}
+
+func (q *queue) acquire(ctx context.Context, class Class) error {
+	q.mutex.Lock()
+	if q.limit <= 0 || (q.running < q.limit && len(q.waiting) == 0) {
+		q.running++
+		q.mutex.Unlock()
+		return nil
+	}
+	if q.maxQueued > 0 && len(q.waiting) >= q.maxQueued {
+		q.rejected++
+		err := &common.APIError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    common.ErrCodeOverloaded,
+			Message: fmt.Sprintf("too many %s transactions are queued, try again later", class),
+			Details: map[string]interface{}{
+				"class":  class,
+				"queued": len(q.waiting),
+			},
+			RetryAfter: q.retryAfter(),
+		}
+		q.mutex.Unlock()
+		return err
+	}
+	ready := make(chan struct{})
+	q.waiting = append(q.waiting, ready)
+	q.mutex.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		q.mutex.Lock()
+		defer q.mutex.Unlock()
+		for i, w := range q.waiting {
+			if w == ready {
+				q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// The slot was handed over meanwhile, so it goes to the next one
+		q.handOver()
+		return ctx.Err()
+	}
+}
+
+func (q *queue) release(d time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.average == 0 {
+		q.average = d
+	} else {
+		q.average += time.Duration(averageWeight * float64(d-q.average))
+	}
+	q.handOver()
+}
+
+// retryAfter estimates how long until the queued transactions are running,
+// at least a second. The caller must hold the mutex.
+func (q *queue) retryAfter() time.Duration {
+	wait := q.average * time.Duration(len(q.waiting)+1) / time.Duration(q.limit)
+	if wait < time.Second {
+		return time.Second
+	}
+	return wait
+}
+
+// handOver gives the slot of a finished transaction to the first waiting
+// one. The caller must hold the mutex.
+func (q *queue) handOver() {
+	if len(q.waiting) == 0 {
+		q.running--
+		return
+	}
+	next := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	close(next)
+}
+
+// GetStats returns the state of the queue of each class
+func GetStats() []Stats {
+	stats := make([]Stats, 0, len(Classes))
+	for _, c := range Classes {
+		q := getQueue(c)
+		q.mutex.Lock()
+		stats = append(stats, Stats{
+			Class:       c,
+			Concurrency: q.limit,
+			Running:     q.running,
+			Queued:      len(q.waiting),
+			MaxQueued:   q.maxQueued,
+			Rejected:    q.rejected,
+		})
+		q.mutex.Unlock()
+	}
+	return stats
+}
@@ -0,0 +1,127 @@
+// Package txschema keeps the JSON Schemas of the request bodies of the
+// chaincode transactions, declared as {txName}.json files in the directory
+// set by TX_SCHEMAS. The generic invoke routes validate bodies against the
+// schema of their transaction before submitting them, and the API console
+// documents the bodies with them, which describes chaincodes that do not
+// expose the metadata of cc-tools.
+package txschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger-labs/ccapi/eventschema"
+	"github.com/pkg/errors"
+)
+
+var (
+	schemas     map[string]eventschema.Schema
+	schemasErr  error
+	schemasOnce sync.Once
+)
+
+// Enabled reports whether a directory of schemas is set in TX_SCHEMAS
+func Enabled() bool {
+	return os.Getenv("TX_SCHEMAS") != ""
+}
+
+// load reads the schemas in TX_SCHEMAS, once
+func load() (map[string]eventschema.Schema, error) {
+	schemasOnce.Do(func() {
+		schemas = make(map[string]eventschema.Schema)
+
+		dir := os.Getenv("TX_SCHEMAS")
+		if dir == "" {
+			return
+		}
+		paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			schemasErr = errors.Wrap(err, "failed to list transaction schemas")
+			return
+		}
+		for _, path := range paths {
+			txName := strings.TrimSuffix(filepath.Base(path), ".json")
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				schemasErr = errors.Wrap(err, "failed to read transaction schema")
+				return
+			}
+			schema, err := eventschema.ParseSchema(data)
+			if err != nil {
+				schemasErr = errors.Wrapf(err, "invalid schema of transaction '%s'", txName)
+				return
+			}
+			schemas[txName] = schema
+		}
+	})
+
+	return schemas, schemasErr
+}
+
+// Get returns the schema of the body of the transaction
+func Get(txName string) (eventschema.Schema, bool, error) {
+	schemas, err := load()
+	if err != nil {
+		return nil, false, err
+	}
+	schema, ok := schemas[txName]
+	return schema, ok, nil
+}
+
+// Names returns the transactions with a schema, sorted
+func Names() ([]string, error) {
+	schemas, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Check validates the request body against the schema of the transaction,
+// returning a 400 error with the violations if it does not match. Bodies
+// of transactions without a schema are not validated.
+func Check(txName string, body map[string]interface{}) error {
+	schema, ok, err := Get(txName)
+	if err != nil {
+		return common.NewAPIError(http.StatusInternalServerError, err.Error())
+	}
+	if !ok {
+		return nil
+	}
+
+	// The validator takes numbers as float64, while the body may hold exact
+	// numbers
+	data, err := json.Marshal(body)
+	if err != nil {
+		return common.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	var value interface{}
+	json.Unmarshal(data, &value)
+
+	violations := schema.Validate(value)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &common.APIError{
+		Status:  http.StatusBadRequest,
+		Code:    common.ErrCodeInvalidBody,
+		Message: fmt.Sprintf("the body does not match the schema of %s", txName),
+		Details: map[string]interface{}{
+			"violations": violations,
+		},
+	}
+}
@@ -0,0 +1,345 @@
+// Package vc issues W3C Verifiable Credentials attesting to the state of
+// assets on the ledger, such as "person X holds book Y as of block N",
+// signed by the key of the organization of the CCAPI, and verifies them.
+// Credentials are secured as JWTs (VC-JWT), with the certificate of the
+// key in the x5c header, so holders can present them to parties without
+// access to the network.
+//
+// The key and certificate are those of the identity in VC_IDENTITY
+// (default Admin), or the PEM files in VC_SIGNING_KEY and VC_SIGNING_CERT.
+// The issuer is named by VC_ISSUER, default urn:ccapi:{mspId}.
+package vc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+// CredentialType is the type of the credentials attesting to ledger state
+const CredentialType = "LedgerStateCredential"
+
+// Credential is a W3C Verifiable Credential (data model 1.1)
+type Credential struct {
+	Context           []string               `json:"@context"`
+	ID                string                 `json:"id"`
+	Type              []string               `json:"type"`
+	Issuer            string                 `json:"issuer"`
+	IssuanceDate      time.Time              `json:"issuanceDate"`
+	ExpirationDate    *time.Time             `json:"expirationDate,omitempty"`
+	CredentialSubject map[string]interface{} `json:"credentialSubject"`
+}
+
+// claims are the claims of the JWT of a credential
+type claims struct {
+	Issuer    string     `json:"iss"`
+	Subject   string     `json:"sub,omitempty"`
+	ID        string     `json:"jti"`
+	NotBefore int64      `json:"nbf"`
+	IssuedAt  int64      `json:"iat"`
+	Expires   int64      `json:"exp,omitempty"`
+	VC        Credential `json:"vc"`
+}
+
+// Issuer is the key and certificate signing the credentials
+type Issuer struct {
+	ID          string
+	MSPID       string
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+}
+
+var (
+	issuer     *Issuer
+	issuerErr  error
+	issuerOnce sync.Once
+)
+
+// GetIssuer returns the issuer of the credentials, loaded once
+func GetIssuer() (*Issuer, error) {
+	issuerOnce.Do(func() {
+		issuer, issuerErr = loadIssuer()
+		if issuerErr != nil {
+			issuerErr = common.NewAPIError(http.StatusServiceUnavailable, "credentials cannot be issued: "+issuerErr.Error())
+		}
+	})
+	return issuer, issuerErr
+}
+
+// loadIssuer reads the key and certificate of the issuer
+func loadIssuer() (*Issuer, error) {
+	keyPath, certPath := os.Getenv("VC_SIGNING_KEY"), os.Getenv("VC_SIGNING_CERT")
+	mspID := common.GetMSPID()
+	if keyPath == "" || certPath == "" {
+		user := os.Getenv("VC_IDENTITY")
+		if user == "" {
+			user = "Admin"
+		}
+		var err error
+		certPath, keyPath, mspID, err = common.IdentityFiles(user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read signing key")
+	}
+	key, err := identity.PrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse signing key")
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported signing key")
+	}
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read signing certificate")
+	}
+	cert, err := identity.CertificateFromPEM(certPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse signing certificate")
+	}
+
+	id := os.Getenv("VC_ISSUER")
+	if id == "" {
+		id = "urn:ccapi:" + mspID
+	}
+	return &Issuer{ID: id, MSPID: mspID, Key: signer, Certificate: cert}, nil
+}
+
+// CertificatePEM returns the certificate of the issuer, PEM encoded
+func (i *Issuer) CertificatePEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: i.Certificate.Raw}))
+}
+
+// Issue signs a credential about the subject, valid for the duration if
+// positive, returning it with its JWT
+func Issue(subject map[string]interface{}, validFor time.Duration) (*Credential, string, error) {
+	iss, err := GetIssuer()
+	if err != nil {
+		return nil, "", err
+	}
+	alg, err := algorithm(iss.Key.Public())
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	cred := Credential{
+		Context:           []string{"https://www.w3.org/2018/credentials/v1"},
+		ID:                "urn:uuid:" + newUUID(),
+		Type:              []string{"VerifiableCredential", CredentialType},
+		Issuer:            iss.ID,
+		IssuanceDate:      now,
+		CredentialSubject: subject,
+	}
+	c := claims{
+		Issuer:    iss.ID,
+		ID:        cred.ID,
+		NotBefore: now.Unix(),
+		IssuedAt:  now.Unix(),
+	}
+	if id, ok := subject["id"].(string); ok {
+		c.Subject = id
+	}
+	if validFor > 0 {
+		expires := now.Add(validFor)
+		cred.ExpirationDate = &expires
+		c.Expires = expires.Unix()
+	}
+	c.VC = cred
+
+	header := map[string]interface{}{
+		"alg": alg,
+		"typ": "JWT",
+		"kid": iss.ID + "#key-1",
+		"x5c": []string{base64.StdEncoding.EncodeToString(iss.Certificate.Raw)},
+	}
+	headerJSON, _ := json.Marshal(header)
+	claimsJSON, err := json.Marshal(c)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to marshal credential")
+	}
+
+	input := b64(headerJSON) + "." + b64(claimsJSON)
+	signature, err := sign(iss.Key, []byte(input))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to sign credential")
+	}
+	return &cred, input + "." + b64(signature), nil
+}
+
+// Verification is the outcome of the verification of a credential
+type Verification struct {
+	Valid      bool        `json:"valid"`
+	Errors     []string    `json:"errors"`
+	Credential *Credential `json:"credential,omitempty"`
+}
+
+// Verify checks the JWT of a credential was signed by the issuer and is
+// within its validity period
+func Verify(jwt string) (*Verification, error) {
+	iss, err := GetIssuer()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Verification{Errors: make([]string, 0)}
+	parts := strings.Split(strings.TrimSpace(jwt), ".")
+	if len(parts) != 3 {
+		res.Errors = append(res.Errors, "not a JWT")
+		return res, nil
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	var c claims
+	headerJSON, err1 := base64.RawURLEncoding.DecodeString(parts[0])
+	claimsJSON, err2 := base64.RawURLEncoding.DecodeString(parts[1])
+	signature, err3 := base64.RawURLEncoding.DecodeString(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil || json.Unmarshal(headerJSON, &header) != nil || json.Unmarshal(claimsJSON, &c) != nil {
+		res.Errors = append(res.Errors, "malformed JWT")
+		return res, nil
+	}
+	res.Credential = &c.VC
+
+	alg, _ := algorithm(iss.Certificate.PublicKey)
+	switch {
+	case c.Issuer != iss.ID || c.VC.Issuer != iss.ID:
+		res.Errors = append(res.Errors, fmt.Sprintf("issued by %s, not %s", c.Issuer, iss.ID))
+	case header.Alg != alg:
+		res.Errors = append(res.Errors, fmt.Sprintf("unexpected algorithm %s", header.Alg))
+	case !verify(iss.Certificate.PublicKey, []byte(parts[0]+"."+parts[1]), signature):
+		res.Errors = append(res.Errors, "invalid signature")
+	}
+
+	now := time.Now().Unix()
+	if c.NotBefore > now {
+		res.Errors = append(res.Errors, "not valid yet")
+	}
+	if c.Expires != 0 && c.Expires <= now {
+		res.Errors = append(res.Errors, "expired")
+	}
+
+	res.Valid = len(res.Errors) == 0
+	return res, nil
+}
+
+// algorithm returns the JWS algorithm of the key
+func algorithm(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve.Params().BitSize {
+		case 256:
+			return "ES256", nil
+		case 384:
+			return "ES384", nil
+		}
+	case ed25519.PublicKey:
+		return "EdDSA", nil
+	case *rsa.PublicKey:
+		return "RS256", nil
+	}
+	return "", errors.New("unsupported signing key")
+}
+
+// sign signs the input with the key. ECDSA signatures are encoded as the
+// concatenation of r and s, as required by JWS.
+func sign(key crypto.Signer, input []byte) ([]byte, error) {
+	switch k := key.Public().(type) {
+	case ed25519.PublicKey:
+		return key.Sign(rand.Reader, input, crypto.Hash(0))
+	case *ecdsa.PublicKey:
+		hash, digest := digestFor(k)
+		der, err := key.Sign(rand.Reader, digest(input), hash)
+		if err != nil {
+			return nil, err
+		}
+		var sig struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(der, &sig); err != nil {
+			return nil, err
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		out := make([]byte, 2*size)
+		sig.R.FillBytes(out[:size])
+		sig.S.FillBytes(out[size:])
+		return out, nil
+	default:
+		digest := sha256.Sum256(input)
+		return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+}
+
+// verify checks the JWS signature of the input with the key
+func verify(key crypto.PublicKey, input, signature []byte) bool {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, input, signature)
+	case *ecdsa.PublicKey:
+		_, digest := digestFor(k)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest(input), r, s)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(input)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
+
+// digestFor returns the hash of the ECDSA algorithm of the key
+func digestFor(key *ecdsa.PublicKey) (crypto.Hash, func([]byte) []byte) {
+	if key.Curve.Params().BitSize == 384 {
+		return crypto.SHA384, func(data []byte) []byte {
+			sum := sha512.Sum384(data)
+			return sum[:]
+		}
+	}
+	return crypto.SHA256, func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}
+}
+
+// b64 encodes data as base64url without padding, as in JWTs
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
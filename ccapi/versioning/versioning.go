@@ -0,0 +1,348 @@
+// Package versioning keeps older clients working after chaincode schema
+// changes. Clients choose the API version with the API-Version header or a
+// path prefix such as /api/v1/..., and the assets in their requests and
+// responses are translated between the shape of that version and the
+// current schema, as declared in the file set by VERSIONS_CONFIG:
+//
+//	{
+//	  "current": "v2",
+//	  "default": "v1",
+//	  "versions": {
+//	    "v1": {
+//	      "sunset": "2025-12-31",
+//	      "assetTypes": {
+//	        "book": {
+//	          "rename": {"title": "name"},
+//	          "remove": ["genres"],
+//	          "defaults": {"edition": 1}
+//	        }
+//	      }
+//	    }
+//	  }
+//	}
+//
+// Renames map current properties to their name in the version, removed
+// properties are not sent to its clients, and defaults are sent for the
+// properties the version has but the current schema no longer does.
+package versioning
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger-labs/ccapi/common"
+	"github.com/pkg/errors"
+)
+
+// Header is the request and response header of the API version
+const Header = "API-Version"
+
+// Mapping translates the assets of a type between the current schema and
+// an older version
+type Mapping struct {
+	Rename   map[string]string      `json:"rename,omitempty"`
+	Remove   []string               `json:"remove,omitempty"`
+	Defaults map[string]interface{} `json:"defaults,omitempty"`
+}
+
+// Version is an older version of the API
+type Version struct {
+	Sunset     string             `json:"sunset,omitempty"`
+	AssetTypes map[string]Mapping `json:"assetTypes,omitempty"`
+}
+
+// Config declares the current version of the API and the older ones still
+// served
+type Config struct {
+	Current  string             `json:"current"`
+	Default  string             `json:"default"`
+	Versions map[string]Version `json:"versions"`
+}
+
+var (
+	config     *Config
+	configErr  error
+	configOnce sync.Once
+)
+
+// GetConfig returns the versions configuration, nil if VERSIONS_CONFIG is
+// not set
+func GetConfig() (*Config, error) {
+	configOnce.Do(func() {
+		path := os.Getenv("VERSIONS_CONFIG")
+		if path == "" {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			configErr = errors.Wrap(err, "failed to read versions config")
+			return
+		}
+		var cfg Config
+		err = json.Unmarshal(data, &cfg)
+		if err != nil {
+			configErr = errors.Wrap(err, "failed to unmarshal versions config")
+			return
+		}
+
+		if cfg.Current == "" {
+			configErr = errors.New("the versions config must set the current version")
+			return
+		}
+		if cfg.Default == "" {
+			cfg.Default = cfg.Current
+		}
+		if _, ok := cfg.Versions[cfg.Default]; !ok && cfg.Default != cfg.Current {
+			configErr = errors.Errorf("unknown default version '%s'", cfg.Default)
+			return
+		}
+		config = &cfg
+	})
+
+	return config, configErr
+}
+
+// Names returns the versions served, sorted
+func (cfg *Config) Names() []string {
+	names := []string{cfg.Current}
+	for name := range cfg.Versions {
+		if name != cfg.Current {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+var pathVersion = regexp.MustCompile(`^/api/(v[0-9]+)(/.*)?$`)
+
+// Handler serves the /api/{version}/... paths as the /api/... routes with
+// the API-Version header set, before the router sees them
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg, _ := GetConfig()
+		if cfg != nil {
+			if m := pathVersion.FindStringSubmatch(r.URL.Path); m != nil {
+				r.Header.Set(Header, m[1])
+				r.URL.Path = "/api" + m[2]
+				r.URL.RawPath = ""
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Middleware translates the requests and responses of older versions from
+// and to the current schema
+func Middleware() gin.HandlerFunc {
+	cfg, err := GetConfig()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return func(c *gin.Context) {
+		if cfg == nil {
+			c.Next()
+			return
+		}
+
+		name := c.GetHeader(Header)
+		if name == "" {
+			name = cfg.Default
+		}
+		c.Header(Header, name)
+		if name == cfg.Current {
+			c.Next()
+			return
+		}
+
+		version, ok := cfg.Versions[name]
+		if !ok {
+			common.Abort(c, http.StatusBadRequest, errors.Errorf("unsupported API version '%s', use one of %s", name, strings.Join(cfg.Names(), ", ")))
+			c.Abort()
+			return
+		}
+		if version.Sunset != "" {
+			c.Header("Deprecation", "true")
+			c.Header("Sunset", version.Sunset)
+		}
+
+		if c.Request.Body != nil && c.Request.ContentLength != 0 && isJSON(c.GetHeader("Content-Type")) {
+			body, _ := io.ReadAll(c.Request.Body)
+			var v interface{}
+			if common.UnmarshalJSON(body, &v) == nil {
+				version.upgrade(v, routeAssetType(c))
+				body, _ = json.Marshal(v)
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+		}
+
+		writer := &versionWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.body == nil {
+			return
+		}
+		body := writer.body.Bytes()
+		var v interface{}
+		if common.UnmarshalJSON(body, &v) == nil {
+			version.downgrade(v)
+			body, _ = json.Marshal(v)
+		}
+		c.Writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		c.Writer.WriteHeaderNow()
+		c.Writer.Write(body)
+	}
+}
+
+// routeAssetType returns the asset type of the asset resource of the
+// route, whose bodies have no @assetType
+func routeAssetType(c *gin.Context) string {
+	path := strings.TrimPrefix(c.FullPath(), "/api/assets/")
+	if path == c.FullPath() {
+		return ""
+	}
+	return strings.SplitN(path, "/", 2)[0]
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// downgrade translates the assets in the value from the current schema to
+// the version
+func (v Version) downgrade(value interface{}) {
+	walk(value, "", func(asset map[string]interface{}, assetType string) {
+		m, ok := v.AssetTypes[assetType]
+		if !ok {
+			return
+		}
+		for _, prop := range m.Remove {
+			delete(asset, prop)
+		}
+		for current, old := range m.Rename {
+			if value, ok := asset[current]; ok {
+				delete(asset, current)
+				asset[old] = value
+			}
+		}
+		for prop, value := range m.Defaults {
+			if _, ok := asset[prop]; !ok {
+				asset[prop] = value
+			}
+		}
+	})
+}
+
+// upgrade translates the assets in the value from the version to the
+// current schema
+func (v Version) upgrade(value interface{}, routeAssetType string) {
+	walk(value, routeAssetType, func(asset map[string]interface{}, assetType string) {
+		m, ok := v.AssetTypes[assetType]
+		if !ok {
+			return
+		}
+		for prop := range m.Defaults {
+			delete(asset, prop)
+		}
+		for current, old := range m.Rename {
+			if value, ok := asset[old]; ok {
+				delete(asset, old)
+				asset[current] = value
+			}
+		}
+	})
+}
+
+// walk calls fn on every object with an asset type in the value, innermost
+// first. The top-level object takes the asset type of the route if it has
+// none.
+func walk(value interface{}, routeAssetType string, fn func(map[string]interface{}, string)) {
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			walk(item, routeAssetType, fn)
+		}
+	case map[string]interface{}:
+		for _, item := range value {
+			walk(item, "", fn)
+		}
+		if isReference(value) {
+			return
+		}
+		assetType, ok := value["@assetType"].(string)
+		if !ok {
+			assetType = routeAssetType
+		}
+		if assetType != "" {
+			fn(value, assetType)
+		}
+	}
+}
+
+// isReference reports whether the object only references an asset
+func isReference(obj map[string]interface{}) bool {
+	for k := range obj {
+		if k != "@key" && k != "@assetType" {
+			return false
+		}
+	}
+	return len(obj) > 0
+}
+
+// versionWriter holds JSON responses so they can be translated before
+// being sent. Other responses, such as streams, are written as they are.
+type versionWriter struct {
+	gin.ResponseWriter
+	body        *bytes.Buffer
+	passThrough bool
+}
+
+func (w *versionWriter) buffer() bool {
+	if w.body == nil && !w.passThrough {
+		if isJSON(w.ResponseWriter.Header().Get("Content-Type")) {
+			w.body = &bytes.Buffer{}
+		} else {
+			w.passThrough = true
+		}
+	}
+	return w.body != nil
+}
+
+func (w *versionWriter) Write(b []byte) (int, error) {
+	if w.buffer() {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *versionWriter) WriteString(s string) (int, error) {
+	if w.buffer() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// WriteHeaderNow is deferred for buffered responses, whose length changes
+// once translated
+func (w *versionWriter) WriteHeaderNow() {
+	if !w.buffer() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,140 @@
+// Package warmup prepares the connections to the Fabric network when the
+// CCAPI starts, so the first requests do not pay for the connection setup:
+// the gateway sessions of the configured identities are opened, their
+// connections dialed and, optionally, a priming transaction evaluated on
+// each channel, which also starts chaincode containers that were idle.
+package warmup
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/chaincode"
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// defaultTimeout bounds the warm-up of each identity
+const defaultTimeout = 10 * time.Second
+
+// Enabled reports whether the connections are warmed up on startup, unless
+// WARMUP is false
+func Enabled() bool {
+	return os.Getenv("WARMUP") != "false"
+}
+
+// getList returns the comma separated values of the variable
+func getList(name string) []string {
+	list := make([]string, 0)
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// identities returns the identities warmed up, from WARMUP_IDENTITIES, by
+// default the user of the CCAPI and Admin, the user of requests without the
+// User header, in their own organization and in each org profile
+func identities() []string {
+	if list := getList("WARMUP_IDENTITIES"); len(list) > 0 {
+		return list
+	}
+
+	users := []string{os.Getenv("USER")}
+	if users[0] != "Admin" {
+		users = append(users, "Admin")
+	}
+
+	list := make([]string, 0)
+	for _, user := range users {
+		list = append(list, user)
+		for _, org := range common.OrgNames() {
+			list = append(list, user+"@"+org)
+		}
+	}
+	return list
+}
+
+// channels returns the channels the priming transaction is evaluated on,
+// CHANNEL and those in WARMUP_CHANNELS
+func channels() []string {
+	list := []string{os.Getenv("CHANNEL")}
+	for _, channel := range getList("WARMUP_CHANNELS") {
+		if channel != list[0] {
+			list = append(list, channel)
+		}
+	}
+	return list
+}
+
+// getTimeout returns how long the warm-up of each identity may take, from
+// WARMUP_TIMEOUT
+func getTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("WARMUP_TIMEOUT")); err == nil && d > 0 {
+		return d
+	}
+	return defaultTimeout
+}
+
+// Start warms up the identities in the background, if enabled
+func Start() {
+	if !Enabled() {
+		return
+	}
+	go Run()
+}
+
+// Run warms up every identity at once and waits for them. Failures are only
+// logged, the identities connecting again on their first request.
+func Run() {
+	start := time.Now()
+	ids := identities()
+
+	var wg sync.WaitGroup
+	for _, identity := range ids {
+		wg.Add(1)
+		go func(identity string) {
+			defer wg.Done()
+
+			identityStart := time.Now()
+			err := warmUp(identity)
+			if err != nil {
+				log.Printf("warm-up of %s failed: %s\n", identity, err)
+				return
+			}
+			log.Printf("warmed up %s in %s\n", identity, time.Since(identityStart).Round(time.Millisecond))
+		}(identity)
+	}
+	wg.Wait()
+
+	log.Printf("warm-up of %d identities done in %s\n", len(ids), time.Since(start).Round(time.Millisecond))
+}
+
+// warmUp dials the connection of the identity and, if WARMUP_EVALUATE is
+// true, evaluates getHeader on each channel as the identity
+func warmUp(identity string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), getTimeout())
+	defer cancel()
+
+	err := common.Preconnect(ctx, identity)
+	if err != nil {
+		return err
+	}
+
+	if os.Getenv("WARMUP_EVALUATE") != "true" {
+		return nil
+	}
+	for _, channel := range channels() {
+		_, err = chaincode.QueryGateway(channel, os.Getenv("CCNAME"), "getHeader", identity, nil)
+		if err != nil {
+			err, _ := common.ParseError(err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/common"
+)
+
+// DeadLetter is a delivery that failed past the retries
+type DeadLetter struct {
+	ID       string          `json:"id"`
+	URL      string          `json:"url"`
+	Type     string          `json:"type"`
+	Event    json.RawMessage `json:"event"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	FailedAt time.Time       `json:"failedAt"`
+}
+
+var (
+	deadMutex   sync.Mutex
+	deadLetters []*DeadLetter
+	deadLoaded  bool
+)
+
+// getDeadLetterPath returns the file dead letters are kept in, from
+// WEBHOOK_DEAD_LETTER_PATH
+func getDeadLetterPath() string {
+	if path := os.Getenv("WEBHOOK_DEAD_LETTER_PATH"); path != "" {
+		return path
+	}
+	return "deadletters.json"
+}
+
+// loadDeadLetters reads the dead-letter file once. The caller must hold the
+// mutex.
+func loadDeadLetters() {
+	if deadLoaded {
+		return
+	}
+	deadLoaded = true
+
+	deadLetters = make([]*DeadLetter, 0)
+	data, err := os.ReadFile(getDeadLetterPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("error reading dead letters: ", err)
+		}
+		return
+	}
+	err = json.Unmarshal(data, &deadLetters)
+	if err != nil {
+		log.Println("error unmarshalling dead letters: ", err)
+		deadLetters = make([]*DeadLetter, 0)
+	}
+}
+
+// saveDeadLetters writes the dead-letter file. The caller must hold the
+// mutex.
+func saveDeadLetters() {
+	data, err := json.MarshalIndent(deadLetters, "", "  ")
+	if err != nil {
+		log.Println("error marshalling dead letters: ", err)
+		return
+	}
+	err = os.WriteFile(getDeadLetterPath(), data, 0644)
+	if err != nil {
+		log.Println("error writing dead letters: ", err)
+	}
+}
+
+// deadLetter keeps the failed delivery of the body to the webhook
+func deadLetter(url string, body []byte, attempts int, deliveryErr error) {
+	var event Event
+	json.Unmarshal(body, &event)
+
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	deadMutex.Lock()
+	defer deadMutex.Unlock()
+
+	loadDeadLetters()
+	deadLetters = append(deadLetters, &DeadLetter{
+		ID:       hex.EncodeToString(id),
+		URL:      url,
+		Type:     event.Type,
+		Event:    body,
+		Attempts: attempts,
+		Error:    deliveryErr.Error(),
+		FailedAt: time.Now().UTC(),
+	})
+	saveDeadLetters()
+	log.Printf("webhook event %s to %s dead-lettered after %d attempts\n", event.Type, url, attempts)
+}
+
+// DeadLetters returns the failed deliveries, oldest first, optionally of the
+// event type
+func DeadLetters(eventType string) []DeadLetter {
+	deadMutex.Lock()
+	defer deadMutex.Unlock()
+
+	loadDeadLetters()
+	res := make([]DeadLetter, 0, len(deadLetters))
+	for _, letter := range deadLetters {
+		if eventType == "" || letter.Type == eventType {
+			res = append(res, *letter)
+		}
+	}
+	return res
+}
+
+// find returns the index of the dead letter with the id. The caller must
+// hold the mutex.
+func find(id string) (int, error) {
+	loadDeadLetters()
+	for i, letter := range deadLetters {
+		if letter.ID == id {
+			return i, nil
+		}
+	}
+	return 0, common.NewAPIError(http.StatusNotFound, "dead letter not found")
+}
+
+// Redrive delivers the dead letter with the id again, once. It is discarded
+// if delivered, and kept with the new error otherwise.
+func Redrive(id string) (DeadLetter, error) {
+	deadMutex.Lock()
+	i, err := find(id)
+	if err != nil {
+		deadMutex.Unlock()
+		return DeadLetter{}, err
+	}
+	letter := *deadLetters[i]
+	deadMutex.Unlock()
+
+	deliveryErr := post(letter.URL, letter.Event)
+
+	deadMutex.Lock()
+	defer deadMutex.Unlock()
+
+	// The dead letter may have been discarded during the delivery
+	i, err = find(id)
+	if err != nil {
+		return letter, deliveryErr
+	}
+	if deliveryErr == nil {
+		deadLetters = append(deadLetters[:i], deadLetters[i+1:]...)
+	} else {
+		deadLetters[i].Attempts++
+		deadLetters[i].Error = deliveryErr.Error()
+		deadLetters[i].FailedAt = time.Now().UTC()
+		letter = *deadLetters[i]
+	}
+	saveDeadLetters()
+	return letter, deliveryErr
+}
+
+// Discard removes the dead letter with the id without delivering it
+func Discard(id string) error {
+	deadMutex.Lock()
+	defer deadMutex.Unlock()
+
+	i, err := find(id)
+	if err != nil {
+		return err
+	}
+	deadLetters = append(deadLetters[:i], deadLetters[i+1:]...)
+	saveDeadLetters()
+	return nil
+}
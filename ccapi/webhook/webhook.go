@@ -0,0 +1,176 @@
+// Package webhook notifies external services of events of the CCAPI, such
+// as proposals awaiting approval, by posting them to the URLs set in
+// WEBHOOK_URLS. Failed deliveries are retried, and kept in a dead-letter
+// store once the retries run out.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/ccapi/plugins"
+	"github.com/pkg/errors"
+)
+
+// Event is the body posted to the webhooks
+type Event struct {
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// client does not follow redirects, which would take deliveries past the
+// hosts allowed for callbacks and replay sinks
+var client = &http.Client{
+	Timeout: 5 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// retryDelay is the delay before the first retry of a failed delivery,
+// doubled on every retry
+var retryDelay = time.Second
+
+// getRetries returns how many times a failed delivery is retried, from
+// WEBHOOK_RETRIES (default 3)
+func getRetries() int {
+	retries, err := strconv.Atoi(os.Getenv("WEBHOOK_RETRIES"))
+	if err != nil || retries < 0 {
+		return 3
+	}
+	return retries
+}
+
+var (
+	managedMutex sync.RWMutex
+	managedURLs  []string
+)
+
+// getURLs returns the comma separated webhook URLs from environment, and
+// those set at runtime
+func getURLs() []string {
+	urls := make([]string, 0)
+	for _, url := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return append(urls, ManagedURLs()...)
+}
+
+// SetManagedURLs replaces the webhook URLs set at runtime, which are
+// notified along with those of WEBHOOK_URLS
+func SetManagedURLs(urls []string) {
+	managedMutex.Lock()
+	defer managedMutex.Unlock()
+	managedURLs = append([]string(nil), urls...)
+}
+
+// ManagedURLs returns the webhook URLs set at runtime
+func ManagedURLs() []string {
+	managedMutex.RLock()
+	defer managedMutex.RUnlock()
+	return append([]string(nil), managedURLs...)
+}
+
+// Notify posts the event to every webhook in the background. Failed
+// deliveries are retried with exponential backoff, and then dead-lettered.
+// The event is also published to the event sink plugins.
+func Notify(eventType string, data interface{}) {
+	urls := getURLs()
+	sinks := plugins.ByKind(plugins.KindEventSink)
+	if len(urls) == 0 && len(sinks) == 0 {
+		return
+	}
+
+	event := Event{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Println("error marshalling webhook event: ", err)
+		return
+	}
+
+	if len(sinks) > 0 {
+		pluginEvent := plugins.Event{Type: event.Type, Timestamp: event.Timestamp}
+		pluginEvent.Data, _ = json.Marshal(data)
+		for _, sink := range sinks {
+			go func(sink *plugins.Plugin) {
+				if err := sink.Publish(pluginEvent); err != nil {
+					log.Printf("error publishing event to plugin '%s': %s\n", sink.Name(), err)
+				}
+			}(sink)
+		}
+	}
+
+	for _, url := range urls {
+		go deliver(url, body)
+	}
+}
+
+// Send posts the event to the URL and waits for the delivery, so that
+// events sent one after another arrive in order. Failed deliveries are
+// retried and dead-lettered like those of Notify.
+func Send(url, eventType string, data interface{}) error {
+	body, err := json.Marshal(Event{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal webhook event")
+	}
+	return deliver(url, body)
+}
+
+// deliver posts the body to the webhook, retrying failed deliveries. The
+// body is dead-lettered once the retries run out.
+func deliver(url string, body []byte) error {
+	retries := getRetries()
+	delay := retryDelay
+
+	var err error
+	attempts := 0
+	for {
+		attempts++
+		err = post(url, body)
+		if err == nil {
+			return nil
+		}
+		log.Printf("error posting webhook event to %s (attempt %d): %s\n", url, attempts, err)
+
+		if attempts > retries {
+			break
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	deadLetter(url, body, attempts, err)
+	return err
+}
+
+// post delivers the body to the webhook, failing on error statuses
+func post(url string, body []byte) error {
+	res, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return errors.Errorf("webhook answered with status %d", res.StatusCode)
+	}
+	return nil
+}
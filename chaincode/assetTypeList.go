@@ -10,4 +10,9 @@ var assetTypeList = []assets.AssetType{
 	assettypes.Book,
 	assettypes.Library,
 	assettypes.Secret,
+	assettypes.Proposal,
+	assettypes.Token,
+	assettypes.Collectible,
+	assettypes.Escrow,
+	assettypes.Loan,
 }
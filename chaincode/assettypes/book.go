@@ -33,6 +33,21 @@ var Book = assets.AssetType{
 			Label:    "Current Tenant",
 			DataType: "->person",
 		},
+		{
+			// Reference to the active loan of the book, set by borrowBook
+			Tag:      "currentLoan",
+			Label:    "Current Loan",
+			DataType: "->loan",
+			ReadOnly: true,
+		},
+		{
+			// Number of copies in the inventory, set by addBookCopies. Books
+			// without it are single copies lent through currentLoan.
+			Tag:      "copies",
+			Label:    "Copies",
+			DataType: "integer",
+			ReadOnly: true,
+		},
 		{
 			// String list
 			Tag:      "genres",
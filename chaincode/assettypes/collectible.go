@@ -0,0 +1,52 @@
+package assettypes
+
+import "github.com/hyperledger-labs/cc-tools/assets"
+
+// Description of a unique asset owned by an organization. Properties are
+// read-only for the generic transactions, so collectibles are only changed by
+// their owner through updateCollectible and transferOwnership, or by the
+// escrow transactions while locked.
+var Collectible = assets.AssetType{
+	Tag:         "collectible",
+	Label:       "Collectible",
+	Description: "Unique asset owned by an organization",
+
+	Props: []assets.AssetProp{
+		{
+			// Primary Key
+			Required: true,
+			IsKey:    true,
+			Tag:      "id",
+			Label:    "ID",
+			DataType: "string",
+		},
+		{
+			Required: true,
+			ReadOnly: true,
+			Tag:      "name",
+			Label:    "Name",
+			DataType: "string",
+		},
+		{
+			ReadOnly: true,
+			Tag:      "description",
+			Label:    "Description",
+			DataType: "string",
+		},
+		{
+			// MSP of the organization owning the asset
+			Required: true,
+			ReadOnly: true,
+			Tag:      "owner",
+			Label:    "Owner",
+			DataType: "string",
+		},
+		{
+			// Escrow holding the collectible, set while it is locked
+			ReadOnly: true,
+			Tag:      "escrow",
+			Label:    "Escrow",
+			DataType: "->escrow",
+		},
+	},
+}
@@ -0,0 +1,70 @@
+package assettypes
+
+import "github.com/hyperledger-labs/cc-tools/assets"
+
+// Description of an escrow locking a collectible until the buyer confirms
+// the transfer or it is released. Escrows are created and settled by the
+// lockEscrow, confirmEscrow and releaseEscrow transactions.
+var Escrow = assets.AssetType{
+	Tag:         "escrow",
+	Label:       "Escrow",
+	Description: "Collectible locked pending the confirmation of a buyer",
+
+	Props: []assets.AssetProp{
+		{
+			// Primary Key
+			Required: true,
+			IsKey:    true,
+			Tag:      "id",
+			Label:    "Escrow ID",
+			DataType: "string",
+		},
+		{
+			Required: true,
+			ReadOnly: true,
+			Tag:      "collectible",
+			Label:    "Collectible",
+			DataType: "->collectible",
+		},
+		{
+			// MSP of the organization that locked the collectible
+			Required: true,
+			ReadOnly: true,
+			Tag:      "seller",
+			Label:    "Seller",
+			DataType: "string",
+		},
+		{
+			// MSP of the organization that must confirm the transfer
+			Required: true,
+			ReadOnly: true,
+			Tag:      "buyer",
+			Label:    "Buyer",
+			DataType: "string",
+		},
+		{
+			// After this date the escrow can no longer be confirmed and
+			// anyone can release it
+			Required: true,
+			ReadOnly: true,
+			Tag:      "expiresAt",
+			Label:    "Expiration Date",
+			DataType: "datetime",
+		},
+		{
+			// Custom data type
+			Required:     true,
+			ReadOnly:     true,
+			Tag:          "status",
+			Label:        "Status",
+			DataType:     "escrowStatus",
+			DefaultValue: "locked",
+		},
+		{
+			ReadOnly: true,
+			Tag:      "settledAt",
+			Label:    "Settlement Date",
+			DataType: "datetime",
+		},
+	},
+}
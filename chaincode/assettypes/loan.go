@@ -0,0 +1,66 @@
+package assettypes
+
+import "github.com/hyperledger-labs/cc-tools/assets"
+
+// Description of the loan of a book to a person. Loans are created and
+// closed by the borrowBook and returnBook transactions.
+var Loan = assets.AssetType{
+	Tag:         "loan",
+	Label:       "Loan",
+	Description: "Loan of a book to a person",
+
+	Props: []assets.AssetProp{
+		{
+			// Primary Key
+			Required: true,
+			IsKey:    true,
+			Tag:      "id",
+			Label:    "Loan ID",
+			DataType: "string",
+		},
+		{
+			Required: true,
+			ReadOnly: true,
+			Tag:      "book",
+			Label:    "Book",
+			DataType: "->book",
+		},
+		{
+			Required: true,
+			ReadOnly: true,
+			Tag:      "borrower",
+			Label:    "Borrower",
+			DataType: "->person",
+		},
+		{
+			Required: true,
+			ReadOnly: true,
+			Tag:      "borrowedAt",
+			Label:    "Borrow Date",
+			DataType: "datetime",
+		},
+		{
+			// After this date the active loan is overdue
+			Required: true,
+			ReadOnly: true,
+			Tag:      "dueDate",
+			Label:    "Due Date",
+			DataType: "datetime",
+		},
+		{
+			// Custom data type
+			Required:     true,
+			ReadOnly:     true,
+			Tag:          "status",
+			Label:        "Status",
+			DataType:     "loanStatus",
+			DefaultValue: "active",
+		},
+		{
+			ReadOnly: true,
+			Tag:      "returnedAt",
+			Label:    "Return Date",
+			DataType: "datetime",
+		},
+	},
+}
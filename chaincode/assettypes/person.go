@@ -49,5 +49,11 @@ var Person = assets.AssetType{
 			DefaultValue: 0,
 			DataType:     "number",
 		},
+		{
+			// Range proof commitment to the salary, which is not stored
+			Tag:      "salaryCommitment",
+			Label:    "Salary Commitment",
+			DataType: "string",
+		},
 	},
 }
@@ -0,0 +1,71 @@
+package assettypes
+
+import "github.com/hyperledger-labs/cc-tools/assets"
+
+// Description of a proposal submitted by an organization to be approved by
+// another one. Proposals are created and decided by the submitProposal,
+// approveProposal and rejectProposal transactions.
+var Proposal = assets.AssetType{
+	Tag:         "proposal",
+	Label:       "Proposal",
+	Description: "Proposal awaiting the approval of another organization",
+
+	Props: []assets.AssetProp{
+		{
+			// Primary Key, the id of the transaction that submitted it
+			Required: true,
+			IsKey:    true,
+			Tag:      "id",
+			Label:    "Proposal ID",
+			DataType: "string",
+		},
+		{
+			Required: true,
+			Tag:      "title",
+			Label:    "Title",
+			DataType: "string",
+		},
+		{
+			Tag:      "description",
+			Label:    "Description",
+			DataType: "string",
+		},
+		{
+			// MSP of the organization that submitted the proposal
+			Required: true,
+			ReadOnly: true,
+			Tag:      "proposer",
+			Label:    "Proposer",
+			DataType: "string",
+		},
+		{
+			// MSP of the organization that must approve the proposal
+			Required: true,
+			ReadOnly: true,
+			Tag:      "approver",
+			Label:    "Approver",
+			DataType: "string",
+		},
+		{
+			// Custom data type
+			Required:     true,
+			ReadOnly:     true,
+			Tag:          "status",
+			Label:        "Status",
+			DataType:     "proposalStatus",
+			DefaultValue: "pending",
+		},
+		{
+			ReadOnly: true,
+			Tag:      "reason",
+			Label:    "Decision Reason",
+			DataType: "string",
+		},
+		{
+			ReadOnly: true,
+			Tag:      "decidedAt",
+			Label:    "Decision Date",
+			DataType: "datetime",
+		},
+	},
+}
@@ -0,0 +1,50 @@
+package assettypes
+
+import "github.com/hyperledger-labs/cc-tools/assets"
+
+// Description of a fungible token. Balances are not kept in the asset but
+// in composite keys (balance~symbol~account) managed by the mint and
+// transfer transactions.
+var Token = assets.AssetType{
+	Tag:         "token",
+	Label:       "Token",
+	Description: "Fungible token, minted by the organization that created it",
+
+	Props: []assets.AssetProp{
+		{
+			// Primary Key
+			Required: true,
+			IsKey:    true,
+			Tag:      "symbol",
+			Label:    "Symbol",
+			DataType: "string",
+		},
+		{
+			Required: true,
+			Tag:      "name",
+			Label:    "Name",
+			DataType: "string",
+		},
+		{
+			Tag:          "decimals",
+			Label:        "Decimals",
+			DataType:     "integer",
+			DefaultValue: 0,
+		},
+		{
+			// MSP of the organization allowed to mint the token
+			Required: true,
+			ReadOnly: true,
+			Tag:      "minter",
+			Label:    "Minter",
+			DataType: "string",
+		},
+		{
+			ReadOnly:     true,
+			Tag:          "totalSupply",
+			Label:        "Total Supply",
+			DataType:     "integer",
+			DefaultValue: 0,
+		},
+	},
+}
@@ -0,0 +1,73 @@
+// Package brdoc validates Brazilian identification numbers with the same
+// rules as the cpf and cnpj data types of the chaincode. It only depends on
+// the standard library, so Go clients can check values before sending them.
+package brdoc
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrCPFLength is returned for CPFs without 11 digits
+	ErrCPFLength = errors.New("CPF must have 11 digits")
+	// ErrCPFInvalid is returned for CPFs with wrong check digits
+	ErrCPFInvalid = errors.New("Invalid CPF")
+	// ErrCNPJLength is returned for CNPJs without 14 digits
+	ErrCNPJLength = errors.New("CNPJ must have 14 digits")
+	// ErrCNPJInvalid is returned for CNPJs with wrong check digits
+	ErrCNPJInvalid = errors.New("Invalid CNPJ")
+)
+
+// ParseCPF validates a CPF, with or without its punctuation
+// (000.000.000-00), and returns its digits
+func ParseCPF(cpf string) (string, error) {
+	cpf = strings.NewReplacer(".", "", "-", "").Replace(cpf)
+	if len(cpf) != 11 || !onlyDigits(cpf) {
+		return "", ErrCPFLength
+	}
+
+	if checkDigit(cpf[:9], []int{10, 9, 8, 7, 6, 5, 4, 3, 2}) != cpf[9] ||
+		checkDigit(cpf[:10], []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}) != cpf[10] {
+		return "", ErrCPFInvalid
+	}
+	return cpf, nil
+}
+
+// ParseCNPJ validates a CNPJ, with or without its punctuation
+// (00.000.000/0000-00), and returns its digits
+func ParseCNPJ(cnpj string) (string, error) {
+	cnpj = strings.NewReplacer(".", "", "-", "", "/", "").Replace(cnpj)
+	if len(cnpj) != 14 || !onlyDigits(cnpj) {
+		return "", ErrCNPJLength
+	}
+
+	if checkDigit(cnpj[:12], []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) != cnpj[12] ||
+		checkDigit(cnpj[:13], []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) != cnpj[13] {
+		return "", ErrCNPJInvalid
+	}
+	return cnpj, nil
+}
+
+// checkDigit computes the modulo 11 check digit of the digits with the
+// weights
+func checkDigit(digits string, weights []int) byte {
+	sum := 0
+	for i, w := range weights {
+		sum += int(digits[i]-'0') * w
+	}
+	d := 11 - sum%11
+	if d > 9 {
+		d = 0
+	}
+	return byte('0' + d)
+}
+
+func onlyDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package datatypes
+
+import (
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes/brdoc"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// cnpj is the registration number of a Brazilian company, stored as digits
+var cnpj = assets.DataType{
+	AcceptedFormats: []string{"string"},
+	Description:     "CNPJ, with or without punctuation",
+	Parse: func(data interface{}) (string, interface{}, errors.ICCError) {
+		cnpj, ok := data.(string)
+		if !ok {
+			return "", nil, errors.NewCCError("property must be a string", 400)
+		}
+
+		cnpj, err := brdoc.ParseCNPJ(cnpj)
+		if err != nil {
+			return "", nil, errors.NewCCError(err.Error(), 400)
+		}
+
+		return cnpj, cnpj, nil
+	},
+}
@@ -6,6 +6,13 @@ import (
 
 // CustomDataTypes contain the user-defined primary data types
 var CustomDataTypes = map[string]assets.DataType{
-	"cpf":      cpf,
-	"bookType": bookType,
+	"cpf":            cpf,
+	"cnpj":           cnpj,
+	"bookType":       bookType,
+	"proposalStatus": proposalStatus,
+	"escrowStatus":   escrowStatus,
+	"loanStatus":     loanStatus,
+	"decimal":        decimal,
+	"money":          money,
+	"geopoint":       geopoint,
 }
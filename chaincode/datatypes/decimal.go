@@ -0,0 +1,64 @@
+package datatypes
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+var decimalRegexp = regexp.MustCompile(`^([+-]?)(\d+)(?:\.(\d+))?$`)
+
+// decimal is an exact decimal number, such as a currency value or an integer
+// beyond the precision of float64. It is stored as a canonical string,
+// without leading or trailing zeros, so it should be sent as a string:
+// numbers are parsed by the chaincode as float64 before reaching it.
+var decimal = assets.DataType{
+	AcceptedFormats: []string{"string", "number"},
+	Description:     "Exact decimal number, sent as a string",
+	Parse: func(data interface{}) (string, interface{}, errors.ICCError) {
+		var value string
+		switch v := data.(type) {
+		case string:
+			value = strings.TrimSpace(v)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return "", nil, errors.NewCCError("property must be a decimal string or a number", 400)
+		}
+
+		canonical, err := canonicalDecimal(value)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return canonical, canonical, nil
+	},
+}
+
+// canonicalDecimal removes the sign of zero and the leading and trailing
+// zeros of a decimal string
+func canonicalDecimal(value string) (string, errors.ICCError) {
+	match := decimalRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return "", errors.NewCCError("invalid decimal '"+value+"'", 400)
+	}
+
+	integer := strings.TrimLeft(match[2], "0")
+	if integer == "" {
+		integer = "0"
+	}
+	fraction := strings.TrimRight(match[3], "0")
+
+	canonical := integer
+	if fraction != "" {
+		canonical += "." + fraction
+	}
+	if match[1] == "-" && canonical != "0" {
+		canonical = "-" + canonical
+	}
+
+	return canonical, nil
+}
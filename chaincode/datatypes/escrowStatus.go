@@ -0,0 +1,37 @@
+package datatypes
+
+import (
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// Status of an escrow
+const (
+	EscrowStatusLocked    = "locked"
+	EscrowStatusConfirmed = "confirmed"
+	EscrowStatusReleased  = "released"
+)
+
+var escrowStatus = assets.DataType{
+	AcceptedFormats: []string{"string"},
+	DropDownValues: map[string]interface{}{
+		"Locked":    EscrowStatusLocked,
+		"Confirmed": EscrowStatusConfirmed,
+		"Released":  EscrowStatusReleased,
+	},
+	Description: `Status of an escrow: locked, confirmed or released`,
+
+	Parse: func(data interface{}) (string, interface{}, errors.ICCError) {
+		status, ok := data.(string)
+		if !ok {
+			return "", nil, errors.NewCCError("property must be a string", 400)
+		}
+
+		switch status {
+		case EscrowStatusLocked, EscrowStatusConfirmed, EscrowStatusReleased:
+			return status, status, nil
+		default:
+			return "", nil, errors.NewCCError("invalid escrow status", 400)
+		}
+	},
+}
@@ -0,0 +1,76 @@
+package datatypes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// geopoint is a location given by its latitude and longitude in decimal
+// degrees. It accepts objects such as {"lat": -16.68, "long": -49.25} or
+// strings such as "-16.68,-49.25", and is stored as an object.
+var geopoint = assets.DataType{
+	AcceptedFormats: []string{"@object", "string"},
+	Description:     "Latitude and longitude in decimal degrees, such as {\"lat\": -16.68, \"long\": -49.25}",
+	Parse: func(data interface{}) (string, interface{}, errors.ICCError) {
+		var lat, long interface{}
+		switch v := data.(type) {
+		case map[string]interface{}:
+			lat, long = v["lat"], v["long"]
+		case string:
+			parts := strings.Split(v, ",")
+			if len(parts) != 2 {
+				return "", nil, errors.NewCCError("geopoint must be a latitude and a longitude separated by a comma", 400)
+			}
+			lat, long = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		default:
+			return "", nil, errors.NewCCError("property must be an object with lat and long", 400)
+		}
+
+		latValue, err := coordinate("lat", lat, 90)
+		if err != nil {
+			return "", nil, err
+		}
+		longValue, err := coordinate("long", long, 180)
+		if err != nil {
+			return "", nil, err
+		}
+
+		key := strconv.FormatFloat(latValue, 'f', -1, 64) + "," + strconv.FormatFloat(longValue, 'f', -1, 64)
+		return key, map[string]interface{}{
+			"lat":  latValue,
+			"long": longValue,
+		}, nil
+	},
+}
+
+// coordinate parses a latitude or longitude, which must be within
+// [-max, max] degrees
+func coordinate(name string, value interface{}, max float64) (float64, errors.ICCError) {
+	var n float64
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case string:
+		var err error
+		n, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, errors.NewCCError(fmt.Sprintf("%s must be a number", name), 400)
+		}
+	default:
+		return 0, errors.NewCCError(fmt.Sprintf("%s must be a number", name), 400)
+	}
+
+	// NaN passes any range check and ParseFloat accepts "NaN" and "Inf"
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, errors.NewCCError(fmt.Sprintf("%s must be a number", name), 400)
+	}
+	if n < -max || n > max {
+		return 0, errors.NewCCError(fmt.Sprintf("%s must be between %g and %g", name, -max, max), 400)
+	}
+	return n, nil
+}
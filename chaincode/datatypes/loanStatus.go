@@ -0,0 +1,35 @@
+package datatypes
+
+import (
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// Status of a book loan. Active loans past their due date are overdue.
+const (
+	LoanStatusActive   = "active"
+	LoanStatusReturned = "returned"
+)
+
+var loanStatus = assets.DataType{
+	AcceptedFormats: []string{"string"},
+	DropDownValues: map[string]interface{}{
+		"Active":   LoanStatusActive,
+		"Returned": LoanStatusReturned,
+	},
+	Description: `Status of a book loan: active or returned`,
+
+	Parse: func(data interface{}) (string, interface{}, errors.ICCError) {
+		status, ok := data.(string)
+		if !ok {
+			return "", nil, errors.NewCCError("property must be a string", 400)
+		}
+
+		switch status {
+		case LoanStatusActive, LoanStatusReturned:
+			return status, status, nil
+		default:
+			return "", nil, errors.NewCCError("invalid loan status", 400)
+		}
+	},
+}
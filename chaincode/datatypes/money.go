@@ -0,0 +1,82 @@
+package datatypes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// currencyDigits are the ISO 4217 currencies accepted by money, with the
+// number of digits of their minor unit
+var currencyDigits = map[string]int{
+	"ARS": 2, "AUD": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"COP": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "INR": 2, "JPY": 0,
+	"KRW": 0, "KWD": 3, "MXN": 2, "NOK": 2, "NZD": 2, "PEN": 2, "PYG": 0,
+	"SEK": 2, "SGD": 2, "USD": 2, "UYU": 2, "ZAR": 2,
+}
+
+// money is an amount of a currency, such as a price or a fee. It accepts
+// objects such as {"amount": "12.5", "currency": "brl"} or strings such as
+// "12.5 BRL", and is stored as an object with the currency code in upper
+// case and the amount as a string with the digits of the minor unit of the
+// currency: {"amount": "12.50", "currency": "BRL"}. Amounts more precise than
+// the minor unit are rejected instead of rounded.
+var money = assets.DataType{
+	AcceptedFormats: []string{"@object", "string"},
+	Description:     "Amount of an ISO 4217 currency, such as {\"amount\": \"12.50\", \"currency\": \"BRL\"}",
+	Parse: func(data interface{}) (string, interface{}, errors.ICCError) {
+		var amount interface{}
+		var currency string
+		switch v := data.(type) {
+		case map[string]interface{}:
+			amount = v["amount"]
+			currency, _ = v["currency"].(string)
+		case string:
+			parts := strings.Fields(v)
+			if len(parts) != 2 {
+				return "", nil, errors.NewCCError("money must be an amount followed by a currency code", 400)
+			}
+			amount, currency = parts[0], parts[1]
+		default:
+			return "", nil, errors.NewCCError("property must be an object with amount and currency", 400)
+		}
+
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		digits, ok := currencyDigits[currency]
+		if !ok {
+			return "", nil, errors.NewCCError(fmt.Sprintf("unsupported currency '%s'", currency), 400)
+		}
+
+		var value string
+		switch a := amount.(type) {
+		case string:
+			value = strings.TrimSpace(a)
+		case float64:
+			value = strconv.FormatFloat(a, 'f', -1, 64)
+		default:
+			return "", nil, errors.NewCCError("amount must be a decimal string or a number", 400)
+		}
+
+		canonical, err := canonicalDecimal(value)
+		if err != nil {
+			return "", nil, err
+		}
+
+		// Pad the fraction to the minor unit of the currency
+		integer, fraction, _ := strings.Cut(canonical, ".")
+		if len(fraction) > digits {
+			return "", nil, errors.NewCCError(fmt.Sprintf("%s amounts have at most %d decimal places", currency, digits), 400)
+		}
+		if digits > 0 {
+			canonical = integer + "." + fraction + strings.Repeat("0", digits-len(fraction))
+		}
+
+		return canonical + " " + currency, map[string]interface{}{
+			"amount":   canonical,
+			"currency": currency,
+		}, nil
+	},
+}
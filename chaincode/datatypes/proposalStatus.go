@@ -0,0 +1,37 @@
+package datatypes
+
+import (
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// Status of a proposal in the approval workflow
+const (
+	ProposalStatusPending  = "pending"
+	ProposalStatusApproved = "approved"
+	ProposalStatusRejected = "rejected"
+)
+
+var proposalStatus = assets.DataType{
+	AcceptedFormats: []string{"string"},
+	DropDownValues: map[string]interface{}{
+		"Pending":  ProposalStatusPending,
+		"Approved": ProposalStatusApproved,
+		"Rejected": ProposalStatusRejected,
+	},
+	Description: `Status of a proposal: pending, approved or rejected`,
+
+	Parse: func(data interface{}) (string, interface{}, errors.ICCError) {
+		status, ok := data.(string)
+		if !ok {
+			return "", nil, errors.NewCCError("property must be a string", 400)
+		}
+
+		switch status {
+		case ProposalStatusPending, ProposalStatusApproved, ProposalStatusRejected:
+			return status, status, nil
+		default:
+			return "", nil, errors.NewCCError("invalid proposal status", 400)
+		}
+	},
+}
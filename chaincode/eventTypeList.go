@@ -7,4 +7,7 @@ import (
 
 var eventTypeList = []events.Event{
 	eventtypes.CreateLibraryLog,
+	eventtypes.BookBorrowedLog,
+	eventtypes.BookReturnedLog,
+	eventtypes.BookReservedLog,
 }
@@ -0,0 +1,33 @@
+package eventtypes
+
+import "github.com/hyperledger-labs/cc-tools/events"
+
+// BookBorrowedLog is a log to be emitted on the CCAPI when a book is borrowed
+var BookBorrowedLog = events.Event{
+	Tag:         "bookBorrowedLog",
+	Label:       "Book Borrowed Log",
+	Description: "Log of a book loan",
+	Type:        events.EventLog,
+	BaseLog:     "Book borrowed",
+	Receivers:   []string{`$org\dMSP`, "$orgMSP"},
+}
+
+// BookReturnedLog is a log to be emitted on the CCAPI when a book is returned
+var BookReturnedLog = events.Event{
+	Tag:         "bookReturnedLog",
+	Label:       "Book Returned Log",
+	Description: "Log of the return of a borrowed book",
+	Type:        events.EventLog,
+	BaseLog:     "Book returned",
+	Receivers:   []string{`$org\dMSP`, "$orgMSP"},
+}
+
+// BookReservedLog is a log to be emitted on the CCAPI when a book is reserved
+var BookReservedLog = events.Event{
+	Tag:         "bookReservedLog",
+	Label:       "Book Reserved Log",
+	Description: "Log of a reservation of a borrowed book",
+	Type:        events.EventLog,
+	BaseLog:     "Book reserved",
+	Receivers:   []string{`$org\dMSP`, "$orgMSP"},
+}
@@ -7,11 +7,36 @@ import (
 )
 
 var txList = []tx.Transaction{
-	tx.CreateAsset,
-	tx.UpdateAsset,
-	tx.DeleteAsset,
+	txdefs.CreateAsset,
+	txdefs.UpdateAsset,
+	txdefs.DeleteAsset,
 	txdefs.CreateNewLibrary,
 	txdefs.GetNumberOfBooksFromLibrary,
 	txdefs.UpdateBookTenant,
 	txdefs.GetBooksByAuthor,
+	txdefs.SubmitProposal,
+	txdefs.ApproveProposal,
+	txdefs.RejectProposal,
+	txdefs.Mint,
+	txdefs.Transfer,
+	txdefs.BalanceOf,
+	txdefs.MintCollectible,
+	txdefs.UpdateCollectible,
+	txdefs.TransferOwnership,
+	txdefs.LockEscrow,
+	txdefs.ConfirmEscrow,
+	txdefs.ReleaseEscrow,
+	txdefs.SetEndorsementPolicy,
+	txdefs.GetEndorsementPolicy,
+	txdefs.ReadPrivateAsset,
+	txdefs.PurgePrivateAsset,
+	txdefs.ValidateValues,
+	txdefs.BorrowBook,
+	txdefs.ReturnBook,
+	txdefs.ListOverdueLoans,
+	txdefs.ReserveBook,
+	txdefs.CancelReservation,
+	txdefs.ListReservations,
+	txdefs.AddBookCopies,
+	txdefs.GetBookCopies,
 }
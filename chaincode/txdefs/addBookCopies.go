@@ -0,0 +1,105 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Add copies of a book to the inventory, or remove available ones
+// POST Method
+var AddBookCopies = tx.Transaction{
+	Tag:         "addBookCopies",
+	Label:       "Add Book Copies",
+	Description: "Add copies of a book to its inventory, or remove available copies with a negative amount",
+	Method:      "POST",
+	Callers: []accesscontrol.Caller{ // Only org2, which writes the books, can call this transaction
+		{MSP: "org2MSP"},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "book",
+			Label:       "Book",
+			Description: "Book of the copies",
+			DataType:    "->book",
+			Required:    true,
+		},
+		{
+			Tag:         "amount",
+			Label:       "Amount",
+			Description: "Number of copies added, negative to remove available copies",
+			DataType:    "integer",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		bookKey, ok := req["book"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter book must be an asset")
+		}
+		amount, _ := req["amount"].(int64)
+		if amount == 0 {
+			return nil, errors.NewCCError("amount must not be zero", 400)
+		}
+		book := bookKey.Key()
+
+		// Returns Book from channel
+		bookMap, err := bookKey.GetMap(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+		}
+
+		var copies int64
+		if hasInventory(bookMap) {
+			copies = int64(bookMap["copies"].(float64))
+		} else {
+			// The single copy must be in the library to start the inventory
+			if bookMap["currentLoan"] != nil {
+				return nil, errors.NewCCError("book is borrowed", 409)
+			}
+			holds, err := getHolds(stub, book)
+			if err != nil {
+				return nil, err
+			}
+			if len(holds) > 0 {
+				return nil, errors.NewCCError("book is held for a reservation", 409)
+			}
+
+			// The single copy is the first of the inventory
+			copies = 1
+			err = addCopies(stub, book, 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if amount > 0 {
+			err = addCopies(stub, book, amount)
+		} else {
+			err = takeCopies(stub, book, -amount)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		bookMap["copies"] = float64(copies + amount)
+		bookMap, err = putAsset(stub, bookMap)
+		if err != nil {
+			return nil, err
+		}
+
+		// Marshal asset back to JSON format
+		bookJSON, nerr := json.Marshal(bookMap)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+		}
+
+		return bookJSON, nil
+	},
+}
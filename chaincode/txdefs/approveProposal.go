@@ -0,0 +1,102 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Approve a pending proposal
+// PUT Method
+var ApproveProposal = tx.Transaction{
+	Tag:         "approveProposal",
+	Label:       "Approve Proposal",
+	Description: "Approve a pending proposal, only allowed to its approver",
+	Method:      "PUT",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "proposal",
+			Label:       "Proposal",
+			Description: "Proposal to be approved",
+			DataType:    "->proposal",
+			Required:    true,
+		},
+		{
+			Tag:         "reason",
+			Label:       "Reason",
+			Description: "Reason of the decision",
+			DataType:    "string",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		return decideProposal(stub, req, datatypes.ProposalStatusApproved)
+	},
+}
+
+// decideProposal sets the status of a pending proposal, which can only be
+// decided by the organization chosen as its approver
+func decideProposal(stub *sw.StubWrapper, req map[string]interface{}, status string) ([]byte, errors.ICCError) {
+	proposalKey, ok := req["proposal"].(assets.Key)
+	if !ok {
+		return nil, errors.WrapError(nil, "Parameter proposal must be an asset")
+	}
+
+	// Returns Proposal from channel
+	proposalAsset, err := proposalKey.Get(stub)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+	}
+	proposalMap := (map[string]interface{})(*proposalAsset)
+
+	caller, err := stub.GetMSPID()
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to get caller MSP")
+	}
+	if proposalMap["approver"] != caller {
+		return nil, errors.NewCCError(fmt.Sprintf("%s is not the approver of the proposal", caller), 403)
+	}
+	if proposalMap["status"] != datatypes.ProposalStatusPending {
+		return nil, errors.NewCCError(fmt.Sprintf("proposal is already %s", proposalMap["status"]), 409)
+	}
+
+	txTimestamp, nerr := stub.Stub.GetTxTimestamp()
+	if nerr != nil {
+		return nil, errors.WrapError(nerr, "failed to get transaction timestamp")
+	}
+
+	// Update data
+	proposalMap["status"] = status
+	proposalMap["decidedAt"] = txTimestamp.AsTime().Format(time.RFC3339)
+	if reason, ok := req["reason"]; ok {
+		proposalMap["reason"] = reason
+	}
+
+	decided, err := assets.NewAsset(proposalMap)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to update asset")
+	}
+	proposalMap, err = decided.Put(stub)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+	}
+
+	// Marshal asset back to JSON format
+	proposalJSON, nerr := json.Marshal(proposalMap)
+	if nerr != nil {
+		return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+	}
+
+	return proposalJSON, nil
+}
@@ -0,0 +1,67 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Return the token balance of an account
+// GET Method
+var BalanceOf = tx.Transaction{
+	Tag:         "balanceOf",
+	Label:       "Balance Of",
+	Description: "Return the token balance of an account, by default the caller",
+	Method:      "GET",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "symbol",
+			Label:       "Symbol",
+			Description: "Symbol of the token",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "account",
+			Label:       "Account",
+			Description: "Account (MSP) whose balance is returned",
+			DataType:    "string",
+		},
+	},
+	ReadOnly: true,
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		symbol, _ := req["symbol"].(string)
+		account, ok := req["account"].(string)
+		if !ok {
+			var err errors.ICCError
+			account, err = stub.GetMSPID()
+			if err != nil {
+				return nil, errors.WrapError(err, "failed to get caller MSP")
+			}
+		}
+
+		balance, err := getBalance(stub, symbol, account)
+		if err != nil {
+			return nil, err
+		}
+
+		responseJSON, nerr := json.Marshal(map[string]interface{}{
+			"symbol":  symbol,
+			"account": account,
+			"balance": balance,
+		})
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to marshal response")
+		}
+
+		return responseJSON, nil
+	},
+}
@@ -0,0 +1,173 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Lend a book to a person until the due date
+// POST Method
+var BorrowBook = tx.Transaction{
+	Tag:         "borrowBook",
+	Label:       "Borrow Book",
+	Description: "Lend an available copy of a book to a person until the due date, making the person the tenant of single copy books",
+	Method:      "POST",
+	Callers: []accesscontrol.Caller{ // Only org2, which writes the books, can call this transaction
+		{MSP: "org2MSP"},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "id",
+			Label:       "Loan ID",
+			Description: "Unique identifier of the loan",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "book",
+			Label:       "Book",
+			Description: "Book to be borrowed",
+			DataType:    "->book",
+			Required:    true,
+		},
+		{
+			Tag:         "borrower",
+			Label:       "Borrower",
+			Description: "Person borrowing the book",
+			DataType:    "->person",
+			Required:    true,
+		},
+		{
+			Tag:         "dueDate",
+			Label:       "Due Date",
+			Description: "Date by which the book must be returned",
+			DataType:    "datetime",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		bookKey, ok := req["book"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter book must be an asset")
+		}
+		borrowerKey, ok := req["borrower"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter borrower must be an asset")
+		}
+		dueDate, _ := req["dueDate"].(time.Time)
+
+		now, err := txTime(stub)
+		if err != nil {
+			return nil, err
+		}
+		if !dueDate.After(now) {
+			return nil, errors.NewCCError("dueDate must be in the future", 400)
+		}
+
+		// Returns Book from channel
+		bookMap, err := bookKey.GetMap(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+		}
+		if !hasInventory(bookMap) && bookMap["currentLoan"] != nil {
+			return nil, errors.NewCCError("book is already borrowed", 409)
+		}
+
+		exists, err := borrowerKey.ExistsInLedger(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read asset from the ledger")
+		}
+		if !exists {
+			return nil, errors.NewCCError("borrower not found", 404)
+		}
+		borrowerRef := personRef(borrowerKey.Key())
+
+		book, borrower := bookKey.Key(), borrowerKey.Key()
+
+		// A copy returned while reserved can only be borrowed by the person
+		// it is held for
+		hold, err := getHold(stub, book, borrower)
+		if err != nil {
+			return nil, err
+		}
+		if hold != nil {
+			err = deleteHold(stub, book, borrower)
+			if err != nil {
+				return nil, err
+			}
+		} else if hasInventory(bookMap) {
+			err = takeCopies(stub, book, 1)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			holds, err := getHolds(stub, book)
+			if err != nil {
+				return nil, err
+			}
+			if len(holds) > 0 {
+				return nil, errors.NewCCError("book is held for another person", 409)
+			}
+		}
+
+		loanMap := make(map[string]interface{})
+		loanMap["@assetType"] = "loan"
+		loanMap["id"] = req["id"]
+		loanMap["book"] = map[string]interface{}{
+			"@assetType": "book",
+			"@key":       bookMap["@key"],
+		}
+		loanMap["borrower"] = borrowerRef
+		loanMap["borrowedAt"] = now.Format(time.RFC3339)
+		loanMap["dueDate"] = dueDate.UTC().Format(time.RFC3339)
+		loanMap["status"] = datatypes.LoanStatusActive
+
+		loanAsset, err := assets.NewAsset(loanMap)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to create a new asset")
+		}
+
+		// Save the new loan on channel, failing if the id is taken
+		loanMap, err = loanAsset.PutNew(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+		}
+
+		// The borrower is the tenant of a single copy book until it is
+		// returned. Books with an inventory are not written, so their loans
+		// do not conflict.
+		if !hasInventory(bookMap) {
+			bookMap["currentTenant"] = borrowerRef
+			bookMap["currentLoan"] = map[string]interface{}{
+				"@assetType": "loan",
+				"@key":       loanAsset.Key(),
+			}
+			_, err = putAsset(stub, bookMap)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = loanLog(stub, "bookBorrowedLog", loanMap)
+		if err != nil {
+			return nil, err
+		}
+
+		// Marshal asset back to JSON format
+		loanJSON, nerr := json.Marshal(loanMap)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+		}
+
+		return loanJSON, nil
+	},
+}
@@ -0,0 +1,100 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Cancel the reservation of a book by a person
+// DELETE Method
+var CancelReservation = tx.Transaction{
+	Tag:         "cancelReservation",
+	Label:       "Cancel Reservation",
+	Description: "Remove a person from the reservation queue of a book, passing the copy held for the person to the next person in the queue",
+	Method:      "DELETE",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "book",
+			Label:       "Book",
+			Description: "Reserved book",
+			DataType:    "->book",
+			Required:    true,
+		},
+		{
+			Tag:         "person",
+			Label:       "Person",
+			Description: "Person cancelling the reservation",
+			DataType:    "->person",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		bookKey, ok := req["book"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter book must be an asset")
+		}
+		personKey, ok := req["person"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter person must be an asset")
+		}
+		book, person := bookKey.Key(), personKey.Key()
+
+		response := map[string]interface{}{
+			"book":   map[string]interface{}{"@assetType": "book", "@key": book},
+			"person": personRef(person),
+		}
+
+		hold, err := getHold(stub, book, person)
+		if err != nil {
+			return nil, err
+		}
+		if hold != nil {
+			// The held copy is available, so it is held for the next person
+			err = deleteHold(stub, book, person)
+			if err != nil {
+				return nil, err
+			}
+			next, err := fulfilNextReservation(stub, book)
+			if err != nil {
+				return nil, err
+			}
+			if next != nil {
+				response["hold"] = next
+			} else {
+				err = releaseCopy(stub, bookKey)
+				if err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			ticket, err := getReservationTicket(stub, book, person)
+			if err != nil {
+				return nil, err
+			}
+			if ticket == "" {
+				return nil, errors.NewCCError("person has no reservation of the book", 404)
+			}
+			err = deleteReservation(stub, book, person, ticket)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		responseJSON, nerr := json.Marshal(response)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode response to JSON format")
+		}
+
+		return responseJSON, nil
+	},
+}
@@ -0,0 +1,58 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// getOwnedCollectible returns the collectible of the request, checking that
+// it is owned by the caller and not locked in escrow
+func getOwnedCollectible(stub *sw.StubWrapper, req map[string]interface{}) (map[string]interface{}, errors.ICCError) {
+	collectibleKey, ok := req["collectible"].(assets.Key)
+	if !ok {
+		return nil, errors.WrapError(nil, "Parameter collectible must be an asset")
+	}
+
+	// Returns Collectible from channel
+	collectibleMap, err := collectibleKey.GetMap(stub)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+	}
+
+	caller, err := stub.GetMSPID()
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to get caller MSP")
+	}
+	if collectibleMap["owner"] != caller {
+		return nil, errors.NewCCError(fmt.Sprintf("%s is not the owner of the collectible", caller), 403)
+	}
+	if _, locked := collectibleMap["escrow"]; locked {
+		return nil, errors.NewCCError("collectible is locked in escrow", 409)
+	}
+
+	return collectibleMap, nil
+}
+
+// putCollectible writes the collectible and returns it as JSON
+func putCollectible(stub *sw.StubWrapper, collectibleMap map[string]interface{}) ([]byte, errors.ICCError) {
+	collectibleAsset, err := assets.NewAsset(collectibleMap)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to create collectible asset")
+	}
+	collectibleMap, err = collectibleAsset.Put(stub)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+	}
+
+	// Marshal asset back to JSON format
+	collectibleJSON, nerr := json.Marshal(collectibleMap)
+	if nerr != nil {
+		return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+	}
+
+	return collectibleJSON, nil
+}
@@ -0,0 +1,58 @@
+package txdefs
+
+import (
+	"fmt"
+
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Confirm an escrow, transferring its collectible to the buyer
+// PUT Method
+var ConfirmEscrow = tx.Transaction{
+	Tag:         "confirmEscrow",
+	Label:       "Confirm Escrow",
+	Description: "Confirm a locked escrow before it expires, transferring its collectible to the buyer, only allowed to the buyer",
+	Method:      "PUT",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "escrow",
+			Label:       "Escrow",
+			Description: "Escrow to be confirmed",
+			DataType:    "->escrow",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		escrowMap, expiresAt, err := getLockedEscrow(stub, req)
+		if err != nil {
+			return nil, err
+		}
+
+		caller, err := stub.GetMSPID()
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get caller MSP")
+		}
+		if escrowMap["buyer"] != caller {
+			return nil, errors.NewCCError(fmt.Sprintf("%s is not the buyer of the escrow", caller), 403)
+		}
+
+		now, err := txTime(stub)
+		if err != nil {
+			return nil, err
+		}
+		if !now.Before(expiresAt) {
+			return nil, errors.NewCCError("escrow has expired", 409)
+		}
+
+		return settleEscrow(stub, escrowMap, datatypes.EscrowStatusConfirmed, caller)
+	},
+}
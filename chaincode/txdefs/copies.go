@@ -0,0 +1,145 @@
+package txdefs
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// The available copies of a book with an inventory are counted in shards
+// instead of in the book asset, so that concurrent loans of the book write
+// different keys and do not fail with MVCC conflicts:
+//
+//	copies~{book}~{shard} holds part of the available copies of the book
+//
+// Every transaction starts at the shard picked by its transaction id, and
+// the available copies are the sum of all shards.
+const (
+	copiesObjectType = "copies"
+	copyShards       = 8
+)
+
+// txShard returns the shard of the counters the transaction starts at
+func txShard(stub *sw.StubWrapper) int {
+	h := fnv.New32a()
+	h.Write([]byte(stub.Stub.GetTxID()))
+	return int(h.Sum32() % copyShards)
+}
+
+// hasInventory reports whether the copies of the book are counted, instead
+// of the book being a single copy lent through its currentLoan
+func hasInventory(bookMap map[string]interface{}) bool {
+	return bookMap["copies"] != nil
+}
+
+// getShard returns the copies counted in the shard of the book
+func getShard(stub *sw.StubWrapper, book string, shard int) (string, int64, errors.ICCError) {
+	key, err := stub.CreateCompositeKey(copiesObjectType, []string{book, strconv.Itoa(shard)})
+	if err != nil {
+		return "", 0, errors.WrapError(err, "failed to create copies key")
+	}
+
+	countBytes, err := stub.GetState(key)
+	if err != nil {
+		return "", 0, errors.WrapError(err, "failed to read copies")
+	}
+	if countBytes == nil {
+		return key, 0, nil
+	}
+
+	count, nerr := strconv.ParseInt(string(countBytes), 10, 64)
+	if nerr != nil {
+		return "", 0, errors.WrapError(nerr, "invalid copies count")
+	}
+	return key, count, nil
+}
+
+// putShard sets the copies counted in the shard with the key
+func putShard(stub *sw.StubWrapper, key string, count int64) errors.ICCError {
+	err := stub.PutState(key, []byte(strconv.FormatInt(count, 10)))
+	if err != nil {
+		return errors.WrapError(err, "failed to write copies")
+	}
+	return nil
+}
+
+// addCopies adds available copies of the book to the shard of the
+// transaction
+func addCopies(stub *sw.StubWrapper, book string, amount int64) errors.ICCError {
+	key, count, err := getShard(stub, book, txShard(stub))
+	if err != nil {
+		return err
+	}
+	return putShard(stub, key, count+amount)
+}
+
+// releaseCopy makes a held copy of the book available again, if the copies
+// of the book are counted
+func releaseCopy(stub *sw.StubWrapper, bookKey assets.Key) errors.ICCError {
+	bookMap, err := bookKey.GetMap(stub)
+	if err != nil {
+		return errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+	}
+	if !hasInventory(bookMap) {
+		return nil
+	}
+	return addCopies(stub, bookKey.Key(), 1)
+}
+
+// takeCopies removes available copies of the book, starting at the shard of
+// the transaction and moving to the next shards only when it runs out. It
+// fails with 409 if there are not enough copies available.
+func takeCopies(stub *sw.StubWrapper, book string, amount int64) errors.ICCError {
+	start := txShard(stub)
+	for i := 0; i < copyShards && amount > 0; i++ {
+		key, count, err := getShard(stub, book, (start+i)%copyShards)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			continue
+		}
+
+		taken := count
+		if taken > amount {
+			taken = amount
+		}
+		err = putShard(stub, key, count-taken)
+		if err != nil {
+			return err
+		}
+		amount -= taken
+	}
+
+	if amount > 0 {
+		return errors.NewCCError("not enough copies available", 409)
+	}
+	return nil
+}
+
+// availableCopies returns the available copies of the book, summing all of
+// its shards
+func availableCopies(stub *sw.StubWrapper, book string) (int64, errors.ICCError) {
+	it, err := stub.GetStateByPartialCompositeKey(copiesObjectType, []string{book})
+	if err != nil {
+		return 0, errors.WrapError(err, "failed to read copies")
+	}
+	defer it.Close()
+
+	var total int64
+	for it.HasNext() {
+		kv, nerr := it.Next()
+		if nerr != nil {
+			return 0, errors.WrapError(nerr, "failed to read copies")
+		}
+		count, nerr := strconv.ParseInt(string(kv.Value), 10, 64)
+		if nerr != nil {
+			return 0, errors.WrapError(nerr, "invalid copies count")
+		}
+		total += count
+	}
+	return total, nil
+}
@@ -0,0 +1,91 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+)
+
+// getEndorsers returns the organizations whose peers must endorse changes to
+// the key, empty when the chaincode endorsement policy applies
+func getEndorsers(stub *sw.StubWrapper, key string) ([]string, errors.ICCError) {
+	ep, err := stub.Stub.GetStateValidationParameter(key)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to get key endorsement policy")
+	}
+	if ep == nil {
+		return []string{}, nil
+	}
+
+	policy, err := statebased.NewStateEP(ep)
+	if err != nil {
+		return nil, errors.WrapError(err, "invalid key endorsement policy")
+	}
+	orgs := policy.ListOrgs()
+	sort.Strings(orgs)
+	return orgs, nil
+}
+
+// setEndorsers requires the endorsement of the peers of every organization
+// for changes to the key, falling back to the chaincode endorsement policy
+// when there are none
+func setEndorsers(stub *sw.StubWrapper, key string, orgs []string) errors.ICCError {
+	if len(orgs) == 0 {
+		err := stub.Stub.SetStateValidationParameter(key, nil)
+		if err != nil {
+			return errors.WrapError(err, "failed to remove key endorsement policy")
+		}
+		return nil
+	}
+
+	policy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return errors.WrapError(err, "failed to create key endorsement policy")
+	}
+	err = policy.AddOrgs(statebased.RoleTypePeer, orgs...)
+	if err != nil {
+		return errors.WrapError(err, "failed to add organizations to key endorsement policy")
+	}
+	ep, err := policy.Policy()
+	if err != nil {
+		return errors.WrapError(err, "failed to marshal key endorsement policy")
+	}
+
+	err = stub.Stub.SetStateValidationParameter(key, ep)
+	if err != nil {
+		return errors.WrapError(err, "failed to set key endorsement policy")
+	}
+	return nil
+}
+
+// transferEndorsement replaces the previous owner of the key by the new one
+// among its endorsers, if the key has its own endorsement policy
+func transferEndorsement(stub *sw.StubWrapper, key, from, to string) errors.ICCError {
+	orgs, err := getEndorsers(stub, key)
+	if err != nil || len(orgs) == 0 {
+		return err
+	}
+
+	newOrgs := []string{to}
+	for _, org := range orgs {
+		if org != from && org != to {
+			newOrgs = append(newOrgs, org)
+		}
+	}
+	return setEndorsers(stub, key, newOrgs)
+}
+
+// endorsementJSON returns the endorsers of the key as JSON
+func endorsementJSON(key string, orgs []string) ([]byte, errors.ICCError) {
+	policyJSON, err := json.Marshal(map[string]interface{}{
+		"@key": key,
+		"orgs": orgs,
+	})
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to encode endorsement policy to JSON format")
+	}
+	return policyJSON, nil
+}
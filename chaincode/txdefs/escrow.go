@@ -0,0 +1,105 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// getLockedEscrow returns the escrow of the request, checking that it was
+// not settled yet, and the expiration date of the escrow
+func getLockedEscrow(stub *sw.StubWrapper, req map[string]interface{}) (map[string]interface{}, time.Time, errors.ICCError) {
+	escrowKey, ok := req["escrow"].(assets.Key)
+	if !ok {
+		return nil, time.Time{}, errors.WrapError(nil, "Parameter escrow must be an asset")
+	}
+
+	// Returns Escrow from channel
+	escrowMap, err := escrowKey.GetMap(stub)
+	if err != nil {
+		return nil, time.Time{}, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+	}
+	if escrowMap["status"] != datatypes.EscrowStatusLocked {
+		return nil, time.Time{}, errors.NewCCError(fmt.Sprintf("escrow is already %s", escrowMap["status"]), 409)
+	}
+
+	expiresAtStr, _ := escrowMap["expiresAt"].(string)
+	expiresAt, nerr := time.Parse(time.RFC3339, expiresAtStr)
+	if nerr != nil {
+		return nil, time.Time{}, errors.WrapError(nerr, "invalid escrow expiration date")
+	}
+
+	return escrowMap, expiresAt, nil
+}
+
+// txTime returns the timestamp of the transaction
+func txTime(stub *sw.StubWrapper) (time.Time, errors.ICCError) {
+	txTimestamp, err := stub.Stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, errors.WrapError(err, "failed to get transaction timestamp")
+	}
+	return txTimestamp.AsTime(), nil
+}
+
+// settleEscrow unlocks the collectible of the escrow, transferring it to the
+// new owner if any, and sets the final status of the escrow
+func settleEscrow(stub *sw.StubWrapper, escrowMap map[string]interface{}, status, newOwner string) ([]byte, errors.ICCError) {
+	now, err := txTime(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	collectibleRef, ok := escrowMap["collectible"].(map[string]interface{})
+	if !ok {
+		return nil, errors.WrapError(nil, "escrow has no collectible")
+	}
+	collectibleKey, err := assets.NewKey(collectibleRef)
+	if err != nil {
+		return nil, errors.WrapError(err, "invalid collectible reference")
+	}
+	collectibleMap, err := collectibleKey.GetMap(stub)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+	}
+
+	// Unlock the collectible
+	delete(collectibleMap, "escrow")
+	if newOwner != "" {
+		key, _ := collectibleMap["@key"].(string)
+		err = transferEndorsement(stub, key, collectibleMap["owner"].(string), newOwner)
+		if err != nil {
+			return nil, err
+		}
+		collectibleMap["owner"] = newOwner
+	}
+	_, err = putCollectible(stub, collectibleMap)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update data
+	escrowMap["status"] = status
+	escrowMap["settledAt"] = now.Format(time.RFC3339)
+
+	settled, err := assets.NewAsset(escrowMap)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to update asset")
+	}
+	escrowMap, err = settled.Put(stub)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+	}
+
+	// Marshal asset back to JSON format
+	escrowJSON, nerr := json.Marshal(escrowMap)
+	if nerr != nil {
+		return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+	}
+
+	return escrowJSON, nil
+}
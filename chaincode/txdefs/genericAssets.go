@@ -0,0 +1,70 @@
+package txdefs
+
+import (
+	"fmt"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// managedAssetTypes are only written by their own transactions, which check
+// who owns, mints, approves, settles or lends them. The generic transactions would
+// let any organization forge, overwrite or delete them.
+var managedAssetTypes = map[string]string{
+	"proposal":    "submitProposal, approveProposal and rejectProposal",
+	"token":       "mint and transfer",
+	"collectible": "mintCollectible, updateCollectible and transferOwnership",
+	"escrow":      "lockEscrow, confirmEscrow and releaseEscrow",
+	"loan":        "borrowBook and returnBook",
+}
+
+// checkGeneric refuses assets of the managed asset types
+func checkGeneric(assetType string) errors.ICCError {
+	if txs, ok := managedAssetTypes[assetType]; ok {
+		return errors.NewCCError(fmt.Sprintf("%s assets can only be written by %s", assetType, txs), 403)
+	}
+	return nil
+}
+
+// guardGeneric returns the generic transaction refusing the assets the
+// check rejects before running its routine
+func guardGeneric(t tx.Transaction, check func(req map[string]interface{}) errors.ICCError) tx.Transaction {
+	routine := t.Routine
+	t.Routine = func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		if err := check(req); err != nil {
+			return nil, err
+		}
+		return routine(stub, req)
+	}
+	return t
+}
+
+// CreateAsset is the generic createAsset, refusing the managed asset types
+var CreateAsset = guardGeneric(tx.CreateAsset, func(req map[string]interface{}) errors.ICCError {
+	assetList, _ := req["asset"].([]interface{})
+	for _, assetInterface := range assetList {
+		asset, _ := assetInterface.(assets.Asset)
+		if err := checkGeneric(asset.TypeTag()); err != nil {
+			return err
+		}
+	}
+	return nil
+})
+
+// UpdateAsset is the generic updateAsset, refusing the managed asset types
+var UpdateAsset = guardGeneric(tx.UpdateAsset, func(req map[string]interface{}) errors.ICCError {
+	request, _ := req["update"].(map[string]interface{})
+	key, err := assets.NewKey(request)
+	if err != nil {
+		return errors.WrapErrorWithStatus(err, "failed to get the key of the asset", err.Status())
+	}
+	return checkGeneric(key.TypeTag())
+})
+
+// DeleteAsset is the generic deleteAsset, refusing the managed asset types
+var DeleteAsset = guardGeneric(tx.DeleteAsset, func(req map[string]interface{}) errors.ICCError {
+	key, _ := req["key"].(assets.Key)
+	return checkGeneric(key.TypeTag())
+})
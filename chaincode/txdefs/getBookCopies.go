@@ -0,0 +1,79 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Return the copies of a book in the inventory
+// GET Method
+var GetBookCopies = tx.Transaction{
+	Tag:         "getBookCopies",
+	Label:       "Get Book Copies",
+	Description: "Return the number of copies of a book and how many of them are available",
+	Method:      "GET",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "book",
+			Label:       "Book",
+			Description: "Book of the copies",
+			DataType:    "->book",
+			Required:    true,
+		},
+	},
+	ReadOnly: true,
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		bookKey, ok := req["book"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter book must be an asset")
+		}
+
+		// Returns Book from channel
+		bookMap, err := bookKey.GetMap(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+		}
+
+		var copies, available interface{}
+		if hasInventory(bookMap) {
+			copies = bookMap["copies"]
+			available, err = availableCopies(stub, bookKey.Key())
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			// A single copy book is available unless borrowed or held
+			holds, err := getHolds(stub, bookKey.Key())
+			if err != nil {
+				return nil, err
+			}
+			copies, available = 1, 0
+			if bookMap["currentLoan"] == nil && len(holds) == 0 {
+				available = 1
+			}
+		}
+
+		response := map[string]interface{}{
+			"book":      map[string]interface{}{"@assetType": "book", "@key": bookKey.Key()},
+			"copies":    copies,
+			"available": available,
+		}
+
+		responseJSON, nerr := json.Marshal(response)
+		if nerr != nil {
+			return nil, errors.WrapErrorWithStatus(nerr, "error marshaling response", 500)
+		}
+
+		return responseJSON, nil
+	},
+}
@@ -0,0 +1,53 @@
+package txdefs
+
+import (
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Return the organizations that must endorse changes to an asset
+// GET Method
+var GetEndorsementPolicy = tx.Transaction{
+	Tag:         "getEndorsementPolicy",
+	Label:       "Get Endorsement Policy",
+	Description: "Return the organizations that must endorse changes to the asset, none if the chaincode endorsement policy applies",
+	Method:      "GET",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "key",
+			Label:       "Key",
+			Description: "Key of the asset",
+			DataType:    "@key",
+			Required:    true,
+		},
+	},
+	ReadOnly: true,
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		key, ok := req["key"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter key must be an asset")
+		}
+
+		exists, err := key.ExistsInLedger(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read asset from the ledger")
+		}
+		if !exists {
+			return nil, errors.NewCCError("asset not found", 404)
+		}
+
+		orgs, err := getEndorsers(stub, key.Key())
+		if err != nil {
+			return nil, err
+		}
+		return endorsementJSON(key.Key(), orgs)
+	},
+}
@@ -0,0 +1,93 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Return the active loans past their due date
+// GET Method
+var ListOverdueLoans = tx.Transaction{
+	Tag:         "listOverdueLoans",
+	Label:       "List Overdue Loans",
+	Description: "Return the active loans past their due date, optionally of a borrower",
+	Method:      "GET",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "borrower",
+			Label:       "Borrower",
+			Description: "Person whose overdue loans are returned",
+			DataType:    "->person",
+		},
+		{
+			Tag:         "limit",
+			Label:       "Limit",
+			Description: "Limit",
+			DataType:    "number",
+		},
+		{
+			Tag:         "bookmark",
+			Label:       "Bookmark",
+			Description: "Bookmark of the next page",
+			DataType:    "string",
+		},
+	},
+	ReadOnly: true,
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		limit, hasLimit := req["limit"].(float64)
+		if hasLimit && limit <= 0 {
+			return nil, errors.NewCCError("limit must be greater than 0", 400)
+		}
+
+		now, err := txTime(stub)
+		if err != nil {
+			return nil, err
+		}
+
+		// Dates are stored as RFC 3339 strings in UTC, so they sort in time order
+		selector := map[string]interface{}{
+			"@assetType": "loan",
+			"status":     datatypes.LoanStatusActive,
+			"dueDate": map[string]interface{}{
+				"$lt": now.UTC().Format(time.RFC3339),
+			},
+		}
+		if borrowerKey, ok := req["borrower"].(assets.Key); ok {
+			selector["borrower.@key"] = borrowerKey.Key()
+		}
+
+		query := map[string]interface{}{
+			"selector": selector,
+		}
+		if hasLimit {
+			query["limit"] = limit
+		}
+		if bookmark, ok := req["bookmark"].(string); ok {
+			query["bookmark"] = bookmark
+		}
+
+		response, err := assets.Search(stub, query, "", false)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "error searching for overdue loans", 500)
+		}
+
+		responseJSON, nerr := json.Marshal(response)
+		if nerr != nil {
+			return nil, errors.WrapErrorWithStatus(nerr, "error marshaling response", 500)
+		}
+
+		return responseJSON, nil
+	},
+}
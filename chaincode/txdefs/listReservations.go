@@ -0,0 +1,64 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Return the reservation queue of a book
+// GET Method
+var ListReservations = tx.Transaction{
+	Tag:         "listReservations",
+	Label:       "List Reservations",
+	Description: "Return the people copies of the book are held for and its reservation queue in order",
+	Method:      "GET",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "book",
+			Label:       "Book",
+			Description: "Reserved book",
+			DataType:    "->book",
+			Required:    true,
+		},
+	},
+	ReadOnly: true,
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		bookKey, ok := req["book"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter book must be an asset")
+		}
+		book := bookKey.Key()
+
+		holds, err := getHolds(stub, book)
+		if err != nil {
+			return nil, err
+		}
+		queue, err := getReservationQueue(stub, book)
+		if err != nil {
+			return nil, err
+		}
+
+		response := map[string]interface{}{
+			"book":  map[string]interface{}{"@assetType": "book", "@key": book},
+			"holds": holds,
+			"queue": queue,
+		}
+
+		responseJSON, nerr := json.Marshal(response)
+		if nerr != nil {
+			return nil, errors.WrapErrorWithStatus(nerr, "error marshaling response", 500)
+		}
+
+		return responseJSON, nil
+	},
+}
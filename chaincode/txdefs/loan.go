@@ -0,0 +1,32 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/events"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// putAsset saves the asset map on the ledger, returning the saved asset
+func putAsset(stub *sw.StubWrapper, assetMap map[string]interface{}) (map[string]interface{}, errors.ICCError) {
+	asset, err := assets.NewAsset(assetMap)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to create asset")
+	}
+	assetMap, err = asset.Put(stub)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+	}
+	return assetMap, nil
+}
+
+// loanLog emits the loan event with the loan as its payload
+func loanLog(stub *sw.StubWrapper, event string, loanMap map[string]interface{}) errors.ICCError {
+	logMsg, nerr := json.Marshal(loanMap)
+	if nerr != nil {
+		return errors.WrapError(nerr, "failed to encode event payload")
+	}
+	return events.CallEvent(stub, event, logMsg)
+}
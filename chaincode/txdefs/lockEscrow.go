@@ -0,0 +1,118 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Lock a collectible in escrow until a buyer confirms its transfer
+// POST Method
+var LockEscrow = tx.Transaction{
+	Tag:         "lockEscrow",
+	Label:       "Lock Escrow",
+	Description: "Lock a collectible of the caller until the buyer confirms its transfer or the escrow expires",
+	Method:      "POST",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "id",
+			Label:       "Escrow ID",
+			Description: "Unique identifier of the escrow",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "collectible",
+			Label:       "Collectible",
+			Description: "Collectible to be locked",
+			DataType:    "->collectible",
+			Required:    true,
+		},
+		{
+			Tag:         "buyer",
+			Label:       "Buyer",
+			Description: "MSP of the organization that must confirm the transfer",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "expiresAt",
+			Label:       "Expiration Date",
+			Description: "Date after which the escrow can be released",
+			DataType:    "datetime",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		buyer, _ := req["buyer"].(string)
+		expiresAt, _ := req["expiresAt"].(time.Time)
+
+		collectibleMap, err := getOwnedCollectible(stub, req)
+		if err != nil {
+			return nil, err
+		}
+		if collectibleMap["owner"] == buyer {
+			return nil, errors.NewCCError("collectible is already owned by "+buyer, 400)
+		}
+
+		now, err := txTime(stub)
+		if err != nil {
+			return nil, err
+		}
+		if !expiresAt.After(now) {
+			return nil, errors.NewCCError("expiresAt must be in the future", 400)
+		}
+
+		escrowMap := make(map[string]interface{})
+		escrowMap["@assetType"] = "escrow"
+		escrowMap["id"] = req["id"]
+		escrowMap["collectible"] = map[string]interface{}{
+			"@assetType": "collectible",
+			"@key":       collectibleMap["@key"],
+		}
+		escrowMap["seller"] = collectibleMap["owner"]
+		escrowMap["buyer"] = buyer
+		escrowMap["expiresAt"] = expiresAt.Format(time.RFC3339)
+		escrowMap["status"] = datatypes.EscrowStatusLocked
+
+		escrowAsset, err := assets.NewAsset(escrowMap)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to create a new asset")
+		}
+
+		// Save the new escrow on channel, failing if the id is taken
+		_, err = escrowAsset.PutNew(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+		}
+
+		// Lock the collectible
+		collectibleMap["escrow"] = map[string]interface{}{
+			"@assetType": "escrow",
+			"@key":       escrowAsset.Key(),
+		}
+		_, err = putCollectible(stub, collectibleMap)
+		if err != nil {
+			return nil, err
+		}
+
+		// Marshal asset back to JSON format
+		escrowJSON, nerr := json.Marshal(escrowAsset)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+		}
+
+		return escrowJSON, nil
+	},
+}
@@ -0,0 +1,126 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// maxSupply is the largest total supply of a token. The integer datatype
+// goes through float64, which holds integers exactly only up to 2^53.
+const maxSupply = 1 << 53
+
+// Mint tokens to the account of the caller, creating the token on its first
+// mint with the caller as its minter
+// POST Method
+var Mint = tx.Transaction{
+	Tag:         "mint",
+	Label:       "Mint",
+	Description: "Mint tokens to the caller, creating the token if it does not exist",
+	Method:      "POST",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "symbol",
+			Label:       "Symbol",
+			Description: "Symbol of the token",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "amount",
+			Label:       "Amount",
+			Description: "Amount of tokens to be minted",
+			DataType:    "integer",
+			Required:    true,
+		},
+		{
+			Tag:         "name",
+			Label:       "Name",
+			Description: "Name of the token, used when it is created",
+			DataType:    "string",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		symbol, _ := req["symbol"].(string)
+		amount, _ := req["amount"].(int64)
+		if amount <= 0 {
+			return nil, errors.NewCCError("amount must be positive", 400)
+		}
+
+		caller, err := stub.GetMSPID()
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get caller MSP")
+		}
+
+		key, err := tokenKey(symbol)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to create token key")
+		}
+
+		exists, err := key.ExistsInLedger(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to check if token exists")
+		}
+
+		var tokenMap map[string]interface{}
+		if exists {
+			tokenMap, err = key.GetMap(stub)
+			if err != nil {
+				return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+			}
+			if tokenMap["minter"] != caller {
+				return nil, errors.NewCCError(fmt.Sprintf("%s is not the minter of %s", caller, symbol), 403)
+			}
+		} else {
+			name, ok := req["name"].(string)
+			if !ok {
+				name = symbol
+			}
+			tokenMap = map[string]interface{}{
+				"@assetType":  "token",
+				"symbol":      symbol,
+				"name":        name,
+				"minter":      caller,
+				"totalSupply": 0.0,
+			}
+		}
+
+		totalSupply, _ := tokenMap["totalSupply"].(float64)
+		if amount > maxSupply-int64(totalSupply) {
+			return nil, errors.NewCCError(fmt.Sprintf("total supply cannot exceed %d", int64(maxSupply)), 400)
+		}
+		tokenMap["totalSupply"] = float64(int64(totalSupply) + amount)
+
+		_, err = addBalance(stub, symbol, caller, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		tokenAsset, err := assets.NewAsset(tokenMap)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to create token asset")
+		}
+		tokenMap, err = tokenAsset.Put(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+		}
+
+		// Marshal asset back to JSON format
+		tokenJSON, nerr := json.Marshal(tokenMap)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+		}
+
+		return tokenJSON, nil
+	},
+}
@@ -0,0 +1,81 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Create a collectible owned by the caller
+// POST Method
+var MintCollectible = tx.Transaction{
+	Tag:         "mintCollectible",
+	Label:       "Mint Collectible",
+	Description: "Create a collectible owned by the caller",
+	Method:      "POST",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "id",
+			Label:       "ID",
+			Description: "Unique identifier of the collectible",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "name",
+			Label:       "Name",
+			Description: "Name of the collectible",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "description",
+			Label:       "Description",
+			Description: "Description of the collectible",
+			DataType:    "string",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		owner, err := stub.GetMSPID()
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get caller MSP")
+		}
+
+		collectibleMap := make(map[string]interface{})
+		collectibleMap["@assetType"] = "collectible"
+		collectibleMap["id"] = req["id"]
+		collectibleMap["name"] = req["name"]
+		collectibleMap["owner"] = owner
+		if description, ok := req["description"]; ok {
+			collectibleMap["description"] = description
+		}
+
+		collectibleAsset, err := assets.NewAsset(collectibleMap)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to create a new asset")
+		}
+
+		// Save the new collectible on channel, failing if the id is taken
+		_, err = collectibleAsset.PutNew(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+		}
+
+		// Marshal asset back to JSON format
+		collectibleJSON, nerr := json.Marshal(collectibleAsset)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+		}
+
+		return collectibleJSON, nil
+	},
+}
@@ -0,0 +1,140 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// privateDataPurger is implemented by the stubs that can purge the history
+// of private data from the peers. The fabric-chaincode-go pinned in go.mod
+// predates PurgePrivateData, so this chaincode only deletes the asset until
+// the shim is upgraded.
+type privateDataPurger interface {
+	PurgePrivateData(collection, key string) error
+}
+
+// Purge a private asset from its collection, refusing referenced assets
+// unless forced
+// DELETE Method
+var PurgePrivateAsset = tx.Transaction{
+	Tag:         "purgePrivateAsset",
+	Label:       "Purge Private Asset",
+	Description: "Delete a private asset and, where the peers support it, purge its history from the collection",
+	Method:      "DELETE",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "key",
+			Label:       "Key",
+			Description: "Key of the private asset",
+			DataType:    "@key",
+			Required:    true,
+		},
+		{
+			Tag:         "force",
+			Label:       "Force",
+			Description: "Purge the asset even if other assets reference it, leaving their references dangling",
+			DataType:    "boolean",
+		},
+		{
+			Tag:         "dryRun",
+			Label:       "Dry Run",
+			Description: "Only report what would be purged",
+			DataType:    "boolean",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		force, _ := req["force"].(bool)
+		dryRun, _ := req["dryRun"].(bool)
+
+		key, ok := req["key"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter key must be an asset")
+		}
+		// Keys given only by @key have the asset type as prefix
+		if _, ok := key["@assetType"]; !ok {
+			key["@assetType"] = strings.SplitN(key.Key(), ":", 2)[0]
+		}
+		assetType := key.Type()
+		if assetType == nil || !assetType.IsPrivate() {
+			return nil, errors.NewCCError("asset type is not private", 400)
+		}
+
+		asset, err := key.Get(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read private asset")
+		}
+		err = asset.CheckWriters(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed write permission check")
+		}
+
+		referrers, err := key.Referrers(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read asset referrers")
+		}
+		referrerKeys := make([]string, 0, len(referrers))
+		for _, referrer := range referrers {
+			referrerKeys = append(referrerKeys, referrer.Key())
+		}
+
+		purger, purgeSupported := stub.Stub.(privateDataPurger)
+		response := map[string]interface{}{
+			"@assetType":     key.TypeTag(),
+			"@key":           key.Key(),
+			"referrers":      referrerKeys,
+			"purgeSupported": purgeSupported,
+			"dryRun":         dryRun,
+		}
+
+		if !dryRun {
+			if len(referrerKeys) > 0 && !force {
+				return nil, errors.NewCCError(fmt.Sprintf("asset is referenced by %s, set force to purge it anyway", strings.Join(referrerKeys, ", ")), 409)
+			}
+
+			// Forced purges drop the reference index of the referrers, which
+			// keep dangling references to the asset
+			for _, referrer := range referrerKeys {
+				indexKey, nerr := stub.Stub.CreateCompositeKey(key.Key(), []string{referrer})
+				if nerr != nil {
+					return nil, errors.WrapError(nerr, "could not create composite key")
+				}
+				err = stub.DelState(indexKey)
+				if err != nil {
+					return nil, errors.WrapError(err, "failed to delete reference index")
+				}
+			}
+
+			_, err = key.Delete(stub)
+			if err != nil {
+				return nil, errors.WrapError(err, "failed to delete private asset")
+			}
+
+			// Without purge support, the history is only removed by the
+			// blockToLive of the collection
+			if purgeSupported {
+				nerr := purger.PurgePrivateData(key.CollectionName(), key.Key())
+				if nerr != nil {
+					return nil, errors.WrapError(nerr, "failed to purge private asset")
+				}
+			}
+		}
+
+		responseJSON, nerr := json.Marshal(response)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode response to JSON format")
+		}
+		return responseJSON, nil
+	},
+}
@@ -0,0 +1,106 @@
+package txdefs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Read a private asset, or only its hash for organizations that are not
+// readers of its collection
+// GET Method
+var ReadPrivateAsset = tx.Transaction{
+	Tag:         "readPrivateAsset",
+	Label:       "Read Private Asset",
+	Description: "Read a private asset with its hash, or only the hash of it for organizations that are not readers of its collection",
+	Method:      "GET",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "key",
+			Label:       "Key",
+			Description: "Key of the private asset",
+			DataType:    "@key",
+			Required:    true,
+		},
+	},
+	ReadOnly: true,
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		key, ok := req["key"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter key must be an asset")
+		}
+		// Keys given only by @key have the asset type as prefix
+		if _, ok := key["@assetType"]; !ok {
+			key["@assetType"] = strings.SplitN(key.Key(), ":", 2)[0]
+		}
+		assetType := key.Type()
+		if assetType == nil || !assetType.IsPrivate() {
+			return nil, errors.NewCCError("asset type is not private", 400)
+		}
+
+		caller, err := stub.GetMSPID()
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get caller MSP")
+		}
+
+		// Readers of the collection get the asset with the hash of its value
+		for _, reader := range assetType.Readers {
+			if reader != caller {
+				continue
+			}
+
+			assetBytes, err := stub.GetPrivateData(key.CollectionName(), key.Key())
+			if err != nil {
+				return nil, errors.WrapError(err, "failed to read private asset")
+			}
+			if assetBytes == nil {
+				return nil, errors.NewCCError("asset not found", 404)
+			}
+
+			var assetMap map[string]interface{}
+			nerr := json.Unmarshal(assetBytes, &assetMap)
+			if nerr != nil {
+				return nil, errors.WrapError(nerr, "failed to unmarshal private asset")
+			}
+			hash := sha256.Sum256(assetBytes)
+			assetMap["@hash"] = hex.EncodeToString(hash[:])
+
+			assetJSON, nerr := json.Marshal(assetMap)
+			if nerr != nil {
+				return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+			}
+			return assetJSON, nil
+		}
+
+		// Other organizations only get the hash stored on the channel ledger
+		hash, err := stub.GetPrivateDataHash(key.CollectionName(), key.Key())
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read private asset hash")
+		}
+		if hash == nil {
+			return nil, errors.NewCCError("asset not found", 404)
+		}
+
+		hashJSON, nerr := json.Marshal(map[string]interface{}{
+			"@assetType": key.TypeTag(),
+			"@key":       key.Key(),
+			"@hash":      hex.EncodeToString(hash),
+		})
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode hash to JSON format")
+		}
+		return hashJSON, nil
+	},
+}
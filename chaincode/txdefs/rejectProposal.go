@@ -0,0 +1,41 @@
+package txdefs
+
+import (
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Reject a pending proposal
+// PUT Method
+var RejectProposal = tx.Transaction{
+	Tag:         "rejectProposal",
+	Label:       "Reject Proposal",
+	Description: "Reject a pending proposal, only allowed to its approver",
+	Method:      "PUT",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "proposal",
+			Label:       "Proposal",
+			Description: "Proposal to be rejected",
+			DataType:    "->proposal",
+			Required:    true,
+		},
+		{
+			Tag:         "reason",
+			Label:       "Reason",
+			Description: "Reason of the decision",
+			DataType:    "string",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		return decideProposal(stub, req, datatypes.ProposalStatusRejected)
+	},
+}
@@ -0,0 +1,67 @@
+package txdefs
+
+import (
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Release an escrow, returning its collectible to the seller
+// PUT Method
+var ReleaseEscrow = tx.Transaction{
+	Tag:         "releaseEscrow",
+	Label:       "Release Escrow",
+	Description: "Release a locked escrow, unlocking its collectible for the seller, allowed to the buyer or to anyone after it expires",
+	Method:      "PUT",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "escrow",
+			Label:       "Escrow",
+			Description: "Escrow to be released",
+			DataType:    "->escrow",
+			Required:    true,
+		},
+		{
+			Tag:         "expired",
+			Label:       "Expired",
+			Description: "Release the escrow only if it expired, by the time of the transaction",
+			DataType:    "boolean",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		escrowMap, expiresAt, err := getLockedEscrow(stub, req)
+		if err != nil {
+			return nil, err
+		}
+
+		caller, err := stub.GetMSPID()
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get caller MSP")
+		}
+		now, err := txTime(stub)
+		if err != nil {
+			return nil, err
+		}
+
+		// Scheduled releases are refused before the escrow expires, even
+		// when submitted by the buyer, whatever the clock of the submitter
+		expired, _ := req["expired"].(bool)
+		if expired && now.Before(expiresAt) {
+			return nil, errors.NewCCError("escrow has not expired yet", 409)
+		}
+
+		// Before expiring, only the buyer may decline the escrow
+		if escrowMap["buyer"] != caller && now.Before(expiresAt) {
+			return nil, errors.NewCCError("escrow can only be released by the buyer before it expires", 403)
+		}
+
+		return settleEscrow(stub, escrowMap, datatypes.EscrowStatusReleased, "")
+	},
+}
@@ -0,0 +1,267 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// Reservations are kept in composite keys instead of a queue asset, so that
+// reserving a book writes only keys of its own and concurrent reservations
+// of the same book do not conflict:
+//
+//	reservation~{book}~{ticket} holds the reservation, in queue order
+//	reservedBy~{book}~{person}  holds the ticket of the person
+//	hold~{book}~{person}        holds the reservation fulfilled on return
+//
+// The ticket is the transaction timestamp in nanoseconds, zero padded so
+// tickets sort in time order, followed by the transaction id to break ties.
+const (
+	reservationObjectType = "reservation"
+	reservedByObjectType  = "reservedBy"
+	holdObjectType        = "hold"
+)
+
+// reservationTicket returns the position of a reservation made by the
+// transaction in the queue of a book
+func reservationTicket(stub *sw.StubWrapper) (string, errors.ICCError) {
+	now, err := txTime(stub)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%020d-%s", now.UnixNano(), stub.Stub.GetTxID()), nil
+}
+
+// personRef returns the reference to the person with the key
+func personRef(key string) map[string]interface{} {
+	return map[string]interface{}{
+		"@assetType": "person",
+		"@key":       key,
+	}
+}
+
+// putReservation adds the person to the end of the reservation queue of the
+// book
+func putReservation(stub *sw.StubWrapper, book, person string) (map[string]interface{}, errors.ICCError) {
+	ticket, err := reservationTicket(stub)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := map[string]interface{}{
+		"book": map[string]interface{}{
+			"@assetType": "book",
+			"@key":       book,
+		},
+		"person":     personRef(person),
+		"reservedAt": now.UTC().Format(time.RFC3339),
+		"ticket":     ticket,
+	}
+	reservationJSON, nerr := json.Marshal(reservation)
+	if nerr != nil {
+		return nil, errors.WrapError(nerr, "failed to encode reservation")
+	}
+
+	key, err := stub.CreateCompositeKey(reservationObjectType, []string{book, ticket})
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to create reservation key")
+	}
+	err = stub.PutState(key, reservationJSON)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to write reservation")
+	}
+
+	key, err = stub.CreateCompositeKey(reservedByObjectType, []string{book, person})
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to create reservation key")
+	}
+	err = stub.PutState(key, []byte(ticket))
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to write reservation")
+	}
+
+	return reservation, nil
+}
+
+// getReservationTicket returns the ticket of the reservation of the person in
+// the queue of the book, empty if the person is not in the queue
+func getReservationTicket(stub *sw.StubWrapper, book, person string) (string, errors.ICCError) {
+	key, err := stub.CreateCompositeKey(reservedByObjectType, []string{book, person})
+	if err != nil {
+		return "", errors.WrapError(err, "failed to create reservation key")
+	}
+	ticket, err := stub.GetState(key)
+	if err != nil {
+		return "", errors.WrapError(err, "failed to read reservation")
+	}
+	return string(ticket), nil
+}
+
+// deleteReservation removes the reservation with the ticket from the queue of
+// the book
+func deleteReservation(stub *sw.StubWrapper, book, person, ticket string) errors.ICCError {
+	key, err := stub.CreateCompositeKey(reservationObjectType, []string{book, ticket})
+	if err != nil {
+		return errors.WrapError(err, "failed to create reservation key")
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return errors.WrapError(err, "failed to delete reservation")
+	}
+
+	key, err = stub.CreateCompositeKey(reservedByObjectType, []string{book, person})
+	if err != nil {
+		return errors.WrapError(err, "failed to create reservation key")
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return errors.WrapError(err, "failed to delete reservation")
+	}
+	return nil
+}
+
+// getReservationQueue returns the reservations of the book, first come first
+// served, with their position in the queue
+func getReservationQueue(stub *sw.StubWrapper, book string) ([]map[string]interface{}, errors.ICCError) {
+	it, err := stub.GetStateByPartialCompositeKey(reservationObjectType, []string{book})
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to read reservations")
+	}
+	defer it.Close()
+
+	queue := make([]map[string]interface{}, 0)
+	for it.HasNext() {
+		kv, nerr := it.Next()
+		if nerr != nil {
+			return nil, errors.WrapError(nerr, "failed to read reservations")
+		}
+
+		var reservation map[string]interface{}
+		nerr = json.Unmarshal(kv.Value, &reservation)
+		if nerr != nil {
+			return nil, errors.WrapError(nerr, "invalid reservation")
+		}
+		reservation["position"] = len(queue) + 1
+		queue = append(queue, reservation)
+	}
+	return queue, nil
+}
+
+// getHold returns the reservation of the person fulfilled for the book, nil
+// if the book is not held for the person
+func getHold(stub *sw.StubWrapper, book, person string) (map[string]interface{}, errors.ICCError) {
+	key, err := stub.CreateCompositeKey(holdObjectType, []string{book, person})
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to create hold key")
+	}
+	holdJSON, err := stub.GetState(key)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to read hold")
+	}
+	if holdJSON == nil {
+		return nil, nil
+	}
+
+	var hold map[string]interface{}
+	nerr := json.Unmarshal(holdJSON, &hold)
+	if nerr != nil {
+		return nil, errors.WrapError(nerr, "invalid hold")
+	}
+	return hold, nil
+}
+
+// getHolds returns the reservations fulfilled for the book. A single copy
+// book is held for one person at most, while books with an inventory are
+// held for a person for each copy returned while reserved.
+func getHolds(stub *sw.StubWrapper, book string) ([]map[string]interface{}, errors.ICCError) {
+	it, err := stub.GetStateByPartialCompositeKey(holdObjectType, []string{book})
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to read holds")
+	}
+	defer it.Close()
+
+	holds := make([]map[string]interface{}, 0)
+	for it.HasNext() {
+		kv, nerr := it.Next()
+		if nerr != nil {
+			return nil, errors.WrapError(nerr, "failed to read holds")
+		}
+
+		var hold map[string]interface{}
+		nerr = json.Unmarshal(kv.Value, &hold)
+		if nerr != nil {
+			return nil, errors.WrapError(nerr, "invalid hold")
+		}
+		holds = append(holds, hold)
+	}
+	return holds, nil
+}
+
+// holdPerson returns the key of the person the hold is for
+func holdPerson(hold map[string]interface{}) string {
+	person, _ := hold["person"].(map[string]interface{})
+	key, _ := person["@key"].(string)
+	return key
+}
+
+// deleteHold releases the book from its hold for the person
+func deleteHold(stub *sw.StubWrapper, book, person string) errors.ICCError {
+	key, err := stub.CreateCompositeKey(holdObjectType, []string{book, person})
+	if err != nil {
+		return errors.WrapError(err, "failed to create hold key")
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return errors.WrapError(err, "failed to delete hold")
+	}
+	return nil
+}
+
+// fulfilNextReservation holds an available copy of the book for the first
+// person in its reservation queue, removing the reservation from the queue.
+// It returns the hold, or nil if nobody reserved the book.
+func fulfilNextReservation(stub *sw.StubWrapper, book string) (map[string]interface{}, errors.ICCError) {
+	queue, err := getReservationQueue(stub, book)
+	if err != nil {
+		return nil, err
+	}
+	if len(queue) == 0 {
+		return nil, nil
+	}
+
+	hold := queue[0]
+	delete(hold, "position")
+	ticket, _ := hold["ticket"].(string)
+	person := holdPerson(hold)
+	err = deleteReservation(stub, book, person, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	hold["heldSince"] = now.UTC().Format(time.RFC3339)
+
+	holdJSON, nerr := json.Marshal(hold)
+	if nerr != nil {
+		return nil, errors.WrapError(nerr, "failed to encode hold")
+	}
+	key, err := stub.CreateCompositeKey(holdObjectType, []string{book, person})
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to create hold key")
+	}
+	err = stub.PutState(key, holdJSON)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to write hold")
+	}
+	return hold, nil
+}
@@ -0,0 +1,123 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/events"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Reserve a borrowed book for a person
+// POST Method
+var ReserveBook = tx.Transaction{
+	Tag:         "reserveBook",
+	Label:       "Reserve Book",
+	Description: "Add a person to the end of the reservation queue of a book that is not available",
+	Method:      "POST",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "book",
+			Label:       "Book",
+			Description: "Book to be reserved",
+			DataType:    "->book",
+			Required:    true,
+		},
+		{
+			Tag:         "person",
+			Label:       "Person",
+			Description: "Person reserving the book",
+			DataType:    "->person",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		bookKey, ok := req["book"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter book must be an asset")
+		}
+		personKey, ok := req["person"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter person must be an asset")
+		}
+		book, person := bookKey.Key(), personKey.Key()
+
+		// Returns Book from channel
+		bookMap, err := bookKey.GetMap(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+		}
+
+		exists, err := personKey.ExistsInLedger(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read asset from the ledger")
+		}
+		if !exists {
+			return nil, errors.NewCCError("person not found", 404)
+		}
+
+		hold, err := getHold(stub, book, person)
+		if err != nil {
+			return nil, err
+		}
+		if hold != nil {
+			return nil, errors.NewCCError("book is already held for the person", 409)
+		}
+
+		var available bool
+		if hasInventory(bookMap) {
+			copies, err := availableCopies(stub, book)
+			if err != nil {
+				return nil, err
+			}
+			available = copies > 0
+		} else {
+			holds, err := getHolds(stub, book)
+			if err != nil {
+				return nil, err
+			}
+			available = len(holds) == 0 && bookMap["currentLoan"] == nil
+		}
+		if available {
+			return nil, errors.NewCCError("book is available to borrow", 409)
+		}
+		if tenant, ok := bookMap["currentTenant"].(map[string]interface{}); ok && tenant["@key"] == person {
+			return nil, errors.NewCCError("person is borrowing the book", 409)
+		}
+
+		ticket, err := getReservationTicket(stub, book, person)
+		if err != nil {
+			return nil, err
+		}
+		if ticket != "" {
+			return nil, errors.NewCCError("person already reserved the book", 409)
+		}
+
+		// The position in the queue is not computed here, as reading the
+		// queue would make concurrent reservations of the book conflict
+		reservation, err := putReservation(stub, book, person)
+		if err != nil {
+			return nil, err
+		}
+
+		reservationJSON, nerr := json.Marshal(reservation)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode reservation to JSON format")
+		}
+
+		err = events.CallEvent(stub, "bookReservedLog", reservationJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		return reservationJSON, nil
+	},
+}
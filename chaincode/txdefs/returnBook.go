@@ -0,0 +1,123 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Close a loan, making its book available again
+// PUT Method
+var ReturnBook = tx.Transaction{
+	Tag:         "returnBook",
+	Label:       "Return Book",
+	Description: "Close an active loan, making its copy of the book available again or holding it for the first person in its reservation queue",
+	Method:      "PUT",
+	Callers: []accesscontrol.Caller{ // Only org2, which writes the books, can call this transaction
+		{MSP: "org2MSP"},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "loan",
+			Label:       "Loan",
+			Description: "Loan of the returned book",
+			DataType:    "->loan",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		loanKey, ok := req["loan"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter loan must be an asset")
+		}
+
+		// Returns Loan from channel
+		loanMap, err := loanKey.GetMap(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+		}
+		if loanMap["status"] != datatypes.LoanStatusActive {
+			return nil, errors.NewCCError("book was already returned", 409)
+		}
+
+		now, err := txTime(stub)
+		if err != nil {
+			return nil, err
+		}
+
+		var hold map[string]interface{}
+		bookRef, _ := loanMap["book"].(map[string]interface{})
+		bookKey, err := assets.NewKey(bookRef)
+		if err != nil {
+			return nil, errors.WrapError(err, "invalid book reference")
+		}
+		bookMap, err := bookKey.GetMap(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+		}
+
+		if hasInventory(bookMap) {
+			// Hold the copy for the first person in the reservation queue,
+			// or make it available again
+			hold, err = fulfilNextReservation(stub, bookKey.Key())
+			if err != nil {
+				return nil, err
+			}
+			if hold == nil {
+				err = addCopies(stub, bookKey.Key(), 1)
+				if err != nil {
+					return nil, err
+				}
+			}
+		} else if currentLoan, ok := bookMap["currentLoan"].(map[string]interface{}); ok && currentLoan["@key"] == loanKey.Key() {
+			// Clear the tenant of the book, unless it was lent again meanwhile
+			delete(bookMap, "currentLoan")
+			delete(bookMap, "currentTenant")
+			_, err = putAsset(stub, bookMap)
+			if err != nil {
+				return nil, err
+			}
+
+			// Hold the book for the first person in its reservation queue
+			hold, err = fulfilNextReservation(stub, bookKey.Key())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Update data
+		loanMap["status"] = datatypes.LoanStatusReturned
+		loanMap["returnedAt"] = now.Format(time.RFC3339)
+		loanMap, err = putAsset(stub, loanMap)
+		if err != nil {
+			return nil, err
+		}
+
+		// The hold is not a property of the loan, but is part of the response
+		// and of the event, so the reservation can be notified
+		if hold != nil {
+			loanMap["hold"] = hold
+		}
+
+		err = loanLog(stub, "bookReturnedLog", loanMap)
+		if err != nil {
+			return nil, err
+		}
+
+		// Marshal asset back to JSON format
+		loanJSON, nerr := json.Marshal(loanMap)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+		}
+
+		return loanJSON, nil
+	},
+}
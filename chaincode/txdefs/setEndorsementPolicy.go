@@ -0,0 +1,88 @@
+package txdefs
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Set the organizations that must endorse changes to an asset
+// PUT Method
+var SetEndorsementPolicy = tx.Transaction{
+	Tag:         "setEndorsementPolicy",
+	Label:       "Set Endorsement Policy",
+	Description: "Require the endorsement of the peers of the organizations for changes to the asset, only allowed to its owner, or to the organization that last wrote it if it has no owner",
+	Method:      "PUT",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "key",
+			Label:       "Key",
+			Description: "Key of the asset",
+			DataType:    "@key",
+			Required:    true,
+		},
+		{
+			Tag:         "orgs",
+			Label:       "Organizations",
+			Description: "MSPs of the organizations that must endorse, none to use the chaincode endorsement policy",
+			DataType:    "[]string",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		key, ok := req["key"].(assets.Key)
+		if !ok {
+			return nil, errors.WrapError(nil, "Parameter key must be an asset")
+		}
+		orgsList, _ := req["orgs"].([]interface{})
+
+		assetMap, err := key.GetMap(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to get asset from the ledger", err.Status())
+		}
+
+		// Assets with an owner have their endorsement managed by it, the
+		// others by the organization that last wrote them
+		caller, err := stub.GetMSPID()
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get caller MSP")
+		}
+		if owner, ok := assetMap["owner"].(string); ok {
+			if owner != caller {
+				return nil, errors.NewCCError(fmt.Sprintf("%s is not the owner of the asset", caller), 403)
+			}
+		} else if lastTouchBy, _ := assetMap["@lastTouchBy"].(string); lastTouchBy != caller {
+			return nil, errors.NewCCError(fmt.Sprintf("%s did not last write the asset", caller), 403)
+		}
+
+		// Listed once and sorted, as getEndorsers returns them
+		orgs := make([]string, 0, len(orgsList))
+		seen := make(map[string]bool)
+		for _, org := range orgsList {
+			if !seen[org.(string)] {
+				seen[org.(string)] = true
+				orgs = append(orgs, org.(string))
+			}
+		}
+		sort.Strings(orgs)
+
+		err = setEndorsers(stub, key.Key(), orgs)
+		if err != nil {
+			return nil, err
+		}
+
+		// The policy written is not read back, as peers return the committed
+		// one until the transaction commits
+		return endorsementJSON(key.Key(), orgs)
+	},
+}
@@ -0,0 +1,89 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools-demo/chaincode/datatypes"
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Submit a proposal to be approved by another organization
+// POST Method
+var SubmitProposal = tx.Transaction{
+	Tag:         "submitProposal",
+	Label:       "Submit Proposal",
+	Description: "Submit a proposal to be approved by another organization",
+	Method:      "POST",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "title",
+			Label:       "Title",
+			Description: "Title of the proposal",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "description",
+			Label:       "Description",
+			Description: "Description of the proposal",
+			DataType:    "string",
+		},
+		{
+			Tag:         "approver",
+			Label:       "Approver",
+			Description: "MSP of the organization that must approve the proposal",
+			DataType:    "string",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		approver, _ := req["approver"].(string)
+
+		proposer, err := stub.GetMSPID()
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get caller MSP")
+		}
+		if approver == proposer {
+			return nil, errors.NewCCError("a proposal must be approved by another organization", 400)
+		}
+
+		proposalMap := make(map[string]interface{})
+		proposalMap["@assetType"] = "proposal"
+		proposalMap["id"] = stub.Stub.GetTxID()
+		proposalMap["title"] = req["title"]
+		proposalMap["proposer"] = proposer
+		proposalMap["approver"] = approver
+		proposalMap["status"] = datatypes.ProposalStatusPending
+		if description, ok := req["description"]; ok {
+			proposalMap["description"] = description
+		}
+
+		proposalAsset, err := assets.NewAsset(proposalMap)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to create a new asset")
+		}
+
+		// Save the new proposal on channel
+		_, err = proposalAsset.PutNew(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "error saving asset on blockchain", err.Status())
+		}
+
+		// Marshal asset back to JSON format
+		proposalJSON, nerr := json.Marshal(proposalAsset)
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to encode asset to JSON format")
+		}
+
+		return proposalJSON, nil
+	},
+}
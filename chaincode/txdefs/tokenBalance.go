@@ -0,0 +1,71 @@
+package txdefs
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// balanceObjectType is the object type of the composite keys holding token
+// balances, one for each symbol and account
+const balanceObjectType = "balance"
+
+// tokenKey returns the key of the token with the symbol
+func tokenKey(symbol string) (assets.Key, errors.ICCError) {
+	return assets.NewKey(map[string]interface{}{
+		"@assetType": "token",
+		"symbol":     symbol,
+	})
+}
+
+// getBalance returns the balance of the account, zero if it never held the
+// token
+func getBalance(stub *sw.StubWrapper, symbol, account string) (int64, errors.ICCError) {
+	key, err := stub.CreateCompositeKey(balanceObjectType, []string{symbol, account})
+	if err != nil {
+		return 0, errors.WrapError(err, "failed to create balance key")
+	}
+
+	balanceBytes, err := stub.GetState(key)
+	if err != nil {
+		return 0, errors.WrapError(err, "failed to read balance")
+	}
+	if balanceBytes == nil {
+		return 0, nil
+	}
+
+	balance, nerr := strconv.ParseInt(string(balanceBytes), 10, 64)
+	if nerr != nil {
+		return 0, errors.WrapError(nerr, "invalid balance")
+	}
+	return balance, nil
+}
+
+// addBalance adds the amount, which may be negative, to the balance of the
+// account and returns the new balance
+func addBalance(stub *sw.StubWrapper, symbol, account string, amount int64) (int64, errors.ICCError) {
+	balance, err := getBalance(stub, symbol, account)
+	if err != nil {
+		return 0, err
+	}
+	if balance+amount < 0 {
+		return 0, errors.NewCCError("insufficient balance", 400)
+	}
+	if amount > 0 && balance > math.MaxInt64-amount {
+		return 0, errors.NewCCError("balance overflow", 400)
+	}
+	balance += amount
+
+	key, err := stub.CreateCompositeKey(balanceObjectType, []string{symbol, account})
+	if err != nil {
+		return 0, errors.WrapError(err, "failed to create balance key")
+	}
+	err = stub.PutState(key, []byte(strconv.FormatInt(balance, 10)))
+	if err != nil {
+		return 0, errors.WrapError(err, "failed to write balance")
+	}
+	return balance, nil
+}
@@ -0,0 +1,97 @@
+package txdefs
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Transfer tokens from the account of the caller to another account
+// POST Method
+var Transfer = tx.Transaction{
+	Tag:         "transfer",
+	Label:       "Transfer",
+	Description: "Transfer tokens from the caller to another account",
+	Method:      "POST",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "symbol",
+			Label:       "Symbol",
+			Description: "Symbol of the token",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "to",
+			Label:       "To",
+			Description: "Account (MSP) receiving the tokens",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "amount",
+			Label:       "Amount",
+			Description: "Amount of tokens to be transferred",
+			DataType:    "integer",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		symbol, _ := req["symbol"].(string)
+		to, _ := req["to"].(string)
+		amount, _ := req["amount"].(int64)
+		if amount <= 0 {
+			return nil, errors.NewCCError("amount must be positive", 400)
+		}
+
+		from, err := stub.GetMSPID()
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get caller MSP")
+		}
+		if from == to {
+			return nil, errors.NewCCError("cannot transfer to the same account", 400)
+		}
+
+		key, err := tokenKey(symbol)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to create token key")
+		}
+		_, err = key.Get(stub)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to get token from the ledger", err.Status())
+		}
+
+		fromBalance, err := addBalance(stub, symbol, from, -amount)
+		if err != nil {
+			return nil, err
+		}
+		toBalance, err := addBalance(stub, symbol, to, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		responseJSON, nerr := json.Marshal(map[string]interface{}{
+			"symbol": symbol,
+			"from":   from,
+			"to":     to,
+			"amount": amount,
+			"balances": map[string]interface{}{
+				from: fromBalance,
+				to:   toBalance,
+			},
+		})
+		if nerr != nil {
+			return nil, errors.WrapError(nil, "failed to marshal response")
+		}
+
+		return responseJSON, nil
+	},
+}
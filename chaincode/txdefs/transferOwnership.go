@@ -0,0 +1,62 @@
+package txdefs
+
+import (
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Transfer a collectible to another organization
+// PUT Method
+var TransferOwnership = tx.Transaction{
+	Tag:         "transferOwnership",
+	Label:       "Transfer Ownership",
+	Description: "Transfer a collectible to another organization, only allowed to its owner",
+	Method:      "PUT",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "collectible",
+			Label:       "Collectible",
+			Description: "Collectible to be transferred",
+			DataType:    "->collectible",
+			Required:    true,
+		},
+		{
+			Tag:         "newOwner",
+			Label:       "New Owner",
+			Description: "MSP of the organization receiving the collectible",
+			DataType:    "string",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		newOwner, _ := req["newOwner"].(string)
+
+		collectibleMap, err := getOwnedCollectible(stub, req)
+		if err != nil {
+			return nil, err
+		}
+		if collectibleMap["owner"] == newOwner {
+			return nil, errors.NewCCError("collectible is already owned by "+newOwner, 400)
+		}
+
+		// The new owner takes the place of the previous one among the
+		// endorsers of the collectible
+		key, _ := collectibleMap["@key"].(string)
+		err = transferEndorsement(stub, key, collectibleMap["owner"].(string), newOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		// Update data
+		collectibleMap["owner"] = newOwner
+
+		return putCollectible(stub, collectibleMap)
+	},
+}
@@ -0,0 +1,58 @@
+package txdefs
+
+import (
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Update the name and description of a collectible
+// PUT Method
+var UpdateCollectible = tx.Transaction{
+	Tag:         "updateCollectible",
+	Label:       "Update Collectible",
+	Description: "Update the name and description of a collectible, only allowed to its owner",
+	Method:      "PUT",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "collectible",
+			Label:       "Collectible",
+			Description: "Collectible to be updated",
+			DataType:    "->collectible",
+			Required:    true,
+		},
+		{
+			Tag:         "name",
+			Label:       "Name",
+			Description: "New name of the collectible",
+			DataType:    "string",
+		},
+		{
+			Tag:         "description",
+			Label:       "Description",
+			Description: "New description of the collectible",
+			DataType:    "string",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		collectibleMap, err := getOwnedCollectible(stub, req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Update data
+		for _, field := range []string{"name", "description"} {
+			if value, ok := req[field]; ok {
+				collectibleMap[field] = value
+			}
+		}
+
+		return putCollectible(stub, collectibleMap)
+	},
+}
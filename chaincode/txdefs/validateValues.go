@@ -0,0 +1,69 @@
+package txdefs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// Validate values against the data types of the chaincode, so clients can
+// check them with exactly the rules applied to the assets
+// GET Method
+var ValidateValues = tx.Transaction{
+	Tag:         "validateValues",
+	Label:       "Validate Values",
+	Description: "Validate values against the data types of the chaincode and return them as they would be stored",
+	Method:      "GET",
+	Callers: []accesscontrol.Caller{ // Any org can call this transaction
+		{MSP: `$org\dMSP`},
+		{MSP: "orgMSP"},
+	},
+
+	Args: []tx.Argument{
+		{
+			Tag:         "values",
+			Label:       "Values",
+			Description: "List of objects with a dataType and a value",
+			DataType:    "[]@object",
+			Required:    true,
+		},
+	},
+	ReadOnly: true,
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		values, _ := req["values"].([]interface{})
+
+		results := make([]map[string]interface{}, 0, len(values))
+		for i, item := range values {
+			v, _ := item.(map[string]interface{})
+			dataTypeName, _ := v["dataType"].(string)
+			dataType := assets.FetchDataType(dataTypeName)
+			if dataType == nil {
+				return nil, errors.NewCCError(fmt.Sprintf("value %d has an unknown data type '%s'", i, dataTypeName), 400)
+			}
+
+			result := map[string]interface{}{
+				"dataType": dataTypeName,
+				"valid":    true,
+			}
+			_, parsed, err := dataType.Parse(v["value"])
+			if err != nil {
+				result["valid"] = false
+				result["error"] = err.Message()
+			} else {
+				result["value"] = parsed
+			}
+			results = append(results, result)
+		}
+
+		resultsJSON, nerr := json.Marshal(results)
+		if nerr != nil {
+			return nil, errors.WrapError(nerr, "failed to encode results")
+		}
+		return resultsJSON, nil
+	},
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestAddBookCopies(t *testing.T) {
+	stub := mock.NewMockStub("org2MSP", new(CCDemo))
+	setupLoanState(stub)
+
+	book := map[string]interface{}{
+		"@key": "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+	}
+	person := map[string]interface{}{
+		"@key": "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+	}
+	invoke := func(txID, txName string, req map[string]interface{}) (int32, map[string]interface{}) {
+		reqBytes, _ := json.Marshal(req)
+		res := stub.MockInvoke(txID, [][]byte{
+			[]byte(txName),
+			reqBytes,
+		})
+		var payload map[string]interface{}
+		json.Unmarshal(res.GetPayload(), &payload)
+		if res.GetStatus() != 200 {
+			log.Println(res.GetMessage())
+		}
+		return res.GetStatus(), payload
+	}
+	copies := func(expectedCopies, expectedAvailable float64) {
+		status, res := invoke("getBookCopies", "getBookCopies", map[string]interface{}{"book": book})
+		if status != 200 || res["copies"] != expectedCopies || res["available"] != expectedAvailable {
+			log.Printf("%#v\n", res)
+			t.FailNow()
+		}
+	}
+
+	// Books start as a single copy
+	copies(1, 1)
+
+	// The single copy is kept in the inventory
+	status, res := invoke("addCopies", "addBookCopies", map[string]interface{}{"book": book, "amount": 3})
+	if status != 200 || res["copies"] != 4.0 {
+		log.Printf("%#v\n", res)
+		t.FailNow()
+	}
+	copies(4, 4)
+
+	// Loans of each copy start at the shard of their transaction
+	for _, id := range []string{"loan-001", "loan-002", "loan-003", "loan-004"} {
+		status, _ = invoke(id, "borrowBook", map[string]interface{}{
+			"id":       id,
+			"book":     book,
+			"borrower": person,
+			"dueDate":  time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		})
+		if status != 200 {
+			t.FailNow()
+		}
+	}
+	copies(4, 0)
+
+	// Borrowed copies are not written on the book
+	var bookMap map[string]interface{}
+	bookJSON, _ := stub.GetState("book:a36a2920-c405-51c3-b584-dcd758338cb5")
+	json.Unmarshal(bookJSON, &bookMap)
+	if bookMap["currentLoan"] != nil {
+		t.FailNow()
+	}
+
+	status, _ = invoke("loan-005", "borrowBook", map[string]interface{}{
+		"id":       "loan-005",
+		"book":     book,
+		"borrower": person,
+		"dueDate":  time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+	})
+	if status != 409 {
+		t.FailNow()
+	}
+
+	// Only available copies can be removed
+	status, _ = invoke("removeCopies", "addBookCopies", map[string]interface{}{"book": book, "amount": -1})
+	if status != 409 {
+		t.FailNow()
+	}
+
+	status, _ = invoke("returnBook", "returnBook", map[string]interface{}{
+		"loan": map[string]interface{}{"@assetType": "loan", "id": "loan-002"},
+	})
+	if status != 200 {
+		t.FailNow()
+	}
+	copies(4, 1)
+
+	status, res = invoke("removeCopies", "addBookCopies", map[string]interface{}{"book": book, "amount": -1})
+	if status != 200 || res["copies"] != 3.0 {
+		log.Printf("%#v\n", res)
+		t.FailNow()
+	}
+	copies(3, 0)
+}
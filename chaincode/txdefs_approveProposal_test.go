@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestApproveProposal(t *testing.T) {
+	stub := mock.NewMockStub("org2MSP", new(CCDemo))
+
+	// State setup
+	setupProposal := map[string]interface{}{
+		"@key":         "proposal:5e1b7a6e-0c1f-5b8d-9a9e-5cbd3b0b5d21",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "submitProposal",
+		"@assetType":   "proposal",
+		"id":           "f3a1",
+		"title":        "Add new genre",
+		"proposer":     "org1MSP",
+		"approver":     "org2MSP",
+		"status":       "pending",
+	}
+	setupProposalJSON, _ := json.Marshal(setupProposal)
+
+	stub.MockTransactionStart("setupApproveProposal")
+	stub.PutState("proposal:5e1b7a6e-0c1f-5b8d-9a9e-5cbd3b0b5d21", setupProposalJSON)
+	stub.MockTransactionEnd("setupApproveProposal")
+
+	req := map[string]interface{}{
+		"proposal": map[string]interface{}{
+			"@key": "proposal:5e1b7a6e-0c1f-5b8d-9a9e-5cbd3b0b5d21",
+		},
+		"reason": "Poetry is welcome",
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("approveProposal", [][]byte{
+		[]byte("approveProposal"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"@key":         "proposal:5e1b7a6e-0c1f-5b8d-9a9e-5cbd3b0b5d21",
+		"@lastTouchBy": "org2MSP",
+		"@lastTx":      "approveProposal",
+		"@assetType":   "proposal",
+		"id":           "f3a1",
+		"title":        "Add new genre",
+		"proposer":     "org1MSP",
+		"approver":     "org2MSP",
+		"status":       "approved",
+		"reason":       "Poetry is welcome",
+	}
+
+	expectedResponse["@lastUpdated"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+	expectedResponse["decidedAt"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// A decided proposal cannot be rejected
+	res = stub.MockInvoke("rejectProposal", [][]byte{
+		[]byte("rejectProposal"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 409 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
+
+func TestApproveProposalNotApprover(t *testing.T) {
+	stub := mock.NewMockStub("org3MSP", new(CCDemo))
+
+	// State setup
+	setupProposal := map[string]interface{}{
+		"@key":         "proposal:5e1b7a6e-0c1f-5b8d-9a9e-5cbd3b0b5d21",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "submitProposal",
+		"@assetType":   "proposal",
+		"id":           "f3a1",
+		"title":        "Add new genre",
+		"proposer":     "org1MSP",
+		"approver":     "org2MSP",
+		"status":       "pending",
+	}
+	setupProposalJSON, _ := json.Marshal(setupProposal)
+
+	stub.MockTransactionStart("setupApproveProposal")
+	stub.PutState("proposal:5e1b7a6e-0c1f-5b8d-9a9e-5cbd3b0b5d21", setupProposalJSON)
+	stub.MockTransactionEnd("setupApproveProposal")
+
+	req := map[string]interface{}{
+		"proposal": map[string]interface{}{
+			"@key": "proposal:5e1b7a6e-0c1f-5b8d-9a9e-5cbd3b0b5d21",
+		},
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("approveProposal", [][]byte{
+		[]byte("approveProposal"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+// setupLoanState puts a person and a book on the ledger of the stub
+func setupLoanState(stub *mock.MockStub) {
+	setupPerson := map[string]interface{}{
+		"@key":         "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "createAsset",
+		"@assetType":   "person",
+		"name":         "Maria",
+		"id":           "31820792048",
+		"height":       0.0,
+	}
+	setupBook := map[string]interface{}{
+		"@key":         "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+		"@lastTouchBy": "org2MSP",
+		"@lastTx":      "createAsset",
+		"@assetType":   "book",
+		"title":        "Meu Nome é Maria",
+		"author":       "Maria Viana",
+		"genres":       []interface{}{"biography", "non-fiction"},
+		"published":    "2019-05-06T22:12:41Z",
+	}
+	setupPersonJSON, _ := json.Marshal(setupPerson)
+	setupBookJSON, _ := json.Marshal(setupBook)
+
+	stub.MockTransactionStart("setupLoan")
+	stub.PutState("person:47061146-c642-51a1-844a-bf0b17cb5e19", setupPersonJSON)
+	stub.PutState("book:a36a2920-c405-51c3-b584-dcd758338cb5", setupBookJSON)
+	stub.MockTransactionEnd("setupLoan")
+}
+
+func TestBorrowBook(t *testing.T) {
+	stub := mock.NewMockStub("org2MSP", new(CCDemo))
+	setupLoanState(stub)
+
+	dueDate := time.Now().Add(14 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	req := map[string]interface{}{
+		"id": "loan-001",
+		"book": map[string]interface{}{
+			"@key": "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+		},
+		"borrower": map[string]interface{}{
+			"@key": "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+		},
+		"dueDate": dueDate,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("borrowBook", [][]byte{
+		[]byte("borrowBook"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	loanKey, _ := resPayload["@key"].(string)
+	now := stub.TxTimestamp.AsTime().Format(time.RFC3339)
+	expectedResponse := map[string]interface{}{
+		"@key":         loanKey,
+		"@lastTouchBy": "org2MSP",
+		"@lastTx":      "borrowBook",
+		"@lastUpdated": now,
+		"@assetType":   "loan",
+		"id":           "loan-001",
+		"book": map[string]interface{}{
+			"@assetType": "book",
+			"@key":       "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+		},
+		"borrower": map[string]interface{}{
+			"@assetType": "person",
+			"@key":       "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+		},
+		"borrowedAt": now,
+		"dueDate":    dueDate,
+		"status":     "active",
+	}
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// The borrower is the tenant of the book
+	var book map[string]interface{}
+	bookJSON, _ := stub.GetState("book:a36a2920-c405-51c3-b584-dcd758338cb5")
+	json.Unmarshal(bookJSON, &book)
+	expectedTenant := map[string]interface{}{
+		"@assetType": "person",
+		"@key":       "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+	}
+	if !reflect.DeepEqual(book["currentTenant"], expectedTenant) {
+		log.Printf("%#v\n", book["currentTenant"])
+		t.FailNow()
+	}
+
+	// The book cannot be lent twice
+	req["id"] = "loan-002"
+	reqBytes, _ = json.Marshal(req)
+	res = stub.MockInvoke("borrowBook", [][]byte{
+		[]byte("borrowBook"),
+		reqBytes,
+	})
+	if res.GetStatus() != 409 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Nor lent until a past date
+	req["dueDate"] = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	reqBytes, _ = json.Marshal(req)
+	res = stub.MockInvoke("borrowBook", [][]byte{
+		[]byte("borrowBook"),
+		reqBytes,
+	})
+	if res.GetStatus() != 400 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestGenericAssetsForgery(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	// Assets written by their own transactions cannot be forged
+	forged := []map[string]interface{}{
+		{
+			"@assetType": "collectible",
+			"id":         "first-edition-001",
+			"name":       "First Edition",
+			"owner":      "org2MSP",
+		},
+		{
+			"@assetType":  "token",
+			"symbol":      "GLD",
+			"name":        "Gold",
+			"minter":      "org2MSP",
+			"totalSupply": 1000000,
+		},
+		{
+			"@assetType": "proposal",
+			"id":         "budget-2024",
+			"title":      "Budget",
+			"proposer":   "org1MSP",
+			"approver":   "org2MSP",
+			"status":     "approved",
+		},
+	}
+	for _, asset := range forged {
+		req, _ := json.Marshal(map[string]interface{}{
+			"asset": []interface{}{asset},
+		})
+		res := stub.MockInvoke("create"+asset["@assetType"].(string), [][]byte{
+			[]byte("createAsset"),
+			req,
+		})
+		if res.GetStatus() != 403 {
+			log.Println(asset["@assetType"], res)
+			t.FailNow()
+		}
+	}
+
+	// Minted tokens cannot be deleted, and thus not minted again by another
+	// organization
+	mintReq, _ := json.Marshal(map[string]interface{}{
+		"symbol": "GLD",
+		"amount": 100,
+	})
+	res := stub.MockInvoke("mint", [][]byte{
+		[]byte("mint"),
+		mintReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+	var token map[string]interface{}
+	json.Unmarshal(res.GetPayload(), &token)
+
+	otherStub := mock.NewMockStub("org2MSP", new(CCDemo))
+	otherStub.State = stub.State
+
+	deleteReq, _ := json.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"@assetType": "token",
+			"@key":       token["@key"],
+		},
+	})
+	res = otherStub.MockInvoke("deleteAsset", [][]byte{
+		[]byte("deleteAsset"),
+		deleteReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Nor updated
+	updateReq, _ := json.Marshal(map[string]interface{}{
+		"update": map[string]interface{}{
+			"@assetType": "token",
+			"@key":       token["@key"],
+			"name":       "Fool's Gold",
+		},
+	})
+	res = otherStub.MockInvoke("updateAsset", [][]byte{
+		[]byte("updateAsset"),
+		updateReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Locked escrows cannot be released without releaseEscrow
+	setupEscrow := map[string]interface{}{
+		"@key":         "escrow:8c2e4b1a-3d5f-5e6a-9b7c-1d2e3f4a5b6c",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "lockEscrow",
+		"@assetType":   "escrow",
+		"id":           "sale-001",
+		"seller":       "org1MSP",
+		"buyer":        "org2MSP",
+		"status":       "locked",
+	}
+	setupEscrowJSON, _ := json.Marshal(setupEscrow)
+
+	stub.MockTransactionStart("setupEscrow")
+	stub.PutState("escrow:8c2e4b1a-3d5f-5e6a-9b7c-1d2e3f4a5b6c", setupEscrowJSON)
+	stub.MockTransactionEnd("setupEscrow")
+
+	escrowReq, _ := json.Marshal(map[string]interface{}{
+		"update": map[string]interface{}{
+			"@assetType": "escrow",
+			"@key":       "escrow:8c2e4b1a-3d5f-5e6a-9b7c-1d2e3f4a5b6c",
+			"status":     "released",
+		},
+	})
+	res = otherStub.MockInvoke("updateEscrow", [][]byte{
+		[]byte("updateAsset"),
+		escrowReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Other asset types are still written by the generic transactions
+	personReq, _ := json.Marshal(map[string]interface{}{
+		"asset": []interface{}{
+			map[string]interface{}{
+				"@assetType": "person",
+				"name":       "Maria",
+				"id":         "31820792048",
+			},
+		},
+	})
+	res = stub.MockInvoke("createPerson", [][]byte{
+		[]byte("createAsset"),
+		personReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
+
+func TestGenericAssetsLoans(t *testing.T) {
+	stub := mock.NewMockStub("org2MSP", new(CCDemo))
+	setupLoanState(stub)
+
+	// Loans cannot be forged, adding copies of the book once returned
+	createReq, _ := json.Marshal(map[string]interface{}{
+		"asset": []interface{}{
+			map[string]interface{}{
+				"@assetType": "loan",
+				"id":         "loan-forged",
+				"book": map[string]interface{}{
+					"@key": "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+				},
+				"borrower": map[string]interface{}{
+					"@key": "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+				},
+				"borrowedAt": "2024-01-01T00:00:00Z",
+				"dueDate":    "2024-01-15T00:00:00Z",
+				"status":     "active",
+			},
+		},
+	})
+	res := stub.MockInvoke("createLoan", [][]byte{
+		[]byte("createAsset"),
+		createReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Nor deleted, losing the copy lent
+	borrowReq, _ := json.Marshal(map[string]interface{}{
+		"id": "loan-001",
+		"book": map[string]interface{}{
+			"@key": "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+		},
+		"borrower": map[string]interface{}{
+			"@key": "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+		},
+		"dueDate": "2999-01-01T00:00:00Z",
+	})
+	res = stub.MockInvoke("borrowBook", [][]byte{
+		[]byte("borrowBook"),
+		borrowReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+	var loan map[string]interface{}
+	json.Unmarshal(res.GetPayload(), &loan)
+
+	deleteReq, _ := json.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"@assetType": "loan",
+			"@key":       loan["@key"],
+		},
+	})
+	res = stub.MockInvoke("deleteLoan", [][]byte{
+		[]byte("deleteAsset"),
+		deleteReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
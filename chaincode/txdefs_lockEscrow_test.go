@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestLockEscrow(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	// State setup
+	setupCollectible := map[string]interface{}{
+		"@key":         "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "mintCollectible",
+		"@assetType":   "collectible",
+		"id":           "first-edition-001",
+		"name":         "First Edition",
+		"owner":        "org1MSP",
+	}
+	setupCollectibleJSON, _ := json.Marshal(setupCollectible)
+
+	stub.MockTransactionStart("setupLockEscrow")
+	stub.PutState("collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f", setupCollectibleJSON)
+	stub.MockTransactionEnd("setupLockEscrow")
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	req := map[string]interface{}{
+		"id": "sale-001",
+		"collectible": map[string]interface{}{
+			"@key": "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		},
+		"buyer":     "org2MSP",
+		"expiresAt": expiresAt,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("lockEscrow", [][]byte{
+		[]byte("lockEscrow"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	escrowKey, _ := resPayload["@key"].(string)
+	expectedResponse := map[string]interface{}{
+		"@key":         escrowKey,
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "lockEscrow",
+		"@assetType":   "escrow",
+		"id":           "sale-001",
+		"collectible": map[string]interface{}{
+			"@assetType": "collectible",
+			"@key":       "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		},
+		"seller":    "org1MSP",
+		"buyer":     "org2MSP",
+		"expiresAt": expiresAt,
+		"status":    "locked",
+	}
+
+	expectedResponse["@lastUpdated"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// The locked collectible can no longer be transferred by its owner
+	transferReq, _ := json.Marshal(map[string]interface{}{
+		"collectible": req["collectible"],
+		"newOwner":    "org3MSP",
+	})
+	res = stub.MockInvoke("transferOwnership", [][]byte{
+		[]byte("transferOwnership"),
+		transferReq,
+	})
+	if res.GetStatus() != 409 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Nor confirmed by anyone but the buyer
+	confirmReq, _ := json.Marshal(map[string]interface{}{
+		"escrow": map[string]interface{}{
+			"@key": escrowKey,
+		},
+	})
+	res = stub.MockInvoke("confirmEscrow", [][]byte{
+		[]byte("confirmEscrow"),
+		confirmReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// The buyer confirmation transfers the collectible
+	buyerStub := mock.NewMockStub("org2MSP", new(CCDemo))
+	buyerStub.State = stub.State
+
+	res = buyerStub.MockInvoke("confirmEscrow2", [][]byte{
+		[]byte("confirmEscrow"),
+		confirmReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var collectible map[string]interface{}
+	json.Unmarshal(buyerStub.State["collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f"], &collectible)
+	if collectible["owner"] != "org2MSP" || collectible["escrow"] != nil {
+		log.Printf("%#v\n", collectible)
+		t.FailNow()
+	}
+}
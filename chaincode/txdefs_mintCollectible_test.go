@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestMintCollectible(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	req := map[string]interface{}{
+		"id":   "first-edition-001",
+		"name": "First Edition",
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("mintCollectible", [][]byte{
+		[]byte("mintCollectible"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"@key":         resPayload["@key"],
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "mintCollectible",
+		"@assetType":   "collectible",
+		"id":           "first-edition-001",
+		"name":         "First Edition",
+		"owner":        "org1MSP",
+	}
+
+	expectedResponse["@lastUpdated"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// Collectibles are unique
+	res = stub.MockInvoke("mintCollectible2", [][]byte{
+		[]byte("mintCollectible"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 409 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
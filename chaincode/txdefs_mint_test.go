@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestMint(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	req := map[string]interface{}{
+		"symbol": "GLD",
+		"name":   "Gold",
+		"amount": 100,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("mint", [][]byte{
+		[]byte("mint"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"@key":         resPayload["@key"],
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "mint",
+		"@assetType":   "token",
+		"symbol":       "GLD",
+		"name":         "Gold",
+		"decimals":     0.0,
+		"minter":       "org1MSP",
+		"totalSupply":  100.0,
+	}
+
+	expectedResponse["@lastUpdated"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	balanceKey, _ := stub.CreateCompositeKey("balance", []string{"GLD", "org1MSP"})
+	if string(stub.State[balanceKey]) != "100" {
+		log.Printf("unexpected balance %s\n", stub.State[balanceKey])
+		t.FailNow()
+	}
+
+	// Only the minter can mint more tokens
+	otherStub := mock.NewMockStub("org2MSP", new(CCDemo))
+	otherStub.State = stub.State
+
+	res = otherStub.MockInvoke("mint2", [][]byte{
+		[]byte("mint"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// The total supply cannot go past 2^53, above which it would lose
+	// precision
+	req["amount"] = 1<<53 - 100
+	reqBytes, _ = json.Marshal(req)
+	res = stub.MockInvoke("mint3", [][]byte{
+		[]byte("mint"),
+		reqBytes,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+	err = json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if resPayload["totalSupply"] != float64(1<<53) {
+		log.Printf("unexpected total supply %v\n", resPayload["totalSupply"])
+		t.FailNow()
+	}
+
+	req["amount"] = 1
+	reqBytes, _ = json.Marshal(req)
+	res = stub.MockInvoke("mint4", [][]byte{
+		[]byte("mint"),
+		reqBytes,
+	})
+	if res.GetStatus() != 400 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
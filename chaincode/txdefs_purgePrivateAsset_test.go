@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// purgingChaincode runs the chaincode on a stub that deletes and purges
+// private data, which the mock stub does not
+type purgingChaincode struct {
+	CCDemo
+	mock   *mock.MockStub
+	purged []string
+}
+
+func (cc *purgingChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	return cc.CCDemo.Invoke(&purgingStub{ChaincodeStubInterface: stub, cc: cc})
+}
+
+type purgingStub struct {
+	shim.ChaincodeStubInterface
+	cc *purgingChaincode
+}
+
+func (s *purgingStub) DelPrivateData(collection, key string) error {
+	delete(s.cc.mock.PvtState[collection], key)
+	return nil
+}
+
+func (s *purgingStub) PurgePrivateData(collection, key string) error {
+	delete(s.cc.mock.PvtState[collection], key)
+	s.cc.purged = append(s.cc.purged, key)
+	return nil
+}
+
+func TestPurgePrivateAsset(t *testing.T) {
+	stub := mock.NewMockStub("org2MSP", new(CCDemo))
+
+	// State setup
+	setupSecret := map[string]interface{}{
+		"@key":         "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+		"@lastTouchBy": "org2MSP",
+		"@lastTx":      "createAsset",
+		"@assetType":   "secret",
+		"secretName":   "recipe",
+		"secret":       "two eggs",
+	}
+	setupLibrary := map[string]interface{}{
+		"@key":         "library:3cab201f-9e2b-579d-b7b2-72297ed17c49",
+		"@lastTouchBy": "org3MSP",
+		"@lastTx":      "createNewLibrary",
+		"@assetType":   "library",
+		"name":         "Biblioteca Maria da Silva",
+		"entranceCode": map[string]interface{}{
+			"@assetType": "secret",
+			"@key":       "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+		},
+	}
+	setupSecretJSON, _ := json.Marshal(setupSecret)
+	setupLibraryJSON, _ := json.Marshal(setupLibrary)
+	refIndex, _ := stub.CreateCompositeKey("secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f", []string{"library:3cab201f-9e2b-579d-b7b2-72297ed17c49"})
+
+	stub.MockTransactionStart("setupPurgePrivateAsset")
+	stub.PutPrivateData("secret", "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f", setupSecretJSON)
+	stub.PutState("library:3cab201f-9e2b-579d-b7b2-72297ed17c49", setupLibraryJSON)
+	stub.PutState(refIndex, []byte{0x00})
+	stub.MockTransactionEnd("setupPurgePrivateAsset")
+
+	key := map[string]interface{}{
+		"@key": "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+	}
+
+	// A dry run reports the referrers without purging
+	reqBytes, _ := json.Marshal(map[string]interface{}{
+		"key":    key,
+		"dryRun": true,
+	})
+	res := stub.MockInvoke("purgePrivateAsset", [][]byte{
+		[]byte("purgePrivateAsset"),
+		reqBytes,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"@assetType":     "secret",
+		"@key":           "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+		"referrers":      []interface{}{"library:3cab201f-9e2b-579d-b7b2-72297ed17c49"},
+		"purgeSupported": false,
+		"dryRun":         true,
+	}
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// Referenced assets are only purged when forced
+	reqBytes, _ = json.Marshal(map[string]interface{}{
+		"key": key,
+	})
+	res = stub.MockInvoke("purgePrivateAsset2", [][]byte{
+		[]byte("purgePrivateAsset"),
+		reqBytes,
+	})
+	if res.GetStatus() != 409 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
+
+func TestPurgePrivateAssetForced(t *testing.T) {
+	cc := new(purgingChaincode)
+	stub := mock.NewMockStub("org2MSP", cc)
+	cc.mock = stub
+
+	// State setup
+	setupSecret := map[string]interface{}{
+		"@key":         "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+		"@lastTouchBy": "org2MSP",
+		"@lastTx":      "createAsset",
+		"@assetType":   "secret",
+		"secretName":   "recipe",
+		"secret":       "two eggs",
+	}
+	setupSecretJSON, _ := json.Marshal(setupSecret)
+	refIndex, _ := stub.CreateCompositeKey("secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f", []string{"library:3cab201f-9e2b-579d-b7b2-72297ed17c49"})
+
+	stub.MockTransactionStart("setupPurgePrivateAsset")
+	stub.PutPrivateData("secret", "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f", setupSecretJSON)
+	stub.PutState(refIndex, []byte{0x00})
+	stub.MockTransactionEnd("setupPurgePrivateAsset")
+
+	reqBytes, _ := json.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"@key": "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+		},
+		"force": true,
+	})
+	res := stub.MockInvoke("purgePrivateAsset", [][]byte{
+		[]byte("purgePrivateAsset"),
+		reqBytes,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if resPayload["purgeSupported"] != true || resPayload["dryRun"] != false {
+		log.Printf("%#v\n", resPayload)
+		t.FailNow()
+	}
+
+	// The asset, its history and the reference index are gone
+	if _, ok := stub.PvtState["secret"]["secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f"]; ok {
+		log.Println("asset was not deleted")
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(cc.purged, []string{"secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f"}) {
+		log.Printf("%#v\n", cc.purged)
+		t.FailNow()
+	}
+	if _, ok := stub.State[refIndex]; ok {
+		log.Println("reference index was not deleted")
+		t.FailNow()
+	}
+}
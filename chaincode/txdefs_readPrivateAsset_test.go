@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestReadPrivateAsset(t *testing.T) {
+	stub := mock.NewMockStub("org2MSP", new(CCDemo))
+
+	// State setup
+	setupSecret := map[string]interface{}{
+		"@key":         "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+		"@lastTouchBy": "org2MSP",
+		"@lastTx":      "createAsset",
+		"@assetType":   "secret",
+		"secretName":   "recipe",
+		"secret":       "two eggs",
+	}
+	setupSecretJSON, _ := json.Marshal(setupSecret)
+
+	stub.MockTransactionStart("setupReadPrivateAsset")
+	stub.PutPrivateData("secret", "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f", setupSecretJSON)
+	stub.MockTransactionEnd("setupReadPrivateAsset")
+
+	req := map[string]interface{}{
+		"key": map[string]interface{}{
+			"@key": "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+		},
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("readPrivateAsset", [][]byte{
+		[]byte("readPrivateAsset"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	hash := sha256.Sum256(setupSecretJSON)
+	expectedResponse := map[string]interface{}{
+		"@key":         "secret:2b7c4f1e-6a3d-5c8b-9e0f-3d4c5b6a7e8f",
+		"@lastTouchBy": "org2MSP",
+		"@lastTx":      "createAsset",
+		"@assetType":   "secret",
+		"@hash":        hex.EncodeToString(hash[:]),
+		"secretName":   "recipe",
+		"secret":       "two eggs",
+	}
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// Public assets are read with readAsset
+	publicReq, _ := json.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"@assetType": "person",
+			"id":         "318.207.920-48",
+		},
+	})
+	res = stub.MockInvoke("readPrivateAsset2", [][]byte{
+		[]byte("readPrivateAsset"),
+		publicReq,
+	})
+	if res.GetStatus() != 400 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
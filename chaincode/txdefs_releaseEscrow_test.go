@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestReleaseEscrow(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	// State setup
+	setupCollectible := map[string]interface{}{
+		"@key":         "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "lockEscrow",
+		"@assetType":   "collectible",
+		"id":           "first-edition-001",
+		"name":         "First Edition",
+		"owner":        "org1MSP",
+		"escrow": map[string]interface{}{
+			"@assetType": "escrow",
+			"@key":       "escrow:8c2e4b1a-3d5f-5e6a-9b7c-1d2e3f4a5b6c",
+		},
+	}
+	setupCollectibleJSON, _ := json.Marshal(setupCollectible)
+	setupEscrow := map[string]interface{}{
+		"@key":         "escrow:8c2e4b1a-3d5f-5e6a-9b7c-1d2e3f4a5b6c",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "lockEscrow",
+		"@assetType":   "escrow",
+		"id":           "sale-001",
+		"collectible": map[string]interface{}{
+			"@assetType": "collectible",
+			"@key":       "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		},
+		"seller":    "org1MSP",
+		"buyer":     "org2MSP",
+		"expiresAt": "2021-06-01T00:00:00Z",
+		"status":    "locked",
+	}
+	setupEscrowJSON, _ := json.Marshal(setupEscrow)
+
+	stub.MockTransactionStart("setupReleaseEscrow")
+	stub.PutState("collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f", setupCollectibleJSON)
+	stub.PutState("escrow:8c2e4b1a-3d5f-5e6a-9b7c-1d2e3f4a5b6c", setupEscrowJSON)
+	stub.MockTransactionEnd("setupReleaseEscrow")
+
+	req := map[string]interface{}{
+		"escrow": map[string]interface{}{
+			"@key": "escrow:8c2e4b1a-3d5f-5e6a-9b7c-1d2e3f4a5b6c",
+		},
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	// The release of an expired escrow is accepted by the time of the
+	// transaction
+	expiredReqBytes, _ := json.Marshal(map[string]interface{}{
+		"escrow":  req["escrow"],
+		"expired": true,
+	})
+
+	// The expired escrow can no longer be confirmed by the buyer
+	buyerStub := mock.NewMockStub("org2MSP", new(CCDemo))
+	buyerStub.State = stub.State
+
+	res := buyerStub.MockInvoke("confirmEscrow", [][]byte{
+		[]byte("confirmEscrow"),
+		reqBytes,
+	})
+	if res.GetStatus() != 409 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// But can be released by anyone
+	res = stub.MockInvoke("releaseEscrow", [][]byte{
+		[]byte("releaseEscrow"),
+		expiredReqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"@key":         "escrow:8c2e4b1a-3d5f-5e6a-9b7c-1d2e3f4a5b6c",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "releaseEscrow",
+		"@assetType":   "escrow",
+		"id":           "sale-001",
+		"collectible": map[string]interface{}{
+			"@assetType": "collectible",
+			"@key":       "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		},
+		"seller":    "org1MSP",
+		"buyer":     "org2MSP",
+		"expiresAt": "2021-06-01T00:00:00Z",
+		"status":    "released",
+	}
+
+	expectedResponse["@lastUpdated"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+	expectedResponse["settledAt"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// The seller can transfer the unlocked collectible again
+	transferReq, _ := json.Marshal(map[string]interface{}{
+		"collectible": map[string]interface{}{
+			"@key": "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		},
+		"newOwner": "org3MSP",
+	})
+	res = stub.MockInvoke("transferOwnership", [][]byte{
+		[]byte("transferOwnership"),
+		transferReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Escrows that did not expire by the time of the transaction are not
+	// released as expired, not even by the buyer
+	setupEscrow["@key"] = "escrow:2d4f6a8c-1b3e-5c7d-9e0f-a1b2c3d4e5f6"
+	setupEscrow["id"] = "sale-002"
+	setupEscrow["expiresAt"] = "2999-01-01T00:00:00Z"
+	setupEscrowJSON, _ = json.Marshal(setupEscrow)
+
+	stub.MockTransactionStart("setupReleaseEscrow2")
+	stub.PutState("escrow:2d4f6a8c-1b3e-5c7d-9e0f-a1b2c3d4e5f6", setupEscrowJSON)
+	stub.MockTransactionEnd("setupReleaseEscrow2")
+
+	pendingReqBytes, _ := json.Marshal(map[string]interface{}{
+		"escrow": map[string]interface{}{
+			"@key": "escrow:2d4f6a8c-1b3e-5c7d-9e0f-a1b2c3d4e5f6",
+		},
+		"expired": true,
+	})
+	res = buyerStub.MockInvoke("releaseEscrow2", [][]byte{
+		[]byte("releaseEscrow"),
+		pendingReqBytes,
+	})
+	if res.GetStatus() != 409 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestReserveBook(t *testing.T) {
+	stub := mock.NewMockStub("org2MSP", new(CCDemo))
+	setupLoanState(stub)
+
+	// Two more people wait for the book
+	people := []string{
+		"person:47061146-c642-51a1-844a-bf0b17cb5e19",
+		"person:9a7e4a6e-2c2d-5a3b-8e0f-5c1d7b3f9e21",
+		"person:c3b1f2d4-6e5a-5b7c-9d8e-0f1a2b3c4d5e",
+	}
+	stub.MockTransactionStart("setupReservations")
+	for i, key := range people[1:] {
+		personJSON, _ := json.Marshal(map[string]interface{}{
+			"@key":       key,
+			"@assetType": "person",
+			"name":       []string{"Ana", "João"}[i],
+			"id":         []string{"52998224725", "11144477735"}[i],
+		})
+		stub.PutState(key, personJSON)
+	}
+	stub.MockTransactionEnd("setupReservations")
+
+	book := map[string]interface{}{
+		"@key": "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+	}
+	invoke := func(txName string, req map[string]interface{}) (int32, map[string]interface{}) {
+		reqBytes, _ := json.Marshal(req)
+		res := stub.MockInvoke(txName, [][]byte{
+			[]byte(txName),
+			reqBytes,
+		})
+		var payload map[string]interface{}
+		json.Unmarshal(res.GetPayload(), &payload)
+		if res.GetStatus() != 200 {
+			log.Println(res.GetMessage())
+		}
+		return res.GetStatus(), payload
+	}
+	reserve := func(person string) int32 {
+		status, _ := invoke("reserveBook", map[string]interface{}{
+			"book":   book,
+			"person": map[string]interface{}{"@key": person},
+		})
+		return status
+	}
+	borrow := func(id, person string) int32 {
+		status, _ := invoke("borrowBook", map[string]interface{}{
+			"id":       id,
+			"book":     book,
+			"borrower": map[string]interface{}{"@key": person},
+			"dueDate":  time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		})
+		return status
+	}
+
+	// An available book cannot be reserved
+	if status := reserve(people[1]); status != 409 {
+		t.FailNow()
+	}
+
+	if status := borrow("loan-001", people[0]); status != 200 {
+		t.FailNow()
+	}
+
+	// The borrower cannot reserve the book, and nobody reserves it twice
+	if status := reserve(people[0]); status != 409 {
+		t.FailNow()
+	}
+	if status := reserve(people[1]); status != 200 {
+		t.FailNow()
+	}
+	if status := reserve(people[2]); status != 200 {
+		t.FailNow()
+	}
+	if status := reserve(people[1]); status != 409 {
+		t.FailNow()
+	}
+
+	status, queue := invoke("listReservations", map[string]interface{}{"book": book})
+	if status != 200 || len(queue["holds"].([]interface{})) != 0 {
+		log.Printf("%#v\n", queue)
+		t.FailNow()
+	}
+	positions := make([]interface{}, 0)
+	for _, r := range queue["queue"].([]interface{}) {
+		reservation := r.(map[string]interface{})
+		positions = append(positions, reservation["person"].(map[string]interface{})["@key"], reservation["position"])
+	}
+	if !reflect.DeepEqual(positions, []interface{}{people[1], 1.0, people[2], 2.0}) {
+		log.Printf("%#v\n", positions)
+		t.FailNow()
+	}
+
+	// On return, the book is held for the first in the queue
+	status, loan := invoke("returnBook", map[string]interface{}{
+		"loan": map[string]interface{}{"@assetType": "loan", "id": "loan-001"},
+	})
+	if status != 200 {
+		t.FailNow()
+	}
+	hold, _ := loan["hold"].(map[string]interface{})
+	if hold == nil || hold["person"].(map[string]interface{})["@key"] != people[1] {
+		log.Printf("%#v\n", loan)
+		t.FailNow()
+	}
+
+	// Only the person the book is held for can borrow it
+	if status := borrow("loan-002", people[2]); status != 409 {
+		t.FailNow()
+	}
+	if status := borrow("loan-002", people[1]); status != 200 {
+		t.FailNow()
+	}
+
+	status, queue = invoke("listReservations", map[string]interface{}{"book": book})
+	if status != 200 || len(queue["holds"].([]interface{})) != 0 || len(queue["queue"].([]interface{})) != 1 {
+		log.Printf("%#v\n", queue)
+		t.FailNow()
+	}
+
+	// Cancelled reservations leave the queue
+	status, _ = invoke("cancelReservation", map[string]interface{}{
+		"book":   book,
+		"person": map[string]interface{}{"@key": people[2]},
+	})
+	if status != 200 {
+		t.FailNow()
+	}
+	status, _ = invoke("cancelReservation", map[string]interface{}{
+		"book":   book,
+		"person": map[string]interface{}{"@key": people[2]},
+	})
+	if status != 404 {
+		t.FailNow()
+	}
+}
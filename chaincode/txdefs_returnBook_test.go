@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestReturnBook(t *testing.T) {
+	stub := mock.NewMockStub("org2MSP", new(CCDemo))
+	setupLoanState(stub)
+
+	borrowReq, _ := json.Marshal(map[string]interface{}{
+		"id": "loan-001",
+		"book": map[string]interface{}{
+			"@key": "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+		},
+		"borrower": map[string]interface{}{
+			"@key": "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+		},
+		"dueDate": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+	res := stub.MockInvoke("borrowBook", [][]byte{
+		[]byte("borrowBook"),
+		borrowReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+	var loan map[string]interface{}
+	json.Unmarshal(res.GetPayload(), &loan)
+
+	req := map[string]interface{}{
+		"loan": map[string]interface{}{
+			"@key": loan["@key"],
+		},
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res = stub.MockInvoke("returnBook", [][]byte{
+		[]byte("returnBook"),
+		reqBytes,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if resPayload["status"] != "returned" || resPayload["returnedAt"] != stub.TxTimestamp.AsTime().Format(time.RFC3339) {
+		log.Printf("%#v\n", resPayload)
+		t.FailNow()
+	}
+
+	// The book has no tenant and can be lent again
+	var book map[string]interface{}
+	bookJSON, _ := stub.GetState("book:a36a2920-c405-51c3-b584-dcd758338cb5")
+	json.Unmarshal(bookJSON, &book)
+	if book["currentTenant"] != nil || book["currentLoan"] != nil {
+		log.Printf("%#v\n", book)
+		t.FailNow()
+	}
+
+	// A loan is returned only once
+	res = stub.MockInvoke("returnBook", [][]byte{
+		[]byte("returnBook"),
+		reqBytes,
+	})
+	if res.GetStatus() != 409 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestSetEndorsementPolicy(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	// State setup
+	setupCollectible := map[string]interface{}{
+		"@key":         "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "mintCollectible",
+		"@assetType":   "collectible",
+		"id":           "first-edition-001",
+		"name":         "First Edition",
+		"owner":        "org1MSP",
+	}
+	setupCollectibleJSON, _ := json.Marshal(setupCollectible)
+
+	stub.MockTransactionStart("setupSetEndorsementPolicy")
+	stub.PutState("collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f", setupCollectibleJSON)
+	stub.MockTransactionEnd("setupSetEndorsementPolicy")
+
+	req := map[string]interface{}{
+		"key": map[string]interface{}{
+			"@key": "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		},
+		"orgs": []string{"org3MSP", "org1MSP", "org3MSP"},
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("setEndorsementPolicy", [][]byte{
+		[]byte("setEndorsementPolicy"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"@key": "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		"orgs": []interface{}{"org1MSP", "org3MSP"},
+	}
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// Only the owner can change the endorsement of the collectible
+	otherStub := mock.NewMockStub("org2MSP", new(CCDemo))
+	otherStub.State = stub.State
+	otherStub.EndorsementPolicies = stub.EndorsementPolicies
+
+	res = otherStub.MockInvoke("setEndorsementPolicy2", [][]byte{
+		[]byte("setEndorsementPolicy"),
+		reqBytes,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Transferring the collectible transfers its endorsement
+	transferReq, _ := json.Marshal(map[string]interface{}{
+		"collectible": req["key"],
+		"newOwner":    "org2MSP",
+	})
+	res = stub.MockInvoke("transferOwnership", [][]byte{
+		[]byte("transferOwnership"),
+		transferReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	getReq, _ := json.Marshal(map[string]interface{}{
+		"key": req["key"],
+	})
+	res = stub.MockInvoke("getEndorsementPolicy", [][]byte{
+		[]byte("getEndorsementPolicy"),
+		getReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	err = json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse["orgs"] = []interface{}{"org2MSP", "org3MSP"}
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// Assets without an owner have their endorsement set by the
+	// organization that last wrote them
+	setupBook := map[string]interface{}{
+		"@key":         "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "createAsset",
+		"@assetType":   "book",
+		"title":        "Meu Nome é Maria",
+		"author":       "Maria Viana",
+	}
+	setupBookJSON, _ := json.Marshal(setupBook)
+
+	stub.MockTransactionStart("setupBook")
+	stub.PutState("book:a36a2920-c405-51c3-b584-dcd758338cb5", setupBookJSON)
+	stub.MockTransactionEnd("setupBook")
+
+	bookReq, _ := json.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"@key": "book:a36a2920-c405-51c3-b584-dcd758338cb5",
+		},
+		"orgs": []string{"org2MSP"},
+	})
+	res = otherStub.MockInvoke("setBookEndorsementPolicy", [][]byte{
+		[]byte("setEndorsementPolicy"),
+		bookReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("setBookEndorsementPolicy2", [][]byte{
+		[]byte("setEndorsementPolicy"),
+		bookReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestSubmitProposal(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	req := map[string]interface{}{
+		"title":       "Add new genre",
+		"description": "Add poetry to the genres of the library",
+		"approver":    "org2MSP",
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("submitProposal", [][]byte{
+		[]byte("submitProposal"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"@key":         resPayload["@key"],
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "submitProposal",
+		"@assetType":   "proposal",
+		"id":           "submitProposal",
+		"title":        "Add new genre",
+		"description":  "Add poetry to the genres of the library",
+		"proposer":     "org1MSP",
+		"approver":     "org2MSP",
+		"status":       "pending",
+	}
+
+	expectedResponse["@lastUpdated"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	var state map[string]interface{}
+	stateBytes := stub.State[resPayload["@key"].(string)]
+	err = json.Unmarshal(stateBytes, &state)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	if !reflect.DeepEqual(state, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", state)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// The proposer cannot be the approver
+	req["approver"] = "org1MSP"
+	reqBytes, _ = json.Marshal(req)
+
+	res = stub.MockInvoke("submitProposal2", [][]byte{
+		[]byte("submitProposal"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 400 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestTransferOwnership(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	// State setup
+	setupCollectible := map[string]interface{}{
+		"@key":         "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "mintCollectible",
+		"@assetType":   "collectible",
+		"id":           "first-edition-001",
+		"name":         "First Edition",
+		"owner":        "org1MSP",
+	}
+	setupCollectibleJSON, _ := json.Marshal(setupCollectible)
+
+	stub.MockTransactionStart("setupTransferOwnership")
+	stub.PutState("collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f", setupCollectibleJSON)
+	stub.MockTransactionEnd("setupTransferOwnership")
+
+	req := map[string]interface{}{
+		"collectible": map[string]interface{}{
+			"@key": "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		},
+		"newOwner": "org2MSP",
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("transferOwnership", [][]byte{
+		[]byte("transferOwnership"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"@key":         "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+		"@lastTouchBy": "org1MSP",
+		"@lastTx":      "transferOwnership",
+		"@assetType":   "collectible",
+		"id":           "first-edition-001",
+		"name":         "First Edition",
+		"owner":        "org2MSP",
+	}
+
+	expectedResponse["@lastUpdated"] = stub.TxTimestamp.AsTime().Format(time.RFC3339)
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// The previous owner can no longer update nor transfer the collectible
+	updateReq, _ := json.Marshal(map[string]interface{}{
+		"collectible": req["collectible"],
+		"name":        "Stolen Edition",
+	})
+	res = stub.MockInvoke("updateCollectible", [][]byte{
+		[]byte("updateCollectible"),
+		updateReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("transferOwnership2", [][]byte{
+		[]byte("transferOwnership"),
+		reqBytes,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// Nor through the generic update transaction
+	genericReq, _ := json.Marshal(map[string]interface{}{
+		"update": map[string]interface{}{
+			"@assetType": "collectible",
+			"@key":       "collectible:1f1d2c5e-8e3b-5d7a-9c4f-0a6b3e2d1c9f",
+			"owner":      "org1MSP",
+		},
+	})
+	res = stub.MockInvoke("updateAsset", [][]byte{
+		[]byte("updateAsset"),
+		genericReq,
+	})
+	if res.GetStatus() != 403 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	// The new owner can
+	newOwnerStub := mock.NewMockStub("org2MSP", new(CCDemo))
+	newOwnerStub.State = stub.State
+
+	res = newOwnerStub.MockInvoke("updateCollectible2", [][]byte{
+		[]byte("updateCollectible"),
+		updateReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestTransfer(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	mintReq, _ := json.Marshal(map[string]interface{}{
+		"symbol": "GLD",
+		"amount": 100,
+	})
+	res := stub.MockInvoke("mint", [][]byte{
+		[]byte("mint"),
+		mintReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	req := map[string]interface{}{
+		"symbol": "GLD",
+		"to":     "org2MSP",
+		"amount": 30,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res = stub.MockInvoke("transfer", [][]byte{
+		[]byte("transfer"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := map[string]interface{}{
+		"symbol": "GLD",
+		"from":   "org1MSP",
+		"to":     "org2MSP",
+		"amount": 30.0,
+		"balances": map[string]interface{}{
+			"org1MSP": 70.0,
+			"org2MSP": 30.0,
+		},
+	}
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// Balances can be read by any account
+	balanceReq, _ := json.Marshal(map[string]interface{}{
+		"symbol":  "GLD",
+		"account": "org2MSP",
+	})
+	res = stub.MockInvoke("balanceOf", [][]byte{
+		[]byte("balanceOf"),
+		balanceReq,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var balance map[string]interface{}
+	json.Unmarshal(res.GetPayload(), &balance)
+	if balance["balance"] != 30.0 {
+		log.Printf("unexpected balance %#v\n", balance)
+		t.FailNow()
+	}
+
+	// Transfers cannot exceed the balance
+	req["amount"] = 71
+	reqBytes, _ = json.Marshal(req)
+
+	res = stub.MockInvoke("transfer2", [][]byte{
+		[]byte("transfer"),
+		reqBytes,
+	})
+
+	if res.GetStatus() != 400 {
+		log.Println(res)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func TestValidateValues(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(CCDemo))
+
+	req := map[string]interface{}{
+		"values": []map[string]interface{}{
+			{"dataType": "cpf", "value": "318.207.920-48"},
+			{"dataType": "cpf", "value": "318.207.920-49"},
+			{"dataType": "cnpj", "value": "11.222.333/0001-81"},
+			{"dataType": "cnpj", "value": "1122233300018"},
+			{"dataType": "decimal", "value": "0012.50"},
+			{"dataType": "money", "value": map[string]interface{}{"amount": 12.5, "currency": "brl"}},
+			{"dataType": "money", "value": "-3 JPY"},
+			{"dataType": "money", "value": "0.001 USD"},
+			{"dataType": "money", "value": "10 XYZ"},
+			{"dataType": "geopoint", "value": "-16.68, -49.25"},
+			{"dataType": "geopoint", "value": map[string]interface{}{"lat": 91, "long": 0}},
+			{"dataType": "geopoint", "value": "NaN, 0"},
+			{"dataType": "geopoint", "value": map[string]interface{}{"lat": 0, "long": "-Inf"}},
+		},
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	res := stub.MockInvoke("validateValues", [][]byte{
+		[]byte("validateValues"),
+		reqBytes,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res)
+		t.FailNow()
+	}
+
+	var resPayload []interface{}
+	err := json.Unmarshal(res.GetPayload(), &resPayload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedResponse := []interface{}{
+		map[string]interface{}{"dataType": "cpf", "valid": true, "value": "31820792048"},
+		map[string]interface{}{"dataType": "cpf", "valid": false, "error": "Invalid CPF"},
+		map[string]interface{}{"dataType": "cnpj", "valid": true, "value": "11222333000181"},
+		map[string]interface{}{"dataType": "cnpj", "valid": false, "error": "CNPJ must have 14 digits"},
+		map[string]interface{}{"dataType": "decimal", "valid": true, "value": "12.5"},
+		map[string]interface{}{"dataType": "money", "valid": true, "value": map[string]interface{}{"amount": "12.50", "currency": "BRL"}},
+		map[string]interface{}{"dataType": "money", "valid": true, "value": map[string]interface{}{"amount": "-3", "currency": "JPY"}},
+		map[string]interface{}{"dataType": "money", "valid": false, "error": "USD amounts have at most 2 decimal places"},
+		map[string]interface{}{"dataType": "money", "valid": false, "error": "unsupported currency 'XYZ'"},
+		map[string]interface{}{"dataType": "geopoint", "valid": true, "value": map[string]interface{}{"lat": -16.68, "long": -49.25}},
+		map[string]interface{}{"dataType": "geopoint", "valid": false, "error": "lat must be between -90 and 90"},
+		map[string]interface{}{"dataType": "geopoint", "valid": false, "error": "lat must be a number"},
+		map[string]interface{}{"dataType": "geopoint", "valid": false, "error": "long must be a number"},
+	}
+
+	if !reflect.DeepEqual(resPayload, expectedResponse) {
+		log.Println("these should be equal")
+		log.Printf("%#v\n", resPayload)
+		log.Printf("%#v\n", expectedResponse)
+		t.FailNow()
+	}
+
+	// Unknown data types are rejected
+	reqBytes, _ = json.Marshal(map[string]interface{}{
+		"values": []map[string]interface{}{{"dataType": "rg", "value": "123"}},
+	})
+	res = stub.MockInvoke("validateValues", [][]byte{
+		[]byte("validateValues"),
+		reqBytes,
+	})
+	if res.GetStatus() != 400 {
+		log.Println(res)
+		t.FailNow()
+	}
+}